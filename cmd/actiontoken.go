@@ -0,0 +1,103 @@
+/*
+Copyright © 2024 Pavlo Tarasiuk <pasha.tarasyuk@gmail.com>
+*/
+package cmd
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// actionKeyName is the key of the generated signing key in collectionTokens
+const actionKeyName = "action_signing"
+
+// errInvalidActionToken is returned for action tokens that weren't signed by the bot or were altered
+var errInvalidActionToken = errors.New("invalid action token")
+
+// actionSigner encodes the context interactions need into signed tokens carried by the blocks
+// themselves, e.g. in a block_id, so handling them doesn't depend on the bot's memory and
+// survives restarts
+type actionSigner struct {
+	key []byte
+}
+
+// newActionSigner creates a signer with a random key, its tokens only verify until the bot stops
+func newActionSigner() *actionSigner {
+	key := make([]byte, 32)
+	if _, err := rand.Read(key); err != nil {
+		panic(fmt.Sprintf("failed to generate action signing key: %v", err))
+	}
+	return &actionSigner{key: key}
+}
+
+// loadActionSigner creates a signer with the configured secret, or else with a key generated
+// once and kept in the store, so tokens issued before a restart still verify
+func loadActionSigner(store Store, secret string) (*actionSigner, error) {
+	if secret != "" {
+		return &actionSigner{key: []byte(secret)}, nil
+	}
+	var key []byte
+	found, err := store.Get(collectionTokens, actionKeyName, &key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load action signing key: %w", err)
+	}
+	if found && len(key) > 0 {
+		return &actionSigner{key: key}, nil
+	}
+	signer := newActionSigner()
+	if err := store.Put(collectionTokens, actionKeyName, signer.key); err != nil {
+		return nil, fmt.Errorf("failed to save action signing key: %w", err)
+	}
+	return signer, nil
+}
+
+// sign returns the signature of payload
+func (s *actionSigner) sign(payload []byte) []byte {
+	mac := hmac.New(sha256.New, s.key)
+	mac.Write(payload)
+	// Block IDs are limited to 255 characters, half the MAC is plenty to detect tampering
+	return mac.Sum(nil)[:16]
+}
+
+// encode turns v into a token of its JSON and the signature, both base64 encoded
+func (s *actionSigner) encode(v interface{}) (string, error) {
+	payload, err := json.Marshal(v)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode action token: %w", err)
+	}
+	enc := base64.RawURLEncoding
+	return enc.EncodeToString(payload) + "." + enc.EncodeToString(s.sign(payload)), nil
+}
+
+// decode verifies the token and decodes its payload into v, failing with errInvalidActionToken
+// when the token was altered or signed with another key
+func (s *actionSigner) decode(token string, v interface{}) error {
+	encodedPayload, encodedSignature, ok := strings.Cut(token, ".")
+	if !ok {
+		return errInvalidActionToken
+	}
+	enc := base64.RawURLEncoding
+	payload, err := enc.DecodeString(encodedPayload)
+	if err != nil {
+		return errInvalidActionToken
+	}
+	signature, err := enc.DecodeString(encodedSignature)
+	if err != nil || !hmac.Equal(signature, s.sign(payload)) {
+		return errInvalidActionToken
+	}
+	if err := json.Unmarshal(payload, v); err != nil {
+		return errInvalidActionToken
+	}
+	return nil
+}
+
+// isActionToken tells tokens from the IDs Slack generates for blocks without one
+func isActionToken(s string) bool {
+	return strings.Contains(s, ".")
+}
@@ -0,0 +1,147 @@
+/*
+Copyright © 2024 Pavlo Tarasiuk <pasha.tarasyuk@gmail.com>
+*/
+package cmd
+
+import (
+	"encoding/base64"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/slack-go/slack"
+)
+
+func TestActionTokenRoundTrip(t *testing.T) {
+	signer := newActionSigner()
+	want := surveyContext{ID: "20240405T120000.000000000-U1", Requester: "U1", ArticleTS: "1712345678.000100"}
+
+	token, err := signer.encode(want)
+	if err != nil {
+		t.Fatalf("encode() error = %v", err)
+	}
+	if !isActionToken(token) {
+		t.Errorf("isActionToken(%q) = false", token)
+	}
+	if len(token) > 255 {
+		t.Errorf("token is %d characters, over the block_id limit", len(token))
+	}
+	var got surveyContext
+	if err := signer.decode(token, &got); err != nil {
+		t.Fatalf("decode() error = %v", err)
+	}
+	if got != want {
+		t.Errorf("decode() = %+v, want %+v", got, want)
+	}
+}
+
+func TestActionTokenTampering(t *testing.T) {
+	signer := newActionSigner()
+	token, err := signer.encode(surveyContext{ID: "S1", Requester: "U1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	payload, signature, _ := strings.Cut(token, ".")
+	forged := base64.RawURLEncoding.EncodeToString([]byte(`{"ID":"S1","Requester":"U0ADMIN"}`))
+	otherKey, err := newActionSigner().encode(surveyContext{ID: "S1", Requester: "U1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name  string
+		token string
+	}{
+		{"altered payload", forged + "." + signature},
+		{"altered signature", payload + "." + strings.Repeat("A", len(signature))},
+		{"missing signature", payload},
+		{"not base64", "!!!." + signature},
+		{"signed with another key", otherKey},
+		{"generated block ID", "a1B2c"},
+		{"empty", ""},
+	}
+	for _, tt := range tests {
+		var got surveyContext
+		if err := signer.decode(tt.token, &got); !errors.Is(err, errInvalidActionToken) {
+			t.Errorf("%s: decode() error = %v, want errInvalidActionToken", tt.name, err)
+		}
+	}
+}
+
+func TestActionSignerSurvivesRestarts(t *testing.T) {
+	store, err := newFileStore(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	before, err := loadActionSigner(store, "")
+	if err != nil {
+		t.Fatalf("loadActionSigner() error = %v", err)
+	}
+	token, err := before.encode(surveyContext{ID: "S1", Requester: "U1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The bot restarts with the same store
+	after, err := loadActionSigner(store, "")
+	if err != nil {
+		t.Fatalf("loadActionSigner() after the restart error = %v", err)
+	}
+	var survey surveyContext
+	if err := after.decode(token, &survey); err != nil || survey.Requester != "U1" {
+		t.Errorf("decode() after the restart = %+v, %v, want the survey of U1", survey, err)
+	}
+}
+
+func TestActionSignerWithSecret(t *testing.T) {
+	one, err := loadActionSigner(nil, "s3cret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	two, _ := loadActionSigner(nil, "s3cret")
+	token, err := one.encode(surveyContext{ID: "S1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var survey surveyContext
+	if err := two.decode(token, &survey); err != nil {
+		t.Errorf("a token of the same secret didn't verify: %v", err)
+	}
+	other, _ := loadActionSigner(nil, "another")
+	if err := other.decode(token, &survey); !errors.Is(err, errInvalidActionToken) {
+		t.Errorf("a token of another secret verified")
+	}
+}
+
+func TestSurveyResponseCarriesItsToken(t *testing.T) {
+	b, _ := newTestBot(t, nil)
+	survey := surveyContext{ID: "S1", Requester: "U9", ArticleTS: "1712345678.000100"}
+	token, err := b.signer.encode(survey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	answer := func(blockID string) error {
+		interaction := slack.InteractionCallback{User: slack.User{ID: "U1"}}
+		interaction.Container.ChannelID = "C1"
+		return b.recordSurveyResponse(interaction, &slack.BlockAction{
+			BlockID:         blockID,
+			SelectedOptions: []slack.OptionBlockObject{{Value: "yes"}},
+		})
+	}
+
+	if err := answer(token); err != nil {
+		t.Fatalf("recordSurveyResponse() error = %v", err)
+	}
+	responses, err := b.recentSurveyResponses(10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(responses) != 1 || responses[0].Survey != "S1" || responses[0].Requester != "U9" || responses[0].ArticleTS != survey.ArticleTS {
+		t.Errorf("recorded %+v, want the context of the token", responses)
+	}
+
+	payload, _, _ := strings.Cut(token, ".")
+	if err := answer(payload + ".AAAAAAAAAAAAAAAAAAAAAA"); !errors.Is(err, errInvalidActionToken) {
+		t.Errorf("recordSurveyResponse() of a tampered token error = %v, want errInvalidActionToken", err)
+	}
+}
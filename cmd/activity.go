@@ -0,0 +1,247 @@
+/*
+Copyright © 2024 Pavlo Tarasiuk <pasha.tarasyuk@gmail.com>
+*/
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/slack-go/slack"
+)
+
+// collectionActivity holds the daily activity totals /activity reports on, keyed by date
+const collectionActivity = "activity"
+
+// activityDayFormat is the layout of the keys in collectionActivity
+const activityDayFormat = "2006-01-02"
+
+// activityRetention is how many days of activity are kept, enough for the longest report
+const activityRetention = 30
+
+// activityTop is how many commands and channels the report ranks
+const activityTop = 5
+
+// activityDay is the activity of a single day in the configured timezone
+type activityDay struct {
+	Events   int            `json:"events"`
+	Errors   int            `json:"errors"`
+	Commands map[string]int `json:"commands,omitempty"`
+	Channels map[string]int `json:"channels,omitempty"`
+}
+
+// add counts another day's activity into d
+func (d *activityDay) add(other activityDay) {
+	d.Events += other.Events
+	d.Errors += other.Errors
+	for name, n := range other.Commands {
+		d.Commands[name] += n
+	}
+	for id, n := range other.Channels {
+		d.Channels[id] += n
+	}
+}
+
+// activityLog keeps the daily activity totals in the store
+type activityLog struct {
+	// mu serializes the read-modify-write of a day's totals
+	mu    sync.Mutex
+	store Store
+}
+
+// recordActivity counts a processed event into the totals of its day in the configured timezone
+func (b *Bot) recordActivity(summary eventSummary) error {
+	local := b.localTime(summary.Time)
+	oldest := local.AddDate(0, 0, -activityRetention+1).Format(activityDayFormat)
+	return b.activity.record(local.Format(activityDayFormat), oldest, summary)
+}
+
+// record counts the event into the totals of the day with the key and deletes the days before oldest
+func (l *activityLog) record(key, oldest string, summary eventSummary) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	day := activityDay{}
+	if _, err := l.store.Get(collectionActivity, key, &day); err != nil {
+		return fmt.Errorf("failed to record activity: %w", err)
+	}
+	// Either map may have been left out of the stored day for being empty
+	if day.Commands == nil {
+		day.Commands = map[string]int{}
+	}
+	if day.Channels == nil {
+		day.Channels = map[string]int{}
+	}
+	day.Events++
+	if summary.Outcome == outcomeError {
+		day.Errors++
+	}
+	if summary.Command != "" {
+		day.Commands[summary.Command]++
+	}
+	if summary.Channel != "" {
+		day.Channels[summary.Channel]++
+	}
+	if err := l.store.Put(collectionActivity, key, day); err != nil {
+		return fmt.Errorf("failed to record activity: %w", err)
+	}
+
+	keys, err := l.store.Keys(collectionActivity)
+	if err != nil {
+		return err
+	}
+	// Dates sort chronologically, so the days out of the retention come first
+	for _, expired := range keys {
+		if expired >= oldest {
+			break
+		}
+		if err := l.store.Delete(collectionActivity, expired); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// parseActivityWindow turns "today", "7d" or "30d" into the number of days it covers
+func parseActivityWindow(s string) (int, bool) {
+	switch s {
+	case "", "today":
+		return 1, true
+	case "7d":
+		return 7, true
+	case "30d":
+		return 30, true
+	}
+	return 0, false
+}
+
+// activityTotals sums the activity of the days up to the day of last, that day included
+func (b *Bot) activityTotals(last time.Time, days int) (activityDay, error) {
+	totals := activityDay{Commands: map[string]int{}, Channels: map[string]int{}}
+	end := b.localTime(last)
+	for i := 0; i < days; i++ {
+		var day activityDay
+		key := end.AddDate(0, 0, -i).Format(activityDayFormat)
+		if _, err := b.store.Get(collectionActivity, key, &day); err != nil {
+			return activityDay{}, err
+		}
+		totals.add(day)
+	}
+	return totals, nil
+}
+
+// topCounts returns the n keys with the highest counts, ties by key
+func topCounts(counts map[string]int, n int) []string {
+	keys := make([]string, 0, len(counts))
+	for key := range counts {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if counts[keys[i]] != counts[keys[j]] {
+			return counts[keys[i]] > counts[keys[j]]
+		}
+		return keys[i] < keys[j]
+	})
+	if len(keys) > n {
+		keys = keys[:n]
+	}
+	return keys
+}
+
+// formatActivity renders the totals of the window as a report
+func formatActivity(window string, totals activityDay) string {
+	var report strings.Builder
+	fmt.Fprintf(&report, "*MAVBot activity (%s)*\n", window)
+	fmt.Fprintf(&report, "Events processed: %d\n", totals.Events)
+	rate := 0.0
+	if totals.Events > 0 {
+		rate = float64(totals.Errors) / float64(totals.Events) * 100
+	}
+	fmt.Fprintf(&report, "Errors: %d (%.1f%%)\n", totals.Errors, rate)
+
+	report.WriteString("*Top commands*\n")
+	commands := topCounts(totals.Commands, activityTop)
+	if len(commands) == 0 {
+		report.WriteString("none\n")
+	}
+	for _, name := range commands {
+		fmt.Fprintf(&report, "%s: %d\n", name, totals.Commands[name])
+	}
+	report.WriteString("*Top channels*\n")
+	channels := topCounts(totals.Channels, activityTop)
+	if len(channels) == 0 {
+		report.WriteString("none\n")
+	}
+	for _, id := range channels {
+		fmt.Fprintf(&report, "<#%s>: %d\n", id, totals.Channels[id])
+	}
+	return report.String()
+}
+
+// activityFlags are the flags of /activity
+var activityFlags = []commandFlag{{Name: "--file"}}
+
+// handleActivity reports the bot's activity: /activity [--file] [today|7d|30d]
+func (b *Bot) handleActivity(command slack.SlashCommand) (*SlashResponse, error) {
+	usage := usageOf("/activity")
+	flags, rest, err := parseFlags(command.Text, activityFlags)
+	if err != nil {
+		return ephemeral(usage), nil
+	}
+	window, asFile := rest, flags["--file"]
+	days, ok := parseActivityWindow(window)
+	if !ok {
+		return ephemeral(usage), nil
+	}
+	if window == "" {
+		window = "today"
+	}
+
+	totals, err := b.activityTotals(b.now(), days)
+	if err != nil {
+		return nil, err
+	}
+	report := formatActivity(window, totals)
+	if !asFile {
+		return ephemeral(report), nil
+	}
+	// The report is for the admin only, so it goes to their DM rather than the channel
+	channel, err := b.openDM(command.UserID)
+	if errors.Is(err, errDMUnavailable) {
+		return ephemeral("I couldn't send you a DM, please check that direct messages from MAVBot are enabled"), nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	_, err = b.api().UploadFileV2(slack.UploadFileV2Parameters{
+		Content:  report,
+		FileSize: len(report),
+		Filename: fmt.Sprintf("mavbot-activity-%s.txt", window),
+		Title:    "MAVBot activity " + window,
+		Channel:  channel,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to upload activity report: %w", err)
+	}
+	if isDirectMessage(command) {
+		return nil, nil
+	}
+	return ephemeral("I sent you a DM with the activity report"), nil
+}
+
+func init() {
+	registerSlashCommand(&slashCommand{
+		Name:        "/activity",
+		Description: "Report the events, top commands and channels and the error rate of a period",
+		Usage:       "[today | 7d | 30d]",
+		Flags:       activityFlags,
+		Example:     "/activity 7d",
+		Category:    categoryAdmin,
+		AdminOnly:   true,
+		Handler:     (*Bot).handleActivity,
+	})
+}
@@ -0,0 +1,173 @@
+/*
+Copyright © 2024 Pavlo Tarasiuk <pasha.tarasyuk@gmail.com>
+*/
+package cmd
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/slack-go/slack"
+)
+
+func TestParseActivityWindow(t *testing.T) {
+	tests := []struct {
+		in   string
+		days int
+		ok   bool
+	}{
+		{"", 1, true},
+		{"today", 1, true},
+		{"7d", 7, true},
+		{"30d", 30, true},
+		{"1d", 0, false},
+		{"week", 0, false},
+	}
+	for _, tt := range tests {
+		days, ok := parseActivityWindow(tt.in)
+		if days != tt.days || ok != tt.ok {
+			t.Errorf("parseActivityWindow(%q) = %d, %t, want %d, %t", tt.in, days, ok, tt.days, tt.ok)
+		}
+	}
+}
+
+func TestTopCounts(t *testing.T) {
+	counts := map[string]int{"/help": 3, "/ask": 5, "/pin": 3, "/faq": 1}
+	if got, want := strings.Join(topCounts(counts, 3), " "), "/ask /help /pin"; got != want {
+		t.Errorf("topCounts() = %s, want %s", got, want)
+	}
+	if got := topCounts(nil, 3); len(got) != 0 {
+		t.Errorf("topCounts(nil) = %v", got)
+	}
+}
+
+// newActivityBot creates a bot on the fake clock with this activity recorded:
+// today 3 events in C1, one of them a failed /help, 3 days ago 2 /ask in C2,
+// 20 days ago one /help in C3
+func newActivityBot(t *testing.T) (*Bot, *fakeSlack, *fakeClock) {
+	b, fake := newTestBot(t, nil)
+	clock := newFakeClock()
+	b.now = clock.now
+
+	clock.advance(-20 * 24 * time.Hour)
+	b.emitEvent(eventSummary{Type: "slash_command", User: "U1", Channel: "C3", Command: "/help"}, nil)
+	clock.advance(17 * 24 * time.Hour)
+	b.emitEvent(eventSummary{Type: "slash_command", User: "U1", Channel: "C2", Command: "/ask"}, nil)
+	b.emitEvent(eventSummary{Type: "slash_command", User: "U2", Channel: "C2", Command: "/ask"}, nil)
+	clock.advance(3 * 24 * time.Hour)
+	b.emitEvent(eventSummary{Type: "app_mention", User: "U1", Channel: "C1"}, nil)
+	b.emitEvent(eventSummary{Type: "app_mention", User: "U2", Channel: "C1"}, nil)
+	b.emitEvent(eventSummary{Type: "slash_command", User: "U2", Channel: "C1", Command: "/help"}, errors.New("failed"))
+	return b, fake, clock
+}
+
+// activityReport runs /activity with the text
+func activityReport(t *testing.T, b *Bot, text string) *SlashResponse {
+	t.Helper()
+	resp, err := b.handleActivity(slack.SlashCommand{Command: "/activity", Text: text, UserID: "U0ADMIN", ChannelID: "C0ADMIN"})
+	if err != nil {
+		t.Fatalf("/activity %s failed: %v", text, err)
+	}
+	return resp
+}
+
+func TestActivityReportMatchesRecordedActivity(t *testing.T) {
+	tests := []struct {
+		window string
+		want   string
+	}{
+		{
+			window: "today",
+			want: "*MAVBot activity (today)*\nEvents processed: 3\nErrors: 1 (33.3%)\n" +
+				"*Top commands*\n/help: 1\n*Top channels*\n<#C1>: 3\n",
+		},
+		{
+			window: "7d",
+			want: "*MAVBot activity (7d)*\nEvents processed: 5\nErrors: 1 (20.0%)\n" +
+				"*Top commands*\n/ask: 2\n/help: 1\n*Top channels*\n<#C1>: 3\n<#C2>: 2\n",
+		},
+		{
+			window: "30d",
+			want: "*MAVBot activity (30d)*\nEvents processed: 6\nErrors: 1 (16.7%)\n" +
+				"*Top commands*\n/ask: 2\n/help: 2\n*Top channels*\n<#C1>: 3\n<#C2>: 2\n<#C3>: 1\n",
+		},
+	}
+	b, _, _ := newActivityBot(t)
+	for _, tt := range tests {
+		if got := activityReport(t, b, tt.window).Text; got != tt.want {
+			t.Errorf("/activity %s =\n%s\nwant\n%s", tt.window, got, tt.want)
+		}
+	}
+	if got := activityReport(t, b, "").Text; !strings.HasPrefix(got, "*MAVBot activity (today)*\nEvents processed: 3\n") {
+		t.Errorf("/activity without a window =\n%s\nwant today's", got)
+	}
+}
+
+func TestActivityWithoutEvents(t *testing.T) {
+	b, _ := newTestBot(t, nil)
+	want := "*MAVBot activity (today)*\nEvents processed: 0\nErrors: 0 (0.0%)\n*Top commands*\nnone\n*Top channels*\nnone\n"
+	if got := activityReport(t, b, "today").Text; got != want {
+		t.Errorf("/activity =\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestActivityRetention(t *testing.T) {
+	b, _, clock := newActivityBot(t)
+	clock.advance(15 * 24 * time.Hour)
+	b.emitEvent(eventSummary{Type: "app_mention", User: "U1", Channel: "C1"}, nil)
+
+	keys, err := b.store.Keys(collectionActivity)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The day 20 days before the first report is 35 days old now
+	if len(keys) != 3 || keys[0] != "2024-04-02" {
+		t.Errorf("kept days %v, want those within %d days", keys, activityRetention)
+	}
+}
+
+func TestActivityAsFile(t *testing.T) {
+	b, fake, _ := newActivityBot(t)
+	fake.answer("files.getUploadURLExternal", `{"ok":true,"upload_url":"`+fake.apiURL()+`upload/F1","file_id":"F1"}`)
+	fake.answer("upload/F1", `{"ok":true}`)
+	fake.answer("files.completeUploadExternal", `{"ok":true,"files":[{"id":"F1","title":"MAVBot activity 7d"}]}`)
+	fake.answer("conversations.open", `{"ok":true,"channel":{"id":"D0ADMIN"}}`)
+
+	if resp := activityReport(t, b, "--file 7d"); resp == nil || resp.Text != "I sent you a DM with the activity report" {
+		t.Errorf("/activity --file 7d answered %+v, want the admin pointed to their DM", resp)
+	}
+	uploads := fake.calls("upload/F1")
+	if len(uploads) != 1 || !strings.Contains(uploads[0].Form.Get("content"), "Events processed: 5") {
+		t.Errorf("uploads = %+v, want the 7d report", uploads)
+	}
+	if got := fake.calls("conversations.open")[0].Form.Get("users"); got != "U0ADMIN" {
+		t.Errorf("opened a DM with %q, want the admin", got)
+	}
+	if got := fake.calls("files.completeUploadExternal")[0].Form.Get("channel_id"); got != "D0ADMIN" {
+		t.Errorf("shared the report to %q, want the admin's DM", got)
+	}
+}
+
+func TestActivityAsFileWithoutDM(t *testing.T) {
+	b, fake, _ := newActivityBot(t)
+	fake.answer("conversations.open", `{"ok":false,"error":"cannot_dm_bot"}`)
+
+	resp := activityReport(t, b, "--file")
+	if resp == nil || !strings.Contains(resp.Text, "couldn't send you a DM") {
+		t.Errorf("/activity --file answered %+v, want the admin told the DM failed", resp)
+	}
+	if calls := fake.calls("files.getUploadURLExternal"); len(calls) != 0 {
+		t.Errorf("uploaded the report without a DM to share it to")
+	}
+}
+
+func TestActivityUsage(t *testing.T) {
+	b, _ := newTestBot(t, nil)
+	for _, text := range []string{"week", "7d 30d", "--bogus", "7d --file"} {
+		if got := activityReport(t, b, text).Text; got != usageOf("/activity") {
+			t.Errorf("/activity %s = %q, want the usage", text, got)
+		}
+	}
+}
@@ -0,0 +1,28 @@
+/*
+Copyright © 2024 Pavlo Tarasiuk <pasha.tarasyuk@gmail.com>
+*/
+package cmd
+
+import "log"
+
+// isAdmin reports whether the user is one of the configured bot administrators,
+// directly or as a member of one of the configured user groups
+func (b *Bot) isAdmin(userID string) bool {
+	for _, admin := range b.cfg.Admins {
+		if admin == userID {
+			return true
+		}
+		if !isUserGroupRef(admin) {
+			continue
+		}
+		member, err := b.inUserGroup(userID, admin)
+		if err != nil {
+			log.Printf("failed to check membership of %s: %v\n", admin, err)
+			continue
+		}
+		if member {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,211 @@
+/*
+Copyright © 2024 Pavlo Tarasiuk <pasha.tarasyuk@gmail.com>
+*/
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/slack-go/slack"
+)
+
+// Where runtime settings live in the Store
+const (
+	collectionSettings     = "settings"
+	settingAllowedChannels = "allowed_channels"
+)
+
+// channelAllowlist is the set of channels the bot is enabled in.
+// An empty allowlist enables the bot everywhere.
+type channelAllowlist struct {
+	mu       sync.RWMutex
+	channels map[string]bool
+}
+
+// newChannelAllowlist creates an allowlist holding channels
+func newChannelAllowlist(channels []string) *channelAllowlist {
+	a := &channelAllowlist{channels: make(map[string]bool)}
+	for _, channel := range channels {
+		a.channels[channel] = true
+	}
+	return a
+}
+
+// allows reports whether the bot is enabled in the channel
+func (a *channelAllowlist) allows(channelID string) bool {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	return len(a.channels) == 0 || a.channels[channelID]
+}
+
+// add enables the channel and reports whether it was missing
+func (a *channelAllowlist) add(channelID string) bool {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.channels[channelID] {
+		return false
+	}
+	a.channels[channelID] = true
+	return true
+}
+
+// remove disables the channel and reports whether it was present
+func (a *channelAllowlist) remove(channelID string) bool {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if !a.channels[channelID] {
+		return false
+	}
+	delete(a.channels, channelID)
+	return true
+}
+
+// list returns the channels, sorted
+func (a *channelAllowlist) list() []string {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	channels := make([]string, 0, len(a.channels))
+	for channel := range a.channels {
+		channels = append(channels, channel)
+	}
+	sort.Strings(channels)
+	return channels
+}
+
+// loadAllowlist builds the allowlist from the Store, falling back to the configured channels
+// when it was never changed at runtime
+func loadAllowlist(store Store, configured []string) (*channelAllowlist, error) {
+	var stored []string
+	ok, err := store.Get(collectionSettings, settingAllowedChannels, &stored)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load the channel allowlist: %w", err)
+	}
+	if ok {
+		return newChannelAllowlist(stored), nil
+	}
+	return newChannelAllowlist(configured), nil
+}
+
+// saveAllowlist persists the allowlist so runtime changes survive restarts
+func (b *Bot) saveAllowlist() error {
+	if err := b.store.Put(collectionSettings, settingAllowedChannels, b.allowlist.list()); err != nil {
+		return fmt.Errorf("failed to save the channel allowlist: %w", err)
+	}
+	return nil
+}
+
+// channelAllowed reports whether the bot should respond in the channel.
+// Direct messages are always allowed.
+func (b *Bot) channelAllowed(channelID string) bool {
+	return strings.HasPrefix(channelID, "D") || b.allowlist.allows(channelID)
+}
+
+// channelReference matches a channel as Slack escapes it in command text, e.g. <#C123|general>
+var channelReference = regexp.MustCompile(`^<#([A-Z0-9]+)(?:\|[^>]*)?>$`)
+
+// parseChannelArg returns the channel ID referenced by arg, or def when arg is empty
+func parseChannelArg(arg, def string) string {
+	if arg == "" {
+		return def
+	}
+	if m := channelReference.FindStringSubmatch(arg); m != nil {
+		return m[1]
+	}
+	return arg
+}
+
+// errAlreadyAllowed and errNotAllowed are the reasons /allow gives for channels it left alone
+var (
+	errAlreadyAllowed = errors.New("already on the allowlist")
+	errNotAllowed     = errors.New("not on the allowlist")
+)
+
+// allowMany adds several channels to or removes them from the allowlist, reporting per channel
+func (b *Bot) allowMany(op string, refs []string) (*SlashResponse, error) {
+	var result multiResult
+	for _, ref := range refs {
+		channel := b.resolveChannel(parseChannelArg(ref, ""))
+		switch {
+		case op == "add" && !b.allowlist.add(channel):
+			result.add(channel, errAlreadyAllowed)
+		case op == "remove" && !b.allowlist.remove(channel):
+			result.add(channel, errNotAllowed)
+		default:
+			result.add(channel, nil)
+		}
+	}
+	if result.failed() < len(result.Results) {
+		if err := b.saveAllowlist(); err != nil {
+			return nil, err
+		}
+	}
+	action := "Enabled MAVBot in"
+	if op == "remove" {
+		action = "Removed from the allowlist"
+	}
+	return ephemeral(result.format(action, "channels", channelRef)), nil
+}
+
+// handleAllow manages the channel allowlist: /allow add|remove [#channel...] or /allow list
+func (b *Bot) handleAllow(command slack.SlashCommand) (*SlashResponse, error) {
+	args := strings.Fields(command.Text)
+	if len(args) == 0 {
+		return ephemeral(usageOf("/allow")), nil
+	}
+	// Several channels at once get an outcome per channel
+	if (args[0] == "add" || args[0] == "remove") && len(args) > 2 {
+		return b.allowMany(args[0], args[1:])
+	}
+
+	switch args[0] {
+	case "list":
+		channels := b.allowlist.list()
+		if len(channels) == 0 {
+			return ephemeral("The allowlist is empty, MAVBot responds in every channel"), nil
+		}
+		refs := make([]string, len(channels))
+		for i, channel := range channels {
+			refs[i] = fmt.Sprintf("<#%s>", channel)
+		}
+		return ephemeral("MAVBot is enabled in " + strings.Join(refs, ", ")), nil
+
+	case "add":
+		channel := b.resolveChannel(parseChannelArg(strings.Join(args[1:], " "), command.ChannelID))
+		if !b.allowlist.add(channel) {
+			return ephemeral(fmt.Sprintf("<#%s> is already on the allowlist", channel)), nil
+		}
+		if err := b.saveAllowlist(); err != nil {
+			return nil, err
+		}
+		return ephemeral(fmt.Sprintf("MAVBot is now enabled in <#%s>", channel)), nil
+
+	case "remove":
+		channel := b.resolveChannel(parseChannelArg(strings.Join(args[1:], " "), command.ChannelID))
+		if !b.allowlist.remove(channel) {
+			return ephemeral(fmt.Sprintf("<#%s> is not on the allowlist", channel)), nil
+		}
+		if err := b.saveAllowlist(); err != nil {
+			return nil, err
+		}
+		return ephemeral(fmt.Sprintf("<#%s> was removed from the allowlist", channel)), nil
+	}
+
+	return ephemeral(fmt.Sprintf("Unknown subcommand %q, use add, remove or list", args[0])), nil
+}
+
+func init() {
+	registerSlashCommand(&slashCommand{
+		Name:        "/allow",
+		Description: "Manage the channels MAVBot is enabled in",
+		Usage:       "add [#channel...] | remove [#channel...] | list",
+		Example:     "/allow add #general",
+		Category:    categoryAdmin,
+		AdminOnly:   true,
+		Handler:     (*Bot).handleAllow,
+	})
+}
@@ -0,0 +1,99 @@
+/*
+Copyright © 2024 Pavlo Tarasiuk <pasha.tarasyuk@gmail.com>
+*/
+package cmd
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/slack-go/slack"
+)
+
+func TestAllowCommand(t *testing.T) {
+	cfg := testConfig(t)
+	cfg.Admins = []string{"U0ADMIN"}
+	b, _ := newTestBot(t, cfg)
+	steps := []struct {
+		text string
+		want string
+		// allowed are the channels on the allowlist afterwards
+		allowed []string
+	}{
+		{text: "list", want: "The allowlist is empty, MAVBot responds in every channel", allowed: []string{}},
+		{text: "add", want: "MAVBot is now enabled in <#C0HERE>", allowed: []string{"C0HERE"}},
+		{text: "add <#C0OTHER|other>", want: "MAVBot is now enabled in <#C0OTHER>", allowed: []string{"C0HERE", "C0OTHER"}},
+		{text: "add <#C0OTHER|other>", want: "<#C0OTHER> is already on the allowlist", allowed: []string{"C0HERE", "C0OTHER"}},
+		{text: "list", want: "MAVBot is enabled in <#C0HERE>, <#C0OTHER>", allowed: []string{"C0HERE", "C0OTHER"}},
+		{text: "remove", want: "<#C0HERE> was removed from the allowlist", allowed: []string{"C0OTHER"}},
+		{text: "remove C0HERE", want: "<#C0HERE> is not on the allowlist", allowed: []string{"C0OTHER"}},
+		{text: "drop", want: `Unknown subcommand "drop", use add, remove or list`, allowed: []string{"C0OTHER"}},
+		{text: "", want: "Usage: /allow add [#channel...] | remove [#channel...] | list", allowed: []string{"C0OTHER"}},
+	}
+	for _, step := range steps {
+		response, err := b.dispatchSlashCommand(slack.SlashCommand{Command: "/allow", Text: step.text, UserID: "U0ADMIN", ChannelID: "C0HERE"})
+		if err != nil {
+			t.Fatalf("/allow %s failed: %v", step.text, err)
+		}
+		if response.Text != step.want {
+			t.Errorf("/allow %s: got %q, want %q", step.text, response.Text, step.want)
+		}
+		if got := b.allowlist.list(); !reflect.DeepEqual(got, step.allowed) {
+			t.Errorf("/allow %s: allowlist is %v, want %v", step.text, got, step.allowed)
+		}
+	}
+
+	// The changes outlive the bot
+	restored, err := loadAllowlist(b.store, []string{"C0CONFIGURED"})
+	if err != nil {
+		t.Fatalf("failed to load the allowlist: %v", err)
+	}
+	if got := restored.list(); !reflect.DeepEqual(got, []string{"C0OTHER"}) {
+		t.Errorf("restored allowlist %v, want the runtime changes", got)
+	}
+}
+
+func TestAllowlistFallsBackToTheConfiguration(t *testing.T) {
+	b, _ := newTestBot(t, nil)
+	allowlist, err := loadAllowlist(b.store, []string{"C2", "C1"})
+	if err != nil {
+		t.Fatalf("failed to load the allowlist: %v", err)
+	}
+	if got := allowlist.list(); !reflect.DeepEqual(got, []string{"C1", "C2"}) {
+		t.Errorf("got %v, want the configured channels", got)
+	}
+}
+
+func TestAllowIsAdminOnly(t *testing.T) {
+	b, _ := newTestBot(t, nil)
+	response, err := b.dispatchSlashCommand(slack.SlashCommand{Command: "/allow", Text: "add", UserID: "U1", ChannelID: "C1"})
+	if err != nil {
+		t.Fatalf("/allow failed: %v", err)
+	}
+	if response.Text != "Sorry, this command is available to MAVBot admins only" || len(b.allowlist.list()) != 0 {
+		t.Errorf("a non-admin changed the allowlist: %q", response.Text)
+	}
+}
+
+func TestChannelAllowed(t *testing.T) {
+	tests := []struct {
+		name      string
+		allowlist []string
+		channel   string
+		want      bool
+	}{
+		{name: "empty allowlist", channel: "C1", want: true},
+		{name: "listed", allowlist: []string{"C1"}, channel: "C1", want: true},
+		{name: "not listed", allowlist: []string{"C1"}, channel: "C2", want: false},
+		{name: "DM", allowlist: []string{"C1"}, channel: "D1", want: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b, _ := newTestBot(t, nil)
+			b.allowlist = newChannelAllowlist(tt.allowlist)
+			if got := b.channelAllowed(tt.channel); got != tt.want {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
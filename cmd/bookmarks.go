@@ -0,0 +1,136 @@
+/*
+Copyright © 2024 Pavlo Tarasiuk <pasha.tarasyuk@gmail.com>
+*/
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/slack-go/slack"
+)
+
+// collectionBookmarks holds the bot messages users bookmarked by starring them
+const collectionBookmarks = "bookmarks"
+
+// starAddedEvent is the star_added event, which slack-go has no type for
+type starAddedEvent struct {
+	Type string `json:"type"`
+	User string `json:"user"`
+	Item struct {
+		Type    string `json:"type"`
+		Channel string `json:"channel"`
+		Message struct {
+			User      string `json:"user"`
+			BotID     string `json:"bot_id"`
+			Text      string `json:"text"`
+			Timestamp string `json:"ts"`
+		} `json:"message"`
+	} `json:"item"`
+	EventTimestamp string `json:"event_ts"`
+}
+
+// bookmark is a bot message a user starred
+type bookmark struct {
+	User      string    `json:"user"`
+	Channel   string    `json:"channel"`
+	Timestamp string    `json:"ts"`
+	Text      string    `json:"text"`
+	StarredAt time.Time `json:"starred_at"`
+}
+
+// bookmarkKey identifies a bookmark in the Store, prefixed by the user so theirs can be listed
+func bookmarkKey(userID, channelID, timestamp string) string {
+	return userID + "/" + channelID + "/" + timestamp
+}
+
+// handleStarAdded records a bookmark when a user stars a message the bot posted
+func (b *Bot) handleStarAdded(raw json.RawMessage) error {
+	var event starAddedEvent
+	if err := json.Unmarshal(raw, &event); err != nil {
+		return fmt.Errorf("failed to decode star_added event: %w", err)
+	}
+
+	message := event.Item.Message
+	if event.Item.Type != "message" || !b.isOwnMessage(message.User, message.BotID) {
+		return nil
+	}
+	// Stars by the bot itself are not bookmarks of a user
+	if event.User == "" || b.isOwnMessage(event.User, "") {
+		return nil
+	}
+
+	mark := bookmark{
+		User:      event.User,
+		Channel:   event.Item.Channel,
+		Timestamp: message.Timestamp,
+		Text:      message.Text,
+		StarredAt: b.now(),
+	}
+	if err := b.store.Put(collectionBookmarks, bookmarkKey(mark.User, mark.Channel, mark.Timestamp), mark); err != nil {
+		return fmt.Errorf("failed to record bookmark: %w", err)
+	}
+	return nil
+}
+
+// userBookmarks returns the bookmarks of the user, oldest first
+func (b *Bot) userBookmarks(userID string) ([]bookmark, error) {
+	keys, err := b.store.Keys(collectionBookmarks)
+	if err != nil {
+		return nil, err
+	}
+	var marks []bookmark
+	for _, key := range keys {
+		if !strings.HasPrefix(key, userID+"/") {
+			continue
+		}
+		var mark bookmark
+		if ok, err := b.store.Get(collectionBookmarks, key, &mark); err != nil {
+			return nil, err
+		} else if ok {
+			marks = append(marks, mark)
+		}
+	}
+	sort.Slice(marks, func(i, j int) bool { return marks[i].StarredAt.Before(marks[j].StarredAt) })
+	return marks, nil
+}
+
+// handleBookmarks lists the invoking user's bookmarks with links to the messages
+func (b *Bot) handleBookmarks(command slack.SlashCommand) (*SlashResponse, error) {
+	marks, err := b.userBookmarks(command.UserID)
+	if err != nil {
+		return nil, err
+	}
+	if len(marks) == 0 {
+		return ephemeral("You have no bookmarks yet. Star one of my messages to bookmark it."), nil
+	}
+
+	var list strings.Builder
+	list.WriteString("*Your bookmarks*\n")
+	for _, mark := range marks {
+		fmt.Fprintf(&list, "• %s %s\n", b.messageLink(mark.Channel, mark.Timestamp, mark.StarredAt.Format("2006-01-02")), excerpt(mark.Text, 80))
+	}
+	return ephemeral(list.String()), nil
+}
+
+// excerpt shortens text to at most n runes on a single line
+func excerpt(text string, n int) string {
+	text = strings.Join(strings.Fields(text), " ")
+	runes := []rune(text)
+	if len(runes) <= n {
+		return text
+	}
+	return string(runes[:n-1]) + "…"
+}
+
+func init() {
+	registerSlashCommand(&slashCommand{
+		Name:        "/bookmarks",
+		Description: "List the bot messages you starred",
+		Category:    categoryPersonal,
+		Handler:     (*Bot).handleBookmarks,
+	})
+}
@@ -0,0 +1,113 @@
+/*
+Copyright © 2024 Pavlo Tarasiuk <pasha.tarasyuk@gmail.com>
+*/
+package cmd
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/slack-go/slack"
+)
+
+// starEvent is a star_added event of the user on a message by author
+func starEvent(user, author, botID, ts, text string) string {
+	return `{"type":"star_added","user":"` + user + `","item":{"type":"message","channel":"C1","message":{"user":"` + author +
+		`","bot_id":"` + botID + `","text":"` + text + `","ts":"` + ts + `"}},"event_ts":"1712345679.000100"}`
+}
+
+func TestStarAddedRecordsBookmarks(t *testing.T) {
+	tests := []struct {
+		name  string
+		event string
+		want  bool
+	}{
+		{name: "user stars a bot message", event: starEvent("U1", "UBOT", "BBOT", "1712345678.000100", "Answer"), want: true},
+		{name: "user stars a message told by the bot ID", event: starEvent("U1", "", "BBOT", "1712345678.000100", "Answer"), want: true},
+		{name: "user stars someone else's message", event: starEvent("U1", "U2", "", "1712345678.000100", "Hi")},
+		{name: "bot stars its own message", event: starEvent("UBOT", "UBOT", "BBOT", "1712345678.000100", "Answer")},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b, _ := newTestBot(t, nil)
+			b.selfUserID, b.selfBotID = "UBOT", "BBOT"
+			socket := &fakeSocket{}
+
+			b.processEvent(context.Background(), unparsedEvent("e1", tt.event), socket)
+
+			if acks := socket.acked(); len(acks) != 1 || acks[0].EnvelopeID != "e1" {
+				t.Errorf("got acknowledgements %+v, want the event acknowledged", acks)
+			}
+			marks, err := b.userBookmarks("U1")
+			if err != nil {
+				t.Fatalf("failed to list bookmarks: %v", err)
+			}
+			if got := len(marks) == 1; got != tt.want {
+				t.Fatalf("got bookmarks %+v, want recorded %v", marks, tt.want)
+			}
+			if tt.want && (marks[0].Channel != "C1" || marks[0].Timestamp != "1712345678.000100" || marks[0].Text != "Answer") {
+				t.Errorf("recorded %+v", marks[0])
+			}
+			if others, _ := b.userBookmarks("UBOT"); len(others) != 0 {
+				t.Errorf("recorded the bot's own star")
+			}
+		})
+	}
+}
+
+func TestBookmarksCommand(t *testing.T) {
+	b, fake := newTestBot(t, nil)
+	b.selfUserID = "UBOT"
+	fake.answer("chat.getPermalink", `{"ok":true,"permalink":"https://x.slack.com/archives/C1/p1712345678000100"}`)
+	clock := newFakeClock()
+	b.now = clock.now
+
+	response, err := b.handleBookmarks(slack.SlashCommand{UserID: "U1"})
+	if err != nil || !strings.Contains(response.Text, "no bookmarks yet") {
+		t.Fatalf("got %+v, %v, want no bookmarks", response, err)
+	}
+
+	for _, ts := range []string{"1712345678.000100", "1712345678.000200"} {
+		if err := b.handleStarAdded([]byte(starEvent("U1", "UBOT", "", ts, "Answer "+ts))); err != nil {
+			t.Fatalf("star_added failed: %v", err)
+		}
+		clock.advance(24 * time.Hour)
+	}
+	if err := b.handleStarAdded([]byte(starEvent("U2", "UBOT", "", "1712345678.000300", "Not yours"))); err != nil {
+		t.Fatalf("star_added failed: %v", err)
+	}
+
+	response, err = b.handleBookmarks(slack.SlashCommand{UserID: "U1"})
+	if err != nil {
+		t.Fatalf("/bookmarks failed: %v", err)
+	}
+	lines := strings.Split(strings.TrimSpace(response.Text), "\n")
+	want := []string{
+		"*Your bookmarks*",
+		"• <https://x.slack.com/archives/C1/p1712345678000100|2024-04-05> Answer 1712345678.000100",
+		"• <https://x.slack.com/archives/C1/p1712345678000100|2024-04-06> Answer 1712345678.000200",
+	}
+	if strings.Join(lines, "\n") != strings.Join(want, "\n") {
+		t.Errorf("got\n%s\nwant\n%s", response.Text, strings.Join(want, "\n"))
+	}
+}
+
+func TestExcerpt(t *testing.T) {
+	tests := []struct {
+		text string
+		n    int
+		want string
+	}{
+		{"short", 10, "short"},
+		{"line one\n  line two", 20, "line one line two"},
+		{"abcdefghij", 5, "abcd…"},
+		{"привіт світ", 7, "привіт…"},
+	}
+	for _, tt := range tests {
+		if got := excerpt(tt.text, tt.n); got != tt.want {
+			t.Errorf("excerpt(%q, %d) = %q, want %q", tt.text, tt.n, got, tt.want)
+		}
+	}
+}
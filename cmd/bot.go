@@ -0,0 +1,215 @@
+/*
+Copyright © 2024 Pavlo Tarasiuk <pasha.tarasyuk@gmail.com>
+*/
+package cmd
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/slack-go/slack"
+)
+
+// Bot bundles the Slack client with the configuration shared by the handlers
+type Bot struct {
+	client  *clientRef
+	cfg     *Config
+	store   Store
+	metrics *metrics
+
+	// selfUserID and selfBotID identify the bot's own messages
+	selfUserID string
+	selfBotID  string
+	// poolIDs are the user and bot IDs of the apps of PostingTokens, their messages are the bot's too
+	poolIDs map[string]bool
+	// apiURL is the base URL of the Web API the bot's client calls, slack.APIURL unless testing
+	apiURL string
+	// workspaceURL is the address of the workspace the bot is installed in, e.g. https://x.slack.com/
+	workspaceURL string
+
+	// ctx is what the bot's work is done under, see within
+	ctx context.Context
+
+	// now is the clock of the bot, replaceable so time dependent behaviour can be tested
+	now func() time.Time
+	// after waits for a duration on the bot's clock, replaceable along with now
+	after func(d time.Duration) <-chan time.Time
+	// startedAt is when the bot was created
+	startedAt time.Time
+
+	// allowlist holds the channels the bot is enabled in
+	allowlist *channelAllowlist
+	// features are the feature flags handlers consult
+	features *featureFlags
+	// maintenance freezes the bot for everything but admin commands
+	maintenance *maintenanceMode
+
+	// signer signs the context interactive blocks carry
+	signer *actionSigner
+
+	// catalogs are the message templates of every language
+	catalogs *catalogRef
+	// captured collects what the bot would send instead of sending it, see capturing
+	captured *capture
+
+	// clients are the clients messages are posted with, the bot's own one and those of PostingTokens
+	clients *clientPool
+	// outbound limits the rate of posted messages, nil when unlimited
+	outbound *tokenBucket
+	// queue holds the messages waiting for the outbound limit, runOutbound posts them
+	queue *outboundQueue
+	// throttle limits the rate of messages posted to each channel
+	throttle *channelThrottle
+	// faults fails Web API requests on purpose for /simulate-ratelimit, nil when it can't
+	faults *faultInjector
+	// usage tracks the recent outbound calls and rate limit events for /ratelimit
+	usage *rateUsage
+
+	// emoji caches the workspace's custom emoji
+	emoji *emojiCache
+	// users caches user info by user ID
+	users *cache[*slack.User]
+	// permalinks caches message permalinks by "channel/timestamp"
+	permalinks *cache[string]
+	// channels caches conversation info by channel ID
+	channels *cache[*slack.Channel]
+	// channelNames caches channel IDs by channel name, kept fresh by rename events
+	channelNames *cache[string]
+	// groupMembership caches the member IDs of user groups by group ID, kept fresh by subteam events
+	groupMembership *cache[[]string]
+	// groupHandles caches user group IDs by handle
+	groupHandles *cache[string]
+	// events remembers the IDs of recent Events API events to drop redeliveries
+	events *dedupCache
+	// lateHandlers, when set, gets what every event handler finishing after its timeout returned
+	lateHandlers chan<- error
+	// commands remembers recent slash command submissions to run a repeated one once
+	commands *dedupCache
+	// results caches the responses of the commands with a cache TTL
+	results *resultCache
+
+	// httpClient makes the requests that don't go through the Slack client, like responses to response_url
+	httpClient *http.Client
+	// canvases edits channel canvases
+	canvases canvasAPI
+	// links acts on the links in messages, nil when they are left alone
+	links LinkProcessor
+	// linkJobs are the messages whose links wait for runLinks
+	linkJobs chan linkJob
+
+	// migration holds messages back while the workspace migrates to Enterprise Grid
+	migration *gridMigration
+
+	// tokenReload recovers from a bot token Slack stopped accepting
+	tokenReload *tokenReload
+
+	// deadLetter keeps the interactions processing failed for, retries included
+	deadLetter DeadLetter
+
+	// activity keeps the daily activity totals /activity reports on
+	activity *activityLog
+
+	// logs holds the latest log lines for /logs, nil when they aren't captured
+	logs *logRing
+
+	// recorder writes incoming events to the event log, nil when recording is off
+	recorder *eventRecorder
+	// webhook receives summaries of the processed events, nil when not configured
+	webhook *webhook
+}
+
+// newBot creates a Bot that talks to Slack through client and keeps its state in store
+func newBot(client *slack.Client, cfg *Config, store Store) (*Bot, error) {
+	catalogs, err := loadCatalogs(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	b := &Bot{
+		client:  &clientRef{client: client, tokenValue: cfg.BotToken},
+		cfg:     cfg,
+		store:   store,
+		metrics: newMetrics(),
+
+		ctx:   context.Background(),
+		now:   time.Now,
+		after: time.After,
+
+		allowlist: newChannelAllowlist(cfg.AllowedChannels),
+		features:  newFeatureFlags(defaultFeatures(cfg)),
+		catalogs:  &catalogRef{catalogs: catalogs},
+		signer:    newActionSigner(),
+
+		emoji:        newEmojiCache(),
+		users:        newCache[*slack.User](),
+		permalinks:   newCache[string](),
+		channels:     newCache[*slack.Channel](),
+		channelNames: newCache[string](),
+
+		groupMembership: newCache[[]string](),
+		groupHandles:    newCache[string](),
+	}
+	b.startedAt = b.now()
+	b.poolIDs = make(map[string]bool)
+	b.apiURL = slack.APIURL
+	b.httpClient = http.DefaultClient
+	b.canvases = newWebAPI(b.httpClient, b.client)
+	b.links = newLinkProcessor(cfg.LinkProcessor, newLinkClient())
+	b.linkJobs = make(chan linkJob, linkJobQueue)
+	b.usage = newRateUsage(usageWindow, b.now)
+	b.events = newDedupCache(cfg.DedupSize, cfg.DedupTTL, b.now, b.metrics, "")
+	b.commands = newDedupCache(commandDedupSize, cfg.CommandDedupWindow, b.now, b.metrics, "command_")
+	b.results = newResultCache(b.now)
+	b.activity = &activityLog{store: store}
+	b.migration = &gridMigration{}
+	b.maintenance = &maintenanceMode{}
+	b.tokenReload = &tokenReload{}
+	b.clients = newClientPool(b.now)
+	b.deadLetter = newFileDeadLetter(cfg.DeadLetterFile, b.now)
+	b.throttle = newChannelThrottle(cfg.ChannelPerMinute, cfg.ChannelLimits, b.now)
+	if cfg.WebhookURL != "" {
+		b.webhook = newWebhook(cfg.WebhookURL, cfg.WebhookSecret, cfg.WebhookRetries, b.now)
+	}
+	b.queue = newOutboundQueue(cfg.OutboundQueueSize)
+	if cfg.OutboundPerMinute > 0 {
+		b.outbound = newTokenBucket(cfg.OutboundPerMinute, b.now)
+	}
+	return b, nil
+}
+
+// api returns the Slack client to make Web API calls with
+func (b *Bot) api() *slack.Client {
+	return b.client.get()
+}
+
+// clientRef holds the Slack client, which is replaced when the bot token is rotated
+type clientRef struct {
+	mu     sync.RWMutex
+	client *slack.Client
+	// tokenValue is the bot token of client, for the calls made without slack-go
+	tokenValue string
+}
+
+// get returns the current client
+func (r *clientRef) get() *slack.Client {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.client
+}
+
+// token returns the bot token of the current client
+func (r *clientRef) token() string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.tokenValue
+}
+
+// set replaces the client using token, calls in flight finish with the previous one
+func (r *clientRef) set(client *slack.Client, token string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.client = client
+	r.tokenValue = token
+}
@@ -0,0 +1,281 @@
+/*
+Copyright © 2024 Pavlo Tarasiuk <pasha.tarasyuk@gmail.com>
+*/
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/slack-go/slack"
+	"github.com/slack-go/slack/slackevents"
+	"github.com/slack-go/slack/socketmode"
+)
+
+// noSecrets is a SecretProvider without any secret
+type noSecrets struct{}
+
+// Secret implements SecretProvider
+func (noSecrets) Secret(string) (string, error) {
+	return "", nil
+}
+
+// testConfig returns the default configuration with the bot's files kept in a temporary directory
+func testConfig(t *testing.T) *Config {
+	t.Helper()
+	cfg, err := loadConfigWith(noSecrets{})
+	if err != nil {
+		t.Fatalf("failed to load config: %v", err)
+	}
+	cfg.BotToken = "xoxb-test"
+	cfg.DataDir = t.TempDir()
+	cfg.DeadLetterFile = cfg.DataDir + "/dead-letter.jsonl"
+	// Failed interactions are tried again right away
+	cfg.InteractionRetryDelay = 0
+	return cfg
+}
+
+// slackCall is a Web API call the fake Slack received
+type slackCall struct {
+	Method string
+	// Form holds the arguments of a form encoded call
+	Form url.Values
+	// Body is the body of a call that isn't form encoded, e.g. JSON
+	Body []byte
+}
+
+// fakeSlack is a Slack Web API recording the calls it gets. Calls are answered with ok,
+// chat.postMessage with a timestamp, unless the test told it otherwise.
+type fakeSlack struct {
+	*httptest.Server
+	mu       sync.Mutex
+	received []slackCall
+	handlers map[string]http.HandlerFunc
+}
+
+// newFakeSlack starts a fake Slack closed with the test
+func newFakeSlack(t *testing.T) *fakeSlack {
+	f := &fakeSlack{handlers: make(map[string]http.HandlerFunc)}
+	f.Server = httptest.NewServer(http.HandlerFunc(f.serve))
+	t.Cleanup(f.Close)
+	return f
+}
+
+// apiURL is the base URL to pass to slack.OptionAPIURL
+func (f *fakeSlack) apiURL() string {
+	return f.URL + "/api/"
+}
+
+// redirecting returns a client sending every request to the fake Slack whatever its host,
+// for the calls slack-go makes to slack.APIURL without a client of its own
+func (f *fakeSlack) redirecting() *http.Client {
+	target, _ := url.Parse(f.URL)
+	return &http.Client{Transport: roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		req = req.Clone(req.Context())
+		req.URL.Scheme, req.URL.Host, req.Host = target.Scheme, target.Host, target.Host
+		return http.DefaultTransport.RoundTrip(req)
+	})}
+}
+
+// serve records the call and answers it
+func (f *fakeSlack) serve(w http.ResponseWriter, r *http.Request) {
+	method := strings.TrimPrefix(r.URL.Path, "/api/")
+	call := slackCall{Method: method}
+	if strings.HasPrefix(r.Header.Get("Content-Type"), "application/x-www-form-urlencoded") {
+		_ = r.ParseForm()
+		call.Form = r.Form
+	} else {
+		call.Body, _ = io.ReadAll(r.Body)
+		call.Form = r.URL.Query()
+	}
+
+	f.mu.Lock()
+	f.received = append(f.received, call)
+	handler := f.handlers[method]
+	f.mu.Unlock()
+
+	if handler != nil {
+		handler(w, r)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	if method == "chat.postMessage" {
+		fmt.Fprintf(w, `{"ok":true,"channel":%q,"ts":"1712345678.%06d"}`, call.Form.Get("channel"), len(f.calls(method)))
+		return
+	}
+	fmt.Fprint(w, `{"ok":true}`)
+}
+
+// handle answers the calls of the method with handler
+func (f *fakeSlack) handle(method string, handler http.HandlerFunc) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.handlers[method] = handler
+}
+
+// answer answers the calls of the method with the JSON
+func (f *fakeSlack) answer(method, body string) {
+	f.handle(method, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, body)
+	})
+}
+
+// calls returns the calls of the methods received so far, in the order they came in
+func (f *fakeSlack) calls(methods ...string) []slackCall {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var calls []slackCall
+	for _, call := range f.received {
+		for _, method := range methods {
+			if call.Method == method {
+				calls = append(calls, call)
+			}
+		}
+	}
+	return calls
+}
+
+// posts returns the texts of the messages posted so far, ephemeral ones included
+func (f *fakeSlack) posts() []string {
+	var texts []string
+	for _, call := range f.calls("chat.postMessage", "chat.postEphemeral") {
+		texts = append(texts, call.Form.Get("text"))
+	}
+	return texts
+}
+
+// waitCalls waits for n calls of the method, for the work the bot does in the background
+func (f *fakeSlack) waitCalls(t *testing.T, method string, n int) []slackCall {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		calls := f.calls(method)
+		if len(calls) >= n || time.Now().After(deadline) {
+			if len(calls) < n {
+				t.Fatalf("got %d %s calls, want %d", len(calls), method, n)
+			}
+			return calls
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+// newTestBot creates a bot talking to a fake Slack, with the configuration defaulting to testConfig
+func newTestBot(t *testing.T, cfg *Config) (*Bot, *fakeSlack) {
+	t.Helper()
+	if cfg == nil {
+		cfg = testConfig(t)
+	}
+	f := newFakeSlack(t)
+	store, err := newFileStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("failed to create store: %v", err)
+	}
+	b, err := newBot(slack.New(cfg.BotToken, slack.OptionAPIURL(f.apiURL())), cfg, store)
+	if err != nil {
+		t.Fatalf("failed to create bot: %v", err)
+	}
+	b.apiURL = f.apiURL()
+	if api, ok := b.canvases.(*webAPI); ok {
+		api.url = b.apiURL
+	}
+	return b, f
+}
+
+// fakeSocket records the acknowledgements in place of socketmode.Client
+type fakeSocket struct {
+	mu   sync.Mutex
+	acks []socketAck
+}
+
+// socketAck is an acknowledgement sent through the fake socket
+type socketAck struct {
+	EnvelopeID string
+	Payload    interface{}
+}
+
+// Ack implements acker
+func (s *fakeSocket) Ack(req socketmode.Request, payload ...interface{}) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	ack := socketAck{EnvelopeID: req.EnvelopeID}
+	if len(payload) > 0 {
+		ack.Payload = payload[0]
+	}
+	s.acks = append(s.acks, ack)
+}
+
+// acked returns the acknowledgements sent so far
+func (s *fakeSocket) acked() []socketAck {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]socketAck(nil), s.acks...)
+}
+
+// slashEvent wraps the command in the socketmode event it is delivered in
+func slashEvent(envelopeID string, command slack.SlashCommand) socketmode.Event {
+	return socketmode.Event{
+		Type:    socketmode.EventTypeSlashCommand,
+		Data:    command,
+		Request: &socketmode.Request{EnvelopeID: envelopeID},
+	}
+}
+
+// callbackEvent wraps the inner event in the Events API callback it is delivered in
+func callbackEvent(innerType string, data interface{}) slackevents.EventsAPIEvent {
+	return slackevents.EventsAPIEvent{
+		Type:       slackevents.CallbackEvent,
+		InnerEvent: slackevents.EventsAPIInnerEvent{Type: innerType, Data: data},
+	}
+}
+
+// unparsedEvent wraps the inner event JSON in the socketmode message slack-go fails to parse
+// for event types it doesn't know
+func unparsedEvent(envelopeID, inner string) socketmode.Event {
+	message := `{"type":"events_api","envelope_id":"` + envelopeID + `","payload":{"type":"event_callback","event":` + inner + `}}`
+	return socketmode.Event{
+		Type: socketmode.EventTypeErrorBadMessage,
+		Data: &socketmode.ErrorBadMessage{Cause: errors.New("unsupported event"), Message: json.RawMessage(message)},
+	}
+}
+
+// logBuffer collects log output, safe to read while goroutines of the bot still log
+type logBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+// Write implements io.Writer
+func (l *logBuffer) Write(p []byte) (int, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.buf.Write(p)
+}
+
+// String returns what has been logged so far
+func (l *logBuffer) String() string {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.buf.String()
+}
+
+// captureLog collects what the test logs until it ends
+func captureLog(t *testing.T) *logBuffer {
+	logs := &logBuffer{}
+	log.SetOutput(logs)
+	t.Cleanup(func() { log.SetOutput(os.Stderr) })
+	return logs
+}
@@ -0,0 +1,144 @@
+/*
+Copyright © 2024 Pavlo Tarasiuk <pasha.tarasyuk@gmail.com>
+*/
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+
+	"github.com/slack-go/slack"
+)
+
+// Policies for broadcast mentions (@channel, @here, @everyone) in outbound messages
+const (
+	// broadcastBlock refuses to send a message containing a broadcast mention
+	broadcastBlock = "block"
+	// broadcastStrip removes broadcast mentions and sends the rest of the message
+	broadcastStrip = "strip"
+	// broadcastAllowAdmins lets broadcast mentions through when an admin triggered the message
+	// and strips them otherwise
+	broadcastAllowAdmins = "allow-admins"
+)
+
+// broadcastPattern matches the special mentions that notify a whole channel, with or without a label
+var broadcastPattern = regexp.MustCompile(`<!(?:channel|here|everyone)(?:\|[^>]*)?>`)
+
+// errBroadcastBlocked is returned when the block policy stops a message
+var errBroadcastBlocked = errors.New("message contains a broadcast mention (@channel, @here or @everyone)")
+
+// validBroadcastPolicy reports whether policy is one of the known policies
+func validBroadcastPolicy(policy string) bool {
+	switch policy {
+	case broadcastBlock, broadcastStrip, broadcastAllowAdmins:
+		return true
+	}
+	return false
+}
+
+// applyBroadcastPolicy enforces the configured policy on the text, attachments and blocks of msg
+func (b *Bot) applyBroadcastPolicy(msg *outboundMessage) error {
+	blocks := slack.Blocks{BlockSet: msg.Blocks}
+	for _, part := range []interface{}{&msg.Text, &msg.Attachments, &blocks} {
+		if err := b.filterBroadcast(part, msg.Invoker); err != nil {
+			return err
+		}
+	}
+	msg.Blocks = blocks.BlockSet
+	return nil
+}
+
+// filterBroadcastPayload enforces the configured policy on a slash command response.
+// The returned payload is the filtered JSON, ready to be sent with the acknowledgement.
+func (b *Bot) filterBroadcastPayload(payload interface{}, invoker string) (interface{}, error) {
+	if payload == nil {
+		return nil, nil
+	}
+	raw, err := marshalUnescaped(payload)
+	if err != nil {
+		return nil, err
+	}
+	raw, err = b.filterBroadcastJSON(raw, invoker)
+	if err != nil {
+		return nil, err
+	}
+	return json.RawMessage(raw), nil
+}
+
+// filterBroadcast enforces the policy on every string held by v, which must be a pointer.
+// Going through JSON covers nested attachments and blocks without walking each type.
+func (b *Bot) filterBroadcast(v interface{}, invoker string) error {
+	raw, err := marshalUnescaped(v)
+	if err != nil {
+		return err
+	}
+	filtered, err := b.filterBroadcastJSON(raw, invoker)
+	if err != nil || bytes.Equal(raw, filtered) {
+		return err
+	}
+	return json.Unmarshal(filtered, v)
+}
+
+// filterBroadcastJSON enforces the policy on raw JSON. The mentions are looked for in the decoded
+// strings, types with their own MarshalJSON (like slack.Blocks) escape < and > in the raw JSON.
+func (b *Bot) filterBroadcastJSON(raw []byte, invoker string) ([]byte, error) {
+	var doc interface{}
+	dec := json.NewDecoder(bytes.NewReader(raw))
+	dec.UseNumber()
+	if err := dec.Decode(&doc); err != nil {
+		return nil, fmt.Errorf("failed to decode message: %w", err)
+	}
+	found := false
+	stripped := mapStrings(doc, func(s string) string {
+		if !broadcastPattern.MatchString(s) {
+			return s
+		}
+		found = true
+		return broadcastPattern.ReplaceAllString(s, "")
+	})
+	if !found {
+		return raw, nil
+	}
+
+	switch b.cfg.BroadcastPolicy {
+	case broadcastBlock:
+		return nil, errBroadcastBlocked
+	case broadcastAllowAdmins:
+		if b.isAdmin(invoker) {
+			return raw, nil
+		}
+	}
+	return marshalUnescaped(stripped)
+}
+
+// mapStrings returns the decoded JSON value v with every string in it replaced by f
+func mapStrings(v interface{}, f func(string) string) interface{} {
+	switch v := v.(type) {
+	case string:
+		return f(v)
+	case []interface{}:
+		for i := range v {
+			v[i] = mapStrings(v[i], f)
+		}
+	case map[string]interface{}:
+		for key := range v {
+			v[key] = mapStrings(v[key], f)
+		}
+	}
+	return v
+}
+
+// marshalUnescaped encodes v as JSON keeping <, > and & as they are, so Slack's
+// special mention syntax stays readable in the payload
+func marshalUnescaped(v interface{}) ([]byte, error) {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(v); err != nil {
+		return nil, fmt.Errorf("failed to encode message: %w", err)
+	}
+	return bytes.TrimSpace(buf.Bytes()), nil
+}
@@ -0,0 +1,133 @@
+/*
+Copyright © 2024 Pavlo Tarasiuk <pasha.tarasyuk@gmail.com>
+*/
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/slack-go/slack"
+)
+
+func TestBroadcastPolicy(t *testing.T) {
+	const text = "<!channel> the build is red"
+	tests := []struct {
+		name    string
+		policy  string
+		invoker string
+		want    string
+		wantErr error
+	}{
+		{name: "block", policy: broadcastBlock, invoker: "U0USER", wantErr: errBroadcastBlocked},
+		{name: "block admin", policy: broadcastBlock, invoker: "U0ADMIN", wantErr: errBroadcastBlocked},
+		{name: "strip", policy: broadcastStrip, invoker: "U0USER", want: " the build is red"},
+		{name: "strip admin", policy: broadcastStrip, invoker: "U0ADMIN", want: " the build is red"},
+		{name: "allow admins for a user", policy: broadcastAllowAdmins, invoker: "U0USER", want: " the build is red"},
+		{name: "allow admins for an admin", policy: broadcastAllowAdmins, invoker: "U0ADMIN", want: text},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := testConfig(t)
+			cfg.BroadcastPolicy = tt.policy
+			cfg.Admins = []string{"U0ADMIN"}
+			b, fake := newTestBot(t, cfg)
+
+			_, err := b.postMessage(outboundMessage{Channel: "C1", Invoker: tt.invoker, Text: text})
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("got error %v, want %v", err, tt.wantErr)
+			}
+			posts := fake.posts()
+			if tt.wantErr != nil {
+				if len(posts) != 0 {
+					t.Errorf("a blocked message was posted: %q", posts)
+				}
+				return
+			}
+			if len(posts) != 1 || posts[0] != tt.want {
+				t.Errorf("got posts %q, want %q", posts, tt.want)
+			}
+		})
+	}
+}
+
+func TestBroadcastPolicyCoversAttachmentsAndBlocks(t *testing.T) {
+	b, fake := newTestBot(t, nil)
+
+	_, err := b.postMessage(outboundMessage{
+		Channel:     "C1",
+		Attachments: []slack.Attachment{{Text: "ping <!here|here>", Fields: []slack.AttachmentField{{Title: "Who", Value: "<!everyone>"}}}},
+		Blocks:      []slack.Block{slack.NewSectionBlock(slack.NewTextBlockObject(slack.MarkdownType, "hey <!channel>", false, false), nil, nil)},
+	})
+	if err != nil {
+		t.Fatalf("post failed: %v", err)
+	}
+	call := fake.calls("chat.postMessage")[0]
+	for _, form := range []string{"attachments", "blocks"} {
+		var decoded interface{}
+		if err := json.Unmarshal([]byte(call.Form.Get(form)), &decoded); err != nil {
+			t.Fatalf("posted invalid %s: %v", form, err)
+		}
+		found := false
+		mapStrings(decoded, func(s string) string {
+			found = found || broadcastPattern.MatchString(s)
+			return s
+		})
+		if found {
+			t.Errorf("%s still mention the channel: %s", form, call.Form.Get(form))
+		}
+	}
+}
+
+func TestFilterBroadcastJSONKeepsOtherContent(t *testing.T) {
+	tests := []struct {
+		name string
+		raw  string
+		want string
+	}{
+		{
+			name: "untouched without a mention",
+			raw:  `{"text":"a \u003c b \u0026\u0026 c","n":12345678901234567890}`,
+			want: `{"text":"a \u003c b \u0026\u0026 c","n":12345678901234567890}`,
+		},
+		{
+			name: "escapes and numbers survive stripping",
+			raw:  `{"n":12345678901234567890,"text":"<!here> say \"\\u003c\" & <b>","x":1.50}`,
+			want: `{"n":12345678901234567890,"text":" say \"\\u003c\" & <b>","x":1.50}`,
+		},
+		{
+			name: "nested arrays",
+			raw:  `{"blocks":[{"elements":["<!channel>","ok"]}]}`,
+			want: `{"blocks":[{"elements":["","ok"]}]}`,
+		},
+	}
+	b, _ := newTestBot(t, nil)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := b.filterBroadcastJSON([]byte(tt.raw), "U1")
+			if err != nil {
+				t.Fatalf("filter failed: %v", err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("got %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFilterBroadcastPayloadBlockedCommand(t *testing.T) {
+	cfg := testConfig(t)
+	cfg.BroadcastPolicy = broadcastBlock
+	b, _ := newTestBot(t, cfg)
+
+	_, err := b.filterBroadcastPayload(ephemeral("hi <!channel>").message(), "U1")
+	if !errors.Is(err, errBroadcastBlocked) {
+		t.Errorf("got error %v, want the response blocked", err)
+	}
+	payload, err := b.filterBroadcastPayload(ephemeral("hi <@U1>").message(), "U1")
+	if err != nil || !strings.Contains(string(payload.(json.RawMessage)), "hi <@U1>") {
+		t.Errorf("got %s, %v, want the response as it is", payload, err)
+	}
+}
@@ -0,0 +1,137 @@
+/*
+Copyright © 2024 Pavlo Tarasiuk <pasha.tarasyuk@gmail.com>
+*/
+package cmd
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/ptarasyuk/mavbot/internal/blocks"
+	"github.com/slack-go/slack"
+)
+
+// splitChannelArgs separates the leading channel references of text from the rest
+func (b *Bot) splitChannelArgs(text string) ([]string, string) {
+	var channels []string
+	rest := strings.TrimSpace(text)
+	for rest != "" {
+		word, remainder, _ := strings.Cut(rest, " ")
+		if !channelReference.MatchString(word) {
+			break
+		}
+		channels = append(channels, b.resolveChannel(parseChannelArg(word, "")))
+		rest = strings.TrimSpace(remainder)
+	}
+	return channels, rest
+}
+
+// publicChannels drops the private channels from channels unless MAVBOT_PRIVATE_BROADCAST allows
+// broadcasting to them, returning the kept and the dropped ones
+func (b *Bot) publicChannels(channels []string) ([]string, []string, error) {
+	if b.cfg.PrivateBroadcast {
+		return channels, nil, nil
+	}
+	var public, private []string
+	for _, channel := range channels {
+		isPrivate, err := b.isPrivateChannel(channel)
+		if err != nil {
+			return nil, nil, err
+		}
+		if isPrivate {
+			private = append(private, channel)
+		} else {
+			public = append(public, channel)
+		}
+	}
+	return public, private, nil
+}
+
+// channelRefs renders the channels as references, comma separated
+func channelRefs(channels []string) string {
+	refs := make([]string, 0, len(channels))
+	for _, channel := range channels {
+		refs = append(refs, channelRef(channel))
+	}
+	return strings.Join(refs, ", ")
+}
+
+// broadcastActionID is the action ID of the button posting a broadcast
+const broadcastActionID = "broadcast_confirm"
+
+// broadcastRequest is a broadcast waiting for the admin's confirmation
+type broadcastRequest struct {
+	Channels []string `json:"channels"`
+	Text     string   `json:"text"`
+}
+
+// handleBroadcast previews the broadcast of the text to every channel given: /broadcast #channel... <text>.
+// It is only posted once the admin confirms it with the button of the preview.
+func (b *Bot) handleBroadcast(command slack.SlashCommand) (*SlashResponse, error) {
+	channels, text := b.splitChannelArgs(command.Text)
+	if len(channels) == 0 || text == "" {
+		return ephemeral(usageOf("/broadcast")), nil
+	}
+	channels, private, err := b.publicChannels(channels)
+	if err != nil {
+		return nil, err
+	}
+	skipped := ""
+	if len(private) > 0 {
+		skipped = fmt.Sprintf("Broadcasts to private channels are off, skipping %s\n", channelRefs(private))
+	}
+	if len(channels) == 0 {
+		return ephemeral(skipped + "That leaves no channel to broadcast to"), nil
+	}
+
+	where := channelRefs(channels)
+	button, err := b.confirmButton(broadcastActionID, "Post", broadcastRequest{Channels: channels, Text: text},
+		"Post the broadcast?", fmt.Sprintf("The message goes to %d channels right away.", len(channels)))
+	if err != nil {
+		return ephemeral(fmt.Sprintf("Sorry, %v", err)), nil
+	}
+	preview := skipped + fmt.Sprintf("This goes to %s:\n%s", where, "> "+strings.ReplaceAll(text, "\n", "\n> "))
+	return &SlashResponse{
+		ResponseType: slack.ResponseTypeEphemeral,
+		Text:         preview,
+		Blocks:       []slack.Block{blocks.Section(preview), blocks.ActionsRow(button)},
+	}, nil
+}
+
+// broadcast posts the confirmed broadcast to every channel. A channel that fails doesn't stop
+// the others, the admin gets the outcome per channel in place of the preview.
+func (b *Bot) broadcast(interaction slack.InteractionCallback, action *slack.BlockAction) error {
+	if !b.isAdmin(interaction.User.ID) {
+		return nil
+	}
+	var request broadcastRequest
+	if err := b.signer.decode(action.Value, &request); err != nil {
+		return err
+	}
+
+	var result multiResult
+	for _, channel := range request.Channels {
+		_, err := b.postMessage(outboundMessage{
+			Channel:  channel,
+			Invoker:  interaction.User.ID,
+			Priority: priorityHigh,
+			Text:     request.Text,
+		})
+		result.add(channel, err)
+	}
+	b.replaceInteractionMessage(interaction, result.format("Posted to", "channels", channelRef))
+	return nil
+}
+
+func init() {
+	registerConfirmedAction(broadcastActionID, (*Bot).broadcast)
+	registerSlashCommand(&slashCommand{
+		Name:        "/broadcast",
+		Description: "Post a message to several channels at once",
+		Usage:       "#channel [#channel...] <text>",
+		Example:     "/broadcast #general #random The office is closed on Friday",
+		Category:    categoryAdmin,
+		AdminOnly:   true,
+		Handler:     (*Bot).handleBroadcast,
+	})
+}
@@ -0,0 +1,56 @@
+/*
+Copyright © 2024 Pavlo Tarasiuk <pasha.tarasyuk@gmail.com>
+*/
+package cmd
+
+import "sync"
+
+// cache is a string keyed map safe for concurrent use.
+// It keeps the results of Slack API lookups that rarely change.
+type cache[V any] struct {
+	mu    sync.RWMutex
+	items map[string]V
+}
+
+// newCache creates an empty cache
+func newCache[V any]() *cache[V] {
+	return &cache[V]{items: make(map[string]V)}
+}
+
+// get returns the cached value for key and whether it was present
+func (c *cache[V]) get(key string) (V, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	v, ok := c.items[key]
+	return v, ok
+}
+
+// set stores value under key
+func (c *cache[V]) set(key string, value V) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.items[key] = value
+}
+
+// delete removes key from the cache
+func (c *cache[V]) delete(key string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.items, key)
+}
+
+// len returns the number of cached entries
+func (c *cache[V]) len() int {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return len(c.items)
+}
+
+// purge removes every entry and returns how many there were
+func (c *cache[V]) purge() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	n := len(c.items)
+	c.items = make(map[string]V)
+	return n
+}
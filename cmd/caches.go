@@ -0,0 +1,96 @@
+/*
+Copyright © 2024 Pavlo Tarasiuk <pasha.tarasyuk@gmail.com>
+*/
+package cmd
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/slack-go/slack"
+)
+
+// purgeable is what /cache needs of a cache
+type purgeable interface {
+	len() int
+	purge() int
+}
+
+// namedCaches returns the bot's caches by the name /cache knows them by
+func (b *Bot) namedCaches() map[string]purgeable {
+	return map[string]purgeable{
+		"emoji":         b.emoji,
+		"events":        b.events,
+		"commands":      b.commands,
+		"results":       b.results,
+		"users":         b.users,
+		"channels":      b.channels,
+		"channel-names": b.channelNames,
+		"permalinks":    b.permalinks,
+		"usergroups":    b.groupMembership,
+		"group-handles": b.groupHandles,
+	}
+}
+
+// cacheNames returns the names of the caches, sorted
+func cacheNames(caches map[string]purgeable) []string {
+	names := make([]string, 0, len(caches))
+	for name := range caches {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// handleCache reports cache sizes or purges caches: /cache stats | /cache purge <name|all>
+func (b *Bot) handleCache(command slack.SlashCommand) (*SlashResponse, error) {
+	usage := usageOf("/cache")
+	caches := b.namedCaches()
+	args := strings.Fields(command.Text)
+	if len(args) == 0 {
+		return ephemeral(usage), nil
+	}
+
+	switch args[0] {
+	case "stats":
+		var stats strings.Builder
+		stats.WriteString("*Cache entries*\n")
+		for _, name := range cacheNames(caches) {
+			fmt.Fprintf(&stats, "%s: %d\n", name, caches[name].len())
+		}
+		fmt.Fprintf(&stats, "Event deduplication: %d hits, %d misses, %d evictions\n",
+			b.metrics.get(metricDedupHits), b.metrics.get(metricDedupMisses), b.metrics.get(metricDedupEvictions))
+		return ephemeral(stats.String()), nil
+
+	case "purge":
+		if len(args) != 2 {
+			return ephemeral(usage), nil
+		}
+		if args[1] == "all" {
+			total := 0
+			for _, c := range caches {
+				total += c.purge()
+			}
+			return ephemeral(fmt.Sprintf("Purged %d entries from every cache", total)), nil
+		}
+		c, ok := caches[args[1]]
+		if !ok {
+			return ephemeral(fmt.Sprintf("Unknown cache %q, the caches are: %s", args[1], strings.Join(cacheNames(caches), ", "))), nil
+		}
+		return ephemeral(fmt.Sprintf("Purged %d entries from %s", c.purge(), args[1])), nil
+	}
+	return ephemeral(usage), nil
+}
+
+func init() {
+	registerSlashCommand(&slashCommand{
+		Name:        "/cache",
+		Description: "Show the sizes of MAVBot's caches or purge them",
+		Usage:       "stats | purge <name|all>",
+		Example:     "/cache purge users",
+		Category:    categoryAdmin,
+		AdminOnly:   true,
+		Handler:     (*Bot).handleCache,
+	})
+}
@@ -0,0 +1,96 @@
+/*
+Copyright © 2024 Pavlo Tarasiuk <pasha.tarasyuk@gmail.com>
+*/
+package cmd
+
+import (
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/slack-go/slack"
+)
+
+// newCachedBot creates a bot with two users, a channel and a permalink cached
+func newCachedBot(t *testing.T) *Bot {
+	b, _ := newTestBot(t, nil)
+	b.users.set("U1", &slack.User{ID: "U1"})
+	b.users.set("U2", &slack.User{ID: "U2"})
+	b.channels.set("C1", &slack.Channel{GroupConversation: slack.GroupConversation{Name: "general"}})
+	b.permalinks.set("C1/1712345678.000100", "https://example.slack.com/archives/C1/p1712345678000100")
+	return b
+}
+
+// cacheCommand runs /cache with the text
+func cacheCommand(t *testing.T, b *Bot, text string) string {
+	t.Helper()
+	response, err := b.handleCache(slack.SlashCommand{Command: "/cache", Text: text, UserID: "U0ADMIN", ChannelID: "C1"})
+	if err != nil {
+		t.Fatalf("/cache %s failed: %v", text, err)
+	}
+	return response.Text
+}
+
+func TestCacheStats(t *testing.T) {
+	b := newCachedBot(t)
+	stats := strings.Split(cacheCommand(t, b, "stats"), "\n")
+	for _, want := range []string{"users: 2", "channels: 1", "permalinks: 1", "channel-names: 0"} {
+		if !containsString(stats, want) {
+			t.Errorf("the stats don't show %q:\n%s", want, strings.Join(stats, "\n"))
+		}
+	}
+}
+
+func TestCachePurge(t *testing.T) {
+	tests := []struct {
+		text string
+		want string
+		// users, channels and permalinks are the entries left in those caches
+		users, channels, permalinks int
+	}{
+		{text: "purge users", want: "Purged 2 entries from users", channels: 1, permalinks: 1},
+		{text: "purge permalinks", want: "Purged 1 entries from permalinks", users: 2, channels: 1},
+		{text: "purge all", want: "Purged 4 entries from every cache"},
+		{text: "purge sessions", want: `Unknown cache "sessions", the caches are: channel-names, channels,`, users: 2, channels: 1, permalinks: 1},
+		{text: "purge", want: usageOf("/cache"), users: 2, channels: 1, permalinks: 1},
+		{text: "", want: usageOf("/cache"), users: 2, channels: 1, permalinks: 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.text, func(t *testing.T) {
+			b := newCachedBot(t)
+			if got := cacheCommand(t, b, tt.text); !strings.HasPrefix(got, tt.want) {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+			if b.users.len() != tt.users || b.channels.len() != tt.channels || b.permalinks.len() != tt.permalinks {
+				t.Errorf("left %d users, %d channels and %d permalinks, want %d, %d and %d",
+					b.users.len(), b.channels.len(), b.permalinks.len(), tt.users, tt.channels, tt.permalinks)
+			}
+		})
+	}
+}
+
+func TestCachePurgeIsSafeWhileInUse(t *testing.T) {
+	c := newCache[string]()
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 1000; j++ {
+				c.set("key", "value")
+				c.get("key")
+			}
+		}()
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				c.purge()
+			}
+		}()
+	}
+	wg.Wait()
+	c.purge()
+	if c.len() != 0 {
+		t.Errorf("%d entries are left after a purge", c.len())
+	}
+}
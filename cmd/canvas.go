@@ -0,0 +1,140 @@
+/*
+Copyright © 2024 Pavlo Tarasiuk <pasha.tarasyuk@gmail.com>
+*/
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/slack-go/slack"
+)
+
+// canvasTimeout bounds the Web API calls of a single append
+const canvasTimeout = 10 * time.Second
+
+// canvasAPI is the part of the canvases API the bot uses, slack-go doesn't cover it yet
+type canvasAPI interface {
+	// channelCanvas returns the ID of the channel's canvas, empty when it has none
+	channelCanvas(ctx context.Context, channelID string) (string, error)
+	// createChannelCanvas creates the channel's canvas with markdown as its content
+	createChannelCanvas(ctx context.Context, channelID, markdown string) (string, error)
+	// appendToCanvas adds markdown at the end of the canvas
+	appendToCanvas(ctx context.Context, canvasID, markdown string) error
+}
+
+// canvasDocument is the document_content of canvas requests
+type canvasDocument struct {
+	Type     string `json:"type"`
+	Markdown string `json:"markdown"`
+}
+
+// channelCanvas implements canvasAPI, slack.Channel has no field for the canvas of a channel
+func (w *webAPI) channelCanvas(ctx context.Context, channelID string) (string, error) {
+	var resp struct {
+		Channel struct {
+			Properties struct {
+				Canvas struct {
+					FileID string `json:"file_id"`
+				} `json:"canvas"`
+			} `json:"properties"`
+		} `json:"channel"`
+	}
+	if err := w.call(ctx, "conversations.info", url.Values{"channel": {channelID}}, &resp); err != nil {
+		return "", err
+	}
+	return resp.Channel.Properties.Canvas.FileID, nil
+}
+
+// createChannelCanvas implements canvasAPI
+func (w *webAPI) createChannelCanvas(ctx context.Context, channelID, markdown string) (string, error) {
+	content, err := json.Marshal(canvasDocument{Type: "markdown", Markdown: markdown})
+	if err != nil {
+		return "", err
+	}
+	var resp struct {
+		CanvasID string `json:"canvas_id"`
+	}
+	values := url.Values{"channel_id": {channelID}, "document_content": {string(content)}}
+	if err := w.call(ctx, "conversations.canvases.create", values, &resp); err != nil {
+		return "", err
+	}
+	return resp.CanvasID, nil
+}
+
+// appendToCanvas implements canvasAPI
+func (w *webAPI) appendToCanvas(ctx context.Context, canvasID, markdown string) error {
+	changes, err := json.Marshal([]interface{}{map[string]interface{}{
+		"operation":        "insert_at_end",
+		"document_content": canvasDocument{Type: "markdown", Markdown: markdown},
+	}})
+	if err != nil {
+		return err
+	}
+	return w.call(ctx, "canvases.edit", url.Values{"canvas_id": {canvasID}, "changes": {string(changes)}}, nil)
+}
+
+// appendToChannelCanvas adds markdown to the channel's canvas, creating the canvas when the
+// channel has none yet. It reports whether the canvas was created.
+func (b *Bot) appendToChannelCanvas(ctx context.Context, channelID, markdown string) (bool, error) {
+	canvasID, err := b.canvases.channelCanvas(ctx, channelID)
+	if err != nil {
+		return false, err
+	}
+	if canvasID == "" {
+		_, err := b.canvases.createChannelCanvas(ctx, channelID, markdown)
+		var apiErr *webAPIError
+		if !errors.As(err, &apiErr) || apiErr.Code != "channel_canvas_already_exists" {
+			return err == nil, err
+		}
+		// Someone else created it in the meantime, append to theirs
+		if canvasID, err = b.canvases.channelCanvas(ctx, channelID); err != nil {
+			return false, err
+		}
+	}
+	return false, b.canvases.appendToCanvas(ctx, canvasID, markdown)
+}
+
+// handleCanvasAppend logs text in the channel's canvas: /canvas-append <text>
+func (b *Bot) handleCanvasAppend(command slack.SlashCommand) (*SlashResponse, error) {
+	if !b.cfg.CanvasAppend {
+		return ephemeral("Appending to canvases is turned off, set MAVBOT_CANVAS_APPEND to enable it"), nil
+	}
+	text := strings.TrimSpace(command.Text)
+	if text == "" {
+		return ephemeral(usageOf("/canvas-append")), nil
+	}
+
+	entry := fmt.Sprintf("- **%s** ![](@%s): %s\n",
+		b.formatTime(b.now(), b.userTimezone(command.UserID)), command.UserID, text)
+	ctx, cancel := context.WithTimeout(context.Background(), canvasTimeout)
+	defer cancel()
+	created, err := b.appendToChannelCanvas(ctx, command.ChannelID, entry)
+	var apiErr *webAPIError
+	if errors.As(err, &apiErr) && apiErr.Code == "missing_scope" {
+		return ephemeral(fmt.Sprintf("MAVBot is missing the %s scope needed to edit canvases", apiErr.Needed)), nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to append to the canvas: %w", err)
+	}
+	if created {
+		return ephemeral("Created the channel canvas with your entry"), nil
+	}
+	return ephemeral("Added your entry to the channel canvas"), nil
+}
+
+func init() {
+	registerSlashCommand(&slashCommand{
+		Name:        "/canvas-append",
+		Description: "Log text in the channel's canvas, creating the canvas if needed",
+		Usage:       "<text>",
+		Example:     "/canvas-append We go with PostgreSQL for the new service",
+		Category:    categoryGeneral,
+		Handler:     (*Bot).handleCanvasAppend,
+	})
+}
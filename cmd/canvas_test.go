@@ -0,0 +1,150 @@
+/*
+Copyright © 2024 Pavlo Tarasiuk <pasha.tarasyuk@gmail.com>
+*/
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/slack-go/slack"
+)
+
+// newCanvasBot creates a bot with /canvas-append enabled
+func newCanvasBot(t *testing.T) (*Bot, *fakeSlack) {
+	cfg := testConfig(t)
+	cfg.CanvasAppend = true
+	return newTestBot(t, cfg)
+}
+
+// answerCanvas answers conversations.info with the channel's canvas, none when canvasID is empty
+func answerCanvas(fake *fakeSlack, canvasID string) {
+	fake.answer("conversations.info",
+		fmt.Sprintf(`{"ok":true,"channel":{"id":"C1","properties":{"canvas":{"file_id":%q}}}}`, canvasID))
+}
+
+// canvasAppend runs /canvas-append with the text in C1
+func canvasAppend(t *testing.T, b *Bot, text string) string {
+	t.Helper()
+	resp, err := b.handleCanvasAppend(slack.SlashCommand{Command: "/canvas-append", Text: text, UserID: "U1", ChannelID: "C1"})
+	if err != nil {
+		t.Fatalf("handleCanvasAppend() error = %v", err)
+	}
+	return resp.Text
+}
+
+func TestCanvasAppendToExistingCanvas(t *testing.T) {
+	b, fake := newCanvasBot(t)
+	answerCanvas(fake, "F1")
+
+	if got, want := canvasAppend(t, b, "We go with PostgreSQL"), "Added your entry to the channel canvas"; got != want {
+		t.Errorf("response = %q, want %q", got, want)
+	}
+	if calls := fake.calls("conversations.canvases.create"); len(calls) != 0 {
+		t.Errorf("created a canvas although the channel has one")
+	}
+	edits := fake.calls("canvases.edit")
+	if len(edits) != 1 {
+		t.Fatalf("got %d canvases.edit calls, want 1", len(edits))
+	}
+	if got := edits[0].Form.Get("canvas_id"); got != "F1" {
+		t.Errorf("canvas_id = %q, want F1", got)
+	}
+	var changes []struct {
+		Operation       string         `json:"operation"`
+		DocumentContent canvasDocument `json:"document_content"`
+	}
+	if err := json.Unmarshal([]byte(edits[0].Form.Get("changes")), &changes); err != nil {
+		t.Fatalf("failed to decode changes: %v", err)
+	}
+	if len(changes) != 1 || changes[0].Operation != "insert_at_end" || changes[0].DocumentContent.Type != "markdown" {
+		t.Fatalf("changes = %+v, want a single markdown insert_at_end", changes)
+	}
+	if entry := changes[0].DocumentContent.Markdown; !strings.Contains(entry, "![](@U1): We go with PostgreSQL") {
+		t.Errorf("entry = %q, want the user and the text", entry)
+	}
+}
+
+func TestCanvasAppendCreatesMissingCanvas(t *testing.T) {
+	b, fake := newCanvasBot(t)
+	answerCanvas(fake, "")
+	fake.answer("conversations.canvases.create", `{"ok":true,"canvas_id":"F2"}`)
+
+	if got, want := canvasAppend(t, b, "Release on Friday"), "Created the channel canvas with your entry"; got != want {
+		t.Errorf("response = %q, want %q", got, want)
+	}
+	creates := fake.calls("conversations.canvases.create")
+	if len(creates) != 1 {
+		t.Fatalf("got %d conversations.canvases.create calls, want 1", len(creates))
+	}
+	if got := creates[0].Form.Get("channel_id"); got != "C1" {
+		t.Errorf("channel_id = %q, want C1", got)
+	}
+	if content := creates[0].Form.Get("document_content"); !strings.Contains(content, "Release on Friday") {
+		t.Errorf("document_content = %q, want the entry", content)
+	}
+	if calls := fake.calls("canvases.edit"); len(calls) != 0 {
+		t.Errorf("edited the canvas it created with the entry")
+	}
+}
+
+func TestCanvasAppendCreatedMeanwhile(t *testing.T) {
+	b, fake := newCanvasBot(t)
+	// The canvas shows up between looking it up and creating it
+	var lookups int32
+	fake.handle("conversations.info", func(w http.ResponseWriter, r *http.Request) {
+		canvasID := ""
+		if atomic.AddInt32(&lookups, 1) > 1 {
+			canvasID = "F3"
+		}
+		fmt.Fprintf(w, `{"ok":true,"channel":{"id":"C1","properties":{"canvas":{"file_id":%q}}}}`, canvasID)
+	})
+	fake.answer("conversations.canvases.create", `{"ok":false,"error":"channel_canvas_already_exists"}`)
+
+	if got, want := canvasAppend(t, b, "Release on Friday"), "Added your entry to the channel canvas"; got != want {
+		t.Errorf("response = %q, want %q", got, want)
+	}
+	edits := fake.calls("canvases.edit")
+	if len(edits) != 1 || edits[0].Form.Get("canvas_id") != "F3" {
+		t.Errorf("canvases.edit calls = %+v, want one for F3", edits)
+	}
+}
+
+func TestCanvasAppendErrors(t *testing.T) {
+	t.Run("turned off", func(t *testing.T) {
+		b, fake := newTestBot(t, nil)
+		if got := canvasAppend(t, b, "text"); !strings.Contains(got, "MAVBOT_CANVAS_APPEND") {
+			t.Errorf("response = %q, want how to enable it", got)
+		}
+		if calls := fake.calls("conversations.info", "canvases.edit"); len(calls) != 0 {
+			t.Errorf("called Slack while turned off: %+v", calls)
+		}
+	})
+	t.Run("no text", func(t *testing.T) {
+		b, _ := newCanvasBot(t)
+		if got, want := canvasAppend(t, b, "  "), usageOf("/canvas-append"); got != want {
+			t.Errorf("response = %q, want %q", got, want)
+		}
+	})
+	t.Run("missing scope", func(t *testing.T) {
+		b, fake := newCanvasBot(t)
+		answerCanvas(fake, "F1")
+		fake.answer("canvases.edit", `{"ok":false,"error":"missing_scope","needed":"canvases:write"}`)
+		if got, want := canvasAppend(t, b, "text"), "MAVBot is missing the canvases:write scope needed to edit canvases"; got != want {
+			t.Errorf("response = %q, want %q", got, want)
+		}
+	})
+	t.Run("failed edit", func(t *testing.T) {
+		b, fake := newCanvasBot(t)
+		answerCanvas(fake, "F1")
+		fake.answer("canvases.edit", `{"ok":false,"error":"canvas_editing_failed"}`)
+		_, err := b.handleCanvasAppend(slack.SlashCommand{Command: "/canvas-append", Text: "text", UserID: "U1", ChannelID: "C1"})
+		if err == nil || !strings.Contains(err.Error(), "canvas_editing_failed") {
+			t.Errorf("handleCanvasAppend() error = %v, want canvas_editing_failed", err)
+		}
+	})
+}
@@ -0,0 +1,143 @@
+/*
+Copyright © 2024 Pavlo Tarasiuk <pasha.tarasyuk@gmail.com>
+*/
+package cmd
+
+import (
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/slack-go/slack"
+	"github.com/slack-go/slack/slackevents"
+)
+
+// channelContext is what handlers and templates know about the channel they respond in
+type channelContext struct {
+	ID      string
+	Name    string
+	Topic   string
+	Purpose string
+}
+
+// channelInfo returns the conversation, asking Slack only the first time it is needed
+func (b *Bot) channelInfo(channelID string) (*slack.Channel, error) {
+	if channel, ok := b.channels.get(channelID); ok {
+		return channel, nil
+	}
+	channel, err := b.api().GetConversationInfo(&slack.GetConversationInfoInput{ChannelID: channelID})
+	if err != nil {
+		return nil, err
+	}
+	b.channels.set(channelID, channel)
+	b.channelNames.set(channel.Name, channelID)
+	return channel, nil
+}
+
+// isPrivateChannel reports whether only invited members can see the channel: private channels,
+// group DMs and DMs, which are known by their ID without asking Slack
+func (b *Bot) isPrivateChannel(channelID string) (bool, error) {
+	if strings.HasPrefix(channelID, "D") {
+		return true, nil
+	}
+	channel, err := b.channelInfo(channelID)
+	if err != nil {
+		return false, fmt.Errorf("failed to get info of channel %s: %w", channelID, err)
+	}
+	return channel.IsPrivate || channel.IsIM || channel.IsMpIM, nil
+}
+
+// validPrivateResponseType reports whether responseType can be the default in private channels,
+// empty meaning no change
+func validPrivateResponseType(responseType string) bool {
+	switch responseType {
+	case "", slack.ResponseTypeEphemeral, slack.ResponseTypeInChannel:
+		return true
+	}
+	return false
+}
+
+// defaultResponseType returns who sees a command's response by default in the channel, def unless
+// MAVBOT_PRIVATE_RESPONSE_TYPE changes it for private channels. Not knowing keeps def.
+func (b *Bot) defaultResponseType(channelID, def string) string {
+	if b.cfg.PrivateResponseType == "" {
+		return def
+	}
+	private, err := b.isPrivateChannel(channelID)
+	if err != nil {
+		log.Println(err)
+		return def
+	}
+	if private {
+		return b.cfg.PrivateResponseType
+	}
+	return def
+}
+
+// resolveChannel turns a #name reference into the channel's ID when the name is known,
+// anything else is returned as it is
+func (b *Bot) resolveChannel(ref string) string {
+	name, ok := strings.CutPrefix(ref, "#")
+	if !ok {
+		return ref
+	}
+	if id, ok := b.channelNames.get(name); ok {
+		return id
+	}
+	return ref
+}
+
+// renameChannel updates the caches after the channel was renamed
+func (b *Bot) renameChannel(channelID, name string) {
+	if channel, ok := b.channels.get(channelID); ok {
+		b.channelNames.delete(channel.Name)
+		// The cached channel may be in use, so it is replaced rather than changed
+		renamed := *channel
+		renamed.Name = name
+		b.channels.set(channelID, &renamed)
+	}
+	b.channelNames.set(name, channelID)
+}
+
+// autoJoin joins a newly created channel when its name matches MAVBOT_AUTO_JOIN. Failing to
+// join only gets logged, the channel works without the bot.
+func (b *Bot) autoJoin(channel slackevents.ChannelCreatedInfo) {
+	if b.cfg.AutoJoin == nil || !b.cfg.AutoJoin.MatchString(channel.Name) {
+		return
+	}
+	_, warning, _, err := b.api().JoinConversation(channel.ID)
+	code, _ := slackErrorCode(err)
+	switch {
+	case err == nil && warning == "already_in_channel", code == "already_in_channel":
+		log.Printf("Already in the new channel #%s\n", channel.Name)
+	case code == "is_archived":
+		log.Printf("Not joining the new channel #%s, it is archived already\n", channel.Name)
+	case err != nil:
+		log.Printf("failed to join the new channel #%s: %v\n", channel.Name, err)
+	default:
+		log.Printf("Joined the new channel #%s\n", channel.Name)
+	}
+}
+
+// forgetChannel drops the channel from the caches, e.g. after it was deleted
+func (b *Bot) forgetChannel(channelID string) {
+	if channel, ok := b.channels.get(channelID); ok {
+		b.channelNames.delete(channel.Name)
+		b.channels.delete(channelID)
+	}
+}
+
+// channelContext describes the channel for templates.
+// When Slack can't be asked only the ID is known, which is enough to reply with the generic messages.
+func (b *Bot) channelContext(channelID string) channelContext {
+	ctx := channelContext{ID: channelID}
+	channel, err := b.channelInfo(channelID)
+	if err != nil {
+		log.Printf("failed to get info of channel %s: %v\n", channelID, err)
+		return ctx
+	}
+	ctx.Name = channel.Name
+	ctx.Topic = channel.Topic.Value
+	ctx.Purpose = channel.Purpose.Value
+	return ctx
+}
@@ -0,0 +1,303 @@
+/*
+Copyright © 2024 Pavlo Tarasiuk <pasha.tarasyuk@gmail.com>
+*/
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/slack-go/slack"
+	"github.com/slack-go/slack/slackevents"
+)
+
+// answerChannel makes the fake Slack describe channel C1 with the topic and purpose
+func answerChannel(fake *fakeSlack, name, topic, purpose string) {
+	fake.answer("conversations.info", `{"ok":true,"channel":{"id":"C1","name":"`+name+`","topic":{"value":"`+topic+`"},"purpose":{"value":"`+purpose+`"}}}`)
+}
+
+func TestChannelContextIsFetchedOnce(t *testing.T) {
+	b, fake := newTestBot(t, nil)
+	answerChannel(fake, "help-desk", "Customer support", "Ask anything")
+
+	for i := 0; i < 2; i++ {
+		got := b.channelContext("C1")
+		want := channelContext{ID: "C1", Name: "help-desk", Topic: "Customer support", Purpose: "Ask anything"}
+		if got != want {
+			t.Fatalf("got %+v, want %+v", got, want)
+		}
+	}
+	if calls := fake.calls("conversations.info"); len(calls) != 1 {
+		t.Errorf("asked Slack %d times, want the channel cached", len(calls))
+	}
+}
+
+func TestChannelContextFallsBackToTheID(t *testing.T) {
+	b, fake := newTestBot(t, nil)
+	fake.answer("conversations.info", `{"ok":false,"error":"channel_not_found"}`)
+
+	if got := b.channelContext("C1"); got != (channelContext{ID: "C1"}) {
+		t.Errorf("got %+v, want only the ID", got)
+	}
+}
+
+func TestGreetingFollowsTheChannelTopic(t *testing.T) {
+	tests := []struct {
+		name    string
+		channel string
+		topic   string
+		purpose string
+		want    string
+	}{
+		{name: "support topic", channel: "general", topic: "Customer Support", want: "sorry you're having trouble"},
+		{name: "support purpose", channel: "general", purpose: "support for MAV users", want: "sorry you're having trouble"},
+		{name: "support name", channel: "support", want: "sorry you're having trouble"},
+		{name: "other channel", channel: "random", topic: "Cats", want: "Hello "},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b, fake := newTestBot(t, nil)
+			fake.answer("users.info", `{"ok":true,"user":{"id":"U1","name":"pasha","real_name":"Pasha"}}`)
+			answerChannel(fake, tt.channel, tt.topic, tt.purpose)
+
+			data := b.mentionData(&slack.User{ID: "U1", Name: "pasha"}, "C1")
+			if data.Channel.Topic != tt.topic || data.Channel.Purpose != tt.purpose {
+				t.Errorf("template data has channel %+v", data.Channel)
+			}
+
+			err := b.handleAppMentionEvent(&slackevents.AppMentionEvent{User: "U1", Channel: "C1", Text: "<@UBOT> hello", TimeStamp: "1712345678.000100"})
+			if err != nil {
+				t.Fatalf("mention failed: %v", err)
+			}
+			calls := fake.calls("chat.postMessage")
+			if len(calls) != 1 {
+				t.Fatalf("got %d posts, want a greeting", len(calls))
+			}
+			var attachments []slack.Attachment
+			if err := json.Unmarshal([]byte(calls[0].Form.Get("attachments")), &attachments); err != nil || len(attachments) != 1 {
+				t.Fatalf("posted attachments %s", calls[0].Form.Get("attachments"))
+			}
+			if !strings.Contains(attachments[0].Text, tt.want) {
+				t.Errorf("greeted with %q, want it to contain %q", attachments[0].Text, tt.want)
+			}
+			if tt.want == "Hello " && strings.Contains(attachments[0].Text, "trouble") {
+				t.Errorf("greeted %q with the support greeting", tt.channel)
+			}
+		})
+	}
+}
+
+func TestChannelEventsKeepTheCachesFresh(t *testing.T) {
+	b, fake := newTestBot(t, nil)
+	answerChannel(fake, "help-desk", "Customer support", "")
+	if _, err := b.channelInfo("C1"); err != nil {
+		t.Fatal(err)
+	}
+	if got := b.resolveChannel("#help-desk"); got != "C1" {
+		t.Fatalf("resolved #help-desk to %q before the rename", got)
+	}
+
+	rename := callbackEvent("channel_rename", &slackevents.ChannelRenameEvent{Channel: slackevents.ChannelRenameInfo{ID: "C1", Name: "support"}})
+	if err := b.handleEventMessage(context.Background(), rename); err != nil {
+		t.Fatalf("rename failed: %v", err)
+	}
+	if got := b.resolveChannel("#support"); got != "C1" {
+		t.Errorf("resolved the new name to %q, want C1", got)
+	}
+	if got := b.resolveChannel("#help-desk"); got != "#help-desk" {
+		t.Errorf("the old name still resolves to %q", got)
+	}
+	if got := b.channelContext("C1"); got.Name != "support" || got.Topic != "Customer support" {
+		t.Errorf("the cached channel is %+v, want it renamed", got)
+	}
+	if calls := fake.calls("conversations.info"); len(calls) != 1 {
+		t.Errorf("asked Slack %d times, want the rename applied to the cache", len(calls))
+	}
+
+	private := callbackEvent("group_rename", &slackevents.GroupRenameEvent{Channel: slackevents.GroupRenameInfo{ID: "G1", Name: "secret"}})
+	if err := b.handleEventMessage(context.Background(), private); err != nil {
+		t.Fatalf("rename failed: %v", err)
+	}
+	if got := b.resolveChannel("#secret"); got != "G1" {
+		t.Errorf("resolved the renamed private channel to %q, want G1", got)
+	}
+
+	deleted := callbackEvent("channel_deleted", &slackevents.ChannelDeletedEvent{Channel: "C1"})
+	if err := b.handleEventMessage(context.Background(), deleted); err != nil {
+		t.Fatalf("delete failed: %v", err)
+	}
+	if got := b.resolveChannel("#support"); got != "#support" {
+		t.Errorf("the deleted channel still resolves to %q", got)
+	}
+}
+
+func TestAutoJoinNewChannels(t *testing.T) {
+	tests := []struct {
+		name    string
+		pattern string
+		channel string
+		// answer is the answer to conversations.join, empty for the default
+		answer   string
+		wantJoin bool
+		wantLog  string
+	}{
+		{name: "matching", pattern: "^help-", channel: "help-billing", wantJoin: true, wantLog: "Joined the new channel #help-billing"},
+		{name: "not matching", pattern: "^help-", channel: "random-fun"},
+		{name: "no pattern", channel: "help-billing"},
+		{
+			name: "already in channel", pattern: "^help-", channel: "help-billing",
+			answer:   `{"ok":true,"channel":{"id":"C9"},"warning":"already_in_channel"}`,
+			wantJoin: true, wantLog: "Already in the new channel #help-billing",
+		},
+		{
+			name: "already in channel error", pattern: "^help-", channel: "help-billing",
+			answer:   `{"ok":false,"error":"already_in_channel"}`,
+			wantJoin: true, wantLog: "Already in the new channel #help-billing",
+		},
+		{
+			name: "archived", pattern: "^help-", channel: "help-billing",
+			answer:   `{"ok":false,"error":"is_archived"}`,
+			wantJoin: true, wantLog: "Not joining the new channel #help-billing, it is archived already",
+		},
+		{
+			name: "failure", pattern: "^help-", channel: "help-billing",
+			answer:   `{"ok":false,"error":"missing_scope"}`,
+			wantJoin: true, wantLog: "failed to join the new channel #help-billing: missing_scope",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			logs := captureLog(t)
+			cfg := testConfig(t)
+			if tt.pattern != "" {
+				cfg.AutoJoin = regexp.MustCompile(tt.pattern)
+			}
+			b, fake := newTestBot(t, cfg)
+			if tt.answer != "" {
+				fake.answer("conversations.join", tt.answer)
+			}
+
+			event := &slackevents.ChannelCreatedEvent{Channel: slackevents.ChannelCreatedInfo{ID: "C9", Name: tt.channel}}
+			if err := b.dispatchEvent(callbackEvent("channel_created", event)); err != nil {
+				t.Fatalf("channel_created failed: %v", err)
+			}
+			joins := fake.calls("conversations.join")
+			if (len(joins) == 1) != tt.wantJoin || len(joins) > 1 {
+				t.Fatalf("joined %d times, want a join %t", len(joins), tt.wantJoin)
+			}
+			if tt.wantJoin && joins[0].Form.Get("channel") != "C9" {
+				t.Errorf("joined %q, want C9", joins[0].Form.Get("channel"))
+			}
+			if !strings.Contains(logs.String(), tt.wantLog) {
+				t.Errorf("logs = %q, want %q", logs.String(), tt.wantLog)
+			}
+		})
+	}
+}
+
+func TestAutoJoinConfig(t *testing.T) {
+	t.Setenv("MAVBOT_AUTO_JOIN", "^help-(")
+	if _, err := loadConfigWith(noSecrets{}); err == nil {
+		t.Errorf("an invalid MAVBOT_AUTO_JOIN was accepted")
+	}
+	t.Setenv("MAVBOT_AUTO_JOIN", "^help-")
+	cfg, err := loadConfigWith(noSecrets{})
+	if err != nil || cfg.AutoJoin == nil || !cfg.AutoJoin.MatchString("help-desk") {
+		t.Errorf("loadConfig() = %v, %v, want the pattern compiled", cfg.AutoJoin, err)
+	}
+}
+
+// answerPrivacy makes the fake Slack describe the channels as private or public, any other
+// channel isn't found
+func answerPrivacy(fake *fakeSlack, private map[string]bool) {
+	fake.handle("conversations.info", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		channel := r.FormValue("channel")
+		isPrivate, ok := private[channel]
+		if !ok {
+			fmt.Fprint(w, `{"ok":false,"error":"channel_not_found"}`)
+			return
+		}
+		fmt.Fprintf(w, `{"ok":true,"channel":{"id":%q,"is_private":%t}}`, channel, isPrivate)
+	})
+}
+
+func TestIsPrivateChannel(t *testing.T) {
+	b, fake := newTestBot(t, nil)
+	answerPrivacy(fake, map[string]bool{"C1": false, "G1": true})
+	tests := []struct {
+		channel string
+		private bool
+		wantErr bool
+	}{
+		{channel: "C1"},
+		{channel: "G1", private: true},
+		{channel: "D1", private: true},
+		{channel: "C404", wantErr: true},
+	}
+	for _, tt := range tests {
+		private, err := b.isPrivateChannel(tt.channel)
+		if (err != nil) != tt.wantErr || private != tt.private {
+			t.Errorf("isPrivateChannel(%s) = %t, %v, want %t, error %t", tt.channel, private, err, tt.private, tt.wantErr)
+		}
+	}
+	b.isPrivateChannel("G1")
+	// DMs are known by their ID and the rest is asked once
+	if got := len(fake.calls("conversations.info")); got != 3 {
+		t.Errorf("asked Slack %d times, want once per channel other than the DM", got)
+	}
+}
+
+func TestPrivateChannelResponseType(t *testing.T) {
+	captureLog(t)
+	tests := []struct {
+		name       string
+		configured string
+		channel    string
+		text       string
+		want       string
+	}{
+		{name: "unconfigured private", channel: "G1", want: slack.ResponseTypeInChannel},
+		{name: "private", configured: slack.ResponseTypeEphemeral, channel: "G1", want: slack.ResponseTypeEphemeral},
+		{name: "DM", configured: slack.ResponseTypeEphemeral, channel: "D1", want: slack.ResponseTypeEphemeral},
+		{name: "public", configured: slack.ResponseTypeEphemeral, channel: "C1", want: slack.ResponseTypeInChannel},
+		{name: "unknown", configured: slack.ResponseTypeEphemeral, channel: "C404", want: slack.ResponseTypeInChannel},
+		{name: "flag wins", configured: slack.ResponseTypeEphemeral, channel: "G1", text: "--in-channel", want: slack.ResponseTypeInChannel},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := testConfig(t)
+			cfg.PrivateResponseType = tt.configured
+			b, fake := newTestBot(t, cfg)
+			answerPrivacy(fake, map[string]bool{"C1": false, "G1": true})
+			resp, err := b.handleHelloCommand(slack.SlashCommand{Command: "/hello", Text: strings.TrimSpace(tt.text + " hi"), UserID: "U1", UserName: "pasha", ChannelID: tt.channel})
+			if err != nil {
+				t.Fatalf("/hello failed: %v", err)
+			}
+			if resp.ResponseType != tt.want {
+				t.Errorf("answered %s, want %s", resp.ResponseType, tt.want)
+			}
+		})
+	}
+}
+
+func TestPrivateChannelConfig(t *testing.T) {
+	cfg, err := loadConfigWith(noSecrets{})
+	if err != nil || !cfg.PrivateBroadcast || cfg.PrivateResponseType != "" {
+		t.Fatalf("loadConfig() = %t, %q, %v, want private channels treated like the rest by default", cfg.PrivateBroadcast, cfg.PrivateResponseType, err)
+	}
+	t.Setenv("MAVBOT_PRIVATE_BROADCAST", "false")
+	t.Setenv("MAVBOT_PRIVATE_RESPONSE_TYPE", "ephemeral")
+	if cfg, err = loadConfigWith(noSecrets{}); err != nil || cfg.PrivateBroadcast || cfg.PrivateResponseType != slack.ResponseTypeEphemeral {
+		t.Errorf("loadConfig() = %t, %q, %v, want the configured behavior", cfg.PrivateBroadcast, cfg.PrivateResponseType, err)
+	}
+	t.Setenv("MAVBOT_PRIVATE_RESPONSE_TYPE", "everyone")
+	if _, err := loadConfigWith(noSecrets{}); err == nil {
+		t.Errorf("an invalid MAVBOT_PRIVATE_RESPONSE_TYPE was accepted")
+	}
+}
@@ -0,0 +1,173 @@
+/*
+Copyright © 2024 Pavlo Tarasiuk <pasha.tarasyuk@gmail.com>
+*/
+package cmd
+
+import (
+	"strings"
+	"time"
+
+	"github.com/slack-go/slack"
+)
+
+// slashHandler takes care of a slash command and returns the payload to acknowledge it with
+type slashHandler func(b *Bot, command slack.SlashCommand) (*SlashResponse, error)
+
+// slashCommand describes a slash command the bot responds to
+type slashCommand struct {
+	// Name is the command as typed in Slack, e.g. "/hello"
+	Name string
+	// Description is a one line summary of what the command does
+	Description string
+	// Usage shows the arguments the command takes after its Flags, e.g. "[n]", empty when it takes none
+	Usage string
+	// Flags are the flags the command takes ahead of its arguments, documented along with Usage
+	Flags []commandFlag
+	// Example is a typical invocation shown in /help
+	Example string
+	// Category groups the command in /help, one of the category constants
+	Category string
+	// AdminOnly restricts the command to the users listed in MAVBOT_ADMINS
+	AdminOnly bool
+	// DebugOnly hides the command from /help and unless MAVBOT_DEBUG is set, from users altogether
+	DebugOnly bool
+	// DMRoute decides where the reply goes when the command is invoked in a direct message
+	DMRoute int
+	// MaxConcurrent caps the invocations of the command running at once, 0 means no limit
+	MaxConcurrent int
+	// Format is the format responses are rendered in, one of the format constants, the handler's
+	// own when empty
+	Format string
+	// CacheTTL is how long a response is reused for the same arguments in the same channel, for
+	// commands whose response doesn't depend on who asks. 0 runs the handler every time.
+	CacheTTL time.Duration
+	// When are the conditions under which the command runs, none means always
+	When []predicate
+	// Handler is called for every invocation of the command
+	Handler slashHandler
+
+	// running is the semaphore enforcing MaxConcurrent
+	running chan struct{}
+}
+
+// invoke calls the handler unless MaxConcurrent invocations are already running, in which
+// case it fails with a *rateLimitedError
+func (c *slashCommand) invoke(b *Bot, command slack.SlashCommand) (response *SlashResponse, err error) {
+	if c.running != nil {
+		select {
+		case c.running <- struct{}{}:
+			defer func() {
+				// Work the handler left running in the background keeps the slot until it is done
+				if response != nil && response.done != nil {
+					go func(done <-chan struct{}) {
+						<-done
+						<-c.running
+					}(response.done)
+					return
+				}
+				<-c.running
+			}()
+		default:
+			return nil, &rateLimitedError{}
+		}
+	}
+	return c.Handler(b, command)
+}
+
+// slashCommands is the registry of slash commands keyed by name
+var slashCommands = map[string]*slashCommand{}
+
+// registerSlashCommand adds the command to the registry, replacing any command with the same name
+func registerSlashCommand(command *slashCommand) {
+	if command.MaxConcurrent > 0 {
+		command.running = make(chan struct{}, command.MaxConcurrent)
+	}
+	slashCommands[command.Name] = command
+}
+
+// SlashResponse is what a slash command handler answers with, a nil response acknowledges
+// the command without a message
+type SlashResponse struct {
+	// ResponseType is slack.ResponseTypeEphemeral or slack.ResponseTypeInChannel
+	ResponseType string
+	Text         string
+	Attachments  []slack.Attachment
+	Blocks       []slack.Block
+	// Posts are messages posted to other channels or threads along with the response, which
+	// reports how each of them went
+	Posts []targetedPost
+
+	// done is closed when the work the response announces as loading has finished, nil otherwise
+	done <-chan struct{}
+}
+
+// message converts the response into the payload the command is acknowledged with
+func (r *SlashResponse) message() slack.Msg {
+	msg := slack.Msg{
+		ResponseType: r.ResponseType,
+		Text:         r.Text,
+		Attachments:  r.Attachments,
+	}
+	if len(r.Blocks) > 0 {
+		msg.Blocks = slack.Blocks{BlockSet: r.Blocks}
+	}
+	return msg
+}
+
+// ephemeral builds a slash command response only the invoking user can see
+func ephemeral(text string) *SlashResponse {
+	return &SlashResponse{
+		ResponseType: slack.ResponseTypeEphemeral,
+		Text:         text,
+	}
+}
+
+// slashPayload builds a slash command response with attachments, responseType being
+// slack.ResponseTypeEphemeral or slack.ResponseTypeInChannel
+func slashPayload(responseType string, attachments ...slack.Attachment) *SlashResponse {
+	return &SlashResponse{
+		ResponseType: responseType,
+		Attachments:  attachments,
+	}
+}
+
+// responseTypeFlags choose who sees the response of a command
+var responseTypeFlags = []commandFlag{
+	{Name: "--ephemeral", Short: "-e", Group: "visibility"},
+	{Name: "--in-channel", Short: "-c", Group: "visibility"},
+}
+
+// parseResponseType lets the invoking user choose who sees the response with a leading
+// --ephemeral or --in-channel flag. It returns the chosen type, def without a flag, and the
+// text that follows the flag. Text that doesn't parse is all text.
+func parseResponseType(text, def string) (string, string) {
+	flags, rest, err := parseFlags(text, responseTypeFlags)
+	switch {
+	case err != nil:
+		return def, strings.TrimSpace(text)
+	case flags["--ephemeral"]:
+		return slack.ResponseTypeEphemeral, rest
+	case flags["--in-channel"]:
+		return slack.ResponseTypeInChannel, rest
+	}
+	return def, rest
+}
+
+func init() {
+	registerSlashCommand(&slashCommand{
+		Name:        "/hello",
+		Description: "Greet the bot and have it echo your text",
+		Usage:       "[text]",
+		Flags:       responseTypeFlags,
+		Example:     "/hello how are you?",
+		Category:    categoryGeneral,
+		DMRoute:     dmPostToDefault,
+		Handler:     (*Bot).handleHelloCommand,
+	})
+	registerSlashCommand(&slashCommand{
+		Name:        "/was-this-article-useful",
+		Description: "Ask whether the article was helpful",
+		Category:    categorySurveys,
+		Handler:     (*Bot).handleIsArticleGood,
+	})
+}
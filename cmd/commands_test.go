@@ -0,0 +1,271 @@
+/*
+Copyright © 2024 Pavlo Tarasiuk <pasha.tarasyuk@gmail.com>
+*/
+package cmd
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/slack-go/slack"
+)
+
+func TestParseResponseType(t *testing.T) {
+	tests := []struct {
+		text     string
+		def      string
+		wantType string
+		wantText string
+	}{
+		{"hi there", slack.ResponseTypeInChannel, slack.ResponseTypeInChannel, "hi there"},
+		{"--ephemeral hi there", slack.ResponseTypeInChannel, slack.ResponseTypeEphemeral, "hi there"},
+		{"-e hi", slack.ResponseTypeInChannel, slack.ResponseTypeEphemeral, "hi"},
+		{"--in-channel hi", slack.ResponseTypeEphemeral, slack.ResponseTypeInChannel, "hi"},
+		{"-c", slack.ResponseTypeEphemeral, slack.ResponseTypeInChannel, ""},
+		{"-e -c hi", slack.ResponseTypeInChannel, slack.ResponseTypeInChannel, "-e -c hi"},
+		{"hi --ephemeral", slack.ResponseTypeInChannel, slack.ResponseTypeInChannel, "hi --ephemeral"},
+	}
+	for _, tt := range tests {
+		gotType, gotText := parseResponseType(tt.text, tt.def)
+		if gotType != tt.wantType || gotText != tt.wantText {
+			t.Errorf("parseResponseType(%q, %q) = %q, %q, want %q, %q", tt.text, tt.def, gotType, gotText, tt.wantType, tt.wantText)
+		}
+	}
+}
+
+func TestHelloResponseType(t *testing.T) {
+	tests := []struct {
+		text string
+		want string
+	}{
+		{text: "hi", want: slack.ResponseTypeInChannel},
+		{text: "--ephemeral hi", want: slack.ResponseTypeEphemeral},
+		{text: "--in-channel hi", want: slack.ResponseTypeInChannel},
+	}
+	for _, tt := range tests {
+		t.Run(tt.text, func(t *testing.T) {
+			b, _ := newTestBot(t, nil)
+
+			payload, err := b.handleSlashCommand(slack.SlashCommand{Command: "/hello", Text: tt.text, UserID: "U1", UserName: "pasha", ChannelID: "C1"})
+			if err != nil {
+				t.Fatalf("/hello failed: %v", err)
+			}
+			var msg slack.Msg
+			if err := json.Unmarshal(payload.(json.RawMessage), &msg); err != nil {
+				t.Fatalf("invalid payload %s: %v", payload, err)
+			}
+			if msg.ResponseType != tt.want {
+				t.Errorf("got response_type %q, want %q", msg.ResponseType, tt.want)
+			}
+			if len(msg.Attachments) != 1 || !strings.Contains(msg.Attachments[0].Text, "You said: hi") {
+				t.Errorf("got attachments %+v, want the greeting without the flag", msg.Attachments)
+			}
+		})
+	}
+}
+
+func TestSlashResponseMessage(t *testing.T) {
+	section := slack.NewSectionBlock(slack.NewTextBlockObject(slack.MarkdownType, "*hi*", false, false), nil, nil)
+	tests := []struct {
+		name     string
+		response *SlashResponse
+		// want holds the fields of the JSON the command is acknowledged with, a null field must be absent
+		want string
+	}{
+		{
+			name:     "ephemeral text",
+			response: ephemeral("psst"),
+			want:     `{"response_type":"ephemeral","text":"psst","attachments":null,"blocks":null}`,
+		},
+		{
+			name:     "attachments in the channel",
+			response: slashPayload(slack.ResponseTypeInChannel, slack.Attachment{Text: "hi", Color: "#3d3d3d"}),
+			want:     `{"response_type":"in_channel","text":null,"attachments":[{"color":"#3d3d3d","text":"hi","blocks":null}],"blocks":null}`,
+		},
+		{
+			name:     "blocks",
+			response: &SlashResponse{ResponseType: slack.ResponseTypeEphemeral, Text: "hi", Blocks: []slack.Block{section}},
+			want:     `{"response_type":"ephemeral","text":"hi","attachments":null,"blocks":[{"type":"section","text":{"type":"mrkdwn","text":"*hi*"}}]}`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			registerTestCommand(t, &slashCommand{
+				Name:    "/test-response",
+				Handler: func(*Bot, slack.SlashCommand) (*SlashResponse, error) { return tt.response, nil },
+			})
+			b, _ := newTestBot(t, nil)
+
+			payload, err := b.handleSlashCommand(slack.SlashCommand{Command: "/test-response", UserID: "U1", ChannelID: "C1"})
+			if err != nil {
+				t.Fatalf("command failed: %v", err)
+			}
+			var got, want map[string]interface{}
+			if err := json.Unmarshal(payload.(json.RawMessage), &got); err != nil {
+				t.Fatalf("invalid payload %s", payload)
+			}
+			json.Unmarshal([]byte(tt.want), &want)
+			for field, value := range want {
+				if !reflect.DeepEqual(got[field], value) {
+					t.Errorf("acknowledged with %s %v, want %v", field, got[field], value)
+				}
+			}
+		})
+	}
+}
+
+func TestNilSlashResponseAcknowledgesOnly(t *testing.T) {
+	registerTestCommand(t, &slashCommand{
+		Name:    "/test-quiet",
+		Handler: func(*Bot, slack.SlashCommand) (*SlashResponse, error) { return nil, nil },
+	})
+	b, _ := newTestBot(t, nil)
+	payload, err := b.handleSlashCommand(slack.SlashCommand{Command: "/test-quiet", UserID: "U1", ChannelID: "C1"})
+	if err != nil || payload != nil {
+		t.Errorf("got %v, %v, want a bare acknowledgement", payload, err)
+	}
+}
+
+func TestConcurrencyLimit(t *testing.T) {
+	release := make(chan struct{})
+	started := make(chan struct{})
+	registerTestCommand(t, &slashCommand{
+		Name:          "/test-heavy",
+		MaxConcurrent: 2,
+		Handler: func(*Bot, slack.SlashCommand) (*SlashResponse, error) {
+			started <- struct{}{}
+			<-release
+			return ephemeral("done"), nil
+		},
+	})
+	b, _ := newTestBot(t, nil)
+	invoke := func() (*SlashResponse, error) {
+		return b.dispatchSlashCommand(slack.SlashCommand{Command: "/test-heavy", UserID: "U1", ChannelID: "C1"})
+	}
+
+	results := make(chan *SlashResponse, 2)
+	for i := 0; i < 2; i++ {
+		go func() {
+			response, _ := invoke()
+			results <- response
+		}()
+		<-started
+	}
+	// Both slots are taken, the third invocation is turned down right away
+	response, err := invoke()
+	if err != nil {
+		t.Fatalf("dispatch failed: %v", err)
+	}
+	if !strings.Contains(response.Text, "Slow down") || response.ResponseType == slack.ResponseTypeInChannel {
+		t.Errorf("the invocation over the limit got %+v, want the ephemeral rate limit message", response)
+	}
+
+	close(release)
+	for i := 0; i < 2; i++ {
+		if response := <-results; response == nil || response.Text != "done" {
+			t.Errorf("an invocation under the limit got %+v", response)
+		}
+	}
+	// The slots are free again
+	go func() { <-started }()
+	if response, _ := invoke(); response == nil || response.Text != "done" {
+		t.Errorf("the invocation after the others finished got %+v", response)
+	}
+}
+
+func TestConcurrencyLimitHoldsBackgroundWork(t *testing.T) {
+	done := make(chan struct{})
+	registerTestCommand(t, &slashCommand{
+		Name:          "/test-background",
+		MaxConcurrent: 1,
+		Handler: func(*Bot, slack.SlashCommand) (*SlashResponse, error) {
+			return &SlashResponse{Text: "working on it", done: done}, nil
+		},
+	})
+	b, _ := newTestBot(t, nil)
+	command := slack.SlashCommand{Command: "/test-background", UserID: "U1", ChannelID: "C1"}
+
+	if response, _ := b.dispatchSlashCommand(command); response.Text != "working on it" {
+		t.Fatalf("got %q", response.Text)
+	}
+	// The handler returned but its work still runs, so the slot stays taken
+	if response, _ := b.dispatchSlashCommand(command); !strings.Contains(response.Text, "Slow down") {
+		t.Errorf("got %q while the background work runs, want the rate limit message", response.Text)
+	}
+
+	close(done)
+	deadline := time.Now().Add(time.Second)
+	for {
+		response, _ := b.dispatchSlashCommand(command)
+		if response.Text == "working on it" {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("the slot wasn't freed after the background work finished")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestUnknownCommand(t *testing.T) {
+	tests := []struct {
+		name    string
+		message string
+		locale  string
+		want    string
+	}{
+		{name: "default", want: "Sorry, I don't know /nope, try /help to see what I can do"},
+		{name: "configured", message: "Unknown command {{.Text}}, try /help", want: "Unknown command /nope, try /help"},
+		{name: "user's language", locale: "uk", want: "Вибачте, я не знаю команди /nope, спробуйте /help, щоб побачити, що я вмію"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := testConfig(t)
+			cfg.UnknownCommandMessage = tt.message
+			b, _ := newTestBot(t, cfg)
+			if tt.locale != "" {
+				if err := b.store.Put(collectionPrefs, "U1", userPrefs{Locale: tt.locale}); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			payload, err := b.handleSlashCommand(slack.SlashCommand{Command: "/nope", Text: "please", UserID: "U1", ChannelID: "C1"})
+			if err != nil {
+				t.Fatalf("command failed: %v", err)
+			}
+			var got struct {
+				ResponseType string `json:"response_type"`
+				Text         string `json:"text"`
+			}
+			if err := json.Unmarshal(payload.(json.RawMessage), &got); err != nil {
+				t.Fatalf("invalid payload %s", payload)
+			}
+			if got.ResponseType != slack.ResponseTypeEphemeral || got.Text != tt.want {
+				t.Errorf("answered %s %q, want ephemeral %q", got.ResponseType, got.Text, tt.want)
+			}
+			if n := b.metrics.get(metricUnknownCommands); n != 1 {
+				t.Errorf("counted %d unknown commands, want 1", n)
+			}
+		})
+	}
+}
+
+func TestUnknownCommandLogging(t *testing.T) {
+	for _, enabled := range []bool{false, true} {
+		logs := captureLog(t)
+		cfg := testConfig(t)
+		cfg.LogUnknownCommands = enabled
+		b, _ := newTestBot(t, cfg)
+
+		if _, err := b.handleSlashCommand(slack.SlashCommand{Command: "/nope", Text: "please", UserID: "U1", ChannelID: "C1"}); err != nil {
+			t.Fatalf("command failed: %v", err)
+		}
+		logged := strings.Contains(logs.String(), `Unknown command /nope "please" from U1 in C1`)
+		if logged != enabled {
+			t.Errorf("with logging %t logged %t:\n%s", enabled, logged, logs)
+		}
+	}
+}
@@ -0,0 +1,554 @@
+/*
+Copyright © 2024 Pavlo Tarasiuk <pasha.tarasyuk@gmail.com>
+*/
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Config holds the runtime settings of MAVBot.
+// Every value is read from the environment, which may be populated from a .env file.
+// Tokens and secrets can be read from files instead, see SecretsDir.
+type Config struct {
+	// SecretsDir holds files named after the secret variables, e.g. SLACK_AUTH_TOKEN, read in place
+	// of the variables; a file named by e.g. SLACK_AUTH_TOKEN_FILE takes precedence (MAVBOT_SECRETS_DIR)
+	SecretsDir string
+
+	// BotToken is the bot user OAuth token (SLACK_AUTH_TOKEN)
+	BotToken string
+	// AppToken is the app-level token used by Socket Mode (SLACK_APP_TOKEN)
+	AppToken string
+
+	// ClientID and ClientSecret are the app credentials used to refresh a rotating bot token
+	// (MAVBOT_CLIENT_ID, MAVBOT_CLIENT_SECRET)
+	ClientID     string
+	ClientSecret string
+	// RefreshToken enables token rotation, it is only read until the bot stores a newer one (MAVBOT_REFRESH_TOKEN)
+	RefreshToken string
+	// PostingTokens are bot tokens of further apps in the workspace messages are spread across to
+	// stay under per-token rate limits, as TOKEN or TOKEN=WEIGHT; the bot's own token has weight 1
+	// (MAVBOT_POSTING_TOKENS)
+	PostingTokens string
+	// TokenRefreshMargin is how long before expiry the bot token is refreshed (MAVBOT_TOKEN_REFRESH_MARGIN)
+	TokenRefreshMargin time.Duration
+
+	// Environment names the deployment, e.g. staging; outside production it is appended
+	// to the bot's display name (MAVBOT_ENVIRONMENT)
+	Environment string
+	// DisplayName is the name the environment suffix is appended to (MAVBOT_DISPLAY_NAME)
+	DisplayName string
+
+	// StatusChannel receives operational notices about the bot itself (MAVBOT_STATUS_CHANNEL)
+	StatusChannel string
+	// ErrorChannel receives reports of failures inside handlers (MAVBOT_ERROR_CHANNEL)
+	ErrorChannel string
+	// DefaultChannel receives replies of commands invoked in a DM that have nowhere else to post (MAVBOT_DEFAULT_CHANNEL)
+	DefaultChannel string
+	// ShutdownNotice enables posting OfflineMessage to StatusChannel on shutdown (MAVBOT_SHUTDOWN_NOTICE)
+	ShutdownNotice bool
+	// OfflineMessage is the text posted when ShutdownNotice is enabled (MAVBOT_OFFLINE_MESSAGE)
+	OfflineMessage string
+	// MaintenanceMessage answers commands and mentions while the bot is under maintenance, see
+	// /maintenance (MAVBOT_MAINTENANCE_MESSAGE)
+	MaintenanceMessage string
+	// ShutdownTimeout bounds the work done on the way out (MAVBOT_SHUTDOWN_TIMEOUT)
+	ShutdownTimeout time.Duration
+	// HeartbeatInterval is how often the bot signals it is alive, 0 disables the heartbeat (MAVBOT_HEARTBEAT_INTERVAL)
+	HeartbeatInterval time.Duration
+	// HeartbeatURL is requested with every heartbeat, e.g. a cron monitor's check-in URL. Without one
+	// the heartbeat is posted to StatusChannel (MAVBOT_HEARTBEAT_URL).
+	HeartbeatURL string
+
+	// DataDir is where the file Store keeps its data (MAVBOT_DATA_DIR)
+	DataDir string
+	// StoreBackend is where the bot keeps its state: file, sqlite or postgres (MAVBOT_STORE)
+	StoreBackend string
+	// StoreDSN is the database of the sqlite and postgres backends, a file path for sqlite,
+	// mavbot.db in DataDir by default, a connection string for postgres. It may hold a password,
+	// so it is read like the other secrets (MAVBOT_STORE_DSN)
+	StoreDSN string
+
+	// AllowedChannels seeds the channel allowlist, empty enables the bot everywhere (MAVBOT_ALLOWED_CHANNELS).
+	// Once changed with /allow the persisted allowlist takes precedence.
+	AllowedChannels []string
+	// AutoJoin is the pattern of the names of new channels the bot joins right away, nil to join none
+	// (MAVBOT_AUTO_JOIN)
+	AutoJoin *regexp.Regexp
+
+	// Admins are the user IDs allowed to run admin commands, user groups given by handle (@oncall)
+	// or ID grant it to their members (MAVBOT_ADMINS)
+	Admins []string
+
+	// BroadcastPolicy is how @channel, @here and @everyone in outbound messages are treated:
+	// block, strip or allow-admins (MAVBOT_BROADCAST_POLICY)
+	BroadcastPolicy string
+	// PrivateBroadcast lets /broadcast post to private channels, whose members may not expect
+	// announcements meant for everyone (MAVBOT_PRIVATE_BROADCAST)
+	PrivateBroadcast bool
+	// PrivateResponseType is who sees command responses by default in private channels and DMs:
+	// ephemeral or in_channel, empty keeps each command's default (MAVBOT_PRIVATE_RESPONSE_TYPE)
+	PrivateResponseType string
+
+	// PinConfirmations makes the bot confirm in thread when it records a pinned message (MAVBOT_PIN_CONFIRMATIONS)
+	PinConfirmations bool
+
+	// OutboundPerMinute caps the messages the bot posts per minute, 0 means no limit (MAVBOT_OUTBOUND_PER_MINUTE)
+	OutboundPerMinute int
+	// OutboundQueueSize caps the messages waiting for the outbound limit, 0 means no limit (MAVBOT_OUTBOUND_QUEUE_SIZE)
+	OutboundQueueSize int
+	// RateLimitRetryWait is the longest wait a post rate-limited by Slack is retried after once,
+	// 0 disables the retry (MAVBOT_RATE_LIMIT_RETRY_WAIT)
+	RateLimitRetryWait time.Duration
+	// OverCapacityRetries is how many times a post Slack is over capacity for is repeated
+	// (MAVBOT_OVER_CAPACITY_RETRIES)
+	OverCapacityRetries int
+	// OverCapacityDelay is the wait before the first repeat, doubling with every further one
+	// (MAVBOT_OVER_CAPACITY_DELAY)
+	OverCapacityDelay time.Duration
+
+	// SlowThreshold is the duration after which Slack calls and handlers are reported as slow,
+	// 0 disables the reports (MAVBOT_SLOW_THRESHOLD)
+	SlowThreshold time.Duration
+	// ResponseTimeFooter adds how long the bot took to respond under its replies (MAVBOT_RESPONSE_TIME_FOOTER)
+	ResponseTimeFooter bool
+
+	// HTTPRetries is how many times a failed read-only Slack call is repeated (MAVBOT_HTTP_RETRIES)
+	HTTPRetries int
+	// HTTPRetryDelay is the wait before the first repeat, doubling with every further one (MAVBOT_HTTP_RETRY_DELAY)
+	HTTPRetryDelay time.Duration
+
+	// DefaultLocale is the language used for users who didn't choose one (MAVBOT_DEFAULT_LOCALE)
+	DefaultLocale string
+
+	// EventLog is the file incoming events are recorded to for replaying, empty disables recording (MAVBOT_EVENT_LOG)
+	EventLog string
+	// EventLogMaxSize is the size in bytes at which the event log is rotated (MAVBOT_EVENT_LOG_MAX_SIZE)
+	EventLogMaxSize int
+
+	// MentionRole is the minimum role a user needs for the bot to answer their mentions:
+	// everyone, member, admin or none (MAVBOT_MENTION_ROLE)
+	MentionRole string
+	// MentionUsers may mention the bot whatever their role (MAVBOT_MENTION_USERS)
+	MentionUsers []string
+	// MentionDeniedNotice tells users who may not mention the bot so with an ephemeral note
+	// instead of ignoring them (MAVBOT_MENTION_DENIED_NOTICE)
+	MentionDeniedNotice bool
+	// MentionFallback is how mentions that aren't a greeting are answered: offer, text, help or
+	// silent (MAVBOT_MENTION_FALLBACK)
+	MentionFallback string
+	// MentionFallbackText is the answer of the text fallback (MAVBOT_MENTION_FALLBACK_TEXT)
+	MentionFallbackText string
+	// AddressFormat is how replies address users: name, display, real or mention (MAVBOT_ADDRESS_FORMAT)
+	AddressFormat string
+	// LinkProcessor acts on the links in messages and mentions, posting a summary in thread: title
+	// fetches the title of their page, check reports whether they work, empty leaves them alone
+	// (MAVBOT_LINK_PROCESSOR)
+	LinkProcessor string
+	// GreetOncePerDay greets each user on their first hello of the day only, later ones get the
+	// fallback answer (MAVBOT_GREET_ONCE_PER_DAY)
+	GreetOncePerDay bool
+
+	// ChannelPerMinute caps the messages the bot posts to a single channel per minute, 0 means no limit.
+	// Excess messages are dropped (MAVBOT_CHANNEL_PER_MINUTE).
+	ChannelPerMinute int
+	// ChannelLimits override ChannelPerMinute for single channels, e.g. C123=5,C456=30 (MAVBOT_CHANNEL_LIMITS)
+	ChannelLimits map[string]int
+
+	// DetectLanguage makes the bot answer mentions in the language they are written in (MAVBOT_DETECT_LANGUAGE)
+	DetectLanguage bool
+
+	// MaxViewSubmission is the largest modal submission in bytes the bot processes, larger ones are
+	// rejected with an error shown in the modal (MAVBOT_MAX_VIEW_SUBMISSION)
+	MaxViewSubmission int
+
+	// WebhookURL receives a JSON summary of every processed event, empty disables it (MAVBOT_WEBHOOK_URL)
+	WebhookURL string
+	// WebhookSecret signs the webhook requests (MAVBOT_WEBHOOK_SECRET)
+	WebhookSecret string
+	// WebhookRetries is how many times a failed webhook delivery is repeated (MAVBOT_WEBHOOK_RETRIES)
+	WebhookRetries int
+
+	// SurveyDelay is how long after /ask-feedback --later the survey follows up (MAVBOT_SURVEY_DELAY)
+	SurveyDelay time.Duration
+
+	// ConversationTimeout is how long a step-by-step conversation like /setup waits for an answer (MAVBOT_CONVERSATION_TIMEOUT)
+	ConversationTimeout time.Duration
+
+	// DateFormat is the Go time layout dates are shown with (MAVBOT_DATE_FORMAT)
+	DateFormat string
+	// Timezone is used for users whose timezone is unknown, empty keeps the server's (MAVBOT_TIMEZONE)
+	Timezone string
+
+	// Features sets the initial state of feature flags, e.g. greetings=false (MAVBOT_FEATURES).
+	// Once toggled with /feature the persisted state takes precedence.
+	Features map[string]bool
+
+	// RateLimitMessage replaces the message users get when they hit a rate limit in the default
+	// language, a template that can refer to {{.Wait}} (MAVBOT_RATE_LIMIT_MESSAGE)
+	RateLimitMessage string
+
+	// CanvasAppend enables /canvas-append, which needs the canvases:write and channels:read
+	// scopes (MAVBOT_CANVAS_APPEND)
+	CanvasAppend bool
+
+	// MentionHours is the daily window the bot answers mentions in, e.g. 09:00-17:00 in
+	// MAVBOT_TIMEZONE, nil when it always does (MAVBOT_MENTION_HOURS)
+	MentionHours *timeWindow
+
+	// ActionSecret signs the context interactive messages carry, without it a key is generated
+	// and kept in the data directory (MAVBOT_ACTION_SECRET)
+	ActionSecret string
+
+	// UnknownCommandMessage replaces the answer to commands the bot doesn't know in the default
+	// language, a template that can refer to the command as {{.Text}} (MAVBOT_UNKNOWN_COMMAND_MESSAGE)
+	UnknownCommandMessage string
+	// LogUnknownCommands logs the commands users try that the bot doesn't know (MAVBOT_LOG_UNKNOWN_COMMANDS)
+	LogUnknownCommands bool
+	// Debug enables the commands for testing the bot's behaviour, like /simulate-ratelimit (MAVBOT_DEBUG)
+	Debug bool
+
+	// CatalogDir holds translations as <language>.json files mapping template names to
+	// templates, overriding the built-in ones; reloaded with /reload-i18n or SIGHUP (MAVBOT_CATALOG_DIR)
+	CatalogDir string
+
+	// SummaryTime is when the daily summary is posted to subscribed channels, in MAVBOT_TIMEZONE
+	// (MAVBOT_SUMMARY_TIME)
+	SummaryTime string
+
+	// DedupSize is how many recent event IDs are remembered to drop redelivered events (MAVBOT_DEDUP_SIZE)
+	DedupSize int
+	// DedupTTL is how long an event ID is remembered (MAVBOT_DEDUP_TTL)
+	DedupTTL time.Duration
+
+	// VoteEmoji lists the reactions that count as votes on the bot's messages, others are ignored
+	// (MAVBOT_VOTE_EMOJI)
+	VoteEmoji []string
+
+	// CommandDedupWindow is how long a slash command submitted again with the same text by the same
+	// user in the same channel is dropped, 0 runs every submission (MAVBOT_COMMAND_DEDUP_WINDOW)
+	CommandDedupWindow time.Duration
+	// CommandCacheTTLs override how long the responses of single commands are cached, 0 disables
+	// the cache of a command, e.g. /survey-recent=30s (MAVBOT_COMMAND_CACHE)
+	CommandCacheTTLs map[string]time.Duration
+
+	// InteractionRetries is how many times processing an interaction is tried again before it is
+	// given up and dead-lettered (MAVBOT_INTERACTION_RETRIES)
+	InteractionRetries int
+	// InteractionRetryDelay is the wait before trying an interaction again (MAVBOT_INTERACTION_RETRY_DELAY)
+	InteractionRetryDelay time.Duration
+	// DeadLetterFile receives the interactions given up on, in the event log format, so they can be
+	// replayed (MAVBOT_DEAD_LETTER_FILE, by default dead-letter.jsonl in MAVBOT_DATA_DIR)
+	DeadLetterFile string
+
+	// LogBuffer is how many of the latest log lines are kept for /logs, 0 keeps none (MAVBOT_LOG_BUFFER)
+	LogBuffer int
+
+	// EventTimeout is how long an Events API event may take to process before the bot stops
+	// waiting for it, 0 waits as long as it takes (MAVBOT_EVENT_TIMEOUT)
+	EventTimeout time.Duration
+	// EventTimeouts override EventTimeout for single event types, e.g. app_mention=10s
+	// (MAVBOT_EVENT_TIMEOUTS)
+	EventTimeouts map[string]time.Duration
+
+	// FieldOrder lists attachment field titles in the order they are shown (MAVBOT_FIELD_ORDER)
+	FieldOrder []string
+}
+
+// loadConfig builds the Config from the environment, applying defaults for unset values.
+// Secrets may also come from files, see fileSecrets.
+func loadConfig() (*Config, error) {
+	return loadConfigWith(fileSecrets{dir: os.Getenv("MAVBOT_SECRETS_DIR")})
+}
+
+// loadConfigWith builds the Config like loadConfig, looking the secrets up with the provider
+func loadConfigWith(secrets SecretProvider) (*Config, error) {
+	cfg := &Config{
+		SecretsDir:            os.Getenv("MAVBOT_SECRETS_DIR"),
+		Environment:           os.Getenv("MAVBOT_ENVIRONMENT"),
+		DisplayName:           envString("MAVBOT_DISPLAY_NAME", "MAVBot"),
+		StatusChannel:         os.Getenv("MAVBOT_STATUS_CHANNEL"),
+		ErrorChannel:          os.Getenv("MAVBOT_ERROR_CHANNEL"),
+		DefaultChannel:        os.Getenv("MAVBOT_DEFAULT_CHANNEL"),
+		OfflineMessage:        envString("MAVBOT_OFFLINE_MESSAGE", "MAVBot going offline for maintenance"),
+		HeartbeatURL:          os.Getenv("MAVBOT_HEARTBEAT_URL"),
+		MaintenanceMessage:    envString("MAVBOT_MAINTENANCE_MESSAGE", "MAVBot is under maintenance, please try again later"),
+		DataDir:               envString("MAVBOT_DATA_DIR", "data"),
+		StoreBackend:          envString("MAVBOT_STORE", storeFile),
+		AllowedChannels:       envList("MAVBOT_ALLOWED_CHANNELS", nil),
+		Admins:                envList("MAVBOT_ADMINS", nil),
+		BroadcastPolicy:       envString("MAVBOT_BROADCAST_POLICY", broadcastStrip),
+		PrivateResponseType:   os.Getenv("MAVBOT_PRIVATE_RESPONSE_TYPE"),
+		DefaultLocale:         envString("MAVBOT_DEFAULT_LOCALE", "en"),
+		FieldOrder:            envList("MAVBOT_FIELD_ORDER", defaultFieldOrder),
+		ClientID:              os.Getenv("MAVBOT_CLIENT_ID"),
+		EventLog:              os.Getenv("MAVBOT_EVENT_LOG"),
+		MentionRole:           envString("MAVBOT_MENTION_ROLE", mentionRoleEveryone),
+		MentionUsers:          envList("MAVBOT_MENTION_USERS", nil),
+		WebhookURL:            os.Getenv("MAVBOT_WEBHOOK_URL"),
+		DateFormat:            envString("MAVBOT_DATE_FORMAT", "2006-01-02 15:04:05"),
+		Timezone:              os.Getenv("MAVBOT_TIMEZONE"),
+		RateLimitMessage:      os.Getenv("MAVBOT_RATE_LIMIT_MESSAGE"),
+		UnknownCommandMessage: os.Getenv("MAVBOT_UNKNOWN_COMMAND_MESSAGE"),
+		CatalogDir:            os.Getenv("MAVBOT_CATALOG_DIR"),
+		SummaryTime:           envString("MAVBOT_SUMMARY_TIME", "09:00"),
+		VoteEmoji:             envList("MAVBOT_VOTE_EMOJI", []string{"+1", "-1"}),
+		MentionFallback:       envString("MAVBOT_MENTION_FALLBACK", mentionFallbackOffer),
+		MentionFallbackText:   os.Getenv("MAVBOT_MENTION_FALLBACK_TEXT"),
+		AddressFormat:         envString("MAVBOT_ADDRESS_FORMAT", addressName),
+		LinkProcessor:         os.Getenv("MAVBOT_LINK_PROCESSOR"),
+	}
+
+	if err := cfg.loadSecrets(secrets); err != nil {
+		return nil, err
+	}
+	if !validBroadcastPolicy(cfg.BroadcastPolicy) {
+		return nil, fmt.Errorf("invalid MAVBOT_BROADCAST_POLICY: %q", cfg.BroadcastPolicy)
+	}
+	if !validMentionRole(cfg.MentionRole) {
+		return nil, fmt.Errorf("invalid MAVBOT_MENTION_ROLE: %q", cfg.MentionRole)
+	}
+	if !validMentionFallback(cfg.MentionFallback) {
+		return nil, fmt.Errorf("invalid MAVBOT_MENTION_FALLBACK: %q", cfg.MentionFallback)
+	}
+	if cfg.MentionFallback == mentionFallbackText && cfg.MentionFallbackText == "" {
+		return nil, errors.New("MAVBOT_MENTION_FALLBACK_TEXT is required with MAVBOT_MENTION_FALLBACK=text")
+	}
+	if !validAddressFormat(cfg.AddressFormat) {
+		return nil, fmt.Errorf("invalid MAVBOT_ADDRESS_FORMAT: %q", cfg.AddressFormat)
+	}
+	if !validPrivateResponseType(cfg.PrivateResponseType) {
+		return nil, fmt.Errorf("invalid MAVBOT_PRIVATE_RESPONSE_TYPE: %q", cfg.PrivateResponseType)
+	}
+	if !validLinkProcessor(cfg.LinkProcessor) {
+		return nil, fmt.Errorf("invalid MAVBOT_LINK_PROCESSOR: %q", cfg.LinkProcessor)
+	}
+
+	var err error
+	if cfg.ShutdownNotice, err = envBool("MAVBOT_SHUTDOWN_NOTICE", false); err != nil {
+		return nil, err
+	}
+	if cfg.PrivateBroadcast, err = envBool("MAVBOT_PRIVATE_BROADCAST", true); err != nil {
+		return nil, err
+	}
+	if cfg.ShutdownTimeout, err = envDuration("MAVBOT_SHUTDOWN_TIMEOUT", 5*time.Second); err != nil {
+		return nil, err
+	}
+	if cfg.HeartbeatInterval, err = envDuration("MAVBOT_HEARTBEAT_INTERVAL", 0); err != nil {
+		return nil, err
+	}
+	if cfg.HeartbeatInterval > 0 && cfg.HeartbeatURL == "" && cfg.StatusChannel == "" {
+		return nil, errors.New("MAVBOT_HEARTBEAT_INTERVAL needs MAVBOT_HEARTBEAT_URL or MAVBOT_STATUS_CHANNEL")
+	}
+	if cfg.PinConfirmations, err = envBool("MAVBOT_PIN_CONFIRMATIONS", false); err != nil {
+		return nil, err
+	}
+	if cfg.OutboundPerMinute, err = envInt("MAVBOT_OUTBOUND_PER_MINUTE", 0); err != nil {
+		return nil, err
+	}
+	if cfg.OutboundQueueSize, err = envInt("MAVBOT_OUTBOUND_QUEUE_SIZE", 100); err != nil {
+		return nil, err
+	}
+	if cfg.RateLimitRetryWait, err = envDuration("MAVBOT_RATE_LIMIT_RETRY_WAIT", 3*time.Second); err != nil {
+		return nil, err
+	}
+	if cfg.OverCapacityRetries, err = envInt("MAVBOT_OVER_CAPACITY_RETRIES", 3); err != nil {
+		return nil, err
+	}
+	if cfg.OverCapacityDelay, err = envDuration("MAVBOT_OVER_CAPACITY_DELAY", time.Second); err != nil {
+		return nil, err
+	}
+	if cfg.SlowThreshold, err = envDuration("MAVBOT_SLOW_THRESHOLD", 2*time.Second); err != nil {
+		return nil, err
+	}
+	if cfg.ResponseTimeFooter, err = envBool("MAVBOT_RESPONSE_TIME_FOOTER", false); err != nil {
+		return nil, err
+	}
+	if cfg.TokenRefreshMargin, err = envDuration("MAVBOT_TOKEN_REFRESH_MARGIN", 10*time.Minute); err != nil {
+		return nil, err
+	}
+	if cfg.EventLogMaxSize, err = envInt("MAVBOT_EVENT_LOG_MAX_SIZE", 10<<20); err != nil {
+		return nil, err
+	}
+	if cfg.MentionDeniedNotice, err = envBool("MAVBOT_MENTION_DENIED_NOTICE", false); err != nil {
+		return nil, err
+	}
+	if cfg.GreetOncePerDay, err = envBool("MAVBOT_GREET_ONCE_PER_DAY", false); err != nil {
+		return nil, err
+	}
+	if cfg.ChannelPerMinute, err = envInt("MAVBOT_CHANNEL_PER_MINUTE", 0); err != nil {
+		return nil, err
+	}
+	if cfg.ChannelLimits, err = parseChannelLimits(envList("MAVBOT_CHANNEL_LIMITS", nil)); err != nil {
+		return nil, fmt.Errorf("invalid MAVBOT_CHANNEL_LIMITS: %w", err)
+	}
+	if cfg.DetectLanguage, err = envBool("MAVBOT_DETECT_LANGUAGE", false); err != nil {
+		return nil, err
+	}
+	if cfg.MaxViewSubmission, err = envInt("MAVBOT_MAX_VIEW_SUBMISSION", 256<<10); err != nil {
+		return nil, err
+	}
+	if cfg.HTTPRetries, err = envInt("MAVBOT_HTTP_RETRIES", 2); err != nil {
+		return nil, err
+	}
+	if cfg.HTTPRetryDelay, err = envDuration("MAVBOT_HTTP_RETRY_DELAY", 500*time.Millisecond); err != nil {
+		return nil, err
+	}
+	if cfg.WebhookRetries, err = envInt("MAVBOT_WEBHOOK_RETRIES", 3); err != nil {
+		return nil, err
+	}
+	if cfg.SurveyDelay, err = envDuration("MAVBOT_SURVEY_DELAY", 15*time.Minute); err != nil {
+		return nil, err
+	}
+	if cfg.ConversationTimeout, err = envDuration("MAVBOT_CONVERSATION_TIMEOUT", 5*time.Minute); err != nil {
+		return nil, err
+	}
+	if cfg.Features, err = parseFeatures(envList("MAVBOT_FEATURES", nil)); err != nil {
+		return nil, fmt.Errorf("invalid MAVBOT_FEATURES: %w", err)
+	}
+	if cfg.CanvasAppend, err = envBool("MAVBOT_CANVAS_APPEND", false); err != nil {
+		return nil, err
+	}
+	if pattern := os.Getenv("MAVBOT_AUTO_JOIN"); pattern != "" {
+		if cfg.AutoJoin, err = regexp.Compile(pattern); err != nil {
+			return nil, fmt.Errorf("invalid MAVBOT_AUTO_JOIN: %w", err)
+		}
+	}
+	if hours := os.Getenv("MAVBOT_MENTION_HOURS"); hours != "" {
+		window, err := parseTimeWindow(hours)
+		if err != nil {
+			return nil, err
+		}
+		cfg.MentionHours = &window
+	}
+	if cfg.LogUnknownCommands, err = envBool("MAVBOT_LOG_UNKNOWN_COMMANDS", false); err != nil {
+		return nil, err
+	}
+	if cfg.Debug, err = envBool("MAVBOT_DEBUG", false); err != nil {
+		return nil, err
+	}
+	if _, err := time.Parse("15:04", cfg.SummaryTime); err != nil {
+		return nil, fmt.Errorf("invalid MAVBOT_SUMMARY_TIME %q, expected e.g. 09:00", cfg.SummaryTime)
+	}
+	if cfg.DedupSize, err = envInt("MAVBOT_DEDUP_SIZE", 10000); err != nil {
+		return nil, err
+	}
+	if cfg.DedupSize < 1 {
+		return nil, fmt.Errorf("invalid MAVBOT_DEDUP_SIZE %d, it must be at least 1", cfg.DedupSize)
+	}
+	if cfg.DedupTTL, err = envDuration("MAVBOT_DEDUP_TTL", 10*time.Minute); err != nil {
+		return nil, err
+	}
+	if cfg.CommandDedupWindow, err = envDuration("MAVBOT_COMMAND_DEDUP_WINDOW", 2*time.Second); err != nil {
+		return nil, err
+	}
+	if cfg.CommandCacheTTLs, err = parseCacheTTLs(envList("MAVBOT_COMMAND_CACHE", nil)); err != nil {
+		return nil, err
+	}
+	if cfg.InteractionRetries, err = envInt("MAVBOT_INTERACTION_RETRIES", 2); err != nil {
+		return nil, err
+	}
+	if cfg.InteractionRetryDelay, err = envDuration("MAVBOT_INTERACTION_RETRY_DELAY", 500*time.Millisecond); err != nil {
+		return nil, err
+	}
+	cfg.DeadLetterFile = envString("MAVBOT_DEAD_LETTER_FILE", filepath.Join(cfg.DataDir, "dead-letter.jsonl"))
+	if !validStoreBackend(cfg.StoreBackend) {
+		return nil, fmt.Errorf("invalid MAVBOT_STORE: %q", cfg.StoreBackend)
+	}
+	if cfg.StoreBackend == storeSQLite && cfg.StoreDSN == "" {
+		cfg.StoreDSN = filepath.Join(cfg.DataDir, "mavbot.db")
+	}
+	if cfg.StoreBackend == storePostgres && cfg.StoreDSN == "" {
+		return nil, errors.New("MAVBOT_STORE_DSN is required with MAVBOT_STORE=postgres")
+	}
+	if _, err := parsePostingTokens(cfg.PostingTokens); err != nil {
+		return nil, fmt.Errorf("invalid MAVBOT_POSTING_TOKENS: %w", err)
+	}
+	if cfg.LogBuffer, err = envInt("MAVBOT_LOG_BUFFER", 500); err != nil {
+		return nil, err
+	}
+	if cfg.EventTimeout, err = envDuration("MAVBOT_EVENT_TIMEOUT", 30*time.Second); err != nil {
+		return nil, err
+	}
+	if cfg.EventTimeouts, err = parseEventTimeouts(envList("MAVBOT_EVENT_TIMEOUTS", nil)); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+// envString returns the value of the variable or def when it is unset or empty
+func envString(key, def string) string {
+	if v := os.Getenv(key); v != "" {
+		return v
+	}
+	return def
+}
+
+// envList splits a comma separated variable into trimmed, non-empty items, falling back to def when unset
+func envList(key string, def []string) []string {
+	v := os.Getenv(key)
+	if v == "" {
+		return def
+	}
+	return splitList(v)
+}
+
+// splitList splits a comma separated value into trimmed, non-empty items
+func splitList(v string) []string {
+	var items []string
+	for _, item := range strings.Split(v, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// envInt parses the variable as a non-negative integer, falling back to def when unset
+func envInt(key string, def int) (int, error) {
+	v := os.Getenv(key)
+	if v == "" {
+		return def, nil
+	}
+	n, err := strconv.Atoi(v)
+	if err != nil {
+		return 0, fmt.Errorf("invalid %s: %w", key, err)
+	}
+	if n < 0 {
+		return 0, fmt.Errorf("invalid %s: must not be negative", key)
+	}
+	return n, nil
+}
+
+// envBool parses the variable with strconv.ParseBool, falling back to def when unset
+func envBool(key string, def bool) (bool, error) {
+	v := os.Getenv(key)
+	if v == "" {
+		return def, nil
+	}
+	b, err := strconv.ParseBool(v)
+	if err != nil {
+		return false, fmt.Errorf("invalid %s: %w", key, err)
+	}
+	return b, nil
+}
+
+// envDuration parses the variable with time.ParseDuration, falling back to def when unset
+func envDuration(key string, def time.Duration) (time.Duration, error) {
+	v := os.Getenv(key)
+	if v == "" {
+		return def, nil
+	}
+	d, err := time.ParseDuration(v)
+	if err != nil {
+		return 0, fmt.Errorf("invalid %s: %w", key, err)
+	}
+	return d, nil
+}
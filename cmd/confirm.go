@@ -0,0 +1,65 @@
+/*
+Copyright © 2024 Pavlo Tarasiuk <pasha.tarasyuk@gmail.com>
+*/
+package cmd
+
+import (
+	"fmt"
+	"log"
+
+	"github.com/ptarasyuk/mavbot/internal/blocks"
+	"github.com/slack-go/slack"
+)
+
+// maxButtonValueLength is the longest value Slack accepts for a button
+const maxButtonValueLength = 2000
+
+// confirmedAction runs the action of a button guarded by withConfirm. Slack only sends the action
+// once the user confirmed it, a cancelled dialog never reaches the bot.
+type confirmedAction func(b *Bot, interaction slack.InteractionCallback, action *slack.BlockAction) error
+
+// confirmedActions are the confirmed actions by action ID
+var confirmedActions = map[string]confirmedAction{}
+
+// registerConfirmedAction makes the bot run handler for the button with actionID
+func registerConfirmedAction(actionID string, handler confirmedAction) {
+	confirmedActions[actionID] = handler
+}
+
+// withConfirm makes the button ask the user to confirm with a dialog before its action is sent
+func withConfirm(button *slack.ButtonBlockElement, title, text, confirm string) *slack.ButtonBlockElement {
+	button.Confirm = blocks.Confirm(title, text, confirm, "Cancel")
+	button.Style = slack.StyleDanger
+	return button
+}
+
+// confirmButton builds a button for the confirmed action with actionID, carrying v signed so
+// the action can't be tampered with on its way back
+func (b *Bot) confirmButton(actionID, label string, v interface{}, title, text string) (*slack.ButtonBlockElement, error) {
+	token, err := b.signer.encode(v)
+	if err != nil {
+		return nil, err
+	}
+	if len(token) > maxButtonValueLength {
+		return nil, fmt.Errorf("the action is too big to confirm, %d characters", len(token))
+	}
+	return withConfirm(blocks.Button(actionID, token, label), title, text, label), nil
+}
+
+// runConfirmedAction runs the confirmed action behind the button, if it is one
+func (b *Bot) runConfirmedAction(interaction slack.InteractionCallback, action *slack.BlockAction) error {
+	handler, ok := confirmedActions[action.ActionID]
+	if !ok {
+		return nil
+	}
+	return handler(b, interaction, action)
+}
+
+// replaceInteractionMessage replaces the message holding the button the user clicked with text.
+// It only logs failures, the action has been carried out and mustn't be repeated over them.
+func (b *Bot) replaceInteractionMessage(interaction slack.InteractionCallback, text string) {
+	msg := &slack.WebhookMessage{Text: text, ReplaceOriginal: true}
+	if err := slack.PostWebhookCustomHTTP(interaction.ResponseURL, b.httpClient, msg); err != nil {
+		log.Printf("failed to replace the message of a confirmed action: %v\n", err)
+	}
+}
@@ -0,0 +1,185 @@
+/*
+Copyright © 2024 Pavlo Tarasiuk <pasha.tarasyuk@gmail.com>
+*/
+package cmd
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/ptarasyuk/mavbot/internal/blocks"
+	"github.com/slack-go/slack"
+)
+
+func TestWithConfirm(t *testing.T) {
+	button := withConfirm(blocks.Button("delete", "1", "Delete"), "Delete the survey?", "The answers are *gone* for good.", "Delete")
+	if button.Style != slack.StyleDanger {
+		t.Errorf("style = %q, want danger", button.Style)
+	}
+	c := button.Confirm
+	if c == nil {
+		t.Fatalf("no confirm dialog attached")
+	}
+	if c.Title.Text != "Delete the survey?" || c.Text.Text != "The answers are *gone* for good." || c.Confirm.Text != "Delete" || c.Deny.Text != "Cancel" {
+		t.Errorf("dialog = %q, %q, %q, %q", c.Title.Text, c.Text.Text, c.Confirm.Text, c.Deny.Text)
+	}
+	if c.Title.Type != slack.PlainTextType || c.Text.Type != slack.MarkdownType {
+		t.Errorf("dialog title is %s and text %s, want plain_text and mrkdwn", c.Title.Type, c.Text.Type)
+	}
+}
+
+func TestConfirmButtonTooBig(t *testing.T) {
+	b, _ := newTestBot(t, nil)
+	_, err := b.confirmButton(broadcastActionID, "Post", broadcastRequest{Channels: []string{"C1"}, Text: strings.Repeat("a", maxButtonValueLength)}, "Post?", "Sure?")
+	if err == nil || !strings.Contains(err.Error(), "too big to confirm") {
+		t.Errorf("confirmButton() error = %v, want the action too big", err)
+	}
+}
+
+// broadcastButton runs /broadcast as the admin and returns the button of the preview
+func broadcastButton(t *testing.T, b *Bot, text string) *slack.ButtonBlockElement {
+	t.Helper()
+	resp, err := b.handleBroadcast(slack.SlashCommand{Command: "/broadcast", Text: text, UserID: "U0ADMIN", ChannelID: "C0"})
+	if err != nil {
+		t.Fatalf("/broadcast failed: %v", err)
+	}
+	if resp.ResponseType != slack.ResponseTypeEphemeral || len(resp.Blocks) != 2 {
+		t.Fatalf("answered %q with %d blocks, want the preview privately", resp.Text, len(resp.Blocks))
+	}
+	if err := validateBlocks(resp.Blocks); err != nil {
+		t.Errorf("invalid preview: %v", err)
+	}
+	row, ok := resp.Blocks[1].(*slack.ActionBlock)
+	if !ok || len(row.Elements.ElementSet) != 1 {
+		t.Fatalf("preview blocks = %+v, want a row with the button", resp.Blocks)
+	}
+	return row.Elements.ElementSet[0].(*slack.ButtonBlockElement)
+}
+
+// clickBroadcast sends the button's action as the user and returns the text the preview was
+// replaced with, empty when it wasn't
+func clickBroadcast(t *testing.T, b *Bot, fake *fakeSlack, userID, value string) (string, error) {
+	t.Helper()
+	before := len(fake.calls("respond"))
+	interaction := slack.InteractionCallback{
+		Type:        slack.InteractionTypeBlockActions,
+		User:        slack.User{ID: userID},
+		ResponseURL: fake.apiURL() + "respond",
+	}
+	interaction.ActionCallback.BlockActions = []*slack.BlockAction{{ActionID: broadcastActionID, BlockID: "b1", Value: value, ActionTs: "1712345678.000300"}}
+	_, err := b.handleInteractiveEvent(interaction, 0, map[string]bool{})
+	calls := fake.calls("respond")
+	if len(calls) == before {
+		return "", err
+	}
+	var replaced responsePayload
+	if jsonErr := json.Unmarshal(calls[len(calls)-1].Body, &replaced); jsonErr != nil {
+		t.Fatalf("invalid replacement %s", calls[len(calls)-1].Body)
+	}
+	if !replaced.ReplaceOriginal {
+		t.Errorf("the outcome didn't replace the preview")
+	}
+	return replaced.Text, err
+}
+
+func TestBroadcastPostsOnlyOnceConfirmed(t *testing.T) {
+	captureLog(t)
+	cfg := testConfig(t)
+	cfg.Admins = []string{"U0ADMIN"}
+	b, fake := newTestBot(t, cfg)
+	fake.answer("conversations.info", `{"ok":true,"channel":{"id":"C1","is_private":false}}`)
+
+	button := broadcastButton(t, b, "<#C1|general> <#C2> The office is closed on Friday")
+	if button.ActionID != broadcastActionID || button.Confirm == nil || button.Confirm.Deny.Text != "Cancel" {
+		t.Fatalf("button = %+v, want it to ask for confirmation", button)
+	}
+	if want := "The message goes to 2 channels right away."; button.Confirm.Text.Text != want {
+		t.Errorf("dialog says %q, want %q", button.Confirm.Text.Text, want)
+	}
+	// Slack only sends the action once the dialog is confirmed, until then nothing is posted
+	if got := len(fake.calls("chat.postMessage")); got != 0 {
+		t.Fatalf("posted %d messages before the confirmation", got)
+	}
+
+	// Someone else's click and a tampered button post nothing
+	if replaced, _ := clickBroadcast(t, b, fake, "U1", button.Value); replaced != "" {
+		t.Errorf("a non-admin's click replaced the preview with %q", replaced)
+	}
+	if _, err := clickBroadcast(t, b, fake, "U0ADMIN", button.Value+"x"); err == nil {
+		t.Errorf("a tampered button was accepted")
+	}
+	if got := len(fake.calls("chat.postMessage")); got != 0 {
+		t.Fatalf("posted %d messages without a valid confirmation", got)
+	}
+
+	replaced, err := clickBroadcast(t, b, fake, "U0ADMIN", button.Value)
+	if err != nil {
+		t.Fatalf("the confirmed broadcast failed: %v", err)
+	}
+	posts := fake.calls("chat.postMessage")
+	if len(posts) != 2 || posts[0].Form.Get("channel") != "C1" || posts[1].Form.Get("channel") != "C2" {
+		t.Fatalf("posted %d messages, want one to each of C1 and C2", len(posts))
+	}
+	for _, post := range posts {
+		if got := post.Form.Get("text"); got != "The office is closed on Friday" {
+			t.Errorf("posted %q, want the broadcast text", got)
+		}
+	}
+	if !strings.HasPrefix(replaced, "Posted to") {
+		t.Errorf("replaced the preview with %q, want the outcome", replaced)
+	}
+}
+
+func TestBroadcastUsage(t *testing.T) {
+	b, _ := newTestBot(t, nil)
+	for _, text := range []string{"", "<#C1>", "hello everyone"} {
+		resp, err := b.handleBroadcast(slack.SlashCommand{Command: "/broadcast", Text: text, UserID: "U0ADMIN"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if resp.Text != usageOf("/broadcast") || len(resp.Blocks) != 0 {
+			t.Errorf("/broadcast %s answered %q, want the usage", text, resp.Text)
+		}
+	}
+}
+
+func TestBroadcastSkipsPrivateChannels(t *testing.T) {
+	cfg := testConfig(t)
+	cfg.Admins = []string{"U0ADMIN"}
+	cfg.PrivateBroadcast = false
+	b, fake := newTestBot(t, cfg)
+	answerPrivacy(fake, map[string]bool{"C1": false, "G1": true})
+
+	button := broadcastButton(t, b, "<#C1> <#G1> The office is closed on Friday")
+	var request broadcastRequest
+	if err := b.signer.decode(button.Value, &request); err != nil {
+		t.Fatalf("invalid button: %v", err)
+	}
+	if strings.Join(request.Channels, ",") != "C1" {
+		t.Errorf("the broadcast goes to %v, want only the public channel", request.Channels)
+	}
+	if _, err := clickBroadcast(t, b, fake, "U0ADMIN", button.Value); err != nil {
+		t.Fatalf("the confirmed broadcast failed: %v", err)
+	}
+	for _, call := range fake.calls("chat.postMessage") {
+		if channel := call.Form.Get("channel"); channel != "C1" {
+			t.Errorf("broadcast to %s, want only the public channel", channel)
+		}
+	}
+
+	resp, err := b.handleBroadcast(slack.SlashCommand{Command: "/broadcast", Text: "<#G1> <#D1> The office is closed on Friday", UserID: "U0ADMIN"})
+	if err != nil {
+		t.Fatalf("/broadcast failed: %v", err)
+	}
+	if want := "Broadcasts to private channels are off, skipping <#G1>, <#D1>\nThat leaves no channel to broadcast to"; resp.Text != want {
+		t.Errorf("answered %q, want %q", resp.Text, want)
+	}
+
+	// Allowed, private channels are broadcast to like any other
+	b.cfg.PrivateBroadcast = true
+	button = broadcastButton(t, b, "<#C1> <#G1> The office is closed on Friday")
+	if want := "The message goes to 2 channels right away."; button.Confirm.Text.Text != want {
+		t.Errorf("dialog says %q, want %q", button.Confirm.Text.Text, want)
+	}
+}
@@ -0,0 +1,121 @@
+/*
+Copyright © 2024 Pavlo Tarasiuk <pasha.tarasyuk@gmail.com>
+*/
+package cmd
+
+import (
+	"fmt"
+	"log"
+	"strings"
+	"time"
+)
+
+// collectionConversations holds the conversations in progress, keyed by user and channel
+const collectionConversations = "conversations"
+
+// cancelWord ends the conversation in progress when it is the whole message
+const cancelWord = "cancel"
+
+// conversationStep is one question of a conversation
+type conversationStep struct {
+	// Prompt asks the question
+	Prompt string
+	// Parse turns the answer into the value kept for the step, or returns why it isn't one
+	// and the question is asked again
+	Parse func(b *Bot, answer string) (string, error)
+}
+
+// conversationFlow is a conversation the bot leads a user through one step at a time
+type conversationFlow struct {
+	// Name identifies the flow in the Store, e.g. "setup"
+	Name  string
+	Steps []conversationStep
+	// Complete acts on the values of every step and returns the bot's last message
+	Complete func(b *Bot, userID string, values []string) (string, error)
+}
+
+// conversationFlows are the flows by name, see registerConversationFlow
+var conversationFlows = map[string]*conversationFlow{}
+
+// registerConversationFlow makes the flow resumable by name
+func registerConversationFlow(flow *conversationFlow) {
+	conversationFlows[flow.Name] = flow
+}
+
+// conversation is the progress of a user through a flow in a channel
+type conversation struct {
+	Flow   string   `json:"flow"`
+	Values []string `json:"values,omitempty"`
+	// StartedAt is when the flow was started
+	StartedAt time.Time `json:"started_at"`
+	// ExpiresAt is when the conversation is given up on unless the user answers
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// conversationKey identifies the conversation of a user in a channel in the Store
+func conversationKey(userID, channelID string) string {
+	return userID + "/" + channelID
+}
+
+// startConversation starts the flow for the user in the channel, replacing a conversation
+// already in progress there, and returns the first question
+func (b *Bot) startConversation(flow *conversationFlow, userID, channelID string) (string, error) {
+	state := conversation{Flow: flow.Name, StartedAt: b.now(), ExpiresAt: b.now().Add(b.cfg.ConversationTimeout)}
+	if err := b.store.Put(collectionConversations, conversationKey(userID, channelID), state); err != nil {
+		return "", fmt.Errorf("failed to start conversation: %w", err)
+	}
+	return flow.Steps[0].Prompt, nil
+}
+
+// advanceConversation takes text as the user's answer in their conversation in the channel and
+// returns what the bot says next. It reports false when the user has no conversation there,
+// an expired one is dropped and the answer is left to the other handlers.
+func (b *Bot) advanceConversation(userID, channelID, text string) (string, bool, error) {
+	key := conversationKey(userID, channelID)
+	var state conversation
+	found, err := b.store.Get(collectionConversations, key, &state)
+	if err != nil || !found {
+		return "", false, err
+	}
+	flow, ok := conversationFlows[state.Flow]
+	if !ok || !b.now().Before(state.ExpiresAt) || len(state.Values) >= len(flow.Steps) {
+		log.Printf("conversation %s of %s in %s timed out\n", state.Flow, userID, channelID)
+		return "", false, b.store.Delete(collectionConversations, key)
+	}
+
+	answer := strings.TrimSpace(text)
+	if strings.EqualFold(answer, cancelWord) {
+		return fmt.Sprintf("OK, /%s cancelled", flow.Name), true, b.store.Delete(collectionConversations, key)
+	}
+	step := flow.Steps[len(state.Values)]
+	value, err := step.Parse(b, answer)
+	if err != nil {
+		return fmt.Sprintf("%v\n%s", err, step.Prompt), true, nil
+	}
+	state.Values = append(state.Values, value)
+
+	if len(state.Values) < len(flow.Steps) {
+		// Every answer gives the user the full timeout for the next one
+		state.ExpiresAt = b.now().Add(b.cfg.ConversationTimeout)
+		if err := b.store.Put(collectionConversations, key, state); err != nil {
+			return "", true, fmt.Errorf("failed to save conversation: %w", err)
+		}
+		return flow.Steps[len(state.Values)].Prompt, true, nil
+	}
+	if err := b.store.Delete(collectionConversations, key); err != nil {
+		return "", true, fmt.Errorf("failed to end conversation: %w", err)
+	}
+	reply, err := flow.Complete(b, userID, state.Values)
+	return reply, true, err
+}
+
+// continueConversation answers a message that belongs to a conversation in progress, privately
+// to its author. It reports whether the message was one.
+func (b *Bot) continueConversation(userID, channelID, text string) (bool, error) {
+	reply, ok, err := b.advanceConversation(userID, channelID, text)
+	if !ok || err != nil {
+		return ok, err
+	}
+	_, err = b.postMessage(outboundMessage{Channel: channelID, EphemeralTo: userID, Invoker: userID, Text: reply})
+	return true, err
+}
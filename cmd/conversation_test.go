@@ -0,0 +1,172 @@
+/*
+Copyright © 2024 Pavlo Tarasiuk <pasha.tarasyuk@gmail.com>
+*/
+package cmd
+
+import (
+	"testing"
+	"time"
+
+	"github.com/slack-go/slack"
+	"github.com/slack-go/slack/slackevents"
+)
+
+// setupTest runs /setup for U1 in C1 on a bot with a fake clock
+type setupTest struct {
+	t     *testing.T
+	b     *Bot
+	fake  *fakeSlack
+	clock *fakeClock
+}
+
+func newSetupTest(t *testing.T) *setupTest {
+	t.Helper()
+	cfg := testConfig(t)
+	cfg.ConversationTimeout = 5 * time.Minute
+	b, fake := newTestBot(t, cfg)
+	clock := newFakeClock()
+	b.now = clock.now
+	resp, err := b.handleSetup(slack.SlashCommand{Command: "/setup", UserID: "U1", ChannelID: "C1"})
+	if err != nil {
+		t.Fatalf("/setup failed: %v", err)
+	}
+	if resp.Text != setupFlow.Steps[0].Prompt || resp.ResponseType != slack.ResponseTypeEphemeral {
+		t.Fatalf("/setup answered %q, want the first question privately", resp.Text)
+	}
+	return &setupTest{t: t, b: b, fake: fake, clock: clock}
+}
+
+// say posts the text as U1 in the channel and returns the bot's private answer, empty for none
+func (s *setupTest) say(channelID, text string) string {
+	s.t.Helper()
+	before := len(s.fake.calls("chat.postEphemeral"))
+	if err := s.b.handleMessageEvent(&slackevents.MessageEvent{User: "U1", Channel: channelID, Text: text, TimeStamp: "1712345678.000200"}); err != nil {
+		s.t.Fatalf("message %q failed: %v", text, err)
+	}
+	calls := s.fake.calls("chat.postEphemeral")
+	if len(calls) == before {
+		return ""
+	}
+	if user := calls[len(calls)-1].Form.Get("user"); user != "U1" {
+		s.t.Errorf("answered %s, want U1 privately", user)
+	}
+	return calls[len(calls)-1].Form.Get("text")
+}
+
+// inProgress reports whether U1 has a conversation in C1
+func (s *setupTest) inProgress() bool {
+	var state conversation
+	found, err := s.b.store.Get(collectionConversations, conversationKey("U1", "C1"), &state)
+	if err != nil {
+		s.t.Fatalf("failed to get conversation: %v", err)
+	}
+	return found
+}
+
+func TestSetupConversation(t *testing.T) {
+	tests := []struct {
+		name    string
+		answers []string
+		// replies are the bot's answers to each
+		replies []string
+		want    userPrefs
+	}{
+		{
+			name:    "straight through",
+			answers: []string{"uk", "no"},
+			replies: []string{setupFlow.Steps[1].Prompt, "All set! You can change these any time with /prefs"},
+			want:    userPrefs{Locale: "uk", NoNudges: true},
+		},
+		{
+			name:    "invalid answers asked again",
+			answers: []string{"klingon", " en ", "maybe", "Y"},
+			replies: []string{
+				"Unknown language \"klingon\", available: en, uk\n" + setupFlow.Steps[0].Prompt,
+				setupFlow.Steps[1].Prompt,
+				"Please reply `yes` or `no`\n" + setupFlow.Steps[1].Prompt,
+				"All set! You can change these any time with /prefs",
+			},
+			want: userPrefs{Locale: "en"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newSetupTest(t)
+			for i, answer := range tt.answers {
+				if got := s.say("C1", answer); got != tt.replies[i] {
+					t.Errorf("answer %q got %q, want %q", answer, got, tt.replies[i])
+				}
+			}
+			if s.inProgress() {
+				t.Errorf("the conversation is still in progress after completing")
+			}
+			if got := s.b.userPrefs("U1"); got != tt.want {
+				t.Errorf("prefs = %+v, want %+v", got, tt.want)
+			}
+			// The flow is over, later messages aren't answers
+			if got := s.say("C1", "yes"); got != "" {
+				t.Errorf("answered %q after the conversation ended", got)
+			}
+		})
+	}
+}
+
+func TestSetupConversationIsPerChannel(t *testing.T) {
+	s := newSetupTest(t)
+	if got := s.say("C2", "uk"); got != "" {
+		t.Errorf("answered %q in another channel", got)
+	}
+	if got := s.say("C1", "uk"); got != setupFlow.Steps[1].Prompt {
+		t.Errorf("answered %q in the channel of the conversation, want the next question", got)
+	}
+}
+
+func TestSetupConversationCancel(t *testing.T) {
+	s := newSetupTest(t)
+	s.say("C1", "uk")
+	if got, want := s.say("C1", "Cancel"), "OK, /setup cancelled"; got != want {
+		t.Errorf("answered %q, want %q", got, want)
+	}
+	if s.inProgress() {
+		t.Errorf("the conversation is still in progress after cancelling")
+	}
+	if got := s.b.userPrefs("U1"); got.Locale != "" {
+		t.Errorf("prefs = %+v, want nothing saved", got)
+	}
+}
+
+func TestSetupConversationTimeout(t *testing.T) {
+	captureLog(t)
+	s := newSetupTest(t)
+	// Every answer gives the full timeout for the next one
+	s.clock.advance(4 * time.Minute)
+	if got := s.say("C1", "uk"); got != setupFlow.Steps[1].Prompt {
+		t.Fatalf("answered %q before the timeout, want the next question", got)
+	}
+	s.clock.advance(4 * time.Minute)
+	if !s.inProgress() {
+		t.Fatalf("the conversation ended before the timeout")
+	}
+
+	s.clock.advance(time.Minute)
+	if got := s.say("C1", "yes"); got != "" {
+		t.Errorf("answered %q after the timeout", got)
+	}
+	if s.inProgress() {
+		t.Errorf("the timed out conversation is still stored")
+	}
+	if got := s.b.userPrefs("U1"); got.Locale != "" {
+		t.Errorf("prefs = %+v, want nothing saved", got)
+	}
+}
+
+func TestSetupRestartReplacesConversation(t *testing.T) {
+	s := newSetupTest(t)
+	s.say("C1", "uk")
+	if _, err := s.b.handleSetup(slack.SlashCommand{Command: "/setup", UserID: "U1", ChannelID: "C1"}); err != nil {
+		t.Fatalf("/setup failed: %v", err)
+	}
+	if got := s.say("C1", "en"); got != setupFlow.Steps[1].Prompt {
+		t.Errorf("answered %q, want the flow started over", got)
+	}
+}
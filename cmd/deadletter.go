@@ -0,0 +1,99 @@
+/*
+Copyright © 2024 Pavlo Tarasiuk <pasha.tarasyuk@gmail.com>
+*/
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/slack-go/slack"
+	"github.com/slack-go/slack/socketmode"
+)
+
+// DeadLetter keeps the requests the bot gave up processing, for inspection or replaying later
+type DeadLetter interface {
+	Add(req socketmode.Request, cause error) error
+}
+
+// deadLetterEntry is a line of the dead-letter file. It is the request as the event log records
+// it, so the file can be fed to the replay command, plus when and why processing failed.
+type deadLetterEntry struct {
+	socketmode.Request
+	FailedAt time.Time `json:"failed_at"`
+	Error    string    `json:"error"`
+}
+
+// fileDeadLetter is the default DeadLetter, it appends one JSON document per request to a file
+type fileDeadLetter struct {
+	path string
+	now  func() time.Time
+
+	mu sync.Mutex
+}
+
+// newFileDeadLetter creates a dead-letter store writing to the file at path
+func newFileDeadLetter(path string, now func() time.Time) *fileDeadLetter {
+	return &fileDeadLetter{path: path, now: now}
+}
+
+// Add implements DeadLetter
+func (d *fileDeadLetter) Add(req socketmode.Request, cause error) error {
+	line, err := json.Marshal(deadLetterEntry{Request: req, FailedAt: d.now(), Error: cause.Error()})
+	if err != nil {
+		return fmt.Errorf("failed to encode dead letter: %w", err)
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	file, err := os.OpenFile(d.path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o600)
+	if err != nil {
+		return fmt.Errorf("failed to open dead-letter file: %w", err)
+	}
+	defer file.Close()
+	if _, err := file.Write(append(line, '\n')); err != nil {
+		return fmt.Errorf("failed to write dead letter: %w", err)
+	}
+	return nil
+}
+
+// interactionRetries returns how many times a failed interaction is tried again. A failed view
+// submission may have posted or stored already, so it isn't.
+func (b *Bot) interactionRetries(interaction slack.InteractionCallback) int {
+	if interaction.Type == slack.InteractionTypeViewSubmission {
+		return 0
+	}
+	return b.cfg.InteractionRetries
+}
+
+// processWithRetries runs process, trying again up to retries times, the configured delay apart,
+// when it fails. Once the attempts are exhausted or ctx is done the request goes to the dead-letter
+// store and only an error storing it is returned.
+func (b *Bot) processWithRetries(ctx context.Context, req socketmode.Request, retries int, process func() error) error {
+	err := process()
+retrying:
+	for attempt := 1; err != nil && attempt <= retries; attempt++ {
+		log.Printf("Processing %s failed, retrying (%d/%d): %v\n", req.EnvelopeID, attempt, retries, err)
+		timer := time.NewTimer(b.cfg.InteractionRetryDelay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			break retrying
+		case <-timer.C:
+		}
+		err = process()
+	}
+	if err == nil {
+		return nil
+	}
+	if dlErr := b.deadLetter.Add(req, err); dlErr != nil {
+		return fmt.Errorf("%w, and it couldn't be dead-lettered: %v", err, dlErr)
+	}
+	log.Printf("Gave up processing %s, it was dead-lettered: %v\n", req.EnvelopeID, err)
+	return nil
+}
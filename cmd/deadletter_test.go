@@ -0,0 +1,238 @@
+/*
+Copyright © 2024 Pavlo Tarasiuk <pasha.tarasyuk@gmail.com>
+*/
+package cmd
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/slack-go/slack"
+	"github.com/slack-go/slack/socketmode"
+)
+
+// readDeadLetters returns the entries of the dead-letter file, none when it doesn't exist
+func readDeadLetters(t *testing.T, path string) []deadLetterEntry {
+	t.Helper()
+	file, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	var entries []deadLetterEntry
+	lines := bufio.NewScanner(file)
+	for lines.Scan() {
+		var entry deadLetterEntry
+		if err := json.Unmarshal(lines.Bytes(), &entry); err != nil {
+			t.Fatalf("invalid dead letter %s: %v", lines.Bytes(), err)
+		}
+		entries = append(entries, entry)
+	}
+	return entries
+}
+
+// newDeadLetterBot returns a bot retrying interactions twice and its dead-letter file
+func newDeadLetterBot(t *testing.T) (*Bot, *fakeSlack, string) {
+	t.Helper()
+	cfg := testConfig(t)
+	cfg.InteractionRetries = 2
+	cfg.DeadLetterFile = filepath.Join(t.TempDir(), "dead-letter.jsonl")
+	b, fake := newTestBot(t, cfg)
+	return b, fake, cfg.DeadLetterFile
+}
+
+func TestProcessWithRetries(t *testing.T) {
+	tests := []struct {
+		name string
+		// failures is how many times processing fails before it succeeds
+		failures     int
+		attempts     int
+		deadLettered bool
+	}{
+		{name: "succeeds", failures: 0, attempts: 1},
+		{name: "succeeds on the last retry", failures: 2, attempts: 3},
+		{name: "exhausted", failures: 10, attempts: 3, deadLettered: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			captureLog(t)
+			b, _, path := newDeadLetterBot(t)
+			attempts := 0
+			err := b.processWithRetries(context.Background(), socketmode.Request{EnvelopeID: "env-1"}, 2, func() error {
+				attempts++
+				if attempts <= tt.failures {
+					return errors.New("store unavailable")
+				}
+				return nil
+			})
+			if err != nil {
+				t.Fatalf("processWithRetries() error = %v", err)
+			}
+			if attempts != tt.attempts {
+				t.Errorf("tried %d times, want %d", attempts, tt.attempts)
+			}
+			entries := readDeadLetters(t, path)
+			if got := len(entries) == 1; got != tt.deadLettered {
+				t.Fatalf("dead-lettered %d requests, want dead-lettered: %t", len(entries), tt.deadLettered)
+			}
+			if tt.deadLettered && (entries[0].EnvelopeID != "env-1" || entries[0].Error != "store unavailable" || entries[0].FailedAt.IsZero()) {
+				t.Errorf("dead letter = %+v, want the request, the error and when", entries[0])
+			}
+		})
+	}
+}
+
+func TestDeadLetterFailureIsReturned(t *testing.T) {
+	captureLog(t)
+	b, _, _ := newDeadLetterBot(t)
+	b.deadLetter = newFileDeadLetter(filepath.Join(t.TempDir(), "missing", "dead-letter.jsonl"), b.now)
+	err := b.processWithRetries(context.Background(), socketmode.Request{EnvelopeID: "env-1"}, 2, func() error {
+		return errors.New("store unavailable")
+	})
+	if err == nil {
+		t.Errorf("the request was lost without an error")
+	}
+}
+
+func TestViewSubmissionsAreNotRetried(t *testing.T) {
+	b, _, _ := newDeadLetterBot(t)
+	if got := b.interactionRetries(slack.InteractionCallback{Type: slack.InteractionTypeBlockActions}); got != 2 {
+		t.Errorf("block actions are retried %d times, want the configured 2", got)
+	}
+	if got := b.interactionRetries(slack.InteractionCallback{Type: slack.InteractionTypeViewSubmission}); got != 0 {
+		t.Errorf("view submissions are retried %d times, want none", got)
+	}
+}
+
+func TestRetriesWaitTheDelay(t *testing.T) {
+	captureLog(t)
+	b, _, _ := newDeadLetterBot(t)
+	b.cfg.InteractionRetryDelay = 30 * time.Millisecond
+	var tries []time.Time
+	b.processWithRetries(context.Background(), socketmode.Request{EnvelopeID: "env-1"}, 2, func() error {
+		tries = append(tries, time.Now())
+		return errors.New("store unavailable")
+	})
+	if len(tries) != 3 {
+		t.Fatalf("tried %d times, want 3", len(tries))
+	}
+	for i := 1; i < len(tries); i++ {
+		if waited := tries[i].Sub(tries[i-1]); waited < b.cfg.InteractionRetryDelay {
+			t.Errorf("try %d came %s after the previous one, want at least %s", i+1, waited, b.cfg.InteractionRetryDelay)
+		}
+	}
+}
+
+func TestRetriesStopWithTheContext(t *testing.T) {
+	captureLog(t)
+	b, _, path := newDeadLetterBot(t)
+	b.cfg.InteractionRetryDelay = time.Minute
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	attempts := 0
+	start := time.Now()
+	b.processWithRetries(ctx, socketmode.Request{EnvelopeID: "env-1"}, 2, func() error {
+		attempts++
+		return errors.New("store unavailable")
+	})
+	if waited := time.Since(start); waited > time.Second {
+		t.Errorf("waited %s to retry after the context was done", waited)
+	}
+	if attempts != 1 {
+		t.Errorf("tried %d times, want no retry once the context is done", attempts)
+	}
+	if entries := readDeadLetters(t, path); len(entries) != 1 {
+		t.Errorf("dead-lettered %d requests, want the request given up on", len(entries))
+	}
+}
+
+// registerTestAction registers a confirmed action for the test
+func registerTestAction(t *testing.T, actionID string, handler confirmedAction) {
+	t.Helper()
+	registerConfirmedAction(actionID, handler)
+	t.Cleanup(func() { delete(confirmedActions, actionID) })
+}
+
+// blockActionsEvent is a click on the buttons with the action IDs, in one interaction
+func blockActionsEvent(envelopeID string, actionIDs ...string) socketmode.Event {
+	interaction := slack.InteractionCallback{Type: slack.InteractionTypeBlockActions, User: slack.User{ID: "U1"}}
+	for _, id := range actionIDs {
+		interaction.ActionCallback.BlockActions = append(interaction.ActionCallback.BlockActions,
+			&slack.BlockAction{ActionID: id, BlockID: "b1", ActionTs: "1712345678.000100"})
+	}
+	return socketmode.Event{
+		Type:    socketmode.EventTypeInteractive,
+		Data:    interaction,
+		Request: &socketmode.Request{EnvelopeID: envelopeID, Payload: json.RawMessage(`{}`)},
+	}
+}
+
+func TestInteractionRetriesSkipCompletedActions(t *testing.T) {
+	captureLog(t)
+	b, _, path := newDeadLetterBot(t)
+	archived, notified := 0, 0
+	registerTestAction(t, "test_archive", func(*Bot, slack.InteractionCallback, *slack.BlockAction) error {
+		archived++
+		return nil
+	})
+	registerTestAction(t, "test_notify", func(*Bot, slack.InteractionCallback, *slack.BlockAction) error {
+		notified++
+		if notified == 1 {
+			return errors.New("rate limited")
+		}
+		return nil
+	})
+
+	socket := &fakeSocket{}
+	b.processEvent(context.Background(), blockActionsEvent("env-1", "test_archive", "test_notify"), socket)
+	if archived != 1 {
+		t.Errorf("archived %d times, want the completed action skipped on retry", archived)
+	}
+	if notified != 2 {
+		t.Errorf("notified %d times, want the failed action retried once", notified)
+	}
+	if acks := socket.acked(); len(acks) != 1 || acks[0].Payload != nil {
+		t.Errorf("acknowledgements = %+v, want one without a payload", acks)
+	}
+	if entries := readDeadLetters(t, path); len(entries) != 0 {
+		t.Errorf("dead-lettered %+v, want the retry to have succeeded", entries)
+	}
+}
+
+func TestExhaustedInteractionIsDeadLettered(t *testing.T) {
+	captureLog(t)
+	b, _, path := newDeadLetterBot(t)
+	attempts := 0
+	registerTestAction(t, "test_broken", func(*Bot, slack.InteractionCallback, *slack.BlockAction) error {
+		attempts++
+		return errors.New("downstream unavailable")
+	})
+
+	socket := &fakeSocket{}
+	b.processEvent(context.Background(), blockActionsEvent("env-7", "test_broken"), socket)
+	if attempts != 3 {
+		t.Errorf("tried %d times, want the first try and 2 retries", attempts)
+	}
+	entries := readDeadLetters(t, path)
+	if len(entries) != 1 {
+		t.Fatalf("dead-lettered %d requests, want 1", len(entries))
+	}
+	if entries[0].EnvelopeID != "env-7" || entries[0].Error != "downstream unavailable" {
+		t.Errorf("dead letter = %+v, want the interaction and why it failed", entries[0])
+	}
+	if len(socket.acked()) != 1 {
+		t.Errorf("the interaction wasn't acknowledged")
+	}
+	if got := b.metrics.get(metricHandlerErrors); got != 0 {
+		t.Errorf("reported %d errors, the dead-lettered interaction isn't lost", got)
+	}
+}
@@ -0,0 +1,124 @@
+/*
+Copyright © 2024 Pavlo Tarasiuk <pasha.tarasyuk@gmail.com>
+*/
+package cmd
+
+import (
+	"container/list"
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/slack-go/slack"
+)
+
+// Names of the counters kept by a dedupCache, prefixed with what it deduplicates
+const (
+	metricDedupHits      = "dedup_hits"
+	metricDedupMisses    = "dedup_misses"
+	metricDedupEvictions = "dedup_evictions"
+)
+
+// dedupEntry is an ID remembered by dedupCache
+type dedupEntry struct {
+	id     string
+	seenAt time.Time
+}
+
+// dedupCache remembers recently seen IDs, like those of processed events so Slack's redeliveries
+// are handled once. It holds at most maxSize IDs for up to ttl since each was last seen, evicting the
+// least recently seen first.
+type dedupCache struct {
+	mu      sync.Mutex
+	maxSize int
+	ttl     time.Duration
+	now     func() time.Time
+	metrics *metrics
+	// prefix tells the counters of this cache apart, the event cache's have none
+	prefix string
+
+	// order holds the entries, most recently seen at the front, so the oldest seenAt is at the back
+	order *list.List
+	items map[string]*list.Element
+}
+
+// newDedupCache creates an empty cache counting its hits, misses and evictions in m under the prefix
+func newDedupCache(maxSize int, ttl time.Duration, now func() time.Time, m *metrics, prefix string) *dedupCache {
+	return &dedupCache{
+		maxSize: maxSize,
+		ttl:     ttl,
+		now:     now,
+		metrics: m,
+		prefix:  prefix,
+		order:   list.New(),
+		items:   make(map[string]*list.Element),
+	}
+}
+
+// seen reports whether the ID was seen within the TTL and remembers it either way
+func (c *dedupCache) seen(id string) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := c.now()
+	if el, ok := c.items[id]; ok {
+		entry := el.Value.(*dedupEntry)
+		if now.Sub(entry.seenAt) < c.ttl {
+			// Seen again, so it moves to the front and its TTL starts over, keeping the order by age
+			entry.seenAt = now
+			c.order.MoveToFront(el)
+			c.metrics.inc(c.prefix + metricDedupHits)
+			return true
+		}
+		// Expired, so it counts as new
+		c.order.Remove(el)
+		delete(c.items, id)
+	}
+
+	c.metrics.inc(c.prefix + metricDedupMisses)
+	c.items[id] = c.order.PushFront(&dedupEntry{id: id, seenAt: now})
+	c.evict(now)
+	return false
+}
+
+// evict drops expired entries and then the least recently seen ones over maxSize. Callers must hold c.mu.
+func (c *dedupCache) evict(now time.Time) {
+	for el := c.order.Back(); el != nil; el = c.order.Back() {
+		entry := el.Value.(*dedupEntry)
+		if c.order.Len() <= c.maxSize && now.Sub(entry.seenAt) < c.ttl {
+			return
+		}
+		c.order.Remove(el)
+		delete(c.items, entry.id)
+		c.metrics.inc(c.prefix + metricDedupEvictions)
+	}
+}
+
+// len returns the number of remembered IDs
+func (c *dedupCache) len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.order.Len()
+}
+
+// purge forgets every ID and returns how many there were
+func (c *dedupCache) purge() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	n := c.order.Len()
+	c.order.Init()
+	c.items = make(map[string]*list.Element)
+	return n
+}
+
+// commandDedupSize bounds the recent slash command submissions remembered
+const commandDedupSize = 1000
+
+// commandFingerprint identifies a slash command submission by who ran what where. Every
+// submission gets its own trigger_id, so a repeated one can only be told by its content.
+func commandFingerprint(command slack.SlashCommand) string {
+	sum := sha256.Sum256([]byte(strings.Join([]string{command.UserID, command.ChannelID, command.Command, command.Text}, "\x00")))
+	return hex.EncodeToString(sum[:])
+}
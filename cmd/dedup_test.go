@@ -0,0 +1,236 @@
+/*
+Copyright © 2024 Pavlo Tarasiuk <pasha.tarasyuk@gmail.com>
+*/
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/slack-go/slack"
+	"github.com/slack-go/slack/slackevents"
+	"github.com/slack-go/slack/socketmode"
+)
+
+func TestDedupCacheHitsAndMisses(t *testing.T) {
+	clock := newFakeClock()
+	m := newMetrics()
+	c := newDedupCache(10, time.Minute, clock.now, m, "")
+
+	for _, step := range []struct {
+		id   string
+		want bool
+	}{{"Ev1", false}, {"Ev2", false}, {"Ev1", true}, {"Ev1", true}, {"Ev3", false}} {
+		if got := c.seen(step.id); got != step.want {
+			t.Errorf("seen(%s) = %t, want %t", step.id, got, step.want)
+		}
+	}
+	if hits, misses := m.get(metricDedupHits), m.get(metricDedupMisses); hits != 2 || misses != 3 {
+		t.Errorf("counted %d hits and %d misses, want 2 and 3", hits, misses)
+	}
+}
+
+func TestDedupCacheEvictsAtCapacity(t *testing.T) {
+	clock := newFakeClock()
+	m := newMetrics()
+	c := newDedupCache(2, time.Hour, clock.now, m, "")
+
+	c.seen("Ev1")
+	c.seen("Ev2")
+	// Seeing Ev1 again makes Ev2 the least recently seen
+	c.seen("Ev1")
+	c.seen("Ev3")
+	if got := c.len(); got != 2 {
+		t.Errorf("len() = %d, want the capacity of 2", got)
+	}
+	if got := m.get(metricDedupEvictions); got != 1 {
+		t.Errorf("counted %d evictions, want 1", got)
+	}
+	if !c.seen("Ev1") {
+		t.Errorf("Ev1 was evicted, want the least recently seen Ev2 evicted")
+	}
+	if c.seen("Ev2") {
+		t.Errorf("Ev2 is still remembered past the capacity")
+	}
+}
+
+func TestDedupCacheExpires(t *testing.T) {
+	clock := newFakeClock()
+	m := newMetrics()
+	c := newDedupCache(10, time.Minute, clock.now, m, "")
+
+	c.seen("Ev1")
+	c.seen("Ev2")
+	clock.advance(time.Minute)
+	if c.seen("Ev1") {
+		t.Errorf("Ev1 is remembered after its TTL")
+	}
+	// Seeing Ev1 evicted the expired Ev2
+	if got := c.len(); got != 1 {
+		t.Errorf("len() = %d, want the expired IDs dropped", got)
+	}
+	if got := m.get(metricDedupEvictions); got != 1 {
+		t.Errorf("counted %d evictions, want the expired Ev2", got)
+	}
+}
+
+func TestDedupHitRefreshesTTL(t *testing.T) {
+	clock := newFakeClock()
+	c := newDedupCache(10, time.Minute, clock.now, newMetrics(), "")
+
+	c.seen("Ev1")
+	clock.advance(50 * time.Second)
+	if !c.seen("Ev1") {
+		t.Fatalf("Ev1 isn't remembered within its TTL")
+	}
+	// A minute after it was first seen, but not after it was last seen
+	clock.advance(50 * time.Second)
+	if !c.seen("Ev1") {
+		t.Errorf("Ev1 expired although it was seen again 50s ago")
+	}
+	clock.advance(time.Minute)
+	if c.seen("Ev1") {
+		t.Errorf("Ev1 is remembered a minute after it was last seen")
+	}
+}
+
+func TestDedupCachePrefix(t *testing.T) {
+	m := newMetrics()
+	c := newDedupCache(10, time.Minute, newFakeClock().now, m, "command_")
+	c.seen("x")
+	c.seen("x")
+	if m.get("command_"+metricDedupHits) != 1 || m.get(metricDedupHits) != 0 {
+		t.Errorf("counters = %v, want the hit counted under the prefix", m.snapshot())
+	}
+}
+
+func TestCommandFingerprint(t *testing.T) {
+	base := slack.SlashCommand{UserID: "U1", ChannelID: "C1", Command: "/ask", Text: "how", TriggerID: "T1"}
+	same := base
+	same.TriggerID = "T2"
+	if commandFingerprint(base) != commandFingerprint(same) {
+		t.Errorf("fingerprints differ by the trigger_id")
+	}
+	for _, other := range []slack.SlashCommand{
+		{UserID: "U2", ChannelID: "C1", Command: "/ask", Text: "how"},
+		{UserID: "U1", ChannelID: "C2", Command: "/ask", Text: "how"},
+		{UserID: "U1", ChannelID: "C1", Command: "/faq", Text: "how"},
+		{UserID: "U1", ChannelID: "C1", Command: "/ask", Text: "why"},
+		// The fields don't run into each other
+		{UserID: "U1", ChannelID: "C1", Command: "/ask how", Text: ""},
+	} {
+		if commandFingerprint(base) == commandFingerprint(other) {
+			t.Errorf("%+v has the fingerprint of %+v", other, base)
+		}
+	}
+}
+
+func TestRedeliveredEventIsDropped(t *testing.T) {
+	logs := captureLog(t)
+	cfg := testConfig(t)
+	cfg.DedupSize = 100
+	b, _ := newTestBot(t, cfg)
+	raw := json.RawMessage(`{"type":"made_up_event"}`)
+	event := socketmode.Event{
+		Type: socketmode.EventTypeEventsAPI,
+		Data: slackevents.EventsAPIEvent{
+			Type:       slackevents.CallbackEvent,
+			Data:       &slackevents.EventsAPICallbackEvent{EventID: "Ev1", InnerEvent: &raw},
+			InnerEvent: slackevents.EventsAPIInnerEvent{Type: "made_up_event"},
+		},
+		Request: &socketmode.Request{EnvelopeID: "env-1"},
+	}
+
+	socket := &fakeSocket{}
+	b.processEvent(context.Background(), event, socket)
+	b.processEvent(context.Background(), event, socket)
+	if got := len(socket.acked()); got != 2 {
+		t.Errorf("acknowledged %d deliveries, want both", got)
+	}
+	if got := strings.Count(logs.String(), "Dropped redelivered event Ev1"); got != 1 {
+		t.Errorf("dropped %d deliveries, want the second one", got)
+	}
+	if got := b.metrics.get(metricDedupHits); got != 1 {
+		t.Errorf("counted %d hits, want 1", got)
+	}
+}
+
+func TestRepeatedSlashCommandRunsOnce(t *testing.T) {
+	logs := captureLog(t)
+	ran := 0
+	registerTestCommand(t, &slashCommand{
+		Name: "/test-once",
+		Handler: func(*Bot, slack.SlashCommand) (*SlashResponse, error) {
+			ran++
+			return ephemeral("Done"), nil
+		},
+	})
+	cfg := testConfig(t)
+	cfg.CommandDedupWindow = 2 * time.Second
+	b, _ := newTestBot(t, cfg)
+	clock := newFakeClock()
+	b.now = clock.now
+	b.commands = newDedupCache(commandDedupSize, cfg.CommandDedupWindow, clock.now, b.metrics, "command_")
+
+	steps := []struct {
+		name    string
+		user    string
+		text    string
+		advance time.Duration
+		runs    bool
+	}{
+		{name: "first submission", user: "U1", text: "go", runs: true},
+		{name: "double submission", user: "U1", text: "go", advance: 300 * time.Millisecond},
+		{name: "other text", user: "U1", text: "stop", runs: true},
+		{name: "other user", user: "U2", text: "go", runs: true},
+		{name: "after the window", user: "U1", text: "go", advance: 3 * time.Second, runs: true},
+	}
+	for _, step := range steps {
+		clock.advance(step.advance)
+		before := ran
+		payload, err := b.handleSlashCommand(slack.SlashCommand{
+			Command: "/test-once", Text: step.text, UserID: step.user, ChannelID: "C1", TriggerID: step.name,
+		})
+		if err != nil {
+			t.Fatalf("%s: command failed: %v", step.name, err)
+		}
+		if runs := ran > before; runs != step.runs {
+			t.Errorf("%s: ran %t, want %t", step.name, runs, step.runs)
+		}
+		// The repeated submission gets no second response
+		if (payload != nil) != step.runs {
+			t.Errorf("%s: answered %v", step.name, payload)
+		}
+	}
+	if got := strings.Count(logs.String(), "Dropped repeated /test-once from U1"); got != 1 {
+		t.Errorf("logged %d dropped submissions, want 1", got)
+	}
+	if got := b.metrics.get("command_" + metricDedupHits); got != 1 {
+		t.Errorf("counted %d repeated submissions, want 1", got)
+	}
+}
+
+func TestCommandDedupSwitchedOff(t *testing.T) {
+	ran := 0
+	registerTestCommand(t, &slashCommand{
+		Name: "/test-always",
+		Handler: func(*Bot, slack.SlashCommand) (*SlashResponse, error) {
+			ran++
+			return nil, nil
+		},
+	})
+	cfg := testConfig(t)
+	cfg.CommandDedupWindow = 0
+	b, _ := newTestBot(t, cfg)
+	for i := 0; i < 3; i++ {
+		if _, err := b.handleSlashCommand(slack.SlashCommand{Command: "/test-always", UserID: "U1", ChannelID: "C1"}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if ran != 3 {
+		t.Errorf("ran %d times, want every submission run without a window", ran)
+	}
+}
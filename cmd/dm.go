@@ -0,0 +1,82 @@
+/*
+Copyright © 2024 Pavlo Tarasiuk <pasha.tarasyuk@gmail.com>
+*/
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/slack-go/slack"
+)
+
+// Where a command invoked from a direct message posts its reply
+const (
+	// dmReplyInDM posts back into the direct message, the default
+	dmReplyInDM = iota
+	// dmPostToDefault posts to the configured default channel instead
+	dmPostToDefault
+)
+
+// isDirectMessage reports whether the command was invoked in a direct message.
+// Slack names DM conversations "directmessage", and their IDs start with a D.
+func isDirectMessage(command slack.SlashCommand) bool {
+	return command.ChannelName == "directmessage" || strings.HasPrefix(command.ChannelID, "D")
+}
+
+// replyChannel returns the channel a command should post its reply to.
+// Commands invoked in a channel always reply there; from a DM the command's
+// DMRoute decides, falling back to the DM when no default channel is configured.
+func (b *Bot) replyChannel(command slack.SlashCommand) string {
+	if !isDirectMessage(command) {
+		return command.ChannelID
+	}
+	registered, ok := slashCommands[command.Command]
+	if ok && registered.DMRoute == dmPostToDefault && b.cfg.DefaultChannel != "" {
+		return b.cfg.DefaultChannel
+	}
+	return command.ChannelID
+}
+
+// errDMUnavailable is returned when the bot may not message the user directly
+var errDMUnavailable = errors.New("direct messages to the user are unavailable")
+
+// dmUnavailableErrors are the Slack errors meaning the user can't receive DMs from the bot
+var dmUnavailableErrors = map[string]bool{
+	"cannot_dm_bot":         true,
+	"messages_tab_disabled": true,
+	"user_disabled":         true,
+	"user_not_found":        true,
+	"restricted_action":     true,
+}
+
+// wrapDMError turns the Slack errors in dmUnavailableErrors into errDMUnavailable
+func wrapDMError(err error) error {
+	var slackErr slack.SlackErrorResponse
+	if errors.As(err, &slackErr) && dmUnavailableErrors[slackErr.Err] {
+		return fmt.Errorf("%w: %s", errDMUnavailable, slackErr.Err)
+	}
+	return err
+}
+
+// openDM opens the direct message conversation with the user, or finds the existing one, and returns its ID
+func (b *Bot) openDM(userID string) (string, error) {
+	channel, _, _, err := b.api().OpenConversation(&slack.OpenConversationParameters{Users: []string{userID}})
+	if err != nil {
+		return "", wrapDMError(fmt.Errorf("failed to open DM: %w", err))
+	}
+	return channel.ID, nil
+}
+
+// postDM posts msg to the user's direct messages, ignoring msg.Channel.
+// It returns errDMUnavailable when the user can't be messaged directly.
+func (b *Bot) postDM(userID string, msg outboundMessage) (string, error) {
+	channel, err := b.openDM(userID)
+	if err != nil {
+		return "", err
+	}
+	msg.Channel = channel
+	ts, err := b.postMessage(msg)
+	return ts, wrapDMError(err)
+}
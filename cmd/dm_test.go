@@ -0,0 +1,62 @@
+/*
+Copyright © 2024 Pavlo Tarasiuk <pasha.tarasyuk@gmail.com>
+*/
+package cmd
+
+import (
+	"testing"
+
+	"github.com/slack-go/slack"
+)
+
+func TestReplyChannel(t *testing.T) {
+	registerTestCommand(t, &slashCommand{Name: "/test-in-dm", DMRoute: dmReplyInDM})
+	registerTestCommand(t, &slashCommand{Name: "/test-to-default", DMRoute: dmPostToDefault})
+	tests := []struct {
+		name           string
+		command        slack.SlashCommand
+		defaultChannel string
+		want           string
+	}{
+		{
+			name:           "channel invocation",
+			command:        slack.SlashCommand{Command: "/test-to-default", ChannelID: "C1", ChannelName: "general"},
+			defaultChannel: "C0DEFAULT",
+			want:           "C1",
+		},
+		{
+			name:           "DM routed to the default channel",
+			command:        slack.SlashCommand{Command: "/test-to-default", ChannelID: "D1", ChannelName: "directmessage"},
+			defaultChannel: "C0DEFAULT",
+			want:           "C0DEFAULT",
+		},
+		{
+			name:    "DM routed to an unset default channel",
+			command: slack.SlashCommand{Command: "/test-to-default", ChannelID: "D1", ChannelName: "directmessage"},
+			want:    "D1",
+		},
+		{
+			name:           "DM replied in the DM",
+			command:        slack.SlashCommand{Command: "/test-in-dm", ChannelID: "D1", ChannelName: "directmessage"},
+			defaultChannel: "C0DEFAULT",
+			want:           "D1",
+		},
+		{
+			name:           "DM told by the channel name only",
+			command:        slack.SlashCommand{Command: "/test-to-default", ChannelID: "G1", ChannelName: "directmessage"},
+			defaultChannel: "C0DEFAULT",
+			want:           "C0DEFAULT",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := testConfig(t)
+			cfg.DefaultChannel = tt.defaultChannel
+			b, _ := newTestBot(t, cfg)
+
+			if got := b.replyChannel(tt.command); got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,92 @@
+/*
+Copyright © 2024 Pavlo Tarasiuk <pasha.tarasyuk@gmail.com>
+*/
+package cmd
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/slack-go/slack/slackevents"
+)
+
+// emojiCache holds the workspace's custom emoji by name. Slack only lists them all at once,
+// so the cache is loaded as a whole and then kept up to date with emoji_changed events.
+type emojiCache struct {
+	mu     sync.RWMutex
+	loaded bool
+	// emoji map names to image URLs, or to "alias:<name>" for aliases
+	emoji map[string]string
+}
+
+// newEmojiCache creates a cache that loads on first use
+func newEmojiCache() *emojiCache {
+	return &emojiCache{emoji: make(map[string]string)}
+}
+
+// len returns the number of cached emoji
+func (c *emojiCache) len() int {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return len(c.emoji)
+}
+
+// purge forgets every emoji so they are loaded again on next use, returning how many there were
+func (c *emojiCache) purge() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	n := len(c.emoji)
+	c.emoji = make(map[string]string)
+	c.loaded = false
+	return n
+}
+
+// apply updates the loaded cache with a change to the custom emoji, an unloaded cache
+// picks the change up when it loads
+func (c *emojiCache) apply(event *slackevents.EmojiChangedEvent) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if !c.loaded {
+		return
+	}
+	switch event.Subtype {
+	case "add":
+		c.emoji[event.Name] = event.Value
+	case "remove":
+		for _, name := range event.Names {
+			delete(c.emoji, name)
+		}
+	case "rename":
+		delete(c.emoji, event.OldName)
+		c.emoji[event.NewName] = event.Value
+	default:
+		// Changes the cache doesn't know how to apply are picked up by loading again
+		c.emoji = make(map[string]string)
+		c.loaded = false
+	}
+}
+
+// customEmojiExists reports whether the workspace has a custom emoji with the name,
+// given with or without colons
+func (b *Bot) customEmojiExists(name string) (bool, error) {
+	name = strings.Trim(name, ":")
+	b.emoji.mu.RLock()
+	if b.emoji.loaded {
+		_, ok := b.emoji.emoji[name]
+		b.emoji.mu.RUnlock()
+		return ok, nil
+	}
+	b.emoji.mu.RUnlock()
+
+	emoji, err := b.api().GetEmoji()
+	if err != nil {
+		return false, fmt.Errorf("failed to list custom emoji: %w", err)
+	}
+	b.emoji.mu.Lock()
+	b.emoji.emoji = emoji
+	b.emoji.loaded = true
+	b.emoji.mu.Unlock()
+	_, ok := emoji[name]
+	return ok, nil
+}
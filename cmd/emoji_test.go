@@ -0,0 +1,78 @@
+/*
+Copyright © 2024 Pavlo Tarasiuk <pasha.tarasyuk@gmail.com>
+*/
+package cmd
+
+import (
+	"context"
+	"testing"
+
+	"github.com/slack-go/slack/slackevents"
+)
+
+func TestEmojiChangedKeepsTheCacheFresh(t *testing.T) {
+	b, fake := newTestBot(t, nil)
+	fake.answer("emoji.list", `{"ok":true,"emoji":{"party":"https://emoji.example/party.gif","shipit":"alias:squirrel"}}`)
+	exists := func(name string) bool {
+		t.Helper()
+		ok, err := b.customEmojiExists(name)
+		if err != nil {
+			t.Fatalf("emoji lookup failed: %v", err)
+		}
+		return ok
+	}
+	changed := func(event *slackevents.EmojiChangedEvent) {
+		t.Helper()
+		if err := b.handleEventMessage(context.Background(), callbackEvent("emoji_changed", event)); err != nil {
+			t.Fatalf("emoji_changed failed: %v", err)
+		}
+	}
+
+	if !exists(":party:") || !exists("shipit") || exists("mav") {
+		t.Fatalf("the loaded emoji don't match the workspace's")
+	}
+
+	changed(&slackevents.EmojiChangedEvent{Subtype: "add", Name: "mav", Value: "https://emoji.example/mav.png"})
+	changed(&slackevents.EmojiChangedEvent{Subtype: "remove", Names: []string{"party"}})
+	changed(&slackevents.EmojiChangedEvent{Subtype: "rename", OldName: "shipit", NewName: "ship-it", Value: "alias:squirrel"})
+	tests := []struct {
+		name string
+		want bool
+	}{
+		{"mav", true},
+		{"party", false},
+		{"shipit", false},
+		{"ship-it", true},
+	}
+	for _, tt := range tests {
+		if got := exists(tt.name); got != tt.want {
+			t.Errorf("%s exists = %t after the changes, want %t", tt.name, got, tt.want)
+		}
+	}
+	if calls := fake.calls("emoji.list"); len(calls) != 1 {
+		t.Errorf("listed the emoji %d times, want the changes applied to the cache", len(calls))
+	}
+
+	// A change the cache can't apply makes it load again
+	changed(&slackevents.EmojiChangedEvent{Subtype: "update"})
+	if !exists("party") {
+		t.Errorf("the reloaded cache doesn't have the workspace's emoji")
+	}
+	if calls := fake.calls("emoji.list"); len(calls) != 2 {
+		t.Errorf("listed the emoji %d times, want a reload after the unknown change", len(calls))
+	}
+}
+
+func TestEmojiChangedBeforeLoading(t *testing.T) {
+	b, fake := newTestBot(t, nil)
+	fake.answer("emoji.list", `{"ok":true,"emoji":{"party":"https://emoji.example/party.gif"}}`)
+
+	b.emoji.apply(&slackevents.EmojiChangedEvent{Subtype: "add", Name: "mav", Value: "https://emoji.example/mav.png"})
+	if b.emoji.len() != 0 {
+		t.Fatalf("the change was applied to a cache that wasn't loaded")
+	}
+	// The cache loads the whole list, the change included, on first use
+	if ok, err := b.customEmojiExists("party"); err != nil || !ok {
+		t.Errorf("got %t, %v, want the emoji loaded", ok, err)
+	}
+}
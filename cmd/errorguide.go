@@ -0,0 +1,97 @@
+/*
+Copyright © 2024 Pavlo Tarasiuk <pasha.tarasyuk@gmail.com>
+*/
+package cmd
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/ptarasyuk/mavbot/internal/blocks"
+	"github.com/slack-go/slack"
+)
+
+// errorGuide is what the user is told about a failure with a known cause, with the next step to take
+type errorGuide struct {
+	Text string
+	// Button and URL make an optional button taking the user to where the problem is fixed
+	Button string
+	URL    string
+}
+
+// errorGuides renders the guidance for the Slack error codes users can do something about.
+// The guides get the command that failed and the scopes the error names as missing, if any.
+var errorGuides = map[string]func(b *Bot, command slack.SlashCommand, needed string) errorGuide{
+	"not_in_channel": func(b *Bot, command slack.SlashCommand, _ string) errorGuide {
+		return errorGuide{Text: fmt.Sprintf("I'm not a member of this channel yet. Invite me with /invite %s and run %s again.", b.selfMention(), command.Command)}
+	},
+	"channel_not_found": func(b *Bot, command slack.SlashCommand, _ string) errorGuide {
+		return errorGuide{Text: fmt.Sprintf("I can't see that channel. If it's private, invite me with /invite %s there first.", b.selfMention())}
+	},
+	"is_archived": func(b *Bot, command slack.SlashCommand, _ string) errorGuide {
+		return errorGuide{Text: "The channel is archived. Unarchive it or pick another channel."}
+	},
+	"missing_scope": func(b *Bot, command slack.SlashCommand, needed string) errorGuide {
+		text := "MAVBot lacks a permission this command needs."
+		if needed != "" {
+			text = fmt.Sprintf("MAVBot lacks the %s permission this command needs.", needed)
+		}
+		guide := errorGuide{Text: text + " Ask a workspace admin to add it and reinstall the app."}
+		if command.APIAppID != "" {
+			guide.Button = "Open app settings"
+			guide.URL = fmt.Sprintf("https://api.slack.com/apps/%s/oauth", command.APIAppID)
+		}
+		return guide
+	},
+	"invalid_auth":     disconnectedGuide,
+	"not_authed":       disconnectedGuide,
+	"token_revoked":    disconnectedGuide,
+	"account_inactive": disconnectedGuide,
+}
+
+// disconnectedGuide is the guidance when the bot's token doesn't work anymore
+func disconnectedGuide(*Bot, slack.SlashCommand, string) errorGuide {
+	return errorGuide{Text: "MAVBot can't talk to Slack right now. Please let a MAVBot admin know."}
+}
+
+// slackErrorCode returns the error code Slack responded with and the missing scopes it named,
+// or an empty code when err didn't come from Slack
+func slackErrorCode(err error) (code, needed string) {
+	var slackErr slack.SlackErrorResponse
+	if errors.As(err, &slackErr) {
+		return slackErr.Err, ""
+	}
+	var apiErr *webAPIError
+	if errors.As(err, &apiErr) {
+		return apiErr.Code, apiErr.Needed
+	}
+	return "", ""
+}
+
+// selfMention returns a mention of the bot, or its name before it knows who it is
+func (b *Bot) selfMention() string {
+	if b.selfUserID == "" {
+		return "@" + b.cfg.DisplayName
+	}
+	return userRef(b.selfUserID)
+}
+
+// guidedErrorResponse turns a failure of the command with a known cause into a response telling
+// the user what to do about it. It reports false for the failures it has no guidance for.
+func (b *Bot) guidedErrorResponse(command slack.SlashCommand, err error) (*SlashResponse, bool) {
+	code, needed := slackErrorCode(err)
+	if errors.Is(err, errPostingStopped) {
+		code = "account_inactive"
+	}
+	render, ok := errorGuides[code]
+	if !ok {
+		return nil, false
+	}
+	guide := render(b, command, needed)
+
+	response := []slack.Block{blocks.Section(":warning: " + guide.Text)}
+	if guide.URL != "" {
+		response = append(response, blocks.ActionsRow(blocks.LinkButton(guide.Button, guide.URL)))
+	}
+	return &SlashResponse{ResponseType: slack.ResponseTypeEphemeral, Text: guide.Text, Blocks: response}, true
+}
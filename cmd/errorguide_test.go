@@ -0,0 +1,132 @@
+/*
+Copyright © 2024 Pavlo Tarasiuk <pasha.tarasyuk@gmail.com>
+*/
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/slack-go/slack"
+)
+
+func TestGuidedErrorResponse(t *testing.T) {
+	const disconnected = "MAVBot can't talk to Slack right now. Please let a MAVBot admin know."
+	tests := []struct {
+		name  string
+		err   error
+		appID string
+		text  string
+		// button is the label of the suggested action, empty for none
+		button, url string
+	}{
+		{
+			name: "not in channel",
+			err:  fmt.Errorf("failed to post message: %w", slack.SlackErrorResponse{Err: "not_in_channel"}),
+			text: "I'm not a member of this channel yet. Invite me with /invite <@U0BOT> and run /test-guide again.",
+		},
+		{
+			name: "channel not found",
+			err:  slack.SlackErrorResponse{Err: "channel_not_found"},
+			text: "I can't see that channel. If it's private, invite me with /invite <@U0BOT> there first.",
+		},
+		{
+			name: "archived",
+			err:  slack.SlackErrorResponse{Err: "is_archived"},
+			text: "The channel is archived. Unarchive it or pick another channel.",
+		},
+		{
+			name: "missing scope",
+			err:  slack.SlackErrorResponse{Err: "missing_scope"},
+			text: "MAVBot lacks a permission this command needs. Ask a workspace admin to add it and reinstall the app.",
+		},
+		{
+			name:   "missing scope named with the app",
+			err:    fmt.Errorf("failed to edit canvas: %w", &webAPIError{Method: "canvases.edit", Code: "missing_scope", Needed: "canvases:write"}),
+			appID:  "A0APP",
+			text:   "MAVBot lacks the canvases:write permission this command needs. Ask a workspace admin to add it and reinstall the app.",
+			button: "Open app settings",
+			url:    "https://api.slack.com/apps/A0APP/oauth",
+		},
+		{name: "revoked token", err: slack.SlackErrorResponse{Err: "token_revoked"}, text: disconnected},
+		{name: "invalid auth", err: slack.SlackErrorResponse{Err: "invalid_auth"}, text: disconnected},
+		{name: "posting stopped", err: fmt.Errorf("failed to post: %w", errPostingStopped), text: disconnected},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b, _ := newTestBot(t, nil)
+			b.selfUserID = "U0BOT"
+			response, ok := b.guidedErrorResponse(slack.SlashCommand{Command: "/test-guide", APIAppID: tt.appID}, tt.err)
+			if !ok {
+				t.Fatalf("no guidance for %v", tt.err)
+			}
+			if response.Text != tt.text || response.ResponseType != slack.ResponseTypeEphemeral {
+				t.Errorf("response = %q (%s), want %q ephemerally", response.Text, response.ResponseType, tt.text)
+			}
+			raw, _ := json.Marshal(response.Blocks)
+			if !strings.Contains(string(raw), `"text":":warning: `) {
+				t.Errorf("blocks = %s, want the guidance with a warning", raw)
+			}
+			hasButton := strings.Contains(string(raw), `"type":"actions"`)
+			if hasButton != (tt.button != "") {
+				t.Fatalf("blocks = %s, want a suggested action: %t", raw, tt.button != "")
+			}
+			if hasButton && (!strings.Contains(string(raw), tt.button) || !strings.Contains(string(raw), tt.url)) {
+				t.Errorf("blocks = %s, want the %q button to %s", raw, tt.button, tt.url)
+			}
+			if err := validateBlocks(response.Blocks); err != nil {
+				t.Errorf("invalid blocks: %v", err)
+			}
+		})
+	}
+}
+
+func TestNoGuidanceForUnknownErrors(t *testing.T) {
+	b, _ := newTestBot(t, nil)
+	for _, err := range []error{
+		errors.New("disk full"),
+		slack.SlackErrorResponse{Err: "ratelimited"},
+		&webAPIError{Method: "canvases.edit", Code: "canvas_editing_failed"},
+	} {
+		if _, ok := b.guidedErrorResponse(slack.SlashCommand{Command: "/test-guide"}, err); ok {
+			t.Errorf("guided the user about %v", err)
+		}
+	}
+}
+
+func TestSelfMentionBeforeIdentified(t *testing.T) {
+	b, _ := newTestBot(t, nil)
+	if got, want := b.selfMention(), "@"+b.cfg.DisplayName; got != want {
+		t.Errorf("selfMention() = %q, want %q", got, want)
+	}
+}
+
+func TestFailedCommandIsAnsweredWithGuidance(t *testing.T) {
+	captureLog(t)
+	registerTestCommand(t, &slashCommand{
+		Name: "/test-guide",
+		Handler: func(*Bot, slack.SlashCommand) (*SlashResponse, error) {
+			return nil, fmt.Errorf("failed to post message: %w", slack.SlackErrorResponse{Err: "not_in_channel"})
+		},
+	})
+	b, _ := newTestBot(t, nil)
+	b.selfUserID = "U0BOT"
+	payload, err := b.handleSlashCommand(slack.SlashCommand{Command: "/test-guide", UserID: "U1", ChannelID: "C1"})
+	if err != nil {
+		t.Fatalf("the guided failure was returned: %v", err)
+	}
+	raw, _ := json.Marshal(payload)
+	var answer slack.Msg
+	if err := json.Unmarshal(raw, &answer); err != nil {
+		t.Fatalf("invalid answer %s", raw)
+	}
+	if want := "I'm not a member of this channel yet. Invite me with /invite <@U0BOT> and run /test-guide again."; answer.Text != want {
+		t.Errorf("answered %q, want %q", answer.Text, want)
+	}
+	if got := b.metrics.get(metricHandlerErrors); got != 0 {
+		t.Errorf("reported %d errors, the user was told what to do", got)
+	}
+}
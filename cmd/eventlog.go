@@ -0,0 +1,155 @@
+/*
+Copyright © 2024 Pavlo Tarasiuk <pasha.tarasyuk@gmail.com>
+*/
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sync"
+
+	"github.com/slack-go/slack"
+	"github.com/slack-go/slack/slackevents"
+	"github.com/slack-go/slack/socketmode"
+)
+
+// eventRecorder appends the raw socketmode messages the bot receives to a file, one JSON document
+// per line. When the file would grow past maxSize it is moved aside to path.1 and started over.
+type eventRecorder struct {
+	path    string
+	maxSize int64
+
+	mu   sync.Mutex
+	file *os.File
+	size int64
+}
+
+// newEventRecorder opens the event log at path for appending
+func newEventRecorder(path string, maxSize int64) (*eventRecorder, error) {
+	r := &eventRecorder{path: path, maxSize: maxSize}
+	if err := r.open(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// open opens the log file and picks up its current size
+func (r *eventRecorder) open() error {
+	file, err := os.OpenFile(r.path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o600)
+	if err != nil {
+		return fmt.Errorf("failed to open event log: %w", err)
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return fmt.Errorf("failed to open event log: %w", err)
+	}
+	r.file = file
+	r.size = info.Size()
+	return nil
+}
+
+// record writes the raw message behind event to the log, events that don't come from Slack are skipped
+func (r *eventRecorder) record(event socketmode.Event) error {
+	var raw []byte
+	switch {
+	case event.Type == socketmode.EventTypeErrorBadMessage:
+		bad, ok := event.Data.(*socketmode.ErrorBadMessage)
+		if !ok {
+			return nil
+		}
+		raw = bad.Message
+	case event.Request != nil:
+		var err error
+		if raw, err = json.Marshal(event.Request); err != nil {
+			return fmt.Errorf("failed to encode event: %w", err)
+		}
+	default:
+		return nil
+	}
+	line := append(append([]byte{}, raw...), '\n')
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.maxSize > 0 && r.size > 0 && r.size+int64(len(line)) > r.maxSize {
+		if err := r.rotate(); err != nil {
+			return err
+		}
+	}
+	n, err := r.file.Write(line)
+	r.size += int64(n)
+	if err != nil {
+		return fmt.Errorf("failed to record event: %w", err)
+	}
+	return nil
+}
+
+// rotate moves the full log aside, replacing the previous one, and starts an empty log
+func (r *eventRecorder) rotate() error {
+	if err := r.file.Close(); err != nil {
+		return fmt.Errorf("failed to rotate event log: %w", err)
+	}
+	if err := os.Rename(r.path, r.path+".1"); err != nil {
+		return fmt.Errorf("failed to rotate event log: %w", err)
+	}
+	return r.open()
+}
+
+// Flush commits the recorded events to disk
+func (r *eventRecorder) Flush() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if err := r.file.Sync(); err != nil {
+		return fmt.Errorf("failed to flush event log: %w", err)
+	}
+	return nil
+}
+
+// Close closes the log file
+func (r *eventRecorder) Close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.file.Close()
+}
+
+// parseRecordedEvent turns a recorded message back into the event socketmode would have delivered.
+// Messages socketmode fails to parse become EventTypeErrorBadMessage events, as they do live.
+func parseRecordedEvent(raw json.RawMessage) (socketmode.Event, error) {
+	req := &socketmode.Request{}
+	if err := json.Unmarshal(raw, req); err != nil {
+		return socketmode.Event{}, fmt.Errorf("failed to decode recorded event: %w", err)
+	}
+
+	badMessage := func(cause error) socketmode.Event {
+		return socketmode.Event{
+			Type: socketmode.EventTypeErrorBadMessage,
+			Data: &socketmode.ErrorBadMessage{Cause: cause, Message: raw},
+		}
+	}
+	switch req.Type {
+	case socketmode.RequestTypeEventsAPI:
+		event, err := slackevents.ParseEvent(req.Payload, slackevents.OptionNoVerifyToken())
+		if err != nil {
+			return badMessage(fmt.Errorf("parsing Events API event: %w", err)), nil
+		}
+		return socketmode.Event{Type: socketmode.EventTypeEventsAPI, Data: event, Request: req}, nil
+	case socketmode.RequestTypeSlashCommands:
+		var command slack.SlashCommand
+		if err := json.Unmarshal(req.Payload, &command); err != nil {
+			return badMessage(fmt.Errorf("parsing slash command: %w", err)), nil
+		}
+		return socketmode.Event{Type: socketmode.EventTypeSlashCommand, Data: command, Request: req}, nil
+	case socketmode.RequestTypeInteractive:
+		var callback slack.InteractionCallback
+		if err := json.Unmarshal(req.Payload, &callback); err != nil {
+			return badMessage(fmt.Errorf("parsing interaction callback: %w", err)), nil
+		}
+		return socketmode.Event{Type: socketmode.EventTypeInteractive, Data: callback, Request: req}, nil
+	case socketmode.RequestTypeHello:
+		return socketmode.Event{Type: socketmode.EventTypeHello, Request: req}, nil
+	case socketmode.RequestTypeDisconnect:
+		return socketmode.Event{Type: socketmode.EventTypeDisconnect, Request: req}, nil
+	}
+	return badMessage(fmt.Errorf("processing WebSocket message: encountered unsupported type %q", req.Type)), nil
+}
@@ -0,0 +1,165 @@
+/*
+Copyright © 2024 Pavlo Tarasiuk <pasha.tarasyuk@gmail.com>
+*/
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/slack-go/slack"
+	"github.com/slack-go/slack/slackevents"
+	"github.com/slack-go/slack/socketmode"
+)
+
+const (
+	recordedSlash   = `{"type":"slash_commands","envelope_id":"e1","payload":{"command":"/test-replay","text":"one","user_id":"U1","channel_id":"C1"}}`
+	recordedMention = `{"type":"events_api","envelope_id":"e2","payload":{"type":"event_callback","event_id":"Ev1","event":{"type":"app_mention","user":"U1","text":"hi","channel":"C1","ts":"1.1"}}}`
+	recordedUnknown = `{"type":"events_api","envelope_id":"e3","payload":{"type":"event_callback","event":{"type":"made_up_event","user":"U1"}}}`
+)
+
+func TestParseRecordedEvent(t *testing.T) {
+	tests := []struct {
+		name string
+		raw  string
+		want socketmode.EventType
+		// check looks into the parsed event, nil when the type says it all
+		check func(t *testing.T, event socketmode.Event)
+	}{
+		{"slash command", recordedSlash, socketmode.EventTypeSlashCommand, func(t *testing.T, event socketmode.Event) {
+			command, ok := event.Data.(slack.SlashCommand)
+			if !ok || command.Command != "/test-replay" || command.Text != "one" || command.UserID != "U1" {
+				t.Errorf("got command %+v", event.Data)
+			}
+			if event.Request.EnvelopeID != "e1" {
+				t.Errorf("got envelope %q, want e1", event.Request.EnvelopeID)
+			}
+		}},
+		{"events api", recordedMention, socketmode.EventTypeEventsAPI, func(t *testing.T, event socketmode.Event) {
+			inner, ok := event.Data.(slackevents.EventsAPIEvent)
+			if !ok || inner.InnerEvent.Type != string(slackevents.AppMention) {
+				t.Errorf("got event %+v, want an app mention", event.Data)
+			}
+		}},
+		{"event slack-go can't parse", recordedUnknown, socketmode.EventTypeErrorBadMessage, func(t *testing.T, event socketmode.Event) {
+			bad, ok := event.Data.(*socketmode.ErrorBadMessage)
+			if !ok || string(bad.Message) != recordedUnknown {
+				t.Errorf("got %+v, want the raw message kept", event.Data)
+			}
+		}},
+		{"hello", `{"type":"hello"}`, socketmode.EventTypeHello, nil},
+		{"disconnect", `{"type":"disconnect","reason":"refresh_requested"}`, socketmode.EventTypeDisconnect, nil},
+		{"unknown type", `{"type":"surprise"}`, socketmode.EventTypeErrorBadMessage, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			event, err := parseRecordedEvent([]byte(tt.raw))
+			if err != nil {
+				t.Fatalf("parse failed: %v", err)
+			}
+			if event.Type != tt.want {
+				t.Fatalf("got %s, want %s", event.Type, tt.want)
+			}
+			if tt.check != nil {
+				tt.check(t, event)
+			}
+		})
+	}
+
+	if _, err := parseRecordedEvent([]byte("not json")); err == nil {
+		t.Errorf("parsed a line that isn't JSON")
+	}
+}
+
+func TestRecordAndReplay(t *testing.T) {
+	b, _ := newTestBot(t, nil)
+	path := filepath.Join(t.TempDir(), "events.jsonl")
+	recorder, err := newEventRecorder(path, 0)
+	if err != nil {
+		t.Fatalf("failed to open event log: %v", err)
+	}
+	b.recorder = recorder
+	var handled []string
+	registerTestCommand(t, &slashCommand{
+		Name: "/test-replay",
+		Handler: func(_ *Bot, command slack.SlashCommand) (*SlashResponse, error) {
+			handled = append(handled, command.Text)
+			return ephemeral("replayed " + command.Text), nil
+		},
+	})
+
+	// Record what the bot receives live: a command, then one it knows nothing of
+	for _, raw := range []string{recordedSlash, strings.Replace(recordedSlash, `"one"`, `"two"`, 1), `{"type":"surprise"}`} {
+		event, err := parseRecordedEvent([]byte(raw))
+		if err != nil {
+			t.Fatal(err)
+		}
+		b.processEvent(context.Background(), event, &fakeSocket{})
+	}
+	if err := recorder.Close(); err != nil {
+		t.Fatal(err)
+	}
+	recorded, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSuffix(string(recorded), "\n"), "\n")
+	if len(lines) != 3 || lines[2] != `{"type":"surprise"}` {
+		t.Fatalf("recorded %q, want the three messages with the unparsed one as received", lines)
+	}
+
+	// Replaying the log on a fresh bot handles the commands again in the order they came in
+	logs := captureLog(t)
+	handled = nil
+	replayer, _ := newTestBot(t, nil)
+	if err := replayer.replay(context.Background(), bytes.NewReader(append(recorded, '\n'))); err != nil {
+		t.Fatalf("replay failed: %v", err)
+	}
+	if strings.Join(handled, ",") != "one,two" {
+		t.Errorf("replay handled %q, want one,two", handled)
+	}
+	if !strings.Contains(logs.String(), "dry-run: ack e1 with") || !strings.Contains(logs.String(), "replayed one") {
+		t.Errorf("the acknowledgement wasn't logged: %s", logs)
+	}
+
+	if err := replayer.replay(context.Background(), strings.NewReader(recordedSlash+"\nnot json\n")); err == nil || !strings.Contains(err.Error(), "line 2") {
+		t.Errorf("got %v, want the broken line reported", err)
+	}
+}
+
+func TestEventRecorderRotates(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "events.jsonl")
+	recorder, err := newEventRecorder(path, int64(len(recordedSlash)+10))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer recorder.Close()
+	for _, raw := range []string{`{"type":"first"}`, `{"type":"second"}`} {
+		event, _ := parseRecordedEvent([]byte(raw))
+		if err := recorder.record(event); err != nil {
+			t.Fatalf("record failed: %v", err)
+		}
+	}
+	// Events that didn't come over the socket have nothing to record
+	if err := recorder.record(socketmode.Event{Type: socketmode.EventTypeConnecting}); err != nil {
+		t.Fatal(err)
+	}
+	event, _ := parseRecordedEvent([]byte(recordedSlash))
+	if err := recorder.record(event); err != nil {
+		t.Fatal(err)
+	}
+
+	previous, _ := os.ReadFile(path + ".1")
+	current, _ := os.ReadFile(path)
+	if string(previous) != "{\"type\":\"first\"}\n{\"type\":\"second\"}\n" {
+		t.Errorf("the rotated log holds %q", previous)
+	}
+	replayed, err := parseRecordedEvent(bytes.TrimSpace(current))
+	if err != nil || replayed.Type != socketmode.EventTypeSlashCommand || strings.Count(string(current), "\n") != 1 {
+		t.Errorf("the current log holds %q, want only the last command", current)
+	}
+}
@@ -0,0 +1,193 @@
+/*
+Copyright © 2024 Pavlo Tarasiuk <pasha.tarasyuk@gmail.com>
+*/
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"runtime/debug"
+
+	"github.com/slack-go/slack"
+	"github.com/slack-go/slack/slackevents"
+	"github.com/slack-go/slack/socketmode"
+)
+
+// acker acknowledges socketmode requests, socketmode.Client being the real one
+type acker interface {
+	Ack(req socketmode.Request, payload ...interface{})
+}
+
+// processEvent dispatches a single socketmode event to its handler and acknowledges it.
+// The waits before trying a failed interaction again end with ctx.
+func (b *Bot) processEvent(ctx context.Context, event socketmode.Event, socket acker) {
+	if b.recorder != nil {
+		if err := b.recorder.record(event); err != nil {
+			log.Println(err)
+		}
+	}
+
+	// Add more use cases here if you want to listen to other events.
+	switch event.Type {
+	// handle EventAPI events
+	case socketmode.EventTypeEventsAPI:
+		// The Event sent on the chanel is not the same as the EventAPI events so we need to type cast it
+		eventsAPIEvent, ok := event.Data.(slackevents.EventsAPIEvent)
+		if !ok {
+			log.Printf("Could not type cast the event to the EventsAPIEvent: %+v\n", event)
+			return
+		}
+		// We need to send an Acknowledge to the slack server
+		socket.Ack(*event.Request)
+		// Slack delivers an event again when the acknowledgement came late, it was handled already
+		if callback, ok := eventsAPIEvent.Data.(*slackevents.EventsAPICallbackEvent); ok && callback.EventID != "" {
+			if b.events.seen(callback.EventID) {
+				log.Printf("Dropped redelivered event %s\n", callback.EventID)
+				return
+			}
+		}
+		// Every event type has its own time to be processed in
+		eventType := eventsAPIEvent.InnerEvent.Type
+		ctx, cancel := b.eventContext(eventType)
+		defer cancel()
+		// Now we have an Events API event, but this event type can in turn be many types, so we actually need another type switch
+		err := runWithin(ctx, eventType, func() error {
+			return b.runHandler(string(socketmode.EventTypeEventsAPI), func() error {
+				return b.handleEventMessage(ctx, eventsAPIEvent)
+			})
+		}, b.lateHandlers)
+		b.emitEvent(eventsAPISummary(eventsAPIEvent), err)
+		// Messages dropped by a rate limit are expected under load, as are handlers outlasting their timeout
+		// and messages posted while the bot shuts down for an inactive token
+		var limited *rateLimitedError
+		if errors.As(err, &limited) || errors.Is(err, context.DeadlineExceeded) || errors.Is(err, errPostingStopped) {
+			log.Println(err)
+		} else if err != nil {
+			b.reportError(eventType, err)
+		}
+
+	// handle Slash Commands
+	case socketmode.EventTypeSlashCommand:
+		// Just like before, type cast to the correct event type, this time a SlashEvent
+		command, ok := event.Data.(slack.SlashCommand)
+		if !ok {
+			log.Printf("Could not type cast the message to a SlashCommand: %+v\n", command)
+			return
+		}
+		// handleSlashCommand will take care of the command
+		var payload interface{}
+		err := b.runHandler(command.Command, func() (err error) {
+			payload, err = b.handleSlashCommand(command)
+			return err
+		})
+		b.emitEvent(slashCommandSummary(command), err)
+		if err != nil {
+			b.reportError(command.Command, err)
+			payload = ephemeral(fmt.Sprintf(":x: Sorry, %s failed, the error has been reported", command.Command)).message()
+		}
+		// Do'nt forget to acknowledge the request and send the payload
+		// The payload is the response
+		socket.Ack(*event.Request, payload)
+
+	// handle Interactive Events
+	case socketmode.EventTypeInteractive:
+		interaction, ok := event.Data.(slack.InteractionCallback)
+		if !ok {
+			log.Printf("Could not type cast the message to a Interaction callback: %+v\n", interaction)
+			return
+		}
+
+		// View submissions are answered in the acknowledgement, the other interactions are acknowledged
+		// before they are processed, so retries don't hold the acknowledgement past Slack's deadline
+		answered := interaction.Type == slack.InteractionTypeViewSubmission
+		if !answered {
+			socket.Ack(*event.Request)
+		}
+		var payload interface{}
+		var err error
+		// The actions that went through are skipped when retrying, they aren't safe to repeat
+		completed := make(map[string]bool)
+		dlErr := b.processWithRetries(ctx, *event.Request, b.interactionRetries(interaction), func() error {
+			err = b.runHandler(string(socketmode.EventTypeInteractive), func() (err error) {
+				payload, err = b.handleInteractiveEvent(interaction, len(event.Request.Payload), completed)
+				return err
+			})
+			return err
+		})
+		b.emitEvent(interactionSummary(interaction), err)
+		if dlErr != nil {
+			b.reportError(string(socketmode.EventTypeInteractive), dlErr)
+		}
+		if answered {
+			socket.Ack(*event.Request, payload)
+		}
+
+	// handle Events API events slack-go couldn't parse
+	case socketmode.EventTypeErrorBadMessage:
+		err := b.runHandler(string(socketmode.EventTypeErrorBadMessage), func() error {
+			return b.handleBadMessage(event, socket)
+		})
+		if err != nil {
+			log.Println(err)
+		}
+	}
+}
+
+// panicError is returned by recoverHandler for a handler that panicked
+type panicError struct {
+	Name  string
+	Value interface{}
+}
+
+// Error implements error
+func (e *panicError) Error() string {
+	return fmt.Sprintf("panic in %s handler: %v", e.Name, e.Value)
+}
+
+// recoverHandler runs handler and turns a panic inside it into a report instead of a crash.
+// The panic is logged with its stack trace, counted and, when configured, posted to the
+// error channel, then returned as a *panicError so the event counts as failed; processing
+// carries on with the next event.
+func (b *Bot) recoverHandler(name string, handler func() error) (err error) {
+	defer func() {
+		r := recover()
+		if r == nil {
+			return
+		}
+		log.Printf("panic in %s handler: %v\n%s", name, r, debug.Stack())
+		b.metrics.inc(metricHandlerPanics)
+		b.postToErrorChannel(fmt.Sprintf(":rotating_light: MAVBot recovered from a panic in the %s handler: %v", name, r))
+		err = &panicError{Name: name, Value: r}
+	}()
+	return handler()
+}
+
+// reportError logs an error a handler returned, counts it and, when configured, posts it to the
+// error channel. The bot keeps processing events afterwards. Panics were reported when they
+// were recovered.
+func (b *Bot) reportError(name string, err error) {
+	var panicked *panicError
+	if errors.As(err, &panicked) {
+		return
+	}
+	log.Printf("%s handler failed: %v\n", name, err)
+	b.metrics.inc(metricHandlerErrors)
+	b.postToErrorChannel(fmt.Sprintf(":warning: The %s handler failed: %v", name, err))
+}
+
+// postToErrorChannel posts the report to the error channel unless there is none
+func (b *Bot) postToErrorChannel(text string) {
+	if b.cfg.ErrorChannel == "" {
+		return
+	}
+	_, err := b.postMessage(outboundMessage{
+		Channel:  b.cfg.ErrorChannel,
+		Priority: priorityHigh,
+		Text:     text,
+	})
+	if err != nil {
+		log.Printf("failed to report to the error channel: %v\n", err)
+	}
+}
@@ -0,0 +1,113 @@
+/*
+Copyright © 2024 Pavlo Tarasiuk <pasha.tarasyuk@gmail.com>
+*/
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/slack-go/slack"
+)
+
+// registerTestCommand registers a slash command for the duration of the test
+func registerTestCommand(t *testing.T, command *slashCommand) {
+	t.Helper()
+	registerSlashCommand(command)
+	t.Cleanup(func() { delete(slashCommands, command.Name) })
+}
+
+func TestHandlerPanicIsRecovered(t *testing.T) {
+	logs := captureLog(t)
+	cfg := testConfig(t)
+	cfg.ErrorChannel = "C0ERRORS"
+	b, fake := newTestBot(t, cfg)
+	registerTestCommand(t, &slashCommand{
+		Name: "/test-panic",
+		Handler: func(*Bot, slack.SlashCommand) (*SlashResponse, error) {
+			panic("handler exploded")
+		},
+	})
+	registerTestCommand(t, &slashCommand{
+		Name: "/test-ok",
+		Handler: func(*Bot, slack.SlashCommand) (*SlashResponse, error) {
+			return ephemeral("still here"), nil
+		},
+	})
+	socket := &fakeSocket{}
+
+	b.processEvent(context.Background(), slashEvent("e1", slack.SlashCommand{Command: "/test-panic", UserID: "U1", ChannelID: "C1"}), socket)
+	b.processEvent(context.Background(), slashEvent("e2", slack.SlashCommand{Command: "/test-ok", UserID: "U1", ChannelID: "C1"}), socket)
+
+	if got := b.metrics.get(metricHandlerPanics); got != 1 {
+		t.Errorf("counted %d panics, want 1", got)
+	}
+	if !strings.Contains(logs.String(), "panic in /test-panic handler: handler exploded") || !strings.Contains(logs.String(), "goroutine") {
+		t.Errorf("the panic wasn't logged with its stack trace:\n%s", logs)
+	}
+	reports := fake.calls("chat.postMessage")
+	if len(reports) != 1 || reports[0].Form.Get("channel") != "C0ERRORS" || !strings.Contains(reports[0].Form.Get("text"), "handler exploded") {
+		t.Errorf("got posts %v, want the panic reported to the error channel", fake.posts())
+	}
+	acks := socket.acked()
+	if len(acks) != 2 {
+		t.Fatalf("got %d acknowledgements, want both commands acknowledged", len(acks))
+	}
+	// The panicked command failed, it isn't answered with an empty acknowledgement
+	if msg, ok := acks[0].Payload.(slack.Msg); !ok || !strings.Contains(msg.Text, "/test-panic failed") {
+		t.Errorf("the panicked command answered %+v, want the failure notice", acks[0].Payload)
+	}
+	if !strings.Contains(string(acks[1].Payload.(json.RawMessage)), "still here") {
+		t.Errorf("the command after the panic answered %s", acks[1].Payload)
+	}
+	if got := b.metrics.get(metricHandlerErrors); got != 0 {
+		t.Errorf("counted the panic as %d handler errors too", got)
+	}
+}
+
+func TestRecoveredPanicIsAnError(t *testing.T) {
+	captureLog(t)
+	b, _ := newTestBot(t, nil)
+	err := b.recoverHandler("test", func() error { panic("handler exploded") })
+	var panicked *panicError
+	if !errors.As(err, &panicked) || err.Error() != "panic in test handler: handler exploded" {
+		t.Errorf("recoverHandler() error = %v, want the panic returned", err)
+	}
+	if err := b.recoverHandler("test", func() error { return nil }); err != nil {
+		t.Errorf("recoverHandler() error = %v without a panic", err)
+	}
+}
+
+func TestHandlerErrorIsReported(t *testing.T) {
+	cfg := testConfig(t)
+	cfg.ErrorChannel = "C0ERRORS"
+	b, fake := newTestBot(t, cfg)
+	registerTestCommand(t, &slashCommand{
+		Name: "/test-fail",
+		Handler: func(*Bot, slack.SlashCommand) (*SlashResponse, error) {
+			return nil, errors.New("disk full")
+		},
+	})
+	socket := &fakeSocket{}
+
+	b.processEvent(context.Background(), slashEvent("e1", slack.SlashCommand{Command: "/test-fail", UserID: "U1", ChannelID: "C1"}), socket)
+
+	if got := b.metrics.get(metricHandlerErrors); got != 1 {
+		t.Errorf("counted %d handler errors, want 1", got)
+	}
+	posts := fake.posts()
+	if len(posts) != 1 || !strings.Contains(posts[0], "disk full") {
+		t.Errorf("got posts %q, want the error reported to the error channel", posts)
+	}
+	acks := socket.acked()
+	if len(acks) != 1 {
+		t.Fatalf("got %d acknowledgements, want 1", len(acks))
+	}
+	msg, ok := acks[0].Payload.(slack.Msg)
+	if !ok || msg.ResponseType != slack.ResponseTypeEphemeral || !strings.Contains(msg.Text, "/test-fail failed") {
+		t.Errorf("got acknowledgement %+v, want an ephemeral failure notice", acks[0].Payload)
+	}
+}
@@ -0,0 +1,157 @@
+/*
+Copyright © 2024 Pavlo Tarasiuk <pasha.tarasyuk@gmail.com>
+*/
+package cmd
+
+import (
+	"encoding/csv"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/slack-go/slack"
+)
+
+// surveyCSVHeader names the columns of the survey report
+var surveyCSVHeader = []string{"time", "user", "answer", "channel", "message_ts", "article_ts", "survey", "requester"}
+
+// writeSurveyCSV writes the responses given since the time to file as CSV, one response at a
+// time so big reports don't have to fit in memory. It returns how many responses it wrote.
+func (b *Bot) writeSurveyCSV(file *os.File, since time.Time) (int, error) {
+	keys, err := b.store.Keys(collectionSurveys)
+	if err != nil {
+		return 0, err
+	}
+	w := csv.NewWriter(file)
+	if err := w.Write(surveyCSVHeader); err != nil {
+		return 0, fmt.Errorf("failed to write survey report: %w", err)
+	}
+	written := 0
+	for _, key := range keys {
+		var response surveyResponse
+		ok, err := b.store.Get(collectionSurveys, key, &response)
+		if err != nil {
+			return 0, err
+		}
+		if !ok || response.Time.Before(since) {
+			continue
+		}
+		err = w.Write([]string{
+			response.Time.UTC().Format(time.RFC3339), response.User, response.Answer, response.Channel,
+			response.MessageTS, response.ArticleTS, response.Survey, response.Requester,
+		})
+		if err != nil {
+			return 0, fmt.Errorf("failed to write survey report: %w", err)
+		}
+		written++
+	}
+	w.Flush()
+	if err := w.Error(); err != nil {
+		return 0, fmt.Errorf("failed to write survey report: %w", err)
+	}
+	return written, nil
+}
+
+// uploadSurveyReport writes the report to a temporary file and streams it to the user's DM.
+// The file is removed once uploaded.
+func (b *Bot) uploadSurveyReport(userID string, since time.Time, title string) (int, error) {
+	file, err := os.CreateTemp("", "mavbot-report-*.csv")
+	if err != nil {
+		return 0, fmt.Errorf("failed to create survey report: %w", err)
+	}
+	defer func() {
+		file.Close()
+		if err := os.Remove(file.Name()); err != nil {
+			log.Printf("failed to remove survey report %s: %v\n", file.Name(), err)
+		}
+	}()
+
+	written, err := b.writeSurveyCSV(file, since)
+	if err != nil || written == 0 {
+		return 0, err
+	}
+	info, err := file.Stat()
+	if err != nil {
+		return 0, fmt.Errorf("failed to read survey report: %w", err)
+	}
+	if _, err := file.Seek(0, 0); err != nil {
+		return 0, fmt.Errorf("failed to read survey report: %w", err)
+	}
+	channelID, err := b.openDM(userID)
+	if err != nil {
+		return 0, err
+	}
+	upload := func() error {
+		_, err := b.api().UploadFileV2(slack.UploadFileV2Parameters{
+			Reader:   file,
+			FileSize: int(info.Size()),
+			Filename: "mavbot-surveys.csv",
+			Title:    title,
+			Channel:  channelID,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to upload survey report: %w", err)
+		}
+		return nil
+	}
+	// Big reports take a while to upload, a note in the DM shows how it is getting on
+	note, err := b.postMessage(outboundMessage{
+		Channel: channelID,
+		Invoker: userID,
+		Text:    fmt.Sprintf("Uploading %d survey responses…", written),
+	})
+	if err != nil {
+		return 0, err
+	}
+	if note == "" {
+		// The note waits for the outbound limit, so there is nothing to react on
+		err = upload()
+	} else {
+		err = b.withProgress(channelID, note, upload)
+	}
+	if err != nil {
+		return 0, err
+	}
+	return written, nil
+}
+
+// handleReport sends the admin the survey responses as a CSV file: /report [days]
+func (b *Bot) handleReport(command slack.SlashCommand) (*SlashResponse, error) {
+	var since time.Time
+	title := "MAVBot survey responses"
+	if arg := strings.TrimSpace(command.Text); arg != "" {
+		days, err := strconv.Atoi(arg)
+		if err != nil || days < 1 {
+			return ephemeral(usageOf("/report")), nil
+		}
+		since = b.now().AddDate(0, 0, -days)
+		title = fmt.Sprintf("%s of the last %d days", title, days)
+	}
+
+	return b.respondLater(command, "Exporting the survey responses…", func() (*SlashResponse, error) {
+		written, err := b.uploadSurveyReport(command.UserID, since, title)
+		if err != nil {
+			return nil, err
+		}
+		if written == 0 {
+			return ephemeral("There are no survey responses to report"), nil
+		}
+		return ephemeral(fmt.Sprintf("I sent you a DM with %d survey responses", written)), nil
+	}), nil
+}
+
+func init() {
+	registerSlashCommand(&slashCommand{
+		Name:          "/report",
+		Description:   "Export the survey responses as a CSV file to your DM",
+		Usage:         "[days]",
+		Example:       "/report 30",
+		Category:      categoryAdmin,
+		AdminOnly:     true,
+		MaxConcurrent: 1,
+		Handler:       (*Bot).handleReport,
+	})
+}
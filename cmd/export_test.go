@@ -0,0 +1,206 @@
+/*
+Copyright © 2024 Pavlo Tarasiuk <pasha.tarasyuk@gmail.com>
+*/
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/slack-go/slack"
+)
+
+func TestWriteSurveyCSV(t *testing.T) {
+	b, _ := newTestBot(t, nil)
+	seedSurveyResponses(t, b, 3)
+	file, err := os.Create(t.TempDir() + "/report.csv")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+
+	// The first response is left out, it was given at 10:00
+	written, err := b.writeSurveyCSV(file, time.Date(2024, 4, 5, 10, 0, 30, 0, time.UTC))
+	if err != nil {
+		t.Fatalf("writeSurveyCSV() error = %v", err)
+	}
+	if written != 2 {
+		t.Errorf("wrote %d responses, want 2", written)
+	}
+	data, err := os.ReadFile(file.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "time,user,answer,channel,message_ts,article_ts,survey,requester\n" +
+		"2024-04-05T10:01:00Z,U2,yes,C1,1712345678.000100,,,\n" +
+		"2024-04-05T10:02:00Z,U3,yes,C1,1712345678.000100,,,\n"
+	if string(data) != want {
+		t.Errorf("report =\n%s\nwant\n%s", data, want)
+	}
+}
+
+// runReport runs /report as an admin and returns the final response
+func runReport(t *testing.T, b *Bot, fake *fakeSlack, text string) string {
+	t.Helper()
+	_, err := b.handleSlashCommand(slack.SlashCommand{
+		Command: "/report", Text: text, UserID: "U0ADMIN", ChannelID: "C1", ResponseURL: fake.apiURL() + "respond",
+	})
+	if err != nil {
+		t.Fatalf("/report failed: %v", err)
+	}
+	var final responsePayload
+	if err := json.Unmarshal(fake.waitCalls(t, "respond", 1)[0].Body, &final); err != nil {
+		t.Fatal(err)
+	}
+	return final.Text
+}
+
+// newReportBot returns an admin's bot writing its temporary files to the returned directory
+func newReportBot(t *testing.T) (*Bot, *fakeSlack, string) {
+	t.Helper()
+	tmp := t.TempDir()
+	t.Setenv("TMPDIR", tmp)
+	cfg := testConfig(t)
+	cfg.Admins = []string{"U0ADMIN"}
+	b, fake := newTestBot(t, cfg)
+	fake.answer("conversations.open", `{"ok":true,"channel":{"id":"D0DM"}}`)
+	fake.answer("files.getUploadURLExternal", `{"ok":true,"upload_url":"`+fake.apiURL()+`upload/F1","file_id":"F1"}`)
+	fake.answer("upload/F1", `{"ok":true}`)
+	fake.answer("files.completeUploadExternal", `{"ok":true,"files":[{"id":"F1","title":"MAVBot survey responses"}]}`)
+	return b, fake, tmp
+}
+
+func TestReportStreamsLargeUpload(t *testing.T) {
+	const responses = 2000
+	b, fake, tmp := newReportBot(t)
+	// SQLite in memory seeds the responses much faster than the file store
+	store, err := newSQLStore("sqlite3", ":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { store.Close() })
+	b.store = store
+	seedSurveyResponses(t, b, responses)
+
+	if got, want := runReport(t, b, fake, ""), "I sent you a DM with 2000 survey responses"; got != want {
+		t.Errorf("answered %q, want %q", got, want)
+	}
+
+	uploads := fake.calls("upload/F1")
+	if len(uploads) != 1 {
+		t.Fatalf("got %d uploads, want 1", len(uploads))
+	}
+	// Streamed from the file as a multipart body rather than sent as form content
+	if uploads[0].Form.Get("content") != "" || !bytes.Contains(uploads[0].Body, []byte("Content-Disposition: form-data")) {
+		t.Errorf("the report wasn't streamed from the file")
+	}
+	if got := bytes.Count(uploads[0].Body, []byte(",yes,C1,")); got != responses {
+		t.Errorf("uploaded %d responses, want %d", got, responses)
+	}
+	// The announced size is the size of the whole report
+	length, _ := strconv.Atoi(fake.calls("files.getUploadURLExternal")[0].Form.Get("length"))
+	if length < responses*40 {
+		t.Errorf("announced %d bytes, want the size of the whole report", length)
+	}
+	if got := fake.calls("files.completeUploadExternal")[0].Form.Get("channel_id"); got != "D0DM" {
+		t.Errorf("shared the report to %q, want the admin's DM", got)
+	}
+
+	if left, _ := os.ReadDir(tmp); len(left) != 0 {
+		t.Errorf("left %d temporary files behind", len(left))
+	}
+}
+
+func TestReportWithoutResponses(t *testing.T) {
+	b, fake, tmp := newReportBot(t)
+	if got, want := runReport(t, b, fake, "7"), "There are no survey responses to report"; got != want {
+		t.Errorf("answered %q, want %q", got, want)
+	}
+	if got := len(fake.calls("files.getUploadURLExternal")); got != 0 {
+		t.Errorf("uploaded an empty report")
+	}
+	if left, _ := os.ReadDir(tmp); len(left) != 0 {
+		t.Errorf("left %d temporary files behind", len(left))
+	}
+}
+
+func TestReportUploadFailureRemovesFile(t *testing.T) {
+	captureLog(t)
+	b, fake, tmp := newReportBot(t)
+	fake.answer("files.completeUploadExternal", `{"ok":false,"error":"not_in_channel"}`)
+	seedSurveyResponses(t, b, 3)
+
+	if _, err := b.uploadSurveyReport("U0ADMIN", time.Time{}, "MAVBot survey responses"); err == nil {
+		t.Errorf("the failed upload wasn't reported")
+	}
+	if left, _ := os.ReadDir(tmp); len(left) != 0 {
+		t.Errorf("left %d temporary files behind", len(left))
+	}
+}
+
+func TestReportShowsUploadProgress(t *testing.T) {
+	tests := []struct {
+		name   string
+		failed bool
+		answer string
+		want   []string
+	}{
+		{
+			name:   "uploaded",
+			answer: "I sent you a DM with 3 survey responses",
+			want:   []string{"reactions.add hourglass", "reactions.add white_check_mark", "reactions.remove hourglass"},
+		},
+		{
+			name:   "upload failed",
+			failed: true,
+			answer: "Sorry, something went wrong, please try again later",
+			want:   []string{"reactions.add hourglass", "reactions.add x", "reactions.remove hourglass"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			captureLog(t)
+			b, fake, _ := newReportBot(t)
+			if tt.failed {
+				fake.answer("files.completeUploadExternal", `{"ok":false,"error":"internal_error"}`)
+			}
+			seedSurveyResponses(t, b, 3)
+
+			if got := runReport(t, b, fake, ""); got != tt.answer {
+				t.Errorf("answered %q, want %q", got, tt.answer)
+			}
+			posts := fake.calls("chat.postMessage")
+			if len(posts) != 1 || posts[0].Form.Get("channel") != "D0DM" || posts[0].Form.Get("text") != "Uploading 3 survey responses…" {
+				t.Fatalf("posted %+v, want a note in the admin's DM", posts)
+			}
+			var got []string
+			for _, call := range fake.calls("reactions.add", "reactions.remove") {
+				if call.Form.Get("channel") != "D0DM" || call.Form.Get("timestamp") != "1712345678.000001" {
+					t.Errorf("%s reacted to %s/%s, want the note", call.Method, call.Form.Get("channel"), call.Form.Get("timestamp"))
+				}
+				got = append(got, call.Method+" "+call.Form.Get("name"))
+			}
+			if strings.Join(got, ",") != strings.Join(tt.want, ",") {
+				t.Errorf("got reactions %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestReportUsage(t *testing.T) {
+	b, _, _ := newReportBot(t)
+	for _, text := range []string{"0", "-3", "week"} {
+		resp, err := b.handleReport(slack.SlashCommand{Text: text, UserID: "U0ADMIN"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if resp.Text != usageOf("/report") {
+			t.Errorf("/report %s answered %q, want the usage", text, resp.Text)
+		}
+	}
+}
@@ -0,0 +1,110 @@
+/*
+Copyright © 2024 Pavlo Tarasiuk <pasha.tarasyuk@gmail.com>
+*/
+package cmd
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/ptarasyuk/mavbot/internal/blocks"
+	"github.com/slack-go/slack"
+)
+
+// targetedPost is a message a command posts somewhere else than where it was invoked,
+// in a channel or in one of its threads
+type targetedPost struct {
+	Channel string
+	// ThreadTS is the thread the message is posted in, empty posts it in the channel
+	ThreadTS string
+	Text     string
+	Blocks   []slack.Block
+}
+
+// target identifies the post's destination in a multiResult
+func (p targetedPost) target() string {
+	if p.ThreadTS == "" {
+		return p.Channel
+	}
+	return p.Channel + "/" + p.ThreadTS
+}
+
+// postRef renders the destination of a targeted post for people, see targetedPost.target
+func postRef(target string) string {
+	channelID, threadTS, ok := strings.Cut(target, "/")
+	if !ok {
+		return channelRef(channelID)
+	}
+	return fmt.Sprintf("thread %s in %s", threadTS, channelRef(channelID))
+}
+
+// postTargeted posts every one of posts on behalf of the invoker. A post that fails doesn't
+// stop the others, the result tells how each went.
+func (b *Bot) postTargeted(invoker string, posts []targetedPost) *multiResult {
+	result := &multiResult{}
+	for _, post := range posts {
+		msg := outboundMessage{
+			Channel: post.Channel,
+			Invoker: invoker,
+			Text:    post.Text,
+			Blocks:  post.Blocks,
+		}
+		if post.ThreadTS != "" {
+			msg.Options = []slack.MsgOption{slack.MsgOptionTS(post.ThreadTS)}
+		}
+		_, err := b.postMessage(msg)
+		result.add(post.target(), err)
+	}
+	return result
+}
+
+// sendPosts makes the targeted posts of the response and returns the response with how they
+// went added, leaving the original alone
+func (b *Bot) sendPosts(invoker string, r *SlashResponse) *SlashResponse {
+	if r == nil || len(r.Posts) == 0 {
+		return r
+	}
+	summary := b.postTargeted(invoker, r.Posts).format("Posted to", "targets", postRef)
+	sent := *r
+	sent.Posts = nil
+	sent.Text = strings.TrimSpace(r.Text + "\n" + summary)
+	if len(r.Blocks) > 0 {
+		sent.Blocks = append(append([]slack.Block{}, r.Blocks...), blocks.Section(summary))
+	}
+	return &sent
+}
+
+// handleNotifyThreads posts the text in the thread of every message linked:
+// /notify-threads <message link> [message link...] <text>
+func (b *Bot) handleNotifyThreads(command slack.SlashCommand) (*SlashResponse, error) {
+	var posts []targetedPost
+	rest := strings.TrimSpace(command.Text)
+	for rest != "" {
+		word, remainder, _ := strings.Cut(rest, " ")
+		link, err := parsePermalink(word)
+		if err != nil {
+			break
+		}
+		posts = append(posts, targetedPost{Channel: link.Channel, ThreadTS: threadOf(link.ThreadTS, link.Timestamp)})
+		rest = strings.TrimSpace(remainder)
+	}
+	if len(posts) == 0 || rest == "" {
+		return ephemeral(usageOf("/notify-threads")), nil
+	}
+	for i := range posts {
+		posts[i].Text = rest
+	}
+	return &SlashResponse{ResponseType: slack.ResponseTypeEphemeral, Posts: posts}, nil
+}
+
+func init() {
+	registerSlashCommand(&slashCommand{
+		Name:        "/notify-threads",
+		Description: "Post a message in the threads of several messages at once",
+		Usage:       "<message link> [message link...] <text>",
+		Example:     "/notify-threads https://example.slack.com/archives/C0123456789/p1712345678123456 The fix is deployed",
+		Category:    categoryAdmin,
+		AdminOnly:   true,
+		Handler:     (*Bot).handleNotifyThreads,
+	})
+}
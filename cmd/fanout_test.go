@@ -0,0 +1,159 @@
+/*
+Copyright © 2024 Pavlo Tarasiuk <pasha.tarasyuk@gmail.com>
+*/
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/slack-go/slack"
+)
+
+func TestPostRef(t *testing.T) {
+	tests := []struct {
+		post targetedPost
+		want string
+	}{
+		{post: targetedPost{Channel: "C1"}, want: "<#C1>"},
+		{post: targetedPost{Channel: "C1", ThreadTS: "1712345678.000100"}, want: "thread 1712345678.000100 in <#C1>"},
+	}
+	for _, tt := range tests {
+		if got := postRef(tt.post.target()); got != tt.want {
+			t.Errorf("postRef(%q) = %q, want %q", tt.post.target(), got, tt.want)
+		}
+	}
+}
+
+// failPostsTo makes the fake Slack refuse posts to the channel with the error
+func failPostsTo(fake *fakeSlack, channel, slackErr string) {
+	fake.handle("chat.postMessage", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if r.FormValue("channel") == channel {
+			fmt.Fprintf(w, `{"ok":false,"error":%q}`, slackErr)
+			return
+		}
+		fmt.Fprintf(w, `{"ok":true,"channel":%q,"ts":"1712345999.000100"}`, r.FormValue("channel"))
+	})
+}
+
+func TestPostTargetedAggregatesResults(t *testing.T) {
+	captureLog(t)
+	b, fake := newTestBot(t, nil)
+	failPostsTo(fake, "C2", "channel_not_found")
+
+	result := b.postTargeted("U1", []targetedPost{
+		{Channel: "C1", ThreadTS: "1712345678.000100", Text: "Fixed"},
+		{Channel: "C2", ThreadTS: "1712345678.000200", Text: "Fixed"},
+		{Channel: "C3", Text: "Fixed"},
+	})
+	calls := fake.calls("chat.postMessage")
+	if len(calls) != 3 {
+		t.Fatalf("attempted %d posts, want every one despite the failure", len(calls))
+	}
+	for i, want := range []struct{ channel, thread string }{{"C1", "1712345678.000100"}, {"C2", "1712345678.000200"}, {"C3", ""}} {
+		if got := calls[i].Form; got.Get("channel") != want.channel || got.Get("thread_ts") != want.thread || got.Get("text") != "Fixed" {
+			t.Errorf("post %d went to %s/%s, want %s/%s", i+1, got.Get("channel"), got.Get("thread_ts"), want.channel, want.thread)
+		}
+	}
+	if got := result.failed(); got != 1 {
+		t.Errorf("%d posts failed, want 1", got)
+	}
+	summary := result.format("Posted to", "targets", postRef)
+	for _, line := range []string{
+		"Posted to 2 of 3 targets",
+		"✓ thread 1712345678.000100 in <#C1>",
+		"✗ thread 1712345678.000200 in <#C2>: ",
+		"✓ <#C3>",
+	} {
+		if !strings.Contains(summary, line) {
+			t.Errorf("summary = %q, want it to contain %q", summary, line)
+		}
+	}
+	if !strings.Contains(summary, "channel_not_found") {
+		t.Errorf("summary = %q, want the reason of the failure", summary)
+	}
+}
+
+func TestSendPostsKeepsTheResponse(t *testing.T) {
+	b, _ := newTestBot(t, nil)
+	if got := b.sendPosts("U1", nil); got != nil {
+		t.Errorf("sendPosts(nil) = %+v", got)
+	}
+	plain := ephemeral("Nothing to post")
+	if got := b.sendPosts("U1", plain); got != plain {
+		t.Errorf("a response without posts was changed")
+	}
+
+	original := &SlashResponse{
+		ResponseType: slack.ResponseTypeEphemeral,
+		Text:         "Notified",
+		Blocks:       []slack.Block{slack.NewDividerBlock()},
+		Posts:        []targetedPost{{Channel: "C1", Text: "Fixed"}},
+	}
+	sent := b.sendPosts("U1", original)
+	if sent.Text != "Notified\nPosted to 1 of 1 targets\n✓ <#C1>" || len(sent.Posts) != 0 {
+		t.Errorf("sent response = %q with %d posts, want the summary added and the posts made", sent.Text, len(sent.Posts))
+	}
+	if len(sent.Blocks) != 2 || len(original.Blocks) != 1 || len(original.Posts) != 1 {
+		t.Errorf("blocks = %d, original = %d blocks and %d posts, want the summary appended to a copy", len(sent.Blocks), len(original.Blocks), len(original.Posts))
+	}
+}
+
+func TestNotifyThreads(t *testing.T) {
+	captureLog(t)
+	cfg := testConfig(t)
+	cfg.Admins = []string{"U0ADMIN"}
+	b, fake := newTestBot(t, cfg)
+	failPostsTo(fake, "C2", "is_archived")
+
+	text := "https://mav.slack.com/archives/C1/p1712345678000100 " +
+		"<https://mav.slack.com/archives/C2/p1712345678000300?thread_ts=1712345678.000200&cid=C2> " +
+		"The fix is deployed"
+	payload, err := b.handleSlashCommand(slack.SlashCommand{Command: "/notify-threads", Text: text, UserID: "U0ADMIN", ChannelID: "C0"})
+	if err != nil {
+		t.Fatalf("/notify-threads failed: %v", err)
+	}
+	raw, _ := json.Marshal(payload)
+	var answer slack.Msg
+	if err := json.Unmarshal(raw, &answer); err != nil {
+		t.Fatalf("invalid answer %s", raw)
+	}
+
+	calls := fake.calls("chat.postMessage")
+	if len(calls) != 2 {
+		t.Fatalf("attempted %d posts, want one per thread", len(calls))
+	}
+	for i, thread := range []string{"1712345678.000100", "1712345678.000200"} {
+		if got := calls[i].Form; got.Get("thread_ts") != thread || got.Get("text") != "The fix is deployed" {
+			t.Errorf("post %d = %q in thread %s, want the text in thread %s", i+1, got.Get("text"), got.Get("thread_ts"), thread)
+		}
+	}
+	if answer.ResponseType != slack.ResponseTypeEphemeral {
+		t.Errorf("answered in the channel, want the outcome privately")
+	}
+	if !strings.HasPrefix(answer.Text, "Posted to 1 of 2 targets\n✓ thread 1712345678.000100 in <#C1>\n✗ thread 1712345678.000200 in <#C2>: ") {
+		t.Errorf("answered %q, want how each post went", answer.Text)
+	}
+}
+
+func TestNotifyThreadsUsage(t *testing.T) {
+	b, fake := newTestBot(t, nil)
+	for _, text := range []string{
+		"",
+		"The fix is deployed",
+		"https://mav.slack.com/archives/C1/p1712345678000100",
+		"https://example.com/archives/C1/p1712345678000100 The fix is deployed",
+	} {
+		resp, err := b.handleNotifyThreads(slack.SlashCommand{Text: text, UserID: "U0ADMIN"})
+		if err != nil || resp.Text != usageOf("/notify-threads") || len(resp.Posts) != 0 {
+			t.Errorf("/notify-threads %s = %+v, %v, want the usage", text, resp, err)
+		}
+	}
+	if got := len(fake.calls("chat.postMessage")); got != 0 {
+		t.Errorf("posted %d messages without a valid command", got)
+	}
+}
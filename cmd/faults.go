@@ -0,0 +1,153 @@
+/*
+Copyright © 2024 Pavlo Tarasiuk <pasha.tarasyuk@gmail.com>
+*/
+package cmd
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"path"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/slack-go/slack"
+)
+
+// defaultSimulatedRetryAfter is the wait /simulate-ratelimit asks for without an argument
+const defaultSimulatedRetryAfter = time.Second
+
+// simulationGrace is how much longer than the wait it asked for /simulate-ratelimit waits for the retry
+const simulationGrace = 10 * time.Second
+
+// faultInjector makes Web API requests fail on purpose, to see how the bot copes
+type faultInjector struct {
+	mu sync.Mutex
+	// rateLimits is how many of the next requests are answered with a rate limit
+	rateLimits int
+	retryAfter time.Duration
+}
+
+// injectRateLimit answers the next request with Slack's rate limit, asking to retry after retryAfter
+func (f *faultInjector) injectRateLimit(retryAfter time.Duration) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.rateLimits++
+	f.retryAfter = retryAfter
+}
+
+// pending returns the number of injected faults still to come
+func (f *faultInjector) pending() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.rateLimits
+}
+
+// nextRateLimit spends an injected rate limit, reporting false when there is none
+func (f *faultInjector) nextRateLimit() (time.Duration, bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.rateLimits == 0 {
+		return 0, false
+	}
+	f.rateLimits--
+	return f.retryAfter, true
+}
+
+// faultTransport answers requests with the faults its injector holds, instead of sending them
+type faultTransport struct {
+	next     http.RoundTripper
+	injector *faultInjector
+}
+
+// RoundTrip implements http.RoundTripper
+func (t faultTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	retryAfter, ok := t.injector.nextRateLimit()
+	if !ok {
+		return t.next.RoundTrip(req)
+	}
+	if req.Body != nil {
+		req.Body.Close()
+	}
+	log.Printf("Injected a rate limit into Slack API %s\n", path.Base(req.URL.Path))
+	return &http.Response{
+		Status:     "429 Too Many Requests",
+		StatusCode: http.StatusTooManyRequests,
+		Header: http.Header{
+			"Content-Type": {"application/json"},
+			"Retry-After":  {strconv.Itoa(int(retryAfter.Round(time.Second) / time.Second))},
+		},
+		Body:    io.NopCloser(bytes.NewReader([]byte(`{"ok":false,"error":"ratelimited"}`))),
+		Request: req,
+	}, nil
+}
+
+// handleSimulateRateLimit has Slack's rate limit hit the next post, a test message to the invoking
+// user, and reports how the bot coped: /simulate-ratelimit [seconds]
+func (b *Bot) handleSimulateRateLimit(command slack.SlashCommand) (*SlashResponse, error) {
+	if b.faults == nil {
+		return ephemeral("Faults can't be injected into this bot's Slack client"), nil
+	}
+	retryAfter := defaultSimulatedRetryAfter
+	if arg := strings.TrimSpace(command.Text); arg != "" {
+		seconds, err := strconv.Atoi(arg)
+		if err != nil || seconds < 1 {
+			return ephemeral(usageOf("/simulate-ratelimit")), nil
+		}
+		retryAfter = time.Duration(seconds) * time.Second
+	}
+
+	return b.respondLater(command, "Simulating a rate limit…", func() (*SlashResponse, error) {
+		b.faults.injectRateLimit(retryAfter)
+		start := time.Now()
+		delivered := make(chan error, 1)
+		_, err := b.postMessage(outboundMessage{
+			Channel:     command.ChannelID,
+			Invoker:     command.UserID,
+			EphemeralTo: command.UserID,
+			Text:        "MAVBot rate limit simulation message",
+			Delivered:   delivered,
+		})
+
+		report := fmt.Sprintf("Injected a rate limit asking to retry after %s.\n", retryAfter)
+		if left := b.faults.pending(); left > 0 {
+			// The post never reached Slack, e.g. it was dropped by the bot's own limits
+			b.faults.nextRateLimit()
+			return ephemeral(report + "The test message wasn't sent, nothing hit the rate limit."), nil
+		}
+		// The retry waits in the outbound queue
+		if err == nil {
+			select {
+			case err = <-delivered:
+			case <-time.After(retryAfter + simulationGrace):
+				err = errors.New("the retry wasn't posted in time")
+			}
+		}
+		took := time.Since(start).Round(10 * time.Millisecond)
+		if err != nil {
+			report += fmt.Sprintf(":x: The test message failed after %s: %v\n", took, err)
+			if retryAfter > b.cfg.RateLimitRetryWait {
+				report += fmt.Sprintf("Waits over %s aren't retried, see MAVBOT_RATE_LIMIT_RETRY_WAIT.", b.cfg.RateLimitRetryWait)
+			}
+			return ephemeral(report), nil
+		}
+		return ephemeral(report + fmt.Sprintf(":white_check_mark: The test message was retried and posted after %s.", took)), nil
+	}), nil
+}
+
+func init() {
+	registerSlashCommand(&slashCommand{
+		Name:        "/simulate-ratelimit",
+		Description: "Have Slack's rate limit hit a test message and report how the bot copes",
+		Usage:       "[seconds]",
+		Category:    categoryAdmin,
+		AdminOnly:   true,
+		DebugOnly:   true,
+		Handler:     (*Bot).handleSimulateRateLimit,
+	})
+}
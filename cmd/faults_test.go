@@ -0,0 +1,166 @@
+/*
+Copyright © 2024 Pavlo Tarasiuk <pasha.tarasyuk@gmail.com>
+*/
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/slack-go/slack"
+)
+
+func TestFaultTransport(t *testing.T) {
+	captureLog(t)
+	fake := newFakeSlack(t)
+	injector := &faultInjector{}
+	client := &http.Client{Transport: faultTransport{next: http.DefaultTransport, injector: injector}}
+	injector.injectRateLimit(2 * time.Second)
+	if got := injector.pending(); got != 1 {
+		t.Errorf("pending() = %d, want the injected rate limit", got)
+	}
+
+	resp, err := client.Post(fake.apiURL()+"chat.postMessage", "application/x-www-form-urlencoded", strings.NewReader("channel=C1"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusTooManyRequests || resp.Header.Get("Retry-After") != "2" {
+		t.Errorf("answered %d retrying after %q, want 429 after 2", resp.StatusCode, resp.Header.Get("Retry-After"))
+	}
+	if got := len(fake.calls("chat.postMessage")); got != 0 {
+		t.Errorf("the rate-limited request reached Slack")
+	}
+
+	// The fault is spent, the next request goes through
+	resp, err = client.Post(fake.apiURL()+"chat.postMessage", "application/x-www-form-urlencoded", strings.NewReader("channel=C1"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK || len(fake.calls("chat.postMessage")) != 1 {
+		t.Errorf("answered %d, want the request sent to Slack", resp.StatusCode)
+	}
+}
+
+// newFaultyBot creates a bot whose Slack client goes through a fault injector, posting queued
+// messages until the test ends
+func newFaultyBot(t *testing.T, cfg *Config) (*Bot, *fakeSlack) {
+	t.Helper()
+	b, fake := newTestBot(t, cfg)
+	b.faults = &faultInjector{}
+	client := &http.Client{Transport: faultTransport{next: http.DefaultTransport, injector: b.faults}}
+	b.client.set(slack.New(cfg.BotToken, slack.OptionAPIURL(fake.apiURL()), slack.OptionHTTPClient(client)), cfg.BotToken)
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		b.runOutbound(ctx)
+	}()
+	t.Cleanup(func() {
+		cancel()
+		<-done
+	})
+	return b, fake
+}
+
+// simulateRateLimit runs /simulate-ratelimit with the text and returns the report
+func simulateRateLimit(t *testing.T, b *Bot, fake *fakeSlack, text string) string {
+	t.Helper()
+	resp, err := b.handleSimulateRateLimit(slack.SlashCommand{Command: "/simulate-ratelimit", Text: text, UserID: "U0ADMIN", ChannelID: "C1", ResponseURL: fake.apiURL() + "respond"})
+	if err != nil {
+		t.Fatalf("/simulate-ratelimit failed: %v", err)
+	}
+	if resp.done == nil {
+		return resp.Text
+	}
+	<-resp.done
+	var report responsePayload
+	if err := json.Unmarshal(fake.waitCalls(t, "respond", 1)[0].Body, &report); err != nil {
+		t.Fatal(err)
+	}
+	return report.Text
+}
+
+func TestSimulatedRateLimitIsRetried(t *testing.T) {
+	logs := captureLog(t)
+	cfg := testConfig(t)
+	cfg.RateLimitRetryWait = 3 * time.Second
+	b, fake := newFaultyBot(t, cfg)
+
+	report := simulateRateLimit(t, b, fake, "1")
+	if !strings.HasPrefix(report, "Injected a rate limit asking to retry after 1s.\n:white_check_mark: The test message was retried and posted after 1") {
+		t.Errorf("reported %q, want the message retried after a second", report)
+	}
+	if posts := fake.calls("chat.postEphemeral"); len(posts) != 1 || posts[0].Form.Get("user") != "U0ADMIN" {
+		t.Errorf("posted %d test messages, want the retry to reach the admin", len(posts))
+	}
+	if !strings.Contains(logs.String(), "Injected a rate limit into Slack API chat.postEphemeral") ||
+		!strings.Contains(logs.String(), "Slack rate limited the post to C1, retrying in 1s") {
+		t.Errorf("logs = %q, want the injected rate limit and the retry", logs.String())
+	}
+	if b.faults.pending() != 0 {
+		t.Errorf("%d faults left behind", b.faults.pending())
+	}
+}
+
+func TestSimulatedLongRateLimitIsNotRetried(t *testing.T) {
+	captureLog(t)
+	cfg := testConfig(t)
+	cfg.RateLimitRetryWait = 3 * time.Second
+	b, fake := newFaultyBot(t, cfg)
+
+	report := simulateRateLimit(t, b, fake, "5")
+	if !strings.Contains(report, ":x: The test message failed after") || !strings.HasSuffix(report, "Waits over 3s aren't retried, see MAVBOT_RATE_LIMIT_RETRY_WAIT.") {
+		t.Errorf("reported %q, want the post failed without a retry", report)
+	}
+	if got := len(fake.calls("chat.postEphemeral")); got != 0 {
+		t.Errorf("posted %d test messages, want none", got)
+	}
+}
+
+func TestSimulateRateLimitRefusals(t *testing.T) {
+	cfg := testConfig(t)
+	b, fake := newTestBot(t, cfg)
+	if got := simulateRateLimit(t, b, fake, ""); got != "Faults can't be injected into this bot's Slack client" {
+		t.Errorf("answered %q without an injector", got)
+	}
+	b.faults = &faultInjector{}
+	for _, text := range []string{"0", "-1", "soon"} {
+		if got := simulateRateLimit(t, b, fake, text); got != usageOf("/simulate-ratelimit") {
+			t.Errorf("/simulate-ratelimit %s answered %q, want the usage", text, got)
+		}
+	}
+	if b.faults.pending() != 0 {
+		t.Errorf("injected a fault for an invalid command")
+	}
+}
+
+func TestSimulateRateLimitDebugOnly(t *testing.T) {
+	cfg := testConfig(t)
+	cfg.Admins = []string{"U0ADMIN"}
+	b, _ := newTestBot(t, cfg)
+	command := slack.SlashCommand{Command: "/simulate-ratelimit", UserID: "U0ADMIN", ChannelID: "C1"}
+	resp, err := b.dispatchSlashCommand(command)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(resp.Text, "I don't know /simulate-ratelimit") {
+		t.Errorf("answered %q without MAVBOT_DEBUG, want the command unknown", resp.Text)
+	}
+	if strings.Contains(b.helpText("U0ADMIN"), "/simulate-ratelimit") {
+		t.Errorf("/help lists the debug command")
+	}
+
+	b.cfg.Debug = true
+	if resp, err = b.dispatchSlashCommand(command); err != nil {
+		t.Fatal(err)
+	}
+	if resp.Text != "Faults can't be injected into this bot's Slack client" {
+		t.Errorf("answered %q with MAVBOT_DEBUG, want the command run", resp.Text)
+	}
+}
@@ -0,0 +1,181 @@
+/*
+Copyright © 2024 Pavlo Tarasiuk <pasha.tarasyuk@gmail.com>
+*/
+package cmd
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/slack-go/slack"
+)
+
+// settingFeatures is where toggled feature flags are kept in collectionSettings
+const settingFeatures = "features"
+
+// Feature flags handlers consult
+const (
+	// featureGreetings makes the bot answer mentions
+	featureGreetings = "greetings"
+	// featureLanguageDetection answers mentions in the language they are written in
+	featureLanguageDetection = "language-detection"
+	// featurePinConfirmations confirms recorded pins in thread
+	featurePinConfirmations = "pin-confirmations"
+	// featureLinkQR lets /link post QR codes
+	featureLinkQR = "link-qr"
+)
+
+// defaultFeatures returns the flags with their configured state, MAVBOT_FEATURES overriding the dedicated settings
+func defaultFeatures(cfg *Config) map[string]bool {
+	flags := map[string]bool{
+		featureGreetings:         true,
+		featureLanguageDetection: cfg.DetectLanguage,
+		featurePinConfirmations:  cfg.PinConfirmations,
+		featureLinkQR:            false,
+	}
+	for name, on := range cfg.Features {
+		flags[name] = on
+	}
+	return flags
+}
+
+// parseFeatures parses feature flags written as greetings=true,pin-confirmations=false
+func parseFeatures(items []string) (map[string]bool, error) {
+	flags := make(map[string]bool, len(items))
+	for _, item := range items {
+		name, value, ok := strings.Cut(item, "=")
+		on, err := strconv.ParseBool(strings.TrimSpace(value))
+		if !ok || err != nil {
+			return nil, fmt.Errorf("invalid feature flag %q, expected NAME=true|false", item)
+		}
+		flags[strings.TrimSpace(name)] = on
+	}
+	return flags, nil
+}
+
+// featureFlags switch bot features on and off at runtime
+type featureFlags struct {
+	mu    sync.RWMutex
+	flags map[string]bool
+}
+
+// newFeatureFlags creates the flags in the given state
+func newFeatureFlags(flags map[string]bool) *featureFlags {
+	f := &featureFlags{flags: make(map[string]bool, len(flags))}
+	for name, on := range flags {
+		f.flags[name] = on
+	}
+	return f
+}
+
+// enabled reports whether the feature is on, unknown features are off
+func (f *featureFlags) enabled(name string) bool {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+	return f.flags[name]
+}
+
+// known reports whether there is a flag for the feature
+func (f *featureFlags) known(name string) bool {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+	_, ok := f.flags[name]
+	return ok
+}
+
+// set switches the feature on or off
+func (f *featureFlags) set(name string, on bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.flags[name] = on
+}
+
+// snapshot returns a copy of every flag
+func (f *featureFlags) snapshot() map[string]bool {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+	flags := make(map[string]bool, len(f.flags))
+	for name, on := range f.flags {
+		flags[name] = on
+	}
+	return flags
+}
+
+// loadFeatureFlags builds the flags from their defaults with the toggles stored at runtime applied
+func loadFeatureFlags(store Store, defaults map[string]bool) (*featureFlags, error) {
+	flags := newFeatureFlags(defaults)
+	var stored map[string]bool
+	if _, err := store.Get(collectionSettings, settingFeatures, &stored); err != nil {
+		return nil, fmt.Errorf("failed to load the feature flags: %w", err)
+	}
+	for name, on := range stored {
+		flags.set(name, on)
+	}
+	return flags, nil
+}
+
+// saveFeatureFlags persists the flags so runtime toggles survive restarts
+func (b *Bot) saveFeatureFlags() error {
+	if err := b.store.Put(collectionSettings, settingFeatures, b.features.snapshot()); err != nil {
+		return fmt.Errorf("failed to save the feature flags: %w", err)
+	}
+	return nil
+}
+
+// handleFeature lists or toggles feature flags: /feature list | /feature on|off <name>
+func (b *Bot) handleFeature(command slack.SlashCommand) (*SlashResponse, error) {
+	usage := usageOf("/feature")
+	args := strings.Fields(command.Text)
+	if len(args) == 0 {
+		return ephemeral(usage), nil
+	}
+
+	switch args[0] {
+	case "list":
+		flags := b.features.snapshot()
+		names := make([]string, 0, len(flags))
+		for name := range flags {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		var list strings.Builder
+		list.WriteString("*Feature flags*\n")
+		for _, name := range names {
+			state := "off"
+			if flags[name] {
+				state = "on"
+			}
+			fmt.Fprintf(&list, "%s: %s\n", name, state)
+		}
+		return ephemeral(list.String()), nil
+
+	case "on", "off":
+		if len(args) != 2 {
+			return ephemeral(usage), nil
+		}
+		if !b.features.known(args[1]) {
+			return ephemeral(fmt.Sprintf("Unknown feature %q, see /feature list", args[1])), nil
+		}
+		b.features.set(args[1], args[0] == "on")
+		if err := b.saveFeatureFlags(); err != nil {
+			return nil, err
+		}
+		return ephemeral(fmt.Sprintf("%s is now %s", args[1], args[0])), nil
+	}
+	return ephemeral(usage), nil
+}
+
+func init() {
+	registerSlashCommand(&slashCommand{
+		Name:        "/feature",
+		Description: "List MAVBot's feature flags or switch features on and off",
+		Usage:       "list | on <name> | off <name>",
+		Example:     "/feature off greetings",
+		Category:    categoryAdmin,
+		AdminOnly:   true,
+		Handler:     (*Bot).handleFeature,
+	})
+}
@@ -0,0 +1,120 @@
+/*
+Copyright © 2024 Pavlo Tarasiuk <pasha.tarasyuk@gmail.com>
+*/
+package cmd
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/slack-go/slack"
+	"github.com/slack-go/slack/slackevents"
+)
+
+func TestParseFeatures(t *testing.T) {
+	tests := []struct {
+		items   []string
+		want    map[string]bool
+		wantErr bool
+	}{
+		{items: []string{"greetings=false", " link-qr = true "}, want: map[string]bool{"greetings": false, "link-qr": true}},
+		{items: []string{"greetings=1"}, want: map[string]bool{"greetings": true}},
+		{items: []string{"greetings"}, wantErr: true},
+		{items: []string{"greetings=maybe"}, wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := parseFeatures(tt.items)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("parseFeatures(%q) error = %v, want error %t", tt.items, err, tt.wantErr)
+			continue
+		}
+		if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("parseFeatures(%q) = %v, want %v", tt.items, got, tt.want)
+		}
+	}
+}
+
+func TestDefaultFeatures(t *testing.T) {
+	cfg := testConfig(t)
+	cfg.PinConfirmations = true
+	cfg.Features = map[string]bool{featureGreetings: false}
+	want := map[string]bool{
+		featureGreetings:         false,
+		featureLanguageDetection: false,
+		featurePinConfirmations:  true,
+		featureLinkQR:            false,
+	}
+	if got := defaultFeatures(cfg); !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
+
+// featureCommand runs /feature with the text as an admin
+func featureCommand(t *testing.T, b *Bot, text string) string {
+	t.Helper()
+	response, err := b.handleFeature(slack.SlashCommand{Command: "/feature", Text: text, UserID: "U0ADMIN", ChannelID: "C1"})
+	if err != nil {
+		t.Fatalf("/feature %s failed: %v", text, err)
+	}
+	return response.Text
+}
+
+func TestFeatureToggle(t *testing.T) {
+	b, fake := newTestBot(t, nil)
+	fake.answer("users.info", `{"ok":true,"user":{"id":"U1","name":"pasha"}}`)
+	mention := func() int {
+		before := len(fake.calls("chat.postMessage"))
+		err := b.handleAppMentionEvent(&slackevents.AppMentionEvent{User: "U1", Channel: "C1", Text: "<@U0BOT> hello", TimeStamp: "1712345678.000100"})
+		if err != nil {
+			t.Fatalf("mention failed: %v", err)
+		}
+		return len(fake.calls("chat.postMessage")) - before
+	}
+
+	if got := mention(); got != 1 {
+		t.Fatalf("got %d replies with greetings on, want 1", got)
+	}
+	if got := featureCommand(t, b, "off greetings"); got != "greetings is now off" {
+		t.Errorf("got %q", got)
+	}
+	if got := mention(); got != 0 {
+		t.Errorf("got %d replies with greetings off, want none", got)
+	}
+	if !strings.Contains(featureCommand(t, b, "list"), "greetings: off\n") {
+		t.Errorf("the list doesn't show greetings off")
+	}
+	featureCommand(t, b, "on greetings")
+	if got := mention(); got != 1 {
+		t.Errorf("got %d replies with greetings back on, want 1", got)
+	}
+
+	for text, want := range map[string]string{
+		"on telepathy": `Unknown feature "telepathy", see /feature list`,
+		"off":          usageOf("/feature"),
+		"toggle x":     usageOf("/feature"),
+	} {
+		if got := featureCommand(t, b, text); got != want {
+			t.Errorf("/feature %s got %q, want %q", text, got, want)
+		}
+	}
+	if b.features.known("telepathy") {
+		t.Errorf("an unknown feature was added")
+	}
+}
+
+func TestFeatureTogglesSurviveRestarts(t *testing.T) {
+	cfg := testConfig(t)
+	b, _ := newTestBot(t, cfg)
+	featureCommand(t, b, "off greetings")
+	featureCommand(t, b, "on link-qr")
+
+	// Loaded on start the way the start command does
+	restarted, err := loadFeatureFlags(b.store, defaultFeatures(cfg))
+	if err != nil {
+		t.Fatalf("failed to load the flags: %v", err)
+	}
+	if restarted.enabled(featureGreetings) || !restarted.enabled(featureLinkQR) {
+		t.Errorf("the restarted bot has flags %v, want the toggles kept", restarted.snapshot())
+	}
+}
@@ -0,0 +1,107 @@
+/*
+Copyright © 2024 Pavlo Tarasiuk <pasha.tarasyuk@gmail.com>
+*/
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/slack-go/slack"
+)
+
+// surveyThreadOptions post a survey in the thread of the message at ts and refer to the message in its metadata
+func surveyThreadOptions(channelID, ts string) []slack.MsgOption {
+	return []slack.MsgOption{
+		slack.MsgOptionTS(ts),
+		slack.MsgOptionMetadata(slack.SlackMetadata{
+			EventType: surveyMetadataType,
+			EventPayload: map[string]interface{}{
+				"channel":    channelID,
+				"message_ts": ts,
+			},
+		}),
+	}
+}
+
+// askFeedbackFlags are the flags of /ask-feedback, posting the survey as a follow-up or calling it off
+var askFeedbackFlags = []commandFlag{
+	{Name: "--later", Group: "follow-up"},
+	{Name: "--cancel", Group: "follow-up"},
+}
+
+// handleAskFeedback posts the article-usefulness survey for everyone in the channel:
+// /ask-feedback [--later | --cancel] [message]. Given a message timestamp or permalink,
+// the survey is posted in its thread and carries a reference to it in its metadata.
+// --later schedules it as a follow-up after the configured delay, --cancel calls that off.
+func (b *Bot) handleAskFeedback(command slack.SlashCommand) (*SlashResponse, error) {
+	usage := usageOf("/ask-feedback")
+	flags, ref, err := parseFlags(command.Text, askFeedbackFlags)
+	if err != nil {
+		return ephemeral(fmt.Sprintf("%v\n%s", err, usage)), nil
+	}
+
+	var ts string
+	if ref != "" {
+		channel, messageTS, ok := parseMessageRef(ref)
+		if !ok {
+			return ephemeral(usage), nil
+		}
+		if channel != "" && channel != command.ChannelID {
+			return ephemeral("The message has to be in this channel"), nil
+		}
+		ts = messageTS
+	}
+
+	switch {
+	case flags["--later"]:
+		if ts == "" {
+			return ephemeral("Tell me which message's thread to follow up in"), nil
+		}
+		postAt, err := b.scheduleSurvey(command.ChannelID, ts, command.UserID)
+		if err != nil {
+			return nil, err
+		}
+		return ephemeral(fmt.Sprintf("The survey will be posted in the thread at %s", postAt.Format("15:04"))), nil
+	case flags["--cancel"]:
+		if ts == "" {
+			return ephemeral("Tell me which message's thread to cancel the follow-up in"), nil
+		}
+		cancelled, err := b.cancelFollowUp(command.ChannelID, ts)
+		if err != nil {
+			return nil, err
+		}
+		if !cancelled {
+			return ephemeral("There is no pending follow-up survey in that thread"), nil
+		}
+		return ephemeral("Follow-up survey cancelled"), nil
+	}
+
+	attachment, err := b.surveyAttachment(b.newSurvey(command.UserID, ts))
+	if err != nil {
+		return nil, err
+	}
+	msg := outboundMessage{
+		Channel:     command.ChannelID,
+		Invoker:     command.UserID,
+		Attachments: []slack.Attachment{attachment},
+	}
+	if ts != "" {
+		msg.Options = surveyThreadOptions(command.ChannelID, ts)
+	}
+	if _, err := b.postMessage(msg); err != nil {
+		return nil, err
+	}
+	return ephemeral("Survey posted"), nil
+}
+
+func init() {
+	registerSlashCommand(&slashCommand{
+		Name:        "/ask-feedback",
+		Description: "Ask the channel whether an article was helpful, in its thread when given one, now or as a follow-up",
+		Usage:       "[message timestamp or link]",
+		Flags:       askFeedbackFlags,
+		Example:     "/ask-feedback --later https://example.slack.com/archives/C123/p1712345678123456",
+		Category:    categorySurveys,
+		Handler:     (*Bot).handleAskFeedback,
+	})
+}
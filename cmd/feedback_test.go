@@ -0,0 +1,89 @@
+/*
+Copyright © 2024 Pavlo Tarasiuk <pasha.tarasyuk@gmail.com>
+*/
+package cmd
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/slack-go/slack"
+)
+
+func TestAskFeedback(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		// thread is the thread the survey is expected in, empty for the channel
+		thread string
+		// want is the response shown to the agent
+		want string
+		// posted tells whether a survey is posted
+		posted bool
+	}{
+		{name: "in the channel", text: "", want: "Survey posted", posted: true},
+		{name: "timestamp", text: "1712345678.123456", thread: "1712345678.123456", want: "Survey posted", posted: true},
+		{
+			name:   "permalink",
+			text:   "<https://example.slack.com/archives/C123/p1712345678123456>",
+			thread: "1712345678.123456",
+			want:   "Survey posted",
+			posted: true,
+		},
+		{
+			name: "permalink to another channel",
+			text: "https://example.slack.com/archives/C999/p1712345678123456",
+			want: "The message has to be in this channel",
+		},
+		{name: "not a message", text: "yesterday", want: usageOf("/ask-feedback")},
+		{name: "unknown flag", text: "--now 1712345678.123456", want: usageOf("/ask-feedback")},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b, fake := newTestBot(t, nil)
+
+			response, err := b.handleAskFeedback(slack.SlashCommand{Command: "/ask-feedback", Text: tt.text, UserID: "U1", ChannelID: "C123"})
+			if err != nil {
+				t.Fatalf("ask-feedback failed: %v", err)
+			}
+			if !strings.Contains(response.Text, tt.want) {
+				t.Errorf("got %q, want %q", response.Text, tt.want)
+			}
+
+			posts := fake.calls("chat.postMessage")
+			if !tt.posted {
+				if len(posts) != 0 {
+					t.Errorf("posted a survey for %q", tt.text)
+				}
+				return
+			}
+			if len(posts) != 1 {
+				t.Fatalf("got %d posts, want the survey", len(posts))
+			}
+			form := posts[0].Form
+			if form.Get("channel") != "C123" || form.Get("thread_ts") != tt.thread {
+				t.Errorf("posted to %s thread %q, want C123 thread %q", form.Get("channel"), form.Get("thread_ts"), tt.thread)
+			}
+			if !strings.Contains(form.Get("attachments"), surveyActionID) {
+				t.Errorf("the post has no survey: %s", form.Get("attachments"))
+			}
+
+			var metadata slack.SlackMetadata
+			if raw := form.Get("metadata"); raw != "" {
+				if err := json.Unmarshal([]byte(raw), &metadata); err != nil {
+					t.Fatalf("invalid metadata %s", raw)
+				}
+			}
+			if tt.thread == "" {
+				if metadata.EventType != "" {
+					t.Errorf("the survey in the channel refers to a message: %+v", metadata)
+				}
+				return
+			}
+			if metadata.EventType != surveyMetadataType || metadata.EventPayload["channel"] != "C123" || metadata.EventPayload["message_ts"] != tt.thread {
+				t.Errorf("the survey carries metadata %+v, want a link to the message", metadata)
+			}
+		})
+	}
+}
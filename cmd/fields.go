@@ -0,0 +1,49 @@
+/*
+Copyright © 2024 Pavlo Tarasiuk <pasha.tarasyuk@gmail.com>
+*/
+package cmd
+
+import (
+	"sort"
+
+	"github.com/slack-go/slack"
+)
+
+// Titles of the context fields every handler attaches to its replies
+const (
+	fieldDate        = "Date"
+	fieldInitializer = "Initializer"
+)
+
+// defaultFieldOrder keeps Date and Initializer leading, as they always have
+var defaultFieldOrder = []string{fieldDate, fieldInitializer}
+
+// attachmentFields turns values into attachment fields.
+// Titles listed in order come first, in that order; the rest follow sorted by title,
+// so the result never depends on map iteration.
+func attachmentFields(values map[string]string, order []string) []slack.AttachmentField {
+	fields := make([]slack.AttachmentField, 0, len(values))
+	seen := make(map[string]bool, len(order))
+
+	for _, title := range order {
+		value, ok := values[title]
+		if !ok || seen[title] {
+			continue
+		}
+		seen[title] = true
+		fields = append(fields, slack.AttachmentField{Title: title, Value: value})
+	}
+
+	rest := make([]string, 0, len(values)-len(fields))
+	for title := range values {
+		if !seen[title] {
+			rest = append(rest, title)
+		}
+	}
+	sort.Strings(rest)
+	for _, title := range rest {
+		fields = append(fields, slack.AttachmentField{Title: title, Value: values[title]})
+	}
+
+	return fields
+}
@@ -0,0 +1,75 @@
+/*
+Copyright © 2024 Pavlo Tarasiuk <pasha.tarasyuk@gmail.com>
+*/
+package cmd
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestAttachmentFieldsOrder(t *testing.T) {
+	tests := []struct {
+		name   string
+		values map[string]string
+		order  []string
+		want   []string
+	}{
+		{
+			name:   "default order",
+			values: map[string]string{fieldInitializer: "<@U1>", fieldDate: "2024-04-05"},
+			order:  defaultFieldOrder,
+			want:   []string{fieldDate, fieldInitializer},
+		},
+		{
+			name:   "configured order",
+			values: map[string]string{fieldDate: "2024-04-05", fieldInitializer: "<@U1>", "Channel": "#general"},
+			order:  []string{"Channel", fieldInitializer, fieldDate},
+			want:   []string{"Channel", fieldInitializer, fieldDate},
+		},
+		{
+			name:   "unlisted titles follow sorted",
+			values: map[string]string{"Zone": "z", fieldDate: "d", "Alpha": "a", fieldInitializer: "i", "Mid": "m"},
+			order:  defaultFieldOrder,
+			want:   []string{fieldDate, fieldInitializer, "Alpha", "Mid", "Zone"},
+		},
+		{
+			name:   "missing and repeated titles in order",
+			values: map[string]string{fieldInitializer: "i"},
+			order:  []string{fieldDate, fieldInitializer, fieldInitializer},
+			want:   []string{fieldInitializer},
+		},
+		{
+			name:   "no values",
+			values: map[string]string{},
+			order:  defaultFieldOrder,
+			want:   []string{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Map iteration order varies between runs, so a stable result has to survive many
+			for i := 0; i < 50; i++ {
+				fields := attachmentFields(tt.values, tt.order)
+				titles := make([]string, 0, len(fields))
+				for _, field := range fields {
+					titles = append(titles, field.Title)
+					if field.Value != tt.values[field.Title] {
+						t.Fatalf("field %s has value %q, want %q", field.Title, field.Value, tt.values[field.Title])
+					}
+				}
+				if !reflect.DeepEqual(titles, tt.want) {
+					t.Fatalf("got titles %v, want %v", titles, tt.want)
+				}
+			}
+		})
+	}
+}
+
+func TestFieldOrderConfig(t *testing.T) {
+	t.Setenv("MAVBOT_FIELD_ORDER", "Initializer, Date")
+	cfg := testConfig(t)
+	if want := []string{fieldInitializer, fieldDate}; !reflect.DeepEqual(cfg.FieldOrder, want) {
+		t.Errorf("got field order %v, want %v", cfg.FieldOrder, want)
+	}
+}
@@ -0,0 +1,94 @@
+/*
+Copyright © 2024 Pavlo Tarasiuk <pasha.tarasyuk@gmail.com>
+*/
+package cmd
+
+import (
+	"fmt"
+	"strings"
+)
+
+// commandFlag is a flag a slash command takes ahead of its arguments. The flags a command is
+// registered with are both what its handler parses and what its usage documents.
+type commandFlag struct {
+	// Name is the flag as typed, e.g. "--qr"
+	Name string
+	// Short is an alias of one letter, e.g. "-e", empty when there is none
+	Short string
+	// Group makes the flags sharing it exclusive, e.g. --ephemeral and --in-channel
+	Group string
+}
+
+// flagSet holds the flags given to a command by name
+type flagSet map[string]bool
+
+// parseFlags takes the flags off the start of text, returning them and the text that follows.
+// Words starting with "--" that aren't one of flags and exclusive flags given together are
+// errors, shorter dashes may start the text itself.
+func parseFlags(text string, flags []commandFlag) (flagSet, string, error) {
+	given := flagSet{}
+	groups := make(map[string]string)
+	rest := strings.TrimSpace(text)
+	for rest != "" {
+		word, remainder, _ := strings.Cut(rest, " ")
+		flag, ok := lookupFlag(flags, word)
+		if !ok {
+			if strings.HasPrefix(word, "--") {
+				return nil, "", fmt.Errorf("unknown flag %s", word)
+			}
+			break
+		}
+		if flag.Group != "" {
+			if other, taken := groups[flag.Group]; taken && other != flag.Name {
+				return nil, "", fmt.Errorf("%s and %s can't be combined", other, flag.Name)
+			}
+			groups[flag.Group] = flag.Name
+		}
+		given[flag.Name] = true
+		rest = strings.TrimSpace(remainder)
+	}
+	return given, rest, nil
+}
+
+// lookupFlag finds the flag typed as word, by name or short alias
+func lookupFlag(flags []commandFlag, word string) (commandFlag, bool) {
+	for _, flag := range flags {
+		if word == flag.Name || (flag.Short != "" && word == flag.Short) {
+			return flag, true
+		}
+	}
+	return commandFlag{}, false
+}
+
+// flagsUsage documents flags the way usage strings do, each group of exclusive flags as one
+// optional choice: [--qr] [--ephemeral | --in-channel]
+func flagsUsage(flags []commandFlag) string {
+	var parts []string
+	grouped := make(map[string]int)
+	for _, flag := range flags {
+		if i, ok := grouped[flag.Group]; ok && flag.Group != "" {
+			parts[i] += " | " + flag.Name
+			continue
+		}
+		grouped[flag.Group] = len(parts)
+		parts = append(parts, flag.Name)
+	}
+	for i := range parts {
+		parts[i] = "[" + parts[i] + "]"
+	}
+	return strings.Join(parts, " ")
+}
+
+// usage documents the syntax of the command after its name, its flags followed by its arguments
+func (c *slashCommand) usage() string {
+	return strings.TrimSpace(flagsUsage(c.Flags) + " " + c.Usage)
+}
+
+// usageOf is the usage message of the registered command, e.g. "Usage: /link [--qr] <url> <title>"
+func usageOf(name string) string {
+	command, ok := slashCommands[name]
+	if !ok {
+		return "Usage: " + name
+	}
+	return strings.TrimSpace("Usage: " + name + " " + command.usage())
+}
@@ -0,0 +1,137 @@
+/*
+Copyright © 2024 Pavlo Tarasiuk <pasha.tarasyuk@gmail.com>
+*/
+package cmd
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/slack-go/slack"
+)
+
+// testFlags are flags like the ones commands declare, a plain one and an exclusive group
+var testFlags = []commandFlag{
+	{Name: "--qr", Short: "-q"},
+	{Name: "--ephemeral", Short: "-e", Group: "visibility"},
+	{Name: "--in-channel", Group: "visibility"},
+}
+
+func TestParseFlags(t *testing.T) {
+	tests := []struct {
+		text    string
+		flags   []string
+		rest    string
+		wantErr bool
+	}{
+		{text: "", rest: ""},
+		{text: "https://example.com Docs", rest: "https://example.com Docs"},
+		{text: "--qr https://example.com Docs", flags: []string{"--qr"}, rest: "https://example.com Docs"},
+		{text: "  -q  --ephemeral  hi ", flags: []string{"--qr", "--ephemeral"}, rest: "hi"},
+		{text: "-e -e hi", flags: []string{"--ephemeral"}, rest: "hi"},
+		{text: "hi --qr", rest: "hi --qr"},
+		{text: "-5 degrees", rest: "-5 degrees"},
+		{text: "--qr", flags: []string{"--qr"}, rest: ""},
+		{text: "--ephemeral --in-channel hi", wantErr: true},
+		{text: "--now hi", wantErr: true},
+	}
+	for _, tt := range tests {
+		given, rest, err := parseFlags(tt.text, testFlags)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("parseFlags(%q) error = %v, want error %t", tt.text, err, tt.wantErr)
+			continue
+		}
+		if tt.wantErr {
+			continue
+		}
+		// Given flags are listed in the order testFlags declares them
+		var names []string
+		for _, flag := range testFlags {
+			if given[flag.Name] {
+				names = append(names, flag.Name)
+			}
+		}
+		if strings.Join(names, " ") != strings.Join(tt.flags, " ") || rest != tt.rest {
+			t.Errorf("parseFlags(%q) = %v, %q, want %v, %q", tt.text, names, rest, tt.flags, tt.rest)
+		}
+	}
+}
+
+func TestFlagsUsage(t *testing.T) {
+	tests := []struct {
+		flags []commandFlag
+		want  string
+	}{
+		{flags: nil, want: ""},
+		{flags: testFlags[:1], want: "[--qr]"},
+		{flags: testFlags, want: "[--qr] [--ephemeral | --in-channel]"},
+		{flags: []commandFlag{{Name: "--a", Group: "x"}, {Name: "--b"}, {Name: "--c", Group: "x"}}, want: "[--a | --c] [--b]"},
+	}
+	for _, tt := range tests {
+		if got := flagsUsage(tt.flags); got != tt.want {
+			t.Errorf("flagsUsage(%v) = %q, want %q", tt.flags, got, tt.want)
+		}
+	}
+}
+
+func TestUsageOf(t *testing.T) {
+	registerTestCommand(t, &slashCommand{
+		Name:  "/test-usage",
+		Usage: "<url> <title>",
+		Flags: []commandFlag{{Name: "--qr"}, {Name: "--ephemeral", Group: "visibility"}, {Name: "--in-channel", Group: "visibility"}},
+	})
+	registerTestCommand(t, &slashCommand{Name: "/test-bare"})
+	tests := []struct {
+		name string
+		want string
+	}{
+		{"/test-usage", "Usage: /test-usage [--qr] [--ephemeral | --in-channel] <url> <title>"},
+		{"/test-bare", "Usage: /test-bare"},
+		{"/test-unregistered", "Usage: /test-unregistered"},
+	}
+	for _, tt := range tests {
+		if got := usageOf(tt.name); got != tt.want {
+			t.Errorf("usageOf(%q) = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestRegisteredUsageMatchesFlags(t *testing.T) {
+	for _, name := range registeredNames() {
+		command := slashCommands[name]
+		// Flags are declared, never written into the arguments' usage by hand
+		if strings.Contains(command.Usage, "--") {
+			t.Errorf("%s documents flags in its Usage %q, declare them in Flags", name, command.Usage)
+		}
+		usage := usageOf(name)
+		for _, flag := range command.Flags {
+			if !strings.Contains(usage, flag.Name) {
+				t.Errorf("%s usage %q doesn't document %s", name, usage, flag.Name)
+			}
+			if _, _, err := parseFlags(flag.Name, command.Flags); err != nil {
+				t.Errorf("%s doesn't parse its documented %s: %v", name, flag.Name, err)
+			}
+		}
+	}
+}
+
+func TestUsageAnswersMatchTheRegistry(t *testing.T) {
+	b, _ := newTestBot(t, nil)
+	tests := []struct {
+		command string
+		text    string
+		want    string
+	}{
+		{command: "/link", text: "--unknown https://example.com Docs", want: "Usage: /link [--qr] <url> <title>"},
+		{command: "/link", text: "--qr https://example.com", want: "Usage: /link [--qr] <url> <title>"},
+	}
+	for _, tt := range tests {
+		resp, err := b.dispatchSlashCommand(slack.SlashCommand{Command: tt.command, Text: tt.text, UserID: "U1", ChannelID: "C1"})
+		if err != nil {
+			t.Fatalf("%s %s failed: %v", tt.command, tt.text, err)
+		}
+		if resp.Text != tt.want || resp.Text != usageOf(tt.command) {
+			t.Errorf("%s %s answered %q, want %q", tt.command, tt.text, resp.Text, tt.want)
+		}
+	}
+}
@@ -0,0 +1,110 @@
+/*
+Copyright © 2024 Pavlo Tarasiuk <pasha.tarasyuk@gmail.com>
+*/
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/slack-go/slack"
+)
+
+// collectionFollowUps holds the surveys scheduled as follow-ups, keyed by channel and thread
+const collectionFollowUps = "followups"
+
+// followUp is an article-usefulness survey scheduled to appear in a thread
+type followUp struct {
+	Channel     string    `json:"channel"`
+	ThreadTS    string    `json:"thread_ts"`
+	ScheduledID string    `json:"scheduled_id"`
+	PostAt      time.Time `json:"post_at"`
+	// ScheduledAt is when the survey was scheduled
+	ScheduledAt time.Time `json:"scheduled_at,omitempty"`
+}
+
+// followUpKey identifies the follow-up of a thread in the Store
+func followUpKey(channelID, threadTS string) string {
+	return channelID + "/" + threadTS
+}
+
+// scheduleSurvey schedules the survey in the thread once the configured delay has passed,
+// replacing a follow-up already scheduled there. It returns when the survey will appear.
+func (b *Bot) scheduleSurvey(channelID, threadTS, invoker string) (time.Time, error) {
+	if _, err := b.cancelFollowUp(channelID, threadTS); err != nil {
+		return time.Time{}, err
+	}
+	attachment, err := b.surveyAttachment(b.newSurvey(invoker, threadTS))
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	postAt := b.now().Add(b.cfg.SurveyDelay)
+	msg := outboundMessage{
+		Channel:     channelID,
+		Invoker:     invoker,
+		Attachments: []slack.Attachment{attachment},
+		Options:     surveyThreadOptions(channelID, threadTS),
+		PostAt:      postAt,
+	}
+	// A capturing bot schedules nothing, so there is nothing to track
+	if b.captured != nil {
+		_, err := b.postMessage(msg)
+		return postAt, err
+	}
+	// Scheduled right away rather than through postMessage, which may queue or hold the message,
+	// so the scheduled message's ID is always known and the follow-up can be called off
+	b.usage.recordCall()
+	id, err := b.scheduleMessage(channelID, postAt, b.postOptions(msg))
+	if err != nil {
+		return time.Time{}, err
+	}
+	scheduled := followUp{Channel: channelID, ThreadTS: threadTS, ScheduledID: id, PostAt: postAt, ScheduledAt: b.now()}
+	if err := b.store.Put(collectionFollowUps, followUpKey(channelID, threadTS), scheduled); err != nil {
+		return time.Time{}, err
+	}
+	return postAt, nil
+}
+
+// cancelFollowUp removes the survey scheduled in the thread and reports whether one was still pending
+func (b *Bot) cancelFollowUp(channelID, threadTS string) (bool, error) {
+	key := followUpKey(channelID, threadTS)
+	var scheduled followUp
+	found, err := b.store.Get(collectionFollowUps, key, &scheduled)
+	if err != nil || !found {
+		return false, err
+	}
+
+	pending := true
+	if !scheduled.PostAt.After(b.now()) {
+		// Already posted, only the record is left to clean up
+		pending = false
+	} else {
+		_, err := b.api().DeleteScheduledMessage(&slack.DeleteScheduledMessageParameters{
+			Channel:            channelID,
+			ScheduledMessageID: scheduled.ScheduledID,
+		})
+		var slackErr slack.SlackErrorResponse
+		if errors.As(err, &slackErr) && slackErr.Err == "invalid_scheduled_message_id" {
+			pending = false
+		} else if err != nil {
+			return false, fmt.Errorf("failed to cancel follow-up survey: %w", err)
+		}
+	}
+	return pending, b.store.Delete(collectionFollowUps, key)
+}
+
+// reopenThread is called when the bot is asked for help in a thread again, the issue
+// isn't settled after all, so the survey scheduled there is called off
+func (b *Bot) reopenThread(channelID, threadTS string) {
+	cancelled, err := b.cancelFollowUp(channelID, threadTS)
+	if err != nil {
+		log.Println(err)
+		return
+	}
+	if cancelled {
+		log.Printf("Cancelled the follow-up survey in %s as the thread was reopened\n", followUpKey(channelID, threadTS))
+	}
+}
@@ -0,0 +1,212 @@
+/*
+Copyright © 2024 Pavlo Tarasiuk <pasha.tarasyuk@gmail.com>
+*/
+package cmd
+
+import (
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/slack-go/slack"
+	"github.com/slack-go/slack/slackevents"
+)
+
+// newFollowUpBot creates a bot on the fake clock whose surveys follow up after 10 minutes,
+// the fake Slack finding every scheduled message as Q1
+func newFollowUpBot(t *testing.T) (*Bot, *fakeSlack, *fakeClock) {
+	cfg := testConfig(t)
+	cfg.SurveyDelay = 10 * time.Minute
+	b, fake := newTestBot(t, cfg)
+	clock := newFakeClock()
+	b.now = clock.now
+	fake.answer("chat.scheduleMessage", `{"ok":true,"channel":"C1","scheduled_message_id":"Q1"}`)
+	fake.answer("chat.scheduledMessages.list", `{"ok":true,"scheduled_messages":[{"id":"Q0","channel_id":"C1","date_created":1},{"id":"Q1","channel_id":"C1","date_created":2}]}`)
+	return b, fake, clock
+}
+
+// askFeedback runs /ask-feedback with the text in C1
+func askFeedback(t *testing.T, b *Bot, text string) string {
+	t.Helper()
+	response, err := b.handleAskFeedback(slack.SlashCommand{Command: "/ask-feedback", Text: text, UserID: "U1", ChannelID: "C1"})
+	if err != nil {
+		t.Fatalf("/ask-feedback %s failed: %v", text, err)
+	}
+	return response.Text
+}
+
+func TestFollowUpIsScheduledWithTheDelay(t *testing.T) {
+	b, fake, clock := newFollowUpBot(t)
+
+	if got := askFeedback(t, b, "--later 1712345678.000100"); got != "The survey will be posted in the thread at 12:10" {
+		t.Errorf("got %q", got)
+	}
+	scheduled := fake.calls("chat.scheduleMessage")
+	if len(scheduled) != 1 {
+		t.Fatalf("got %d scheduled messages, want the survey", len(scheduled))
+	}
+	form := scheduled[0].Form
+	if want := strconv.FormatInt(clock.now().Add(10*time.Minute).Unix(), 10); form.Get("post_at") != want {
+		t.Errorf("scheduled at %s, want %s", form.Get("post_at"), want)
+	}
+	if form.Get("thread_ts") != "1712345678.000100" || !strings.Contains(form.Get("attachments"), surveyActionID) {
+		t.Errorf("scheduled %v, want the survey in the thread", form)
+	}
+	if len(fake.calls("chat.postMessage")) != 0 {
+		t.Errorf("the survey was posted right away")
+	}
+
+	var stored followUp
+	if ok, err := b.store.Get(collectionFollowUps, followUpKey("C1", "1712345678.000100"), &stored); err != nil || !ok {
+		t.Fatalf("the follow-up isn't tracked: %v", err)
+	}
+	if stored.ScheduledID != "Q1" || !stored.PostAt.Equal(clock.now().Add(10*time.Minute)) {
+		t.Errorf("tracked %+v", stored)
+	}
+
+	if got := askFeedback(t, b, "--later"); got != "Tell me which message's thread to follow up in" {
+		t.Errorf("got %q for a follow-up without a thread", got)
+	}
+}
+
+func TestFollowUpCancellation(t *testing.T) {
+	tests := []struct {
+		name string
+		// cancel calls the follow-up off
+		cancel func(t *testing.T, b *Bot, clock *fakeClock)
+		// deleted tells whether the scheduled message is expected to be deleted
+		deleted bool
+	}{
+		{
+			name: "by the agent",
+			cancel: func(t *testing.T, b *Bot, clock *fakeClock) {
+				if got := askFeedback(t, b, "--cancel 1712345678.000100"); got != "Follow-up survey cancelled" {
+					t.Errorf("got %q", got)
+				}
+			},
+			deleted: true,
+		},
+		{
+			name: "when the thread reopens",
+			cancel: func(t *testing.T, b *Bot, clock *fakeClock) {
+				err := b.handleAppMentionEvent(&slackevents.AppMentionEvent{
+					User: "U2", Channel: "C1", Text: "<@U0BOT> still broken", TimeStamp: "1712345999.000100", ThreadTimeStamp: "1712345678.000100",
+				})
+				if err != nil {
+					t.Fatalf("mention failed: %v", err)
+				}
+			},
+			deleted: true,
+		},
+		{
+			name: "after it was posted",
+			cancel: func(t *testing.T, b *Bot, clock *fakeClock) {
+				clock.advance(11 * time.Minute)
+				if got := askFeedback(t, b, "--cancel 1712345678.000100"); got != "There is no pending follow-up survey in that thread" {
+					t.Errorf("got %q", got)
+				}
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b, fake, clock := newFollowUpBot(t)
+			fake.answer("users.info", `{"ok":true,"user":{"id":"U2","name":"ivan"}}`)
+			askFeedback(t, b, "--later 1712345678.000100")
+
+			tt.cancel(t, b, clock)
+
+			deletes := fake.calls("chat.deleteScheduledMessage")
+			if tt.deleted && (len(deletes) != 1 || deletes[0].Form.Get("scheduled_message_id") != "Q1" || deletes[0].Form.Get("channel") != "C1") {
+				t.Errorf("deleted %+v, want the scheduled survey", deletes)
+			}
+			if !tt.deleted && len(deletes) != 0 {
+				t.Errorf("deleted a survey that was posted already")
+			}
+			if ok, _ := b.store.Get(collectionFollowUps, followUpKey("C1", "1712345678.000100"), &followUp{}); ok {
+				t.Errorf("the follow-up is still tracked")
+			}
+		})
+	}
+}
+
+func TestFollowUpRescheduleReplacesThePendingOne(t *testing.T) {
+	b, fake, clock := newFollowUpBot(t)
+	askFeedback(t, b, "--later 1712345678.000100")
+	clock.advance(5 * time.Minute)
+	if got := askFeedback(t, b, "--later 1712345678.000100"); got != "The survey will be posted in the thread at 12:15" {
+		t.Errorf("got %q", got)
+	}
+	if deletes := fake.calls("chat.deleteScheduledMessage"); len(deletes) != 1 {
+		t.Errorf("deleted %d scheduled surveys, want the first one replaced", len(deletes))
+	}
+	if got := askFeedback(t, b, "--cancel 1712345678.000100"); got != "Follow-up survey cancelled" {
+		t.Errorf("got %q", got)
+	}
+	if got := askFeedback(t, b, "--cancel 1712345678.000100"); got != "There is no pending follow-up survey in that thread" {
+		t.Errorf("got %q cancelling twice", got)
+	}
+}
+
+func TestFollowUpAlreadyGoneOnSlack(t *testing.T) {
+	b, fake, _ := newFollowUpBot(t)
+	askFeedback(t, b, "--later 1712345678.000100")
+	fake.answer("chat.deleteScheduledMessage", `{"ok":false,"error":"invalid_scheduled_message_id"}`)
+
+	if got := askFeedback(t, b, "--cancel 1712345678.000100"); got != "There is no pending follow-up survey in that thread" {
+		t.Errorf("got %q", got)
+	}
+}
+
+func TestFollowUpIsTrackedWhenPostsWait(t *testing.T) {
+	tests := []struct {
+		name string
+		// hold makes the bot's posts wait instead of going out
+		hold func(b *Bot, clock *fakeClock)
+	}{
+		{
+			name: "outbound limit reached",
+			hold: func(b *Bot, clock *fakeClock) {
+				b.outbound = newTokenBucket(1, clock.now)
+				for b.outbound.tryTake() {
+				}
+			},
+		},
+		{name: "grid migration", hold: func(b *Bot, _ *fakeClock) { b.migration.start() }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			captureLog(t)
+			b, fake, clock := newFollowUpBot(t)
+			tt.hold(b, clock)
+			askFeedback(t, b, "--later 1712345678.000100")
+
+			var stored followUp
+			if ok, err := b.store.Get(collectionFollowUps, followUpKey("C1", "1712345678.000100"), &stored); !ok || err != nil || stored.ScheduledID != "Q1" {
+				t.Fatalf("tracked %+v, %t, %v, want the scheduled survey", stored, ok, err)
+			}
+			if got := askFeedback(t, b, "--cancel 1712345678.000100"); got != "Follow-up survey cancelled" {
+				t.Errorf("got %q", got)
+			}
+			if deletes := fake.calls("chat.deleteScheduledMessage"); len(deletes) != 1 {
+				t.Errorf("deleted %d scheduled surveys, want the survey called off", len(deletes))
+			}
+		})
+	}
+}
+
+func TestFollowUpCapturedIsNotTracked(t *testing.T) {
+	b, fake, _ := newFollowUpBot(t)
+	b.captured = &capture{}
+	askFeedback(t, b, "--later 1712345678.000100")
+	if got := len(fake.calls("chat.scheduleMessage")); got != 0 {
+		t.Errorf("a capturing bot scheduled %d surveys", got)
+	}
+	if len(b.captured.messages) != 1 {
+		t.Errorf("captured %d messages, want the survey", len(b.captured.messages))
+	}
+	if ok, _ := b.store.Get(collectionFollowUps, followUpKey("C1", "1712345678.000100"), &followUp{}); ok {
+		t.Errorf("tracked a survey that wasn't scheduled")
+	}
+}
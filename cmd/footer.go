@@ -0,0 +1,52 @@
+/*
+Copyright © 2024 Pavlo Tarasiuk <pasha.tarasyuk@gmail.com>
+*/
+package cmd
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/ptarasyuk/mavbot/internal/blocks"
+	"github.com/slack-go/slack"
+)
+
+// responseTime tells how long the bot took to respond to what it received at receivedAt
+func (b *Bot) responseTime(receivedAt time.Time) string {
+	return fmt.Sprintf("responded in %s", b.now().Sub(receivedAt).Round(time.Millisecond))
+}
+
+// withFooter adds footer to a message. A text-only message gets its text as a section first,
+// the text of a message with blocks is only shown in notifications. Blocks show above attachments,
+// so a message made of attachments only gets the footer text in the footer of the last one instead.
+// The slices passed in are left alone, they may be shared.
+func withFooter(text string, messageBlocks []slack.Block, attachments []slack.Attachment, footer string) ([]slack.Block, []slack.Attachment) {
+	if len(messageBlocks) == 0 && text == "" && len(attachments) > 0 {
+		attachments = append([]slack.Attachment{}, attachments...)
+		attachments[len(attachments)-1].Footer = footer
+		return messageBlocks, attachments
+	}
+	if len(messageBlocks) == 0 && text != "" {
+		messageBlocks = []slack.Block{blocks.Section(text)}
+	}
+	return append(append([]slack.Block{}, messageBlocks...), blocks.Context(footer)), attachments
+}
+
+// addResponseTime adds the response time footer to the message when it is enabled and the
+// message answers something received
+func (b *Bot) addResponseTime(msg *outboundMessage) {
+	if !b.cfg.ResponseTimeFooter || msg.ReceivedAt.IsZero() {
+		return
+	}
+	msg.Blocks, msg.Attachments = withFooter(msg.Text, msg.Blocks, msg.Attachments, b.responseTime(msg.ReceivedAt))
+}
+
+// addResponseTimeToResponse adds the response time footer to a command's response when it is enabled
+func (b *Bot) addResponseTimeToResponse(r *SlashResponse, receivedAt time.Time) *SlashResponse {
+	if !b.cfg.ResponseTimeFooter || r == nil {
+		return r
+	}
+	withTime := *r
+	withTime.Blocks, withTime.Attachments = withFooter(r.Text, r.Blocks, r.Attachments, b.responseTime(receivedAt))
+	return &withTime
+}
@@ -0,0 +1,140 @@
+/*
+Copyright © 2024 Pavlo Tarasiuk <pasha.tarasyuk@gmail.com>
+*/
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ptarasyuk/mavbot/internal/blocks"
+	"github.com/slack-go/slack"
+	"github.com/slack-go/slack/slackevents"
+)
+
+// blockTypes lists the types of the blocks
+func blockTypes(messageBlocks []slack.Block) string {
+	types := make([]string, len(messageBlocks))
+	for i, block := range messageBlocks {
+		types[i] = string(block.BlockType())
+	}
+	return strings.Join(types, ",")
+}
+
+func TestWithFooter(t *testing.T) {
+	header := []slack.Block{blocks.Header("News")}
+	attachments := []slack.Attachment{{Text: "first"}, {Text: "last"}}
+	tests := []struct {
+		name        string
+		text        string
+		blocks      []slack.Block
+		attachments []slack.Attachment
+		// want are the types of the blocks, lastFooter the footer of the last attachment
+		want       string
+		lastFooter string
+	}{
+		{name: "text", text: "Hello", want: "section,context"},
+		{name: "blocks", text: "News fallback", blocks: header, want: "header,context"},
+		{name: "attachments", attachments: attachments, lastFooter: "responded in 320ms"},
+		{name: "text and attachments", text: "Hello", attachments: attachments, want: "section,context"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gotBlocks, gotAttachments := withFooter(tt.text, tt.blocks, tt.attachments, "responded in 320ms")
+			if got := blockTypes(gotBlocks); got != tt.want {
+				t.Errorf("blocks = %s, want %s", got, tt.want)
+			}
+			if tt.want != "" {
+				footer := gotBlocks[len(gotBlocks)-1].(*slack.ContextBlock)
+				if text := footer.ContextElements.Elements[0].(*slack.TextBlockObject).Text; text != "responded in 320ms" {
+					t.Errorf("footer = %q", text)
+				}
+			}
+			if len(gotAttachments) != len(tt.attachments) {
+				t.Fatalf("got %d attachments, want %d", len(gotAttachments), len(tt.attachments))
+			}
+			if len(gotAttachments) > 0 && gotAttachments[len(gotAttachments)-1].Footer != tt.lastFooter {
+				t.Errorf("last attachment footer = %q, want %q", gotAttachments[len(gotAttachments)-1].Footer, tt.lastFooter)
+			}
+		})
+	}
+	if len(header) != 1 || attachments[1].Footer != "" {
+		t.Errorf("the slices passed in were changed")
+	}
+}
+
+func TestMentionReplyFooter(t *testing.T) {
+	for _, enabled := range []bool{true, false} {
+		t.Run(fmt.Sprint(enabled), func(t *testing.T) {
+			cfg := testConfig(t)
+			cfg.ResponseTimeFooter = enabled
+			b, fake := newTestBot(t, cfg)
+			clock := newFakeClock()
+			b.now = clock.now
+			// Looking the user up takes 320ms
+			fake.handle("users.info", func(w http.ResponseWriter, r *http.Request) {
+				clock.advance(320 * time.Millisecond)
+				w.Header().Set("Content-Type", "application/json")
+				fmt.Fprint(w, `{"ok":true,"user":{"id":"U1","name":"pasha"}}`)
+			})
+
+			if err := b.handleAppMentionEvent(&slackevents.AppMentionEvent{User: "U1", Channel: "C1", Text: "<@U0BOT> hello", TimeStamp: "1712345678.000100"}); err != nil {
+				t.Fatal(err)
+			}
+			posts := fake.calls("chat.postMessage")
+			if len(posts) != 1 {
+				t.Fatalf("posted %d replies, want 1", len(posts))
+			}
+			var attachments []slack.Attachment
+			if err := json.Unmarshal([]byte(posts[0].Form.Get("attachments")), &attachments); err != nil {
+				t.Fatal(err)
+			}
+			want := ""
+			if enabled {
+				want = "responded in 320ms"
+			}
+			if got := attachments[len(attachments)-1].Footer; got != want {
+				t.Errorf("footer = %q, want %q", got, want)
+			}
+		})
+	}
+}
+
+func TestCommandResponseFooter(t *testing.T) {
+	cfg := testConfig(t)
+	cfg.ResponseTimeFooter = true
+	b, _ := newTestBot(t, cfg)
+	clock := newFakeClock()
+	b.now = clock.now
+	registerTestCommand(t, &slashCommand{
+		Name: "/test-footer",
+		Handler: func(*Bot, slack.SlashCommand) (*SlashResponse, error) {
+			clock.advance(1500 * time.Millisecond)
+			return ephemeral("Report ready"), nil
+		},
+	})
+
+	payload, err := b.handleSlashCommand(slack.SlashCommand{Command: "/test-footer", UserID: "U1", ChannelID: "C1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	raw, _ := json.Marshal(payload)
+	var msg slack.Msg
+	if err := json.Unmarshal(raw, &msg); err != nil {
+		t.Fatalf("invalid payload %s", raw)
+	}
+	if got := blockTypes(msg.Blocks.BlockSet); got != "section,context" {
+		t.Fatalf("blocks = %s, want the text and the footer", got)
+	}
+	footer := msg.Blocks.BlockSet[1].(*slack.ContextBlock).ContextElements.Elements[0].(*slack.TextBlockObject)
+	if footer.Text != "responded in 1.5s" {
+		t.Errorf("footer = %q, want the handler's time", footer.Text)
+	}
+	if msg.Text != "Report ready" {
+		t.Errorf("text = %q, want it kept for notifications", msg.Text)
+	}
+}
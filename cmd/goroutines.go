@@ -0,0 +1,57 @@
+/*
+Copyright © 2024 Pavlo Tarasiuk <pasha.tarasyuk@gmail.com>
+*/
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"runtime"
+	"runtime/pprof"
+
+	"github.com/slack-go/slack"
+)
+
+// goroutineDump returns the stacks of all goroutines in the format of a panic
+func goroutineDump() ([]byte, error) {
+	var dump bytes.Buffer
+	if err := pprof.Lookup("goroutine").WriteTo(&dump, 2); err != nil {
+		return nil, fmt.Errorf("failed to dump goroutines: %w", err)
+	}
+	return dump.Bytes(), nil
+}
+
+// handleGoroutines uploads a dump of the goroutine stacks to the admin's DM, the stacks show
+// internals, so they don't go to the channel
+func (b *Bot) handleGoroutines(command slack.SlashCommand) (*SlashResponse, error) {
+	count := runtime.NumGoroutine()
+	dump, err := goroutineDump()
+	if err != nil {
+		return nil, err
+	}
+	channelID, err := b.openDM(command.UserID)
+	if err != nil {
+		return nil, err
+	}
+	_, err = b.api().UploadFileV2(slack.UploadFileV2Parameters{
+		Content:  string(dump),
+		FileSize: len(dump),
+		Filename: "mavbot-goroutines.txt",
+		Title:    fmt.Sprintf("MAVBot goroutines (%d)", count),
+		Channel:  channelID,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to upload goroutine dump: %w", err)
+	}
+	return ephemeral(fmt.Sprintf("%d goroutines are running, I sent you a DM with their stacks", count)), nil
+}
+
+func init() {
+	registerSlashCommand(&slashCommand{
+		Name:        "/goroutines",
+		Description: "Count the running goroutines and DM you their stacks",
+		Category:    categoryAdmin,
+		AdminOnly:   true,
+		Handler:     (*Bot).handleGoroutines,
+	})
+}
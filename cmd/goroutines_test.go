@@ -0,0 +1,66 @@
+/*
+Copyright © 2024 Pavlo Tarasiuk <pasha.tarasyuk@gmail.com>
+*/
+package cmd
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/slack-go/slack"
+)
+
+func TestGoroutineDump(t *testing.T) {
+	dump, err := goroutineDump()
+	if err != nil {
+		t.Fatalf("goroutineDump() error = %v", err)
+	}
+	if len(dump) == 0 || !strings.Contains(string(dump), "goroutine ") {
+		t.Fatalf("dump = %q, want goroutine stacks", dump)
+	}
+	// The stacks include the test itself
+	if !strings.Contains(string(dump), "TestGoroutineDump") {
+		t.Errorf("the dump misses the running test")
+	}
+}
+
+func TestGoroutinesUploadsDumpToDM(t *testing.T) {
+	cfg := testConfig(t)
+	cfg.Admins = []string{"U0ADMIN"}
+	b, fake := newTestBot(t, cfg)
+	fake.answer("conversations.open", `{"ok":true,"channel":{"id":"D0DM"}}`)
+	fake.answer("files.getUploadURLExternal", `{"ok":true,"upload_url":"`+fake.apiURL()+`upload/F1","file_id":"F1"}`)
+	fake.answer("upload/F1", `{"ok":true}`)
+	fake.answer("files.completeUploadExternal", `{"ok":true,"files":[{"id":"F1","title":"MAVBot goroutines"}]}`)
+
+	resp, err := b.dispatchSlashCommand(slack.SlashCommand{Command: "/goroutines", UserID: "U0ADMIN", ChannelID: "C1"})
+	if err != nil {
+		t.Fatalf("/goroutines failed: %v", err)
+	}
+	if !strings.HasSuffix(resp.Text, " goroutines are running, I sent you a DM with their stacks") {
+		t.Errorf("answered %q, want the count", resp.Text)
+	}
+	uploads := fake.calls("upload/F1")
+	if len(uploads) != 1 || !strings.Contains(uploads[0].Form.Get("content")+string(uploads[0].Body), "goroutine ") {
+		t.Fatalf("uploads = %d, want the goroutine dump", len(uploads))
+	}
+	if got := fake.calls("files.completeUploadExternal")[0].Form.Get("channel_id"); got != "D0DM" {
+		t.Errorf("shared the dump to %q, want the admin's DM", got)
+	}
+}
+
+func TestGoroutinesAdminOnly(t *testing.T) {
+	cfg := testConfig(t)
+	cfg.Admins = []string{"U0ADMIN"}
+	b, fake := newTestBot(t, cfg)
+	resp, err := b.dispatchSlashCommand(slack.SlashCommand{Command: "/goroutines", UserID: "U1", ChannelID: "C1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.Text != "Sorry, this command is available to MAVBot admins only" {
+		t.Errorf("answered %q, want the command refused", resp.Text)
+	}
+	if calls := fake.calls("files.getUploadURLExternal", "conversations.open"); len(calls) != 0 {
+		t.Errorf("the dump was sent to a non-admin")
+	}
+}
@@ -0,0 +1,138 @@
+/*
+Copyright © 2024 Pavlo Tarasiuk <pasha.tarasyuk@gmail.com>
+*/
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/slack-go/slack"
+)
+
+// usageWindow is the span /ratelimit counts outbound calls over
+const usageWindow = time.Minute
+
+// maxRateLimitEvents is how many of the latest rate limit events are kept for /ratelimit
+const maxRateLimitEvents = 20
+
+// rateLimitEvent is a message that was turned down by a rate limit, Slack's or the bot's own
+type rateLimitEvent struct {
+	At      time.Time
+	Source  string
+	Channel string
+	Wait    time.Duration
+}
+
+// rateUsage tracks the outbound calls of a sliding window and the latest rate limit events,
+// so operators can tell how close the bot is to being throttled
+type rateUsage struct {
+	mu     sync.Mutex
+	window time.Duration
+	now    func() time.Time
+	// calls are the times of the calls within the window, oldest first
+	calls []time.Time
+	// limited are the latest rate limit events, oldest first
+	limited []rateLimitEvent
+}
+
+// newRateUsage creates a rateUsage counting calls over window
+func newRateUsage(window time.Duration, now func() time.Time) *rateUsage {
+	return &rateUsage{window: window, now: now}
+}
+
+// prune drops the calls that left the window. Callers must hold u.mu.
+func (u *rateUsage) prune(now time.Time) {
+	cutoff := now.Add(-u.window)
+	i := 0
+	for i < len(u.calls) && !u.calls[i].After(cutoff) {
+		i++
+	}
+	u.calls = append(u.calls[:0], u.calls[i:]...)
+}
+
+// recordCall counts an outbound call
+func (u *rateUsage) recordCall() {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	now := u.now()
+	u.prune(now)
+	u.calls = append(u.calls, now)
+}
+
+// recordLimited remembers a message turned down by the named rate limit
+func (u *rateUsage) recordLimited(source, channelID string, wait time.Duration) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	u.limited = append(u.limited, rateLimitEvent{At: u.now(), Source: source, Channel: channelID, Wait: wait})
+	if len(u.limited) > maxRateLimitEvents {
+		u.limited = u.limited[len(u.limited)-maxRateLimitEvents:]
+	}
+}
+
+// callCount returns the number of outbound calls within the window
+func (u *rateUsage) callCount() int {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	u.prune(u.now())
+	return len(u.calls)
+}
+
+// limitedEvents returns a copy of the latest rate limit events, oldest first
+func (u *rateUsage) limitedEvents() []rateLimitEvent {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	return append([]rateLimitEvent(nil), u.limited...)
+}
+
+// recordSlackLimited remembers err when it is Slack turning a call down for the rate limit
+func (b *Bot) recordSlackLimited(channelID string, err error) {
+	var limited *slack.RateLimitedError
+	if errors.As(err, &limited) {
+		b.usage.recordLimited("slack", channelID, limited.RetryAfter)
+	}
+}
+
+// handleRateLimit reports the outbound calls of the last minute and the latest rate limit events
+func (b *Bot) handleRateLimit(command slack.SlashCommand) (*SlashResponse, error) {
+	var report strings.Builder
+	calls := b.usage.callCount()
+	fmt.Fprintf(&report, "*Outbound calls in the last %s:* %d", usageWindow, calls)
+	if b.cfg.OutboundPerMinute > 0 {
+		fmt.Fprintf(&report, " of %d allowed", b.cfg.OutboundPerMinute)
+	}
+	report.WriteString("\n")
+	if n := b.clients.size(); n > 1 {
+		fmt.Fprintf(&report, "Messages are spread across %d bot tokens\n", n)
+	}
+
+	events := b.usage.limitedEvents()
+	if len(events) == 0 {
+		report.WriteString("No rate limits hit since the start")
+		return ephemeral(report.String()), nil
+	}
+	fmt.Fprintf(&report, "*Latest rate limit events (%d)*\n", len(events))
+	tz := b.userTimezone(command.UserID)
+	for i := len(events) - 1; i >= 0; i-- {
+		event := events[i]
+		fmt.Fprintf(&report, "%s %s limit in <#%s>", b.formatTime(event.At, tz), event.Source, event.Channel)
+		if wait := formatWait(event.Wait); wait != "" {
+			fmt.Fprintf(&report, ", retry after %s", wait)
+		}
+		report.WriteString("\n")
+	}
+	return ephemeral(report.String()), nil
+}
+
+func init() {
+	registerSlashCommand(&slashCommand{
+		Name:        "/ratelimit",
+		Description: "Show the outbound calls of the last minute and the latest rate limit events",
+		Category:    categoryAdmin,
+		AdminOnly:   true,
+		Handler:     (*Bot).handleRateLimit,
+	})
+}
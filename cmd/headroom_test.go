@@ -0,0 +1,127 @@
+/*
+Copyright © 2024 Pavlo Tarasiuk <pasha.tarasyuk@gmail.com>
+*/
+package cmd
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/slack-go/slack"
+)
+
+func TestRateUsageSlidingWindow(t *testing.T) {
+	clock := newFakeClock()
+	usage := newRateUsage(time.Minute, clock.now)
+
+	usage.recordCall()
+	clock.advance(30 * time.Second)
+	usage.recordCall()
+	usage.recordCall()
+	if got := usage.callCount(); got != 3 {
+		t.Errorf("callCount() = %d, want 3", got)
+	}
+	clock.advance(30 * time.Second)
+	if got := usage.callCount(); got != 2 {
+		t.Errorf("callCount() a minute after the first call = %d, want 2", got)
+	}
+	clock.advance(time.Minute)
+	if got := usage.callCount(); got != 0 {
+		t.Errorf("callCount() after a quiet minute = %d, want 0", got)
+	}
+}
+
+func TestRateUsageKeepsLatestEvents(t *testing.T) {
+	clock := newFakeClock()
+	usage := newRateUsage(time.Minute, clock.now)
+	for i := 0; i < maxRateLimitEvents+5; i++ {
+		usage.recordLimited("slack", fmt.Sprintf("C%d", i), time.Second)
+	}
+	events := usage.limitedEvents()
+	if len(events) != maxRateLimitEvents {
+		t.Fatalf("kept %d events, want %d", len(events), maxRateLimitEvents)
+	}
+	if events[0].Channel != "C5" || events[len(events)-1].Channel != fmt.Sprintf("C%d", maxRateLimitEvents+4) {
+		t.Errorf("kept events from %s to %s, want the latest", events[0].Channel, events[len(events)-1].Channel)
+	}
+}
+
+// rateLimitReport runs /ratelimit and returns the report
+func rateLimitReport(t *testing.T, b *Bot) string {
+	t.Helper()
+	resp, err := b.handleRateLimit(slack.SlashCommand{Command: "/ratelimit", UserID: "U1", ChannelID: "C1"})
+	if err != nil {
+		t.Fatalf("handleRateLimit() error = %v", err)
+	}
+	return resp.Text
+}
+
+func TestRateLimitReportsRecordedCalls(t *testing.T) {
+	cfg := testConfig(t)
+	cfg.OutboundPerMinute = 50
+	b, _ := newTestBot(t, cfg)
+	clock := newFakeClock()
+	b.now = clock.now
+	b.usage = newRateUsage(usageWindow, clock.now)
+	b.outbound = newTokenBucket(cfg.OutboundPerMinute, clock.now)
+
+	if got := rateLimitReport(t, b); !strings.Contains(got, "last 1m0s:* 0 of 50 allowed") || !strings.Contains(got, "No rate limits hit") {
+		t.Errorf("report before posting = %q", got)
+	}
+	for i := 0; i < 3; i++ {
+		if _, err := b.postMessage(outboundMessage{Channel: "C1", Text: "hi"}); err != nil {
+			t.Fatalf("post %d failed: %v", i+1, err)
+		}
+	}
+	if got := rateLimitReport(t, b); !strings.Contains(got, "last 1m0s:* 3 of 50 allowed") {
+		t.Errorf("report after 3 posts = %q", got)
+	}
+	clock.advance(usageWindow)
+	if got := rateLimitReport(t, b); !strings.Contains(got, "last 1m0s:* 0 of 50 allowed") {
+		t.Errorf("report a minute later = %q", got)
+	}
+}
+
+func TestRateLimitReportsLimitEvents(t *testing.T) {
+	cfg := testConfig(t)
+	cfg.ChannelPerMinute = 1
+	b, fake := newTestBot(t, cfg)
+	clock := newFakeClock()
+	b.now = clock.now
+	b.usage = newRateUsage(usageWindow, clock.now)
+	b.throttle = newChannelThrottle(cfg.ChannelPerMinute, nil, clock.now)
+	fake.handle("chat.postMessage", func(w http.ResponseWriter, r *http.Request) {
+		if r.FormValue("channel") == "C2" {
+			w.Header().Set("Retry-After", "120")
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"ok":true,"channel":"C1","ts":"1712345678.000100"}`)
+	})
+
+	for i := 0; i < 2; i++ {
+		_, _ = b.postMessage(outboundMessage{Channel: "C1", Text: "hi"})
+	}
+	if _, err := b.postMessage(outboundMessage{Channel: "C2", Text: "hi"}); err == nil {
+		t.Fatalf("post Slack rate limited succeeded")
+	}
+
+	report := rateLimitReport(t, b)
+	for _, want := range []string{
+		"*Latest rate limit events (2)*",
+		"channel limit in <#C1>, retry after 1m0s",
+		"slack limit in <#C2>, retry after 2m0s",
+	} {
+		if !strings.Contains(report, want) {
+			t.Errorf("report = %q, want it to contain %q", report, want)
+		}
+	}
+	// The latest event comes first
+	if strings.Index(report, "<#C2>") > strings.Index(report, "<#C1>") {
+		t.Errorf("report = %q, want the latest event first", report)
+	}
+}
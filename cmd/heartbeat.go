@@ -0,0 +1,68 @@
+/*
+Copyright © 2024 Pavlo Tarasiuk <pasha.tarasyuk@gmail.com>
+*/
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+)
+
+// beat signals that the bot is alive, by requesting HeartbeatURL or posting to StatusChannel
+func (b *Bot) beat(ctx context.Context) error {
+	if b.cfg.HeartbeatURL == "" {
+		_, err := b.within(ctx).postMessage(outboundMessage{
+			Channel: b.cfg.StatusChannel,
+			Text:    fmt.Sprintf("MAVBot heartbeat, up %s", b.now().Sub(b.startedAt).Round(time.Second)),
+		})
+		if err != nil {
+			return fmt.Errorf("failed to post heartbeat: %w", err)
+		}
+		return nil
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, b.cfg.HeartbeatURL, nil)
+	if err != nil {
+		return fmt.Errorf("failed to build heartbeat request: %w", err)
+	}
+	resp, err := b.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to send heartbeat: %w", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("failed to send heartbeat: %s", resp.Status)
+	}
+	return nil
+}
+
+// runHeartbeat beats every HeartbeatInterval, by the bot's clock, until ctx is cancelled. Missing
+// beats are what alerts operators, so a failed one is only logged. A beat is bounded by the
+// interval so a hanging healthcheck can't pile up beats.
+func (b *Bot) runHeartbeat(ctx context.Context) {
+	interval := b.cfg.HeartbeatInterval
+	if interval <= 0 {
+		return
+	}
+	next := b.now().Add(interval)
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-b.after(next.Sub(b.now())):
+		}
+		// Beats missed while the previous one ran are skipped rather than made up for
+		for !next.After(b.now()) {
+			next = next.Add(interval)
+		}
+
+		beatCtx, cancel := context.WithTimeout(ctx, b.cfg.HeartbeatInterval)
+		if err := b.beat(beatCtx); err != nil && ctx.Err() == nil {
+			log.Println(err)
+		}
+		cancel()
+	}
+}
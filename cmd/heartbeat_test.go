@@ -0,0 +1,213 @@
+/*
+Copyright © 2024 Pavlo Tarasiuk <pasha.tarasyuk@gmail.com>
+*/
+package cmd
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// timerClock is a clock whose waits are ended by the test, which gets each of them from waits
+type timerClock struct {
+	mu    sync.Mutex
+	t     time.Time
+	waits chan clockWait
+}
+
+// clockWait is a wait for d on the timerClock, ended by sending on fire
+type clockWait struct {
+	d    time.Duration
+	fire chan time.Time
+}
+
+func newTimerClock() *timerClock {
+	return &timerClock{t: newFakeClock().now(), waits: make(chan clockWait)}
+}
+
+func (c *timerClock) now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.t
+}
+
+func (c *timerClock) set(t time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.t = t
+}
+
+func (c *timerClock) after(d time.Duration) <-chan time.Time {
+	fire := make(chan time.Time, 1)
+	c.waits <- clockWait{d: d, fire: fire}
+	return fire
+}
+
+// nextWait returns the wait the bot starts next
+func (c *timerClock) nextWait(t *testing.T) clockWait {
+	t.Helper()
+	select {
+	case w := <-c.waits:
+		return w
+	case <-time.After(2 * time.Second):
+		t.Fatalf("the heartbeat didn't wait for the next beat")
+		return clockWait{}
+	}
+}
+
+// runTestHeartbeat runs the heartbeat of the bot on the clock until the test ends
+func runTestHeartbeat(t *testing.T, b *Bot, clock *timerClock) {
+	t.Helper()
+	b.now, b.after, b.startedAt = clock.now, clock.after, clock.now()
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		b.runHeartbeat(ctx)
+	}()
+	t.Cleanup(func() {
+		cancel()
+		<-done
+	})
+}
+
+func TestHeartbeatPostsOnInterval(t *testing.T) {
+	cfg := testConfig(t)
+	cfg.HeartbeatInterval = time.Minute
+	cfg.StatusChannel = "C0STATUS"
+	b, fake := newTestBot(t, cfg)
+	clock := newTimerClock()
+	start := clock.now()
+	runTestHeartbeat(t, b, clock)
+
+	steps := []struct {
+		// wake is when the wait ends, after the start
+		wake time.Duration
+		// want is the wait started before it, and the heartbeat posted after it
+		wait time.Duration
+		post string
+	}{
+		{wake: time.Minute, wait: time.Minute, post: "MAVBot heartbeat, up 1m0s"},
+		{wake: 2 * time.Minute, wait: time.Minute, post: "MAVBot heartbeat, up 2m0s"},
+		// Woken up late, the beats missed meanwhile are skipped
+		{wake: 5*time.Minute + 30*time.Second, wait: time.Minute, post: "MAVBot heartbeat, up 5m30s"},
+		{wake: 6 * time.Minute, wait: 30 * time.Second, post: "MAVBot heartbeat, up 6m0s"},
+	}
+	for i, step := range steps {
+		w := clock.nextWait(t)
+		if w.d != step.wait {
+			t.Errorf("step %d: waited %s, want %s", i+1, w.d, step.wait)
+		}
+		if got := len(fake.calls("chat.postMessage")); got != i {
+			t.Fatalf("step %d: posted %d heartbeats before the wait ended, want %d", i+1, got, i)
+		}
+		clock.set(start.Add(step.wake))
+		w.fire <- clock.now()
+		posts := fake.waitCalls(t, "chat.postMessage", i+1)
+		if got := posts[i].Form; got.Get("channel") != "C0STATUS" || got.Get("text") != step.post {
+			t.Errorf("step %d: posted %q to %s, want %q to the status channel", i+1, got.Get("text"), got.Get("channel"), step.post)
+		}
+	}
+	clock.nextWait(t)
+}
+
+func TestHeartbeatHitsURL(t *testing.T) {
+	logs := captureLog(t)
+	var mu sync.Mutex
+	hits, status := 0, http.StatusOK
+	monitor := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		hits++
+		w.WriteHeader(status)
+	}))
+	defer monitor.Close()
+	hitCount := func() int {
+		mu.Lock()
+		defer mu.Unlock()
+		return hits
+	}
+
+	cfg := testConfig(t)
+	cfg.HeartbeatInterval = time.Minute
+	cfg.HeartbeatURL = monitor.URL + "/ping/abc"
+	b, fake := newTestBot(t, cfg)
+	clock := newTimerClock()
+	start := clock.now()
+	runTestHeartbeat(t, b, clock)
+
+	w := clock.nextWait(t)
+	for i := 1; i <= 2; i++ {
+		if i == 2 {
+			mu.Lock()
+			status = http.StatusNotFound
+			mu.Unlock()
+		}
+		clock.set(start.Add(time.Duration(i) * time.Minute))
+		w.fire <- clock.now()
+		// The next wait starts once the beat is done
+		w = clock.nextWait(t)
+		if got := hitCount(); got != i {
+			t.Errorf("beat %d: the monitor got %d hits, want %d", i, got, i)
+		}
+	}
+	if !strings.Contains(logs.String(), "failed to send heartbeat: 404 Not Found") {
+		t.Errorf("logs = %q, want the failed beat logged", logs.String())
+	}
+	if got := len(fake.calls("chat.postMessage")); got != 0 {
+		t.Errorf("posted %d heartbeats, want the URL requested instead", got)
+	}
+}
+
+func TestHeartbeatStopsOnShutdown(t *testing.T) {
+	cfg := testConfig(t)
+	cfg.HeartbeatInterval = time.Minute
+	cfg.StatusChannel = "C0STATUS"
+	b, fake := newTestBot(t, cfg)
+	clock := newTimerClock()
+	b.now, b.after = clock.now, clock.after
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		b.runHeartbeat(ctx)
+	}()
+	clock.nextWait(t)
+	cancel()
+	select {
+	case <-done:
+	case <-time.After(2 * time.Second):
+		t.Fatalf("the heartbeat kept running after the shutdown")
+	}
+	if got := len(fake.calls("chat.postMessage")); got != 0 {
+		t.Errorf("posted %d heartbeats", got)
+	}
+}
+
+func TestHeartbeatDisabled(t *testing.T) {
+	b, _ := newTestBot(t, nil)
+	b.after = func(time.Duration) <-chan time.Time {
+		t.Fatalf("the disabled heartbeat waited for a beat")
+		return nil
+	}
+	b.runHeartbeat(context.Background())
+}
+
+func TestHeartbeatConfig(t *testing.T) {
+	t.Setenv("MAVBOT_HEARTBEAT_INTERVAL", "1m")
+	t.Setenv("MAVBOT_STATUS_CHANNEL", "")
+	t.Setenv("MAVBOT_HEARTBEAT_URL", "")
+	if _, err := loadConfigWith(noSecrets{}); err == nil {
+		t.Errorf("a heartbeat with nowhere to go was accepted")
+	}
+	t.Setenv("MAVBOT_HEARTBEAT_URL", "https://hc.example.com/ping/abc")
+	if cfg, err := loadConfigWith(noSecrets{}); err != nil || cfg.HeartbeatInterval != time.Minute {
+		t.Errorf("loadConfig() = %v, %v, want the heartbeat every minute", cfg, err)
+	}
+}
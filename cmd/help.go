@@ -0,0 +1,95 @@
+/*
+Copyright © 2024 Pavlo Tarasiuk <pasha.tarasyuk@gmail.com>
+*/
+package cmd
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/slack-go/slack"
+)
+
+// Categories commands are grouped by in /help
+const (
+	categoryGeneral  = "General"
+	categoryPersonal = "Personal"
+	categorySurveys  = "Surveys"
+	categoryAdmin    = "Administration"
+)
+
+// categoryOrder is the order categories are listed in, unknown categories follow alphabetically
+var categoryOrder = []string{categoryGeneral, categoryPersonal, categorySurveys, categoryAdmin}
+
+// helpText renders the commands the user may run grouped by category, with their usage and an example
+func (b *Bot) helpText(userID string) string {
+	groups := map[string][]*slashCommand{}
+	for _, command := range slashCommands {
+		if (command.AdminOnly && !b.isAdmin(userID)) || command.DebugOnly {
+			continue
+		}
+		category := command.Category
+		if category == "" {
+			category = categoryGeneral
+		}
+		groups[category] = append(groups[category], command)
+	}
+
+	categories := append([]string{}, categoryOrder...)
+	var extra []string
+	for category := range groups {
+		if !containsString(categoryOrder, category) {
+			extra = append(extra, category)
+		}
+	}
+	sort.Strings(extra)
+	categories = append(categories, extra...)
+
+	var help strings.Builder
+	help.WriteString("*MAVBot commands*\n")
+	for _, category := range categories {
+		commands := groups[category]
+		if len(commands) == 0 {
+			continue
+		}
+		sort.Slice(commands, func(i, j int) bool { return commands[i].Name < commands[j].Name })
+
+		fmt.Fprintf(&help, "\n*%s*\n", category)
+		for _, command := range commands {
+			usage := command.Name
+			if syntax := command.usage(); syntax != "" {
+				usage += " " + syntax
+			}
+			fmt.Fprintf(&help, "• `%s` %s\n", usage, command.Description)
+			if command.Example != "" {
+				fmt.Fprintf(&help, "    e.g. `%s`\n", command.Example)
+			}
+		}
+	}
+	return help.String()
+}
+
+// containsString reports whether s is one of items
+func containsString(items []string, s string) bool {
+	for _, item := range items {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
+
+// handleHelp lists the available commands to the invoking user
+func (b *Bot) handleHelp(command slack.SlashCommand) (*SlashResponse, error) {
+	return ephemeral(b.helpText(command.UserID)), nil
+}
+
+func init() {
+	registerSlashCommand(&slashCommand{
+		Name:        "/help",
+		Description: "List the commands you can use",
+		Category:    categoryGeneral,
+		Handler:     (*Bot).handleHelp,
+	})
+}
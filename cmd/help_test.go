@@ -0,0 +1,69 @@
+/*
+Copyright © 2024 Pavlo Tarasiuk <pasha.tarasyuk@gmail.com>
+*/
+package cmd
+
+import (
+	"strings"
+	"testing"
+)
+
+// helpSection returns the lines /help lists under the category, nil when it doesn't list it
+func helpSection(help, category string) []string {
+	_, rest, ok := strings.Cut(help, "\n*"+category+"*\n")
+	if !ok {
+		return nil
+	}
+	section, _, _ := strings.Cut(rest, "\n\n")
+	return strings.Split(strings.TrimSuffix(section, "\n"), "\n")
+}
+
+func TestHelpGroupsCommandsByCategory(t *testing.T) {
+	registerTestCommand(t, &slashCommand{
+		Name:        "/test-zeta",
+		Description: "Run the last test",
+		Usage:       "<n>",
+		Flags:       []commandFlag{{Name: "--quiet"}},
+		Example:     "/test-zeta 3",
+		Category:    "Testing",
+	})
+	registerTestCommand(t, &slashCommand{Name: "/test-alpha", Description: "Run the first test", Category: "Testing"})
+	registerTestCommand(t, &slashCommand{Name: "/test-uncategorized", Description: "Stray command"})
+	registerTestCommand(t, &slashCommand{Name: "/test-secret", Description: "Admins only", Category: "Testing", AdminOnly: true})
+	registerTestCommand(t, &slashCommand{Name: "/test-debug", Description: "Debugging only", Category: "Testing", DebugOnly: true})
+	cfg := testConfig(t)
+	cfg.Admins = []string{"U0ADMIN"}
+	b, _ := newTestBot(t, cfg)
+
+	help := b.helpText("U1")
+	want := []string{
+		"• `/test-alpha` Run the first test",
+		"• `/test-zeta [--quiet] <n>` Run the last test",
+		"    e.g. `/test-zeta 3`",
+	}
+	if got := helpSection(help, "Testing"); strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("the Testing section lists\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+	if !containsString(helpSection(help, categoryGeneral), "• `/test-uncategorized` Stray command") {
+		t.Errorf("the command without a category isn't listed under General:\n%s", help)
+	}
+	// Known categories come first in their order, the others after them
+	last := -1
+	for _, category := range append(append([]string{}, categoryOrder...), "Testing") {
+		i := strings.Index(help, "\n*"+category+"*\n")
+		if i < 0 {
+			continue
+		}
+		if i < last {
+			t.Errorf("%s is listed out of order:\n%s", category, help)
+		}
+		last = i
+	}
+	if strings.Contains(help, "/test-secret") || strings.Contains(help, "/test-debug") {
+		t.Errorf("the help shows hidden commands to a user:\n%s", help)
+	}
+
+	if admin := b.helpText("U0ADMIN"); !strings.Contains(admin, "• `/test-secret` Admins only") || strings.Contains(admin, "/test-debug") {
+		t.Errorf("the admin's help doesn't list just the admin command:\n%s", admin)
+	}
+}
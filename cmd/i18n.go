@@ -0,0 +1,169 @@
+/*
+Copyright © 2024 Pavlo Tarasiuk <pasha.tarasyuk@gmail.com>
+*/
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"syscall"
+
+	"github.com/slack-go/slack"
+)
+
+// catalogRef holds the message catalogs, which are replaced when they are reloaded
+type catalogRef struct {
+	mu       sync.RWMutex
+	catalogs *catalogs
+}
+
+// get returns the current catalogs
+func (r *catalogRef) get() *catalogs {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.catalogs
+}
+
+// set replaces the catalogs, renders in progress finish with the previous ones
+func (r *catalogRef) set(c *catalogs) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.catalogs = c
+}
+
+// messages returns the message catalogs of every language
+func (b *Bot) messages() *catalogs {
+	return b.catalogs.get()
+}
+
+// readCatalogDir reads the translations in dir, one <language>.json file per language mapping
+// template names to their sources, into sources, replacing the templates they define
+func readCatalogDir(dir string, sources map[string]map[string]string) error {
+	files, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	if err != nil {
+		return fmt.Errorf("failed to list catalogs: %w", err)
+	}
+	for _, file := range files {
+		raw, err := os.ReadFile(file)
+		if err != nil {
+			return fmt.Errorf("failed to read catalog: %w", err)
+		}
+		var templates map[string]string
+		if err := json.Unmarshal(raw, &templates); err != nil {
+			return fmt.Errorf("failed to decode catalog %s: %w", filepath.Base(file), err)
+		}
+		lang := strings.TrimSuffix(filepath.Base(file), ".json")
+		if sources[lang] == nil {
+			sources[lang] = make(map[string]string, len(templates))
+		}
+		for name, source := range templates {
+			sources[lang][name] = source
+		}
+	}
+	return nil
+}
+
+// loadCatalogs builds the catalogs from the built-in ones, the translations in the catalog
+// directory and the configuration, checking that every template renders
+func loadCatalogs(cfg *Config) (*catalogs, error) {
+	sources, err := catalogSources(cfg)
+	if err != nil {
+		return nil, err
+	}
+	c, err := parseCatalogs(sources, cfg.DefaultLocale)
+	if err != nil {
+		return nil, err
+	}
+	if err := c.validate(); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// validate renders every template with empty data, catching errors parsing can't, like unknown fields
+func (c *catalogs) validate() error {
+	for _, lang := range c.list() {
+		for _, name := range c.names(lang) {
+			if _, err := c.render(lang, name, templateData{}); err != nil {
+				return fmt.Errorf("invalid template %s/%s: %w", lang, name, err)
+			}
+		}
+	}
+	return nil
+}
+
+// reloadCatalogs reads the catalogs again and swaps them in when they are valid, the current
+// ones stay in use otherwise. It returns the number of templates of every language.
+func (b *Bot) reloadCatalogs() (map[string]int, error) {
+	c, err := loadCatalogs(b.cfg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to reload catalogs: %w", err)
+	}
+	b.catalogs.set(c)
+	counts := make(map[string]int)
+	for _, lang := range c.list() {
+		counts[lang] = len(c.names(lang))
+	}
+	return counts, nil
+}
+
+// formatCatalogCounts renders the template counts by language, e.g. "en: 6, uk: 6"
+func formatCatalogCounts(counts map[string]int) string {
+	langs := make([]string, 0, len(counts))
+	for lang := range counts {
+		langs = append(langs, lang)
+	}
+	sort.Strings(langs)
+	parts := make([]string, 0, len(counts))
+	for _, lang := range langs {
+		parts = append(parts, fmt.Sprintf("%s: %d", lang, counts[lang]))
+	}
+	return strings.Join(parts, ", ")
+}
+
+// reloadOnHangup reloads the catalogs whenever the process receives SIGHUP, until ctx is cancelled
+func (b *Bot) reloadOnHangup(ctx context.Context) {
+	hangup := make(chan os.Signal, 1)
+	signal.Notify(hangup, syscall.SIGHUP)
+	defer signal.Stop(hangup)
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-hangup:
+			counts, err := b.reloadCatalogs()
+			if err != nil {
+				log.Println(err)
+				continue
+			}
+			log.Printf("Catalogs reloaded: %s\n", formatCatalogCounts(counts))
+		}
+	}
+}
+
+// handleReloadI18n re-reads the message catalogs and reports how many templates every language has
+func (b *Bot) handleReloadI18n(command slack.SlashCommand) (*SlashResponse, error) {
+	counts, err := b.reloadCatalogs()
+	if err != nil {
+		return ephemeral(fmt.Sprintf("The catalogs were not reloaded, the current ones stay in use: %v", err)), nil
+	}
+	return ephemeral("Catalogs reloaded, templates per language: " + formatCatalogCounts(counts)), nil
+}
+
+func init() {
+	registerSlashCommand(&slashCommand{
+		Name:        "/reload-i18n",
+		Description: "Re-read the message catalogs from disk, the same as sending MAVBot SIGHUP",
+		Category:    categoryAdmin,
+		AdminOnly:   true,
+		Handler:     (*Bot).handleReloadI18n,
+	})
+}
@@ -0,0 +1,153 @@
+/*
+Copyright © 2024 Pavlo Tarasiuk <pasha.tarasyuk@gmail.com>
+*/
+package cmd
+
+import (
+	"context"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/slack-go/slack"
+)
+
+// writeCatalog writes the catalog of the language to dir
+func writeCatalog(t *testing.T, dir, lang, content string) {
+	t.Helper()
+	if err := os.WriteFile(filepath.Join(dir, lang+".json"), []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// newCatalogBot creates a bot reading its catalogs from a temporary directory
+func newCatalogBot(t *testing.T) (*Bot, string) {
+	cfg := testConfig(t)
+	cfg.CatalogDir = t.TempDir()
+	b, _ := newTestBot(t, cfg)
+	return b, cfg.CatalogDir
+}
+
+// greet renders the greeting for U1
+func greet(t *testing.T, b *Bot) string {
+	t.Helper()
+	text, err := b.render("U1", templateGreeting, templateData{User: "pasha"})
+	if err != nil {
+		t.Fatalf("render() error = %v", err)
+	}
+	return text
+}
+
+// reloadI18n runs /reload-i18n and returns the answer
+func reloadI18n(t *testing.T, b *Bot) string {
+	t.Helper()
+	resp, err := b.handleReloadI18n(slack.SlashCommand{Command: "/reload-i18n", UserID: "U0ADMIN", ChannelID: "C1"})
+	if err != nil {
+		t.Fatalf("/reload-i18n failed: %v", err)
+	}
+	return resp.Text
+}
+
+func TestReadCatalogDir(t *testing.T) {
+	dir := t.TempDir()
+	writeCatalog(t, dir, "en", `{"greeting":"Hi {{.User}}"}`)
+	writeCatalog(t, dir, "pl", `{"greeting":"Cześć {{.User}}"}`)
+	if err := os.WriteFile(filepath.Join(dir, "notes.txt"), []byte("not a catalog"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	sources := map[string]map[string]string{"en": {"greeting": "Hello", "help_offer": "How can I help"}}
+	if err := readCatalogDir(dir, sources); err != nil {
+		t.Fatalf("readCatalogDir() error = %v", err)
+	}
+	if got := sources["en"]["greeting"]; got != "Hi {{.User}}" {
+		t.Errorf("en greeting = %q, want the one from the directory", got)
+	}
+	if got := sources["en"]["help_offer"]; got != "How can I help" {
+		t.Errorf("en help_offer = %q, want the one not overridden kept", got)
+	}
+	if got := sources["pl"]["greeting"]; got != "Cześć {{.User}}" {
+		t.Errorf("pl greeting = %q, want the new language added", got)
+	}
+
+	writeCatalog(t, dir, "uk", `{"greeting":`)
+	if err := readCatalogDir(dir, sources); err == nil || !strings.Contains(err.Error(), "uk.json") {
+		t.Errorf("readCatalogDir() of a broken catalog error = %v, want it naming uk.json", err)
+	}
+}
+
+func TestReloadChangesTheGreeting(t *testing.T) {
+	b, dir := newCatalogBot(t)
+	if got := greet(t, b); got != "Hello pasha" {
+		t.Fatalf("greeting before the reload = %q", got)
+	}
+
+	writeCatalog(t, dir, "en", `{"greeting":"Hi there {{.User}}"}`)
+	writeCatalog(t, dir, "pl", `{"greeting":"Cześć {{.User}}"}`)
+	if got := greet(t, b); got != "Hello pasha" {
+		t.Errorf("greeting changed before the reload: %q", got)
+	}
+	if got, want := reloadI18n(t, b), "Catalogs reloaded, templates per language: en: 5, pl: 1, uk: 5"; got != want {
+		t.Errorf("/reload-i18n = %q, want %q", got, want)
+	}
+	if got := greet(t, b); got != "Hi there pasha" {
+		t.Errorf("greeting after the reload = %q, want the reloaded one", got)
+	}
+}
+
+func TestInvalidCatalogIsNotSwapped(t *testing.T) {
+	tests := []struct {
+		name    string
+		catalog string
+	}{
+		{"broken JSON", `{"greeting":`},
+		{"parse error", `{"greeting":"Hi {{.User"}`},
+		{"unknown field", `{"greeting":"Hi {{.Nickname}}"}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b, dir := newCatalogBot(t)
+			writeCatalog(t, dir, "en", tt.catalog)
+
+			if got := reloadI18n(t, b); !strings.HasPrefix(got, "The catalogs were not reloaded, the current ones stay in use") {
+				t.Errorf("/reload-i18n = %q, want the reload refused", got)
+			}
+			if got := greet(t, b); got != "Hello pasha" {
+				t.Errorf("greeting after the refused reload = %q, want the current one", got)
+			}
+		})
+	}
+}
+
+func TestReloadOnHangup(t *testing.T) {
+	captureLog(t)
+	// Keep SIGHUP from ending the test process before the bot listens for it
+	guard := make(chan os.Signal, 1)
+	signal.Notify(guard, syscall.SIGHUP)
+	defer signal.Stop(guard)
+
+	b, dir := newCatalogBot(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go b.reloadOnHangup(ctx)
+	self, err := os.FindProcess(os.Getpid())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	writeCatalog(t, dir, "en", `{"greeting":"Hi there {{.User}}"}`)
+	deadline := time.Now().Add(2 * time.Second)
+	for greet(t, b) != "Hi there pasha" {
+		if time.Now().After(deadline) {
+			t.Fatalf("greeting = %q, want the catalog reloaded on SIGHUP", greet(t, b))
+		}
+		if err := self.Signal(syscall.SIGHUP); err != nil {
+			t.Fatal(err)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
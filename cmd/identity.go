@@ -0,0 +1,53 @@
+/*
+Copyright © 2024 Pavlo Tarasiuk <pasha.tarasyuk@gmail.com>
+*/
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/slack-go/slack"
+)
+
+// identify asks Slack who the bot is, so its own messages and actions can be recognised. Messages
+// posted with PostingTokens come from the bot users of their apps, so those are asked about too.
+func (b *Bot) identify() error {
+	auth, err := b.api().AuthTest()
+	switch {
+	// A reloaded token has been identified with already
+	case err != nil && isInactiveToken(err) && b.handleInactiveToken(err):
+	case err != nil:
+		return fmt.Errorf("failed to identify the bot: %w", err)
+	default:
+		b.setIdentity(auth)
+	}
+
+	for i, client := range b.clients.others() {
+		auth, err := client.AuthTest()
+		if err != nil {
+			return fmt.Errorf("failed to identify posting token %d: %w", i+1, err)
+		}
+		for _, id := range []string{auth.UserID, auth.BotID} {
+			if id != "" {
+				b.poolIDs[id] = true
+			}
+		}
+	}
+	return nil
+}
+
+// setIdentity remembers who Slack says the bot is
+func (b *Bot) setIdentity(auth *slack.AuthTestResponse) {
+	b.selfUserID = auth.UserID
+	b.selfBotID = auth.BotID
+	b.workspaceURL = auth.URL
+}
+
+// isOwnMessage reports whether a message with the given author was posted by the bot, with its
+// own token or one of PostingTokens
+func (b *Bot) isOwnMessage(userID, botID string) bool {
+	if (userID != "" && userID == b.selfUserID) || (botID != "" && botID == b.selfBotID) {
+		return true
+	}
+	return b.poolIDs[userID] || b.poolIDs[botID]
+}
@@ -0,0 +1,242 @@
+/*
+Copyright © 2024 Pavlo Tarasiuk <pasha.tarasyuk@gmail.com>
+*/
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"path"
+	"regexp"
+	"strings"
+	"sync"
+
+	"github.com/slack-go/slack"
+)
+
+// userReference matches a user as Slack escapes it in command text, e.g. <@U123|jane>
+var userReference = regexp.MustCompile(`^<@([A-Z0-9]+)(?:\|[^>]*)?>$`)
+
+// parseUserArg returns the user ID referenced by arg
+func parseUserArg(arg string) string {
+	if m := userReference.FindStringSubmatch(arg); m != nil {
+		return m[1]
+	}
+	return arg
+}
+
+// capture collects what a shadow bot would have sent, see capturing
+type capture struct {
+	mu       sync.Mutex
+	messages []outboundMessage
+	// webhooks are the messages sent to response URLs, like the responses replacing a loading message
+	webhooks []slack.WebhookMessage
+}
+
+// addMessage collects a message the bot would have posted
+func (c *capture) addMessage(msg outboundMessage) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.messages = append(c.messages, msg)
+}
+
+// addWebhook collects a message the bot would have sent to a response URL
+func (c *capture) addWebhook(msg slack.WebhookMessage) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.webhooks = append(c.webhooks, msg)
+}
+
+// collected returns what was collected so far
+func (c *capture) collected() ([]outboundMessage, []slack.WebhookMessage) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([]outboundMessage{}, c.messages...), append([]slack.WebhookMessage{}, c.webhooks...)
+}
+
+// capturing returns a copy of the bot that runs commands without side effects: the messages it
+// would post are collected instead, its Store discards writes and of the Web API only the methods
+// that read are called, the others are answered like a dry run
+func (b *Bot) capturing() (*Bot, *capture) {
+	captured := &capture{}
+	shadow := *b
+	shadow.captured = captured
+	shadow.store = discardingStore{b.store}
+	shadow.activity = &activityLog{store: shadow.store}
+	// Responses cached for the impersonated user mustn't be served to anyone else
+	shadow.results = newResultCache(b.now)
+	shadow.httpClient = &http.Client{Transport: &shadowTransport{next: transportOf(b.httpClient), capture: captured}}
+	token := b.client.token()
+	shadow.client = &clientRef{
+		client:     slack.New(token, slack.OptionHTTPClient(shadow.httpClient), slack.OptionAPIURL(b.apiURL)),
+		tokenValue: token,
+	}
+	canvases := newWebAPI(shadow.httpClient, shadow.client)
+	canvases.url = b.apiURL
+	shadow.canvases = canvases
+	return &shadow, captured
+}
+
+// discardingStore reads from the Store it wraps and drops every write
+type discardingStore struct {
+	Store
+}
+
+// Put implements Store
+func (discardingStore) Put(collection, key string, v interface{}) error {
+	return nil
+}
+
+// Delete implements Store
+func (discardingStore) Delete(collection, key string) error {
+	return nil
+}
+
+// readMethod matches the Web API methods that only read, which a shadow bot still calls
+var readMethod = regexp.MustCompile(`^(?:auth\.test|[a-zA-Z.]+\.(?:info|list|history|replies|members|conversations|getPermalink|lookupByEmail|get))$`)
+
+// dryRunResults are the responses of the methods a shadow bot doesn't call whose callers need
+// more than a bare success
+var dryRunResults = map[string]string{
+	"conversations.open": `{"ok":true,"channel":{"id":"D00DRYRUN"}}`,
+}
+
+// shadowTransport is the transport of a shadow bot. Web API methods that read go through next,
+// the other methods and requests to response URLs are answered without being sent.
+type shadowTransport struct {
+	next    http.RoundTripper
+	capture *capture
+}
+
+// RoundTrip implements http.RoundTripper
+func (t *shadowTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	method := path.Base(req.URL.Path)
+	isAPI := strings.Contains(req.URL.Path, "/api/")
+	if isAPI && readMethod.MatchString(method) {
+		return t.next.RoundTrip(req)
+	}
+
+	var body []byte
+	if req.Body != nil {
+		body, _ = io.ReadAll(req.Body)
+		req.Body.Close()
+	}
+	result := `{"ok":true}`
+	if isAPI {
+		log.Printf("dry-run: %s\n", method)
+		if r, ok := dryRunResults[method]; ok {
+			result = r
+		}
+	} else {
+		var msg slack.WebhookMessage
+		if err := json.Unmarshal(body, &msg); err == nil {
+			t.capture.addWebhook(msg)
+		}
+	}
+	return &http.Response{
+		StatusCode: http.StatusOK,
+		Header:     http.Header{"Content-Type": {"application/json"}},
+		Body:       io.NopCloser(bytes.NewReader([]byte(result))),
+		Request:    req,
+	}, nil
+}
+
+// transportOf returns the transport requests made with client go through
+func transportOf(client *http.Client) http.RoundTripper {
+	if client == nil || client.Transport == nil {
+		return http.DefaultTransport
+	}
+	return client.Transport
+}
+
+// handleAs runs a command as if the given user invoked it, with their preferences applied:
+// /as @user /command [text]. Everything the command would post goes to the admin only.
+func (b *Bot) handleAs(command slack.SlashCommand) (*SlashResponse, error) {
+	args := strings.SplitN(strings.TrimSpace(command.Text), " ", 3)
+	if len(args) < 2 || !strings.HasPrefix(args[1], "/") {
+		return ephemeral(usageOf("/as")), nil
+	}
+
+	target, ok := slashCommands[args[1]]
+	if !ok {
+		return ephemeral(fmt.Sprintf("Unknown command %s", args[1])), nil
+	}
+	// Admin commands keep acting as the admin, impersonating for them makes no sense
+	if target.AdminOnly {
+		return ephemeral(fmt.Sprintf("%s is an admin command and can't be run as another user", target.Name)), nil
+	}
+
+	user, err := b.userInfo(parseUserArg(args[0]))
+	if err != nil {
+		return ephemeral(fmt.Sprintf("Could not find user %s: %v", args[0], err)), nil
+	}
+
+	impersonated := command
+	impersonated.Command = target.Name
+	impersonated.UserID = user.ID
+	impersonated.UserName = user.Name
+	impersonated.Text = ""
+	if len(args) == 3 {
+		impersonated.Text = args[2]
+	}
+
+	// The command goes through the checks any invocation does, as the user would run into them
+	shadow, captured := b.capturing()
+	payload, err := shadow.dispatchSlashCommand(impersonated)
+	if err != nil {
+		return ephemeral(fmt.Sprintf("%s failed as %s: %v", target.Name, user.Name, err)), nil
+	}
+	if payload != nil && payload.done != nil {
+		// The response replacing the loading message is what the user gets back
+		done := payload.done
+		return b.respondLater(command, fmt.Sprintf("Running %s as %s...", target.Name, user.Name), func() (*SlashResponse, error) {
+			<-done
+			var replaced *SlashResponse
+			if _, webhooks := captured.collected(); len(webhooks) > 0 {
+				last := webhooks[len(webhooks)-1]
+				replaced = &SlashResponse{Text: last.Text, Attachments: last.Attachments}
+			}
+			return impersonationResult(target, user, captured, replaced), nil
+		}), nil
+	}
+	// The posts the response asks for are captured like any other
+	payload = shadow.sendPosts(impersonated.UserID, payload)
+	return impersonationResult(target, user, captured, payload), nil
+}
+
+// impersonationResult shows the admin both what the command run as the user would post and
+// what the user would get back
+func impersonationResult(target *slashCommand, user *slack.User, captured *capture, payload *SlashResponse) *SlashResponse {
+	messages, _ := captured.collected()
+	response := ephemeral(fmt.Sprintf("Result of %s as %s:", target.Name, user.Name))
+	for _, msg := range messages {
+		response.Attachments = append(response.Attachments, slack.Attachment{
+			Pretext: fmt.Sprintf("Would post to <#%s>:", msg.Channel),
+			Text:    msg.Text,
+		})
+		response.Attachments = append(response.Attachments, msg.Attachments...)
+	}
+	if payload != nil {
+		if payload.Text != "" {
+			response.Attachments = append(response.Attachments, slack.Attachment{Pretext: "Response:", Text: payload.Text})
+		}
+		response.Attachments = append(response.Attachments, payload.Attachments...)
+	}
+	return response
+}
+
+func init() {
+	registerSlashCommand(&slashCommand{
+		Name:        "/as",
+		Description: "Run a command as another user to see what they would get",
+		Usage:       "@user /command [text]",
+		Example:     "/as @ivan /hello",
+		Category:    categoryAdmin,
+		AdminOnly:   true,
+		Handler:     (*Bot).handleAs,
+	})
+}
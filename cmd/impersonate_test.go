@@ -0,0 +1,146 @@
+/*
+Copyright © 2024 Pavlo Tarasiuk <pasha.tarasyuk@gmail.com>
+*/
+package cmd
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/slack-go/slack"
+)
+
+// runAs runs /as as the admin U0ADMIN in channel C1
+func runAs(t *testing.T, b *Bot, text string) *SlashResponse {
+	t.Helper()
+	response, err := b.dispatchSlashCommand(slack.SlashCommand{Command: "/as", Text: text, UserID: "U0ADMIN", UserName: "admin", ChannelID: "C1"})
+	if err != nil {
+		t.Fatalf("/as %s failed: %v", text, err)
+	}
+	return response
+}
+
+// shownText joins the text of the response and its attachments
+func shownText(response *SlashResponse) string {
+	parts := []string{response.Text}
+	for _, attachment := range response.Attachments {
+		parts = append(parts, attachment.Pretext, attachment.Text)
+	}
+	return strings.Join(parts, "\n")
+}
+
+func newImpersonationBot(t *testing.T) (*Bot, *fakeSlack) {
+	cfg := testConfig(t)
+	cfg.Admins = []string{"U0ADMIN"}
+	b, fake := newTestBot(t, cfg)
+	fake.answer("users.info", `{"ok":true,"user":{"id":"U0IVAN","name":"ivan"}}`)
+	return b, fake
+}
+
+func TestAsAppliesTheUsersPreferences(t *testing.T) {
+	tests := []struct {
+		name   string
+		locale string
+		want   string
+	}{
+		{name: "default locale", want: "Hello ivan! You said: hi"},
+		{name: "Ukrainian", locale: "uk", want: "Привіт, ivan! Ви сказали: hi"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b, fake := newImpersonationBot(t)
+			if tt.locale != "" {
+				if err := b.store.Put(collectionPrefs, "U0IVAN", userPrefs{Locale: tt.locale}); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			response := runAs(t, b, "<@U0IVAN|ivan> /hello hi")
+
+			if response.ResponseType != slack.ResponseTypeEphemeral {
+				t.Errorf("got response type %q, want the result shown to the admin only", response.ResponseType)
+			}
+			text := shownText(response)
+			if !strings.Contains(text, "Result of /hello as ivan") || !strings.Contains(text, tt.want) {
+				t.Errorf("got\n%s\nwant it to contain %q", text, tt.want)
+			}
+			if posts := fake.posts(); len(posts) != 0 {
+				t.Errorf("posted %q while impersonating", posts)
+			}
+		})
+	}
+}
+
+func TestAsHasNoSideEffects(t *testing.T) {
+	b, fake := newImpersonationBot(t)
+
+	response := runAs(t, b, "<@U0IVAN> /prefs locale uk")
+
+	if text := shownText(response); !strings.Contains(text, "Your language is now uk") {
+		t.Errorf("got\n%s\nwant the command's answer", text)
+	}
+	if prefs := b.userPrefs("U0IVAN"); prefs.Locale != "" {
+		t.Errorf("impersonating changed the user's preferences to %+v", prefs)
+	}
+	for _, call := range fake.received {
+		if call.Method != "users.info" {
+			t.Errorf("impersonating called %s", call.Method)
+		}
+	}
+}
+
+func TestAsGoesThroughTheDispatchChecks(t *testing.T) {
+	b, _ := newImpersonationBot(t)
+	b.maintenance.set(true)
+
+	text := shownText(runAs(t, b, "<@U0IVAN> /hello hi"))
+	if !strings.Contains(text, b.cfg.MaintenanceMessage) || strings.Contains(text, "You said") {
+		t.Errorf("got\n%s\nwant the maintenance message the user would get", text)
+	}
+}
+
+func TestAsRefusals(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		want string
+	}{
+		{name: "usage", text: "<@U0IVAN>", want: "Usage: /as @user /command [text]"},
+		{name: "not a command", text: "<@U0IVAN> hello", want: "Usage: /as @user /command [text]"},
+		{name: "unknown command", text: "<@U0IVAN> /nope", want: "Unknown command /nope"},
+		{name: "admin command", text: "<@U0IVAN> /allow add", want: "/allow is an admin command and can't be run as another user"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b, _ := newImpersonationBot(t)
+			if got := runAs(t, b, tt.text).Text; got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAsIsAdminOnly(t *testing.T) {
+	b, _ := newImpersonationBot(t)
+	response, err := b.dispatchSlashCommand(slack.SlashCommand{Command: "/as", Text: "<@U0IVAN> /hello hi", UserID: "U1", ChannelID: "C1"})
+	if err != nil {
+		t.Fatalf("/as failed: %v", err)
+	}
+	if response.Text != "Sorry, this command is available to MAVBot admins only" {
+		t.Errorf("got %q, want the command refused", response.Text)
+	}
+}
+
+func TestParseUserArg(t *testing.T) {
+	tests := map[string]string{
+		"<@U123|jane>": "U123",
+		"<@U123>":      "U123",
+		"U123":         "U123",
+		"@jane":        "@jane",
+	}
+	for arg, want := range tests {
+		if got := parseUserArg(arg); got != want {
+			t.Errorf("parseUserArg(%q) = %q, want %q", arg, got, want)
+		}
+	}
+}
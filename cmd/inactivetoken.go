@@ -0,0 +1,112 @@
+/*
+Copyright © 2024 Pavlo Tarasiuk <pasha.tarasyuk@gmail.com>
+*/
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+
+	"github.com/slack-go/slack"
+)
+
+// errPostingStopped is returned for messages posted after the bot token stopped working for good
+var errPostingStopped = errors.New("posting stopped, Slack doesn't accept the bot token anymore")
+
+// inactiveTokenCodes are the errors Slack responds with once the bot token is revoked or deactivated
+var inactiveTokenCodes = map[string]bool{
+	"account_inactive": true,
+	"token_revoked":    true,
+}
+
+// isInactiveToken reports whether err means Slack doesn't accept the bot token anymore
+func isInactiveToken(err error) bool {
+	code, _ := slackErrorCode(err)
+	return inactiveTokenCodes[code]
+}
+
+// tokenReload recovers from a bot token Slack stopped accepting. The token is read again from
+// secrets and, when that doesn't give a working one, posting stops and the bot shuts down.
+type tokenReload struct {
+	// secrets is where the bot token is read again from, the tokenRotator when the token rotates,
+	// nil when it can't be reloaded
+	secrets SecretProvider
+	// newClient creates a client for a reloaded token
+	newClient func(token string) *slack.Client
+	// shutdown signals the bot to shut down, nil when there is nothing to signal yet
+	shutdown func()
+
+	mu      sync.Mutex
+	stopped bool
+}
+
+// isStopped reports whether posting has stopped
+func (r *tokenReload) isStopped() bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.stopped
+}
+
+// handleInactiveToken reacts to Slack rejecting the bot token with err. It reports whether the
+// bot has a working token again, otherwise posting has stopped and the shutdown is signalled.
+func (b *Bot) handleInactiveToken(err error) bool {
+	r := b.tokenReload
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.stopped {
+		return false
+	}
+
+	log.Printf("CRITICAL: Slack doesn't accept the bot token anymore: %v\n", err)
+	reloadErr := b.reloadToken()
+	if reloadErr == nil {
+		log.Println("Bot token reloaded, posting continues")
+		return true
+	}
+	log.Printf("CRITICAL: %v, posting stopped and shutting down\n", reloadErr)
+	r.stopped = true
+	if r.shutdown != nil {
+		r.shutdown()
+	}
+	return false
+}
+
+// reloadToken reads the bot token again and switches to it once Slack accepts it. The token
+// may be the current one when another call has reloaded it already.
+func (b *Bot) reloadToken() error {
+	r := b.tokenReload
+	if r.secrets == nil || r.newClient == nil {
+		return errors.New("failed to reload the bot token: no secret provider configured")
+	}
+	token, err := r.secrets.Secret("SLACK_AUTH_TOKEN")
+	if err != nil {
+		return fmt.Errorf("failed to reload the bot token: %w", err)
+	}
+	if token == "" {
+		return errors.New("failed to reload the bot token: SLACK_AUTH_TOKEN is empty")
+	}
+
+	client := b.api()
+	if token != b.client.token() {
+		client = r.newClient(token)
+	}
+	auth, err := client.AuthTest()
+	if err != nil {
+		return fmt.Errorf("failed to reload the bot token: %w", err)
+	}
+	b.client.set(client, token)
+	b.setIdentity(auth)
+	return nil
+}
+
+// recoverToken reports whether a call made with member failed for an inactive bot token and
+// a working one has been reloaded since, so the call is worth repeating. Only the bot's own
+// client is recovered, PostingTokens are left to the pool.
+func (b *Bot) recoverToken(member *pooledClient, err error) bool {
+	if err == nil || member.client != nil || !isInactiveToken(err) {
+		return false
+	}
+	return b.handleInactiveToken(err)
+}
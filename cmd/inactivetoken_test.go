@@ -0,0 +1,143 @@
+/*
+Copyright © 2024 Pavlo Tarasiuk <pasha.tarasyuk@gmail.com>
+*/
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/slack-go/slack"
+)
+
+// staticSecrets provides the secrets it holds
+type staticSecrets map[string]string
+
+func (s staticSecrets) Secret(key string) (string, error) {
+	value, ok := s[key]
+	if !ok {
+		return "", fmt.Errorf("no secret %s", key)
+	}
+	return value, nil
+}
+
+func TestIsInactiveToken(t *testing.T) {
+	tests := []struct {
+		err  error
+		want bool
+	}{
+		{err: slack.SlackErrorResponse{Err: "account_inactive"}, want: true},
+		{err: fmt.Errorf("failed to post message: %w", slack.SlackErrorResponse{Err: "token_revoked"}), want: true},
+		{err: slack.SlackErrorResponse{Err: "channel_not_found"}},
+		{err: errors.New("account_inactive")},
+		{err: nil},
+	}
+	for _, tt := range tests {
+		if got := isInactiveToken(tt.err); got != tt.want {
+			t.Errorf("isInactiveToken(%v) = %t, want %t", tt.err, got, tt.want)
+		}
+	}
+}
+
+// newInactiveTokenBot returns a bot whose token Slack answers with account_inactive, which
+// reloads the token from secrets into a client of the replacement Slack
+func newInactiveTokenBot(t *testing.T, secrets SecretProvider) (b *Bot, old, replacement *fakeSlack, shutdowns *int) {
+	t.Helper()
+	b, old = newTestBot(t, nil)
+	old.answer("chat.postMessage", `{"ok":false,"error":"account_inactive"}`)
+	old.answer("auth.test", `{"ok":false,"error":"account_inactive"}`)
+	replacement = newFakeSlack(t)
+	replacement.answer("auth.test", `{"ok":true,"user_id":"U0NEW","bot_id":"B0NEW"}`)
+	shutdowns = new(int)
+	b.tokenReload.secrets = secrets
+	b.tokenReload.newClient = func(token string) *slack.Client {
+		return slack.New(token, slack.OptionAPIURL(replacement.apiURL()))
+	}
+	b.tokenReload.shutdown = func() { *shutdowns++ }
+	return b, old, replacement, shutdowns
+}
+
+func TestInactiveTokenIsReloaded(t *testing.T) {
+	captureLog(t)
+	b, old, replacement, shutdowns := newInactiveTokenBot(t, staticSecrets{"SLACK_AUTH_TOKEN": "xoxb-new"})
+
+	if _, err := b.postMessage(outboundMessage{Channel: "C1", Text: "Deploy finished"}); err != nil {
+		t.Fatalf("post failed after the reload: %v", err)
+	}
+	if got := len(old.calls("chat.postMessage")); got != 1 {
+		t.Errorf("tried the old token %d times, want once", got)
+	}
+	if got := replacement.posts(); len(got) != 1 || got[0] != "Deploy finished" {
+		t.Errorf("posted %q with the reloaded token, want the message repeated", got)
+	}
+	if b.client.token() != "xoxb-new" || b.selfUserID != "U0NEW" {
+		t.Errorf("bot uses token %q as %q, want the reloaded token identified", b.client.token(), b.selfUserID)
+	}
+	if *shutdowns != 0 || b.tokenReload.isStopped() {
+		t.Errorf("posting stopped although the token was reloaded")
+	}
+}
+
+func TestInactiveTokenShutsDown(t *testing.T) {
+	tests := []struct {
+		name    string
+		secrets SecretProvider
+		// rejected is whether Slack rejects the reloaded token too
+		rejected bool
+		want     string
+	}{
+		{name: "no secret provider", want: "no secret provider configured"},
+		{name: "no token", secrets: staticSecrets{}, want: "no secret SLACK_AUTH_TOKEN"},
+		{name: "same token", secrets: staticSecrets{"SLACK_AUTH_TOKEN": "xoxb-test"}, want: "account_inactive"},
+		{name: "rejected token", secrets: staticSecrets{"SLACK_AUTH_TOKEN": "xoxb-new"}, rejected: true, want: "account_inactive"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			logs := captureLog(t)
+			b, old, replacement, shutdowns := newInactiveTokenBot(t, tt.secrets)
+			if tt.rejected {
+				replacement.answer("auth.test", `{"ok":false,"error":"account_inactive"}`)
+			}
+
+			if _, err := b.postMessage(outboundMessage{Channel: "C1", Text: "Deploy finished"}); !isInactiveToken(err) {
+				t.Errorf("post error = %v, want the inactive token", err)
+			}
+			if !strings.Contains(logs.String(), "CRITICAL: Slack doesn't accept the bot token anymore") {
+				t.Errorf("logs = %q, want the inactive token logged as critical", logs.String())
+			}
+			if !strings.Contains(logs.String(), tt.want) {
+				t.Errorf("logs = %q, want why the reload failed: %q", logs.String(), tt.want)
+			}
+			if *shutdowns != 1 || !b.tokenReload.isStopped() {
+				t.Fatalf("shutdown signalled %d times, stopped %t, want posting stopped once", *shutdowns, b.tokenReload.isStopped())
+			}
+
+			// Nothing is posted, nor is the shutdown signalled again
+			if _, err := b.postMessage(outboundMessage{Channel: "C1", Text: "Still here?"}); !errors.Is(err, errPostingStopped) {
+				t.Errorf("post error = %v, want posting stopped", err)
+			}
+			if got := len(old.calls("chat.postMessage")) + len(replacement.calls("chat.postMessage")); got != 1 {
+				t.Errorf("made %d posts, want none after posting stopped", got)
+			}
+			if err := b.identify(); err == nil {
+				t.Errorf("identify() succeeded with an inactive token")
+			}
+			if *shutdowns != 1 {
+				t.Errorf("shutdown signalled %d times, want once", *shutdowns)
+			}
+		})
+	}
+}
+
+func TestIdentifyReloadsInactiveToken(t *testing.T) {
+	captureLog(t)
+	b, _, _, shutdowns := newInactiveTokenBot(t, staticSecrets{"SLACK_AUTH_TOKEN": "xoxb-new"})
+	if err := b.identify(); err != nil {
+		t.Fatalf("identify() error = %v, want the reloaded token identified", err)
+	}
+	if b.selfUserID != "U0NEW" || *shutdowns != 0 {
+		t.Errorf("identified as %q with %d shutdowns, want U0NEW and none", b.selfUserID, *shutdowns)
+	}
+}
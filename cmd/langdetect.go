@@ -0,0 +1,55 @@
+/*
+Copyright © 2024 Pavlo Tarasiuk <pasha.tarasyuk@gmail.com>
+*/
+package cmd
+
+import (
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+// slackMarkup matches the parts of a message that aren't written by the user, like <@U123> or <https://...>
+var slackMarkup = regexp.MustCompile(`<[^>]*>`)
+
+// ukrainianLetters only occur in Ukrainian among the Cyrillic languages
+const ukrainianLetters = "іїєґІЇЄҐ"
+
+// detectLanguage guesses the language of text from the letters it is written in.
+// It knows English and Ukrainian and returns an empty string when it can't tell.
+func detectLanguage(text string) string {
+	text = slackMarkup.ReplaceAllString(text, " ")
+
+	var latin, cyrillic int
+	for _, r := range text {
+		switch {
+		case unicode.Is(unicode.Latin, r):
+			latin++
+		case unicode.Is(unicode.Cyrillic, r):
+			cyrillic++
+		}
+	}
+	switch {
+	case cyrillic > latin:
+		// Cyrillic text without a single Ukrainian letter is likely another language
+		if strings.ContainsAny(text, ukrainianLetters) {
+			return "uk"
+		}
+		return ""
+	case latin > 0:
+		return "en"
+	}
+	return ""
+}
+
+// messageLocale returns the language to answer a message of the user in: the language the
+// message is written in when detection is enabled and there is a catalog for it, else the
+// user's preferred language
+func (b *Bot) messageLocale(userID, text string) string {
+	if b.features.enabled(featureLanguageDetection) {
+		if lang := detectLanguage(text); lang != "" && b.messages().has(lang) {
+			return lang
+		}
+	}
+	return b.userLocale(userID)
+}
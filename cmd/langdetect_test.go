@@ -0,0 +1,102 @@
+/*
+Copyright © 2024 Pavlo Tarasiuk <pasha.tarasyuk@gmail.com>
+*/
+package cmd
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/slack-go/slack"
+	"github.com/slack-go/slack/slackevents"
+)
+
+func TestDetectLanguage(t *testing.T) {
+	tests := []struct {
+		text string
+		want string
+	}{
+		{"hello, how are you?", "en"},
+		{"привіт, підкажіть будь ласка", "uk"},
+		{"<@U0BOT> привіт, що нового?", "uk"},
+		{"<@U0BOT> <https://example.com|link> hello", "en"},
+		// Russian has no letter only Ukrainian uses
+		{"привет, как дела?", ""},
+		{"👍 123", ""},
+		{"", ""},
+		{"MAVBot, допоможіть будь ласка", "uk"},
+	}
+	for _, tt := range tests {
+		if got := detectLanguage(tt.text); got != tt.want {
+			t.Errorf("detectLanguage(%q) = %q, want %q", tt.text, got, tt.want)
+		}
+	}
+}
+
+func TestMessageLocale(t *testing.T) {
+	tests := []struct {
+		name     string
+		detect   bool
+		userLang string
+		text     string
+		want     string
+	}{
+		{name: "english", detect: true, text: "hello there", want: "en"},
+		{name: "ukrainian", detect: true, text: "привіт, як справи?", want: "uk"},
+		{name: "ukrainian over the user's language", detect: true, userLang: "en", text: "привіт", want: "uk"},
+		{name: "undetected falls back to the user's language", detect: true, userLang: "uk", text: "👍", want: "uk"},
+		{name: "undetected falls back to the default", detect: true, text: "👍", want: "en"},
+		{name: "detection off", userLang: "en", text: "привіт", want: "en"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := testConfig(t)
+			cfg.DetectLanguage = tt.detect
+			b, _ := newTestBot(t, cfg)
+			if tt.userLang != "" {
+				if err := b.store.Put(collectionPrefs, "U1", userPrefs{Locale: tt.userLang}); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if got := b.messageLocale("U1", tt.text); got != tt.want {
+				t.Errorf("messageLocale = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMentionIsAnsweredInItsLanguage(t *testing.T) {
+	tests := []struct {
+		text string
+		want string
+	}{
+		{"<@U0BOT> hello", "Hello pasha"},
+		{"<@U0BOT> hello, підкажіть будь ласка", "Привіт, pasha"},
+		{"<@U0BOT> де знайти інструкцію?", "Чим я можу допомогти, pasha?"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.text, func(t *testing.T) {
+			cfg := testConfig(t)
+			cfg.DetectLanguage = true
+			b, fake := newTestBot(t, cfg)
+			fake.answer("users.info", `{"ok":true,"user":{"id":"U1","name":"pasha"}}`)
+
+			err := b.handleAppMentionEvent(&slackevents.AppMentionEvent{User: "U1", Channel: "C1", Text: tt.text, TimeStamp: "1712345678.000100"})
+			if err != nil {
+				t.Fatalf("mention failed: %v", err)
+			}
+			calls := fake.calls("chat.postMessage")
+			if len(calls) != 1 {
+				t.Fatalf("got %d posts, want a reply", len(calls))
+			}
+			var attachments []slack.Attachment
+			if err := json.Unmarshal([]byte(calls[0].Form.Get("attachments")), &attachments); err != nil || len(attachments) != 1 {
+				t.Fatalf("posted attachments %s", calls[0].Form.Get("attachments"))
+			}
+			if !strings.HasPrefix(attachments[0].Text, tt.want) {
+				t.Errorf("replied %q, want %q", attachments[0].Text, tt.want)
+			}
+		})
+	}
+}
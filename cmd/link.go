@@ -0,0 +1,106 @@
+/*
+Copyright © 2024 Pavlo Tarasiuk <pasha.tarasyuk@gmail.com>
+*/
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/ptarasyuk/mavbot/internal/blocks"
+	"github.com/skip2/go-qrcode"
+	"github.com/slack-go/slack"
+)
+
+// qrCodeSize is the width and height of generated QR codes in pixels
+const qrCodeSize = 256
+
+// parseLinkURL accepts absolute http and https URLs only, other schemes make poor buttons
+func parseLinkURL(raw string) (*url.URL, error) {
+	u, err := url.Parse(raw)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil, fmt.Errorf("%q isn't an http(s) URL", raw)
+	}
+	return u, nil
+}
+
+// linkCardBlocks builds a card with the title, the link's host and a button opening it
+func linkCardBlocks(u *url.URL, title string) []slack.Block {
+	card := blocks.Section(fmt.Sprintf("*%s*\n%s", title, u.Host))
+	card.Accessory = slack.NewAccessory(blocks.LinkButton("Open", u.String()))
+	return []slack.Block{card}
+}
+
+// uploadQRCode uploads a QR code of the link to the card's thread
+func (b *Bot) uploadQRCode(channelID, threadTS string, u *url.URL) error {
+	png, err := qrcode.Encode(u.String(), qrcode.Medium, qrCodeSize)
+	if err != nil {
+		return fmt.Errorf("failed to generate QR code: %w", err)
+	}
+	_, err = b.api().UploadFileV2(slack.UploadFileV2Parameters{
+		Reader:          bytes.NewReader(png),
+		FileSize:        len(png),
+		Filename:        "qr.png",
+		Title:           "QR code",
+		AltTxt:          "QR code of " + u.String(),
+		Channel:         channelID,
+		ThreadTimestamp: threadTS,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to upload QR code: %w", err)
+	}
+	return nil
+}
+
+// linkFlags are the flags of /link
+var linkFlags = []commandFlag{{Name: "--qr"}}
+
+// handleLink posts a link card: /link [--qr] <url> <title>. With --qr and the link-qr feature on,
+// a QR code of the link follows in the card's thread.
+func (b *Bot) handleLink(command slack.SlashCommand) (*SlashResponse, error) {
+	flags, rest, err := parseFlags(command.Text, linkFlags)
+	args := strings.Fields(rest)
+	if err != nil || len(args) < 2 {
+		return ephemeral(usageOf("/link")), nil
+	}
+	withQR := flags["--qr"]
+	u, err := parseLinkURL(args[0])
+	if err != nil {
+		return ephemeral(err.Error()), nil
+	}
+	if withQR && !b.features.enabled(featureLinkQR) {
+		return ephemeral(fmt.Sprintf("QR codes are switched off, turn on the %s feature to post them", featureLinkQR)), nil
+	}
+
+	title := strings.Join(args[1:], " ")
+	ts, err := b.postMessage(outboundMessage{
+		Channel: command.ChannelID,
+		Invoker: command.UserID,
+		Text:    title + ": " + u.String(),
+		Blocks:  linkCardBlocks(u, title),
+	})
+	if err != nil {
+		return nil, err
+	}
+	// A capturing bot posts nothing to attach the code to
+	if withQR && ts != "" {
+		if err := b.uploadQRCode(command.ChannelID, ts, u); err != nil {
+			return nil, err
+		}
+	}
+	return nil, nil
+}
+
+func init() {
+	registerSlashCommand(&slashCommand{
+		Name:        "/link",
+		Description: "Post a card with a button opening a link",
+		Usage:       "<url> <title>",
+		Flags:       linkFlags,
+		Example:     "/link --qr https://wiki.example.com/onboarding Onboarding guide",
+		Category:    categoryGeneral,
+		Handler:     (*Bot).handleLink,
+	})
+}
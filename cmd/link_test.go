@@ -0,0 +1,153 @@
+/*
+Copyright © 2024 Pavlo Tarasiuk <pasha.tarasyuk@gmail.com>
+*/
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/slack-go/slack"
+)
+
+func TestParseLinkURL(t *testing.T) {
+	tests := []struct {
+		raw     string
+		wantErr bool
+	}{
+		{raw: "https://wiki.example.com/onboarding"},
+		{raw: "http://example.com"},
+		{raw: "ftp://example.com/file", wantErr: true},
+		{raw: "javascript:alert(1)", wantErr: true},
+		{raw: "wiki.example.com/onboarding", wantErr: true},
+		{raw: "https://", wantErr: true},
+	}
+	for _, tt := range tests {
+		if _, err := parseLinkURL(tt.raw); (err != nil) != tt.wantErr {
+			t.Errorf("parseLinkURL(%q) error = %v, want error %t", tt.raw, err, tt.wantErr)
+		}
+	}
+}
+
+func TestLinkCardBlocks(t *testing.T) {
+	u, _ := parseLinkURL("https://wiki.example.com/onboarding")
+	raw, err := json.Marshal(linkCardBlocks(u, "Onboarding guide"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var card []struct {
+		Type string `json:"type"`
+		Text struct {
+			Type string `json:"type"`
+			Text string `json:"text"`
+		} `json:"text"`
+		Accessory struct {
+			Type string `json:"type"`
+			URL  string `json:"url"`
+			Text struct {
+				Text string `json:"text"`
+			} `json:"text"`
+		} `json:"accessory"`
+	}
+	if err := json.Unmarshal(raw, &card); err != nil {
+		t.Fatal(err)
+	}
+	if len(card) != 1 || card[0].Type != "section" {
+		t.Fatalf("card = %s, want a single section", raw)
+	}
+	if card[0].Text.Type != "mrkdwn" || card[0].Text.Text != "*Onboarding guide*\nwiki.example.com" {
+		t.Errorf("card text = %+v, want the title and the host", card[0].Text)
+	}
+	if button := card[0].Accessory; button.Type != "button" || button.URL != u.String() || button.Text.Text != "Open" {
+		t.Errorf("card button = %+v, want Open linking to the URL", button)
+	}
+	if err := validateBlocks(linkCardBlocks(u, "Onboarding guide")); err != nil {
+		t.Errorf("the card is invalid: %v", err)
+	}
+}
+
+// runLink runs /link with the text in C1 and returns the answer, empty when there is none
+func runLink(t *testing.T, b *Bot, text string) string {
+	t.Helper()
+	resp, err := b.handleLink(slack.SlashCommand{Command: "/link", Text: text, UserID: "U1", ChannelID: "C1"})
+	if err != nil {
+		t.Fatalf("/link %s failed: %v", text, err)
+	}
+	if resp == nil {
+		return ""
+	}
+	return resp.Text
+}
+
+func TestLinkPostsCard(t *testing.T) {
+	b, fake := newTestBot(t, nil)
+	if got := runLink(t, b, "https://wiki.example.com/onboarding Onboarding guide"); got != "" {
+		t.Errorf("answered %q, want only the card posted", got)
+	}
+	posts := fake.calls("chat.postMessage")
+	if len(posts) != 1 {
+		t.Fatalf("got %d posts, want the card", len(posts))
+	}
+	if got := posts[0].Form.Get("text"); got != "Onboarding guide: https://wiki.example.com/onboarding" {
+		t.Errorf("card fallback text = %q", got)
+	}
+	if blocks := posts[0].Form.Get("blocks"); !bytes.Contains([]byte(blocks), []byte(`"url":"https://wiki.example.com/onboarding"`)) {
+		t.Errorf("card blocks = %s, want the button", blocks)
+	}
+	if calls := fake.calls("files.getUploadURLExternal"); len(calls) != 0 {
+		t.Errorf("uploaded a QR code without --qr")
+	}
+}
+
+func TestLinkUploadsQRCode(t *testing.T) {
+	b, fake := newTestBot(t, nil)
+	b.features.set(featureLinkQR, true)
+	fake.answer("files.getUploadURLExternal", `{"ok":true,"upload_url":"`+fake.apiURL()+`upload/F1","file_id":"F1"}`)
+	fake.answer("upload/F1", `{"ok":true}`)
+	fake.answer("files.completeUploadExternal", `{"ok":true,"files":[{"id":"F1","title":"QR code"}]}`)
+
+	runLink(t, b, "--qr https://wiki.example.com/onboarding Onboarding guide")
+
+	uploads := fake.calls("upload/F1")
+	if len(uploads) != 1 || !bytes.Contains(uploads[0].Body, []byte("\x89PNG")) {
+		t.Fatalf("uploads = %d, want the PNG of the QR code", len(uploads))
+	}
+	completes := fake.calls("files.completeUploadExternal")
+	if len(completes) != 1 {
+		t.Fatalf("got %d files.completeUploadExternal calls, want 1", len(completes))
+	}
+	// The card was posted as 1712345678.000001, the code goes to its thread
+	form := completes[0].Form
+	if form.Get("channel_id") != "C1" || form.Get("thread_ts") != "1712345678.000001" {
+		t.Errorf("shared to %q in thread %q, want the card's thread", form.Get("channel_id"), form.Get("thread_ts"))
+	}
+}
+
+func TestLinkRefusals(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		want string
+	}{
+		{name: "no title", text: "https://wiki.example.com", want: usageOf("/link")},
+		{name: "nothing", text: "", want: usageOf("/link")},
+		{name: "not http", text: "ftp://example.com Files", want: `"ftp://example.com" isn't an http(s) URL`},
+		{
+			name: "QR switched off",
+			text: "--qr https://wiki.example.com Wiki",
+			want: "QR codes are switched off, turn on the " + featureLinkQR + " feature to post them",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b, fake := newTestBot(t, nil)
+			if got := runLink(t, b, tt.text); got != tt.want {
+				t.Errorf("answered %q, want %q", got, tt.want)
+			}
+			if posts := fake.calls("chat.postMessage"); len(posts) != 0 {
+				t.Errorf("posted a card")
+			}
+		})
+	}
+}
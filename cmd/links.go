@@ -0,0 +1,287 @@
+/*
+Copyright © 2024 Pavlo Tarasiuk <pasha.tarasyuk@gmail.com>
+*/
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"html"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/slack-go/slack"
+)
+
+// Link processors MAVBOT_LINK_PROCESSOR selects
+const (
+	// linkProcessorTitle summarizes links by the title and description of their page
+	linkProcessorTitle = "title"
+	// linkProcessorCheck reports whether links work
+	linkProcessorCheck = "check"
+)
+
+const (
+	// maxProcessedLinks bounds the links processed per message
+	maxProcessedLinks = 3
+	// linkTimeout bounds processing a single link
+	linkTimeout = 5 * time.Second
+	// maxPageHead is how much of a page is read looking for its title
+	maxPageHead = 256 << 10
+	// linkJobQueue bounds the messages waiting for their links to be processed
+	linkJobQueue = 100
+)
+
+// urlPattern matches http(s) URLs, whether Slack formatted them as <url|label> or not
+var urlPattern = regexp.MustCompile(`https?://[^\s<>|]+`)
+
+// extractURLs returns the distinct http(s) URLs in message text, in order of appearance
+func extractURLs(text string) []*url.URL {
+	var urls []*url.URL
+	seen := make(map[string]bool)
+	for _, raw := range urlPattern.FindAllString(text, -1) {
+		// Punctuation ending a sentence isn't part of a URL typed as plain text
+		raw = strings.TrimRight(raw, ".,;:!?)'\"")
+		u, err := parseLinkURL(html.UnescapeString(raw))
+		if err != nil || seen[u.String()] {
+			continue
+		}
+		seen[u.String()] = true
+		urls = append(urls, u)
+	}
+	return urls
+}
+
+// LinkProcessor acts on a link found in a message and returns the line the bot summarizes it with
+type LinkProcessor interface {
+	Process(ctx context.Context, u *url.URL) (string, error)
+}
+
+// newLinkProcessor returns the processor named by MAVBOT_LINK_PROCESSOR, nil when links are left alone
+func newLinkProcessor(name string, client *http.Client) LinkProcessor {
+	switch name {
+	case linkProcessorTitle:
+		return titleFetcher{client: client}
+	case linkProcessorCheck:
+		return linkChecker{client: client}
+	}
+	return nil
+}
+
+// validLinkProcessor reports whether name is a known link processor, empty meaning none
+func validLinkProcessor(name string) bool {
+	switch name {
+	case "", linkProcessorTitle, linkProcessorCheck:
+		return true
+	}
+	return false
+}
+
+// errNonPublicAddress is returned for links to hosts on loopback, private or link-local addresses,
+// which the bot could reach on behalf of whoever posted the link
+var errNonPublicAddress = errors.New("address isn't public")
+
+// sharedAddressSpace is the range carrier-grade NAT uses, private like those net.IP.IsPrivate knows
+var sharedAddressSpace = &net.IPNet{IP: net.IPv4(100, 64, 0, 0), Mask: net.CIDRMask(10, 32)}
+
+// publicOnly is a dialer Control hook refusing connections to addresses that aren't public. It
+// sees the address after name resolution, redirects included, so a public name can't lead elsewhere.
+func publicOnly(network, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	ip := net.ParseIP(host)
+	if ip == nil || ip.IsLoopback() || ip.IsPrivate() || ip.IsUnspecified() || ip.IsLinkLocalUnicast() ||
+		ip.IsLinkLocalMulticast() || ip.IsInterfaceLocalMulticast() || ip.IsMulticast() || sharedAddressSpace.Contains(ip) {
+		return fmt.Errorf("%s: %w", host, errNonPublicAddress)
+	}
+	return nil
+}
+
+// newLinkClient creates the HTTP client links are fetched with, which only connects to public
+// addresses and never through a proxy, the proxy would make the connection instead
+func newLinkClient() *http.Client {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = nil
+	transport.DialContext = (&net.Dialer{Timeout: linkTimeout, Control: publicOnly}).DialContext
+	return &http.Client{Transport: transport}
+}
+
+// pageStatusError is returned for pages answered with an error status
+type pageStatusError struct {
+	Host   string
+	Status string
+}
+
+// Error implements error
+func (e *pageStatusError) Error() string {
+	return fmt.Sprintf("%s answered %s", e.Host, e.Status)
+}
+
+// getPage requests the page at u, failing on error statuses
+func getPage(ctx context.Context, client *http.Client, u *url.URL) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("User-Agent", "MAVBot")
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode >= 400 {
+		resp.Body.Close()
+		return nil, &pageStatusError{Host: u.Host, Status: resp.Status}
+	}
+	return resp, nil
+}
+
+var (
+	titlePattern       = regexp.MustCompile(`(?is)<title[^>]*>(.*?)</title>`)
+	descriptionPattern = regexp.MustCompile(`(?is)<meta\s[^>]*(?:name|property)=["'](?:og:)?description["'][^>]*>`)
+	contentPattern     = regexp.MustCompile(`(?is)\scontent=["']([^"']*)["']`)
+)
+
+// pageSummary finds the title and description in the head of an HTML page
+func pageSummary(page string) (title, description string) {
+	if m := titlePattern.FindStringSubmatch(page); m != nil {
+		title = strings.Join(strings.Fields(html.UnescapeString(m[1])), " ")
+	}
+	if meta := descriptionPattern.FindString(page); meta != "" {
+		if m := contentPattern.FindStringSubmatch(meta); m != nil {
+			description = strings.Join(strings.Fields(html.UnescapeString(m[1])), " ")
+		}
+	}
+	return title, description
+}
+
+// titleFetcher is the default LinkProcessor, it summarizes a link by its page's title and description
+type titleFetcher struct {
+	client *http.Client
+}
+
+// Process implements LinkProcessor
+func (f titleFetcher) Process(ctx context.Context, u *url.URL) (string, error) {
+	resp, err := getPage(ctx, f.client, u)
+	if err != nil {
+		return "", fmt.Errorf("failed to fetch %s: %w", u, err)
+	}
+	defer resp.Body.Close()
+	head, err := io.ReadAll(io.LimitReader(resp.Body, maxPageHead))
+	if err != nil {
+		return "", fmt.Errorf("failed to read %s: %w", u, err)
+	}
+	title, description := pageSummary(string(head))
+	if title == "" {
+		title = u.Host
+	}
+	summary := fmt.Sprintf("<%s|%s>", u, slackEscaper.Replace(title))
+	if description != "" {
+		summary += " — " + slackEscaper.Replace(description)
+	}
+	return summary, nil
+}
+
+// linkChecker is a LinkProcessor reporting whether a link works
+type linkChecker struct {
+	client *http.Client
+}
+
+// Process implements LinkProcessor, a broken link is reported in the summary rather than as an error.
+// Only the status of a page is shown, why it couldn't be reached tells about the bot's network.
+func (c linkChecker) Process(ctx context.Context, u *url.URL) (string, error) {
+	resp, err := getPage(ctx, c.client, u)
+	var status *pageStatusError
+	if errors.As(err, &status) {
+		return fmt.Sprintf(":x: %s is broken, it answered %s", u, status.Status), nil
+	}
+	if err != nil {
+		log.Printf("failed to check %s: %v\n", u, err)
+		return fmt.Sprintf(":x: %s is unreachable", u), nil
+	}
+	resp.Body.Close()
+	return fmt.Sprintf(":white_check_mark: %s works", u), nil
+}
+
+// slackEscaper escapes the characters Slack treats as markup in message text
+var slackEscaper = strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;")
+
+// linkJob is a message whose links are waiting to be processed
+type linkJob struct {
+	channelID, userID, threadTS, text string
+}
+
+// processLinks queues the links of a message for runLinks, fetching them would hold up the
+// events behind it. Links are a courtesy, so they are skipped when too many are waiting.
+func (b *Bot) processLinks(channelID, userID, threadTS, text string) {
+	if b.links == nil || len(extractURLs(text)) == 0 {
+		return
+	}
+	select {
+	case b.linkJobs <- linkJob{channelID: channelID, userID: userID, threadTS: threadTS, text: text}:
+	default:
+		log.Printf("too many links waiting, skipped the links of a message in %s\n", channelID)
+	}
+}
+
+// runLinks processes the queued links until ctx is cancelled
+func (b *Bot) runLinks(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case job := <-b.linkJobs:
+			b.summarizeLinks(job.channelID, job.userID, job.threadTS, job.text)
+		}
+	}
+}
+
+// summarizeLinks runs the link processor on the links of a message and posts their summary in
+// its thread. Failures are only logged.
+func (b *Bot) summarizeLinks(channelID, userID, threadTS, text string) {
+	urls := extractURLs(text)
+	if len(urls) > maxProcessedLinks {
+		urls = urls[:maxProcessedLinks]
+	}
+	var lines []string
+	for _, u := range urls {
+		ctx, cancel := context.WithTimeout(context.Background(), linkTimeout)
+		line, err := b.links.Process(ctx, u)
+		cancel()
+		if err != nil {
+			log.Printf("failed to process link: %v\n", err)
+			continue
+		}
+		lines = append(lines, line)
+	}
+	if len(lines) == 0 {
+		return
+	}
+	_, err := b.postMessage(outboundMessage{
+		Channel:  channelID,
+		Invoker:  userID,
+		Priority: priorityLow,
+		Text:     strings.Join(lines, "\n"),
+		Options:  []slack.MsgOption{slack.MsgOptionTS(threadTS), slack.MsgOptionDisableLinkUnfurl()},
+	})
+	if err != nil {
+		log.Printf("failed to post link summary to %s: %v\n", channelID, err)
+	}
+}
+
+// threadOf returns the thread a message belongs to, a message outside of threads starts its own
+func threadOf(threadTS, ts string) string {
+	if threadTS != "" {
+		return threadTS
+	}
+	return ts
+}
@@ -0,0 +1,279 @@
+/*
+Copyright © 2024 Pavlo Tarasiuk <pasha.tarasyuk@gmail.com>
+*/
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestExtractURLs(t *testing.T) {
+	tests := []struct {
+		text string
+		want []string
+	}{
+		{text: "no links here"},
+		{text: "see https://example.com/docs", want: []string{"https://example.com/docs"}},
+		{text: "see <https://example.com/docs|the docs> and <http://status.example.com>", want: []string{"https://example.com/docs", "http://status.example.com"}},
+		{text: "Is https://example.com/a, or https://example.com/b. down?", want: []string{"https://example.com/a", "https://example.com/b"}},
+		{text: "(https://example.com/wiki)", want: []string{"https://example.com/wiki"}},
+		{text: "https://example.com/?a=1&amp;b=2", want: []string{"https://example.com/?a=1&b=2"}},
+		{text: "https://example.com twice https://example.com", want: []string{"https://example.com"}},
+		{text: "ftp://example.com/file and mailto:ops@example.com", want: nil},
+	}
+	for _, tt := range tests {
+		var got []string
+		for _, u := range extractURLs(tt.text) {
+			got = append(got, u.String())
+		}
+		if strings.Join(got, " ") != strings.Join(tt.want, " ") {
+			t.Errorf("extractURLs(%q) = %q, want %q", tt.text, got, tt.want)
+		}
+	}
+}
+
+func TestNewLinkProcessor(t *testing.T) {
+	if _, ok := newLinkProcessor(linkProcessorTitle, http.DefaultClient).(titleFetcher); !ok {
+		t.Errorf("%q didn't select the title fetcher", linkProcessorTitle)
+	}
+	if _, ok := newLinkProcessor(linkProcessorCheck, http.DefaultClient).(linkChecker); !ok {
+		t.Errorf("%q didn't select the link checker", linkProcessorCheck)
+	}
+	if p := newLinkProcessor("", http.DefaultClient); p != nil {
+		t.Errorf("links are processed by %T when none is configured", p)
+	}
+	for name, want := range map[string]bool{"": true, "title": true, "check": true, "unfurl": false} {
+		if got := validLinkProcessor(name); got != want {
+			t.Errorf("validLinkProcessor(%q) = %t, want %t", name, got, want)
+		}
+	}
+}
+
+func TestPublicOnly(t *testing.T) {
+	tests := []struct {
+		address string
+		public  bool
+	}{
+		{address: "93.184.216.34:443", public: true},
+		{address: "[2606:2800:220:1:248:1893:25c8:1946]:443", public: true},
+		{address: "127.0.0.1:80"},
+		{address: "[::1]:80"},
+		{address: "169.254.169.254:80"},
+		{address: "[fe80::1]:80"},
+		{address: "10.0.0.5:8080"},
+		{address: "192.168.1.1:80"},
+		{address: "172.16.0.1:80"},
+		{address: "100.64.0.1:80"},
+		{address: "0.0.0.0:80"},
+		{address: "224.0.0.1:80"},
+	}
+	for _, tt := range tests {
+		err := publicOnly("tcp", tt.address, nil)
+		if tt.public && err != nil {
+			t.Errorf("publicOnly(%s) error = %v, want the connection allowed", tt.address, err)
+		}
+		if !tt.public && !errors.Is(err, errNonPublicAddress) {
+			t.Errorf("publicOnly(%s) error = %v, want the connection refused", tt.address, err)
+		}
+	}
+}
+
+func TestLinkClientRefusesLoopback(t *testing.T) {
+	fetched := false
+	page := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fetched = true
+	}))
+	defer page.Close()
+	u, _ := url.Parse(page.URL)
+	_, err := titleFetcher{client: newLinkClient()}.Process(context.Background(), u)
+	if !errors.Is(err, errNonPublicAddress) {
+		t.Errorf("Process() error = %v, want the loopback address refused", err)
+	}
+	if fetched {
+		t.Errorf("the page on the loopback address was fetched")
+	}
+}
+
+// newPageServer serves the pages by path, any other path is not found
+func newPageServer(t *testing.T, pages map[string]string) *url.URL {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		page, ok := pages[r.URL.Path]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", "text/html")
+		fmt.Fprint(w, page)
+	}))
+	t.Cleanup(srv.Close)
+	u, _ := url.Parse(srv.URL)
+	return u
+}
+
+func TestTitleFetcher(t *testing.T) {
+	base := newPageServer(t, map[string]string{
+		"/docs": `<html><head><title>
+			MAVBot   docs</title>
+			<meta name="description" content="How to run &amp; configure MAVBot"></head></html>`,
+		"/og":       `<head><meta property="og:description" content='Status of &lt;services&gt;'><TITLE>Status</TITLE></head>`,
+		"/untitled": `<html><body>Nothing here</body></html>`,
+	})
+	tests := []struct {
+		path    string
+		want    string
+		wantErr bool
+	}{
+		{path: "/docs", want: "<%s/docs|MAVBot docs> — How to run &amp; configure MAVBot"},
+		{path: "/og", want: "<%s/og|Status> — Status of &lt;services&gt;"},
+		{path: "/untitled", want: "<%s/untitled|" + base.Host + ">"},
+		{path: "/missing", wantErr: true},
+	}
+	fetcher := titleFetcher{client: http.DefaultClient}
+	for _, tt := range tests {
+		u := base.JoinPath(tt.path)
+		got, err := fetcher.Process(context.Background(), u)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("Process(%s) error = %v, want error %t", tt.path, err, tt.wantErr)
+			continue
+		}
+		if want := fmt.Sprintf(tt.want, base); !tt.wantErr && got != want {
+			t.Errorf("Process(%s) = %q, want %q", tt.path, got, want)
+		}
+	}
+}
+
+func TestLinkChecker(t *testing.T) {
+	captureLog(t)
+	base := newPageServer(t, map[string]string{"/ok": "fine"})
+	unreachable, _ := url.Parse("http://127.0.0.1:1/down")
+	tests := []struct {
+		u    *url.URL
+		want string
+	}{
+		{u: base.JoinPath("/ok"), want: fmt.Sprintf(":white_check_mark: %s/ok works", base)},
+		{u: base.JoinPath("/gone"), want: fmt.Sprintf(":x: %s/gone is broken, it answered 404 Not Found", base)},
+		{u: unreachable, want: ":x: http://127.0.0.1:1/down is unreachable"},
+	}
+	checker := linkChecker{client: http.DefaultClient}
+	for _, tt := range tests {
+		got, err := checker.Process(context.Background(), tt.u)
+		if err != nil || got != tt.want {
+			t.Errorf("Process(%s) = %q, %v, want %q", tt.u, got, err, tt.want)
+		}
+	}
+}
+
+func TestLinkSummaryPostedInThread(t *testing.T) {
+	b, fake := newTestBot(t, nil)
+	base := newPageServer(t, map[string]string{"/a": "<title>A</title>", "/b": "<title>B</title>"})
+	b.links = titleFetcher{client: http.DefaultClient}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go b.runLinks(ctx)
+
+	text := fmt.Sprintf("see <%s/a> and %s/b", base, base)
+	b.processLinks("C1", "U1", "1712345678.000100", text)
+	call := fake.waitCalls(t, "chat.postMessage", 1)[0]
+	if want := fmt.Sprintf("<%s/a|A>\n<%s/b|B>", base, base); call.Form.Get("text") != want {
+		t.Errorf("posted %q, want %q", call.Form.Get("text"), want)
+	}
+	if got := call.Form.Get("thread_ts"); got != "1712345678.000100" {
+		t.Errorf("posted in thread %q, want the message's", got)
+	}
+	if got := call.Form.Get("unfurl_links"); got != "false" {
+		t.Errorf("unfurl_links = %q, want the summary not unfurled", got)
+	}
+}
+
+// blockingLinks is a LinkProcessor that holds every link until it is released
+type blockingLinks struct {
+	started, release chan struct{}
+}
+
+// Process implements LinkProcessor
+func (l blockingLinks) Process(_ context.Context, u *url.URL) (string, error) {
+	select {
+	case l.started <- struct{}{}:
+	default:
+	}
+	<-l.release
+	return u.String(), nil
+}
+
+func TestLinksAreProcessedOffTheEventLoop(t *testing.T) {
+	logs := captureLog(t)
+	b, fake := newTestBot(t, nil)
+	links := blockingLinks{started: make(chan struct{}, 1), release: make(chan struct{})}
+	b.links = links
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go b.runLinks(ctx)
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		b.processLinks("C1", "U1", "1712345678.000100", "see https://example.com")
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatalf("processLinks() waited for the link to be processed")
+	}
+	<-links.started
+
+	// Links of messages that find the queue full are skipped
+	for i := 0; i < linkJobQueue; i++ {
+		b.processLinks("C1", "U1", "1712345678.000100", "see https://example.com")
+	}
+	b.processLinks("C2", "U1", "1712345678.000100", "see https://example.com")
+	if !strings.Contains(logs.String(), "too many links waiting, skipped the links of a message in C2") {
+		t.Errorf("logs = %q, want the skipped message logged", logs.String())
+	}
+	b.processLinks("C1", "U1", "1712345678.000100", "no links")
+
+	close(links.release)
+	fake.waitCalls(t, "chat.postMessage", linkJobQueue+1)
+}
+
+func TestLinkNetworkErrorsAreNotPosted(t *testing.T) {
+	logs := captureLog(t)
+	b, fake := newTestBot(t, nil)
+	page := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer page.Close()
+	b.links = titleFetcher{client: newLinkClient()}
+
+	b.summarizeLinks("C1", "U1", "1712345678.000100", "see "+page.URL+"/internal")
+	if posts := fake.posts(); len(posts) != 0 {
+		t.Errorf("posted %q, want the failure kept out of the channel", posts)
+	}
+	if !strings.Contains(logs.String(), "failed to process link: ") || !strings.Contains(logs.String(), "address isn't public") {
+		t.Errorf("logs = %q, want the failure logged", logs.String())
+	}
+}
+
+func TestLinksAreBounded(t *testing.T) {
+	b, fake := newTestBot(t, nil)
+	base := newPageServer(t, map[string]string{})
+	b.links = linkChecker{client: http.DefaultClient}
+	var text []string
+	for i := 1; i <= maxProcessedLinks+2; i++ {
+		text = append(text, fmt.Sprintf("%s/%d", base, i))
+	}
+	b.summarizeLinks("C1", "U1", "1712345678.000100", strings.Join(text, " "))
+	posts := fake.posts()
+	if len(posts) != 1 {
+		t.Fatalf("posted %d messages, want one summary", len(posts))
+	}
+	if got := strings.Count(posts[0], "\n") + 1; got != maxProcessedLinks {
+		t.Errorf("summarized %d links, want at most %d", got, maxProcessedLinks)
+	}
+}
@@ -0,0 +1,92 @@
+/*
+Copyright © 2024 Pavlo Tarasiuk <pasha.tarasyuk@gmail.com>
+*/
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"log"
+
+	"github.com/ptarasyuk/mavbot/internal/blocks"
+	"github.com/slack-go/slack"
+)
+
+// loadingResponse acknowledges a command whose answer takes a while with a context block saying so,
+// only the invoking user sees it
+func loadingResponse(text string) *SlashResponse {
+	return &SlashResponse{
+		ResponseType: slack.ResponseTypeEphemeral,
+		Text:         text,
+		Blocks: []slack.Block{
+			blocks.Context(":hourglass_flowing_sand: " + text),
+		},
+	}
+}
+
+// webhookMessage converts the response into a message for the command's response_url that
+// replaces the message the command was acknowledged with
+func (r *SlashResponse) webhookMessage() *slack.WebhookMessage {
+	msg := &slack.WebhookMessage{
+		ResponseType:    r.ResponseType,
+		Text:            r.Text,
+		Attachments:     r.Attachments,
+		ReplaceOriginal: true,
+	}
+	if len(r.Blocks) > 0 {
+		msg.Blocks = &slack.Blocks{BlockSet: r.Blocks}
+	}
+	return msg
+}
+
+// respondLater acknowledges the command with a loading message and runs work in the background,
+// replacing the loading message with whatever work responds. A failure replaces it with an error note.
+func (b *Bot) respondLater(command slack.SlashCommand, loading string, work func() (*SlashResponse, error)) *SlashResponse {
+	receivedAt := b.now()
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		var response *SlashResponse
+		err := b.runHandler(command.Command, func() (err error) {
+			response, err = work()
+			return err
+		})
+		if err != nil {
+			log.Printf("%s failed: %v\n", command.Command, err)
+			guided, ok := b.guidedErrorResponse(command, err)
+			if !ok {
+				guided = ephemeral("Sorry, something went wrong, please try again later")
+			}
+			response = guided
+		}
+		if response == nil {
+			// Nothing to show, so the loading message goes away
+			if err := slack.PostWebhookCustomHTTP(command.ResponseURL, b.httpClient, &slack.WebhookMessage{DeleteOriginal: true}); err != nil {
+				log.Printf("failed to remove the loading message of %s: %v\n", command.Command, err)
+			}
+			return
+		}
+		response = b.addResponseTimeToResponse(formatResponse(command.Command, b.sendPosts(command.UserID, response)), receivedAt)
+		if err := b.replaceResponse(command, response); err != nil {
+			log.Println(err)
+		}
+	}()
+	response := loadingResponse(loading)
+	response.done = done
+	return response
+}
+
+// replaceResponse replaces the message the command was acknowledged with by response
+func (b *Bot) replaceResponse(command slack.SlashCommand, response *SlashResponse) error {
+	msg := response.webhookMessage()
+	// The response is an outbound message too, so it is subject to the broadcast policy
+	if err := b.filterBroadcast(msg, command.UserID); errors.Is(err, errBroadcastBlocked) {
+		msg = ephemeral("Sorry, I can't send a message that mentions the whole channel").webhookMessage()
+	} else if err != nil {
+		return err
+	}
+	if err := slack.PostWebhookCustomHTTP(command.ResponseURL, b.httpClient, msg); err != nil {
+		return fmt.Errorf("failed to respond to %s: %w", command.Command, err)
+	}
+	return nil
+}
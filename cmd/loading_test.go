@@ -0,0 +1,122 @@
+/*
+Copyright © 2024 Pavlo Tarasiuk <pasha.tarasyuk@gmail.com>
+*/
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/slack-go/slack"
+)
+
+// responsePayload is what the tests read of an acknowledgement or a response_url message
+type responsePayload struct {
+	ResponseType    string `json:"response_type"`
+	Text            string `json:"text"`
+	ReplaceOriginal bool   `json:"replace_original"`
+	DeleteOriginal  bool   `json:"delete_original"`
+	Blocks          []struct {
+		Type     string `json:"type"`
+		Elements []struct {
+			Text string `json:"text"`
+		} `json:"elements"`
+	} `json:"blocks"`
+}
+
+// runLater registers /test-later responding later with work, runs it and returns the
+// acknowledgement and the message that replaced it through the response_url
+func runLater(t *testing.T, work func() (*SlashResponse, error)) (ack, final responsePayload) {
+	t.Helper()
+	registerTestCommand(t, &slashCommand{
+		Name: "/test-later",
+		Handler: func(b *Bot, command slack.SlashCommand) (*SlashResponse, error) {
+			return b.respondLater(command, "Working on it…", work), nil
+		},
+	})
+	b, fake := newTestBot(t, nil)
+	payload, err := b.handleSlashCommand(slack.SlashCommand{
+		Command:     "/test-later",
+		UserID:      "U1",
+		ChannelID:   "C1",
+		ResponseURL: fake.apiURL() + "respond",
+	})
+	if err != nil {
+		t.Fatalf("command failed: %v", err)
+	}
+	if err := json.Unmarshal(payload.(json.RawMessage), &ack); err != nil {
+		t.Fatalf("invalid acknowledgement %s", payload)
+	}
+	responses := fake.waitCalls(t, "respond", 1)
+	if err := json.Unmarshal(responses[0].Body, &final); err != nil {
+		t.Fatalf("invalid response %s", responses[0].Body)
+	}
+	return ack, final
+}
+
+func TestLoadingThenFinalResponse(t *testing.T) {
+	ack, final := runLater(t, func() (*SlashResponse, error) {
+		return ephemeral("All done"), nil
+	})
+
+	if ack.ResponseType != slack.ResponseTypeEphemeral || ack.Text != "Working on it…" {
+		t.Errorf("acknowledged with %+v, want the ephemeral loading text", ack)
+	}
+	if len(ack.Blocks) != 1 || ack.Blocks[0].Type != "context" || len(ack.Blocks[0].Elements) != 1 ||
+		ack.Blocks[0].Elements[0].Text != ":hourglass_flowing_sand: Working on it…" {
+		t.Errorf("acknowledged with blocks %+v, want the loading context block", ack.Blocks)
+	}
+
+	if !final.ReplaceOriginal || final.Text != "All done" {
+		t.Errorf("responded with %+v, want All done replacing the loading message", final)
+	}
+	if len(final.Blocks) != 0 {
+		t.Errorf("the final response kept blocks %+v", final.Blocks)
+	}
+}
+
+func TestLoadingReplacedByTheError(t *testing.T) {
+	captureLog(t)
+	_, final := runLater(t, func() (*SlashResponse, error) {
+		return nil, errors.New("boom")
+	})
+	if !final.ReplaceOriginal || final.Text != "Sorry, something went wrong, please try again later" {
+		t.Errorf("responded with %+v, want the error note replacing the loading message", final)
+	}
+}
+
+func TestLoadingRemovedWithoutResponse(t *testing.T) {
+	_, final := runLater(t, func() (*SlashResponse, error) {
+		return nil, nil
+	})
+	if !final.DeleteOriginal {
+		t.Errorf("responded with %+v, want the loading message deleted", final)
+	}
+}
+
+func TestPrivateReportLoadsFirst(t *testing.T) {
+	b, fake := newTestBot(t, nil)
+	fake.answer("conversations.open", `{"ok":true,"channel":{"id":"D0DM"}}`)
+
+	payload, err := b.handleSlashCommand(slack.SlashCommand{
+		Command:     "/private-report",
+		UserID:      "U1",
+		ChannelID:   "C1",
+		ResponseURL: fake.apiURL() + "respond",
+	})
+	if err != nil {
+		t.Fatalf("/private-report failed: %v", err)
+	}
+	var ack, final responsePayload
+	if err := json.Unmarshal(payload.(json.RawMessage), &ack); err != nil || ack.Text != "Compiling your report…" {
+		t.Errorf("acknowledged with %s, want the loading message", payload)
+	}
+	responses := fake.waitCalls(t, "respond", 1)
+	if err := json.Unmarshal(responses[0].Body, &final); err != nil {
+		t.Fatalf("invalid response %s", responses[0].Body)
+	}
+	if !final.ReplaceOriginal || final.Text != "I sent you a DM with your report" {
+		t.Errorf("responded with %+v, want the confirmation replacing the loading message", final)
+	}
+}
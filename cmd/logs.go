@@ -0,0 +1,192 @@
+/*
+Copyright © 2024 Pavlo Tarasiuk <pasha.tarasyuk@gmail.com>
+*/
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"log/slog"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/slack-go/slack"
+)
+
+// maxLogLines bounds the lines /logs shows at once
+const maxLogLines = 100
+
+// slackTokenPattern matches Slack tokens, which are redacted from the lines /logs shows
+var slackTokenPattern = regexp.MustCompile(`xox[a-z]-[A-Za-z0-9-]+|xapp-[A-Za-z0-9-]+`)
+
+// logRing is a slog.Handler keeping the latest log lines in memory for /logs and passing every
+// record on to next
+type logRing struct {
+	next slog.Handler
+
+	// buffer is shared by the handlers WithAttrs and WithGroup derive
+	buffer *lineBuffer
+}
+
+// lineBuffer is a ring of log lines
+type lineBuffer struct {
+	mu    sync.Mutex
+	lines []string
+	// start is the index of the oldest line once the ring is full
+	start int
+	size  int
+}
+
+// newLogRing creates a handler remembering the last size lines
+func newLogRing(size int, next slog.Handler) *logRing {
+	return &logRing{next: next, buffer: &lineBuffer{size: size}}
+}
+
+// Enabled implements slog.Handler
+func (r *logRing) Enabled(ctx context.Context, level slog.Level) bool {
+	return r.next.Enabled(ctx, level)
+}
+
+// Handle implements slog.Handler
+func (r *logRing) Handle(ctx context.Context, record slog.Record) error {
+	var line strings.Builder
+	line.WriteString(record.Time.Format("2006/01/02 15:04:05 "))
+	line.WriteString(strings.TrimSuffix(record.Message, "\n"))
+	record.Attrs(func(attr slog.Attr) bool {
+		fmt.Fprintf(&line, " %s", attr)
+		return true
+	})
+	r.buffer.add(line.String())
+	return r.next.Handle(ctx, record)
+}
+
+// WithAttrs implements slog.Handler
+func (r *logRing) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &logRing{next: r.next.WithAttrs(attrs), buffer: r.buffer}
+}
+
+// WithGroup implements slog.Handler
+func (r *logRing) WithGroup(name string) slog.Handler {
+	return &logRing{next: r.next.WithGroup(name), buffer: r.buffer}
+}
+
+// add appends the line, overwriting the oldest one when the ring is full
+func (l *lineBuffer) add(line string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if len(l.lines) < l.size {
+		l.lines = append(l.lines, line)
+		return
+	}
+	l.lines[l.start] = line
+	l.start = (l.start + 1) % l.size
+}
+
+// last returns the latest n lines, oldest first
+func (r *logRing) last(n int) []string {
+	l := r.buffer
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	ordered := append(append([]string{}, l.lines[l.start:]...), l.lines[:l.start]...)
+	if n < len(ordered) {
+		ordered = ordered[len(ordered)-n:]
+	}
+	return ordered
+}
+
+// stdLogHandler writes records the way the log package does, so capturing the lines leaves the
+// bot's output as it was
+type stdLogHandler struct {
+	logger *log.Logger
+	attrs  []slog.Attr
+}
+
+// Enabled implements slog.Handler
+func (h *stdLogHandler) Enabled(context.Context, slog.Level) bool {
+	return true
+}
+
+// Handle implements slog.Handler
+func (h *stdLogHandler) Handle(_ context.Context, record slog.Record) error {
+	var line strings.Builder
+	line.WriteString(record.Message)
+	for _, attr := range h.attrs {
+		fmt.Fprintf(&line, " %s", attr)
+	}
+	record.Attrs(func(attr slog.Attr) bool {
+		fmt.Fprintf(&line, " %s", attr)
+		return true
+	})
+	return h.logger.Output(0, line.String())
+}
+
+// WithAttrs implements slog.Handler
+func (h *stdLogHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &stdLogHandler{logger: h.logger, attrs: append(append([]slog.Attr{}, h.attrs...), attrs...)}
+}
+
+// WithGroup implements slog.Handler, groups aren't used by the bot so they are flattened
+func (h *stdLogHandler) WithGroup(string) slog.Handler {
+	return h
+}
+
+// captureLogs routes the log package and slog through a logRing of the given size and returns it.
+// The lines are still written to the log's output as before.
+func captureLogs(size int) *logRing {
+	next := &stdLogHandler{logger: log.New(log.Writer(), log.Prefix(), log.Flags())}
+	ring := newLogRing(size, next)
+	slog.SetDefault(slog.New(ring))
+	return ring
+}
+
+// redactLine hides Slack tokens and the configured secrets in a log line
+func (b *Bot) redactLine(line string) string {
+	line = slackTokenPattern.ReplaceAllString(line, "[redacted]")
+	for _, secret := range b.cfg.secretFields() {
+		if *secret != "" {
+			line = strings.ReplaceAll(line, *secret, "[redacted]")
+		}
+	}
+	return line
+}
+
+// handleLogs shows the latest log lines, secrets redacted: /logs [n]
+func (b *Bot) handleLogs(command slack.SlashCommand) (*SlashResponse, error) {
+	if b.logs == nil {
+		return ephemeral("Log capture is off, set MAVBOT_LOG_BUFFER to turn it on"), nil
+	}
+	n := 20
+	if arg := strings.TrimSpace(command.Text); arg != "" {
+		var err error
+		if n, err = strconv.Atoi(arg); err != nil || n < 1 {
+			return ephemeral(fmt.Sprintf("%s, n up to %d", usageOf("/logs"), maxLogLines)), nil
+		}
+	}
+	if n > maxLogLines {
+		n = maxLogLines
+	}
+
+	lines := b.logs.last(n)
+	if len(lines) == 0 {
+		return ephemeral("Nothing has been logged yet"), nil
+	}
+	for i, line := range lines {
+		lines[i] = b.redactLine(line)
+	}
+	return ephemeral("```\n" + strings.Join(lines, "\n") + "\n```"), nil
+}
+
+func init() {
+	registerSlashCommand(&slashCommand{
+		Name:        "/logs",
+		Description: "Show the latest lines MAVBot logged, secrets redacted",
+		Usage:       "[n]",
+		Example:     "/logs 50",
+		Category:    categoryAdmin,
+		AdminOnly:   true,
+		Handler:     (*Bot).handleLogs,
+	})
+}
@@ -0,0 +1,101 @@
+/*
+Copyright © 2024 Pavlo Tarasiuk <pasha.tarasyuk@gmail.com>
+*/
+package cmd
+
+import (
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+
+	"github.com/slack-go/slack"
+	"github.com/slack-go/slack/slackevents"
+)
+
+// settingMaintenance is where the maintenance mode is kept in collectionSettings
+const settingMaintenance = "maintenance"
+
+// maintenanceMode freezes the bot, e.g. during deploys: commands and mentions are answered with
+// MaintenanceMessage instead of being processed, admin commands still work
+type maintenanceMode struct {
+	mu sync.RWMutex
+	on bool
+}
+
+// enabled reports whether the bot is under maintenance
+func (m *maintenanceMode) enabled() bool {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.on
+}
+
+// set puts the bot under maintenance or takes it out
+func (m *maintenanceMode) set(on bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.on = on
+}
+
+// loadMaintenanceMode restores the maintenance mode as it was left
+func loadMaintenanceMode(store Store) (*maintenanceMode, error) {
+	m := &maintenanceMode{}
+	if _, err := store.Get(collectionSettings, settingMaintenance, &m.on); err != nil {
+		return nil, fmt.Errorf("failed to load the maintenance mode: %w", err)
+	}
+	return m, nil
+}
+
+// saveMaintenanceMode persists the maintenance mode so a restart during a deploy keeps the bot frozen
+func (b *Bot) saveMaintenanceMode() error {
+	if err := b.store.Put(collectionSettings, settingMaintenance, b.maintenance.enabled()); err != nil {
+		return fmt.Errorf("failed to save the maintenance mode: %w", err)
+	}
+	return nil
+}
+
+// answerMaintenance tells the user who mentioned the bot that it is under maintenance
+func (b *Bot) answerMaintenance(event *slackevents.AppMentionEvent) error {
+	_, err := b.postMessage(outboundMessage{
+		Channel:     event.Channel,
+		Invoker:     event.User,
+		EphemeralTo: event.User,
+		Text:        b.cfg.MaintenanceMessage,
+	})
+	return err
+}
+
+// handleMaintenance shows or toggles the maintenance mode: /maintenance [on|off]
+func (b *Bot) handleMaintenance(command slack.SlashCommand) (*SlashResponse, error) {
+	switch arg := strings.TrimSpace(command.Text); arg {
+	case "":
+		if b.maintenance.enabled() {
+			return ephemeral("MAVBot is under maintenance, only admin commands are processed"), nil
+		}
+		return ephemeral("MAVBot is not under maintenance"), nil
+
+	case "on", "off":
+		b.maintenance.set(arg == "on")
+		if err := b.saveMaintenanceMode(); err != nil {
+			return nil, err
+		}
+		log.Printf("Maintenance mode turned %s by %s\n", arg, command.UserID)
+		if arg == "on" {
+			return ephemeral("MAVBot is now under maintenance, users get: " + b.cfg.MaintenanceMessage), nil
+		}
+		return ephemeral("MAVBot is back from maintenance"), nil
+	}
+	return ephemeral(usageOf("/maintenance")), nil
+}
+
+func init() {
+	registerSlashCommand(&slashCommand{
+		Name:        "/maintenance",
+		Description: "Freeze the bot for everything but admin commands, e.g. during a deploy",
+		Usage:       "[on|off]",
+		Example:     "/maintenance on",
+		Category:    categoryAdmin,
+		AdminOnly:   true,
+		Handler:     (*Bot).handleMaintenance,
+	})
+}
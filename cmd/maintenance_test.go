@@ -0,0 +1,139 @@
+/*
+Copyright © 2024 Pavlo Tarasiuk <pasha.tarasyuk@gmail.com>
+*/
+package cmd
+
+import (
+	"testing"
+
+	"github.com/slack-go/slack"
+	"github.com/slack-go/slack/slackevents"
+)
+
+// newMaintenanceBot creates a bot with U0ADMIN as admin and /test-normal and /test-admin
+// registered, returning how often each ran
+func newMaintenanceBot(t *testing.T) (*Bot, *fakeSlack, map[string]int) {
+	t.Helper()
+	runs := map[string]int{}
+	for _, adminOnly := range []bool{false, true} {
+		name := "/test-normal"
+		if adminOnly {
+			name = "/test-admin"
+		}
+		registerTestCommand(t, &slashCommand{
+			Name:      name,
+			AdminOnly: adminOnly,
+			Handler: func(_ *Bot, command slack.SlashCommand) (*SlashResponse, error) {
+				runs[command.Command]++
+				return ephemeral("Done"), nil
+			},
+		})
+	}
+	cfg := testConfig(t)
+	cfg.Admins = []string{"U0ADMIN"}
+	cfg.MaintenanceMessage = "Deploying, back in 5 minutes"
+	b, fake := newTestBot(t, cfg)
+	return b, fake, runs
+}
+
+// runMaintenance runs /maintenance as the admin and returns the answer
+func runMaintenance(t *testing.T, b *Bot, text string) string {
+	t.Helper()
+	resp, err := b.dispatchSlashCommand(slack.SlashCommand{Command: "/maintenance", Text: text, UserID: "U0ADMIN", ChannelID: "C1"})
+	if err != nil {
+		t.Fatalf("/maintenance %s failed: %v", text, err)
+	}
+	return resp.Text
+}
+
+func TestMaintenanceShortCircuitsHandlers(t *testing.T) {
+	captureLog(t)
+	b, fake, runs := newMaintenanceBot(t)
+	fake.answer("users.info", `{"ok":true,"user":{"id":"U1","name":"pasha"}}`)
+	if got, want := runMaintenance(t, b, "on"), "MAVBot is now under maintenance, users get: Deploying, back in 5 minutes"; got != want {
+		t.Errorf("answered %q, want %q", got, want)
+	}
+
+	tests := []struct {
+		command string
+		user    string
+		want    string
+		// ran is whether the handler runs
+		ran bool
+	}{
+		{command: "/test-normal", user: "U1", want: "Deploying, back in 5 minutes"},
+		{command: "/test-normal", user: "U0ADMIN", want: "Deploying, back in 5 minutes"},
+		{command: "/test-admin", user: "U0ADMIN", want: "Done", ran: true},
+		{command: "/test-admin", user: "U1", want: "Sorry, this command is available to MAVBot admins only"},
+	}
+	for _, tt := range tests {
+		before := runs[tt.command]
+		resp, err := b.dispatchSlashCommand(slack.SlashCommand{Command: tt.command, UserID: tt.user, ChannelID: "C1"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if resp.Text != tt.want {
+			t.Errorf("%s by %s answered %q, want %q", tt.command, tt.user, resp.Text, tt.want)
+		}
+		if ran := runs[tt.command] > before; ran != tt.ran {
+			t.Errorf("%s by %s ran the handler: %t, want %t", tt.command, tt.user, ran, tt.ran)
+		}
+	}
+
+	// Mentions get the message privately instead of the greeting
+	if err := b.handleAppMentionEvent(&slackevents.AppMentionEvent{User: "U1", Channel: "C1", Text: "<@U0BOT> hello", TimeStamp: "1712345678.000100"}); err != nil {
+		t.Fatal(err)
+	}
+	if got := len(fake.calls("chat.postMessage")); got != 0 {
+		t.Errorf("posted %d greetings under maintenance", got)
+	}
+	ephemerals := fake.calls("chat.postEphemeral")
+	if len(ephemerals) != 1 || ephemerals[0].Form.Get("text") != "Deploying, back in 5 minutes" || ephemerals[0].Form.Get("user") != "U1" {
+		t.Errorf("answered the mention with %d ephemeral messages, want the maintenance message to U1", len(ephemerals))
+	}
+
+	if got := runMaintenance(t, b, "off"); got != "MAVBot is back from maintenance" {
+		t.Errorf("answered %q", got)
+	}
+	resp, err := b.dispatchSlashCommand(slack.SlashCommand{Command: "/test-normal", UserID: "U1", ChannelID: "C1"})
+	if err != nil || resp.Text != "Done" {
+		t.Errorf("answered %q, %v after maintenance, want the handler run", resp.Text, err)
+	}
+}
+
+func TestMaintenanceModePersists(t *testing.T) {
+	captureLog(t)
+	b, _, _ := newMaintenanceBot(t)
+	if got := runMaintenance(t, b, ""); got != "MAVBot is not under maintenance" {
+		t.Errorf("answered %q", got)
+	}
+	runMaintenance(t, b, "on")
+	if got := runMaintenance(t, b, ""); got != "MAVBot is under maintenance, only admin commands are processed" {
+		t.Errorf("answered %q", got)
+	}
+
+	// A restart keeps the bot frozen
+	restored, err := loadMaintenanceMode(b.store)
+	if err != nil || !restored.enabled() {
+		t.Errorf("loadMaintenanceMode() = %v, %v, want it on", restored, err)
+	}
+	runMaintenance(t, b, "off")
+	if restored, err = loadMaintenanceMode(b.store); err != nil || restored.enabled() {
+		t.Errorf("loadMaintenanceMode() = %v, %v, want it off", restored, err)
+	}
+	if restored, err = loadMaintenanceMode(newMemStore()); err != nil || restored.enabled() {
+		t.Errorf("loadMaintenanceMode() = %v, %v, want it off when never set", restored, err)
+	}
+}
+
+func TestMaintenanceUsage(t *testing.T) {
+	b, _, _ := newMaintenanceBot(t)
+	for _, text := range []string{"maybe", "on now"} {
+		if got := runMaintenance(t, b, text); got != usageOf("/maintenance") {
+			t.Errorf("/maintenance %s answered %q, want the usage", text, got)
+		}
+	}
+	if b.maintenance.enabled() {
+		t.Errorf("an invalid command changed the maintenance mode")
+	}
+}
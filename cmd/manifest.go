@@ -0,0 +1,98 @@
+/*
+Copyright © 2024 Pavlo Tarasiuk <pasha.tarasyuk@gmail.com>
+*/
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+
+	"github.com/spf13/cobra"
+)
+
+// manifestSlashCommand is a slash command as declared in the Slack app manifest
+type manifestSlashCommand struct {
+	Command      string `json:"command"`
+	Description  string `json:"description"`
+	UsageHint    string `json:"usage_hint,omitempty"`
+	ShouldEscape bool   `json:"should_escape"`
+}
+
+// manifestFeatures is the part of the app manifest generated from the command registry
+type manifestFeatures struct {
+	Features struct {
+		SlashCommands []manifestSlashCommand `json:"slash_commands"`
+	} `json:"features"`
+}
+
+// manifestCommands lists the registered slash commands for the manifest, sorted by name
+func manifestCommands() []manifestSlashCommand {
+	commands := make([]manifestSlashCommand, 0, len(slashCommands))
+	for _, command := range slashCommands {
+		commands = append(commands, manifestSlashCommand{
+			Command:     command.Name,
+			Description: command.Description,
+			UsageHint:   command.usage(),
+			// The handlers expect users and channels escaped, e.g. <@U123|jane>
+			ShouldEscape: true,
+		})
+	}
+	sort.Slice(commands, func(i, j int) bool { return commands[i].Command < commands[j].Command })
+	return commands
+}
+
+// writeManifestJSON writes the slash command section of the manifest as JSON
+func writeManifestJSON(w io.Writer, commands []manifestSlashCommand) error {
+	var manifest manifestFeatures
+	manifest.Features.SlashCommands = commands
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(manifest)
+}
+
+// writeManifestYAML writes the slash command section of the manifest as YAML, strings are
+// double quoted so descriptions with colons or quotes stay valid
+func writeManifestYAML(w io.Writer, commands []manifestSlashCommand) error {
+	if _, err := fmt.Fprintln(w, "features:\n  slash_commands:"); err != nil {
+		return err
+	}
+	for _, command := range commands {
+		fmt.Fprintf(w, "    - command: %s\n", strconv.Quote(command.Command))
+		fmt.Fprintf(w, "      description: %s\n", strconv.Quote(command.Description))
+		if command.UsageHint != "" {
+			fmt.Fprintf(w, "      usage_hint: %s\n", strconv.Quote(command.UsageHint))
+		}
+		if _, err := fmt.Fprintf(w, "      should_escape: %t\n", command.ShouldEscape); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// manifestCmd represents the manifest command
+var manifestCmd = &cobra.Command{
+	Use:   "manifest",
+	Short: "Print the slash commands section of the Slack app manifest",
+	Long: `The manifest command generates the slash commands of the Slack app manifest from the
+	commands MAVBot registers, ready to paste into the app configuration so both stay in sync.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		format, _ := cmd.Flags().GetString("format")
+		commands := manifestCommands()
+		switch format {
+		case "json":
+			return writeManifestJSON(cmd.OutOrStdout(), commands)
+		case "yaml":
+			return writeManifestYAML(cmd.OutOrStdout(), commands)
+		}
+		return fmt.Errorf("unknown format %q, use yaml or json", format)
+	},
+}
+
+func init() {
+	manifestCmd.Flags().String("format", "yaml", "output format, yaml or json")
+	rootCmd.AddCommand(manifestCmd)
+}
@@ -0,0 +1,103 @@
+/*
+Copyright © 2024 Pavlo Tarasiuk <pasha.tarasyuk@gmail.com>
+*/
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"sort"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// runManifest runs `mavbot manifest` in the format and returns what it printed
+func runManifest(t *testing.T, format string) string {
+	t.Helper()
+	var out bytes.Buffer
+	manifestCmd.SetOut(&out)
+	t.Cleanup(func() {
+		manifestCmd.SetOut(nil)
+		_ = manifestCmd.Flags().Set("format", "yaml")
+	})
+	if err := manifestCmd.Flags().Set("format", format); err != nil {
+		t.Fatalf("failed to set the format: %v", err)
+	}
+	if err := manifestCmd.RunE(manifestCmd, nil); err != nil {
+		t.Fatalf("manifest failed: %v", err)
+	}
+	return out.String()
+}
+
+// registeredNames returns the names of the registered slash commands, sorted
+func registeredNames() []string {
+	var names []string
+	for name := range slashCommands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func TestManifestJSONListsAllCommands(t *testing.T) {
+	registerTestCommand(t, &slashCommand{
+		Name:        "/test-manifest",
+		Description: `Says "hi": loudly`,
+		Usage:       "<name>",
+	})
+
+	var manifest manifestFeatures
+	if err := json.Unmarshal([]byte(runManifest(t, "json")), &manifest); err != nil {
+		t.Fatalf("failed to decode the manifest: %v", err)
+	}
+	var names []string
+	for _, command := range manifest.Features.SlashCommands {
+		names = append(names, command.Command)
+		registered := slashCommands[command.Command]
+		if registered == nil {
+			t.Errorf("manifest lists %s, which isn't registered", command.Command)
+			continue
+		}
+		if command.Description != registered.Description || command.UsageHint != registered.usage() || !command.ShouldEscape {
+			t.Errorf("manifest entry %+v doesn't match the registry", command)
+		}
+	}
+	if want := registeredNames(); strings.Join(names, " ") != strings.Join(want, " ") {
+		t.Errorf("manifest lists %v, want the registered %v in order", names, want)
+	}
+}
+
+func TestManifestYAMLListsAllCommands(t *testing.T) {
+	registerTestCommand(t, &slashCommand{
+		Name:        "/test-manifest",
+		Description: `Says "hi": loudly`,
+		Usage:       "<name>",
+	})
+
+	out := runManifest(t, "yaml")
+	if !strings.HasPrefix(out, "features:\n  slash_commands:\n") {
+		t.Errorf("manifest starts with %q, want the slash_commands section", out[:min(len(out), 40)])
+	}
+	for _, name := range registeredNames() {
+		if !strings.Contains(out, "    - command: "+strconv.Quote(name)+"\n") {
+			t.Errorf("manifest doesn't list %s", name)
+		}
+	}
+	entry := `    - command: "/test-manifest"
+      description: "Says \"hi\": loudly"
+      usage_hint: "<name>"
+      should_escape: true
+`
+	if !strings.Contains(out, entry) {
+		t.Errorf("manifest doesn't quote the entry of /test-manifest:\n%s", out)
+	}
+}
+
+func TestManifestUnknownFormat(t *testing.T) {
+	t.Cleanup(func() { _ = manifestCmd.Flags().Set("format", "yaml") })
+	_ = manifestCmd.Flags().Set("format", "toml")
+	if err := manifestCmd.RunE(manifestCmd, nil); err == nil {
+		t.Errorf("manifest in toml succeeded, want an error")
+	}
+}
@@ -0,0 +1,191 @@
+/*
+Copyright © 2024 Pavlo Tarasiuk <pasha.tarasyuk@gmail.com>
+*/
+package cmd
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/slack-go/slack"
+	"github.com/slack-go/slack/slackevents"
+)
+
+// Minimum roles a user needs to get a reply when mentioning the bot
+const (
+	// mentionRoleEveryone lets anyone mention the bot
+	mentionRoleEveryone = "everyone"
+	// mentionRoleMember excludes guests
+	mentionRoleMember = "member"
+	// mentionRoleAdmin requires a workspace admin or owner, or a MAVBot admin
+	mentionRoleAdmin = "admin"
+	// mentionRoleNone leaves only the users listed in MAVBOT_MENTION_USERS
+	mentionRoleNone = "none"
+)
+
+// Answers to mentions that aren't a greeting
+const (
+	// mentionFallbackOffer offers help in the channel
+	mentionFallbackOffer = "offer"
+	// mentionFallbackText posts MAVBOT_MENTION_FALLBACK_TEXT in the channel
+	mentionFallbackText = "text"
+	// mentionFallbackHelp shows the user the commands they may run
+	mentionFallbackHelp = "help"
+	// mentionFallbackSilent doesn't answer
+	mentionFallbackSilent = "silent"
+)
+
+// validMentionFallback reports whether fallback is one of the known answers
+func validMentionFallback(fallback string) bool {
+	switch fallback {
+	case mentionFallbackOffer, mentionFallbackText, mentionFallbackHelp, mentionFallbackSilent:
+		return true
+	}
+	return false
+}
+
+// Ways replies address users
+const (
+	// addressName uses the username, e.g. jane.doe
+	addressName = "name"
+	// addressDisplay uses the display name from the profile, e.g. Jane
+	addressDisplay = "display"
+	// addressReal uses the full name, e.g. Jane Doe
+	addressReal = "real"
+	// addressMention uses a clickable mention, which notifies the user
+	addressMention = "mention"
+)
+
+// validAddressFormat reports whether format is one of the known ways to address users
+func validAddressFormat(format string) bool {
+	switch format {
+	case addressName, addressDisplay, addressReal, addressMention:
+		return true
+	}
+	return false
+}
+
+// addressUser returns how replies refer to the user, see AddressFormat. The username stands
+// in for the names the user didn't fill in.
+func (b *Bot) addressUser(user *slack.User) string {
+	switch b.cfg.AddressFormat {
+	case addressDisplay:
+		if user.Profile.DisplayName != "" {
+			return user.Profile.DisplayName
+		}
+	case addressReal:
+		if user.RealName != "" {
+			return user.RealName
+		}
+		if user.Profile.RealName != "" {
+			return user.Profile.RealName
+		}
+	case addressMention:
+		return userRef(user.ID)
+	}
+	return user.Name
+}
+
+// validMentionRole reports whether role is one of the known roles
+func validMentionRole(role string) bool {
+	switch role {
+	case mentionRoleEveryone, mentionRoleMember, mentionRoleAdmin, mentionRoleNone:
+		return true
+	}
+	return false
+}
+
+// mayMention reports whether the bot answers mentions by user
+func (b *Bot) mayMention(user *slack.User) bool {
+	for _, id := range b.cfg.MentionUsers {
+		if id == user.ID {
+			return true
+		}
+	}
+	switch b.cfg.MentionRole {
+	case mentionRoleMember:
+		return !user.IsRestricted && !user.IsUltraRestricted
+	case mentionRoleAdmin:
+		return user.IsAdmin || user.IsOwner || b.isAdmin(user.ID)
+	case mentionRoleNone:
+		return false
+	}
+	return true
+}
+
+// mentionReply starts a reply to a mention by the user, with the context every such reply carries
+func (b *Bot) mentionReply(user *slack.User) *replyBuilder {
+	return b.reply().
+		Field(fieldDate, b.formatTime(b.now(), user.TZ)).
+		Field(fieldInitializer, b.addressUser(user))
+}
+
+// mentionData is what the templates of a reply to a mention by the user in the channel get,
+// so they can tailor the message to what the channel is about
+func (b *Bot) mentionData(user *slack.User, channelID string) templateData {
+	return templateData{
+		User:    b.addressUser(user),
+		Channel: b.channelContext(channelID),
+	}
+}
+
+// collectionGreeted holds the day each user was last greeted on, keyed by user ID
+const collectionGreeted = "greeted"
+
+// firstGreetingToday reports whether the user hasn't been greeted yet today, in their timezone,
+// and remembers that they have been now
+func (b *Bot) firstGreetingToday(user *slack.User) (bool, error) {
+	now := b.now()
+	if loc := b.location(user.TZ); loc != nil {
+		now = now.In(loc)
+	}
+	today := now.Format(time.DateOnly)
+
+	var last string
+	if _, err := b.store.Get(collectionGreeted, user.ID, &last); err != nil {
+		return false, err
+	}
+	if last == today {
+		return false, nil
+	}
+	if err := b.store.Put(collectionGreeted, user.ID, today); err != nil {
+		return false, fmt.Errorf("failed to remember the greeting: %w", err)
+	}
+	return true, nil
+}
+
+// mentionHandler answers mentions of the bot, when its predicates pass
+type mentionHandler struct {
+	Name string
+	// When are the conditions under which the handler runs, none means always
+	When    []predicate
+	Handler func(b *Bot, event *slackevents.AppMentionEvent) error
+}
+
+// mentionHandlers are tried in order, the first one whose predicates pass handles the mention
+var mentionHandlers = []*mentionHandler{
+	{Name: "greeting", When: []predicate{duringMentionHours}, Handler: (*Bot).handleGreeting},
+	{Name: "after-hours", Handler: (*Bot).handleAfterHours},
+}
+
+// duringMentionHours passes within MAVBOT_MENTION_HOURS, or always when they aren't configured
+func duringMentionHours(b *Bot, inv invocation) (bool, error) {
+	if b.cfg.MentionHours == nil {
+		return true, nil
+	}
+	return withinHours(*b.cfg.MentionHours)(b, inv)
+}
+
+// handleAfterHours lets the user know when the bot answers mentions
+func (b *Bot) handleAfterHours(event *slackevents.AppMentionEvent) error {
+	if b.cfg.MentionHours == nil {
+		return nil
+	}
+	_, err := b.postMessage(outboundMessage{
+		Channel:     event.Channel,
+		Invoker:     event.User,
+		EphemeralTo: event.User,
+		Text:        fmt.Sprintf("I answer mentions between %s, please try again then", b.cfg.MentionHours),
+	})
+	return err
+}
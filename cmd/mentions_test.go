@@ -0,0 +1,306 @@
+/*
+Copyright © 2024 Pavlo Tarasiuk <pasha.tarasyuk@gmail.com>
+*/
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/slack-go/slack"
+	"github.com/slack-go/slack/slackevents"
+)
+
+func TestMayMention(t *testing.T) {
+	member := &slack.User{ID: "U1"}
+	guest := &slack.User{ID: "U2", IsRestricted: true}
+	singleChannelGuest := &slack.User{ID: "U3", IsUltraRestricted: true}
+	workspaceAdmin := &slack.User{ID: "U4", IsAdmin: true}
+	owner := &slack.User{ID: "U5", IsOwner: true}
+	botAdmin := &slack.User{ID: "U0ADMIN"}
+	listed := &slack.User{ID: "U0LISTED", IsRestricted: true}
+
+	tests := []struct {
+		role string
+		user *slack.User
+		want bool
+	}{
+		{mentionRoleEveryone, guest, true},
+		{mentionRoleMember, member, true},
+		{mentionRoleMember, guest, false},
+		{mentionRoleMember, singleChannelGuest, false},
+		{mentionRoleMember, listed, true},
+		{mentionRoleAdmin, member, false},
+		{mentionRoleAdmin, workspaceAdmin, true},
+		{mentionRoleAdmin, owner, true},
+		{mentionRoleAdmin, botAdmin, true},
+		{mentionRoleNone, workspaceAdmin, false},
+		{mentionRoleNone, listed, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.role+" "+tt.user.ID, func(t *testing.T) {
+			cfg := testConfig(t)
+			cfg.MentionRole = tt.role
+			cfg.MentionUsers = []string{"U0LISTED"}
+			cfg.Admins = []string{"U0ADMIN"}
+			b, _ := newTestBot(t, cfg)
+			if got := b.mayMention(tt.user); got != tt.want {
+				t.Errorf("mayMention = %t, want %t", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMentionRoleIsEnforced(t *testing.T) {
+	tests := []struct {
+		name   string
+		user   string
+		notice bool
+		// posts and ephemerals are the chat.postMessage and chat.postEphemeral calls expected
+		posts, ephemerals int
+	}{
+		{name: "allowed mentioner", user: `{"id":"U1","name":"pasha","is_admin":true}`, posts: 1},
+		{name: "disallowed mentioner", user: `{"id":"U1","name":"pasha"}`},
+		{name: "disallowed mentioner with notice", user: `{"id":"U1","name":"pasha"}`, notice: true, ephemerals: 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := testConfig(t)
+			cfg.MentionRole = mentionRoleAdmin
+			cfg.MentionDeniedNotice = tt.notice
+			b, fake := newTestBot(t, cfg)
+			fake.answer("users.info", `{"ok":true,"user":`+tt.user+`}`)
+
+			err := b.handleAppMentionEvent(&slackevents.AppMentionEvent{User: "U1", Channel: "C1", Text: "<@UBOT> hello", TimeStamp: "1712345678.000100"})
+			if err != nil {
+				t.Fatalf("mention failed: %v", err)
+			}
+			if got := len(fake.calls("chat.postMessage")); got != tt.posts {
+				t.Errorf("got %d replies, want %d", got, tt.posts)
+			}
+			ephemerals := fake.calls("chat.postEphemeral")
+			if len(ephemerals) != tt.ephemerals {
+				t.Fatalf("got %d ephemeral notes, want %d", len(ephemerals), tt.ephemerals)
+			}
+			if tt.ephemerals > 0 && ephemerals[0].Form.Get("user") != "U1" {
+				t.Errorf("the note went to %q, want the mentioner", ephemerals[0].Form.Get("user"))
+			}
+		})
+	}
+}
+
+func TestMentionFallback(t *testing.T) {
+	tests := []struct {
+		fallback string
+		// attachment is a part of the answer posted in the channel, empty for none
+		attachment string
+		// help tells whether the user is shown the help
+		help bool
+	}{
+		{fallback: mentionFallbackOffer, attachment: `"pretext":"How can I be of service?"`},
+		{fallback: mentionFallbackText, attachment: `"text":"Ask in #help-desk, we answer within a day"`},
+		{fallback: mentionFallbackHelp, help: true},
+		{fallback: mentionFallbackSilent},
+	}
+	for _, tt := range tests {
+		t.Run(tt.fallback, func(t *testing.T) {
+			cfg := testConfig(t)
+			cfg.MentionFallback = tt.fallback
+			cfg.MentionFallbackText = "Ask in #help-desk, we answer within a day"
+			b, fake := newTestBot(t, cfg)
+			fake.answer("users.info", `{"ok":true,"user":{"id":"U1","name":"pasha"}}`)
+
+			err := b.handleAppMentionEvent(&slackevents.AppMentionEvent{User: "U1", Channel: "C1", Text: "<@U0BOT> the build is red", TimeStamp: "1712345678.000100"})
+			if err != nil {
+				t.Fatalf("mention failed: %v", err)
+			}
+			posts := fake.calls("chat.postMessage")
+			if tt.attachment == "" && len(posts) != 0 {
+				t.Errorf("posted %d answers, want none in the channel", len(posts))
+			}
+			if tt.attachment != "" && (len(posts) != 1 || !strings.Contains(posts[0].Form.Get("attachments"), tt.attachment)) {
+				t.Errorf("posted %+v, want an answer with %s", posts, tt.attachment)
+			}
+			ephemerals := fake.calls("chat.postEphemeral")
+			if !tt.help && len(ephemerals) != 0 {
+				t.Errorf("showed %d ephemeral messages, want none", len(ephemerals))
+			}
+			if tt.help && (len(ephemerals) != 1 || ephemerals[0].Form.Get("text") != b.helpText("U1") || ephemerals[0].Form.Get("user") != "U1") {
+				t.Errorf("showed %+v, want the help to the mentioner", ephemerals)
+			}
+		})
+	}
+}
+
+func TestMentionFallbackGreetingUnaffected(t *testing.T) {
+	cfg := testConfig(t)
+	cfg.MentionFallback = mentionFallbackSilent
+	b, fake := newTestBot(t, cfg)
+	fake.answer("users.info", `{"ok":true,"user":{"id":"U1","name":"pasha"}}`)
+	if err := b.handleAppMentionEvent(&slackevents.AppMentionEvent{User: "U1", Channel: "C1", Text: "<@U0BOT> hello", TimeStamp: "1712345678.000100"}); err != nil {
+		t.Fatal(err)
+	}
+	if got := len(fake.calls("chat.postMessage")); got != 1 {
+		t.Errorf("posted %d greetings, want the greeting answered", got)
+	}
+}
+
+func TestMentionFallbackConfig(t *testing.T) {
+	tests := []struct {
+		fallback, text string
+		wantErr        bool
+	}{
+		{fallback: ""},
+		{fallback: "help"},
+		{fallback: "silent"},
+		{fallback: "text", text: "Ask in #help-desk"},
+		{fallback: "text", wantErr: true},
+		{fallback: "loud", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Setenv("MAVBOT_MENTION_FALLBACK", tt.fallback)
+		t.Setenv("MAVBOT_MENTION_FALLBACK_TEXT", tt.text)
+		cfg, err := loadConfigWith(noSecrets{})
+		if (err != nil) != tt.wantErr {
+			t.Errorf("fallback %q with text %q: error = %v, want error %t", tt.fallback, tt.text, err, tt.wantErr)
+		}
+		if tt.fallback == "" && err == nil && cfg.MentionFallback != mentionFallbackOffer {
+			t.Errorf("default fallback = %q, want %q", cfg.MentionFallback, mentionFallbackOffer)
+		}
+	}
+}
+
+func TestGreetOncePerDay(t *testing.T) {
+	steps := []struct {
+		// after is how long after the step before the user says hello
+		after time.Duration
+		user  string
+		want  string
+	}{
+		{user: "U1", want: "Greetings"},
+		{after: time.Hour, user: "U1", want: "How can I be of service?"},
+		{user: "U2", want: "Greetings"},
+		// Midnight in Tokyo, the same day in UTC
+		{after: 2 * time.Hour, user: "U1", want: "Greetings"},
+		{after: time.Minute, user: "U1", want: "How can I be of service?"},
+		{user: "U2", want: "Greetings"},
+	}
+	cfg := testConfig(t)
+	cfg.GreetOncePerDay = true
+	b, fake := newTestBot(t, cfg)
+	clock := newFakeClock()
+	b.now = clock.now
+	fake.handle("users.info", func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintf(w, `{"ok":true,"user":{"id":%[1]q,"name":"user-%[1]s","tz":"Asia/Tokyo"}}`, r.Form.Get("user"))
+	})
+
+	for i, step := range steps {
+		clock.advance(step.after)
+		err := b.handleAppMentionEvent(&slackevents.AppMentionEvent{User: step.user, Channel: "C1", Text: "<@U0BOT> hello", TimeStamp: "1712345678.000100"})
+		if err != nil {
+			t.Fatalf("step %d: mention failed: %v", i+1, err)
+		}
+		posts := fake.calls("chat.postMessage")
+		if len(posts) != i+1 {
+			t.Fatalf("step %d: %d replies, want one per mention", i+1, len(posts))
+		}
+		if got := posts[i].Form.Get("attachments"); !strings.Contains(got, `"pretext":"`+step.want+`"`) {
+			t.Errorf("step %d: %s at %s replied %s, want %q", i+1, step.user, clock.now(), got, step.want)
+		}
+	}
+}
+
+func TestGreetEveryTimeByDefault(t *testing.T) {
+	b, fake := newTestBot(t, nil)
+	fake.answer("users.info", `{"ok":true,"user":{"id":"U1","name":"pasha"}}`)
+	for i := 0; i < 2; i++ {
+		if err := b.handleAppMentionEvent(&slackevents.AppMentionEvent{User: "U1", Channel: "C1", Text: "<@U0BOT> hello"}); err != nil {
+			t.Fatalf("mention failed: %v", err)
+		}
+	}
+	for _, post := range fake.calls("chat.postMessage") {
+		if !strings.Contains(post.Form.Get("attachments"), `"pretext":"Greetings"`) {
+			t.Errorf("replied %s, want a greeting every time", post.Form.Get("attachments"))
+		}
+	}
+	var last string
+	if found, _ := b.store.Get(collectionGreeted, "U1", &last); found {
+		t.Errorf("remembered the greeting on %s with the option off", last)
+	}
+}
+
+func TestAddressUser(t *testing.T) {
+	full := &slack.User{ID: "U1", Name: "jane.doe", RealName: "Jane Doe", Profile: slack.UserProfile{DisplayName: "Jane"}}
+	profileOnly := &slack.User{ID: "U2", Name: "john.roe", Profile: slack.UserProfile{RealName: "John Roe"}}
+	bare := &slack.User{ID: "U3", Name: "bot.tester"}
+	tests := []struct {
+		format string
+		user   *slack.User
+		want   string
+	}{
+		{addressName, full, "jane.doe"},
+		{addressDisplay, full, "Jane"},
+		{addressDisplay, bare, "bot.tester"},
+		{addressReal, full, "Jane Doe"},
+		{addressReal, profileOnly, "John Roe"},
+		{addressReal, bare, "bot.tester"},
+		{addressMention, full, "<@U1>"},
+		{addressMention, bare, "<@U3>"},
+	}
+	for _, tt := range tests {
+		cfg := testConfig(t)
+		cfg.AddressFormat = tt.format
+		b, _ := newTestBot(t, cfg)
+		if got := b.addressUser(tt.user); got != tt.want {
+			t.Errorf("%s: addressUser(%s) = %q, want %q", tt.format, tt.user.ID, got, tt.want)
+		}
+	}
+}
+
+func TestGreetingAddressesUser(t *testing.T) {
+	cfg := testConfig(t)
+	cfg.AddressFormat = addressMention
+	b, fake := newTestBot(t, cfg)
+	fake.answer("users.info", `{"ok":true,"user":{"id":"U1","name":"jane.doe"}}`)
+	if err := b.handleAppMentionEvent(&slackevents.AppMentionEvent{User: "U1", Channel: "C1", Text: "<@U0BOT> hello", TimeStamp: "1712345678.000100"}); err != nil {
+		t.Fatal(err)
+	}
+	posts := fake.calls("chat.postMessage")
+	if len(posts) != 1 {
+		t.Fatalf("posted %d replies, want the greeting", len(posts))
+	}
+	var attachments []slack.Attachment
+	if err := json.Unmarshal([]byte(posts[0].Form.Get("attachments")), &attachments); err != nil || len(attachments) != 1 {
+		t.Fatalf("invalid attachments %s", posts[0].Form.Get("attachments"))
+	}
+	if got := attachments[0].Text; got != "Hello <@U1>" {
+		t.Errorf("greeted with %q, want the user mentioned", got)
+	}
+	for _, field := range attachments[0].Fields {
+		if field.Title == fieldInitializer && field.Value != "<@U1>" {
+			t.Errorf("initializer = %q, want the user mentioned", field.Value)
+		}
+	}
+}
+
+func TestAddressFormatConfig(t *testing.T) {
+	for _, tt := range []struct {
+		format  string
+		wantErr bool
+	}{{format: ""}, {format: "display"}, {format: "real"}, {format: "mention"}, {format: "nickname", wantErr: true}} {
+		t.Setenv("MAVBOT_ADDRESS_FORMAT", tt.format)
+		cfg, err := loadConfigWith(noSecrets{})
+		if (err != nil) != tt.wantErr {
+			t.Errorf("format %q: error = %v, want error %t", tt.format, err, tt.wantErr)
+		}
+		if tt.format == "" && err == nil && cfg.AddressFormat != addressName {
+			t.Errorf("default format = %q, want %q", cfg.AddressFormat, addressName)
+		}
+	}
+}
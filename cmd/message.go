@@ -0,0 +1,35 @@
+/*
+Copyright © 2024 Pavlo Tarasiuk <pasha.tarasyuk@gmail.com>
+*/
+package cmd
+
+import (
+	"strings"
+
+	"github.com/slack-go/slack"
+	"github.com/slack-go/slack/slackevents"
+)
+
+// handleMessageEvent takes care of messages posted where the bot is. A thread reply also sent to
+// the channel arrives once, as a thread_broadcast message, and is handled as the reply it is.
+func (b *Bot) handleMessageEvent(event *slackevents.MessageEvent) error {
+	if !b.channelAllowed(event.Channel) || b.isOwnMessage(event.User, event.BotID) {
+		return nil
+	}
+	switch event.SubType {
+	case "", slack.MsgSubTypeThreadBroadcast:
+	default:
+		// Edits, deletions, joins and the like aren't new messages
+		return nil
+	}
+	// Mentions are answered by handleAppMentionEvent
+	if b.selfUserID != "" && strings.Contains(event.Text, "<@"+b.selfUserID+">") {
+		return nil
+	}
+	// Answers to the bot's questions, e.g. those of /setup, are for the conversation only
+	if handled, err := b.continueConversation(event.User, event.Channel, event.Text); handled || err != nil {
+		return err
+	}
+	b.processLinks(event.Channel, event.User, threadOf(event.ThreadTimeStamp, event.TimeStamp), event.Text)
+	return nil
+}
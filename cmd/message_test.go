@@ -0,0 +1,85 @@
+/*
+Copyright © 2024 Pavlo Tarasiuk <pasha.tarasyuk@gmail.com>
+*/
+package cmd
+
+import (
+	"context"
+	"net/url"
+	"testing"
+
+	"github.com/slack-go/slack"
+	"github.com/slack-go/slack/slackevents"
+)
+
+// stubLinks is a LinkProcessor that is never run, the tests only look at the queued jobs
+type stubLinks struct{}
+
+// Process implements LinkProcessor
+func (stubLinks) Process(context.Context, *url.URL) (string, error) {
+	return "", nil
+}
+
+func TestThreadMessagesAreHandledOnce(t *testing.T) {
+	const thread = "1712345678.000100"
+	tests := []struct {
+		name  string
+		event slackevents.MessageEvent
+		// links is the number of link jobs the message queues
+		links int
+	}{
+		{
+			name:  "thread reply",
+			event: slackevents.MessageEvent{TimeStamp: "1712345999.000100", ThreadTimeStamp: thread},
+			links: 1,
+		},
+		{
+			name:  "thread reply broadcast to the channel",
+			event: slackevents.MessageEvent{SubType: slack.MsgSubTypeThreadBroadcast, TimeStamp: "1712345999.000100", ThreadTimeStamp: thread},
+			links: 1,
+		},
+		{
+			name:  "channel message",
+			event: slackevents.MessageEvent{TimeStamp: "1712345999.000100"},
+			links: 1,
+		},
+		{
+			name:  "edited reply",
+			event: slackevents.MessageEvent{SubType: slack.MsgSubTypeMessageChanged, TimeStamp: "1712345999.000100", ThreadTimeStamp: thread},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b, _ := newTestBot(t, nil)
+			b.links = stubLinks{}
+
+			event := tt.event
+			event.User, event.Channel, event.Text = "U2", "C1", "Fixed, see https://status.example.com"
+			err := b.handleEventMessage(context.Background(), callbackEvent("message", &event))
+			if err != nil {
+				t.Fatalf("message failed: %v", err)
+			}
+
+			if got := len(b.linkJobs); got != tt.links {
+				t.Errorf("queued %d link jobs, want %d", got, tt.links)
+			}
+		})
+	}
+}
+
+func TestOwnThreadBroadcastIsIgnored(t *testing.T) {
+	b, _ := newTestBot(t, nil)
+	b.links = stubLinks{}
+	b.selfUserID = "U0BOT"
+
+	event := slackevents.MessageEvent{
+		SubType: slack.MsgSubTypeThreadBroadcast, User: "U0BOT", Channel: "C1", Text: "Thanks for the report, see https://status.example.com",
+		TimeStamp: "1712345999.000100", ThreadTimeStamp: "1712345678.000100",
+	}
+	if err := b.handleEventMessage(context.Background(), callbackEvent("message", &event)); err != nil {
+		t.Fatalf("message failed: %v", err)
+	}
+	if got := len(b.linkJobs); got != 0 {
+		t.Errorf("the bot's own broadcast reply queued %d link jobs", got)
+	}
+}
@@ -0,0 +1,55 @@
+/*
+Copyright © 2024 Pavlo Tarasiuk <pasha.tarasyuk@gmail.com>
+*/
+package cmd
+
+import "sync"
+
+// Names of the counters kept by the bot
+const (
+	metricHandlerPanics    = "handler_panics"
+	metricHandlerErrors    = "handler_errors"
+	metricUnknownCommands  = "unknown_commands"
+	metricCommandCacheHits = "command_cache_hits"
+)
+
+// metrics is a set of named counters safe for concurrent use
+type metrics struct {
+	mu       sync.Mutex
+	counters map[string]int64
+}
+
+// newMetrics creates an empty set of counters
+func newMetrics() *metrics {
+	return &metrics{counters: make(map[string]int64)}
+}
+
+// inc increments the named counter by one
+func (m *metrics) inc(name string) {
+	m.add(name, 1)
+}
+
+// add increments the named counter by n
+func (m *metrics) add(name string, n int64) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.counters[name] += n
+}
+
+// get returns the current value of the named counter
+func (m *metrics) get(name string) int64 {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.counters[name]
+}
+
+// snapshot returns a copy of all counters
+func (m *metrics) snapshot() map[string]int64 {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	counters := make(map[string]int64, len(m.counters))
+	for name, value := range m.counters {
+		counters[name] = value
+	}
+	return counters
+}
@@ -0,0 +1,87 @@
+/*
+Copyright © 2024 Pavlo Tarasiuk <pasha.tarasyuk@gmail.com>
+*/
+package cmd
+
+import (
+	"log"
+	"sync"
+)
+
+// maxHeldMessages bounds the messages kept back during a migration, later ones are dropped
+const maxHeldMessages = 100
+
+// gridMigration pauses posting while the workspace migrates to Enterprise Grid, the messages
+// handlers post meanwhile are held and posted once the migration has finished
+type gridMigration struct {
+	mu     sync.Mutex
+	active bool
+	held   []outboundMessage
+}
+
+// start pauses posting
+func (m *gridMigration) start() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.active = true
+}
+
+// finish resumes posting and returns the messages held in the meantime
+func (m *gridMigration) finish() []outboundMessage {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	held := m.held
+	m.active = false
+	m.held = nil
+	return held
+}
+
+// hold keeps msg back when a migration is going on and reports whether it did
+func (m *gridMigration) hold(msg outboundMessage) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if !m.active {
+		return false
+	}
+	if len(m.held) < maxHeldMessages {
+		m.held = append(m.held, msg)
+	} else {
+		log.Printf("Dropped message to %s, too many messages are held during the grid migration\n", msg.Channel)
+	}
+	return true
+}
+
+// heldCount returns the number of messages held back
+func (m *gridMigration) heldCount() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return len(m.held)
+}
+
+// handleGridMigrationStarted pauses posting until the migration has finished
+func (b *Bot) handleGridMigrationStarted() {
+	log.Println("Enterprise Grid migration started, posting is paused")
+	b.migration.start()
+}
+
+// handleGridMigrationFinished picks up the workspace as it is after the migration, the cached
+// IDs and names may not hold anymore, and posts the messages held meanwhile
+func (b *Bot) handleGridMigrationFinished() error {
+	held := b.migration.finish()
+	log.Printf("Enterprise Grid migration finished, posting %d held messages\n", len(held))
+	if err := b.identify(); err != nil {
+		return err
+	}
+	for name, cache := range b.namedCaches() {
+		// Event IDs are still good for telling redeliveries apart
+		if name != "events" {
+			cache.purge()
+		}
+	}
+	for _, msg := range held {
+		if _, err := b.postMessage(msg); err != nil {
+			log.Printf("failed to post held message to %s: %v\n", msg.Channel, err)
+		}
+	}
+	return nil
+}
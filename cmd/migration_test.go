@@ -0,0 +1,81 @@
+/*
+Copyright © 2024 Pavlo Tarasiuk <pasha.tarasyuk@gmail.com>
+*/
+package cmd
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/slack-go/slack"
+	"github.com/slack-go/slack/slackevents"
+)
+
+func TestGridMigrationHoldsMessages(t *testing.T) {
+	captureLog(t)
+	var m gridMigration
+	if m.hold(outboundMessage{Channel: "C1"}) {
+		t.Fatalf("held a message without a migration going on")
+	}
+	m.start()
+	for i := 0; i < maxHeldMessages+5; i++ {
+		if !m.hold(outboundMessage{Channel: fmt.Sprintf("C%d", i)}) {
+			t.Fatalf("message %d went out during the migration", i)
+		}
+	}
+	if got := m.heldCount(); got != maxHeldMessages {
+		t.Errorf("heldCount() = %d, want the messages past %d dropped", got, maxHeldMessages)
+	}
+	held := m.finish()
+	if len(held) != maxHeldMessages || held[0].Channel != "C0" {
+		t.Errorf("finish() returned %d messages starting with %+v, want the first %d in order", len(held), held[0], maxHeldMessages)
+	}
+	if m.heldCount() != 0 || m.hold(outboundMessage{Channel: "C1"}) {
+		t.Errorf("still holding messages after the migration")
+	}
+}
+
+func TestPostingPausedDuringGridMigration(t *testing.T) {
+	captureLog(t)
+	b, fake := newTestBot(t, nil)
+	fake.answer("auth.test", `{"ok":true,"user_id":"U0MIGRATED","bot_id":"B0MIGRATED"}`)
+	b.users.set("U1", &slack.User{ID: "U1"})
+	b.events.seen("Ev1")
+
+	if err := b.dispatchEvent(callbackEvent("grid_migration_started", &slackevents.GridMigrationStartedEvent{})); err != nil {
+		t.Fatalf("grid_migration_started failed: %v", err)
+	}
+	for _, channel := range []string{"C1", "C2"} {
+		if _, err := b.postMessage(outboundMessage{Channel: channel, Text: "Deploy finished"}); err != nil {
+			t.Fatalf("posting during the migration failed: %v", err)
+		}
+	}
+	if got := len(fake.calls("chat.postMessage")); got != 0 {
+		t.Fatalf("posted %d messages during the migration, want them held", got)
+	}
+
+	if err := b.dispatchEvent(callbackEvent("grid_migration_finished", &slackevents.GridMigrationFinishedEvent{})); err != nil {
+		t.Fatalf("grid_migration_finished failed: %v", err)
+	}
+	posts := fake.calls("chat.postMessage")
+	if len(posts) != 2 || posts[0].Form.Get("channel") != "C1" || posts[1].Form.Get("channel") != "C2" {
+		t.Fatalf("posted %d messages after the migration, want the held ones in order", len(posts))
+	}
+	if b.selfUserID != "U0MIGRATED" || b.selfBotID != "B0MIGRATED" {
+		t.Errorf("identity = %s/%s, want the bot identified again", b.selfUserID, b.selfBotID)
+	}
+	if _, ok := b.users.get("U1"); ok {
+		t.Errorf("the user cache outlived the migration")
+	}
+	if !b.events.seen("Ev1") {
+		t.Errorf("the seen events were forgotten, redeliveries would be handled again")
+	}
+
+	// Posting goes straight out again
+	if _, err := b.postMessage(outboundMessage{Channel: "C3", Text: "Back to normal"}); err != nil {
+		t.Fatal(err)
+	}
+	if got := len(fake.calls("chat.postMessage")); got != 3 {
+		t.Errorf("got %d posts, want the message after the migration posted", got)
+	}
+}
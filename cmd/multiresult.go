@@ -0,0 +1,65 @@
+/*
+Copyright © 2024 Pavlo Tarasiuk <pasha.tarasyuk@gmail.com>
+*/
+package cmd
+
+import (
+	"fmt"
+	"strings"
+)
+
+// targetResult is the outcome of an operation on one of several targets
+type targetResult struct {
+	// Target identifies what the operation was applied to, e.g. a channel ID
+	Target string
+	Err    error
+}
+
+// multiResult collects the outcomes of an operation applied to several targets, so a batch
+// reports what went through and what didn't instead of stopping at the first failure
+type multiResult struct {
+	Results []targetResult
+}
+
+// add records the outcome for target
+func (r *multiResult) add(target string, err error) {
+	r.Results = append(r.Results, targetResult{Target: target, Err: err})
+}
+
+// failed returns the number of targets the operation failed for
+func (r *multiResult) failed() int {
+	n := 0
+	for _, result := range r.Results {
+		if result.Err != nil {
+			n++
+		}
+	}
+	return n
+}
+
+// format renders the results as a Slack message: a summary line saying what was done to how
+// many of the targets, e.g. "Posted to 2 of 3 channels", then ✓ or ✗ with the reason per target.
+// describe renders a target, e.g. as a channel reference.
+func (r *multiResult) format(action, noun string, describe func(target string) string) string {
+	var out strings.Builder
+	succeeded := len(r.Results) - r.failed()
+	fmt.Fprintf(&out, "%s %d of %d %s\n", action, succeeded, len(r.Results), noun)
+	for _, result := range r.Results {
+		if result.Err != nil {
+			fmt.Fprintf(&out, "✗ %s: %v\n", describe(result.Target), result.Err)
+			continue
+		}
+		fmt.Fprintf(&out, "✓ %s\n", describe(result.Target))
+	}
+	return strings.TrimSuffix(out.String(), "\n")
+}
+
+// channelRef renders a channel ID as a channel reference Slack displays by name
+func channelRef(channelID string) string {
+	return fmt.Sprintf("<#%s>", channelID)
+}
+
+// userRef renders a user ID as a mention Slack displays by name
+func userRef(userID string) string {
+	return fmt.Sprintf("<@%s>", userID)
+}
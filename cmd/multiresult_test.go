@@ -0,0 +1,107 @@
+/*
+Copyright © 2024 Pavlo Tarasiuk <pasha.tarasyuk@gmail.com>
+*/
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/slack-go/slack"
+)
+
+func TestMultiResultFormat(t *testing.T) {
+	tests := []struct {
+		name    string
+		results []targetResult
+		failed  int
+		want    string
+	}{
+		{
+			name:    "all succeeded",
+			results: []targetResult{{Target: "C1"}, {Target: "C2"}},
+			want:    "Posted to 2 of 2 channels\n✓ <#C1>\n✓ <#C2>",
+		},
+		{
+			name: "all failed",
+			results: []targetResult{
+				{Target: "C1", Err: errors.New("channel_not_found")},
+				{Target: "C2", Err: errors.New("not_in_channel")},
+			},
+			failed: 2,
+			want:   "Posted to 0 of 2 channels\n✗ <#C1>: channel_not_found\n✗ <#C2>: not_in_channel",
+		},
+		{
+			name: "mixed",
+			results: []targetResult{
+				{Target: "C1"},
+				{Target: "C2", Err: errors.New("is_archived")},
+				{Target: "C3"},
+			},
+			failed: 1,
+			want:   "Posted to 2 of 3 channels\n✓ <#C1>\n✗ <#C2>: is_archived\n✓ <#C3>",
+		},
+		{name: "none", want: "Posted to 0 of 0 channels"},
+	}
+	for _, tt := range tests {
+		var result multiResult
+		for _, r := range tt.results {
+			result.add(r.Target, r.Err)
+		}
+		if got := result.failed(); got != tt.failed {
+			t.Errorf("%s: failed() = %d, want %d", tt.name, got, tt.failed)
+		}
+		if got := result.format("Posted to", "channels", channelRef); got != tt.want {
+			t.Errorf("%s: format() =\n%s\nwant\n%s", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestMultiResultDescribesTargets(t *testing.T) {
+	var result multiResult
+	result.add("U1", nil)
+	result.add("U2", errors.New("cannot_dm_bot"))
+	if got, want := result.format("Nudged", "users", userRef), "Nudged 1 of 2 users\n✓ <@U1>\n✗ <@U2>: cannot_dm_bot"; got != want {
+		t.Errorf("format() =\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestBroadcastReportsEveryChannel(t *testing.T) {
+	cfg := testConfig(t)
+	cfg.Admins = []string{"U0ADMIN"}
+	b, fake := newTestBot(t, cfg)
+	fake.handle("chat.postMessage", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if channel := r.FormValue("channel"); channel == "C2" {
+			fmt.Fprint(w, `{"ok":false,"error":"channel_not_found"}`)
+			return
+		}
+		fmt.Fprint(w, `{"ok":true,"channel":"C1","ts":"1712345678.000100"}`)
+	})
+	token, err := b.signer.encode(broadcastRequest{Channels: []string{"C1", "C2", "C3"}, Text: "The office is closed on Friday"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	interaction := slack.InteractionCallback{User: slack.User{ID: "U0ADMIN"}, ResponseURL: fake.apiURL() + "respond"}
+	if err := b.broadcast(interaction, &slack.BlockAction{Value: token}); err != nil {
+		t.Fatalf("broadcast() error = %v", err)
+	}
+	if got := len(fake.calls("chat.postMessage")); got != 3 {
+		t.Errorf("posted %d times, want every channel tried despite the failure", got)
+	}
+	var replaced struct {
+		Text            string `json:"text"`
+		ReplaceOriginal bool   `json:"replace_original"`
+	}
+	if err := json.Unmarshal(fake.waitCalls(t, "respond", 1)[0].Body, &replaced); err != nil {
+		t.Fatal(err)
+	}
+	want := "Posted to 2 of 3 channels\n✓ <#C1>\n✗ <#C2>: failed to post message: channel_not_found\n✓ <#C3>"
+	if !replaced.ReplaceOriginal || replaced.Text != want {
+		t.Errorf("replaced the preview with %+v, want\n%s", replaced, want)
+	}
+}
@@ -0,0 +1,139 @@
+/*
+Copyright © 2024 Pavlo Tarasiuk <pasha.tarasyuk@gmail.com>
+*/
+package cmd
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+	"time"
+
+	"github.com/slack-go/slack"
+)
+
+// netCheckHosts are the Slack endpoints the bot connects to, the Web API and the Socket Mode WebSocket
+var netCheckHosts = []string{"slack.com", "wss-primary.slack.com"}
+
+// netCheckTimeout bounds every step of /netcheck
+const netCheckTimeout = 5 * time.Second
+
+// netProber makes the connections /netcheck probes, replaceable so the probes can be tested
+type netProber struct {
+	lookupHost func(ctx context.Context, host string) ([]string, error)
+	dial       func(ctx context.Context, network, address string) (net.Conn, error)
+	// handshake runs the TLS handshake for host over conn
+	handshake func(ctx context.Context, conn net.Conn, host string) error
+}
+
+// defaultNetProber probes with the system resolver and dialer
+func defaultNetProber() netProber {
+	return netProber{
+		lookupHost: net.DefaultResolver.LookupHost,
+		dial:       (&net.Dialer{}).DialContext,
+		handshake: func(ctx context.Context, conn net.Conn, host string) error {
+			return tls.Client(conn, &tls.Config{ServerName: host}).HandshakeContext(ctx)
+		},
+	}
+}
+
+// hostChecks returns the steps probing host: DNS resolution, TCP connect and the TLS handshake.
+// A step is skipped when the one before it failed.
+func (p netProber) hostChecks(host string) []selfTestCheck {
+	var addrs []string
+	var conn net.Conn
+	return []selfTestCheck{
+		{
+			name: host + " DNS",
+			run: func() (err error) {
+				ctx, cancel := context.WithTimeout(context.Background(), netCheckTimeout)
+				defer cancel()
+				addrs, err = p.lookupHost(ctx, host)
+				if err == nil && len(addrs) == 0 {
+					err = errors.New("no addresses")
+				}
+				return err
+			},
+		},
+		{
+			name: host + " TCP connect",
+			run: func() (err error) {
+				if len(addrs) == 0 {
+					return errors.New("skipped, the name didn't resolve")
+				}
+				ctx, cancel := context.WithTimeout(context.Background(), netCheckTimeout)
+				defer cancel()
+				conn, err = p.dial(ctx, "tcp", net.JoinHostPort(addrs[0], "443"))
+				return err
+			},
+		},
+		{
+			name: host + " TLS handshake",
+			run: func() error {
+				if conn == nil {
+					return errors.New("skipped, no connection")
+				}
+				defer conn.Close()
+				ctx, cancel := context.WithTimeout(context.Background(), netCheckTimeout)
+				defer cancel()
+				return p.handshake(ctx, conn, host)
+			},
+		},
+	}
+}
+
+// handleNetCheck probes the network path to Slack step by step and reports how long each step took,
+// so network trouble can be told apart from trouble with Slack itself
+func (b *Bot) handleNetCheck(command slack.SlashCommand) (*SlashResponse, error) {
+	return b.respondLater(command, "Checking the connection to Slack…", func() (*SlashResponse, error) {
+		return ephemeral(b.runNetCheck(defaultNetProber())), nil
+	}), nil
+}
+
+// runNetCheck probes every Slack host with prober, then makes a cheap API call
+func (b *Bot) runNetCheck(prober netProber) string {
+	var checks []selfTestCheck
+	for _, host := range netCheckHosts {
+		checks = append(checks, prober.hostChecks(host)...)
+	}
+	checks = append(checks, selfTestCheck{
+		name: "auth.test",
+		run: func() error {
+			ctx, cancel := context.WithTimeout(context.Background(), netCheckTimeout)
+			defer cancel()
+			_, err := b.api().AuthTestContext(ctx)
+			return err
+		},
+	})
+	return runTimedChecks("MAVBot network check", checks, b.now)
+}
+
+// runTimedChecks runs every check like runSelfTest, adding how long each of them took
+func runTimedChecks(title string, checks []selfTestCheck, now func() time.Time) string {
+	var report strings.Builder
+	fmt.Fprintf(&report, "*%s*\n", title)
+	for _, check := range checks {
+		start := now()
+		err := check.run()
+		took := now().Sub(start).Round(time.Millisecond)
+		if err != nil {
+			fmt.Fprintf(&report, ":x: %s (%s): %v\n", check.name, took, err)
+		} else {
+			fmt.Fprintf(&report, ":white_check_mark: %s (%s)\n", check.name, took)
+		}
+	}
+	return report.String()
+}
+
+func init() {
+	registerSlashCommand(&slashCommand{
+		Name:        "/netcheck",
+		Description: "Check the network connection to Slack's API and Socket Mode endpoints",
+		Category:    categoryAdmin,
+		AdminOnly:   true,
+		Handler:     (*Bot).handleNetCheck,
+	})
+}
@@ -0,0 +1,167 @@
+/*
+Copyright © 2024 Pavlo Tarasiuk <pasha.tarasyuk@gmail.com>
+*/
+package cmd
+
+import (
+	"context"
+	"errors"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/slack-go/slack"
+)
+
+// stubProber answers every probe with the stubbed results, recording what was probed
+type stubProber struct {
+	addrs        []string
+	lookupErr    error
+	dialErr      error
+	handshakeErr error
+	// dialed and shaken are the addresses dialled and the hosts the handshake was run for
+	dialed, shaken []string
+	closed         int
+}
+
+// closeCounter counts the connections closed
+type closeCounter struct {
+	net.Conn
+	closed *int
+}
+
+func (c closeCounter) Close() error {
+	*c.closed++
+	return c.Conn.Close()
+}
+
+func (s *stubProber) prober() netProber {
+	return netProber{
+		lookupHost: func(ctx context.Context, host string) ([]string, error) {
+			if _, ok := ctx.Deadline(); !ok {
+				return nil, errors.New("no deadline")
+			}
+			return s.addrs, s.lookupErr
+		},
+		dial: func(ctx context.Context, network, address string) (net.Conn, error) {
+			s.dialed = append(s.dialed, network+" "+address)
+			if s.dialErr != nil {
+				return nil, s.dialErr
+			}
+			conn, _ := net.Pipe()
+			return closeCounter{Conn: conn, closed: &s.closed}, nil
+		},
+		handshake: func(ctx context.Context, conn net.Conn, host string) error {
+			s.shaken = append(s.shaken, host)
+			return s.handshakeErr
+		},
+	}
+}
+
+func TestNetCheckProbes(t *testing.T) {
+	tests := []struct {
+		name   string
+		prober *stubProber
+		// authTest is the answer to auth.test
+		authTest string
+		want     []string
+		// dials and handshakes are the probes expected to get that far
+		dials, handshakes int
+	}{
+		{
+			name:     "all fine",
+			prober:   &stubProber{addrs: []string{"192.0.2.10", "192.0.2.11"}},
+			authTest: `{"ok":true,"user_id":"U0BOT"}`,
+			want: []string{
+				":white_check_mark: slack.com DNS (5ms)",
+				":white_check_mark: slack.com TCP connect (5ms)",
+				":white_check_mark: slack.com TLS handshake (5ms)",
+				":white_check_mark: wss-primary.slack.com DNS (5ms)",
+				":white_check_mark: wss-primary.slack.com TCP connect (5ms)",
+				":white_check_mark: wss-primary.slack.com TLS handshake (5ms)",
+				":white_check_mark: auth.test (5ms)",
+			},
+			dials: 2, handshakes: 2,
+		},
+		{
+			name:     "name doesn't resolve",
+			prober:   &stubProber{lookupErr: errors.New("no such host")},
+			authTest: `{"ok":false,"error":"invalid_auth"}`,
+			want: []string{
+				":x: slack.com DNS (5ms): no such host",
+				":x: slack.com TCP connect (5ms): skipped, the name didn't resolve",
+				":x: slack.com TLS handshake (5ms): skipped, no connection",
+				":x: auth.test (5ms): invalid_auth",
+			},
+		},
+		{
+			name:     "no addresses",
+			prober:   &stubProber{},
+			authTest: `{"ok":true}`,
+			want:     []string{":x: slack.com DNS (5ms): no addresses", ":x: slack.com TCP connect (5ms): skipped, the name didn't resolve"},
+		},
+		{
+			name:     "connection refused",
+			prober:   &stubProber{addrs: []string{"192.0.2.10"}, dialErr: errors.New("connection refused")},
+			authTest: `{"ok":true}`,
+			want:     []string{":white_check_mark: slack.com DNS (5ms)", ":x: slack.com TCP connect (5ms): connection refused", ":x: slack.com TLS handshake (5ms): skipped, no connection"},
+			dials:    2,
+		},
+		{
+			name:     "TLS intercepted",
+			prober:   &stubProber{addrs: []string{"192.0.2.10"}, handshakeErr: errors.New("x509: certificate signed by unknown authority")},
+			authTest: `{"ok":true}`,
+			want:     []string{":white_check_mark: slack.com TCP connect (5ms)", ":x: slack.com TLS handshake (5ms): x509: certificate signed by unknown authority"},
+			dials:    2, handshakes: 2,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b, fake := newTestBot(t, nil)
+			fake.answer("auth.test", tt.authTest)
+			// Every reading of the clock is 5ms after the last, so each step takes 5ms
+			clock := newFakeClock()
+			b.now = func() time.Time {
+				clock.advance(5 * time.Millisecond)
+				return clock.now()
+			}
+
+			report := b.runNetCheck(tt.prober.prober())
+			if !strings.HasPrefix(report, "*MAVBot network check*\n") {
+				t.Errorf("report = %q, want the title first", report)
+			}
+			lines := strings.Split(strings.TrimSpace(report), "\n")
+			if len(lines) != 8 {
+				t.Errorf("report has %d lines, want the title and 7 steps", len(lines))
+			}
+			for _, want := range tt.want {
+				if !strings.Contains(report, want+"\n") {
+					t.Errorf("report = %q, want %q", report, want)
+				}
+			}
+			if len(tt.prober.dialed) != tt.dials || len(tt.prober.shaken) != tt.handshakes {
+				t.Errorf("dialled %d and shook hands %d times, want %d and %d", len(tt.prober.dialed), len(tt.prober.shaken), tt.dials, tt.handshakes)
+			}
+			if tt.dials > 0 && tt.prober.dialErr == nil && tt.prober.dialed[0] != "tcp 192.0.2.10:443" {
+				t.Errorf("dialled %q, want the first address on 443", tt.prober.dialed[0])
+			}
+			if tt.prober.dialErr == nil && tt.prober.closed != tt.dials {
+				t.Errorf("closed %d of %d connections", tt.prober.closed, tt.dials)
+			}
+		})
+	}
+}
+
+func TestNetCheckAdminOnly(t *testing.T) {
+	cfg := testConfig(t)
+	cfg.Admins = []string{"U0ADMIN"}
+	b, _ := newTestBot(t, cfg)
+	resp, err := b.dispatchSlashCommand(slack.SlashCommand{Command: "/netcheck", UserID: "U1", ChannelID: "C1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.Text != "Sorry, this command is available to MAVBot admins only" {
+		t.Errorf("answered %q, want the command refused", resp.Text)
+	}
+}
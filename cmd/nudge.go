@@ -0,0 +1,153 @@
+/*
+Copyright © 2024 Pavlo Tarasiuk <pasha.tarasyuk@gmail.com>
+*/
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/slack-go/slack"
+)
+
+// collectionSeen holds when each user last interacted with the bot and was last nudged, keyed by user ID
+const collectionSeen = "seen"
+
+// maxNudgesPerRun bounds the DMs a single /nudge sends
+const maxNudgesPerRun = 50
+
+// userSeen is what the bot knows about a user's engagement
+type userSeen struct {
+	LastSeen time.Time `json:"last_seen"`
+	NudgedAt time.Time `json:"nudged_at,omitempty"`
+}
+
+// recordSeen notes that the user interacted with the bot at the time. The record is only
+// written once a day per user, that is as precise as /nudge needs it.
+func (l *activityLog) recordSeen(userID string, at time.Time) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	var seen userSeen
+	if _, err := l.store.Get(collectionSeen, userID, &seen); err != nil {
+		return fmt.Errorf("failed to record activity: %w", err)
+	}
+	if seen.LastSeen.Format(activityDayFormat) == at.Format(activityDayFormat) {
+		return nil
+	}
+	seen.LastSeen = at
+	if err := l.store.Put(collectionSeen, userID, seen); err != nil {
+		return fmt.Errorf("failed to record activity: %w", err)
+	}
+	return nil
+}
+
+// recordNudged notes that the user was nudged at the time
+func (l *activityLog) recordNudged(userID string, at time.Time) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	var seen userSeen
+	if _, err := l.store.Get(collectionSeen, userID, &seen); err != nil {
+		return fmt.Errorf("failed to record nudge: %w", err)
+	}
+	seen.NudgedAt = at
+	if err := l.store.Put(collectionSeen, userID, seen); err != nil {
+		return fmt.Errorf("failed to record nudge: %w", err)
+	}
+	return nil
+}
+
+// inactiveUsers returns the users who haven't interacted with the bot since the time and weren't
+// nudged since then either. Users who opted out of nudges are left out.
+func (b *Bot) inactiveUsers(since time.Time) ([]string, error) {
+	ids, err := b.store.Keys(collectionSeen)
+	if err != nil {
+		return nil, err
+	}
+	var inactive []string
+	for _, id := range ids {
+		var seen userSeen
+		ok, err := b.store.Get(collectionSeen, id, &seen)
+		if err != nil {
+			return nil, err
+		}
+		if !ok || seen.LastSeen.After(since) || seen.NudgedAt.After(since) {
+			continue
+		}
+		if b.userPrefs(id).NoNudges {
+			continue
+		}
+		inactive = append(inactive, id)
+	}
+	return inactive, nil
+}
+
+// nudge sends the user a DM with the digest and remembers it did
+func (b *Bot) nudge(userID, digest string) error {
+	_, err := b.postDM(userID, outboundMessage{
+		Text: "We haven't seen you for a while, here is what's been going on. " +
+			"Use /prefs nudges off to stop these reminders.\n\n" + digest,
+	})
+	if errors.Is(err, errDMUnavailable) {
+		return errors.New("their DMs are closed")
+	}
+	if err != nil {
+		return err
+	}
+	return b.activity.recordNudged(userID, b.now())
+}
+
+// handleNudge DMs a digest to the users who haven't interacted with the bot for the given
+// number of days: /nudge <days>. At most maxNudgesPerRun users are nudged at once.
+func (b *Bot) handleNudge(command slack.SlashCommand) (*SlashResponse, error) {
+	days, err := strconv.Atoi(strings.TrimSpace(command.Text))
+	if err != nil || days < 1 {
+		return ephemeral(usageOf("/nudge")), nil
+	}
+
+	return b.respondLater(command, "Nudging inactive users…", func() (*SlashResponse, error) {
+		users, err := b.inactiveUsers(b.now().AddDate(0, 0, -days))
+		if err != nil {
+			return nil, err
+		}
+		if len(users) == 0 {
+			return ephemeral(fmt.Sprintf("Everyone has been around in the last %d days", days)), nil
+		}
+		var skipped int
+		if len(users) > maxNudgesPerRun {
+			skipped = len(users) - maxNudgesPerRun
+			users = users[:maxNudgesPerRun]
+		}
+		digest, err := b.dailySummary(b.now())
+		if err != nil {
+			return nil, err
+		}
+
+		var result multiResult
+		for _, user := range users {
+			result.add(user, b.nudge(user, digest))
+		}
+		report := result.format("Nudged", "inactive users", userRef)
+		if skipped > 0 {
+			report += fmt.Sprintf("\n%d more are left for the next /nudge", skipped)
+		}
+		return ephemeral(report), nil
+	}), nil
+}
+
+func init() {
+	registerSlashCommand(&slashCommand{
+		Name:          "/nudge",
+		Description:   "DM a digest to the users who haven't used MAVBot for a number of days",
+		Usage:         "<days>",
+		Example:       "/nudge 14",
+		Category:      categoryAdmin,
+		AdminOnly:     true,
+		MaxConcurrent: 1,
+		Handler:       (*Bot).handleNudge,
+	})
+}
@@ -0,0 +1,265 @@
+/*
+Copyright © 2024 Pavlo Tarasiuk <pasha.tarasyuk@gmail.com>
+*/
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/slack-go/slack"
+)
+
+// memStore is a Store kept in memory, counting the writes to each collection
+type memStore struct {
+	mu   sync.Mutex
+	docs map[string]map[string][]byte
+	puts map[string]int
+}
+
+// newMemStore returns an empty memStore
+func newMemStore() *memStore {
+	return &memStore{docs: map[string]map[string][]byte{}, puts: map[string]int{}}
+}
+
+// Get implements Store
+func (s *memStore) Get(collection, key string, v interface{}) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	raw, ok := s.docs[collection][key]
+	if !ok {
+		return false, nil
+	}
+	return true, json.Unmarshal(raw, v)
+}
+
+// Put implements Store
+func (s *memStore) Put(collection, key string, v interface{}) error {
+	raw, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.docs[collection] == nil {
+		s.docs[collection] = map[string][]byte{}
+	}
+	s.docs[collection][key] = raw
+	s.puts[collection]++
+	return nil
+}
+
+// Delete implements Store
+func (s *memStore) Delete(collection, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.docs[collection], key)
+	return nil
+}
+
+// Keys implements Store
+func (s *memStore) Keys(collection string) ([]string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var keys []string
+	for key := range s.docs[collection] {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys, nil
+}
+
+// writes returns the number of Puts to the collection
+func (s *memStore) writes(collection string) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.puts[collection]
+}
+
+// newNudgeBot returns an admin's bot keeping its state in a memStore, at the fake clock's time
+func newNudgeBot(t *testing.T) (*Bot, *fakeSlack, *memStore) {
+	t.Helper()
+	cfg := testConfig(t)
+	cfg.Admins = []string{"U0ADMIN"}
+	b, fake := newTestBot(t, cfg)
+	store := newMemStore()
+	b.store = store
+	b.activity.store = store
+	b.now = newFakeClock().now
+	fake.handle("conversations.open", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintf(w, `{"ok":true,"channel":{"id":"D%s"}}`, r.FormValue("users"))
+	})
+	return b, fake, store
+}
+
+// seen records when the user was last seen and nudged, days before the fake clock's time.
+// A negative nudged means never.
+func seen(t *testing.T, store Store, user string, lastSeen, nudged int) {
+	t.Helper()
+	now := newFakeClock().now()
+	record := userSeen{LastSeen: now.AddDate(0, 0, -lastSeen)}
+	if nudged >= 0 {
+		record.NudgedAt = now.AddDate(0, 0, -nudged)
+	}
+	if err := store.Put(collectionSeen, user, record); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestInactiveUsers(t *testing.T) {
+	tests := []struct {
+		name     string
+		lastSeen int
+		nudged   int
+		optedOut bool
+		want     bool
+	}{
+		{name: "active yesterday", lastSeen: 1, nudged: -1},
+		{name: "inactive", lastSeen: 20, nudged: -1, want: true},
+		{name: "inactive, nudged long ago", lastSeen: 60, nudged: 30, want: true},
+		{name: "inactive, nudged recently", lastSeen: 60, nudged: 3},
+		{name: "opted out", lastSeen: 20, nudged: -1, optedOut: true},
+		{name: "exactly 14 days", lastSeen: 14, nudged: -1, want: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b, _, store := newNudgeBot(t)
+			seen(t, store, "U1", tt.lastSeen, tt.nudged)
+			if tt.optedOut {
+				if err := store.Put(collectionPrefs, "U1", userPrefs{NoNudges: true}); err != nil {
+					t.Fatal(err)
+				}
+			}
+			users, err := b.inactiveUsers(b.now().AddDate(0, 0, -14))
+			if err != nil {
+				t.Fatalf("inactiveUsers() error = %v", err)
+			}
+			if got := len(users) == 1; got != tt.want {
+				t.Errorf("inactiveUsers() = %v, want U1 selected: %t", users, tt.want)
+			}
+		})
+	}
+}
+
+// runNudge runs /nudge as an admin and returns the final response
+func runNudge(t *testing.T, b *Bot, fake *fakeSlack, text string) string {
+	t.Helper()
+	_, err := b.handleSlashCommand(slack.SlashCommand{
+		Command: "/nudge", Text: text, UserID: "U0ADMIN", ChannelID: "C1", ResponseURL: fake.apiURL() + "respond",
+	})
+	if err != nil {
+		t.Fatalf("/nudge failed: %v", err)
+	}
+	var final responsePayload
+	if err := json.Unmarshal(fake.waitCalls(t, "respond", 1)[0].Body, &final); err != nil {
+		t.Fatal(err)
+	}
+	return final.Text
+}
+
+func TestNudgeRespectsOptOut(t *testing.T) {
+	b, fake, store := newNudgeBot(t)
+	seen(t, store, "U1", 30, -1)
+	seen(t, store, "U2", 30, -1)
+	seen(t, store, "U3", 2, -1)
+	if _, err := b.handlePrefs(slack.SlashCommand{UserID: "U2", Text: "nudges off"}); err != nil {
+		t.Fatal(err)
+	}
+
+	if got, want := runNudge(t, b, fake, "14"), "Nudged 1 of 1 inactive users\n✓ <@U1>"; got != want {
+		t.Errorf("answered\n%s\nwant\n%s", got, want)
+	}
+	posts := fake.calls("chat.postMessage")
+	if len(posts) != 1 || posts[0].Form.Get("channel") != "DU1" {
+		t.Fatalf("got %d DMs, want U1 nudged only", len(posts))
+	}
+	if text := posts[0].Form.Get("text"); !strings.Contains(text, "/prefs nudges off") || !strings.Contains(text, "*Daily summary") {
+		t.Errorf("nudge = %q, want the digest and how to opt out", text)
+	}
+
+	// U1 was nudged now, so the next run has nobody left
+	var record userSeen
+	if _, err := store.Get(collectionSeen, "U1", &record); err != nil || !record.NudgedAt.Equal(b.now()) {
+		t.Errorf("recorded %+v, want U1 nudged now", record)
+	}
+	if users, _ := b.inactiveUsers(b.now().AddDate(0, 0, -14)); len(users) != 0 {
+		t.Errorf("inactiveUsers() = %v after the nudge, want none", users)
+	}
+}
+
+func TestNudgeDMsClosed(t *testing.T) {
+	b, fake, store := newNudgeBot(t)
+	fake.handle("conversations.open", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if r.FormValue("users") == "U2" {
+			fmt.Fprint(w, `{"ok":false,"error":"messages_tab_disabled"}`)
+			return
+		}
+		fmt.Fprintf(w, `{"ok":true,"channel":{"id":"D%s"}}`, r.FormValue("users"))
+	})
+	seen(t, store, "U1", 30, -1)
+	seen(t, store, "U2", 30, -1)
+
+	want := "Nudged 1 of 2 inactive users\n✓ <@U1>\n✗ <@U2>: their DMs are closed"
+	if got := runNudge(t, b, fake, "14"); got != want {
+		t.Errorf("answered\n%s\nwant\n%s", got, want)
+	}
+	var record userSeen
+	if _, err := store.Get(collectionSeen, "U2", &record); err != nil || !record.NudgedAt.IsZero() {
+		t.Errorf("recorded %+v, want U2 left to nudge again", record)
+	}
+}
+
+func TestNudgeIsCappedPerRun(t *testing.T) {
+	b, fake, store := newNudgeBot(t)
+	for i := 0; i < maxNudgesPerRun+2; i++ {
+		seen(t, store, fmt.Sprintf("U%03d", i), 30, -1)
+	}
+	got := runNudge(t, b, fake, "14")
+	if !strings.HasPrefix(got, fmt.Sprintf("Nudged %d of %d inactive users", maxNudgesPerRun, maxNudgesPerRun)) ||
+		!strings.HasSuffix(got, "\n2 more are left for the next /nudge") {
+		t.Errorf("answered\n%s\nwant %d nudged and 2 left", got, maxNudgesPerRun)
+	}
+	if posts := len(fake.calls("chat.postMessage")); posts != maxNudgesPerRun {
+		t.Errorf("sent %d DMs, want %d", posts, maxNudgesPerRun)
+	}
+}
+
+func TestNudgeNobodyInactive(t *testing.T) {
+	b, fake, store := newNudgeBot(t)
+	seen(t, store, "U1", 2, -1)
+	if got, want := runNudge(t, b, fake, "7"), "Everyone has been around in the last 7 days"; got != want {
+		t.Errorf("answered %q, want %q", got, want)
+	}
+	for _, text := range []string{"", "0", "soon"} {
+		resp, err := b.handleNudge(slack.SlashCommand{Text: text})
+		if err != nil || resp.Text != usageOf("/nudge") {
+			t.Errorf("/nudge %s answered %v, %v, want the usage", text, resp, err)
+		}
+	}
+}
+
+func TestRecordSeenOncePerDay(t *testing.T) {
+	store := newMemStore()
+	activity := &activityLog{store: store}
+	morning := time.Date(2024, 4, 5, 9, 0, 0, 0, time.UTC)
+	for _, at := range []time.Time{morning, morning.Add(time.Hour), morning.Add(5 * time.Hour), morning.AddDate(0, 0, 1)} {
+		if err := activity.recordSeen("U1", at); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if got := store.writes(collectionSeen); got != 2 {
+		t.Errorf("wrote %d times, want once a day", got)
+	}
+	var record userSeen
+	if _, err := store.Get(collectionSeen, "U1", &record); err != nil || !record.LastSeen.Equal(morning.AddDate(0, 0, 1)) {
+		t.Errorf("recorded %+v, want the last day seen", record)
+	}
+}
@@ -0,0 +1,212 @@
+/*
+Copyright © 2024 Pavlo Tarasiuk <pasha.tarasyuk@gmail.com>
+*/
+package cmd
+
+import (
+	"context"
+	"log"
+	"math"
+	"sync"
+	"time"
+)
+
+// queuedPost is a message waiting in the outbound queue
+type queuedPost struct {
+	msg outboundMessage
+	// seq orders the messages of a rank by when they were queued
+	seq int
+	// due is when the message may be posted
+	due time.Time
+	// attempts counts the posts Slack was over capacity for
+	attempts int
+	// waited is set once a rate limit Slack answered the message with has been waited out
+	waited bool
+}
+
+// outboundQueue holds the messages the outbound rate limit keeps from being posted right away.
+// Messages of a higher rank are posted first, those of a rank in the order they were queued.
+type outboundQueue struct {
+	mu    sync.Mutex
+	items []*queuedPost
+	seq   int
+	// size is the number of messages the queue holds, 0 is unlimited
+	size int
+	// wake is signalled when a message is queued
+	wake chan struct{}
+}
+
+// newOutboundQueue creates an empty queue holding up to size messages, 0 being unlimited
+func newOutboundQueue(size int) *outboundQueue {
+	return &outboundQueue{size: size, wake: make(chan struct{}, 1)}
+}
+
+// push queues the message to be posted from due on. When the queue is full it makes room by
+// dropping the last queued message of the lowest rank below the message's, and it reports
+// false when there is none, the message is dropped instead.
+func (q *outboundQueue) push(msg outboundMessage, due time.Time) bool {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.size > 0 && len(q.items) >= q.size {
+		victim := -1
+		for i, item := range q.items {
+			if item.msg.rank() >= msg.rank() {
+				continue
+			}
+			if victim < 0 || item.msg.rank() < q.items[victim].msg.rank() ||
+				(item.msg.rank() == q.items[victim].msg.rank() && item.seq > q.items[victim].seq) {
+				victim = i
+			}
+		}
+		if victim < 0 {
+			return false
+		}
+		log.Printf("outbound queue full, dropped queued message to %s\n", q.items[victim].msg.Channel)
+		q.items = append(q.items[:victim], q.items[victim+1:]...)
+	}
+	q.seq++
+	q.items = append(q.items, &queuedPost{msg: msg, seq: q.seq, due: due})
+	q.signal()
+	return true
+}
+
+// requeue queues a message again to retry posting it from due on. It keeps its place among the
+// messages of its rank and is never dropped for room, it was taken in already.
+func (q *outboundQueue) requeue(item *queuedPost, due time.Time) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if item.seq == 0 {
+		q.seq++
+		item.seq = q.seq
+	}
+	item.due = due
+	q.items = append(q.items, item)
+	q.signal()
+}
+
+// signal wakes up the sender waiting for a message. Callers must hold q.mu.
+func (q *outboundQueue) signal() {
+	select {
+	case q.wake <- struct{}{}:
+	default:
+	}
+}
+
+// pop removes and returns the message to post next among those due at now, nil when none is
+func (q *outboundQueue) pop(now time.Time) *queuedPost {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	best := -1
+	for i, item := range q.items {
+		if item.due.After(now) {
+			continue
+		}
+		if best < 0 || item.msg.rank() > q.items[best].msg.rank() ||
+			(item.msg.rank() == q.items[best].msg.rank() && item.seq < q.items[best].seq) {
+			best = i
+		}
+	}
+	if best < 0 {
+		return nil
+	}
+	item := q.items[best]
+	q.items = append(q.items[:best], q.items[best+1:]...)
+	return item
+}
+
+// nextDue returns when the earliest message is due, false when the queue is empty
+func (q *outboundQueue) nextDue() (time.Time, bool) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	var next time.Time
+	for i, item := range q.items {
+		if i == 0 || item.due.Before(next) {
+			next = item.due
+		}
+	}
+	return next, len(q.items) > 0
+}
+
+// hasDue reports whether a message is due at now, new messages wait behind it
+func (q *outboundQueue) hasDue(now time.Time) bool {
+	next, ok := q.nextDue()
+	return ok && !next.After(now)
+}
+
+// holds reports whether the message is queued
+func (q *outboundQueue) holds(item *queuedPost) bool {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	for _, queued := range q.items {
+		if queued == item {
+			return true
+		}
+	}
+	return false
+}
+
+// len returns the number of queued messages
+func (q *outboundQueue) len() int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return len(q.items)
+}
+
+// waitDue blocks until a message is due, it reports false when ctx is done first
+func (q *outboundQueue) waitDue(ctx context.Context, now func() time.Time) bool {
+	for {
+		next, ok := q.nextDue()
+		// With nothing queued only a new message or ctx ends the wait
+		wait := time.Duration(math.MaxInt64)
+		if ok {
+			if wait = next.Sub(now()); wait <= 0 {
+				return true
+			}
+		}
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return false
+		case <-q.wake:
+		case <-timer.C:
+		}
+		timer.Stop()
+	}
+}
+
+// runOutbound posts the queued messages as the outbound rate limit allows until ctx is cancelled
+func (b *Bot) runOutbound(ctx context.Context) {
+	for b.queue.waitDue(ctx, b.now) {
+		if b.outbound != nil && !b.outbound.take(ctx) {
+			return
+		}
+		// Picked once the limit lets it through, so a message of a higher rank queued meanwhile goes first
+		if item := b.queue.pop(b.now()); item != nil {
+			b.deliver(item)
+		}
+	}
+}
+
+// drainOutbound posts the messages left in the queue as the outbound rate limit allows until
+// the queue is empty or ctx is done, when the messages still queued are logged as lost
+func (b *Bot) drainOutbound(ctx context.Context) {
+	for b.queue.len() > 0 && ctx.Err() == nil && b.queue.waitDue(ctx, b.now) {
+		if b.outbound != nil && !b.outbound.take(ctx) {
+			break
+		}
+		if item := b.queue.pop(b.now()); item != nil {
+			b.deliver(item)
+		}
+	}
+	if n := b.queue.len(); n > 0 {
+		log.Printf("dropped %d queued messages on shutdown\n", n)
+	}
+}
+
+// deliver posts a queued message, logging a failure as nobody waits for the result anymore
+func (b *Bot) deliver(item *queuedPost) {
+	if _, err := b.send(item); err != nil {
+		log.Printf("failed to post queued message to %s: %v\n", item.msg.Channel, err)
+	}
+}
@@ -0,0 +1,173 @@
+/*
+Copyright © 2024 Pavlo Tarasiuk <pasha.tarasyuk@gmail.com>
+*/
+package cmd
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+)
+
+// queuedTexts pops every message due at now and returns their texts in the order they came out
+func queuedTexts(q *outboundQueue, now time.Time) []string {
+	var texts []string
+	for item := q.pop(now); item != nil; item = q.pop(now) {
+		texts = append(texts, item.msg.Text)
+	}
+	return texts
+}
+
+func TestOutboundQueueOrder(t *testing.T) {
+	now := newFakeClock().now()
+	q := newOutboundQueue(0)
+	for _, msg := range []outboundMessage{
+		{Text: "ack 1", Priority: priorityLow},
+		{Text: "reply 1"},
+		{Text: "error 1", Priority: priorityHigh},
+		{Text: "ack 2", Priority: priorityLow},
+		{Text: "reply 2", Priority: priorityNormal},
+		{Text: "error 2", Priority: priorityHigh},
+	} {
+		q.push(msg, now)
+	}
+	// A message that isn't due yet waits whatever its rank
+	q.push(outboundMessage{Text: "later", Priority: priorityHigh}, now.Add(time.Minute))
+
+	want := []string{"error 1", "error 2", "reply 1", "reply 2", "ack 1", "ack 2"}
+	if got := queuedTexts(q, now); strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Errorf("posted %q, want %q", got, want)
+	}
+	if next, ok := q.nextDue(); !ok || !next.Equal(now.Add(time.Minute)) {
+		t.Errorf("nextDue() = %s, %t, want the later message", next, ok)
+	}
+	if got := queuedTexts(q, now.Add(time.Minute)); len(got) != 1 || got[0] != "later" {
+		t.Errorf("posted %q once due, want the later message", got)
+	}
+}
+
+func TestOutboundQueueRequeueKeepsPlace(t *testing.T) {
+	now := newFakeClock().now()
+	q := newOutboundQueue(2)
+	q.push(outboundMessage{Text: "first"}, now)
+	q.push(outboundMessage{Text: "second"}, now)
+	item := q.pop(now)
+	q.requeue(item, now)
+	if got := queuedTexts(q, now); strings.Join(got, ",") != "first,second" {
+		t.Errorf("posted %q, want the requeued message first again", got)
+	}
+}
+
+func TestOutboundQueueFull(t *testing.T) {
+	tests := []struct {
+		name   string
+		queued []outboundMessage
+		pushed outboundMessage
+		// accepted is whether the pushed message is queued, want what is left in the queue
+		accepted bool
+		want     []string
+	}{
+		{
+			name:     "the latest of the lowest rank makes room",
+			queued:   []outboundMessage{{Text: "ack 1", Priority: priorityLow}, {Text: "reply"}, {Text: "ack 2", Priority: priorityLow}},
+			pushed:   outboundMessage{Text: "error", Priority: priorityHigh},
+			accepted: true,
+			want:     []string{"error", "reply", "ack 1"},
+		},
+		{
+			name:     "a normal message makes room for a high one",
+			queued:   []outboundMessage{{Text: "reply 1"}, {Text: "error 1", Priority: priorityHigh}, {Text: "reply 2"}},
+			pushed:   outboundMessage{Text: "error 2", Priority: priorityHigh},
+			accepted: true,
+			want:     []string{"error 1", "error 2", "reply 1"},
+		},
+		{
+			name:   "nothing of a lower rank",
+			queued: []outboundMessage{{Text: "reply 1"}, {Text: "error", Priority: priorityHigh}, {Text: "reply 2"}},
+			pushed: outboundMessage{Text: "reply 3"},
+			want:   []string{"error", "reply 1", "reply 2"},
+		},
+		{
+			name:   "a low message is dropped",
+			queued: []outboundMessage{{Text: "ack 1", Priority: priorityLow}, {Text: "ack 2", Priority: priorityLow}, {Text: "ack 3", Priority: priorityLow}},
+			pushed: outboundMessage{Text: "ack 4", Priority: priorityLow},
+			want:   []string{"ack 1", "ack 2", "ack 3"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			captureLog(t)
+			now := newFakeClock().now()
+			q := newOutboundQueue(len(tt.queued))
+			for _, msg := range tt.queued {
+				q.push(msg, now)
+			}
+			if got := q.push(tt.pushed, now); got != tt.accepted {
+				t.Errorf("push() = %t, want %t", got, tt.accepted)
+			}
+			if got := queuedTexts(q, now); strings.Join(got, ",") != strings.Join(tt.want, ",") {
+				t.Errorf("queue holds %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestHighPriorityPostedBeforeQueuedLowPriority(t *testing.T) {
+	cfg := testConfig(t)
+	cfg.OutboundPerMinute = 1200
+	b, fake := newTestBot(t, cfg)
+	b.outbound = newTokenBucket(cfg.OutboundPerMinute, time.Now)
+	for b.outbound.tryTake() {
+	}
+
+	// The budget is spent, so the messages are queued in the order they were posted
+	for _, msg := range []outboundMessage{
+		{Channel: "C1", Text: "Got it", Priority: priorityLow},
+		{Channel: "C1", Text: "Here's the report"},
+		{Channel: "C1", Text: "Deploy failed", Priority: priorityHigh},
+	} {
+		if ts, err := b.postMessage(msg); ts != "" || err != nil {
+			t.Fatalf("post %q = %q, %v, want it queued", msg.Text, ts, err)
+		}
+	}
+	if got := len(fake.calls("chat.postMessage")); got != 0 {
+		t.Fatalf("posted %d messages over the budget", got)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		b.runOutbound(ctx)
+	}()
+	fake.waitCalls(t, "chat.postMessage", 3)
+	cancel()
+	<-done
+
+	want := []string{"Deploy failed", "Here's the report", "Got it"}
+	if got := fake.posts(); strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Errorf("posted %q, want %q", got, want)
+	}
+}
+
+func TestRunOutboundStopsWhileWaitingForTheBudget(t *testing.T) {
+	b, fake := newQueueingBot(t, 1, time.Minute)
+	b.postMessage(outboundMessage{Channel: "C1", Text: "later"})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		b.runOutbound(ctx)
+	}()
+	cancel()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatalf("runOutbound() kept waiting for the budget after the context was cancelled")
+	}
+	if len(fake.posts()) != 0 || b.queue.len() != 1 {
+		t.Errorf("the message was posted past the budget, want it left in the queue")
+	}
+}
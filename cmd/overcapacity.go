@@ -0,0 +1,65 @@
+/*
+Copyright © 2024 Pavlo Tarasiuk <pasha.tarasyuk@gmail.com>
+*/
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+
+	"github.com/slack-go/slack"
+)
+
+// Counters of Slack being over capacity, kept apart from its rate limits
+const (
+	// metricOverCapacity counts the posts Slack answered with 503 or service_unavailable
+	metricOverCapacity = "slack_over_capacity"
+	// metricOverCapacityFailures counts the posts given up on after retrying
+	metricOverCapacityFailures = "slack_over_capacity_failures"
+)
+
+// isOverCapacity reports whether err is Slack being too busy to take the call, which passes
+// unlike most errors, so the call is worth repeating
+func isOverCapacity(err error) bool {
+	var statusErr slack.StatusCodeError
+	if errors.As(err, &statusErr) {
+		return statusErr.Code == http.StatusServiceUnavailable
+	}
+	code, _ := slackErrorCode(err)
+	return code == "service_unavailable"
+}
+
+// overCapacityError is returned when Slack stayed over capacity for every attempt of a post
+type overCapacityError struct {
+	Attempts int
+	Err      error
+}
+
+// Error implements error
+func (e *overCapacityError) Error() string {
+	return fmt.Sprintf("Slack is over capacity, gave up after %d attempts: %v", e.Attempts, e.Err)
+}
+
+// Unwrap returns the error of the last attempt
+func (e *overCapacityError) Unwrap() error {
+	return e.Err
+}
+
+// retryOverCapacity queues a post Slack was over capacity for again, err being the attempt's error,
+// up to OverCapacityRetries times. Unlike a rate limit Slack doesn't say when to come back, so the
+// wait starts at OverCapacityDelay and doubles with every attempt. Once the retries are used up it
+// returns an *overCapacityError.
+func (b *Bot) retryOverCapacity(item *queuedPost, err error) error {
+	b.metrics.inc(metricOverCapacity)
+	if item.attempts >= b.cfg.OverCapacityRetries {
+		b.metrics.inc(metricOverCapacityFailures)
+		return &overCapacityError{Attempts: item.attempts + 1, Err: err}
+	}
+	delay := b.cfg.OverCapacityDelay << item.attempts
+	item.attempts++
+	log.Printf("Slack is over capacity, retrying the post to %s in %s\n", item.msg.Channel, delay)
+	b.queue.requeue(item, b.now().Add(delay))
+	return nil
+}
@@ -0,0 +1,170 @@
+/*
+Copyright © 2024 Pavlo Tarasiuk <pasha.tarasyuk@gmail.com>
+*/
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/slack-go/slack"
+)
+
+func TestIsOverCapacity(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{name: "503", err: slack.StatusCodeError{Code: http.StatusServiceUnavailable, Status: "503 Service Unavailable"}, want: true},
+		{name: "wrapped 503", err: fmt.Errorf("failed to post: %w", slack.StatusCodeError{Code: http.StatusServiceUnavailable}), want: true},
+		{name: "service_unavailable", err: slack.SlackErrorResponse{Err: "service_unavailable"}, want: true},
+		{name: "500", err: slack.StatusCodeError{Code: http.StatusInternalServerError}},
+		{name: "rate limit", err: &slack.RateLimitedError{RetryAfter: time.Second}},
+		{name: "ratelimited", err: slack.SlackErrorResponse{Err: "ratelimited"}},
+		{name: "other", err: errors.New("connection reset by peer")},
+		{name: "none"},
+	}
+	for _, tt := range tests {
+		if got := isOverCapacity(tt.err); got != tt.want {
+			t.Errorf("%s: isOverCapacity(%v) = %t, want %t", tt.name, tt.err, got, tt.want)
+		}
+	}
+}
+
+// overCapacityFor makes the fake Slack answer the first n posts the way Slack does when it is
+// over capacity, the ones after them as usual
+func overCapacityFor(fake *fakeSlack, n int, answer http.HandlerFunc) {
+	var mu sync.Mutex
+	fake.handle("chat.postMessage", func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		over := n > 0
+		n--
+		mu.Unlock()
+		if over {
+			answer(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"ok":true,"channel":"C1","ts":"1712345999.000100"}`)
+	})
+}
+
+// unavailable answers with HTTP 503
+func unavailable(w http.ResponseWriter, r *http.Request) {
+	w.WriteHeader(http.StatusServiceUnavailable)
+}
+
+// serviceUnavailable answers with Slack's service_unavailable error
+func serviceUnavailable(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	fmt.Fprint(w, `{"ok":false,"error":"service_unavailable"}`)
+}
+
+// newOverCapacityBot creates a bot retrying over capacity posts the times, 5ms apart at first,
+// and posting queued messages until the test ends
+func newOverCapacityBot(t *testing.T, retries int) (*Bot, *fakeSlack) {
+	t.Helper()
+	cfg := testConfig(t)
+	cfg.OverCapacityRetries = retries
+	cfg.OverCapacityDelay = 5 * time.Millisecond
+	b, fake := newTestBot(t, cfg)
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		b.runOutbound(ctx)
+	}()
+	t.Cleanup(func() {
+		cancel()
+		<-done
+	})
+	return b, fake
+}
+
+// postDelivered posts to C1 and waits for the outcome of the post, retries included
+func postDelivered(t *testing.T, b *Bot) error {
+	t.Helper()
+	delivered := make(chan error, 1)
+	if _, err := b.postMessage(outboundMessage{Channel: "C1", Text: "Deploy finished", Delivered: delivered}); err != nil {
+		return err
+	}
+	select {
+	case err := <-delivered:
+		return err
+	case <-time.After(2 * time.Second):
+		t.Fatalf("the post was neither delivered nor given up on")
+		return nil
+	}
+}
+
+func TestOverCapacityRetrySucceeds(t *testing.T) {
+	for name, answer := range map[string]http.HandlerFunc{"503": unavailable, "service_unavailable": serviceUnavailable} {
+		t.Run(name, func(t *testing.T) {
+			logs := captureLog(t)
+			b, fake := newOverCapacityBot(t, 3)
+			overCapacityFor(fake, 2, answer)
+
+			if err := postDelivered(t, b); err != nil {
+				t.Fatalf("the post failed: %v", err)
+			}
+			if got := len(fake.calls("chat.postMessage")); got != 3 {
+				t.Errorf("attempted %d posts, want 2 over capacity and 1 delivered", got)
+			}
+			// The wait doubles, Slack doesn't say when to come back
+			for _, line := range []string{"retrying the post to C1 in 5ms", "retrying the post to C1 in 10ms"} {
+				if !strings.Contains(logs.String(), "Slack is over capacity, "+line) {
+					t.Errorf("logs = %q, want %q", logs.String(), line)
+				}
+			}
+			if got, failed := b.metrics.get(metricOverCapacity), b.metrics.get(metricOverCapacityFailures); got != 2 || failed != 0 {
+				t.Errorf("counted %d over capacity and %d failures, want 2 and 0", got, failed)
+			}
+			// It isn't mistaken for a rate limit
+			if events := b.usage.limitedEvents(); len(events) != 0 {
+				t.Errorf("recorded rate limit events %+v", events)
+			}
+		})
+	}
+}
+
+func TestOverCapacityGivesUp(t *testing.T) {
+	captureLog(t)
+	b, fake := newOverCapacityBot(t, 2)
+	overCapacityFor(fake, 10, unavailable)
+
+	err := postDelivered(t, b)
+	var overCapacity *overCapacityError
+	if !errors.As(err, &overCapacity) || overCapacity.Attempts != 3 {
+		t.Fatalf("the post failed with %v, want it given up after 3 attempts", err)
+	}
+	if !strings.HasPrefix(err.Error(), "Slack is over capacity, gave up after 3 attempts: ") || !isOverCapacity(err) {
+		t.Errorf("error = %q, want it to say Slack was over capacity", err)
+	}
+	if got := len(fake.calls("chat.postMessage")); got != 3 {
+		t.Errorf("attempted %d posts, want 3", got)
+	}
+	if got, failed := b.metrics.get(metricOverCapacity), b.metrics.get(metricOverCapacityFailures); got != 3 || failed != 1 {
+		t.Errorf("counted %d over capacity and %d failures, want 3 and 1", got, failed)
+	}
+}
+
+func TestOverCapacityWithoutRetries(t *testing.T) {
+	captureLog(t)
+	b, fake := newOverCapacityBot(t, 0)
+	overCapacityFor(fake, 1, unavailable)
+	_, err := b.postMessage(outboundMessage{Channel: "C1", Text: "Deploy finished"})
+	var overCapacity *overCapacityError
+	if !errors.As(err, &overCapacity) || overCapacity.Attempts != 1 {
+		t.Errorf("postMessage() error = %v, want it given up right away", err)
+	}
+	if got := len(fake.calls("chat.postMessage")); got != 1 {
+		t.Errorf("attempted %d posts, want 1", got)
+	}
+}
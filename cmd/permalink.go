@@ -0,0 +1,101 @@
+/*
+Copyright © 2024 Pavlo Tarasiuk <pasha.tarasyuk@gmail.com>
+*/
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/url"
+	"regexp"
+	"strings"
+
+	"github.com/slack-go/slack"
+)
+
+// permalink returns the permanent URL of the message, or an empty string when it can't be resolved.
+// Permalinks never change, so successful lookups are cached for the lifetime of the bot.
+func (b *Bot) permalink(channelID, timestamp string) string {
+	key := channelID + "/" + timestamp
+	if link, ok := b.permalinks.get(key); ok {
+		return link
+	}
+
+	link, err := b.api().GetPermalink(&slack.PermalinkParameters{Channel: channelID, Ts: timestamp})
+	if err != nil {
+		log.Printf("failed to get permalink for %s: %v\n", key, err)
+		return ""
+	}
+	b.permalinks.set(key, link)
+	return link
+}
+
+// messageLink formats a reference to the message as a Slack link labelled with label,
+// falling back to the bare label when there is no permalink
+func (b *Bot) messageLink(channelID, timestamp, label string) string {
+	link := b.permalink(channelID, timestamp)
+	if link == "" {
+		return label
+	}
+	return fmt.Sprintf("<%s|%s>", link, label)
+}
+
+// permalinkPath matches the path of a message permalink, e.g. /archives/C123/p1712345678123456
+var permalinkPath = regexp.MustCompile(`^/archives/([A-Z0-9]+)/p(\d+)(\d{6})$`)
+
+// errInvalidPermalink is returned for links that aren't Slack message permalinks
+var errInvalidPermalink = errors.New("not a Slack message permalink")
+
+// messagePermalink is what a permalink says about the message it points at
+type messagePermalink struct {
+	// Host is the workspace's domain, e.g. x.slack.com
+	Host    string
+	Channel string
+	// Timestamp is the message's own timestamp, ThreadTS the one of its thread's parent, if any
+	Timestamp string
+	ThreadTS  string
+}
+
+// thread returns the timestamp replies to the message are posted under
+func (p messagePermalink) thread() string {
+	if p.ThreadTS != "" {
+		return p.ThreadTS
+	}
+	return p.Timestamp
+}
+
+// parsePermalink reads a permalink like https://x.slack.com/archives/C123/p1712345678123456,
+// optionally with a thread_ts query parameter as links to replies have. The angle brackets
+// Slack wraps links in are accepted.
+func parsePermalink(link string) (messagePermalink, error) {
+	u, err := url.Parse(strings.Trim(strings.TrimSpace(link), "<>"))
+	if err != nil || u.Scheme != "https" || !strings.HasSuffix(u.Hostname(), ".slack.com") {
+		return messagePermalink{}, errInvalidPermalink
+	}
+	m := permalinkPath.FindStringSubmatch(u.Path)
+	if m == nil {
+		return messagePermalink{}, errInvalidPermalink
+	}
+	threadTS := u.Query().Get("thread_ts")
+	if threadTS != "" && !timestampPattern.MatchString(threadTS) {
+		return messagePermalink{}, errInvalidPermalink
+	}
+	return messagePermalink{Host: u.Hostname(), Channel: m[1], Timestamp: m[2] + "." + m[3], ThreadTS: threadTS}, nil
+}
+
+// timestampPattern matches a bare message timestamp, e.g. 1712345678.123456
+var timestampPattern = regexp.MustCompile(`^\d+\.\d{6}$`)
+
+// parseMessageRef reads a message reference given as a permalink or a bare timestamp.
+// The channel is only known from a permalink and is empty otherwise.
+func parseMessageRef(ref string) (channelID, timestamp string, ok bool) {
+	ref = strings.Trim(strings.TrimSpace(ref), "<>")
+	if timestampPattern.MatchString(ref) {
+		return "", ref, true
+	}
+	if link, err := parsePermalink(ref); err == nil {
+		return link.Channel, link.Timestamp, true
+	}
+	return "", "", false
+}
@@ -0,0 +1,65 @@
+/*
+Copyright © 2024 Pavlo Tarasiuk <pasha.tarasyuk@gmail.com>
+*/
+package cmd
+
+import "testing"
+
+func TestPermalinkIsCached(t *testing.T) {
+	b, fake := newTestBot(t, nil)
+	fake.answer("chat.getPermalink", `{"ok":true,"channel":"C1","permalink":"https://x.slack.com/archives/C1/p1712345678000100"}`)
+
+	for i := 0; i < 3; i++ {
+		if got := b.permalink("C1", "1712345678.000100"); got != "https://x.slack.com/archives/C1/p1712345678000100" {
+			t.Fatalf("got permalink %q", got)
+		}
+	}
+	calls := fake.calls("chat.getPermalink")
+	if len(calls) != 1 {
+		t.Fatalf("got %d lookups, want the permalink fetched once", len(calls))
+	}
+	if calls[0].Form.Get("channel") != "C1" || calls[0].Form.Get("message_ts") != "1712345678.000100" {
+		t.Errorf("looked up %v", calls[0].Form)
+	}
+}
+
+func TestMessageLink(t *testing.T) {
+	tests := []struct {
+		name   string
+		answer string
+		want   string
+	}{
+		{
+			name:   "linked",
+			answer: `{"ok":true,"permalink":"https://x.slack.com/archives/C1/p1712345678000100"}`,
+			want:   "<https://x.slack.com/archives/C1/p1712345678000100|the message>",
+		},
+		{
+			name:   "lookup fails",
+			answer: `{"ok":false,"error":"message_not_found"}`,
+			want:   "the message",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b, fake := newTestBot(t, nil)
+			fake.answer("chat.getPermalink", tt.answer)
+
+			if got := b.messageLink("C1", "1712345678.000100", "the message"); got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPermalinkFailureIsNotCached(t *testing.T) {
+	b, fake := newTestBot(t, nil)
+	fake.answer("chat.getPermalink", `{"ok":false,"error":"ratelimited"}`)
+	if got := b.permalink("C1", "1712345678.000100"); got != "" {
+		t.Fatalf("got %q for a failed lookup", got)
+	}
+	fake.answer("chat.getPermalink", `{"ok":true,"permalink":"https://x.slack.com/archives/C1/p1712345678000100"}`)
+	if got := b.permalink("C1", "1712345678.000100"); got == "" {
+		t.Errorf("the failed lookup was cached")
+	}
+}
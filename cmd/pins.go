@@ -0,0 +1,69 @@
+/*
+Copyright © 2024 Pavlo Tarasiuk <pasha.tarasyuk@gmail.com>
+*/
+package cmd
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/slack-go/slack"
+	"github.com/slack-go/slack/slackevents"
+)
+
+// collectionPins holds the pinned messages tracked for documentation
+const collectionPins = "pins"
+
+// pinnedMessage is a reference to a message pinned in a channel
+type pinnedMessage struct {
+	Channel   string    `json:"channel"`
+	Timestamp string    `json:"ts"`
+	Author    string    `json:"author,omitempty"`
+	PinnedBy  string    `json:"pinned_by"`
+	PinnedAt  time.Time `json:"pinned_at"`
+}
+
+// pinKey identifies a pinned message in the Store
+func pinKey(channelID, timestamp string) string {
+	return channelID + "/" + timestamp
+}
+
+// handlePinAdded records the pinned message and, when enabled, confirms it in the message's thread
+func (b *Bot) handlePinAdded(event *slackevents.PinAddedEvent) error {
+	// Only messages are tracked, pinned files have no thread to document
+	if event.Item.Message == nil {
+		return nil
+	}
+
+	pin := pinnedMessage{
+		Channel:   event.Channel,
+		Timestamp: event.Item.Message.Timestamp,
+		Author:    event.Item.Message.User,
+		PinnedBy:  event.User,
+		PinnedAt:  b.now(),
+	}
+	if err := b.store.Put(collectionPins, pinKey(pin.Channel, pin.Timestamp), pin); err != nil {
+		return fmt.Errorf("failed to record pin: %w", err)
+	}
+
+	if !b.features.enabled(featurePinConfirmations) {
+		return nil
+	}
+	_, err := b.postMessage(outboundMessage{
+		Channel: pin.Channel,
+		Text:    ":pushpin: Pinned message recorded for documentation",
+		Options: []slack.MsgOption{slack.MsgOptionTS(pin.Timestamp)},
+	})
+	return err
+}
+
+// handlePinRemoved forgets the unpinned message
+func (b *Bot) handlePinRemoved(event *slackevents.PinRemovedEvent) error {
+	if event.Item.Message == nil {
+		return nil
+	}
+	if err := b.store.Delete(collectionPins, pinKey(event.Channel, event.Item.Message.Timestamp)); err != nil {
+		return fmt.Errorf("failed to remove pin: %w", err)
+	}
+	return nil
+}
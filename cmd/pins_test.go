@@ -0,0 +1,78 @@
+/*
+Copyright © 2024 Pavlo Tarasiuk <pasha.tarasyuk@gmail.com>
+*/
+package cmd
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/slack-go/slack/slackevents"
+)
+
+func pinEvent(kind string) interface{} {
+	item := slackevents.Item{Type: "message", Message: &slackevents.ItemMessage{Timestamp: "1712345678.000100", User: "U0AUTHOR"}}
+	if kind == "pin_removed" {
+		return &slackevents.PinRemovedEvent{Type: kind, User: "U0PINNER", Channel: "C1", Item: item}
+	}
+	return &slackevents.PinAddedEvent{Type: kind, User: "U0PINNER", Channel: "C1", Item: item}
+}
+
+func TestPinEvents(t *testing.T) {
+	b, fake := newTestBot(t, nil)
+	now := time.Date(2024, 4, 5, 12, 0, 0, 0, time.UTC)
+	b.now = func() time.Time { return now }
+	key := pinKey("C1", "1712345678.000100")
+
+	if err := b.handleEventMessage(context.Background(), callbackEvent("pin_added", pinEvent("pin_added"))); err != nil {
+		t.Fatalf("pin_added failed: %v", err)
+	}
+	var pin pinnedMessage
+	if ok, err := b.store.Get(collectionPins, key, &pin); err != nil || !ok {
+		t.Fatalf("the pin wasn't recorded: %v", err)
+	}
+	want := pinnedMessage{Channel: "C1", Timestamp: "1712345678.000100", Author: "U0AUTHOR", PinnedBy: "U0PINNER", PinnedAt: now}
+	if pin != want {
+		t.Errorf("recorded %+v, want %+v", pin, want)
+	}
+	if posts := fake.posts(); len(posts) != 0 {
+		t.Errorf("confirmed the pin with confirmations off: %q", posts)
+	}
+
+	if err := b.handleEventMessage(context.Background(), callbackEvent("pin_removed", pinEvent("pin_removed"))); err != nil {
+		t.Fatalf("pin_removed failed: %v", err)
+	}
+	if ok, _ := b.store.Get(collectionPins, key, &pin); ok {
+		t.Errorf("the pin is still recorded after pin_removed")
+	}
+}
+
+func TestPinConfirmation(t *testing.T) {
+	cfg := testConfig(t)
+	cfg.PinConfirmations = true
+	b, fake := newTestBot(t, cfg)
+
+	if err := b.handleEventMessage(context.Background(), callbackEvent("pin_added", pinEvent("pin_added"))); err != nil {
+		t.Fatalf("pin_added failed: %v", err)
+	}
+	calls := fake.calls("chat.postMessage")
+	if len(calls) != 1 {
+		t.Fatalf("got %d posts, want a confirmation", len(calls))
+	}
+	if calls[0].Form.Get("channel") != "C1" || calls[0].Form.Get("thread_ts") != "1712345678.000100" {
+		t.Errorf("confirmed in %s/%s, want the pinned message's thread", calls[0].Form.Get("channel"), calls[0].Form.Get("thread_ts"))
+	}
+}
+
+func TestPinnedFileIsIgnored(t *testing.T) {
+	b, _ := newTestBot(t, nil)
+	event := &slackevents.PinAddedEvent{Type: "pin_added", User: "U1", Channel: "C1", Item: slackevents.Item{Type: "file"}}
+
+	if err := b.handlePinAdded(event); err != nil {
+		t.Fatalf("pin_added failed: %v", err)
+	}
+	if keys, _ := b.store.Keys(collectionPins); len(keys) != 0 {
+		t.Errorf("recorded pins %v for a file", keys)
+	}
+}
@@ -0,0 +1,247 @@
+/*
+Copyright © 2024 Pavlo Tarasiuk <pasha.tarasyuk@gmail.com>
+*/
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+	"time"
+
+	"github.com/slack-go/slack"
+)
+
+// Priorities of outbound messages
+const (
+	// priorityNormal messages wait in the outbound queue when the rate limit is reached
+	priorityNormal = iota
+	// priorityLow messages wait behind the others and are the first dropped when the queue is full
+	priorityLow
+	// priorityHigh messages, like errors and broadcasts, wait ahead of the others
+	priorityHigh
+)
+
+// outboundMessage is a message the bot is about to post
+type outboundMessage struct {
+	// Channel is the conversation the message is posted to
+	Channel string
+	// Invoker is the user whose action produced the message, if any
+	Invoker string
+	// Priority decides what happens to the message when the rate limit is reached
+	Priority int
+	// EphemeralTo is the user the message is shown to, empty posts it for everyone
+	EphemeralTo string
+	// PostAt schedules the message for later, the zero time posts it right away
+	PostAt time.Time
+	// ReceivedAt is when the bot received what the message answers, for the response time footer
+	ReceivedAt time.Time
+
+	Text        string
+	Attachments []slack.Attachment
+	Blocks      []slack.Block
+
+	// Options are passed to the Slack client as they are
+	Options []slack.MsgOption
+
+	// Delivered, when set, gets the error of the post once it went out or failed for good, also
+	// when it waited in the outbound queue. It must have room for the error, which isn't waited for.
+	Delivered chan<- error
+}
+
+// msgOptions converts the message into options for the Slack client
+func (msg outboundMessage) msgOptions() []slack.MsgOption {
+	var options []slack.MsgOption
+	if msg.Text != "" {
+		options = append(options, slack.MsgOptionText(msg.Text, false))
+	}
+	if len(msg.Attachments) > 0 {
+		options = append(options, slack.MsgOptionAttachments(msg.Attachments...))
+	}
+	if len(msg.Blocks) > 0 {
+		options = append(options, slack.MsgOptionBlocks(msg.Blocks...))
+	}
+	return append(options, msg.Options...)
+}
+
+// rank orders the messages waiting for the outbound rate limit, higher ranks are posted first
+func (msg outboundMessage) rank() int {
+	switch msg.Priority {
+	case priorityHigh:
+		return 2
+	case priorityLow:
+		return 0
+	}
+	return 1
+}
+
+// postMessage is the path every message posted by a handler takes to Slack.
+// Outbound policies are enforced here so handlers don't have to care about them.
+// It returns the timestamp of the posted message, which is empty when the message was queued, dropped or held back,
+// or the ID of a scheduled message. A message over the channel's limit fails with a *rateLimitedError.
+func (b *Bot) postMessage(msg outboundMessage) (string, error) {
+	// The handler posting the message ran out of time, its work is abandoned
+	if err := b.ctx.Err(); err != nil {
+		return "", fmt.Errorf("failed to post message to %s: %w", msg.Channel, err)
+	}
+	if err := b.applyBroadcastPolicy(&msg); err != nil {
+		return "", err
+	}
+	if err := validateBlocks(msg.Blocks); err != nil {
+		return "", fmt.Errorf("invalid blocks: %w", err)
+	}
+	// A capturing bot only collects what it would post
+	if b.captured != nil {
+		b.captured.addMessage(msg)
+		return "", nil
+	}
+	// Without a working bot token nothing can be posted anymore
+	if b.tokenReload.isStopped() {
+		return "", errPostingStopped
+	}
+	// Messages posted during a grid migration go out once it has finished
+	if b.migration.hold(msg) {
+		return "", nil
+	}
+
+	// Ephemeral messages don't crowd the channel, so only the others count against its limit
+	if msg.EphemeralTo == "" {
+		if ok, wait := b.throttle.allow(msg.Channel); !ok {
+			log.Printf("channel rate limit reached, dropped message to %s\n", msg.Channel)
+			b.usage.recordLimited("channel", msg.Channel, wait)
+			return "", &rateLimitedError{Wait: wait}
+		}
+	}
+	b.addResponseTime(&msg)
+	// Over the outbound limit the message waits in the queue, behind those queued before it
+	if b.outbound != nil && (b.queue.hasDue(b.now()) || !b.outbound.tryTake()) {
+		b.usage.recordLimited("outbound", msg.Channel, b.outbound.retryAfter())
+		if !b.queue.push(msg, b.now()) {
+			log.Printf("outbound queue full, dropped message to %s\n", msg.Channel)
+		}
+		return "", nil
+	}
+	return b.send(&queuedPost{msg: msg})
+}
+
+// send posts the message to Slack, past the outbound policies postMessage enforces.
+// A post worth retrying goes back to the queue rather than being waited for, it returns
+// an empty timestamp then.
+func (b *Bot) send(item *queuedPost) (string, error) {
+	ts, err := b.trySend(item)
+	if item.msg.Delivered != nil && !b.queue.holds(item) {
+		select {
+		case item.msg.Delivered <- err:
+		default:
+		}
+	}
+	return ts, err
+}
+
+// postOptions returns the options the message is posted with, under the bot's display name
+func (b *Bot) postOptions(msg outboundMessage) []slack.MsgOption {
+	options := msg.msgOptions()
+	if name := b.displayName(); name != "" {
+		options = append(options, slack.MsgOptionUsername(name))
+	}
+	return options
+}
+
+// trySend makes one attempt at posting the message for send
+func (b *Bot) trySend(item *queuedPost) (string, error) {
+	msg := item.msg
+	options := b.postOptions(msg)
+
+	b.usage.recordCall()
+	if !msg.PostAt.IsZero() {
+		return b.scheduleMessage(msg.Channel, msg.PostAt, options)
+	}
+	post := func(client *slack.Client) (string, error) {
+		if msg.EphemeralTo != "" {
+			return client.PostEphemeralContext(b.ctx, msg.Channel, msg.EphemeralTo, options...)
+		}
+		_, ts, err := client.PostMessageContext(b.ctx, msg.Channel, options...)
+		return ts, err
+	}
+	client, member := b.poster()
+	ts, err := post(client)
+	if b.recoverToken(member, err) {
+		client = b.api()
+		ts, err = post(client)
+	}
+	// A short rate limit is waited out once, unless another client of the pool is free right away
+	var limited *slack.RateLimitedError
+	if errors.As(err, &limited) && limited.RetryAfter <= b.cfg.RateLimitRetryWait && !item.waited {
+		b.recordSlackLimited(msg.Channel, err)
+		b.clients.backOff(member, err)
+		next, nextMember := b.poster()
+		if nextMember == member {
+			log.Printf("Slack rate limited the post to %s, retrying in %s\n", msg.Channel, limited.RetryAfter)
+			item.waited = true
+			b.queue.requeue(item, b.now().Add(limited.RetryAfter))
+			return "", nil
+		}
+		member = nextMember
+		ts, err = post(next)
+	}
+	if isOverCapacity(err) {
+		if err := b.retryOverCapacity(item, err); err != nil {
+			return "", err
+		}
+		return "", nil
+	}
+	if isMsgTooLong(err) {
+		// The content still reaches the channel, just not as a message
+		return b.postAsSnippet(msg)
+	}
+	if err != nil {
+		b.recordSlackLimited(msg.Channel, err)
+		b.clients.backOff(member, err)
+		if msg.EphemeralTo != "" {
+			return "", fmt.Errorf("failed to post ephemeral message: %w", err)
+		}
+		return "", fmt.Errorf("failed to post message: %w", err)
+	}
+	return ts, nil
+}
+
+// displayName returns the username to post with, e.g. "MAVBot [staging]", or an empty string
+// to keep the bot's own name. Only non-production environments get a suffix; overriding the
+// name requires the chat:write.customize scope.
+func (b *Bot) displayName() string {
+	switch b.cfg.Environment {
+	case "", "prod", "production":
+		return ""
+	}
+	return fmt.Sprintf("%s [%s]", b.cfg.DisplayName, b.cfg.Environment)
+}
+
+// scheduleMessage schedules a message and returns its scheduled message ID, which slack-go
+// doesn't pass on, so it is looked up among the channel's messages scheduled for the time
+func (b *Bot) scheduleMessage(channelID string, postAt time.Time, options []slack.MsgOption) (string, error) {
+	at := strconv.FormatInt(postAt.Unix(), 10)
+	if _, _, err := b.api().ScheduleMessage(channelID, at, options...); err != nil {
+		b.recordSlackLimited(channelID, err)
+		return "", fmt.Errorf("failed to schedule message: %w", err)
+	}
+	scheduled, _, err := b.api().GetScheduledMessages(&slack.GetScheduledMessagesParameters{
+		Channel: channelID,
+		Oldest:  at,
+		Latest:  at,
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to find scheduled message: %w", err)
+	}
+	// The latest created one is ours when several are due at the same second
+	id, created := "", -1
+	for _, msg := range scheduled {
+		if msg.DateCreated > created {
+			id, created = msg.ID, msg.DateCreated
+		}
+	}
+	if id == "" {
+		return "", fmt.Errorf("failed to find scheduled message in %s at %s", channelID, at)
+	}
+	return id, nil
+}
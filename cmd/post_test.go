@@ -0,0 +1,38 @@
+/*
+Copyright © 2024 Pavlo Tarasiuk <pasha.tarasyuk@gmail.com>
+*/
+package cmd
+
+import "testing"
+
+func TestDisplayNameSuffix(t *testing.T) {
+	tests := []struct {
+		environment string
+		want        string
+	}{
+		{environment: "", want: ""},
+		{environment: "prod", want: ""},
+		{environment: "production", want: ""},
+		{environment: "staging", want: "MAVBot [staging]"},
+		{environment: "dev", want: "MAVBot [dev]"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.environment, func(t *testing.T) {
+			cfg := testConfig(t)
+			cfg.Environment = tt.environment
+			b, fake := newTestBot(t, cfg)
+
+			if _, err := b.postMessage(outboundMessage{Channel: "C1", Text: "hi"}); err != nil {
+				t.Fatalf("post failed: %v", err)
+			}
+			if _, err := b.postMessage(outboundMessage{Channel: "C1", EphemeralTo: "U1", Text: "psst"}); err != nil {
+				t.Fatalf("ephemeral post failed: %v", err)
+			}
+			for _, call := range fake.calls("chat.postMessage", "chat.postEphemeral") {
+				if got := call.Form.Get("username"); got != tt.want {
+					t.Errorf("%s posted as %q, want %q", call.Method, got, tt.want)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,148 @@
+/*
+Copyright © 2024 Pavlo Tarasiuk <pasha.tarasyuk@gmail.com>
+*/
+package cmd
+
+import (
+	"fmt"
+	"path"
+	"strings"
+	"time"
+)
+
+// invocation is what a predicate decides on: who triggered a handler and where
+type invocation struct {
+	UserID    string
+	ChannelID string
+}
+
+// predicate is a condition a handler declares, the handler only runs when all of its predicates pass
+type predicate func(b *Bot, inv invocation) (bool, error)
+
+// passes reports whether every predicate passes for the invocation
+func (b *Bot) passes(predicates []predicate, inv invocation) (bool, error) {
+	for _, p := range predicates {
+		ok, err := p(b, inv)
+		if err != nil || !ok {
+			return false, err
+		}
+	}
+	return true, nil
+}
+
+// allOf passes when every one of predicates does
+func allOf(predicates ...predicate) predicate {
+	return func(b *Bot, inv invocation) (bool, error) {
+		return b.passes(predicates, inv)
+	}
+}
+
+// anyOf passes when at least one of predicates does
+func anyOf(predicates ...predicate) predicate {
+	return func(b *Bot, inv invocation) (bool, error) {
+		for _, p := range predicates {
+			ok, err := p(b, inv)
+			if err != nil {
+				return false, err
+			}
+			if ok {
+				return true, nil
+			}
+		}
+		return false, nil
+	}
+}
+
+// not passes when p doesn't
+func not(p predicate) predicate {
+	return func(b *Bot, inv invocation) (bool, error) {
+		ok, err := p(b, inv)
+		return !ok && err == nil, err
+	}
+}
+
+// inChannels passes for invocations in one of the channels
+func inChannels(channelIDs ...string) predicate {
+	return func(b *Bot, inv invocation) (bool, error) {
+		return containsString(channelIDs, inv.ChannelID), nil
+	}
+}
+
+// channelMatches passes in channels whose name matches the glob pattern, e.g. "support-*"
+func channelMatches(pattern string) predicate {
+	return func(b *Bot, inv invocation) (bool, error) {
+		channel, err := b.channelInfo(inv.ChannelID)
+		if err != nil {
+			return false, err
+		}
+		return path.Match(pattern, channel.Name)
+	}
+}
+
+// privateChannel passes in private channels and DMs, see isPrivateChannel
+func privateChannel(b *Bot, inv invocation) (bool, error) {
+	return b.isPrivateChannel(inv.ChannelID)
+}
+
+// userInGroup passes for members of the user group, given by its handle or ID
+func userInGroup(group string) predicate {
+	return func(b *Bot, inv invocation) (bool, error) {
+		return b.inUserGroup(inv.UserID, group)
+	}
+}
+
+// timeWindow is a daily span of time given as offsets from midnight, End before Start wraps past midnight
+type timeWindow struct {
+	Start time.Duration
+	End   time.Duration
+}
+
+// parseTimeWindow parses a window like "09:00-17:00"
+func parseTimeWindow(s string) (timeWindow, error) {
+	from, to, ok := strings.Cut(s, "-")
+	if !ok {
+		return timeWindow{}, fmt.Errorf("invalid time window %q, expected e.g. 09:00-17:00", s)
+	}
+	var window timeWindow
+	for _, part := range []struct {
+		text string
+		dst  *time.Duration
+	}{{from, &window.Start}, {to, &window.End}} {
+		t, err := time.Parse("15:04", strings.TrimSpace(part.text))
+		if err != nil {
+			return timeWindow{}, fmt.Errorf("invalid time window %q, expected e.g. 09:00-17:00", s)
+		}
+		*part.dst = time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute
+	}
+	return window, nil
+}
+
+// contains reports whether the time of day of t falls into the window
+func (w timeWindow) contains(t time.Time) bool {
+	offset := time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute + time.Duration(t.Second())*time.Second
+	if w.Start <= w.End {
+		return offset >= w.Start && offset < w.End
+	}
+	return offset >= w.Start || offset < w.End
+}
+
+// String renders the window as it is configured
+func (w timeWindow) String() string {
+	midnight := time.Time{}
+	return midnight.Add(w.Start).Format("15:04") + "-" + midnight.Add(w.End).Format("15:04")
+}
+
+// withinHours passes while the bot's clock is inside the window in the configured timezone
+func withinHours(window timeWindow) predicate {
+	return func(b *Bot, inv invocation) (bool, error) {
+		return window.contains(b.localTime(b.now())), nil
+	}
+}
+
+// localTime returns t in the configured timezone, or unchanged when none is configured
+func (b *Bot) localTime(t time.Time) time.Time {
+	if loc := b.location(""); loc != nil {
+		return t.In(loc)
+	}
+	return t
+}
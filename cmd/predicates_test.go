@@ -0,0 +1,186 @@
+/*
+Copyright © 2024 Pavlo Tarasiuk <pasha.tarasyuk@gmail.com>
+*/
+package cmd
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/slack-go/slack"
+	"github.com/slack-go/slack/slackevents"
+)
+
+func TestParseTimeWindow(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    timeWindow
+		wantErr bool
+	}{
+		{in: "09:00-17:00", want: timeWindow{Start: 9 * time.Hour, End: 17 * time.Hour}},
+		{in: " 08:30 - 12:15 ", want: timeWindow{Start: 8*time.Hour + 30*time.Minute, End: 12*time.Hour + 15*time.Minute}},
+		{in: "22:00-06:00", want: timeWindow{Start: 22 * time.Hour, End: 6 * time.Hour}},
+		{in: "09:00", wantErr: true},
+		{in: "9am-5pm", wantErr: true},
+		{in: "09:00-25:00", wantErr: true},
+		{in: "", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := parseTimeWindow(tt.in)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("parseTimeWindow(%q) error = %v, want error %t", tt.in, err, tt.wantErr)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("parseTimeWindow(%q) = %+v, want %+v", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestTimeWindowContains(t *testing.T) {
+	day := timeWindow{Start: 9 * time.Hour, End: 17 * time.Hour}
+	night := timeWindow{Start: 22 * time.Hour, End: 6 * time.Hour}
+	at := func(hour, min int) time.Time { return time.Date(2024, 4, 5, hour, min, 0, 0, time.UTC) }
+	tests := []struct {
+		window timeWindow
+		t      time.Time
+		want   bool
+	}{
+		{day, at(9, 0), true},
+		{day, at(12, 30), true},
+		{day, at(16, 59), true},
+		{day, at(17, 0), false},
+		{day, at(8, 59), false},
+		{night, at(23, 0), true},
+		{night, at(2, 0), true},
+		{night, at(6, 0), false},
+		{night, at(12, 0), false},
+	}
+	for _, tt := range tests {
+		if got := tt.window.contains(tt.t); got != tt.want {
+			t.Errorf("%s contains %s = %t, want %t", tt.window, tt.t.Format("15:04"), got, tt.want)
+		}
+	}
+}
+
+func TestWithinHoursUsesTheClock(t *testing.T) {
+	b, _ := newTestBot(t, nil)
+	clock := newFakeClock()
+	b.now = clock.now
+	businessHours := withinHours(timeWindow{Start: 9 * time.Hour, End: 17 * time.Hour})
+
+	if ok, err := businessHours(b, invocation{}); !ok || err != nil {
+		t.Errorf("at 12:00 = %t, %v, want allowed", ok, err)
+	}
+	clock.advance(6 * time.Hour)
+	if ok, err := businessHours(b, invocation{}); ok || err != nil {
+		t.Errorf("at 18:00 = %t, %v, want blocked", ok, err)
+	}
+
+	// 18:00 UTC is 21:00 in Kyiv, 12:00 UTC is 15:00 there
+	b.cfg.Timezone = "Europe/Kyiv"
+	evening := withinHours(timeWindow{Start: 20 * time.Hour, End: 22 * time.Hour})
+	if ok, _ := evening(b, invocation{}); !ok {
+		t.Errorf("at 21:00 in Kyiv blocked, want allowed")
+	}
+	clock.advance(-6 * time.Hour)
+	if ok, _ := evening(b, invocation{}); ok {
+		t.Errorf("at 15:00 in Kyiv allowed, want blocked")
+	}
+}
+
+func TestCombinedPredicates(t *testing.T) {
+	b, _ := newTestBot(t, nil)
+	in := invocation{UserID: "U1", ChannelID: "C1"}
+	tests := []struct {
+		name string
+		p    predicate
+		want bool
+	}{
+		{"in channel", inChannels("C1", "C2"), true},
+		{"not in channel", inChannels("C2"), false},
+		{"all pass", allOf(inChannels("C1"), inChannels("C1", "C2")), true},
+		{"one of all fails", allOf(inChannels("C1"), inChannels("C2")), false},
+		{"none of all", allOf(), true},
+		{"one of any passes", anyOf(inChannels("C2"), inChannels("C1")), true},
+		{"none of any passes", anyOf(inChannels("C2"), inChannels("C3")), false},
+		{"not", not(inChannels("C2")), true},
+		{"not passing", not(inChannels("C1")), false},
+	}
+	for _, tt := range tests {
+		ok, err := tt.p(b, in)
+		if err != nil {
+			t.Errorf("%s: error = %v", tt.name, err)
+		}
+		if ok != tt.want {
+			t.Errorf("%s = %t, want %t", tt.name, ok, tt.want)
+		}
+	}
+}
+
+func TestCommandRunsOnlyWhenPredicatesPass(t *testing.T) {
+	b, _ := newTestBot(t, nil)
+	clock := newFakeClock()
+	b.now = clock.now
+	runs := 0
+	registerTestCommand(t, &slashCommand{
+		Name: "/test-office",
+		When: []predicate{withinHours(timeWindow{Start: 9 * time.Hour, End: 17 * time.Hour})},
+		Handler: func(*Bot, slack.SlashCommand) (*SlashResponse, error) {
+			runs++
+			return ephemeral("at your service"), nil
+		},
+	})
+	run := func() string {
+		t.Helper()
+		resp, err := b.dispatchSlashCommand(slack.SlashCommand{Command: "/test-office", UserID: "U1", ChannelID: "C1"})
+		if err != nil {
+			t.Fatalf("dispatchSlashCommand() error = %v", err)
+		}
+		return resp.Text
+	}
+
+	if got := run(); got != "at your service" {
+		t.Errorf("at 12:00 got %q, want the handler's answer", got)
+	}
+	clock.advance(8 * time.Hour)
+	if got, want := run(), "/test-office isn't available here at the moment"; got != want {
+		t.Errorf("at 20:00 got %q, want %q", got, want)
+	}
+	if runs != 1 {
+		t.Errorf("handler ran %d times, want only within the hours", runs)
+	}
+}
+
+func TestMentionFallsThroughOutsideHours(t *testing.T) {
+	cfg := testConfig(t)
+	cfg.MentionHours = &timeWindow{Start: 9 * time.Hour, End: 11 * time.Hour}
+	b, fake := newTestBot(t, cfg)
+	clock := newFakeClock()
+	b.now = clock.now
+	fake.answer("users.info", `{"ok":true,"user":{"id":"U1","name":"pasha"}}`)
+
+	err := b.handleAppMentionEvent(&slackevents.AppMentionEvent{User: "U1", Channel: "C1", Text: "<@U0BOT> hello", TimeStamp: "1712345678.000100"})
+	if err != nil {
+		t.Fatalf("mention failed: %v", err)
+	}
+	notes := fake.calls("chat.postEphemeral")
+	if len(notes) != 1 || notes[0].Form.Get("text") != "I answer mentions between 09:00-11:00, please try again then" {
+		t.Fatalf("at 12:00 got %v, want the after-hours note", fake.posts())
+	}
+
+	clock.advance(-2 * time.Hour)
+	err = b.handleAppMentionEvent(&slackevents.AppMentionEvent{User: "U1", Channel: "C1", Text: "<@U0BOT> hello", TimeStamp: "1712345678.000200"})
+	if err != nil {
+		t.Fatalf("mention failed: %v", err)
+	}
+	for _, text := range fake.posts()[1:] {
+		if strings.Contains(text, "I answer mentions between") {
+			t.Errorf("at 10:00 got the after-hours note, want the greeting")
+		}
+	}
+	if got := len(fake.posts()); got < 2 {
+		t.Errorf("at 10:00 nothing was posted, want the greeting")
+	}
+}
@@ -0,0 +1,85 @@
+/*
+Copyright © 2024 Pavlo Tarasiuk <pasha.tarasyuk@gmail.com>
+*/
+package cmd
+
+import (
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/slack-go/slack"
+)
+
+// collectionPrefs holds the preferences of each user, keyed by user ID
+const collectionPrefs = "prefs"
+
+// userPrefs are the settings a user chose for themselves
+type userPrefs struct {
+	// Locale is the language the bot talks to the user in
+	Locale string `json:"locale,omitempty"`
+	// NoNudges opts the user out of the reminders /nudge sends
+	NoNudges bool `json:"no_nudges,omitempty"`
+}
+
+// userPrefs returns the user's preferences, empty when they never set any
+func (b *Bot) userPrefs(userID string) userPrefs {
+	var prefs userPrefs
+	if _, err := b.store.Get(collectionPrefs, userID, &prefs); err != nil {
+		log.Printf("failed to load prefs of %s: %v\n", userID, err)
+	}
+	return prefs
+}
+
+// userLocale returns the language to talk to the user in
+func (b *Bot) userLocale(userID string) string {
+	if prefs := b.userPrefs(userID); prefs.Locale != "" {
+		return prefs.Locale
+	}
+	return b.cfg.DefaultLocale
+}
+
+// handlePrefs shows or changes the invoking user's preferences:
+// /prefs, /prefs locale <language> or /prefs nudges on|off
+func (b *Bot) handlePrefs(command slack.SlashCommand) (*SlashResponse, error) {
+	args := strings.Fields(command.Text)
+	prefs := b.userPrefs(command.UserID)
+
+	if len(args) == 0 {
+		nudges := "on"
+		if prefs.NoNudges {
+			nudges = "off"
+		}
+		return ephemeral(fmt.Sprintf("Your language: %s\nReminders when you've been away: %s", b.userLocale(command.UserID), nudges)), nil
+	}
+	if len(args) == 2 && args[0] == "nudges" && (args[1] == "on" || args[1] == "off") {
+		prefs.NoNudges = args[1] == "off"
+		if err := b.store.Put(collectionPrefs, command.UserID, prefs); err != nil {
+			return nil, fmt.Errorf("failed to save prefs: %w", err)
+		}
+		return ephemeral(fmt.Sprintf("Reminders when you've been away are now %s", args[1])), nil
+	}
+	if args[0] != "locale" || len(args) != 2 {
+		return ephemeral(usageOf("/prefs")), nil
+	}
+
+	if !b.messages().has(args[1]) {
+		return ephemeral(fmt.Sprintf("Unknown language %q, available: %s", args[1], strings.Join(b.messages().list(), ", "))), nil
+	}
+	prefs.Locale = args[1]
+	if err := b.store.Put(collectionPrefs, command.UserID, prefs); err != nil {
+		return nil, fmt.Errorf("failed to save prefs: %w", err)
+	}
+	return ephemeral(fmt.Sprintf("Your language is now %s", prefs.Locale)), nil
+}
+
+func init() {
+	registerSlashCommand(&slashCommand{
+		Name:        "/prefs",
+		Description: "Show or change your preferences, like the language MAVBot talks to you in",
+		Usage:       "[locale <language> | nudges on|off]",
+		Example:     "/prefs locale uk",
+		Category:    categoryPersonal,
+		Handler:     (*Bot).handlePrefs,
+	})
+}
@@ -0,0 +1,62 @@
+/*
+Copyright © 2024 Pavlo Tarasiuk <pasha.tarasyuk@gmail.com>
+*/
+package cmd
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/slack-go/slack"
+)
+
+// previewMessage builds the message the bot would post when replying to the user in the channel
+// with the template, the same way a reply to a mention is built
+func (b *Bot) previewMessage(user *slack.User, channelID, lang, name string) (slack.Attachment, error) {
+	text, err := b.messages().render(lang, name, b.mentionData(user, channelID))
+	if err != nil {
+		return slack.Attachment{}, err
+	}
+	return b.mentionReply(user).Text(text).Style(name).Build(), nil
+}
+
+// handlePreview shows the admin a template rendered for them in the channel, looking just as
+// it would when posted, without posting it: /preview <template> [language]
+func (b *Bot) handlePreview(command slack.SlashCommand) (*SlashResponse, error) {
+	args := strings.Fields(command.Text)
+	if len(args) == 0 || len(args) > 2 {
+		return ephemeral(usageOf("/preview")), nil
+	}
+	name, lang := args[0], b.userLocale(command.UserID)
+	if len(args) == 2 {
+		lang = args[1]
+	}
+	if !b.messages().defines(lang, name) {
+		return ephemeral(fmt.Sprintf("There is no template %q in the %q catalog, available: %s",
+			name, lang, strings.Join(b.messages().names(lang), ", "))), nil
+	}
+
+	user, err := b.userInfo(command.UserID)
+	if err != nil {
+		return nil, err
+	}
+	attachment, err := b.previewMessage(user, command.ChannelID, lang, name)
+	if err != nil {
+		return ephemeral(fmt.Sprintf("Template error: %v", err)), nil
+	}
+	response := ephemeral(fmt.Sprintf("Preview of *%s* (%s), only you can see it:", name, lang))
+	response.Attachments = []slack.Attachment{attachment}
+	return response, nil
+}
+
+func init() {
+	registerSlashCommand(&slashCommand{
+		Name:        "/preview",
+		Description: "Preview a message template as the bot would post it here, visible to you only",
+		Usage:       "<template> [language]",
+		Example:     "/preview greeting uk",
+		Category:    categoryAdmin,
+		AdminOnly:   true,
+		Handler:     (*Bot).handlePreview,
+	})
+}
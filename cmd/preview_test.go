@@ -0,0 +1,112 @@
+/*
+Copyright © 2024 Pavlo Tarasiuk <pasha.tarasyuk@gmail.com>
+*/
+package cmd
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/slack-go/slack"
+	"github.com/slack-go/slack/slackevents"
+)
+
+// runPreview runs /preview as the admin in the support channel and returns the answer
+func runPreview(t *testing.T, b *Bot, text string) *SlashResponse {
+	t.Helper()
+	resp, err := b.handlePreview(slack.SlashCommand{Text: text, UserID: "U0ADMIN", ChannelID: "C1"})
+	if err != nil {
+		t.Fatalf("/preview failed: %v", err)
+	}
+	if resp.ResponseType != slack.ResponseTypeEphemeral {
+		t.Errorf("answered in the channel, want ephemerally")
+	}
+	return resp
+}
+
+func TestPreviewMatchesPostedReply(t *testing.T) {
+	tests := []struct {
+		template string
+		// mention is what the admin says to get the template posted
+		mention string
+	}{
+		{template: templateGreeting, mention: "<@U0BOT> hello"},
+		{template: templateHelpOffer, mention: "<@U0BOT> are you there?"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.template, func(t *testing.T) {
+			b, fake := newTestBot(t, nil)
+			b.now = newFakeClock().now
+			fake.answer("users.info", `{"ok":true,"user":{"id":"U0ADMIN","name":"pasha","tz":"Europe/Kyiv"}}`)
+			fake.answer("conversations.info", `{"ok":true,"channel":{"id":"C1","name":"help","purpose":{"value":"support for the fleet"}}}`)
+
+			resp := runPreview(t, b, tt.template+" en")
+			if len(resp.Attachments) != 1 {
+				t.Fatalf("previewed %d attachments, want 1", len(resp.Attachments))
+			}
+			if got := len(fake.calls("chat.postMessage", "chat.postEphemeral")); got != 0 {
+				t.Fatalf("the preview posted %d messages", got)
+			}
+			preview, _ := json.Marshal(resp.Attachments)
+
+			err := b.handleAppMentionEvent(&slackevents.AppMentionEvent{User: "U0ADMIN", Channel: "C1", Text: tt.mention, TimeStamp: "1712345678.000100"})
+			if err != nil {
+				t.Fatalf("mention failed: %v", err)
+			}
+			posts := fake.calls("chat.postMessage")
+			if len(posts) != 1 {
+				t.Fatalf("posted %d replies, want 1", len(posts))
+			}
+			if posted := posts[0].Form.Get("attachments"); posted != string(preview) {
+				t.Errorf("posted %s, previewed %s", posted, preview)
+			}
+			if tt.template == templateGreeting && !strings.Contains(string(preview), "sorry you're having trouble") {
+				t.Errorf("previewed %s, want the greeting tailored to the support channel", preview)
+			}
+		})
+	}
+}
+
+func TestPreviewLanguage(t *testing.T) {
+	b, fake := newTestBot(t, nil)
+	fake.answer("users.info", `{"ok":true,"user":{"id":"U0ADMIN","name":"pasha"}}`)
+	resp := runPreview(t, b, "help_offer uk")
+	if want := "Preview of *help_offer* (uk), only you can see it:"; resp.Text != want {
+		t.Errorf("answered %q, want %q", resp.Text, want)
+	}
+	if got, want := resp.Attachments[0].Text, "Чим я можу допомогти, pasha?"; got != want {
+		t.Errorf("previewed %q, want %q", got, want)
+	}
+}
+
+func TestPreviewRefusals(t *testing.T) {
+	b, _ := newTestBot(t, nil)
+	tests := []struct {
+		text string
+		want string
+	}{
+		{text: "", want: usageOf("/preview")},
+		{text: "greeting en extra", want: usageOf("/preview")},
+		{text: "farewell en", want: `There is no template "farewell" in the "en" catalog, available: `},
+	}
+	for _, tt := range tests {
+		resp := runPreview(t, b, tt.text)
+		if !strings.HasPrefix(resp.Text, tt.want) || len(resp.Attachments) != 0 {
+			t.Errorf("/preview %s answered %q, want %q", tt.text, resp.Text, tt.want)
+		}
+	}
+}
+
+func TestPreviewAdminOnly(t *testing.T) {
+	cfg := testConfig(t)
+	cfg.Admins = []string{"U0ADMIN"}
+	b, _ := newTestBot(t, cfg)
+	resp, err := b.dispatchSlashCommand(slack.SlashCommand{Command: "/preview", Text: "greeting", UserID: "U1", ChannelID: "C1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.Text != "Sorry, this command is available to MAVBot admins only" {
+		t.Errorf("answered %q, want the command refused", resp.Text)
+	}
+}
@@ -0,0 +1,73 @@
+/*
+Copyright © 2024 Pavlo Tarasiuk <pasha.tarasyuk@gmail.com>
+*/
+package cmd
+
+import (
+	"log"
+	"sync"
+
+	"github.com/slack-go/slack"
+)
+
+// Reactions reflecting the state of a long running operation
+const (
+	reactionInProgress = "hourglass"
+	reactionSucceeded  = "white_check_mark"
+	reactionFailed     = "x"
+)
+
+// progress reflects the state of a long running operation with a reaction on the message that triggered it
+type progress struct {
+	bot  *Bot
+	item slack.ItemRef
+	once sync.Once
+}
+
+// startProgress marks the message with the in-progress reaction
+func (b *Bot) startProgress(channelID, timestamp string) *progress {
+	p := &progress{
+		bot:  b,
+		item: slack.NewRefToMessage(channelID, timestamp),
+	}
+	p.react(reactionInProgress)
+	return p
+}
+
+// done replaces the in-progress reaction with the success or failure one depending on err.
+// The final reaction is added before the hourglass is removed, so a failure half way
+// through still leaves the message with a status. Calls after the first are ignored.
+func (p *progress) done(err error) {
+	p.once.Do(func() {
+		if err != nil {
+			p.react(reactionFailed)
+		} else {
+			p.react(reactionSucceeded)
+		}
+		p.unreact(reactionInProgress)
+	})
+}
+
+// withProgress runs op while the triggering message shows its progress
+func (b *Bot) withProgress(channelID, timestamp string, op func() error) error {
+	p := b.startProgress(channelID, timestamp)
+	err := op()
+	p.done(err)
+	return err
+}
+
+// react adds the reaction, treating an existing one as success
+func (p *progress) react(name string) {
+	err := p.bot.api().AddReaction(name, p.item)
+	if err != nil && err.Error() != "already_reacted" {
+		log.Printf("failed to add %s reaction: %v\n", name, err)
+	}
+}
+
+// unreact removes the reaction, treating a missing one as success
+func (p *progress) unreact(name string) {
+	err := p.bot.api().RemoveReaction(name, p.item)
+	if err != nil && err.Error() != "no_reaction" {
+		log.Printf("failed to remove %s reaction: %v\n", name, err)
+	}
+}
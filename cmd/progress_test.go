@@ -0,0 +1,65 @@
+/*
+Copyright © 2024 Pavlo Tarasiuk <pasha.tarasyuk@gmail.com>
+*/
+package cmd
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestWithProgressReactions(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want []string
+	}{
+		{
+			name: "success",
+			want: []string{"reactions.add hourglass", "reactions.add white_check_mark", "reactions.remove hourglass"},
+		},
+		{
+			name: "failure",
+			err:  errors.New("boom"),
+			want: []string{"reactions.add hourglass", "reactions.add x", "reactions.remove hourglass"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b, fake := newTestBot(t, nil)
+
+			err := b.withProgress("C1", "1712345678.000100", func() error { return tt.err })
+			if err != tt.err {
+				t.Errorf("got error %v, want %v", err, tt.err)
+			}
+
+			var got []string
+			for _, call := range fake.calls("reactions.add", "reactions.remove") {
+				if call.Form.Get("channel") != "C1" || call.Form.Get("timestamp") != "1712345678.000100" {
+					t.Errorf("%s reacted to %s/%s", call.Method, call.Form.Get("channel"), call.Form.Get("timestamp"))
+				}
+				got = append(got, call.Method+" "+call.Form.Get("name"))
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got reactions %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestProgressDoneOnce(t *testing.T) {
+	b, fake := newTestBot(t, nil)
+	fake.answer("reactions.remove", `{"ok":false,"error":"no_reaction"}`)
+
+	p := b.startProgress("C1", "1712345678.000100")
+	p.done(nil)
+	p.done(errors.New("late"))
+
+	if got := len(fake.calls("reactions.add")); got != 2 {
+		t.Errorf("got %d reactions added, want the hourglass and a single final one", got)
+	}
+	if got := len(fake.calls("reactions.remove")); got != 1 {
+		t.Errorf("got %d reactions removed, want 1", got)
+	}
+}
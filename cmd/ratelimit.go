@@ -0,0 +1,113 @@
+/*
+Copyright © 2024 Pavlo Tarasiuk <pasha.tarasyuk@gmail.com>
+*/
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// tokenBucket limits how often something may happen. It holds up to capacity tokens
+// and regains them at a steady rate; every event spends one token.
+type tokenBucket struct {
+	mu       sync.Mutex
+	capacity float64
+	tokens   float64
+	// rate is the number of tokens regained per second
+	rate float64
+	last time.Time
+
+	now func() time.Time
+}
+
+// newTokenBucket creates a full bucket allowing perMinute events per minute, measuring time with now
+func newTokenBucket(perMinute int, now func() time.Time) *tokenBucket {
+	return &tokenBucket{
+		capacity: float64(perMinute),
+		tokens:   float64(perMinute),
+		rate:     float64(perMinute) / 60,
+		last:     now(),
+		now:      now,
+	}
+}
+
+// refill adds the tokens regained since the last call. Callers must hold t.mu.
+func (t *tokenBucket) refill() {
+	now := t.now()
+	t.tokens += now.Sub(t.last).Seconds() * t.rate
+	if t.tokens > t.capacity {
+		t.tokens = t.capacity
+	}
+	t.last = now
+}
+
+// tryTake spends a token if one is available and reports whether it did
+func (t *tokenBucket) tryTake() bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.refill()
+	if t.tokens < 1 {
+		return false
+	}
+	t.tokens--
+	return true
+}
+
+// take spends a token, waiting until one is available, and reports whether it did before ctx was done
+func (t *tokenBucket) take(ctx context.Context) bool {
+	for !t.tryTake() {
+		timer := time.NewTimer(t.retryAfter())
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return false
+		case <-timer.C:
+		}
+	}
+	return true
+}
+
+// retryAfter returns how long until a token is available, zero when one is
+func (t *tokenBucket) retryAfter() time.Duration {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.refill()
+	if t.tokens >= 1 {
+		return 0
+	}
+	return time.Duration((1 - t.tokens) / t.rate * float64(time.Second))
+}
+
+// rateLimitedError is returned when a user's action was turned down by a rate limit
+type rateLimitedError struct {
+	// Wait is how long until the action is allowed again, zero when unknown
+	Wait time.Duration
+}
+
+// Error implements error
+func (e *rateLimitedError) Error() string {
+	if e.Wait == 0 {
+		return "rate limited"
+	}
+	return fmt.Sprintf("rate limited, retry in %s", e.Wait)
+}
+
+// formatWait renders a wait for users, rounded up to whole seconds, or an empty string when unknown
+func formatWait(d time.Duration) string {
+	if d <= 0 {
+		return ""
+	}
+	return (d + time.Second - 1).Truncate(time.Second).String()
+}
+
+// rateLimitedResponse tells the user in their language that they hit a rate limit and how long to wait
+func (b *Bot) rateLimitedResponse(userID string, wait time.Duration) (*SlashResponse, error) {
+	text, err := b.render(userID, templateRateLimited, templateData{Wait: formatWait(wait)})
+	if err != nil {
+		return nil, err
+	}
+	return ephemeral(text), nil
+}
@@ -0,0 +1,233 @@
+/*
+Copyright © 2024 Pavlo Tarasiuk <pasha.tarasyuk@gmail.com>
+*/
+package cmd
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/slack-go/slack"
+	"github.com/slack-go/slack/slackevents"
+)
+
+// fakeClock is a clock tests move by hand
+type fakeClock struct {
+	t time.Time
+}
+
+func newFakeClock() *fakeClock {
+	return &fakeClock{t: time.Date(2024, 4, 5, 12, 0, 0, 0, time.UTC)}
+}
+
+func (c *fakeClock) now() time.Time {
+	return c.t
+}
+
+func (c *fakeClock) advance(d time.Duration) {
+	c.t = c.t.Add(d)
+}
+
+func TestTokenBucketRate(t *testing.T) {
+	tests := []struct {
+		perMinute int
+		// refill is how long a token takes to come back
+		refill time.Duration
+	}{
+		{perMinute: 1, refill: time.Minute},
+		{perMinute: 6, refill: 10 * time.Second},
+		{perMinute: 60, refill: time.Second},
+	}
+	for _, tt := range tests {
+		clock := newFakeClock()
+		bucket := newTokenBucket(tt.perMinute, clock.now)
+
+		for i := 0; i < tt.perMinute; i++ {
+			if !bucket.tryTake() {
+				t.Fatalf("%d/min: token %d refused with a full bucket", tt.perMinute, i+1)
+			}
+		}
+		if bucket.tryTake() {
+			t.Fatalf("%d/min: took more tokens than the bucket holds", tt.perMinute)
+		}
+		if got := bucket.retryAfter(); got != tt.refill {
+			t.Errorf("%d/min: retry after %s, want %s", tt.perMinute, got, tt.refill)
+		}
+		clock.advance(tt.refill - time.Millisecond)
+		if bucket.tryTake() {
+			t.Errorf("%d/min: a token came back early", tt.perMinute)
+		}
+		clock.advance(time.Millisecond)
+		if !bucket.tryTake() {
+			t.Errorf("%d/min: no token after %s", tt.perMinute, tt.refill)
+		}
+		// A long pause doesn't save up more than a full bucket
+		clock.advance(time.Hour)
+		taken := 0
+		for bucket.tryTake() {
+			taken++
+		}
+		if taken != tt.perMinute {
+			t.Errorf("%d/min: took %d tokens after a pause, want the capacity", tt.perMinute, taken)
+		}
+	}
+}
+
+func TestTokenBucketTakeWaits(t *testing.T) {
+	bucket := newTokenBucket(1200, time.Now)
+	for bucket.tryTake() {
+	}
+
+	start := time.Now()
+	for i := 0; i < 2; i++ {
+		if !bucket.take(context.Background()) {
+			t.Fatalf("take() gave up without a deadline")
+		}
+	}
+	// A token is regained every 50ms at 1200/min
+	if waited := time.Since(start); waited < 90*time.Millisecond {
+		t.Errorf("waited %s for 2 tokens at 1200/min, want about 100ms", waited)
+	}
+}
+
+func TestTokenBucketTakeStopsWithContext(t *testing.T) {
+	bucket := newTokenBucket(1, time.Now)
+	bucket.tryTake()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	if bucket.take(ctx) {
+		t.Errorf("take() spent a token that isn't there")
+	}
+	if waited := time.Since(start); waited > time.Second {
+		t.Errorf("take() waited %s for the next token, want it to stop with the context", waited)
+	}
+}
+
+func TestOutboundBudgetHoldsPosts(t *testing.T) {
+	cfg := testConfig(t)
+	cfg.OutboundPerMinute = 2
+	b, fake := newTestBot(t, cfg)
+	clock := newFakeClock()
+	b.now = clock.now
+	b.outbound = newTokenBucket(cfg.OutboundPerMinute, clock.now)
+
+	for i := 0; i < 3; i++ {
+		if _, err := b.postMessage(outboundMessage{Channel: "C1", Text: "hi"}); err != nil {
+			t.Fatalf("post %d failed: %v", i+1, err)
+		}
+	}
+	if got := len(fake.calls("chat.postMessage")); got != 2 {
+		t.Errorf("posted %d messages right away, want the budget of 2", got)
+	}
+	if got := b.queue.len(); got != 1 {
+		t.Errorf("%d messages wait for the budget, want 1", got)
+	}
+}
+
+func TestFormatWait(t *testing.T) {
+	tests := []struct {
+		wait time.Duration
+		want string
+	}{
+		{0, ""},
+		{-time.Second, ""},
+		{1500 * time.Millisecond, "2s"},
+		{20 * time.Second, "20s"},
+		{90 * time.Second, "1m30s"},
+	}
+	for _, tt := range tests {
+		if got := formatWait(tt.wait); got != tt.want {
+			t.Errorf("formatWait(%s) = %q, want %q", tt.wait, got, tt.want)
+		}
+	}
+}
+
+func TestRateLimitedResponse(t *testing.T) {
+	tests := []struct {
+		name       string
+		configured string
+		locale     string
+		wait       time.Duration
+		want       string
+	}{
+		{name: "default", wait: 20 * time.Second, want: "Slow down a little, I can't keep up. Please try again in 20s"},
+		{name: "unknown wait", want: "Slow down a little, I can't keep up. Please try again in a moment"},
+		{name: "configured", configured: "Easy there, try again in {{.Wait}}", wait: 1500 * time.Millisecond, want: "Easy there, try again in 2s"},
+		{name: "user's language", configured: "Easy there", locale: "uk", wait: 20 * time.Second, want: "Трохи повільніше, я не встигаю. Спробуйте ще раз через 20s"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := testConfig(t)
+			cfg.RateLimitMessage = tt.configured
+			b, _ := newTestBot(t, cfg)
+			if tt.locale != "" {
+				if err := b.store.Put(collectionPrefs, "U1", userPrefs{Locale: tt.locale}); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			response, err := b.rateLimitedResponse("U1", tt.wait)
+			if err != nil {
+				t.Fatalf("rendering failed: %v", err)
+			}
+			if response.Text != tt.want || response.ResponseType != slack.ResponseTypeEphemeral {
+				t.Errorf("got %+v, want the ephemeral %q", response, tt.want)
+			}
+		})
+	}
+}
+
+func TestThrottledMentionGetsTheWait(t *testing.T) {
+	cfg := testConfig(t)
+	cfg.ChannelPerMinute = 1
+	cfg.RateLimitMessage = "Too many messages here, I'll be back in {{.Wait}}"
+	b, fake := newTestBot(t, cfg)
+	clock := newFakeClock()
+	b.now = clock.now
+	b.throttle = newChannelThrottle(cfg.ChannelPerMinute, nil, clock.now)
+	fake.answer("users.info", `{"ok":true,"user":{"id":"U1","name":"pasha"}}`)
+
+	for i := 0; i < 2; i++ {
+		err := b.handleAppMentionEvent(&slackevents.AppMentionEvent{User: "U1", Channel: "C1", Text: "<@U0BOT> hello", TimeStamp: "1712345678.000100"})
+		if err != nil {
+			t.Fatalf("mention failed: %v", err)
+		}
+		clock.advance(15 * time.Second)
+	}
+	notes := fake.calls("chat.postEphemeral")
+	if len(notes) != 1 {
+		t.Fatalf("got %d notes, want the throttled user told", len(notes))
+	}
+	if got := notes[0].Form.Get("text"); got != "Too many messages here, I'll be back in 45s" {
+		t.Errorf("got %q", got)
+	}
+}
+
+func TestConcurrencyLimitGetsTheConfiguredMessage(t *testing.T) {
+	release := make(chan struct{})
+	defer close(release)
+	started := make(chan struct{})
+	registerTestCommand(t, &slashCommand{
+		Name:          "/test-single",
+		MaxConcurrent: 1,
+		Handler: func(*Bot, slack.SlashCommand) (*SlashResponse, error) {
+			close(started)
+			<-release
+			return nil, nil
+		},
+	})
+	cfg := testConfig(t)
+	cfg.RateLimitMessage = "One at a time please{{if .Wait}}, wait {{.Wait}}{{end}}"
+	b, _ := newTestBot(t, cfg)
+	command := slack.SlashCommand{Command: "/test-single", UserID: "U1", ChannelID: "C1"}
+	go b.dispatchSlashCommand(command)
+	<-started
+
+	response, err := b.dispatchSlashCommand(command)
+	if err != nil || response.Text != "One at a time please" {
+		t.Errorf("got %+v, %v, want the configured message", response, err)
+	}
+}
@@ -0,0 +1,62 @@
+/*
+Copyright © 2024 Pavlo Tarasiuk <pasha.tarasyuk@gmail.com>
+*/
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/slack-go/slack"
+)
+
+// handleRenderTest renders a message template with sample data for the admin only:
+// /render-test <template> [language] [json]. The JSON fills templateData, e.g.
+// {"User": "Ivan", "Channel": {"Name": "support"}}.
+func (b *Bot) handleRenderTest(command slack.SlashCommand) (*SlashResponse, error) {
+	usage := usageOf("/render-test")
+	text := strings.TrimSpace(command.Text)
+	name, rest, _ := strings.Cut(text, " ")
+	if name == "" {
+		return ephemeral(usage), nil
+	}
+	rest = strings.TrimSpace(rest)
+	lang := b.userLocale(command.UserID)
+	if rest != "" && !strings.HasPrefix(rest, "{") {
+		lang, rest, _ = strings.Cut(rest, " ")
+		rest = strings.TrimSpace(rest)
+	}
+	if !b.messages().defines(lang, name) {
+		return ephemeral(fmt.Sprintf("There is no template %q in the %q catalog", name, lang)), nil
+	}
+
+	var data templateData
+	if rest != "" {
+		decoder := json.NewDecoder(bytes.NewReader([]byte(rest)))
+		// Misspelt fields would otherwise render as empty values without a hint
+		decoder.DisallowUnknownFields()
+		if err := decoder.Decode(&data); err != nil {
+			return ephemeral(fmt.Sprintf("Invalid sample data: %v", err)), nil
+		}
+	}
+
+	out, err := b.messages().render(lang, name, data)
+	if err != nil {
+		return ephemeral(fmt.Sprintf("Template error: %v", err)), nil
+	}
+	return ephemeral(fmt.Sprintf("Rendered *%s* (%s):\n>>> %s", name, lang, out)), nil
+}
+
+func init() {
+	registerSlashCommand(&slashCommand{
+		Name:        "/render-test",
+		Description: "Render a message template with sample data, visible to you only",
+		Usage:       "<template> [language] [json data]",
+		Example:     `/render-test greeting uk {"User": "Ivan", "Channel": {"Name": "support"}}`,
+		Category:    categoryAdmin,
+		AdminOnly:   true,
+		Handler:     (*Bot).handleRenderTest,
+	})
+}
@@ -0,0 +1,68 @@
+/*
+Copyright © 2024 Pavlo Tarasiuk <pasha.tarasyuk@gmail.com>
+*/
+package cmd
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/slack-go/slack"
+)
+
+func TestRenderTest(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		want string
+	}{
+		{
+			name: "sample data",
+			text: `greeting {"User": "Ivan", "Channel": {"Name": "support"}}`,
+			want: "Rendered *greeting* (en):\n>>> Hello Ivan, sorry you're having trouble.",
+		},
+		{
+			name: "other language",
+			text: `help_offer uk {"User": "Ivan"}`,
+			want: "Rendered *help_offer* (uk):\n>>> Чим я можу допомогти, Ivan?",
+		},
+		{name: "without data", text: "help_offer", want: "Rendered *help_offer* (en):\n>>> How can I help you "},
+		{name: "template error", text: `unknown_command {"Text": "/nope"}`, want: "Template error: failed to render unknown_command:"},
+		{name: "unknown template", text: "farewell", want: `There is no template "farewell" in the "en" catalog`},
+		{name: "misspelt field", text: `greeting {"Usr": "Ivan"}`, want: `Invalid sample data: json: unknown field "Usr"`},
+		{name: "broken JSON", text: `greeting {"User": `, want: "Invalid sample data:"},
+		{name: "no template", text: "", want: usageOf("/render-test")},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := testConfig(t)
+			// Renders empty data but fails on a short text, which neither parsing nor validation catches
+			cfg.UnknownCommandMessage = "{{if .Text}}{{index .Text 20}}{{end}} isn't a command"
+			b, fake := newTestBot(t, cfg)
+
+			response, err := b.handleRenderTest(slack.SlashCommand{Command: "/render-test", Text: tt.text, UserID: "U0ADMIN", ChannelID: "C1"})
+			if err != nil {
+				t.Fatalf("render test failed: %v", err)
+			}
+			if !strings.HasPrefix(response.Text, tt.want) {
+				t.Errorf("got %q, want it to start with %q", response.Text, tt.want)
+			}
+			if response.ResponseType == slack.ResponseTypeInChannel || len(fake.posts()) != 0 {
+				t.Errorf("the rendering was shown to the channel")
+			}
+		})
+	}
+}
+
+func TestRenderTestIsAdminOnly(t *testing.T) {
+	cfg := testConfig(t)
+	cfg.Admins = []string{"U0ADMIN"}
+	b, _ := newTestBot(t, cfg)
+	response, err := b.dispatchSlashCommand(slack.SlashCommand{Command: "/render-test", Text: "greeting", UserID: "U1", ChannelID: "C1"})
+	if err != nil {
+		t.Fatalf("dispatch failed: %v", err)
+	}
+	if !strings.Contains(response.Text, "admins only") {
+		t.Errorf("got %q, want the command refused", response.Text)
+	}
+}
@@ -0,0 +1,129 @@
+/*
+Copyright © 2024 Pavlo Tarasiuk <pasha.tarasyuk@gmail.com>
+*/
+package cmd
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+
+	"github.com/joho/godotenv"
+	"github.com/slack-go/slack"
+	"github.com/slack-go/slack/socketmode"
+	"github.com/spf13/cobra"
+)
+
+// replayCmd represents the replay command
+var replayCmd = &cobra.Command{
+	Use:   "replay <file>",
+	Short: "Feed recorded events through MAVBot without talking to Slack",
+	Long: `The replay command reads an event log written with MAVBOT_EVENT_LOG and processes
+	every event again against a dry-run Slack client, which logs the Web API calls instead of making them.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		godotenv.Load(".env")
+
+		cfg, err := loadConfig()
+		if err != nil {
+			return err
+		}
+		// Keep the replay from touching the real state
+		dataDir, err := os.MkdirTemp("", "mavbot-replay-")
+		if err != nil {
+			return err
+		}
+		defer os.RemoveAll(dataDir)
+		store, err := newFileStore(dataDir)
+		if err != nil {
+			return err
+		}
+		bot, err := newBot(newDryRunClient(), cfg, store)
+		if err != nil {
+			return err
+		}
+		bot.httpClient = &http.Client{Transport: dryRunTransport{}}
+		bot.canvases = newWebAPI(bot.httpClient, bot.client)
+		bot.links = newLinkProcessor(cfg.LinkProcessor, bot.httpClient)
+		bot.deadLetter = newFileDeadLetter(filepath.Join(dataDir, "dead-letter.jsonl"), bot.now)
+
+		file, err := os.Open(args[0])
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		go bot.runOutbound(ctx)
+		go bot.runLinks(ctx)
+		return bot.replay(ctx, file)
+	},
+}
+
+// replay processes every event recorded in r in order
+func (b *Bot) replay(ctx context.Context, r io.Reader) error {
+	scanner := bufio.NewScanner(r)
+	// Events with big payloads, e.g. modal submissions, don't fit the default buffer
+	scanner.Buffer(make([]byte, 0, 64*1024), 4*1024*1024)
+	for n := 1; scanner.Scan(); n++ {
+		if len(bytes.TrimSpace(scanner.Bytes())) == 0 {
+			continue
+		}
+		event, err := parseRecordedEvent(scanner.Bytes())
+		if err != nil {
+			return fmt.Errorf("line %d: %w", n, err)
+		}
+		log.Printf("Replaying %s event from line %d\n", event.Type, n)
+		b.processEvent(ctx, event, replayAcker{})
+	}
+	return scanner.Err()
+}
+
+// replayAcker logs acknowledgements instead of sending them
+type replayAcker struct{}
+
+// Ack implements acker
+func (replayAcker) Ack(req socketmode.Request, payload ...interface{}) {
+	if len(payload) == 0 {
+		log.Printf("dry-run: ack %s\n", req.EnvelopeID)
+		return
+	}
+	body, _ := json.Marshal(payload[0])
+	log.Printf("dry-run: ack %s with %s\n", req.EnvelopeID, body)
+}
+
+// newDryRunClient creates a Slack client whose Web API calls are logged and answered with a bare success
+func newDryRunClient() *slack.Client {
+	return slack.New("dry-run", slack.OptionHTTPClient(&http.Client{Transport: dryRunTransport{}}))
+}
+
+// dryRunTransport stands in for Slack's Web API, the responses carry no data
+type dryRunTransport struct{}
+
+// RoundTrip implements http.RoundTripper
+func (dryRunTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	var body []byte
+	if req.Body != nil {
+		body, _ = io.ReadAll(req.Body)
+		req.Body.Close()
+	}
+	log.Printf("dry-run: %s %s\n", path.Base(req.URL.Path), body)
+	return &http.Response{
+		StatusCode: http.StatusOK,
+		Header:     http.Header{"Content-Type": {"application/json"}},
+		Body:       io.NopCloser(bytes.NewReader([]byte(`{"ok":true}`))),
+		Request:    req,
+	}, nil
+}
+
+func init() {
+	rootCmd.AddCommand(replayCmd)
+}
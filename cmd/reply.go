@@ -0,0 +1,93 @@
+/*
+Copyright © 2024 Pavlo Tarasiuk <pasha.tarasyuk@gmail.com>
+*/
+package cmd
+
+import "github.com/slack-go/slack"
+
+// replyBuilder assembles the attachment handlers reply with, e.g.
+//
+//	b.reply().Text("Hello").Color("#4af030").Field(fieldInitializer, name).Build()
+type replyBuilder struct {
+	attachment slack.Attachment
+	fields     map[string]string
+	order      []string
+}
+
+// reply starts a reply whose fields follow the configured field order
+func (b *Bot) reply() *replyBuilder {
+	return &replyBuilder{
+		fields: make(map[string]string),
+		order:  b.cfg.FieldOrder,
+	}
+}
+
+// Title sets the bold title of the attachment
+func (r *replyBuilder) Title(title string) *replyBuilder {
+	r.attachment.Title = title
+	return r
+}
+
+// Pretext sets the text shown above the attachment
+func (r *replyBuilder) Pretext(pretext string) *replyBuilder {
+	r.attachment.Pretext = pretext
+	return r
+}
+
+// Text sets the main text of the attachment
+func (r *replyBuilder) Text(text string) *replyBuilder {
+	r.attachment.Text = text
+	return r
+}
+
+// Color sets the colour of the attachment's side bar
+func (r *replyBuilder) Color(color string) *replyBuilder {
+	r.attachment.Color = color
+	return r
+}
+
+// replyStyle is the look of the replies rendered from a template
+type replyStyle struct {
+	Pretext string
+	Color   string
+}
+
+// replyStyles are the looks of the templates replies are rendered from, keyed by template name
+var replyStyles = map[string]replyStyle{
+	templateGreeting:  {Pretext: "Greetings", Color: "#4af030"},
+	templateHelpOffer: {Pretext: "How can I be of service?", Color: "#3d3d3d"},
+}
+
+// Style gives the attachment the look of replies rendered from the template, if it has one
+func (r *replyBuilder) Style(template string) *replyBuilder {
+	if style, ok := replyStyles[template]; ok {
+		r.Pretext(style.Pretext).Color(style.Color)
+	}
+	return r
+}
+
+// Field adds a field, setting it again replaces the value
+func (r *replyBuilder) Field(title, value string) *replyBuilder {
+	r.fields[title] = value
+	return r
+}
+
+// Blocks appends Block Kit blocks to the attachment
+func (r *replyBuilder) Blocks(blocks ...slack.Block) *replyBuilder {
+	r.attachment.Blocks.BlockSet = append(r.attachment.Blocks.BlockSet, blocks...)
+	return r
+}
+
+// Build returns the assembled attachment
+func (r *replyBuilder) Build() slack.Attachment {
+	attachment := r.attachment
+	if len(r.fields) > 0 {
+		attachment.Fields = attachmentFields(r.fields, r.order)
+	}
+	return attachment
+}
+
+// Options returns the assembled attachment as options for the Slack client
+func (r *replyBuilder) Options() []slack.MsgOption {
+	return []slack.MsgOption{slack.MsgOptionAttachments(r.Build())}
+}
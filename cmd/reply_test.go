@@ -0,0 +1,98 @@
+/*
+Copyright © 2024 Pavlo Tarasiuk <pasha.tarasyuk@gmail.com>
+*/
+package cmd
+
+import (
+	"net/url"
+	"reflect"
+	"testing"
+
+	"github.com/slack-go/slack"
+)
+
+func TestReplyBuilder(t *testing.T) {
+	section := slack.NewSectionBlock(slack.NewTextBlockObject(slack.MarkdownType, "Was it useful?", false, false), nil, nil)
+	tests := []struct {
+		name  string
+		build func(r *replyBuilder) *replyBuilder
+		want  slack.Attachment
+	}{
+		{
+			name: "greeting",
+			build: func(r *replyBuilder) *replyBuilder {
+				return r.Pretext("Greetings").Text("Hello <@U1>").Color("#4af030").
+					Field(fieldInitializer, "<@U1>").Field(fieldDate, "2024-04-05")
+			},
+			want: slack.Attachment{
+				Pretext: "Greetings",
+				Text:    "Hello <@U1>",
+				Color:   "#4af030",
+				Fields: []slack.AttachmentField{
+					{Title: fieldDate, Value: "2024-04-05"},
+					{Title: fieldInitializer, Value: "<@U1>"},
+				},
+			},
+		},
+		{
+			name: "style of a template",
+			build: func(r *replyBuilder) *replyBuilder {
+				return r.Style(templateHelpOffer).Title("Help")
+			},
+			want: slack.Attachment{Title: "Help", Pretext: "How can I be of service?", Color: "#3d3d3d"},
+		},
+		{
+			name: "unknown style",
+			build: func(r *replyBuilder) *replyBuilder {
+				return r.Style("no-such-template").Text("plain")
+			},
+			want: slack.Attachment{Text: "plain"},
+		},
+		{
+			name: "field set again",
+			build: func(r *replyBuilder) *replyBuilder {
+				return r.Field(fieldDate, "old").Field(fieldDate, "new")
+			},
+			want: slack.Attachment{Fields: []slack.AttachmentField{{Title: fieldDate, Value: "new"}}},
+		},
+		{
+			name: "blocks",
+			build: func(r *replyBuilder) *replyBuilder {
+				return r.Blocks(section)
+			},
+			want: slack.Attachment{Blocks: slack.Blocks{BlockSet: []slack.Block{section}}},
+		},
+	}
+	b, _ := newTestBot(t, nil)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.build(b.reply()).Build(); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestReplyBuilderOptions(t *testing.T) {
+	b, _ := newTestBot(t, nil)
+	built := b.reply().Text("Hello").Color("#4af030").Field(fieldInitializer, "<@U1>").Options()
+	inline := []slack.MsgOption{slack.MsgOptionAttachments(slack.Attachment{
+		Text:   "Hello",
+		Color:  "#4af030",
+		Fields: []slack.AttachmentField{{Title: fieldInitializer, Value: "<@U1>"}},
+	})}
+
+	if got, want := optionValues(t, built), optionValues(t, inline); !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
+
+// optionValues returns the arguments the options send to chat.postMessage
+func optionValues(t *testing.T, options []slack.MsgOption) url.Values {
+	t.Helper()
+	_, values, err := slack.UnsafeApplyMsgOptions("xoxb-test", "C1", "https://slack.com/api/", options...)
+	if err != nil {
+		t.Fatalf("failed to apply options: %v", err)
+	}
+	return values
+}
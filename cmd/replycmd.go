@@ -0,0 +1,64 @@
+/*
+Copyright © 2024 Pavlo Tarasiuk <pasha.tarasyuk@gmail.com>
+*/
+package cmd
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/slack-go/slack"
+)
+
+// sameWorkspace reports whether the permalink points into the workspace the bot is installed in.
+// Before the bot identified itself any workspace is accepted.
+func (b *Bot) sameWorkspace(link messagePermalink) bool {
+	if b.workspaceURL == "" {
+		return true
+	}
+	u, err := url.Parse(b.workspaceURL)
+	return err == nil && strings.EqualFold(u.Hostname(), link.Host)
+}
+
+// handleReply posts text in the thread of the linked message: /reply <permalink> <text>
+func (b *Bot) handleReply(command slack.SlashCommand) (*SlashResponse, error) {
+	ref, text, _ := strings.Cut(strings.TrimSpace(command.Text), " ")
+	text = strings.TrimSpace(text)
+	if ref == "" || text == "" {
+		return ephemeral(usageOf("/reply")), nil
+	}
+
+	link, err := parsePermalink(ref)
+	if err != nil {
+		return ephemeral(fmt.Sprintf("%s doesn't look like a link to a Slack message, use \"Copy link\" on the message", ref)), nil
+	}
+	if !b.sameWorkspace(link) {
+		return ephemeral(fmt.Sprintf("The message is in another workspace (%s), I can only reply in this one", link.Host)), nil
+	}
+	if !b.channelAllowed(link.Channel) {
+		return ephemeral("MAVBot is not enabled in that channel"), nil
+	}
+
+	_, err = b.postMessage(outboundMessage{
+		Channel: link.Channel,
+		Invoker: command.UserID,
+		Text:    text,
+		Options: []slack.MsgOption{slack.MsgOptionTS(link.thread())},
+	})
+	if err != nil {
+		return nil, err
+	}
+	return ephemeral(fmt.Sprintf("Replied in the thread in <#%s>", link.Channel)), nil
+}
+
+func init() {
+	registerSlashCommand(&slashCommand{
+		Name:        "/reply",
+		Description: "Post a reply in the thread of a message",
+		Usage:       "<message link> <text>",
+		Example:     "/reply https://example.slack.com/archives/C123/p1712345678123456 Fixed in the latest release",
+		Category:    categoryGeneral,
+		Handler:     (*Bot).handleReply,
+	})
+}
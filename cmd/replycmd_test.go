@@ -0,0 +1,130 @@
+/*
+Copyright © 2024 Pavlo Tarasiuk <pasha.tarasyuk@gmail.com>
+*/
+package cmd
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/slack-go/slack"
+)
+
+func TestParsePermalink(t *testing.T) {
+	tests := []struct {
+		link    string
+		want    messagePermalink
+		wantErr bool
+	}{
+		{
+			link: "https://mav.slack.com/archives/C123/p1712345678123456",
+			want: messagePermalink{Host: "mav.slack.com", Channel: "C123", Timestamp: "1712345678.123456"},
+		},
+		{
+			link: "<https://mav.slack.com/archives/C123/p1712345678123456>",
+			want: messagePermalink{Host: "mav.slack.com", Channel: "C123", Timestamp: "1712345678.123456"},
+		},
+		{
+			link: "https://mav.slack.com/archives/C123/p1712345699000200?thread_ts=1712345678.123456&cid=C123",
+			want: messagePermalink{Host: "mav.slack.com", Channel: "C123", Timestamp: "1712345699.000200", ThreadTS: "1712345678.123456"},
+		},
+		{link: "http://mav.slack.com/archives/C123/p1712345678123456", wantErr: true},
+		{link: "https://mav.example.com/archives/C123/p1712345678123456", wantErr: true},
+		{link: "https://slack.com.evil.io/archives/C123/p1712345678123456", wantErr: true},
+		{link: "https://mav.slack.com/archives/C123", wantErr: true},
+		{link: "https://mav.slack.com/archives/C123/p123", wantErr: true},
+		{link: "https://mav.slack.com/archives/C123/p1712345678123456?thread_ts=yesterday", wantErr: true},
+		{link: "1712345678.123456", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := parsePermalink(tt.link)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("parsePermalink(%q) error = %v, want error %t", tt.link, err, tt.wantErr)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("parsePermalink(%q) = %+v, want %+v", tt.link, got, tt.want)
+		}
+	}
+}
+
+func TestReplyCommand(t *testing.T) {
+	tests := []struct {
+		name      string
+		text      string
+		workspace string
+		allowed   []string
+		want      string
+		// thread is the thread the reply is expected in, empty when nothing is posted
+		thread string
+	}{
+		{
+			name:   "message link",
+			text:   "https://mav.slack.com/archives/C123/p1712345678123456 Fixed in the latest release",
+			want:   "Replied in the thread in <#C123>",
+			thread: "1712345678.123456",
+		},
+		{
+			name:   "link to a reply",
+			text:   "<https://mav.slack.com/archives/C123/p1712345699000200?thread_ts=1712345678.123456> Fixed",
+			want:   "Replied in the thread in <#C123>",
+			thread: "1712345678.123456",
+		},
+		{
+			name:      "same workspace",
+			text:      "https://MAV.slack.com/archives/C123/p1712345678123456 Fixed",
+			workspace: "https://mav.slack.com/",
+			want:      "Replied in the thread in <#C123>",
+			thread:    "1712345678.123456",
+		},
+		{
+			name:      "other workspace",
+			text:      "https://other.slack.com/archives/C123/p1712345678123456 Fixed",
+			workspace: "https://mav.slack.com/",
+			want:      "The message is in another workspace (other.slack.com)",
+		},
+		{
+			name: "not a permalink",
+			text: "https://example.com/archives/C123/p1712345678123456 Fixed",
+			want: "doesn't look like a link to a Slack message",
+		},
+		{
+			name:    "channel not enabled",
+			text:    "https://mav.slack.com/archives/C123/p1712345678123456 Fixed",
+			allowed: []string{"C1"},
+			want:    "MAVBot is not enabled in that channel",
+		},
+		{name: "no text", text: "https://mav.slack.com/archives/C123/p1712345678123456", want: usageOf("/reply")},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := testConfig(t)
+			cfg.AllowedChannels = tt.allowed
+			b, fake := newTestBot(t, cfg)
+			b.workspaceURL = tt.workspace
+
+			response, err := b.handleReply(slack.SlashCommand{Command: "/reply", Text: tt.text, UserID: "U1", ChannelID: "C1"})
+			if err != nil {
+				t.Fatalf("/reply failed: %v", err)
+			}
+			if !strings.Contains(response.Text, tt.want) {
+				t.Errorf("got %q, want %q", response.Text, tt.want)
+			}
+
+			posts := fake.calls("chat.postMessage")
+			if tt.thread == "" {
+				if len(posts) != 0 {
+					t.Errorf("posted a reply for %q", tt.text)
+				}
+				return
+			}
+			if len(posts) != 1 {
+				t.Fatalf("got %d posts, want the reply", len(posts))
+			}
+			form := posts[0].Form
+			if form.Get("channel") != "C123" || form.Get("thread_ts") != tt.thread || !strings.HasPrefix(form.Get("text"), "Fixed") {
+				t.Errorf("posted %v, want the text in thread %s of C123", form, tt.thread)
+			}
+		})
+	}
+}
@@ -0,0 +1,62 @@
+/*
+Copyright © 2024 Pavlo Tarasiuk <pasha.tarasyuk@gmail.com>
+*/
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/slack-go/slack"
+)
+
+// handlePrivateReport sends the invoking user a report of what the bot keeps about them.
+// The report is private, so it goes to their DM and the channel only gets a confirmation.
+// Compiling it takes a few API calls, so the user sees a loading message meanwhile.
+func (b *Bot) handlePrivateReport(command slack.SlashCommand) (*SlashResponse, error) {
+	return b.respondLater(command, "Compiling your report…", func() (*SlashResponse, error) {
+		return b.sendPrivateReport(command)
+	}), nil
+}
+
+// sendPrivateReport compiles the report and sends it to the user's DM
+func (b *Bot) sendPrivateReport(command slack.SlashCommand) (*SlashResponse, error) {
+	marks, err := b.userBookmarks(command.UserID)
+	if err != nil {
+		return nil, err
+	}
+
+	var report strings.Builder
+	report.WriteString("*Your MAVBot report*\n")
+	fmt.Fprintf(&report, "Language: %s\n", b.userLocale(command.UserID))
+	fmt.Fprintf(&report, "Bookmarks: %d\n", len(marks))
+	for _, mark := range marks {
+		fmt.Fprintf(&report, "• %s %s\n", b.messageLink(mark.Channel, mark.Timestamp, mark.StarredAt.Format("2006-01-02")), excerpt(mark.Text, 80))
+	}
+
+	_, err = b.postDM(command.UserID, outboundMessage{
+		Invoker: command.UserID,
+		Text:    report.String(),
+	})
+	if errors.Is(err, errDMUnavailable) {
+		return ephemeral("I couldn't send you a DM, please check that direct messages from MAVBot are enabled"), nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if isDirectMessage(command) {
+		return nil, nil
+	}
+	return ephemeral("I sent you a DM with your report"), nil
+}
+
+func init() {
+	registerSlashCommand(&slashCommand{
+		Name:          "/private-report",
+		Description:   "Get a report of what MAVBot keeps about you in a DM",
+		Category:      categoryPersonal,
+		MaxConcurrent: 2,
+		Handler:       (*Bot).handlePrivateReport,
+	})
+}
@@ -0,0 +1,88 @@
+/*
+Copyright © 2024 Pavlo Tarasiuk <pasha.tarasyuk@gmail.com>
+*/
+package cmd
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/slack-go/slack"
+)
+
+func TestPrivateReport(t *testing.T) {
+	tests := []struct {
+		name    string
+		channel string
+		// open and post are the answers of conversations.open and chat.postMessage, empty for ok
+		open, post string
+		// want is the text shown in the channel, empty for none
+		want string
+		// delivered tells whether the report reaches the DM
+		delivered bool
+	}{
+		{name: "from a channel", channel: "C1", want: "I sent you a DM with your report", delivered: true},
+		{name: "from the DM", channel: "D0DM", delivered: true},
+		{
+			name:    "DMs disabled",
+			channel: "C1",
+			open:    `{"ok":false,"error":"messages_tab_disabled"}`,
+			want:    "I couldn't send you a DM",
+		},
+		{
+			name:    "DM rejected on post",
+			channel: "C1",
+			post:    `{"ok":false,"error":"cannot_dm_bot"}`,
+			want:    "I couldn't send you a DM",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b, fake := newTestBot(t, nil)
+			open := tt.open
+			if open == "" {
+				open = `{"ok":true,"channel":{"id":"D0DM"}}`
+			}
+			fake.answer("conversations.open", open)
+			if tt.post != "" {
+				fake.answer("chat.postMessage", tt.post)
+			}
+
+			response, err := b.sendPrivateReport(slack.SlashCommand{Command: "/private-report", UserID: "U1", ChannelID: tt.channel})
+			if err != nil {
+				t.Fatalf("report failed: %v", err)
+			}
+			if opened := fake.calls("conversations.open"); len(opened) != 1 || opened[0].Form.Get("users") != "U1" {
+				t.Errorf("opened %+v, want the invoker's DM", opened)
+			}
+			if tt.want == "" && response != nil {
+				t.Errorf("answered %q in the DM the report went to", response.Text)
+			}
+			if tt.want != "" && (response == nil || !strings.Contains(response.Text, tt.want)) {
+				t.Errorf("answered %+v, want %q", response, tt.want)
+			}
+			if response != nil && response.ResponseType == slack.ResponseTypeInChannel {
+				t.Errorf("the confirmation is visible to the channel")
+			}
+
+			posts := fake.calls("chat.postMessage")
+			if !tt.delivered {
+				return
+			}
+			if len(posts) != 1 || posts[0].Form.Get("channel") != "D0DM" {
+				t.Fatalf("posted %+v, want the report in the DM", posts)
+			}
+			if text := posts[0].Form.Get("text"); !strings.Contains(text, "Your MAVBot report") || !strings.Contains(text, "Bookmarks: 0") {
+				t.Errorf("the report reads %q", text)
+			}
+		})
+	}
+}
+
+func TestPrivateReportFailure(t *testing.T) {
+	b, fake := newTestBot(t, nil)
+	fake.answer("conversations.open", `{"ok":false,"error":"account_inactive"}`)
+	if _, err := b.sendPrivateReport(slack.SlashCommand{Command: "/private-report", UserID: "U1", ChannelID: "C1"}); err == nil {
+		t.Errorf("a failure other than disabled DMs wasn't reported")
+	}
+}
@@ -0,0 +1,105 @@
+/*
+Copyright © 2024 Pavlo Tarasiuk <pasha.tarasyuk@gmail.com>
+*/
+package cmd
+
+import (
+	"strings"
+
+	"github.com/ptarasyuk/mavbot/internal/blocks"
+	"github.com/slack-go/slack"
+)
+
+// Formats a command's responses are rendered in, see slashCommand.Format
+const (
+	// formatAsIs leaves the response the way the handler built it
+	formatAsIs = ""
+	// formatPlain reduces the response to its text
+	formatPlain = "plain"
+	// formatMarkdown puts the text in an attachment with mrkdwn enabled
+	formatMarkdown = "markdown"
+	// formatBlocks puts the text in section blocks, keeping it as the notification fallback
+	formatBlocks = "blocks"
+)
+
+// responseText collects the text of the response, from its attachments and section blocks too
+func responseText(r *SlashResponse) string {
+	var parts []string
+	if r.Text != "" {
+		parts = append(parts, r.Text)
+	}
+	for _, attachment := range r.Attachments {
+		for _, text := range []string{attachment.Pretext, attachment.Title, attachment.Text} {
+			if text != "" {
+				parts = append(parts, text)
+			}
+		}
+		for _, field := range attachment.Fields {
+			parts = append(parts, field.Title+": "+field.Value)
+		}
+	}
+	for _, block := range r.Blocks {
+		if section, ok := block.(*slack.SectionBlock); ok && section.Text != nil {
+			parts = append(parts, section.Text.Text)
+		}
+	}
+	return strings.Join(parts, "\n")
+}
+
+// splitLines splits text into chunks of at most max bytes, between lines where it can
+func splitLines(text string, max int) []string {
+	var chunks []string
+	for len(text) > max {
+		cut := strings.LastIndex(text[:max], "\n")
+		if cut <= 0 {
+			cut = max
+		}
+		chunks = append(chunks, text[:cut])
+		text = strings.TrimPrefix(text[cut:], "\n")
+	}
+	if text != "" {
+		chunks = append(chunks, text)
+	}
+	return chunks
+}
+
+// renderResponse converts the response into format. The text of a response with blocks is only
+// their notification fallback, so the blocks and markdown formats leave such a response alone.
+// The original is left alone too, it may be shared.
+func renderResponse(r *SlashResponse, format string) *SlashResponse {
+	if r == nil || (len(r.Blocks) > 0 && format != formatPlain) {
+		return r
+	}
+	rendered := &SlashResponse{ResponseType: r.ResponseType, Posts: r.Posts, done: r.done}
+	switch format {
+	case formatPlain:
+		rendered.Text = responseText(r)
+	case formatMarkdown:
+		if r.Text != "" {
+			rendered.Attachments = append(rendered.Attachments, slack.Attachment{
+				Text:       r.Text,
+				Fallback:   r.Text,
+				MarkdownIn: []string{"text"},
+			})
+		}
+		rendered.Attachments = append(rendered.Attachments, r.Attachments...)
+	case formatBlocks:
+		rendered.Text = r.Text
+		for _, chunk := range splitLines(r.Text, maxSectionTextLength) {
+			rendered.Blocks = append(rendered.Blocks, blocks.Section(chunk))
+		}
+		rendered.Attachments = r.Attachments
+	default:
+		return r
+	}
+	return rendered
+}
+
+// formatResponse renders the response of the command in the format it is registered with
+func formatResponse(commandName string, r *SlashResponse) *SlashResponse {
+	registered, ok := slashCommands[commandName]
+	if !ok {
+		return r
+	}
+	return renderResponse(r, registered.Format)
+}
@@ -0,0 +1,203 @@
+/*
+Copyright © 2024 Pavlo Tarasiuk <pasha.tarasyuk@gmail.com>
+*/
+package cmd
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/ptarasyuk/mavbot/internal/blocks"
+	"github.com/slack-go/slack"
+)
+
+// sampleResponse is a response with text and an attachment, as handlers build them
+func sampleResponse() *SlashResponse {
+	return &SlashResponse{
+		ResponseType: slack.ResponseTypeInChannel,
+		Text:         "*Uptime* report",
+		Attachments: []slack.Attachment{{
+			Pretext: "Greetings",
+			Text:    "All good",
+			Fields:  []slack.AttachmentField{{Title: "Uptime", Value: "3h"}},
+		}},
+	}
+}
+
+// rendered returns the JSON of the response as Slack gets it
+func rendered(r *SlashResponse) string {
+	raw, _ := json.Marshal(r.message())
+	return string(raw)
+}
+
+func TestRenderResponseFormats(t *testing.T) {
+	tests := []struct {
+		format string
+		check  func(t *testing.T, r *SlashResponse)
+	}{
+		{
+			format: formatAsIs,
+			check: func(t *testing.T, r *SlashResponse) {
+				if rendered(r) != rendered(sampleResponse()) {
+					t.Errorf("rendered %s, want the response as built", rendered(r))
+				}
+			},
+		},
+		{
+			format: formatPlain,
+			check: func(t *testing.T, r *SlashResponse) {
+				if want := "*Uptime* report\nGreetings\nAll good\nUptime: 3h"; r.Text != want {
+					t.Errorf("text = %q, want %q", r.Text, want)
+				}
+				if len(r.Attachments) != 0 || len(r.Blocks) != 0 {
+					t.Errorf("rendered %s, want the text only", rendered(r))
+				}
+			},
+		},
+		{
+			format: formatMarkdown,
+			check: func(t *testing.T, r *SlashResponse) {
+				if r.Text != "" || len(r.Attachments) != 2 {
+					t.Fatalf("rendered %s, want the text moved into an attachment ahead of the other", rendered(r))
+				}
+				first := r.Attachments[0]
+				if first.Text != "*Uptime* report" || first.Fallback != "*Uptime* report" || len(first.MarkdownIn) != 1 || first.MarkdownIn[0] != "text" {
+					t.Errorf("first attachment = %+v, want the text as mrkdwn", first)
+				}
+				if r.Attachments[1].Text != "All good" {
+					t.Errorf("second attachment = %+v, want the handler's", r.Attachments[1])
+				}
+			},
+		},
+		{
+			format: formatBlocks,
+			check: func(t *testing.T, r *SlashResponse) {
+				if r.Text != "*Uptime* report" {
+					t.Errorf("text = %q, want it kept as the notification fallback", r.Text)
+				}
+				if len(r.Blocks) != 1 || len(r.Attachments) != 1 {
+					t.Fatalf("rendered %s, want a section and the attachment", rendered(r))
+				}
+				if section, ok := r.Blocks[0].(*slack.SectionBlock); !ok || section.Text.Type != slack.MarkdownType || section.Text.Text != "*Uptime* report" {
+					t.Errorf("blocks = %+v, want the text in a mrkdwn section", r.Blocks)
+				}
+				if err := validateBlocks(r.Blocks); err != nil {
+					t.Errorf("invalid blocks: %v", err)
+				}
+			},
+		},
+		{
+			format: "unknown",
+			check: func(t *testing.T, r *SlashResponse) {
+				if rendered(r) != rendered(sampleResponse()) {
+					t.Errorf("rendered %s, want the response as built", rendered(r))
+				}
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.format, func(t *testing.T) {
+			original := sampleResponse()
+			r := renderResponse(original, tt.format)
+			if r.ResponseType != slack.ResponseTypeInChannel {
+				t.Errorf("response type = %q, want it kept", r.ResponseType)
+			}
+			tt.check(t, r)
+			if rendered(original) != rendered(sampleResponse()) {
+				t.Errorf("the original was changed to %s", rendered(original))
+			}
+		})
+	}
+}
+
+func TestRenderResponseWithBlocks(t *testing.T) {
+	response := &SlashResponse{Text: "Fallback", Blocks: []slack.Block{blocks.Header("News"), blocks.Section("Deploy *done*")}}
+	for _, format := range []string{formatMarkdown, formatBlocks} {
+		if got := renderResponse(response, format); got != response {
+			t.Errorf("%s: rendered %s, want blocks left alone", format, rendered(got))
+		}
+	}
+	if got := renderResponse(response, formatPlain); got.Text != "Fallback\nDeploy *done*" || len(got.Blocks) != 0 {
+		t.Errorf("plain: rendered %s, want the text of the sections", rendered(got))
+	}
+	if renderResponse(nil, formatPlain) != nil {
+		t.Errorf("rendered a nil response")
+	}
+}
+
+func TestSplitLines(t *testing.T) {
+	tests := []struct {
+		text string
+		max  int
+		want []string
+	}{
+		{text: "", max: 10},
+		{text: "short", max: 10, want: []string{"short"}},
+		{text: "one\ntwo\nthree", max: 8, want: []string{"one\ntwo", "three"}},
+		{text: "abcdefghij", max: 4, want: []string{"abcd", "efgh", "ij"}},
+	}
+	for _, tt := range tests {
+		if got := splitLines(tt.text, tt.max); strings.Join(got, "|") != strings.Join(tt.want, "|") {
+			t.Errorf("splitLines(%q, %d) = %q, want %q", tt.text, tt.max, got, tt.want)
+		}
+	}
+
+	long := strings.Repeat(strings.Repeat("x", 99)+"\n", 100)
+	r := renderResponse(&SlashResponse{Text: long}, formatBlocks)
+	if len(r.Blocks) != 4 {
+		t.Errorf("split %d bytes into %d sections, want 4", len(long), len(r.Blocks))
+	}
+	if err := validateBlocks(r.Blocks); err != nil {
+		t.Errorf("invalid blocks: %v", err)
+	}
+}
+
+func TestCommandResponseFormatted(t *testing.T) {
+	for _, format := range []string{formatPlain, formatMarkdown, formatBlocks} {
+		t.Run(format, func(t *testing.T) {
+			registerTestCommand(t, &slashCommand{
+				Name:   "/test-format",
+				Format: format,
+				Handler: func(*Bot, slack.SlashCommand) (*SlashResponse, error) {
+					return sampleResponse(), nil
+				},
+			})
+			b, _ := newTestBot(t, nil)
+			got, err := b.dispatchSlashCommand(slack.SlashCommand{Command: "/test-format", UserID: "U1", ChannelID: "C1"})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if want := renderResponse(sampleResponse(), format); rendered(got) != rendered(want) {
+				t.Errorf("responded %s, want %s", rendered(got), rendered(want))
+			}
+		})
+	}
+}
+
+func TestLoadingResponseFormatted(t *testing.T) {
+	registerTestCommand(t, &slashCommand{
+		Name:   "/test-format",
+		Format: formatBlocks,
+		Handler: func(b *Bot, command slack.SlashCommand) (*SlashResponse, error) {
+			return b.respondLater(command, "Working on it…", func() (*SlashResponse, error) {
+				return ephemeral("Done *now*"), nil
+			}), nil
+		},
+	})
+	b, fake := newTestBot(t, nil)
+	loading, err := b.dispatchSlashCommand(slack.SlashCommand{Command: "/test-format", UserID: "U1", ChannelID: "C1", ResponseURL: fake.apiURL() + "respond"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(rendered(loading), `"type":"section"`) {
+		t.Errorf("the loading message was formatted: %s", rendered(loading))
+	}
+	var replaced responsePayload
+	if err := json.Unmarshal(fake.waitCalls(t, "respond", 1)[0].Body, &replaced); err != nil {
+		t.Fatal(err)
+	}
+	if len(replaced.Blocks) != 1 || replaced.Blocks[0].Type != "section" || !strings.HasPrefix(replaced.Text, "Done *now*") {
+		t.Errorf("replaced with %+v, want the response in a section", replaced)
+	}
+}
@@ -0,0 +1,121 @@
+/*
+Copyright © 2024 Pavlo Tarasiuk <pasha.tarasyuk@gmail.com>
+*/
+package cmd
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/slack-go/slack"
+)
+
+// cachedResult is a command's response kept until expiresAt
+type cachedResult struct {
+	response  *SlashResponse
+	expiresAt time.Time
+}
+
+// resultCache keeps the responses of the commands with a cache TTL, keyed by command,
+// arguments and channel
+type resultCache struct {
+	mu    sync.Mutex
+	items map[string]cachedResult
+	now   func() time.Time
+}
+
+// newResultCache creates an empty resultCache reading the time from now
+func newResultCache(now func() time.Time) *resultCache {
+	return &resultCache{items: make(map[string]cachedResult), now: now}
+}
+
+// resultKey identifies the response of an invocation, the same command with the same
+// arguments in the same channel gets the same response
+func resultKey(command slack.SlashCommand) string {
+	return command.Command + "/" + command.ChannelID + "/" + strings.Join(strings.Fields(command.Text), " ")
+}
+
+// get returns the response cached for the key unless it has expired
+func (c *resultCache) get(key string) (*SlashResponse, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	result, ok := c.items[key]
+	if !ok || !c.now().Before(result.expiresAt) {
+		return nil, false
+	}
+	return result.response, true
+}
+
+// set caches the response for the key for ttl, dropping the entries that have expired meanwhile
+func (c *resultCache) set(key string, response *SlashResponse, ttl time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	now := c.now()
+	for k, result := range c.items {
+		if !now.Before(result.expiresAt) {
+			delete(c.items, k)
+		}
+	}
+	c.items[key] = cachedResult{response: response, expiresAt: now.Add(ttl)}
+}
+
+// len returns the number of cached responses
+func (c *resultCache) len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.items)
+}
+
+// purge removes every cached response and returns how many there were
+func (c *resultCache) purge() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	n := len(c.items)
+	c.items = make(map[string]cachedResult)
+	return n
+}
+
+// parseCacheTTLs parses the command cache TTLs of MAVBOT_COMMAND_CACHE, e.g. /survey-recent=30s,/activity=0
+func parseCacheTTLs(items []string) (map[string]time.Duration, error) {
+	ttls := make(map[string]time.Duration, len(items))
+	for _, item := range items {
+		name, value, ok := strings.Cut(item, "=")
+		name = strings.TrimSpace(name)
+		ttl, err := time.ParseDuration(strings.TrimSpace(value))
+		if !ok || err != nil || ttl < 0 || !strings.HasPrefix(name, "/") {
+			return nil, fmt.Errorf("invalid command cache TTL %q, expected /COMMAND=DURATION", item)
+		}
+		ttls[name] = ttl
+	}
+	return ttls, nil
+}
+
+// cacheTTL returns how long the responses of the command are cached, 0 when they aren't
+func (b *Bot) cacheTTL(command *slashCommand) time.Duration {
+	if ttl, ok := b.cfg.CommandCacheTTLs[command.Name]; ok {
+		return ttl
+	}
+	return command.CacheTTL
+}
+
+// invokeCached returns the cached response of the invocation or invokes the command, caching its
+// response when the command has a cache TTL. Errors aren't cached, nor are loading messages,
+// the response replacing them comes later, nor responses with posts to make.
+func (b *Bot) invokeCached(registered *slashCommand, command slack.SlashCommand) (*SlashResponse, error) {
+	ttl := b.cacheTTL(registered)
+	if ttl <= 0 {
+		return registered.invoke(b, command)
+	}
+	key := resultKey(command)
+	if response, ok := b.results.get(key); ok {
+		b.metrics.inc(metricCommandCacheHits)
+		return response, nil
+	}
+	response, err := registered.invoke(b, command)
+	if err == nil && response != nil && response.done == nil && len(response.Posts) == 0 {
+		b.results.set(key, response, ttl)
+	}
+	return response, err
+}
@@ -0,0 +1,184 @@
+/*
+Copyright © 2024 Pavlo Tarasiuk <pasha.tarasyuk@gmail.com>
+*/
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/slack-go/slack"
+)
+
+func TestParseCacheTTLs(t *testing.T) {
+	tests := []struct {
+		items   []string
+		want    map[string]time.Duration
+		wantErr bool
+	}{
+		{items: nil, want: map[string]time.Duration{}},
+		{
+			items: []string{"/survey-recent=30s", " /activity = 0 "},
+			want:  map[string]time.Duration{"/survey-recent": 30 * time.Second, "/activity": 0},
+		},
+		{items: []string{"survey-recent=30s"}, wantErr: true},
+		{items: []string{"/survey-recent"}, wantErr: true},
+		{items: []string{"/survey-recent=a while"}, wantErr: true},
+		{items: []string{"/survey-recent=-1m"}, wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := parseCacheTTLs(tt.items)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("parseCacheTTLs(%q) error = %v, want error %t", tt.items, err, tt.wantErr)
+			continue
+		}
+		if !tt.wantErr && fmt.Sprint(got) != fmt.Sprint(tt.want) {
+			t.Errorf("parseCacheTTLs(%q) = %v, want %v", tt.items, got, tt.want)
+		}
+	}
+}
+
+func TestResultKey(t *testing.T) {
+	a := resultKey(slack.SlashCommand{Command: "/report", ChannelID: "C1", Text: " weekly   csv "})
+	if b := resultKey(slack.SlashCommand{Command: "/report", ChannelID: "C1", Text: "weekly csv", UserID: "U2"}); a != b {
+		t.Errorf("resultKey() = %q and %q, want spacing and the user ignored", a, b)
+	}
+	for _, other := range []slack.SlashCommand{
+		{Command: "/report", ChannelID: "C2", Text: "weekly csv"},
+		{Command: "/report", ChannelID: "C1", Text: "monthly csv"},
+		{Command: "/activity", ChannelID: "C1", Text: "weekly csv"},
+	} {
+		if resultKey(other) == a {
+			t.Errorf("resultKey(%+v) = %q, want it told apart", other, a)
+		}
+	}
+}
+
+// newCachingBot creates a bot on the clock with /test-cache registered, its responses cached
+// for ttl, and returns the number of times the handler ran
+func newCachingBot(t *testing.T, cfg *Config, ttl time.Duration, clock *fakeClock) (*Bot, *int) {
+	t.Helper()
+	runs := new(int)
+	registerTestCommand(t, &slashCommand{
+		Name:     "/test-cache",
+		CacheTTL: ttl,
+		Handler: func(_ *Bot, command slack.SlashCommand) (*SlashResponse, error) {
+			*runs++
+			return ephemeral(fmt.Sprintf("Report %d for %q", *runs, command.Text)), nil
+		},
+	})
+	b, _ := newTestBot(t, cfg)
+	b.now = clock.now
+	b.results = newResultCache(clock.now)
+	return b, runs
+}
+
+func TestCommandResultCache(t *testing.T) {
+	clock := newFakeClock()
+	b, runs := newCachingBot(t, nil, time.Minute, clock)
+	steps := []struct {
+		// after is how long after the step before the command is run
+		after   time.Duration
+		text    string
+		channel string
+		user    string
+		want    string
+	}{
+		{text: "weekly", channel: "C1", user: "U1", want: `Report 1 for "weekly"`},
+		{after: 30 * time.Second, text: "weekly", channel: "C1", user: "U2", want: `Report 1 for "weekly"`},
+		{text: "monthly", channel: "C1", user: "U1", want: `Report 2 for "monthly"`},
+		{text: "weekly", channel: "C2", user: "U1", want: `Report 3 for "weekly"`},
+		{after: 29 * time.Second, text: "weekly", channel: "C1", user: "U1", want: `Report 1 for "weekly"`},
+		// The TTL is over, so the report is made again
+		{after: time.Second, text: "weekly", channel: "C1", user: "U1", want: `Report 4 for "weekly"`},
+		{text: "weekly", channel: "C1", user: "U1", want: `Report 4 for "weekly"`},
+	}
+	for i, step := range steps {
+		clock.advance(step.after)
+		resp, err := b.dispatchSlashCommand(slack.SlashCommand{Command: "/test-cache", Text: step.text, ChannelID: step.channel, UserID: step.user})
+		if err != nil {
+			t.Fatalf("step %d: %v", i+1, err)
+		}
+		if resp.Text != step.want {
+			t.Errorf("step %d: answered %q, want %q", i+1, resp.Text, step.want)
+		}
+	}
+	if *runs != 4 {
+		t.Errorf("ran the handler %d times, want 4", *runs)
+	}
+	if got := b.metrics.get(metricCommandCacheHits); got != 3 {
+		t.Errorf("counted %d cache hits, want 3", got)
+	}
+	if got := b.results.purge(); got != 3 || b.results.len() != 0 {
+		t.Errorf("purged %d responses, want the 3 cached", got)
+	}
+}
+
+func TestCommandCacheTTLOverride(t *testing.T) {
+	tests := []struct {
+		name     string
+		ttl      time.Duration
+		override map[string]time.Duration
+		wantRuns int
+	}{
+		{name: "registered", ttl: time.Minute, wantRuns: 1},
+		{name: "not cached", wantRuns: 2},
+		{name: "disabled", ttl: time.Minute, override: map[string]time.Duration{"/test-cache": 0}, wantRuns: 2},
+		{name: "enabled", override: map[string]time.Duration{"/test-cache": time.Minute}, wantRuns: 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := testConfig(t)
+			cfg.CommandCacheTTLs = tt.override
+			b, runs := newCachingBot(t, cfg, tt.ttl, newFakeClock())
+			for i := 0; i < 2; i++ {
+				if _, err := b.dispatchSlashCommand(slack.SlashCommand{Command: "/test-cache", ChannelID: "C1", UserID: "U1"}); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if *runs != tt.wantRuns {
+				t.Errorf("ran the handler %d times, want %d", *runs, tt.wantRuns)
+			}
+		})
+	}
+}
+
+func TestCommandCacheSkipsUncacheable(t *testing.T) {
+	tests := []struct {
+		name     string
+		response func(b *Bot, command slack.SlashCommand) (*SlashResponse, error)
+	}{
+		{name: "error", response: func(*Bot, slack.SlashCommand) (*SlashResponse, error) {
+			return nil, errors.New("store unavailable")
+		}},
+		{name: "nothing", response: func(*Bot, slack.SlashCommand) (*SlashResponse, error) { return nil, nil }},
+		{name: "posts", response: func(*Bot, slack.SlashCommand) (*SlashResponse, error) {
+			return &SlashResponse{Text: "Posting", Posts: []targetedPost{{}}}, nil
+		}},
+		{name: "loading", response: func(*Bot, slack.SlashCommand) (*SlashResponse, error) {
+			return &SlashResponse{Text: "Loading", done: make(chan struct{})}, nil
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			runs := 0
+			registered := &slashCommand{
+				Name:     "/test-cache",
+				CacheTTL: time.Minute,
+				Handler: func(b *Bot, command slack.SlashCommand) (*SlashResponse, error) {
+					runs++
+					return tt.response(b, command)
+				},
+			}
+			b, _ := newTestBot(t, nil)
+			for i := 0; i < 2; i++ {
+				b.invokeCached(registered, slack.SlashCommand{Command: "/test-cache", ChannelID: "C1"})
+			}
+			if runs != 2 || b.results.len() != 0 {
+				t.Errorf("ran the handler %d times with %d cached, want it run every time", runs, b.results.len())
+			}
+		})
+	}
+}
@@ -0,0 +1,98 @@
+/*
+Copyright © 2024 Pavlo Tarasiuk <pasha.tarasyuk@gmail.com>
+*/
+package cmd
+
+import (
+	"log"
+	"net/http"
+	"path"
+	"time"
+)
+
+// idempotentMethods are the Web API methods that only read, slack-go sends most of them as
+// POST requests, so the HTTP method alone doesn't tell whether a request may be repeated
+var idempotentMethods = map[string]bool{
+	"auth.test":             true,
+	"chat.getPermalink":     true,
+	"conversations.info":    true,
+	"conversations.list":    true,
+	"team.info":             true,
+	"usergroups.list":       true,
+	"usergroups.users.list": true,
+	"users.info":            true,
+	"users.list":            true,
+}
+
+// retryTransport repeats failed idempotent requests with exponential backoff, for networks
+// that drop connections now and then. Requests that change something are passed through once.
+type retryTransport struct {
+	next http.RoundTripper
+	// retries is how many times a request is repeated at most
+	retries int
+	// delay is the wait before the first retry, doubling with every further one
+	delay time.Duration
+}
+
+// newRetryTransport wraps next, repeating idempotent requests up to retries times
+func newRetryTransport(next http.RoundTripper, retries int, delay time.Duration) *retryTransport {
+	return &retryTransport{next: next, retries: retries, delay: delay}
+}
+
+// idempotent reports whether the request may safely be sent again
+func idempotent(req *http.Request) bool {
+	if req.Method == http.MethodGet || req.Method == http.MethodHead {
+		return true
+	}
+	// Repeating a request needs a fresh copy of its body
+	return idempotentMethods[path.Base(req.URL.Path)] && (req.Body == nil || req.GetBody != nil)
+}
+
+// retryable reports whether the outcome of an attempt is worth another one
+func retryable(resp *http.Response, err error) bool {
+	if err != nil {
+		return true
+	}
+	switch resp.StatusCode {
+	case http.StatusInternalServerError, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	}
+	return false
+}
+
+// RoundTrip implements http.RoundTripper
+func (t *retryTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if t.retries == 0 || !idempotent(req) {
+		return t.next.RoundTrip(req)
+	}
+
+	delay := t.delay
+	for attempt := 0; ; attempt++ {
+		resp, err := t.next.RoundTrip(req)
+		if attempt == t.retries || !retryable(resp, err) {
+			return resp, err
+		}
+		if err != nil {
+			log.Printf("Slack API %s failed, retrying in %s: %v\n", path.Base(req.URL.Path), delay, err)
+		} else {
+			log.Printf("Slack API %s returned %s, retrying in %s\n", path.Base(req.URL.Path), resp.Status, delay)
+			resp.Body.Close()
+		}
+
+		select {
+		case <-req.Context().Done():
+			return nil, req.Context().Err()
+		case <-time.After(delay):
+		}
+		delay *= 2
+
+		if req.GetBody != nil {
+			body, err := req.GetBody()
+			if err != nil {
+				return nil, err
+			}
+			req = req.Clone(req.Context())
+			req.Body = body
+		}
+	}
+}
@@ -0,0 +1,118 @@
+/*
+Copyright © 2024 Pavlo Tarasiuk <pasha.tarasyuk@gmail.com>
+*/
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/slack-go/slack"
+)
+
+func TestRetryTransport(t *testing.T) {
+	tests := []struct {
+		name string
+		// failures is the number of calls the flaky server fails before it recovers
+		failures int32
+		status   int
+		// call makes the Slack call under test
+		call         func(api *slack.Client) error
+		wantAttempts int32
+		wantErr      bool
+	}{
+		{
+			name:         "read succeeds after retries",
+			failures:     2,
+			status:       http.StatusServiceUnavailable,
+			call:         func(api *slack.Client) error { _, err := api.GetUserInfo("U1"); return err },
+			wantAttempts: 3,
+		},
+		{
+			name:         "read gives up",
+			failures:     5,
+			status:       http.StatusBadGateway,
+			call:         func(api *slack.Client) error { _, err := api.GetUserInfo("U1"); return err },
+			wantAttempts: 3,
+			wantErr:      true,
+		},
+		{
+			name:         "client errors aren't retried",
+			failures:     1,
+			status:       http.StatusBadRequest,
+			call:         func(api *slack.Client) error { _, err := api.GetUserInfo("U1"); return err },
+			wantAttempts: 1,
+			wantErr:      true,
+		},
+		{
+			name:     "posts are left to the explicit retries",
+			failures: 1,
+			status:   http.StatusServiceUnavailable,
+			call: func(api *slack.Client) error {
+				_, _, err := api.PostMessage("C1", slack.MsgOptionText("hi", false))
+				return err
+			},
+			wantAttempts: 1,
+			wantErr:      true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake := newFakeSlack(t)
+			var attempts int32
+			flaky := func(w http.ResponseWriter, r *http.Request) {
+				if atomic.AddInt32(&attempts, 1) <= tt.failures {
+					w.WriteHeader(tt.status)
+					return
+				}
+				fmt.Fprint(w, `{"ok":true,"user":{"id":"U1"},"channel":"C1","ts":"1.1"}`)
+			}
+			fake.handle("users.info", flaky)
+			fake.handle("chat.postMessage", flaky)
+			api := slack.New("xoxb-test", slack.OptionAPIURL(fake.apiURL()),
+				slack.OptionHTTPClient(&http.Client{Transport: newRetryTransport(http.DefaultTransport, 2, time.Millisecond)}))
+
+			err := tt.call(api)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("got error %v, want error %t", err, tt.wantErr)
+			}
+			if got := atomic.LoadInt32(&attempts); got != tt.wantAttempts {
+				t.Errorf("made %d attempts, want %d", got, tt.wantAttempts)
+			}
+		})
+	}
+}
+
+func TestRetryTransportBacksOffOnNetworkErrors(t *testing.T) {
+	var sent []time.Time
+	next := roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		sent = append(sent, time.Now())
+		if len(sent) < 3 {
+			return nil, errors.New("connection reset by peer")
+		}
+		return http.DefaultTransport.RoundTrip(req)
+	})
+	fake := newFakeSlack(t)
+	fake.answer("users.info", `{"ok":true,"user":{"id":"U1","name":"pasha"}}`)
+	api := slack.New("xoxb-test", slack.OptionAPIURL(fake.apiURL()),
+		slack.OptionHTTPClient(&http.Client{Transport: newRetryTransport(next, 3, 20*time.Millisecond)}))
+
+	user, err := api.GetUserInfo("U1")
+	if err != nil || user.Name != "pasha" {
+		t.Fatalf("got %+v, %v, want the user after the network recovered", user, err)
+	}
+	if len(sent) != 3 {
+		t.Fatalf("made %d attempts, want 3", len(sent))
+	}
+	// The delay doubles with every retry
+	if first, second := sent[1].Sub(sent[0]), sent[2].Sub(sent[1]); first < 20*time.Millisecond || second < 40*time.Millisecond {
+		t.Errorf("waited %s and %s, want at least 20ms and 40ms", first, second)
+	}
+	if got := fake.calls("users.info"); len(got) != 1 || got[0].Form.Get("user") != "U1" {
+		t.Errorf("the retried request arrived as %+v, want its body intact", got)
+	}
+}
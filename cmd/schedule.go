@@ -0,0 +1,119 @@
+/*
+Copyright © 2024 Pavlo Tarasiuk <pasha.tarasyuk@gmail.com>
+*/
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/slack-go/slack"
+)
+
+// scheduleTimeLayouts are the times of day /schedule understands
+var scheduleTimeLayouts = []string{"3pm", "3:04pm", "3 pm", "3:04 pm", "15:04"}
+
+// errInvalidScheduleTime is returned for times /schedule can't make sense of
+var errInvalidScheduleTime = errors.New("invalid time")
+
+// userLocation returns the timezone of the user, falling back to the configured one and then the server's
+func (b *Bot) userLocation(userID string) *time.Location {
+	if loc := b.location(b.userTimezone(userID)); loc != nil {
+		return loc
+	}
+	return time.Local
+}
+
+// parseScheduleTime interprets a time of day like "9am", "9:30pm" or "14:00", optionally preceded
+// by "today" or "tomorrow", in loc. Without a day the next occurrence after now is meant.
+func parseScheduleTime(spec string, now time.Time, loc *time.Location) (time.Time, error) {
+	spec = strings.ToLower(strings.TrimSpace(spec))
+	day, clock, hasDay := strings.Cut(spec, " ")
+	if !hasDay || (day != "today" && day != "tomorrow") {
+		day, clock = "", spec
+	}
+
+	var parsed time.Time
+	var err error
+	for _, layout := range scheduleTimeLayouts {
+		if parsed, err = time.Parse(layout, strings.TrimSpace(clock)); err == nil {
+			break
+		}
+	}
+	if err != nil {
+		return time.Time{}, errInvalidScheduleTime
+	}
+
+	local := now.In(loc)
+	at := time.Date(local.Year(), local.Month(), local.Day(), parsed.Hour(), parsed.Minute(), 0, 0, loc)
+	switch {
+	case day == "tomorrow":
+		at = at.AddDate(0, 0, 1)
+	case day == "" && !at.After(now):
+		at = at.AddDate(0, 0, 1)
+	}
+	return at, nil
+}
+
+// splitScheduleArgs separates the time from the message, the time being the first word or two
+func splitScheduleArgs(text string) (string, string) {
+	words := strings.Fields(text)
+	n := 1
+	if len(words) > 1 {
+		switch strings.ToLower(words[0]) {
+		case "today", "tomorrow":
+			n = 2
+		}
+	}
+	// "9 am" is written with a space too
+	if len(words) > n && (strings.EqualFold(words[n], "am") || strings.EqualFold(words[n], "pm")) {
+		n++
+	}
+	if len(words) <= n {
+		return strings.Join(words, " "), ""
+	}
+	return strings.Join(words[:n], " "), strings.Join(words[n:], " ")
+}
+
+// handleSchedule posts a message to the channel later: /schedule [today|tomorrow] <time> <text>.
+// The time is read in the invoking user's timezone.
+func (b *Bot) handleSchedule(command slack.SlashCommand) (*SlashResponse, error) {
+	usage := usageOf("/schedule") + ", e.g. /schedule 9am Standup starts now"
+	spec, text := splitScheduleArgs(command.Text)
+	if spec == "" || text == "" {
+		return ephemeral(usage), nil
+	}
+	loc := b.userLocation(command.UserID)
+	at, err := parseScheduleTime(spec, b.now(), loc)
+	if err != nil {
+		return ephemeral(fmt.Sprintf("I don't understand the time %q. %s", spec, usage)), nil
+	}
+	if !at.After(b.now()) {
+		return ephemeral("That time has already passed"), nil
+	}
+
+	_, err = b.postMessage(outboundMessage{
+		Channel: command.ChannelID,
+		Invoker: command.UserID,
+		Text:    text,
+		PostAt:  at,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return ephemeral(fmt.Sprintf("Scheduled for %s (%s), %s UTC",
+		at.Format("Mon 2 Jan 15:04"), loc, at.UTC().Format("Mon 2 Jan 15:04"))), nil
+}
+
+func init() {
+	registerSlashCommand(&slashCommand{
+		Name:        "/schedule",
+		Description: "Post a message to the channel at a time in your timezone",
+		Usage:       "[today | tomorrow] <time> <text>",
+		Example:     "/schedule tomorrow 9am Standup starts now",
+		Category:    categoryGeneral,
+		Handler:     (*Bot).handleSchedule,
+	})
+}
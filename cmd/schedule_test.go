@@ -0,0 +1,150 @@
+/*
+Copyright © 2024 Pavlo Tarasiuk <pasha.tarasyuk@gmail.com>
+*/
+package cmd
+
+import (
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/slack-go/slack"
+)
+
+func TestParseScheduleTime(t *testing.T) {
+	// 12:00 UTC is 15:00 in Kyiv and 08:00 in New York
+	now := time.Date(2024, 4, 5, 12, 0, 0, 0, time.UTC)
+	kyiv, err := time.LoadLocation("Europe/Kyiv")
+	if err != nil {
+		t.Fatal(err)
+	}
+	newYork, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		spec    string
+		loc     *time.Location
+		want    time.Time
+		wantErr bool
+	}{
+		{spec: "9am", loc: time.UTC, want: time.Date(2024, 4, 6, 9, 0, 0, 0, time.UTC)},
+		{spec: "9am", loc: kyiv, want: time.Date(2024, 4, 6, 6, 0, 0, 0, time.UTC)},
+		{spec: "9am", loc: newYork, want: time.Date(2024, 4, 5, 13, 0, 0, 0, time.UTC)},
+		{spec: "9pm", loc: kyiv, want: time.Date(2024, 4, 5, 18, 0, 0, 0, time.UTC)},
+		{spec: "9:30 PM", loc: newYork, want: time.Date(2024, 4, 6, 1, 30, 0, 0, time.UTC)},
+		{spec: "14:00", loc: time.UTC, want: time.Date(2024, 4, 5, 14, 0, 0, 0, time.UTC)},
+		{spec: "tomorrow 9am", loc: newYork, want: time.Date(2024, 4, 6, 13, 0, 0, 0, time.UTC)},
+		// A passed time of today is returned as it is, for the caller to refuse
+		{spec: "today 9am", loc: kyiv, want: time.Date(2024, 4, 5, 6, 0, 0, 0, time.UTC)},
+		{spec: "noon", loc: time.UTC, wantErr: true},
+		{spec: "25:00", loc: time.UTC, wantErr: true},
+		{spec: "tomorrow", loc: time.UTC, wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := parseScheduleTime(tt.spec, now, tt.loc)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("parseScheduleTime(%q, %s) error = %v, want error %t", tt.spec, tt.loc, err, tt.wantErr)
+			continue
+		}
+		if !got.Equal(tt.want) {
+			t.Errorf("parseScheduleTime(%q, %s) = %s, want %s", tt.spec, tt.loc, got.UTC(), tt.want)
+		}
+		if err == nil && got.Location() != tt.loc {
+			t.Errorf("parseScheduleTime(%q, %s) is in %s, want the user's timezone", tt.spec, tt.loc, got.Location())
+		}
+	}
+}
+
+func TestSplitScheduleArgs(t *testing.T) {
+	tests := []struct {
+		text, spec, rest string
+	}{
+		{"9am Standup starts now", "9am", "Standup starts now"},
+		{"9 am Standup", "9 am", "Standup"},
+		{"tomorrow 9:30pm Deploy", "tomorrow 9:30pm", "Deploy"},
+		{"Today 9 PM Deploy", "Today 9 PM", "Deploy"},
+		{"9am", "9am", ""},
+		{"", "", ""},
+	}
+	for _, tt := range tests {
+		spec, rest := splitScheduleArgs(tt.text)
+		if spec != tt.spec || rest != tt.rest {
+			t.Errorf("splitScheduleArgs(%q) = %q, %q, want %q, %q", tt.text, spec, rest, tt.spec, tt.rest)
+		}
+	}
+}
+
+func TestScheduleInUserTimezone(t *testing.T) {
+	tests := []struct {
+		name string
+		// tz is the timezone of the user in Slack, fallback the configured one
+		tz, fallback string
+		text         string
+		want         string
+		// postAt is when the message is scheduled, zero for none
+		postAt time.Time
+	}{
+		{
+			name:   "New York",
+			tz:     "America/New_York",
+			text:   "9am Standup starts now",
+			want:   "Scheduled for Fri 5 Apr 09:00 (America/New_York), Fri 5 Apr 13:00 UTC",
+			postAt: time.Date(2024, 4, 5, 13, 0, 0, 0, time.UTC),
+		},
+		{
+			name:   "Kyiv",
+			tz:     "Europe/Kyiv",
+			text:   "9am Standup starts now",
+			want:   "Scheduled for Sat 6 Apr 09:00 (Europe/Kyiv), Sat 6 Apr 06:00 UTC",
+			postAt: time.Date(2024, 4, 6, 6, 0, 0, 0, time.UTC),
+		},
+		{
+			name:     "configured timezone",
+			fallback: "America/New_York",
+			text:     "9am Standup starts now",
+			want:     "Scheduled for Fri 5 Apr 09:00 (America/New_York), Fri 5 Apr 13:00 UTC",
+			postAt:   time.Date(2024, 4, 5, 13, 0, 0, 0, time.UTC),
+		},
+		{name: "passed", tz: "Europe/Kyiv", text: "today 9am Standup", want: "That time has already passed"},
+		{name: "no text", tz: "Europe/Kyiv", text: "9am", want: usageOf("/schedule") + ", e.g. /schedule 9am Standup starts now"},
+		{
+			name: "invalid time",
+			tz:   "Europe/Kyiv",
+			text: "noon Standup",
+			want: `I don't understand the time "noon". ` + usageOf("/schedule") + ", e.g. /schedule 9am Standup starts now",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := testConfig(t)
+			cfg.Timezone = tt.fallback
+			b, fake := newTestBot(t, cfg)
+			b.now = newFakeClock().now
+			fake.answer("users.info", `{"ok":true,"user":{"id":"U1","tz":"`+tt.tz+`"}}`)
+			fake.answer("chat.scheduleMessage", `{"ok":true,"channel":"C1","scheduled_message_id":"Q1"}`)
+			fake.answer("chat.scheduledMessages.list", `{"ok":true,"scheduled_messages":[{"id":"Q1","channel_id":"C1","date_created":1}]}`)
+
+			resp, err := b.handleSchedule(slack.SlashCommand{Command: "/schedule", Text: tt.text, UserID: "U1", ChannelID: "C1"})
+			if err != nil {
+				t.Fatalf("/schedule failed: %v", err)
+			}
+			if resp.Text != tt.want {
+				t.Errorf("answered %q, want %q", resp.Text, tt.want)
+			}
+			scheduled := fake.calls("chat.scheduleMessage")
+			if tt.postAt.IsZero() {
+				if len(scheduled) != 0 {
+					t.Errorf("scheduled a message")
+				}
+				return
+			}
+			if len(scheduled) != 1 {
+				t.Fatalf("got %d chat.scheduleMessage calls, want 1", len(scheduled))
+			}
+			if got, want := scheduled[0].Form.Get("post_at"), strconv.FormatInt(tt.postAt.Unix(), 10); got != want {
+				t.Errorf("post_at = %s, want %s", got, want)
+			}
+		})
+	}
+}
@@ -0,0 +1,72 @@
+/*
+Copyright © 2024 Pavlo Tarasiuk <pasha.tarasyuk@gmail.com>
+*/
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// SecretProvider looks up a secret by the name of the environment variable it would otherwise be read from
+type SecretProvider interface {
+	Secret(key string) (string, error)
+}
+
+// fileSecrets is the default SecretProvider. A secret is read from the file KEY_FILE names, the way
+// Docker and Kubernetes secrets are mounted, then from a file named KEY in dir and only then from
+// the KEY variable itself, so tokens don't have to appear in the environment.
+type fileSecrets struct {
+	dir string
+}
+
+// Secret implements SecretProvider
+func (s fileSecrets) Secret(key string) (string, error) {
+	if path := os.Getenv(key + "_FILE"); path != "" {
+		return readSecretFile(key, path)
+	}
+	if s.dir != "" {
+		path := filepath.Join(s.dir, key)
+		if _, err := os.Stat(path); err == nil {
+			return readSecretFile(key, path)
+		}
+	}
+	return os.Getenv(key), nil
+}
+
+// readSecretFile reads the secret from the file, the trailing newline editors add is dropped
+func readSecretFile(key, path string) (string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to read %s: %w", key, err)
+	}
+	return strings.TrimSpace(string(data)), nil
+}
+
+// secretFields returns the secrets of the configuration by the variable they are read from
+func (c *Config) secretFields() map[string]*string {
+	return map[string]*string{
+		"SLACK_AUTH_TOKEN":      &c.BotToken,
+		"SLACK_APP_TOKEN":       &c.AppToken,
+		"MAVBOT_CLIENT_SECRET":  &c.ClientSecret,
+		"MAVBOT_REFRESH_TOKEN":  &c.RefreshToken,
+		"MAVBOT_WEBHOOK_SECRET": &c.WebhookSecret,
+		"MAVBOT_ACTION_SECRET":  &c.ActionSecret,
+		"MAVBOT_POSTING_TOKENS": &c.PostingTokens,
+		"MAVBOT_STORE_DSN":      &c.StoreDSN,
+	}
+}
+
+// loadSecrets fills in the secrets of the configuration from the provider
+func (c *Config) loadSecrets(secrets SecretProvider) error {
+	for key, field := range c.secretFields() {
+		value, err := secrets.Secret(key)
+		if err != nil {
+			return err
+		}
+		*field = value
+	}
+	return nil
+}
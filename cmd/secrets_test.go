@@ -0,0 +1,101 @@
+/*
+Copyright © 2024 Pavlo Tarasiuk <pasha.tarasyuk@gmail.com>
+*/
+package cmd
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// writeSecret writes the secret to a file named after the key in dir and returns its path
+func writeSecret(t *testing.T, dir, key, value string) string {
+	t.Helper()
+	path := filepath.Join(dir, key)
+	if err := os.WriteFile(path, []byte(value), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestFileSecrets(t *testing.T) {
+	tests := []struct {
+		name string
+		// file is written to its own file named by SLACK_AUTH_TOKEN_FILE, inDir to the secrets directory
+		file, inDir, env string
+		want             string
+	}{
+		{name: "file variable", file: "xoxb-file\n", want: "xoxb-file"},
+		{name: "secrets directory", inDir: "xoxb-dir\n", want: "xoxb-dir"},
+		{name: "environment", env: "xoxb-env", want: "xoxb-env"},
+		{name: "file variable over directory", file: "xoxb-file", inDir: "xoxb-dir", env: "xoxb-env", want: "xoxb-file"},
+		{name: "directory over environment", inDir: "xoxb-dir", env: "xoxb-env", want: "xoxb-dir"},
+		{name: "nowhere"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			t.Setenv("SLACK_AUTH_TOKEN", tt.env)
+			t.Setenv("SLACK_AUTH_TOKEN_FILE", "")
+			if tt.file != "" {
+				t.Setenv("SLACK_AUTH_TOKEN_FILE", writeSecret(t, t.TempDir(), "token", tt.file))
+			}
+			if tt.inDir != "" {
+				writeSecret(t, dir, "SLACK_AUTH_TOKEN", tt.inDir)
+			}
+			got, err := fileSecrets{dir: dir}.Secret("SLACK_AUTH_TOKEN")
+			if err != nil {
+				t.Fatalf("Secret() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("Secret() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFileSecretsMissingFile(t *testing.T) {
+	t.Setenv("SLACK_AUTH_TOKEN_FILE", filepath.Join(t.TempDir(), "missing"))
+	if _, err := (fileSecrets{}).Secret("SLACK_AUTH_TOKEN"); err == nil {
+		t.Errorf("a missing secret file isn't an error")
+	}
+}
+
+func TestConfigLoadsTokensFromSecretsFiles(t *testing.T) {
+	dir := t.TempDir()
+	for _, key := range []string{"SLACK_AUTH_TOKEN", "SLACK_APP_TOKEN", "MAVBOT_WEBHOOK_SECRET"} {
+		t.Setenv(key, "")
+		t.Setenv(key+"_FILE", "")
+	}
+	writeSecret(t, dir, "SLACK_AUTH_TOKEN", "xoxb-from-file\n")
+	writeSecret(t, dir, "SLACK_APP_TOKEN", "xapp-from-file")
+	t.Setenv("MAVBOT_WEBHOOK_SECRET_FILE", writeSecret(t, t.TempDir(), "webhook", "hook-secret"))
+	t.Setenv("MAVBOT_SECRETS_DIR", dir)
+
+	cfg, err := loadConfig()
+	if err != nil {
+		t.Fatalf("loadConfig() error = %v", err)
+	}
+	if cfg.BotToken != "xoxb-from-file" || cfg.AppToken != "xapp-from-file" || cfg.WebhookSecret != "hook-secret" {
+		t.Errorf("loaded %q, %q and %q, want the tokens from the files", cfg.BotToken, cfg.AppToken, cfg.WebhookSecret)
+	}
+	if os.Getenv("SLACK_AUTH_TOKEN") != "" {
+		t.Errorf("the token ended up in the environment")
+	}
+}
+
+// failingSecrets is a SecretProvider that can't be reached
+type failingSecrets struct{}
+
+// Secret implements SecretProvider
+func (failingSecrets) Secret(key string) (string, error) {
+	return "", errors.New("secret manager unavailable")
+}
+
+func TestConfigSecretProviderError(t *testing.T) {
+	if _, err := loadConfigWith(failingSecrets{}); err == nil {
+		t.Errorf("loadConfigWith() ignored the failing provider")
+	}
+}
@@ -0,0 +1,88 @@
+/*
+Copyright © 2024 Pavlo Tarasiuk <pasha.tarasyuk@gmail.com>
+*/
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/slack-go/slack"
+)
+
+// selfTestCheck is a single step of the /selftest diagnostic
+type selfTestCheck struct {
+	name string
+	run  func() error
+}
+
+// handleSelfTest exercises the Slack API calls the bot depends on and reports
+// which of them succeeded, so missing scopes or permissions are easy to spot
+func (b *Bot) handleSelfTest(command slack.SlashCommand) (*SlashResponse, error) {
+	// The reaction is added to the test message, so remember where it was posted
+	var testMessageTS string
+
+	checks := []selfTestCheck{
+		{
+			name: "users.info on the caller",
+			run: func() error {
+				_, err := b.api().GetUserInfo(command.UserID)
+				return err
+			},
+		},
+		{
+			name: "chat.postMessage to this channel",
+			run: func() error {
+				_, ts, err := b.api().PostMessage(command.ChannelID, slack.MsgOptionText("MAVBot self-test message", false))
+				testMessageTS = ts
+				return err
+			},
+		},
+		{
+			name: "reactions.add on the test message",
+			run: func() error {
+				if testMessageTS == "" {
+					return errors.New("skipped, the test message was not posted")
+				}
+				return b.api().AddReaction("white_check_mark", slack.NewRefToMessage(command.ChannelID, testMessageTS))
+			},
+		},
+		{
+			name: "chat.scheduleMessage 1 minute out",
+			run: func() error {
+				postAt := strconv.FormatInt(b.now().Add(time.Minute).Unix(), 10)
+				_, _, err := b.api().ScheduleMessage(command.ChannelID, postAt, slack.MsgOptionText("MAVBot self-test scheduled message", false))
+				return err
+			},
+		},
+	}
+
+	return ephemeral(runSelfTest(checks)), nil
+}
+
+// runSelfTest runs every check, even after a failure, and renders the outcome as a checklist
+func runSelfTest(checks []selfTestCheck) string {
+	var report strings.Builder
+	report.WriteString("*MAVBot self-test*\n")
+	for _, check := range checks {
+		if err := check.run(); err != nil {
+			fmt.Fprintf(&report, ":x: %s: %v\n", check.name, err)
+		} else {
+			fmt.Fprintf(&report, ":white_check_mark: %s\n", check.name)
+		}
+	}
+	return report.String()
+}
+
+func init() {
+	registerSlashCommand(&slashCommand{
+		Name:        "/selftest",
+		Description: "Check that the bot's Slack scopes and permissions work",
+		Category:    categoryAdmin,
+		AdminOnly:   true,
+		Handler:     (*Bot).handleSelfTest,
+	})
+}
@@ -0,0 +1,88 @@
+/*
+Copyright © 2024 Pavlo Tarasiuk <pasha.tarasyuk@gmail.com>
+*/
+package cmd
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/slack-go/slack"
+)
+
+func TestSelfTest(t *testing.T) {
+	const missingScope = `{"ok":false,"error":"missing_scope"}`
+	tests := []struct {
+		name    string
+		answers map[string]string
+		want    []string
+	}{
+		{
+			name: "all pass",
+			want: []string{
+				":white_check_mark: users.info on the caller",
+				":white_check_mark: chat.postMessage to this channel",
+				":white_check_mark: reactions.add on the test message",
+				":white_check_mark: chat.scheduleMessage 1 minute out",
+			},
+		},
+		{
+			name:    "reaction fails",
+			answers: map[string]string{"reactions.add": missingScope},
+			want: []string{
+				":white_check_mark: users.info on the caller",
+				":white_check_mark: chat.postMessage to this channel",
+				":x: reactions.add on the test message: missing_scope",
+				":white_check_mark: chat.scheduleMessage 1 minute out",
+			},
+		},
+		{
+			name: "post fails",
+			answers: map[string]string{
+				"users.info":       missingScope,
+				"chat.postMessage": `{"ok":false,"error":"not_in_channel"}`,
+			},
+			want: []string{
+				":x: users.info on the caller: missing_scope",
+				":x: chat.postMessage to this channel: not_in_channel",
+				":x: reactions.add on the test message: skipped, the test message was not posted",
+				":white_check_mark: chat.scheduleMessage 1 minute out",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b, fake := newTestBot(t, nil)
+			fake.answer("users.info", `{"ok":true,"user":{"id":"U1","name":"pasha"}}`)
+			for method, answer := range tt.answers {
+				fake.answer(method, answer)
+			}
+
+			response, err := b.handleSelfTest(slack.SlashCommand{UserID: "U1", ChannelID: "C1"})
+			if err != nil {
+				t.Fatalf("selftest failed: %v", err)
+			}
+			if response.ResponseType != slack.ResponseTypeEphemeral {
+				t.Errorf("got response type %q, want ephemeral", response.ResponseType)
+			}
+			lines := strings.Split(strings.TrimSpace(response.Text), "\n")[1:]
+			if strings.Join(lines, "\n") != strings.Join(tt.want, "\n") {
+				t.Errorf("got checklist\n%s\nwant\n%s", strings.Join(lines, "\n"), strings.Join(tt.want, "\n"))
+			}
+		})
+	}
+}
+
+func TestSelfTestIsAdminOnly(t *testing.T) {
+	b, fake := newTestBot(t, nil)
+	response, err := b.dispatchSlashCommand(slack.SlashCommand{Command: "/selftest", UserID: "U1", ChannelID: "C1"})
+	if err != nil {
+		t.Fatalf("dispatch failed: %v", err)
+	}
+	if !strings.Contains(response.Text, "admins only") {
+		t.Errorf("got %q, want the command refused", response.Text)
+	}
+	if calls := fake.calls("chat.postMessage"); len(calls) != 0 {
+		t.Errorf("a non-admin ran the self-test")
+	}
+}
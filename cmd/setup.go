@@ -0,0 +1,67 @@
+/*
+Copyright © 2024 Pavlo Tarasiuk <pasha.tarasyuk@gmail.com>
+*/
+package cmd
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/slack-go/slack"
+)
+
+// setupFlow walks a user through their preferences, the language and the reminders
+var setupFlow = &conversationFlow{
+	Name: "setup",
+	Steps: []conversationStep{
+		{
+			Prompt: "Which language should MAVBot talk to you in? Reply with its code, or `cancel` to stop.",
+			Parse: func(b *Bot, answer string) (string, error) {
+				if !b.messages().has(answer) {
+					return "", fmt.Errorf("Unknown language %q, available: %s", answer, strings.Join(b.messages().list(), ", "))
+				}
+				return answer, nil
+			},
+		},
+		{
+			Prompt: "Should MAVBot remind you when you've been away for a while? Reply `yes` or `no`.",
+			Parse: func(_ *Bot, answer string) (string, error) {
+				switch strings.ToLower(answer) {
+				case "yes", "y":
+					return "yes", nil
+				case "no", "n":
+					return "no", nil
+				}
+				return "", fmt.Errorf("Please reply `yes` or `no`")
+			},
+		},
+	},
+	Complete: func(b *Bot, userID string, values []string) (string, error) {
+		prefs := b.userPrefs(userID)
+		prefs.Locale = values[0]
+		prefs.NoNudges = values[1] == "no"
+		if err := b.store.Put(collectionPrefs, userID, prefs); err != nil {
+			return "", fmt.Errorf("failed to save prefs: %w", err)
+		}
+		return "All set! You can change these any time with /prefs", nil
+	},
+}
+
+// handleSetup starts the setup conversation, the answers are the user's next messages in the channel
+func (b *Bot) handleSetup(command slack.SlashCommand) (*SlashResponse, error) {
+	prompt, err := b.startConversation(setupFlow, command.UserID, command.ChannelID)
+	if err != nil {
+		return nil, err
+	}
+	return ephemeral(prompt), nil
+}
+
+func init() {
+	registerConversationFlow(setupFlow)
+	registerSlashCommand(&slashCommand{
+		Name:        "/setup",
+		Description: "Set up your preferences step by step",
+		Category:    categoryPersonal,
+		Handler:     (*Bot).handleSetup,
+	})
+}
@@ -0,0 +1,56 @@
+/*
+Copyright © 2024 Pavlo Tarasiuk <pasha.tarasyuk@gmail.com>
+*/
+package cmd
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/slack-go/slack"
+)
+
+// isSecretField reports whether the Config field holds a credential that must not be shown
+func isSecretField(name string) bool {
+	return strings.HasSuffix(name, "Token") || strings.HasSuffix(name, "Tokens") || strings.HasSuffix(name, "Secret") ||
+		strings.HasSuffix(name, "DSN")
+}
+
+// redact hides a secret value, only telling whether it is set
+func redact(value string) string {
+	if value == "" {
+		return "(unset)"
+	}
+	return "[redacted]"
+}
+
+// describeConfig lists every setting of cfg in declaration order, secrets redacted
+func describeConfig(cfg *Config) string {
+	var out strings.Builder
+	v := reflect.ValueOf(*cfg)
+	for i := 0; i < v.NumField(); i++ {
+		name := v.Type().Field(i).Name
+		value := fmt.Sprint(v.Field(i).Interface())
+		if isSecretField(name) {
+			value = redact(value)
+		}
+		fmt.Fprintf(&out, "%s: %s\n", name, value)
+	}
+	return out.String()
+}
+
+// handleConfig shows the configuration the bot is running with
+func (b *Bot) handleConfig(command slack.SlashCommand) (*SlashResponse, error) {
+	return ephemeral("*Effective configuration*\n```\n" + describeConfig(b.cfg) + "```"), nil
+}
+
+func init() {
+	registerSlashCommand(&slashCommand{
+		Name:        "/config",
+		Description: "Show the configuration MAVBot is running with, secrets redacted",
+		Category:    categoryAdmin,
+		AdminOnly:   true,
+		Handler:     (*Bot).handleConfig,
+	})
+}
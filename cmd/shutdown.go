@@ -0,0 +1,62 @@
+/*
+Copyright © 2024 Pavlo Tarasiuk <pasha.tarasyuk@gmail.com>
+*/
+package cmd
+
+import (
+	"context"
+	"log"
+	"sort"
+)
+
+// shutdown posts the messages still queued, announces the shutdown and flushes whatever is still
+// buffered. All of it is bounded by ShutdownTimeout so an unresponsive Slack API or webhook
+// receiver can't hold up the exit.
+func (b *Bot) shutdown() {
+	ctx, cancel := context.WithTimeout(context.Background(), b.cfg.ShutdownTimeout)
+	defer cancel()
+
+	// The offline message comes after the replies still queued, so it is the bot's last word
+	b.drainOutbound(ctx)
+	b.announceShutdown(ctx)
+	b.flush(ctx)
+}
+
+// announceShutdown posts the offline message to the status channel when it is enabled. It takes
+// the outbound path ahead of other messages; when it has to wait, flush posts it.
+func (b *Bot) announceShutdown(ctx context.Context) {
+	if !b.cfg.ShutdownNotice || b.cfg.StatusChannel == "" {
+		return
+	}
+
+	msg := outboundMessage{Channel: b.cfg.StatusChannel, Text: b.cfg.OfflineMessage, Priority: priorityHigh}
+	if _, err := b.within(ctx).postMessage(msg); err != nil {
+		log.Printf("failed to post offline message: %v\n", err)
+	}
+}
+
+// flush posts the messages still queued, waits for webhook deliveries still in flight, syncs the
+// event log to disk and logs the final metrics, which would otherwise be lost with the process
+func (b *Bot) flush(ctx context.Context) {
+	b.drainOutbound(ctx)
+	if b.webhook != nil {
+		if err := b.webhook.Flush(ctx); err != nil {
+			log.Printf("failed to flush webhook deliveries: %v\n", err)
+		}
+	}
+	if b.recorder != nil {
+		if err := b.recorder.Flush(); err != nil {
+			log.Println(err)
+		}
+	}
+
+	counters := b.metrics.snapshot()
+	names := make([]string, 0, len(counters))
+	for name := range counters {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		log.Printf("metric %s=%d\n", name, counters[name])
+	}
+}
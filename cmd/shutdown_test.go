@@ -0,0 +1,188 @@
+/*
+Copyright © 2024 Pavlo Tarasiuk <pasha.tarasyuk@gmail.com>
+*/
+package cmd
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestShutdownOfflineMessage(t *testing.T) {
+	tests := []struct {
+		name          string
+		notice        bool
+		statusChannel string
+		want          int
+	}{
+		{name: "enabled", notice: true, statusChannel: "C0STATUS", want: 1},
+		{name: "disabled", notice: false, statusChannel: "C0STATUS", want: 0},
+		{name: "no status channel", notice: true, want: 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := testConfig(t)
+			cfg.ShutdownNotice = tt.notice
+			cfg.StatusChannel = tt.statusChannel
+			b, fake := newTestBot(t, cfg)
+
+			b.shutdown()
+
+			calls := fake.calls("chat.postMessage")
+			if len(calls) != tt.want {
+				t.Fatalf("got %d posts, want %d", len(calls), tt.want)
+			}
+			if tt.want == 0 {
+				return
+			}
+			if got := calls[0].Form.Get("channel"); got != tt.statusChannel {
+				t.Errorf("posted to %q, want %q", got, tt.statusChannel)
+			}
+			if got := calls[0].Form.Get("text"); got != "MAVBot going offline for maintenance" {
+				t.Errorf("posted %q, want the default offline message", got)
+			}
+		})
+	}
+}
+
+func TestShutdownDoesNotHangOnSlack(t *testing.T) {
+	cfg := testConfig(t)
+	cfg.ShutdownNotice = true
+	cfg.StatusChannel = "C0STATUS"
+	cfg.ShutdownTimeout = 50 * time.Millisecond
+	b, fake := newTestBot(t, cfg)
+	release := make(chan struct{})
+	defer close(release)
+	fake.handle("chat.postMessage", func(w http.ResponseWriter, r *http.Request) {
+		<-release
+	})
+
+	start := time.Now()
+	b.shutdown()
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("shutdown took %s with Slack not answering, want it bounded by the timeout", elapsed)
+	}
+	if len(fake.calls("chat.postMessage")) != 1 {
+		t.Errorf("the offline message wasn't attempted")
+	}
+}
+
+// newQueueingBot creates a bot whose outbound budget of perMinute is spent, so posts wait in the queue
+func newQueueingBot(t *testing.T, perMinute int, timeout time.Duration) (*Bot, *fakeSlack) {
+	cfg := testConfig(t)
+	cfg.OutboundPerMinute = perMinute
+	cfg.ShutdownTimeout = timeout
+	b, fake := newTestBot(t, cfg)
+	b.outbound = newTokenBucket(perMinute, time.Now)
+	for b.outbound.tryTake() {
+	}
+	return b, fake
+}
+
+func TestShutdownDrainsTheOutboundQueue(t *testing.T) {
+	b, fake := newQueueingBot(t, 1200, 2*time.Second)
+	for _, text := range []string{"first", "second"} {
+		if _, err := b.postMessage(outboundMessage{Channel: "C1", Text: text}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if b.queue.len() != 2 {
+		t.Fatalf("%d messages are queued, want both", b.queue.len())
+	}
+
+	b.shutdown()
+
+	if got := strings.Join(fake.posts(), ","); got != "first,second" {
+		t.Errorf("posted %q on shutdown, want the queued messages in order", got)
+	}
+	if b.queue.len() != 0 {
+		t.Errorf("%d messages are left in the queue", b.queue.len())
+	}
+}
+
+func TestShutdownNoticeComesAfterTheQueue(t *testing.T) {
+	b, fake := newQueueingBot(t, 1200, 2*time.Second)
+	b.cfg.ShutdownNotice = true
+	b.cfg.StatusChannel = "C0STATUS"
+	for _, text := range []string{"first", "second"} {
+		b.postMessage(outboundMessage{Channel: "C1", Text: text})
+	}
+
+	b.shutdown()
+
+	want := "first,second,MAVBot going offline for maintenance"
+	if got := strings.Join(fake.posts(), ","); got != want {
+		t.Errorf("posted %q on shutdown, want the queued messages, then the offline message", got)
+	}
+}
+
+func TestShutdownGivesUpOnTheQueueAtTheTimeout(t *testing.T) {
+	logs := captureLog(t)
+	b, fake := newQueueingBot(t, 1, 50*time.Millisecond)
+	b.postMessage(outboundMessage{Channel: "C1", Text: "too late"})
+
+	start := time.Now()
+	b.shutdown()
+
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("shutdown took %s waiting for the budget, want it bounded by the timeout", elapsed)
+	}
+	if len(fake.posts()) != 0 {
+		t.Errorf("posted past the outbound budget")
+	}
+	if !strings.Contains(logs.String(), "dropped 1 queued messages on shutdown") {
+		t.Errorf("the lost message wasn't logged:\n%s", logs)
+	}
+}
+
+func TestShutdownFlushesWebhookAndMetrics(t *testing.T) {
+	logs := captureLog(t)
+	release := make(chan struct{})
+	var delivered int32
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+		atomic.AddInt32(&delivered, 1)
+	}))
+	defer receiver.Close()
+	b, _ := newTestBot(t, nil)
+	b.webhook = newWebhook(receiver.URL, "", 0, b.now)
+
+	b.metrics.inc(metricUnknownCommands)
+	b.emitEvent(eventSummary{Type: "app_mention", User: "U1", Channel: "C1"}, nil)
+	// The receiver answers once the shutdown waits for it
+	time.AfterFunc(20*time.Millisecond, func() { close(release) })
+	b.shutdown()
+
+	if atomic.LoadInt32(&delivered) != 1 {
+		t.Errorf("the webhook delivery in flight was lost on shutdown")
+	}
+	if !strings.Contains(logs.String(), "metric "+metricUnknownCommands+"=1") {
+		t.Errorf("the final metrics weren't logged:\n%s", logs)
+	}
+}
+
+func TestShutdownFlushesTheEventLog(t *testing.T) {
+	b, _ := newTestBot(t, nil)
+	path := filepath.Join(t.TempDir(), "events.jsonl")
+	recorder, err := newEventRecorder(path, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer recorder.Close()
+	b.recorder = recorder
+	event, _ := parseRecordedEvent([]byte(`{"type":"hello"}`))
+	b.processEvent(context.Background(), event, &fakeSocket{})
+
+	b.shutdown()
+
+	if recorded, _ := os.ReadFile(path); !strings.HasPrefix(string(recorded), `{"type":"hello"`) {
+		t.Errorf("the event log holds %q after shutdown", recorded)
+	}
+}
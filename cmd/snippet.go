@@ -0,0 +1,105 @@
+/*
+Copyright © 2024 Pavlo Tarasiuk <pasha.tarasyuk@gmail.com>
+*/
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/slack-go/slack"
+)
+
+// errMsgTooLong is the error Slack responds with when a message is over its size limit
+const errMsgTooLong = "msg_too_long"
+
+// isMsgTooLong reports whether Slack turned a message down for its size
+func isMsgTooLong(err error) bool {
+	var slackErr slack.SlackErrorResponse
+	return errors.As(err, &slackErr) && slackErr.Err == errMsgTooLong
+}
+
+// plainText flattens the content of the message into text, attachments and blocks included
+func (msg outboundMessage) plainText() string {
+	var parts []string
+	add := func(text string) {
+		if text = strings.TrimSpace(text); text != "" {
+			parts = append(parts, text)
+		}
+	}
+	add(msg.Text)
+	for _, attachment := range msg.Attachments {
+		add(attachment.Pretext)
+		add(attachment.Title)
+		add(attachment.Text)
+		for _, field := range attachment.Fields {
+			add(field.Title + ": " + field.Value)
+		}
+	}
+	for _, block := range msg.Blocks {
+		switch block := block.(type) {
+		case *slack.HeaderBlock:
+			if block.Text != nil {
+				add(block.Text.Text)
+			}
+		case *slack.SectionBlock:
+			if block.Text != nil {
+				add(block.Text.Text)
+			}
+			for _, field := range block.Fields {
+				add(field.Text)
+			}
+		}
+	}
+	return strings.Join(parts, "\n\n")
+}
+
+// postAsSnippet uploads the content of a message Slack found too long as a text file, into
+// the message's thread if it had one. Files can't be ephemeral, so the content of an
+// ephemeral message goes to the user's DM instead. It returns the timestamp of the file's message.
+func (b *Bot) postAsSnippet(msg outboundMessage) (string, error) {
+	channelID := msg.Channel
+	if msg.EphemeralTo != "" {
+		var err error
+		if channelID, err = b.openDM(msg.EphemeralTo); err != nil {
+			return "", err
+		}
+	}
+	// The thread is only known to the options, so they are applied to find it
+	_, values, err := slack.UnsafeApplyMsgOptions("", channelID, "", msg.Options...)
+	if err != nil {
+		return "", fmt.Errorf("failed to read message options: %w", err)
+	}
+	threadTS := values.Get("thread_ts")
+	if msg.EphemeralTo != "" {
+		threadTS = ""
+	}
+
+	content := msg.plainText()
+	summary, err := b.api().UploadFileV2(slack.UploadFileV2Parameters{
+		Content:         content,
+		FileSize:        len(content),
+		Filename:        "message.txt",
+		Title:           "Message",
+		InitialComment:  "The message was too long to post, so here it is as a file",
+		Channel:         channelID,
+		ThreadTimestamp: threadTS,
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to upload message as a file: %w", err)
+	}
+	// The upload doesn't tell where the file was shared, its info does once Slack has shared it
+	file, _, _, err := b.api().GetFileInfo(summary.ID, 0, 0)
+	if err != nil {
+		log.Printf("failed to get the message of file %s: %v\n", summary.ID, err)
+		return "", nil
+	}
+	for _, shares := range []map[string][]slack.ShareFileInfo{file.Shares.Public, file.Shares.Private} {
+		if share := shares[channelID]; len(share) > 0 {
+			return share[0].Ts, nil
+		}
+	}
+	return "", nil
+}
@@ -0,0 +1,116 @@
+/*
+Copyright © 2024 Pavlo Tarasiuk <pasha.tarasyuk@gmail.com>
+*/
+package cmd
+
+import (
+	"testing"
+
+	"github.com/slack-go/slack"
+)
+
+func TestPlainText(t *testing.T) {
+	tests := []struct {
+		name string
+		msg  outboundMessage
+		want string
+	}{
+		{name: "text", msg: outboundMessage{Text: " hello "}, want: "hello"},
+		{
+			name: "attachments",
+			msg: outboundMessage{Text: "Report", Attachments: []slack.Attachment{{
+				Title:  "Deploys",
+				Text:   "All green",
+				Fields: []slack.AttachmentField{{Title: "Failed", Value: "0"}},
+			}}},
+			want: "Report\n\nDeploys\n\nAll green\n\nFailed: 0",
+		},
+		{
+			name: "blocks",
+			msg: outboundMessage{Blocks: []slack.Block{
+				slack.NewHeaderBlock(slack.NewTextBlockObject(slack.PlainTextType, "Summary", false, false)),
+				slack.NewSectionBlock(slack.NewTextBlockObject(slack.MarkdownType, "*3* tickets", false, false),
+					[]*slack.TextBlockObject{slack.NewTextBlockObject(slack.MarkdownType, "open: 1", false, false)}, nil),
+				slack.NewDividerBlock(),
+			}},
+			want: "Summary\n\n*3* tickets\n\nopen: 1",
+		},
+	}
+	for _, tt := range tests {
+		if got := tt.msg.plainText(); got != tt.want {
+			t.Errorf("%s: plainText() = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+// newSnippetBot creates a bot whose posts Slack finds too long, with the file upload answered
+func newSnippetBot(t *testing.T) (*Bot, *fakeSlack) {
+	b, fake := newTestBot(t, nil)
+	fake.answer("chat.postMessage", `{"ok":false,"error":"msg_too_long"}`)
+	fake.answer("chat.postEphemeral", `{"ok":false,"error":"msg_too_long"}`)
+	fake.answer("files.getUploadURLExternal", `{"ok":true,"upload_url":"`+fake.apiURL()+`upload/F1","file_id":"F1"}`)
+	fake.answer("upload/F1", `{"ok":true}`)
+	fake.answer("files.completeUploadExternal", `{"ok":true,"files":[{"id":"F1","title":"Message"}]}`)
+	fake.answer("files.info", `{"ok":true,"file":{"id":"F1","shares":{"public":{"C1":[{"ts":"1712345678.000900"}]},"private":{"D1":[{"ts":"1712345678.000901"}]}}}}`)
+	fake.answer("conversations.open", `{"ok":true,"channel":{"id":"D1"}}`)
+	return b, fake
+}
+
+func TestMsgTooLongFallsBackToSnippet(t *testing.T) {
+	b, fake := newSnippetBot(t)
+
+	ts, err := b.postMessage(outboundMessage{
+		Channel: "C1",
+		Text:    "A very long report",
+		Options: []slack.MsgOption{slack.MsgOptionTS("1712345678.000100")},
+	})
+	if err != nil {
+		t.Fatalf("postMessage() error = %v", err)
+	}
+	if ts != "1712345678.000900" {
+		t.Errorf("ts = %q, want the timestamp of the shared file", ts)
+	}
+	uploads := fake.calls("upload/F1")
+	if len(uploads) != 1 || uploads[0].Form.Get("content") != "A very long report" {
+		t.Fatalf("uploads = %+v, want the message content", uploads)
+	}
+	completes := fake.calls("files.completeUploadExternal")
+	if len(completes) != 1 {
+		t.Fatalf("got %d files.completeUploadExternal calls, want 1", len(completes))
+	}
+	form := completes[0].Form
+	if form.Get("channel_id") != "C1" || form.Get("thread_ts") != "1712345678.000100" {
+		t.Errorf("shared to %q in thread %q, want C1 in the message's thread", form.Get("channel_id"), form.Get("thread_ts"))
+	}
+	if got := form.Get("initial_comment"); got != "The message was too long to post, so here it is as a file" {
+		t.Errorf("initial_comment = %q", got)
+	}
+}
+
+func TestMsgTooLongEphemeralGoesToDM(t *testing.T) {
+	b, fake := newSnippetBot(t)
+
+	ts, err := b.postMessage(outboundMessage{Channel: "C1", EphemeralTo: "U1", Text: "Only for you"})
+	if err != nil {
+		t.Fatalf("postMessage() error = %v", err)
+	}
+	if ts != "1712345678.000901" {
+		t.Errorf("ts = %q, want the timestamp of the file shared in the DM", ts)
+	}
+	completes := fake.calls("files.completeUploadExternal")
+	if len(completes) != 1 || completes[0].Form.Get("channel_id") != "D1" {
+		t.Errorf("files.completeUploadExternal calls = %+v, want one sharing to the DM", completes)
+	}
+}
+
+func TestOtherPostErrorsAreNotUploaded(t *testing.T) {
+	b, fake := newTestBot(t, nil)
+	fake.answer("chat.postMessage", `{"ok":false,"error":"channel_not_found"}`)
+
+	if _, err := b.postMessage(outboundMessage{Channel: "C1", Text: "hi"}); err == nil {
+		t.Fatalf("postMessage() succeeded, want channel_not_found")
+	}
+	if calls := fake.calls("files.getUploadURLExternal"); len(calls) != 0 {
+		t.Errorf("uploaded a file for an error other than msg_too_long")
+	}
+}
@@ -0,0 +1,171 @@
+/*
+Copyright © 2024 Pavlo Tarasiuk <pasha.tarasyuk@gmail.com>
+*/
+package cmd
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	_ "github.com/lib/pq"
+	_ "github.com/mattn/go-sqlite3"
+)
+
+// Store backends MAVBOT_STORE selects
+const (
+	// storeFile keeps each collection in a JSON file in DataDir
+	storeFile = "file"
+	// storeSQLite keeps the documents in a SQLite database file
+	storeSQLite = "sqlite"
+	// storePostgres keeps the documents in a PostgreSQL database
+	storePostgres = "postgres"
+)
+
+// validStoreBackend reports whether backend is one of the known Store backends
+func validStoreBackend(backend string) bool {
+	switch backend {
+	case storeFile, storeSQLite, storePostgres:
+		return true
+	}
+	return false
+}
+
+// openStore opens the Store the configuration selects. Closing it, when it is an io.Closer,
+// releases the database connection every collection shares.
+func openStore(cfg *Config) (Store, error) {
+	switch cfg.StoreBackend {
+	case storeSQLite:
+		if err := os.MkdirAll(cfg.DataDir, 0o750); err != nil {
+			return nil, fmt.Errorf("failed to create data directory: %w", err)
+		}
+		return newSQLStore("sqlite3", cfg.StoreDSN)
+	case storePostgres:
+		return newSQLStore("postgres", cfg.StoreDSN)
+	}
+	return newFileStore(cfg.DataDir)
+}
+
+// sqlStore is a Store keeping every collection in one table of a SQL database
+type sqlStore struct {
+	db *sql.DB
+	// postgres numbers the query parameters, $1, instead of using ?
+	postgres bool
+}
+
+// newSQLStore connects to the database with the database/sql driver and creates the documents
+// table unless it exists
+func newSQLStore(driver, dsn string) (*sqlStore, error) {
+	db, err := sql.Open(driver, dsn)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open the %s store: %w", driver, err)
+	}
+	if driver == "sqlite3" {
+		// SQLite takes one writer at a time, a single connection queues them instead of failing
+		db.SetMaxOpenConns(1)
+	}
+	s := &sqlStore{db: db, postgres: driver == "postgres"}
+	_, err = db.Exec(`CREATE TABLE IF NOT EXISTS mavbot_documents (
+		collection TEXT NOT NULL,
+		doc_key    TEXT NOT NULL,
+		value      TEXT NOT NULL,
+		PRIMARY KEY (collection, doc_key)
+	)`)
+	if err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to create the %s store: %w", driver, err)
+	}
+	return s, nil
+}
+
+// query adapts a query written with ? parameters to the database
+func (s *sqlStore) query(q string) string {
+	if !s.postgres {
+		return q
+	}
+	var out strings.Builder
+	n := 0
+	for _, r := range q {
+		if r == '?' {
+			n++
+			out.WriteString("$" + strconv.Itoa(n))
+			continue
+		}
+		out.WriteRune(r)
+	}
+	return out.String()
+}
+
+// Get implements Store
+func (s *sqlStore) Get(collection, key string, v interface{}) (bool, error) {
+	var raw string
+	err := s.db.QueryRow(s.query(`SELECT value FROM mavbot_documents WHERE collection = ? AND doc_key = ?`),
+		collection, key).Scan(&raw)
+	if errors.Is(err, sql.ErrNoRows) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to read %s/%s: %w", collection, key, err)
+	}
+	if err := json.Unmarshal([]byte(raw), v); err != nil {
+		return false, fmt.Errorf("failed to decode %s/%s: %w", collection, key, err)
+	}
+	return true, nil
+}
+
+// Put implements Store
+func (s *sqlStore) Put(collection, key string, v interface{}) error {
+	raw, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Errorf("failed to encode %s/%s: %w", collection, key, err)
+	}
+	_, err = s.db.Exec(s.query(`INSERT INTO mavbot_documents (collection, doc_key, value) VALUES (?, ?, ?)
+		ON CONFLICT (collection, doc_key) DO UPDATE SET value = excluded.value`),
+		collection, key, string(raw))
+	if err != nil {
+		return fmt.Errorf("failed to write %s/%s: %w", collection, key, err)
+	}
+	return nil
+}
+
+// Delete implements Store
+func (s *sqlStore) Delete(collection, key string) error {
+	_, err := s.db.Exec(s.query(`DELETE FROM mavbot_documents WHERE collection = ? AND doc_key = ?`), collection, key)
+	if err != nil {
+		return fmt.Errorf("failed to delete %s/%s: %w", collection, key, err)
+	}
+	return nil
+}
+
+// Keys implements Store
+func (s *sqlStore) Keys(collection string) ([]string, error) {
+	rows, err := s.db.Query(s.query(`SELECT doc_key FROM mavbot_documents WHERE collection = ?`), collection)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", collection, err)
+	}
+	defer rows.Close()
+	keys := []string{}
+	for rows.Next() {
+		var key string
+		if err := rows.Scan(&key); err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", collection, err)
+		}
+		keys = append(keys, key)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", collection, err)
+	}
+	// Sorted here, the database's collation may order them differently
+	sort.Strings(keys)
+	return keys, nil
+}
+
+// Close closes the database connection
+func (s *sqlStore) Close() error {
+	return s.db.Close()
+}
@@ -7,10 +7,13 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"io"
 	"log"
+	"net/http"
 	"os"
+	"os/signal"
 	"strings"
-	"time"
+	"syscall"
 
 	"github.com/joho/godotenv"
 	"github.com/slack-go/slack"
@@ -32,13 +35,35 @@ var startCmd = &cobra.Command{
 		// Load Env variables from .env file
 		godotenv.Load(".env")
 
-		token := os.Getenv("SLACK_AUTH_TOKEN")
-		appToken := os.Getenv("SLACK_APP_TOKEN")
+		cfg, err := loadConfig()
+		if err != nil {
+			log.Fatal(err)
+		}
+		// Capturing starts right away, so /logs shows the start-up too
+		var logs *logRing
+		if cfg.LogBuffer > 0 {
+			logs = captureLogs(cfg.LogBuffer)
+		}
 
 		// Create a new client to slack by giving token
 		// Set debug to true while developing
 		// Also add a ApplicationToken option to the client
-		client := slack.New(token, slack.OptionDebug(true), slack.OptionAppLevelToken(appToken))
+		// Every Web API request goes through the timing transport to surface slow calls,
+		// read-only requests are repeated when the network lets them down
+		faults := &faultInjector{}
+		httpClient := &http.Client{Transport: newRetryTransport(
+			newTimingTransport(faultTransport{next: http.DefaultTransport, injector: faults}, cfg.SlowThreshold),
+			cfg.HTTPRetries, cfg.HTTPRetryDelay,
+		)}
+		// Clients are created again with every rotated bot token
+		newClient := func(token string) *slack.Client {
+			return slack.New(token,
+				slack.OptionDebug(true),
+				slack.OptionAppLevelToken(cfg.AppToken),
+				slack.OptionHTTPClient(httpClient),
+			)
+		}
+		client := newClient(cfg.BotToken)
 		// go-slack comes with a SocketMode package that we need to use
 		// that accepts a Slack client and outputs a Socket mode client instead
 		socketClient := socketmode.New(
@@ -47,13 +72,78 @@ var startCmd = &cobra.Command{
 			// Option to set a custom logger
 			socketmode.OptionLog(log.New(os.Stdout, "socketmode: ", log.Lshortfile|log.LstdFlags)),
 		)
+		store, err := openStore(cfg)
+		if err != nil {
+			log.Fatal(err)
+		}
+		if closer, ok := store.(io.Closer); ok {
+			defer closer.Close()
+		}
+		bot, err := newBot(client, cfg, store)
+		if err != nil {
+			log.Fatal(err)
+		}
+		bot.logs = logs
+		bot.faults = faults
+		if bot.allowlist, err = loadAllowlist(store, cfg.AllowedChannels); err != nil {
+			log.Fatal(err)
+		}
+		if bot.features, err = loadFeatureFlags(store, defaultFeatures(cfg)); err != nil {
+			log.Fatal(err)
+		}
+		if bot.maintenance, err = loadMaintenanceMode(store); err != nil {
+			log.Fatal(err)
+		}
+		if bot.signer, err = loadActionSigner(store, cfg.ActionSecret); err != nil {
+			log.Fatal(err)
+		}
+		var rotator *tokenRotator
+		if cfg.tokenRotationEnabled() {
+			if rotator, err = newTokenRotator(bot, httpClient, newClient); err != nil {
+				log.Fatal(err)
+			}
+		}
+		bot.tokenReload.secrets = fileSecrets{dir: cfg.SecretsDir}
+		// With rotation the token is reloaded by refreshing it
+		if rotator != nil {
+			bot.tokenReload.secrets = rotator
+		}
+		bot.tokenReload.newClient = newClient
+		bot.httpClient = httpClient
+		bot.canvases = newWebAPI(httpClient, bot.client)
+		postingTokens, err := parsePostingTokens(cfg.PostingTokens)
+		if err != nil {
+			log.Fatal(err)
+		}
+		for _, token := range postingTokens {
+			bot.clients.add(newClient(token.Token), token.Weight)
+		}
+		if err := bot.identify(); err != nil {
+			log.Fatal(err)
+		}
+		if cfg.EventLog != "" {
+			if bot.recorder, err = newEventRecorder(cfg.EventLog, int64(cfg.EventLogMaxSize)); err != nil {
+				log.Fatal(err)
+			}
+			defer bot.recorder.Close()
+		}
 
-		// Create a context that can be used to cancel goroutine
-		ctx, cancel := context.WithCancel(context.Background())
-		// Make this chanel called properly in a real program, graceful shutdown etc
+		// Create a context that is cancelled on SIGINT/SIGTERM so the goroutine and socket client stop together
+		ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
 		defer cancel()
+		// An inactive bot token that can't be reloaded shuts the bot down like a signal would
+		bot.tokenReload.shutdown = cancel
+
+		if rotator != nil {
+			go rotator.run(ctx)
+		}
+		go bot.reloadOnHangup(ctx)
+		go bot.runSummaries(ctx)
+		go bot.runHeartbeat(ctx)
+		go bot.runOutbound(ctx)
+		go bot.runLinks(ctx)
 
-		go func(ctx context.Context, client *slack.Client, socketClient *socketmode.Client) {
+		go func(ctx context.Context, bot *Bot, socketClient *socketmode.Client) {
 			// Create a for loop that selects either the context cancellation or the events incomming
 			for {
 				select {
@@ -62,69 +152,30 @@ var startCmd = &cobra.Command{
 					log.Println("Shutting down socketmode listener")
 					return
 				case event := <-socketClient.Events:
-					// We have a new Events, let's type switch the event
-					// Add more use cases here if you want to listen to other events.
-					switch event.Type {
-					// handle EventAPI events
-					case socketmode.EventTypeEventsAPI:
-						// The Event sent on the chanel is not the same as the EventAPI events so we need to type cast it
-						eventsAPIEvent, ok := event.Data.(slackevents.EventsAPIEvent)
-						if !ok {
-							log.Printf("Could not type cast the event to the EventsAPIEvent: %+v\n", event)
-							continue
-						}
-						// We need to send an Acknowledge to the slack server
-						socketClient.Ack(*event.Request)
-						// Now we have an Events API event, but this event type can in turn be many types, so we actually need another type switch
-						//log.Println(EventsAPIEvent)
-						err := handleEventMessage(eventsAPIEvent, client)
-						if err != nil {
-							// Replace with actual err handling
-							log.Fatal(err)
-						}
-
-					// handle Slash Commands
-					case socketmode.EventTypeSlashCommand:
-						// Just like before, type cast to the correct event type, this time a SlashEvent
-						command, ok := event.Data.(slack.SlashCommand)
-						if !ok {
-							log.Printf("Could not type cast the message to a SlashCommand: %+v\n", command)
-							continue
-						}
-						// handleSlashCommand will take care of the command
-						payload, err := handleSlashCommand(command, client)
-						if err != nil {
-							log.Fatal(err)
-						}
-						// Do'nt forget to acknowledge the request and send the payload
-						// The payload is the response
-						socketClient.Ack(*event.Request, payload)
-
-					// handle Interactive Events
-					case socketmode.EventTypeInteractive:
-						interaction, ok := event.Data.(slack.InteractionCallback)
-						if !ok {
-							log.Printf("Could not type cast the message to a Interaction callback: %+v\n", interaction)
-							continue
-						}
-
-						err := handleInteractiveEvent(interaction, client)
-						if err != nil {
-							log.Fatal(err)
-						}
-						socketClient.Ack(*event.Request)
-					}
-					// end of switch
+					// We have a new Events, let processEvent type switch and dispatch it
+					bot.processEvent(ctx, event, socketClient)
 				}
 			}
-		}(ctx, client, socketClient)
+		}(ctx, bot, socketClient)
+
+		err = socketClient.RunContext(ctx)
+		if err != nil && !errors.Is(err, context.Canceled) {
+			log.Println(err)
+		}
 
-		socketClient.Run()
+		// The context is cancelled at this point, let the channels know and flush buffers before exiting
+		bot.shutdown()
 	},
 }
 
-// handleEventMessage will take an event and handle it properly based on the type of event
-func handleEventMessage(event slackevents.EventsAPIEvent, client *slack.Client) error {
+// handleEventMessage will take an event and handle it properly based on the type of event. The
+// handlers work under ctx, once it is cancelled they can't post or change the state anymore.
+func (b *Bot) handleEventMessage(ctx context.Context, event slackevents.EventsAPIEvent) error {
+	return b.within(ctx).dispatchEvent(event)
+}
+
+// dispatchEvent calls the handler of the event's type
+func (b *Bot) dispatchEvent(event slackevents.EventsAPIEvent) error {
 	switch event.Type {
 	// First we check if this is a CallbackEvent
 	case slackevents.CallbackEvent:
@@ -135,10 +186,39 @@ func handleEventMessage(event slackevents.EventsAPIEvent, client *slack.Client)
 		case *slackevents.AppMentionEvent:
 			// The application has been mentioned since this Event is a Mention event
 			//log.Println(ev)
-			err := handleAppMentionEvent(ev, client)
+			err := b.handleAppMentionEvent(ev)
 			if err != nil {
 				return err
 			}
+		case *slackevents.MessageEvent:
+			return b.handleMessageEvent(ev)
+		case *slackevents.ReactionAddedEvent:
+			return b.recordVote(ev.User, ev.Reaction, ev.ItemUser, ev.Item, true)
+		case *slackevents.ReactionRemovedEvent:
+			return b.recordVote(ev.User, ev.Reaction, ev.ItemUser, ev.Item, false)
+		case *slackevents.PinAddedEvent:
+			return b.handlePinAdded(ev)
+		case *slackevents.PinRemovedEvent:
+			return b.handlePinRemoved(ev)
+		// Keep the channel caches in step with the workspace
+		case *slackevents.ChannelRenameEvent:
+			b.renameChannel(ev.Channel.ID, ev.Channel.Name)
+		case *slackevents.GroupRenameEvent:
+			b.renameChannel(ev.Channel.ID, ev.Channel.Name)
+		case *slackevents.ChannelCreatedEvent:
+			b.autoJoin(ev.Channel)
+		case *slackevents.ChannelDeletedEvent:
+			b.forgetChannel(ev.Channel)
+		case *slackevents.GroupDeletedEvent:
+			b.forgetChannel(ev.Channel)
+		case *slackevents.ChannelIDChangedEvent:
+			b.forgetChannel(ev.OldChannelID)
+		case *slackevents.EmojiChangedEvent:
+			b.emoji.apply(ev)
+		case *slackevents.GridMigrationStartedEvent:
+			b.handleGridMigrationStarted()
+		case *slackevents.GridMigrationFinishedEvent:
+			return b.handleGridMigrationFinished()
 		}
 	default:
 		return errors.New("unsupported event type")
@@ -147,145 +227,254 @@ func handleEventMessage(event slackevents.EventsAPIEvent, client *slack.Client)
 }
 
 // handleAppMentionEvent is used to take care of the AppMentionEvent when the bot is mentioned
-func handleAppMentionEvent(event *slackevents.AppMentionEvent, client *slack.Client) error {
+func (b *Bot) handleAppMentionEvent(event *slackevents.AppMentionEvent) error {
+	// Stay quiet in channels the bot isn't enabled in
+	if !b.channelAllowed(event.Channel) {
+		return nil
+	}
+	if b.maintenance.enabled() {
+		return b.answerMaintenance(event)
+	}
+	b.processLinks(event.Channel, event.User, threadOf(event.ThreadTimeStamp, event.TimeStamp), event.Text)
+	// Asking for help in a thread again means the issue isn't settled
+	if event.ThreadTimeStamp != "" {
+		b.reopenThread(event.Channel, event.ThreadTimeStamp)
+	}
+	inv := invocation{UserID: event.User, ChannelID: event.Channel}
+	for _, handler := range mentionHandlers {
+		ok, err := b.passes(handler.When, inv)
+		if err != nil {
+			return fmt.Errorf("failed to check the conditions of the %s mention handler: %w", handler.Name, err)
+		}
+		if ok {
+			return handler.Handler(b, event)
+		}
+	}
+	return nil
+}
+
+// handleGreeting greets the user who mentioned the bot or offers help
+func (b *Bot) handleGreeting(event *slackevents.AppMentionEvent) error {
+	receivedAt := b.now()
+	if !b.features.enabled(featureGreetings) {
+		return nil
+	}
 
 	// Grab the user name based on the ID of the one who mentioned the bot
-	user, err := client.GetUserInfo(event.User)
+	user, err := b.userInfo(event.User)
 	if err != nil {
 		return err
 	}
+	if !b.mayMention(user) {
+		if !b.cfg.MentionDeniedNotice {
+			return nil
+		}
+		_, err := b.postMessage(outboundMessage{
+			Channel:     event.Channel,
+			Invoker:     event.User,
+			EphemeralTo: event.User,
+			Text:        "Sorry, I only answer mentions from certain users in this workspace",
+		})
+		return err
+	}
 	// Check if the user said Hallo to the bot
 	text := strings.ToLower(event.Text)
 
-	// Create the attachment and assigned based on the message
-	attachment := slack.Attachment{}
-	// Add Some default context like user who mentioned the bot
-	attachment.Fields = []slack.AttachmentField{
-		{
-			Title: "Date",
-			Value: time.Now().Format("2006-01-02 15:04:05"),
-		}, {
-			Title: "Initializer",
-			Value: user.Name,
-		},
+	// Create the reply and add some default context like user who mentioned the bot
+	reply := b.mentionReply(user)
+	data := b.mentionData(user, event.Channel)
+	lang := b.messageLocale(event.User, event.Text)
+	greet := strings.Contains(text, "hello")
+	// Repeated greetings get the answer to any other mention
+	if greet && b.cfg.GreetOncePerDay {
+		if greet, err = b.firstGreetingToday(user); err != nil {
+			return err
+		}
 	}
-	if strings.Contains(text, "hello") {
+	if greet {
 		// Greet the user
-		attachment.Text = fmt.Sprintf("Hello %s", user.Name)
-		attachment.Pretext = "Greetings"
-		attachment.Color = "#4af030"
+		greeting, err := b.messages().render(lang, templateGreeting, data)
+		if err != nil {
+			return err
+		}
+		reply.Text(greeting).Style(templateGreeting)
 	} else {
-		// Send a message to the user
-		attachment.Text = fmt.Sprintf("How can I help you %s", user.Name)
-		attachment.Pretext = "How can I be of service?"
-		attachment.Color = "#3d3d3d"
+		switch b.cfg.MentionFallback {
+		case mentionFallbackSilent:
+			return nil
+		case mentionFallbackHelp:
+			_, err := b.postMessage(outboundMessage{
+				Channel:     event.Channel,
+				Invoker:     event.User,
+				EphemeralTo: event.User,
+				Text:        b.helpText(event.User),
+			})
+			return err
+		case mentionFallbackText:
+			reply.Text(b.cfg.MentionFallbackText).Color("#3d3d3d")
+		default:
+			// Send a message to the user
+			offer, err := b.messages().render(lang, templateHelpOffer, data)
+			if err != nil {
+				return err
+			}
+			reply.Text(offer).Style(templateHelpOffer)
+		}
 	}
 	// Send the message to the channel
 	// The Chanel is available in the event message
-	_, _, err = client.PostMessage(event.Channel, slack.MsgOptionAttachments(attachment))
-	if err != nil {
-		return fmt.Errorf("failed to post message: %w", err)
+	_, err = b.postMessage(outboundMessage{
+		Channel:     event.Channel,
+		Invoker:     event.User,
+		ReceivedAt:  receivedAt,
+		Attachments: []slack.Attachment{reply.Build()},
+	})
+	// Let the user know why there's no answer, only they see the note so it doesn't add to the flood
+	var limited *rateLimitedError
+	if errors.As(err, &limited) {
+		note, err := b.rateLimitedResponse(event.User, limited.Wait)
+		if err != nil {
+			return err
+		}
+		_, err = b.postMessage(outboundMessage{
+			Channel:     event.Channel,
+			Invoker:     event.User,
+			EphemeralTo: event.User,
+			Text:        note.Text,
+		})
+		return err
 	}
-
-	return nil
+	return err
 }
 
-// handleSlashCommand will take a slash command and route to the appropriate function
-func handleSlashCommand(command slack.SlashCommand, client *slack.Client) (interface{}, error) {
-	// We need to switch depending on the command
-	switch command.Command {
-	case "/hello":
-		// This was a hello command, so pass it along to the proper function
-		return nil, handleHelloCommand(command, client)
-	case "/was-this-article-useful":
-		return handleIsArticleGood(command, client)
+// handleSlashCommand will take a slash command and route to the appropriate function.
+// It returns the payload to acknowledge the command with.
+func (b *Bot) handleSlashCommand(command slack.SlashCommand) (interface{}, error) {
+	receivedAt := b.now()
+	// The same command submitted again right away, e.g. by a double Enter, was answered already
+	if b.commands.seen(commandFingerprint(command)) {
+		log.Printf("Dropped repeated %s from %s\n", command.Command, command.UserID)
+		return nil, nil
 	}
-	return nil, nil
+	response, err := b.dispatchSlashCommand(command)
+	if guided, ok := b.guidedErrorResponse(command, err); ok {
+		log.Printf("%s failed: %v\n", command.Command, err)
+		response, err = guided, nil
+	}
+	if err != nil || response == nil {
+		return nil, err
+	}
+	if response.done == nil {
+		response = b.addResponseTimeToResponse(b.sendPosts(command.UserID, response), receivedAt)
+	}
+	// The response is an outbound message too, so it is subject to the broadcast policy
+	payload, err := b.filterBroadcastPayload(response.message(), command.UserID)
+	if errors.Is(err, errBroadcastBlocked) {
+		return ephemeral("Sorry, I can't send a message that mentions the whole channel").message(), nil
+	}
+	return payload, err
 }
 
-// handleHelloCommand will take care of /hello submissions
-func handleHelloCommand(command slack.SlashCommand, client *slack.Client) error {
-	// The Input is found in the text field so
-	// Create the attachment and assigned based on the message
-	attachment := slack.Attachment{}
-	// Add Some default context like user who mentioned the bot
-	attachment.Fields = []slack.AttachmentField{
-		{
-			Title: "Date",
-			Value: time.Now().Format("2006-01-02 15:04:05"),
-		}, {
-			Title: "Initializer",
-			Value: command.UserName,
-		},
+// dispatchSlashCommand checks the user may run the command and calls its handler
+func (b *Bot) dispatchSlashCommand(command slack.SlashCommand) (*SlashResponse, error) {
+	// Look the command up in the registry
+	registered, ok := slashCommands[command.Command]
+	if !ok || (registered.DebugOnly && !b.cfg.Debug) {
+		return b.unknownCommandResponse(command)
+	}
+	if !registered.AdminOnly && b.maintenance.enabled() {
+		return ephemeral(b.cfg.MaintenanceMessage), nil
+	}
+	if registered.AdminOnly && !b.isAdmin(command.UserID) {
+		return ephemeral("Sorry, this command is available to MAVBot admins only"), nil
+	}
+	// Admin commands work everywhere, so the bot can be enabled from a channel it isn't allowed in yet
+	if !registered.AdminOnly && !b.channelAllowed(command.ChannelID) {
+		return ephemeral("MAVBot is not enabled in this channel"), nil
+	}
+	ok, err := b.passes(registered.When, invocation{UserID: command.UserID, ChannelID: command.ChannelID})
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return ephemeral(fmt.Sprintf("%s isn't available here at the moment", command.Command)), nil
+	}
+	response, err := b.invokeCached(registered, command)
+	var limited *rateLimitedError
+	if errors.As(err, &limited) {
+		return b.rateLimitedResponse(command.UserID, limited.Wait)
 	}
+	// A loading message is only the placeholder, the response replacing it gets formatted instead
+	if response != nil && response.done == nil {
+		response = renderResponse(response, registered.Format)
+	}
+	return response, err
+}
 
-	// Greet the user
-	attachment.Text = fmt.Sprintf("Hello %s! You said: %s", command.UserName, command.Text)
-	attachment.Color = "#4af030"
+// unknownCommandResponse tells the user the bot has no such command, counting and optionally
+// logging it so operators learn what users expect the bot to do
+func (b *Bot) unknownCommandResponse(command slack.SlashCommand) (*SlashResponse, error) {
+	b.metrics.inc(metricUnknownCommands)
+	if b.cfg.LogUnknownCommands {
+		log.Printf("Unknown command %s %q from %s in %s\n", command.Command, command.Text, command.UserID, command.ChannelID)
+	}
+	text, err := b.render(command.UserID, templateUnknownCommand, templateData{Text: command.Command})
+	if err != nil {
+		return nil, err
+	}
+	return ephemeral(text), nil
+}
 
-	// Send the message to the channel
-	// The Chanel is available in the command.ChannelID
-	_, _, err := client.PostMessage(command.ChannelID, slack.MsgOptionAttachments(attachment))
+// handleHelloCommand will take care of /hello submissions
+func (b *Bot) handleHelloCommand(command slack.SlashCommand) (*SlashResponse, error) {
+	// The Input is found in the text field, optionally starting with who should see the response
+	responseType, text := parseResponseType(command.Text, b.defaultResponseType(command.ChannelID, slack.ResponseTypeInChannel))
+	greeting, err := b.render(command.UserID, templateHelloCommand, templateData{User: command.UserName, Text: text})
 	if err != nil {
-		return fmt.Errorf("failed to post message: %w", err)
+		return nil, err
 	}
-	return nil
+	// Greet the user and add some default context like user who invoked the command
+	reply := b.reply().
+		Text(greeting).
+		Color("#4af030").
+		Field(fieldDate, b.formatTime(b.now(), b.userTimezone(command.UserID))).
+		Field(fieldInitializer, command.UserName)
+
+	// The response payload only reaches the conversation the command came from,
+	// so a reply routed elsewhere from a DM is posted to its channel instead
+	channel := b.replyChannel(command)
+	if responseType == slack.ResponseTypeInChannel && channel != command.ChannelID {
+		_, err := b.postMessage(outboundMessage{
+			Channel:     channel,
+			Invoker:     command.UserID,
+			Attachments: []slack.Attachment{reply.Build()},
+		})
+		if err != nil {
+			return nil, err
+		}
+		return ephemeral(fmt.Sprintf("Posted to <#%s>", channel)), nil
+	}
+	return slashPayload(responseType, reply.Build()), nil
 }
 
 // handleIsArticleGood will trigger a Yes or No question to the initializer
-func handleIsArticleGood(command slack.SlashCommand, client *slack.Client) (interface{}, error) {
-	// Create the attachment and assigned based on the message
-	attachment := slack.Attachment{}
-
-	// Create the checkbox element
-	checkbox := slack.NewCheckboxGroupsBlockElement("answer",
-		slack.NewOptionBlockObject(
-			"yes",
-			&slack.TextBlockObject{
-				Text: "Yes",
-				Type: slack.MarkdownType,
-			},
-			&slack.TextBlockObject{
-				Text: "Did you Enjoy it?",
-				Type: slack.MarkdownType,
-			},
-		),
-		slack.NewOptionBlockObject(
-			"no",
-			&slack.TextBlockObject{
-				Text: "No",
-				Type: slack.MarkdownType,
-			},
-			&slack.TextBlockObject{
-				Text: "Did you Dislike it?",
-				Type: slack.MarkdownType,
-			},
-		),
-	)
-	// Create the Accessory that will be included in the Block and add the checkbox to it
-	accessory := slack.NewAccessory(checkbox)
-	// Add Blocks to the attachment
-	attachment.Blocks = slack.Blocks{
-		BlockSet: []slack.Block{
-			// Create a new section block element and add some text and the accessory to it
-			slack.NewSectionBlock(
-				&slack.TextBlockObject{
-					Type: slack.MarkdownType,
-					Text: "Did you think this article was helpful?",
-				},
-				nil,
-				accessory,
-			),
-		},
+func (b *Bot) handleIsArticleGood(command slack.SlashCommand) (*SlashResponse, error) {
+	attachment, err := b.surveyAttachment(b.newSurvey(command.UserID, ""))
+	if err != nil {
+		return nil, err
 	}
+	return slashPayload(slack.ResponseTypeEphemeral, attachment), nil
+}
 
-	attachment.Text = "Rate the tutorial"
-	attachment.Color = "#4af030"
-	return attachment, nil
+// actionKey identifies a block action within an interaction
+func actionKey(action *slack.BlockAction) string {
+	return action.BlockID + "/" + action.ActionID + "/" + action.ActionTs
 }
 
-// handleInteractiveEvent will take care of interactive events
-func handleInteractiveEvent(interaction slack.InteractionCallback, client *slack.Client) error {
+// handleInteractiveEvent will take care of interactive events, size being the size of the raw payload.
+// It returns the payload to acknowledge the interaction with, if any. Block actions handled are
+// added to completed and those already in it are skipped, so processing can be retried safely.
+func (b *Bot) handleInteractiveEvent(interaction slack.InteractionCallback, size int, completed map[string]bool) (interface{}, error) {
 	// This is where we would handle the interaction
 	// Switch depending on the type
 	log.Printf("The action called is: %s\n", interaction.ActionID)
@@ -295,12 +484,27 @@ func handleInteractiveEvent(interaction slack.InteractionCallback, client *slack
 		// This is block action, so we need to handle it
 
 		for _, action := range interaction.ActionCallback.BlockActions {
+			key := actionKey(action)
+			if completed[key] {
+				continue
+			}
 			log.Printf("Action: %+v\n", action)
 			log.Println("Selected option: ", action.SelectedOptions)
+			if action.ActionID == surveyActionID {
+				if err := b.recordSurveyResponse(interaction, action); err != nil {
+					return nil, err
+				}
+			}
+			if err := b.runConfirmedAction(interaction, action); err != nil {
+				return nil, err
+			}
+			completed[key] = true
 		}
+	case slack.InteractionTypeViewSubmission:
+		return b.handleViewSubmission(interaction, size)
 	default:
 	}
-	return nil
+	return nil, nil
 }
 
 func init() {
@@ -0,0 +1,167 @@
+/*
+Copyright © 2024 Pavlo Tarasiuk <pasha.tarasyuk@gmail.com>
+*/
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+)
+
+// Store persists the bot's state as JSON documents grouped into collections
+type Store interface {
+	// Get decodes the document stored under key into v and reports whether it exists
+	Get(collection, key string, v interface{}) (bool, error)
+	// Put stores v under key, replacing any previous document
+	Put(collection, key string, v interface{}) error
+	// Delete removes the document under key, deleting a missing document is not an error
+	Delete(collection, key string) error
+	// Keys returns the keys of every document in the collection, sorted
+	Keys(collection string) ([]string, error)
+}
+
+// fileStore is the default Store, keeping each collection in its own JSON file in a directory
+type fileStore struct {
+	dir string
+
+	mu          sync.Mutex
+	collections map[string]map[string]json.RawMessage
+}
+
+// newFileStore creates a Store backed by JSON files in dir, creating the directory if needed
+func newFileStore(dir string) (*fileStore, error) {
+	if err := os.MkdirAll(dir, 0o750); err != nil {
+		return nil, fmt.Errorf("failed to create data directory: %w", err)
+	}
+	return &fileStore{
+		dir:         dir,
+		collections: make(map[string]map[string]json.RawMessage),
+	}, nil
+}
+
+// Get implements Store
+func (s *fileStore) Get(collection, key string, v interface{}) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	docs, err := s.load(collection)
+	if err != nil {
+		return false, err
+	}
+	raw, ok := docs[key]
+	if !ok {
+		return false, nil
+	}
+	if err := json.Unmarshal(raw, v); err != nil {
+		return false, fmt.Errorf("failed to decode %s/%s: %w", collection, key, err)
+	}
+	return true, nil
+}
+
+// Put implements Store
+func (s *fileStore) Put(collection, key string, v interface{}) error {
+	raw, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Errorf("failed to encode %s/%s: %w", collection, key, err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	docs, err := s.load(collection)
+	if err != nil {
+		return err
+	}
+	docs[key] = raw
+	return s.save(collection, docs)
+}
+
+// Delete implements Store
+func (s *fileStore) Delete(collection, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	docs, err := s.load(collection)
+	if err != nil {
+		return err
+	}
+	if _, ok := docs[key]; !ok {
+		return nil
+	}
+	delete(docs, key)
+	return s.save(collection, docs)
+}
+
+// Keys implements Store
+func (s *fileStore) Keys(collection string) ([]string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	docs, err := s.load(collection)
+	if err != nil {
+		return nil, err
+	}
+	keys := make([]string, 0, len(docs))
+	for key := range docs {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys, nil
+}
+
+// path returns the file holding the collection
+func (s *fileStore) path(collection string) string {
+	return filepath.Join(s.dir, collection+".json")
+}
+
+// load returns the collection, reading it from disk the first time. Callers must hold s.mu.
+func (s *fileStore) load(collection string) (map[string]json.RawMessage, error) {
+	if docs, ok := s.collections[collection]; ok {
+		return docs, nil
+	}
+
+	docs := make(map[string]json.RawMessage)
+	data, err := os.ReadFile(s.path(collection))
+	switch {
+	case os.IsNotExist(err):
+	case err != nil:
+		return nil, fmt.Errorf("failed to read %s: %w", collection, err)
+	default:
+		if err := json.Unmarshal(data, &docs); err != nil {
+			return nil, fmt.Errorf("failed to decode %s: %w", collection, err)
+		}
+	}
+	s.collections[collection] = docs
+	return docs, nil
+}
+
+// save writes the collection to disk. The file is replaced atomically so a crash
+// can't leave it half written. Callers must hold s.mu.
+func (s *fileStore) save(collection string, docs map[string]json.RawMessage) error {
+	data, err := json.MarshalIndent(docs, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode %s: %w", collection, err)
+	}
+
+	tmp, err := os.CreateTemp(s.dir, collection+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("failed to write %s: %w", collection, err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write %s: %w", collection, err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write %s: %w", collection, err)
+	}
+	if err := os.Rename(tmp.Name(), s.path(collection)); err != nil {
+		return fmt.Errorf("failed to write %s: %w", collection, err)
+	}
+	return nil
+}
@@ -0,0 +1,288 @@
+/*
+Copyright © 2024 Pavlo Tarasiuk <pasha.tarasyuk@gmail.com>
+*/
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// storeOpener opens a Store over the same storage every time it is called within a test
+type storeOpener func(t *testing.T) Store
+
+// closeStore closes the store with the test when it holds a connection
+func closeStore(t *testing.T, store Store) {
+	t.Cleanup(func() {
+		if closer, ok := store.(io.Closer); ok {
+			closer.Close()
+		}
+	})
+}
+
+// storeBackends are the Store backends the conformance suite runs against. Postgres needs a
+// database, given by MAVBOT_TEST_POSTGRES_DSN, and is skipped without one.
+func storeBackends() map[string]func(t *testing.T) storeOpener {
+	return map[string]func(t *testing.T) storeOpener{
+		storeFile: func(t *testing.T) storeOpener {
+			dir := t.TempDir()
+			return func(t *testing.T) Store {
+				store, err := newFileStore(dir)
+				if err != nil {
+					t.Fatalf("failed to open the file store: %v", err)
+				}
+				return store
+			}
+		},
+		storeSQLite: func(t *testing.T) storeOpener {
+			dsn := filepath.Join(t.TempDir(), "mavbot.db")
+			return func(t *testing.T) Store {
+				store, err := newSQLStore("sqlite3", dsn)
+				if err != nil {
+					t.Fatalf("failed to open the sqlite store: %v", err)
+				}
+				closeStore(t, store)
+				return store
+			}
+		},
+		storePostgres: func(t *testing.T) storeOpener {
+			dsn := os.Getenv("MAVBOT_TEST_POSTGRES_DSN")
+			if dsn == "" {
+				t.Skip("set MAVBOT_TEST_POSTGRES_DSN to run the suite against postgres")
+			}
+			return func(t *testing.T) Store {
+				store, err := newSQLStore("postgres", dsn)
+				if err != nil {
+					t.Fatalf("failed to open the postgres store: %v", err)
+				}
+				closeStore(t, store)
+				return store
+			}
+		},
+	}
+}
+
+// storedDoc is a document like the ones the bot stores
+type storedDoc struct {
+	User    string            `json:"user"`
+	Answers map[string]string `json:"answers"`
+	Score   int               `json:"score"`
+	At      time.Time         `json:"at"`
+}
+
+func TestStoreConformance(t *testing.T) {
+	for name, backend := range storeBackends() {
+		t.Run(name, func(t *testing.T) {
+			open := backend(t)
+			// Collections are unique to the run, a database may outlive it
+			prefix := fmt.Sprintf("test%d_", time.Now().UnixNano())
+			testStore(t, open, prefix+"surveys", prefix+"prefs")
+		})
+	}
+}
+
+// testStore runs the Store conformance suite over the stores open returns, using the collections
+func testStore(t *testing.T, open storeOpener, surveys, prefs string) {
+	store := open(t)
+	t.Cleanup(func() {
+		for _, collection := range []string{surveys, prefs} {
+			keys, _ := store.Keys(collection)
+			for _, key := range keys {
+				store.Delete(collection, key)
+			}
+		}
+	})
+
+	t.Run("missing document", func(t *testing.T) {
+		var doc storedDoc
+		ok, err := store.Get(surveys, "U404", &doc)
+		if ok || err != nil {
+			t.Errorf("Get() = %t, %v, want a missing document", ok, err)
+		}
+		keys, err := store.Keys(surveys)
+		if len(keys) != 0 || err != nil {
+			t.Errorf("Keys() = %v, %v, want none in an empty collection", keys, err)
+		}
+	})
+
+	at := time.Date(2024, 4, 5, 18, 21, 18, 0, time.UTC)
+	first := storedDoc{User: "U1", Answers: map[string]string{"rating": "5", "comment": "Дякую 👍 \"great\""}, Score: 5, At: at}
+	t.Run("put and get", func(t *testing.T) {
+		if err := store.Put(surveys, "U1", first); err != nil {
+			t.Fatalf("Put() error = %v", err)
+		}
+		var got storedDoc
+		if ok, err := store.Get(surveys, "U1", &got); !ok || err != nil {
+			t.Fatalf("Get() = %t, %v, want the stored document", ok, err)
+		}
+		if fmt.Sprint(got) != fmt.Sprint(first) || !got.At.Equal(at) {
+			t.Errorf("Get() = %+v, want %+v", got, first)
+		}
+	})
+
+	t.Run("put replaces", func(t *testing.T) {
+		second := storedDoc{User: "U1", Score: 3}
+		if err := store.Put(surveys, "U1", second); err != nil {
+			t.Fatalf("Put() error = %v", err)
+		}
+		var got storedDoc
+		if ok, err := store.Get(surveys, "U1", &got); !ok || err != nil || got.Score != 3 || got.Answers != nil {
+			t.Errorf("Get() = %t, %v, %+v, want the replacement only", ok, err, got)
+		}
+		if keys, _ := store.Keys(surveys); len(keys) != 1 {
+			t.Errorf("Keys() = %v, want the document once", keys)
+		}
+	})
+
+	t.Run("collections are separate", func(t *testing.T) {
+		if err := store.Put(prefs, "U1", map[string]string{"locale": "uk"}); err != nil {
+			t.Fatalf("Put() error = %v", err)
+		}
+		var doc storedDoc
+		if err := store.Put(prefs, "U2", "plain"); err != nil {
+			t.Fatalf("Put() error = %v", err)
+		}
+		if ok, _ := store.Get(surveys, "U2", &doc); ok {
+			t.Errorf("a document of another collection was found")
+		}
+		var prefsOfU1 map[string]string
+		if ok, err := store.Get(prefs, "U1", &prefsOfU1); !ok || err != nil || prefsOfU1["locale"] != "uk" {
+			t.Errorf("Get() = %t, %v, %v, want the document of its collection", ok, err, prefsOfU1)
+		}
+	})
+
+	t.Run("keys are sorted", func(t *testing.T) {
+		for _, key := range []string{"b", "C0/1712345678.000100", "a", "a b", "10", "9"} {
+			if err := store.Put(surveys, key, storedDoc{User: key}); err != nil {
+				t.Fatalf("Put(%q) error = %v", key, err)
+			}
+		}
+		want := "10|9|C0/1712345678.000100|U1|a|a b|b"
+		if keys, err := store.Keys(surveys); strings.Join(keys, "|") != want || err != nil {
+			t.Errorf("Keys() = %q, %v, want %q", keys, err, want)
+		}
+	})
+
+	t.Run("delete", func(t *testing.T) {
+		if err := store.Delete(surveys, "a"); err != nil {
+			t.Fatalf("Delete() error = %v", err)
+		}
+		var doc storedDoc
+		if ok, err := store.Get(surveys, "a", &doc); ok || err != nil {
+			t.Errorf("Get() = %t, %v after Delete(), want a missing document", ok, err)
+		}
+		if err := store.Delete(surveys, "a"); err != nil {
+			t.Errorf("deleting a missing document failed: %v", err)
+		}
+		if err := store.Delete("never_used", "a"); err != nil {
+			t.Errorf("deleting from a missing collection failed: %v", err)
+		}
+		if keys, _ := store.Keys(surveys); containsString(keys, "a") {
+			t.Errorf("Keys() = %v, want the deleted document gone", keys)
+		}
+	})
+
+	t.Run("decode error", func(t *testing.T) {
+		var score int
+		if _, err := store.Get(surveys, "U1", &score); err == nil {
+			t.Errorf("decoding a document into the wrong type succeeded")
+		}
+	})
+
+	t.Run("concurrent writes", func(t *testing.T) {
+		var wg sync.WaitGroup
+		errs := make(chan error, 20)
+		for i := 0; i < 20; i++ {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				errs <- store.Put(prefs, fmt.Sprintf("W%02d", i), i)
+			}(i)
+		}
+		wg.Wait()
+		close(errs)
+		for err := range errs {
+			if err != nil {
+				t.Errorf("Put() error = %v", err)
+			}
+		}
+		if keys, _ := store.Keys(prefs); len(keys) != 22 {
+			t.Errorf("Keys() = %d keys, want every concurrent write kept", len(keys))
+		}
+	})
+
+	t.Run("persists", func(t *testing.T) {
+		reopened := open(t)
+		var got storedDoc
+		if ok, err := reopened.Get(surveys, "U1", &got); !ok || err != nil || got.Score != 3 {
+			t.Errorf("Get() = %t, %v, %+v after reopening, want the stored document", ok, err, got)
+		}
+		before, _ := store.Keys(surveys)
+		after, err := reopened.Keys(surveys)
+		if strings.Join(before, "|") != strings.Join(after, "|") || err != nil {
+			t.Errorf("Keys() = %v, %v after reopening, want %v", after, err, before)
+		}
+	})
+}
+
+func TestSQLStorePlaceholders(t *testing.T) {
+	tests := []struct {
+		postgres bool
+		want     string
+	}{
+		{postgres: false, want: "DELETE FROM t WHERE a = ? AND b = ?"},
+		{postgres: true, want: "DELETE FROM t WHERE a = $1 AND b = $2"},
+	}
+	for _, tt := range tests {
+		s := &sqlStore{postgres: tt.postgres}
+		if got := s.query("DELETE FROM t WHERE a = ? AND b = ?"); got != tt.want {
+			t.Errorf("query() with postgres %t = %q, want %q", tt.postgres, got, tt.want)
+		}
+	}
+}
+
+func TestStoreBackendConfig(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("MAVBOT_DATA_DIR", dir)
+	cfg, err := loadConfigWith(noSecrets{})
+	if err != nil || cfg.StoreBackend != storeFile {
+		t.Fatalf("loadConfig() = %q, %v, want the file store by default", cfg.StoreBackend, err)
+	}
+	if store, err := openStore(cfg); err != nil {
+		t.Errorf("openStore() error = %v", err)
+	} else if _, ok := store.(*fileStore); !ok {
+		t.Errorf("openStore() = %T, want the file store", store)
+	}
+
+	t.Setenv("MAVBOT_STORE", storeSQLite)
+	cfg, err = loadConfigWith(noSecrets{})
+	if want := filepath.Join(dir, "mavbot.db"); err != nil || cfg.StoreDSN != want {
+		t.Fatalf("loadConfig() DSN = %q, %v, want %q", cfg.StoreDSN, err, want)
+	}
+	store, err := openStore(cfg)
+	if err != nil {
+		t.Fatalf("openStore() error = %v", err)
+	}
+	closeStore(t, store)
+	if _, ok := store.(*sqlStore); !ok {
+		t.Errorf("openStore() = %T, want the sqlite store", store)
+	}
+	if _, err := os.Stat(cfg.StoreDSN); err != nil {
+		t.Errorf("the database file wasn't created: %v", err)
+	}
+
+	t.Setenv("MAVBOT_STORE", storePostgres)
+	if _, err := loadConfigWith(noSecrets{}); err == nil || !strings.Contains(err.Error(), "MAVBOT_STORE_DSN is required") {
+		t.Errorf("loadConfig() error = %v, want the DSN required", err)
+	}
+	t.Setenv("MAVBOT_STORE", "redis")
+	if _, err := loadConfigWith(noSecrets{}); err == nil {
+		t.Errorf("an unknown MAVBOT_STORE was accepted")
+	}
+}
@@ -0,0 +1,225 @@
+/*
+Copyright © 2024 Pavlo Tarasiuk <pasha.tarasyuk@gmail.com>
+*/
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/slack-go/slack"
+)
+
+// collectionSubscriptions holds the channels subscribed to the daily summary, keyed by channel ID
+const collectionSubscriptions = "subscriptions"
+
+// subscription is a channel receiving the daily summary
+type subscription struct {
+	Channel      string    `json:"channel"`
+	SubscribedBy string    `json:"subscribed_by"`
+	Since        time.Time `json:"since"`
+}
+
+// subscriptions returns every subscribed channel
+func (b *Bot) subscriptions() ([]subscription, error) {
+	keys, err := b.store.Keys(collectionSubscriptions)
+	if err != nil {
+		return nil, err
+	}
+	subs := make([]subscription, 0, len(keys))
+	for _, key := range keys {
+		var sub subscription
+		ok, err := b.store.Get(collectionSubscriptions, key, &sub)
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			subs = append(subs, sub)
+		}
+	}
+	return subs, nil
+}
+
+// subscribe adds the channel to the summary's recipients and reports whether it wasn't one already
+func (b *Bot) subscribe(channelID, userID string) (bool, error) {
+	var existing subscription
+	found, err := b.store.Get(collectionSubscriptions, channelID, &existing)
+	if err != nil || found {
+		return false, err
+	}
+	sub := subscription{Channel: channelID, SubscribedBy: userID, Since: b.now()}
+	if err := b.store.Put(collectionSubscriptions, channelID, sub); err != nil {
+		return false, fmt.Errorf("failed to save subscription: %w", err)
+	}
+	return true, nil
+}
+
+// unsubscribe removes the channel from the summary's recipients and reports whether it was one
+func (b *Bot) unsubscribe(channelID string) (bool, error) {
+	var existing subscription
+	found, err := b.store.Get(collectionSubscriptions, channelID, &existing)
+	if err != nil || !found {
+		return false, err
+	}
+	if err := b.store.Delete(collectionSubscriptions, channelID); err != nil {
+		return false, fmt.Errorf("failed to delete subscription: %w", err)
+	}
+	return true, nil
+}
+
+// surveyResponsesSince returns the responses given since the time, oldest first
+func (b *Bot) surveyResponsesSince(since time.Time) ([]surveyResponse, error) {
+	keys, err := b.store.Keys(collectionSurveys)
+	if err != nil {
+		return nil, err
+	}
+	var responses []surveyResponse
+	// Keys sort chronologically, so walking back stops at the first older response
+	for i := len(keys) - 1; i >= 0; i-- {
+		var response surveyResponse
+		ok, err := b.store.Get(collectionSurveys, keys[i], &response)
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			continue
+		}
+		if response.Time.Before(since) {
+			break
+		}
+		responses = append([]surveyResponse{response}, responses...)
+	}
+	return responses, nil
+}
+
+// dailySummary renders the summary of the day before the time: survey answers and activity
+func (b *Bot) dailySummary(now time.Time) (string, error) {
+	responses, err := b.surveyResponsesSince(now.Add(-24 * time.Hour))
+	if err != nil {
+		return "", err
+	}
+	answers := map[string]int{}
+	for _, response := range responses {
+		for _, answer := range strings.Split(response.Answer, ",") {
+			answers[answer]++
+		}
+	}
+	totals, err := b.activityTotals(now.AddDate(0, 0, -1), 1)
+	if err != nil {
+		return "", err
+	}
+
+	var summary strings.Builder
+	fmt.Fprintf(&summary, "*Daily summary for %s*\n", b.localTime(now.AddDate(0, 0, -1)).Format("Mon 2 Jan"))
+	fmt.Fprintf(&summary, "Survey responses in the last 24 hours: %d (%d yes, %d no)\n", len(responses), answers["yes"], answers["no"])
+	fmt.Fprintf(&summary, "Events processed: %d, errors: %d\n", totals.Events, totals.Errors)
+	if commands := topCounts(totals.Commands, activityTop); len(commands) > 0 {
+		for i, name := range commands {
+			commands[i] = fmt.Sprintf("%s (%d)", name, totals.Commands[name])
+		}
+		fmt.Fprintf(&summary, "Top commands: %s\n", strings.Join(commands, ", "))
+	}
+	return summary.String(), nil
+}
+
+// deliverSummaries posts the daily summary to every subscribed channel
+func (b *Bot) deliverSummaries() (*multiResult, error) {
+	subs, err := b.subscriptions()
+	if err != nil {
+		return nil, err
+	}
+	result := &multiResult{}
+	if len(subs) == 0 {
+		return result, nil
+	}
+	summary, err := b.dailySummary(b.now())
+	if err != nil {
+		return nil, err
+	}
+	for _, sub := range subs {
+		_, err := b.postMessage(outboundMessage{
+			Channel:  sub.Channel,
+			Priority: priorityNormal,
+			Text:     summary,
+		})
+		result.add(sub.Channel, err)
+	}
+	return result, nil
+}
+
+// nextSummaryAt returns the next time after now the summary is due, at SummaryTime in the configured timezone
+func (b *Bot) nextSummaryAt(now time.Time) time.Time {
+	local := b.localTime(now)
+	clock, _ := time.Parse("15:04", b.cfg.SummaryTime)
+	at := time.Date(local.Year(), local.Month(), local.Day(), clock.Hour(), clock.Minute(), 0, 0, local.Location())
+	if !at.After(now) {
+		at = at.AddDate(0, 0, 1)
+	}
+	return at
+}
+
+// runSummaries delivers the daily summary every day until ctx is cancelled
+func (b *Bot) runSummaries(ctx context.Context) {
+	for {
+		timer := time.NewTimer(b.nextSummaryAt(b.now()).Sub(b.now()))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+
+		result, err := b.deliverSummaries()
+		if err != nil {
+			log.Printf("failed to deliver the daily summary: %v\n", err)
+			continue
+		}
+		if result.failed() > 0 {
+			log.Println(result.format("Daily summary delivered to", "channels", func(id string) string { return id }))
+		}
+	}
+}
+
+// handleSubscribe subscribes the channel to the daily summary
+func (b *Bot) handleSubscribe(command slack.SlashCommand) (*SlashResponse, error) {
+	added, err := b.subscribe(command.ChannelID, command.UserID)
+	if err != nil {
+		return nil, err
+	}
+	if !added {
+		return ephemeral("This channel already receives the daily summary"), nil
+	}
+	return ephemeral(fmt.Sprintf("This channel will receive the daily summary at %s", b.cfg.SummaryTime)), nil
+}
+
+// handleUnsubscribe stops the daily summary in the channel
+func (b *Bot) handleUnsubscribe(command slack.SlashCommand) (*SlashResponse, error) {
+	removed, err := b.unsubscribe(command.ChannelID)
+	if err != nil {
+		return nil, err
+	}
+	if !removed {
+		return ephemeral("This channel isn't subscribed to the daily summary"), nil
+	}
+	return ephemeral("This channel won't receive the daily summary anymore"), nil
+}
+
+func init() {
+	registerSlashCommand(&slashCommand{
+		Name:        "/subscribe",
+		Description: "Have the daily summary of survey answers and activity posted to this channel",
+		Category:    categoryAdmin,
+		AdminOnly:   true,
+		Handler:     (*Bot).handleSubscribe,
+	})
+	registerSlashCommand(&slashCommand{
+		Name:        "/unsubscribe",
+		Description: "Stop the daily summary in this channel",
+		Category:    categoryAdmin,
+		AdminOnly:   true,
+		Handler:     (*Bot).handleUnsubscribe,
+	})
+}
@@ -0,0 +1,157 @@
+/*
+Copyright © 2024 Pavlo Tarasiuk <pasha.tarasyuk@gmail.com>
+*/
+package cmd
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/slack-go/slack"
+)
+
+func TestSubscribePersists(t *testing.T) {
+	dir := t.TempDir()
+	b, _ := newTestBot(t, nil)
+	store, err := newFileStore(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b.store = store
+	b.now = newFakeClock().now
+
+	run := func(handler func(*Bot, slack.SlashCommand) (*SlashResponse, error), channel string) string {
+		t.Helper()
+		resp, err := handler(b, slack.SlashCommand{UserID: "U0ADMIN", ChannelID: channel})
+		if err != nil {
+			t.Fatalf("command failed: %v", err)
+		}
+		return resp.Text
+	}
+	steps := []struct {
+		handler func(*Bot, slack.SlashCommand) (*SlashResponse, error)
+		channel string
+		want    string
+	}{
+		{(*Bot).handleSubscribe, "C1", "This channel will receive the daily summary at 09:00"},
+		{(*Bot).handleSubscribe, "C1", "This channel already receives the daily summary"},
+		{(*Bot).handleSubscribe, "C2", "This channel will receive the daily summary at 09:00"},
+		{(*Bot).handleUnsubscribe, "C2", "This channel won't receive the daily summary anymore"},
+		{(*Bot).handleUnsubscribe, "C2", "This channel isn't subscribed to the daily summary"},
+		{(*Bot).handleSubscribe, "C3", "This channel will receive the daily summary at 09:00"},
+	}
+	for i, step := range steps {
+		if got := run(step.handler, step.channel); got != step.want {
+			t.Errorf("step %d in %s: got %q, want %q", i+1, step.channel, got, step.want)
+		}
+	}
+
+	// The subscriptions outlive the bot
+	reopened, err := newFileStore(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b.store = reopened
+	subs, err := b.subscriptions()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(subs) != 2 || subs[0].Channel != "C1" || subs[1].Channel != "C3" {
+		t.Fatalf("subscriptions = %+v, want C1 and C3", subs)
+	}
+	if subs[0].SubscribedBy != "U0ADMIN" || !subs[0].Since.Equal(newFakeClock().now()) {
+		t.Errorf("subscription = %+v, want who subscribed and when", subs[0])
+	}
+}
+
+func TestNextSummaryAt(t *testing.T) {
+	tests := []struct {
+		timezone string
+		now      time.Time
+		want     time.Time
+	}{
+		{"", time.Date(2024, 4, 5, 8, 0, 0, 0, time.UTC), time.Date(2024, 4, 5, 9, 0, 0, 0, time.UTC)},
+		{"", time.Date(2024, 4, 5, 9, 0, 0, 0, time.UTC), time.Date(2024, 4, 6, 9, 0, 0, 0, time.UTC)},
+		{"", time.Date(2024, 4, 5, 12, 0, 0, 0, time.UTC), time.Date(2024, 4, 6, 9, 0, 0, 0, time.UTC)},
+		// 09:00 in Kyiv is 06:00 UTC
+		{"Europe/Kyiv", time.Date(2024, 4, 5, 5, 0, 0, 0, time.UTC), time.Date(2024, 4, 5, 6, 0, 0, 0, time.UTC)},
+		{"Europe/Kyiv", time.Date(2024, 4, 5, 7, 0, 0, 0, time.UTC), time.Date(2024, 4, 6, 6, 0, 0, 0, time.UTC)},
+	}
+	for _, tt := range tests {
+		cfg := testConfig(t)
+		cfg.Timezone = tt.timezone
+		b, _ := newTestBot(t, cfg)
+		if got := b.nextSummaryAt(tt.now); !got.Equal(tt.want) {
+			t.Errorf("nextSummaryAt(%s) in %q = %s, want %s", tt.now, tt.timezone, got.UTC(), tt.want)
+		}
+	}
+}
+
+func TestDailySummary(t *testing.T) {
+	b, _ := newTestBot(t, nil)
+	clock := newFakeClock()
+	b.now = clock.now
+	seedSurveyResponses(t, b, 3)
+	b.emitEvent(eventSummary{Type: "slash_command", User: "U1", Channel: "C1", Command: "/help"}, nil)
+	b.emitEvent(eventSummary{Type: "slash_command", User: "U1", Channel: "C1", Command: "/help"}, errors.New("failed"))
+	b.emitEvent(eventSummary{Type: "slash_command", User: "U2", Channel: "C1", Command: "/ask"}, nil)
+
+	summary, err := b.dailySummary(clock.now().Add(21 * time.Hour))
+	if err != nil {
+		t.Fatalf("dailySummary() error = %v", err)
+	}
+	want := "*Daily summary for Fri 5 Apr*\n" +
+		"Survey responses in the last 24 hours: 3 (3 yes, 0 no)\n" +
+		"Events processed: 3, errors: 1\n" +
+		"Top commands: /help (2), /ask (1)\n"
+	if summary != want {
+		t.Errorf("dailySummary() =\n%s\nwant\n%s", summary, want)
+	}
+}
+
+func TestSchedulerDeliversToSubscribedChannels(t *testing.T) {
+	b, fake := newTestBot(t, nil)
+	// The summary is due 50ms from now
+	now := time.Date(2024, 4, 6, 8, 59, 59, 950e6, time.UTC)
+	b.now = func() time.Time { return now }
+	for _, channel := range []string{"C1", "C2"} {
+		if _, err := b.subscribe(channel, "U0ADMIN"); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		b.runSummaries(ctx)
+	}()
+	posts := fake.waitCalls(t, "chat.postMessage", 2)
+	cancel()
+	<-done
+
+	channels := map[string]bool{}
+	for _, post := range posts[:2] {
+		channels[post.Form.Get("channel")] = true
+		if text := post.Form.Get("text"); !strings.HasPrefix(text, "*Daily summary for Fri 5 Apr*\n") {
+			t.Errorf("posted %q, want the daily summary", text)
+		}
+	}
+	if !channels["C1"] || !channels["C2"] {
+		t.Errorf("delivered to %v, want C1 and C2", channels)
+	}
+}
+
+func TestNoSummaryWithoutSubscriptions(t *testing.T) {
+	b, fake := newTestBot(t, nil)
+	result, err := b.deliverSummaries()
+	if err != nil {
+		t.Fatalf("deliverSummaries() error = %v", err)
+	}
+	if len(result.Results) != 0 || len(fake.calls("chat.postMessage")) != 0 {
+		t.Errorf("delivered %+v without subscriptions", result.Results)
+	}
+}
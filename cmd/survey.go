@@ -0,0 +1,239 @@
+/*
+Copyright © 2024 Pavlo Tarasiuk <pasha.tarasyuk@gmail.com>
+*/
+package cmd
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ptarasyuk/mavbot/internal/blocks"
+	"github.com/slack-go/slack"
+)
+
+// collectionSurveys holds the answers to the article-usefulness survey
+const collectionSurveys = "surveys"
+
+// surveyActionID is the action ID of the survey's answer checkboxes
+const surveyActionID = "answer"
+
+// surveyMetadataType is the metadata event type of surveys asking about a particular message
+const surveyMetadataType = "article_survey"
+
+// Limits of /survey-recent
+const (
+	defaultRecentSurveys = 10
+	maxRecentSurveys     = 50
+)
+
+// surveyResponse is a user's answer to the article-usefulness survey
+type surveyResponse struct {
+	ID        string    `json:"id"`
+	User      string    `json:"user"`
+	Answer    string    `json:"answer"`
+	Channel   string    `json:"channel,omitempty"`
+	MessageTS string    `json:"message_ts,omitempty"`
+	ArticleTS string    `json:"article_ts,omitempty"`
+	Survey    string    `json:"survey,omitempty"`
+	Requester string    `json:"requester,omitempty"`
+	Time      time.Time `json:"time"`
+}
+
+// surveyContext identifies a posted survey and what it asks about. It travels in the survey's
+// block ID as a signed token, so answers are recorded correctly after a restart too.
+type surveyContext struct {
+	ID        string `json:"id"`
+	Requester string `json:"by,omitempty"`
+	ArticleTS string `json:"article,omitempty"`
+}
+
+// newSurveyID returns an ID for a response given at t. IDs sort in the order the
+// responses were given, so the Store's sorted keys are chronological.
+func newSurveyID(t time.Time, userID string) string {
+	return t.UTC().Format("20060102T150405.000000000") + "-" + userID
+}
+
+// newSurvey creates the context of a survey requested by the user, about the article at articleTS if given
+func (b *Bot) newSurvey(requester, articleTS string) surveyContext {
+	return surveyContext{ID: newSurveyID(b.now(), requester), Requester: requester, ArticleTS: articleTS}
+}
+
+// surveyAttachment builds the article-usefulness survey: a question with Yes and No checkboxes
+func (b *Bot) surveyAttachment(survey surveyContext) (slack.Attachment, error) {
+	token, err := b.signer.encode(survey)
+	if err != nil {
+		return slack.Attachment{}, err
+	}
+	question := blocks.Survey(surveyActionID, token, "Did you think this article was helpful?",
+		blocks.SurveyOption{Value: "yes", Label: "Yes", Description: "Did you Enjoy it?"},
+		blocks.SurveyOption{Value: "no", Label: "No", Description: "Did you Dislike it?"},
+	)
+	// Catch malformed blocks here rather than with a cryptic error from Slack
+	if err := validateBlocks([]slack.Block{question}); err != nil {
+		return slack.Attachment{}, fmt.Errorf("invalid survey blocks: %w", err)
+	}
+	// Add the block to the attachment
+	return b.reply().
+		Blocks(question).
+		Text("Rate the tutorial").
+		Color("#4af030").
+		Build(), nil
+}
+
+// recordSurveyResponse stores the answers selected in the survey's checkboxes
+func (b *Bot) recordSurveyResponse(interaction slack.InteractionCallback, action *slack.BlockAction) error {
+	answers := make([]string, 0, len(action.SelectedOptions))
+	for _, option := range action.SelectedOptions {
+		answers = append(answers, option.Value)
+	}
+	// Unticking every box leaves nothing to record
+	if len(answers) == 0 {
+		return nil
+	}
+
+	now := b.now()
+	response := surveyResponse{
+		ID:        newSurveyID(now, interaction.User.ID),
+		User:      interaction.User.ID,
+		Answer:    strings.Join(answers, ","),
+		Channel:   interaction.Container.ChannelID,
+		MessageTS: interaction.Container.MessageTs,
+		Time:      now,
+	}
+	if isActionToken(action.BlockID) {
+		var survey surveyContext
+		if err := b.signer.decode(action.BlockID, &survey); err != nil {
+			return fmt.Errorf("failed to record survey response of %s: %w", response.User, err)
+		}
+		response.Survey = survey.ID
+		response.Requester = survey.Requester
+		response.ArticleTS = survey.ArticleTS
+	} else if metadata := interaction.Message.Metadata; metadata.EventType == surveyMetadataType {
+		// Surveys posted before they carried a token refer to the article in their metadata
+		response.ArticleTS, _ = metadata.EventPayload["message_ts"].(string)
+	}
+	if err := b.store.Put(collectionSurveys, response.ID, response); err != nil {
+		return fmt.Errorf("failed to record survey response: %w", err)
+	}
+	return nil
+}
+
+// recentSurveyResponses returns up to n of the latest responses, newest first
+func (b *Bot) recentSurveyResponses(n int) ([]surveyResponse, error) {
+	keys, err := b.store.Keys(collectionSurveys)
+	if err != nil {
+		return nil, err
+	}
+
+	responses := make([]surveyResponse, 0, n)
+	for i := len(keys) - 1; i >= 0 && len(responses) < n; i-- {
+		var response surveyResponse
+		ok, err := b.store.Get(collectionSurveys, keys[i], &response)
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			responses = append(responses, response)
+		}
+	}
+	return responses, nil
+}
+
+// formatSurveyResponses renders responses as a list, one per line
+func (b *Bot) formatSurveyResponses(responses []surveyResponse) string {
+	var list strings.Builder
+	for _, response := range responses {
+		fmt.Fprintf(&list, "• <@%s> answered *%s* on %s `%s`\n", response.User, response.Answer, b.formatTime(response.Time, ""), response.ID)
+	}
+	return list.String()
+}
+
+// handleSurveyRecent lists the latest survey responses: /survey-recent [n]
+func (b *Bot) handleSurveyRecent(command slack.SlashCommand) (*SlashResponse, error) {
+	n := defaultRecentSurveys
+	if arg := strings.TrimSpace(command.Text); arg != "" {
+		parsed, err := strconv.Atoi(arg)
+		if err != nil || parsed < 1 {
+			return ephemeral(usageOf("/survey-recent") + ", n being a positive number"), nil
+		}
+		n = min(parsed, maxRecentSurveys)
+	}
+
+	responses, err := b.recentSurveyResponses(n)
+	if err != nil {
+		return nil, err
+	}
+	if len(responses) == 0 {
+		return ephemeral("There are no survey responses yet"), nil
+	}
+
+	header := fmt.Sprintf("*Last %d survey responses*\n", len(responses))
+	if len(responses) < n {
+		header = fmt.Sprintf("*Only %d survey responses so far*\n", len(responses))
+	}
+	return ephemeral(header + b.formatSurveyResponses(responses)), nil
+}
+
+// formatSurveyResponse renders every detail of a response, linking the message it was given on
+func (b *Bot) formatSurveyResponse(response surveyResponse) string {
+	var details strings.Builder
+	fmt.Fprintf(&details, "*Survey response* `%s`\n", response.ID)
+	fmt.Fprintf(&details, "User: %s\n", userRef(response.User))
+	fmt.Fprintf(&details, "Answer: *%s*\n", response.Answer)
+	fmt.Fprintf(&details, "Time: %s\n", b.formatTime(response.Time, ""))
+	if response.Channel != "" && response.MessageTS != "" {
+		fmt.Fprintf(&details, "Message: %s\n", b.messageLink(response.Channel, response.MessageTS, "in "+channelRef(response.Channel)))
+	}
+	if response.Channel != "" && response.ArticleTS != "" {
+		fmt.Fprintf(&details, "Article: %s\n", b.messageLink(response.Channel, response.ArticleTS, response.ArticleTS))
+	}
+	if response.Survey != "" {
+		fmt.Fprintf(&details, "Survey: `%s`\n", response.Survey)
+	}
+	if response.Requester != "" {
+		fmt.Fprintf(&details, "Requested by: %s\n", userRef(response.Requester))
+	}
+	return details.String()
+}
+
+// handleSurveyGet shows a single survey response, IDs being listed by /survey-recent: /survey-get <id>
+func (b *Bot) handleSurveyGet(command slack.SlashCommand) (*SlashResponse, error) {
+	id := strings.TrimSpace(command.Text)
+	if id == "" || strings.Contains(id, " ") {
+		return ephemeral(usageOf("/survey-get")), nil
+	}
+
+	var response surveyResponse
+	ok, err := b.store.Get(collectionSurveys, id, &response)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get survey response %s: %w", id, err)
+	}
+	if !ok {
+		return ephemeral(fmt.Sprintf("There is no survey response with ID `%s`", id)), nil
+	}
+	return ephemeral(b.formatSurveyResponse(response)), nil
+}
+
+func init() {
+	registerSlashCommand(&slashCommand{
+		Name:        "/survey-recent",
+		Description: "List the latest answers to the article survey",
+		Usage:       "[n]",
+		Example:     "/survey-recent 20",
+		Category:    categorySurveys,
+		AdminOnly:   true,
+		CacheTTL:    time.Minute,
+		Handler:     (*Bot).handleSurveyRecent,
+	})
+	registerSlashCommand(&slashCommand{
+		Name:        "/survey-get",
+		Description: "Show every detail of one answer to the article survey",
+		Usage:       "<id>",
+		Example:     "/survey-get 20240405T101500.000000000-U0123456789",
+		Category:    categorySurveys,
+		AdminOnly:   true,
+		Handler:     (*Bot).handleSurveyGet,
+	})
+}
@@ -0,0 +1,189 @@
+/*
+Copyright © 2024 Pavlo Tarasiuk <pasha.tarasyuk@gmail.com>
+*/
+package cmd
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/slack-go/slack"
+)
+
+// seedSurveyResponses stores n responses, one a minute, answered by U1, U2, ... in turn
+func seedSurveyResponses(t *testing.T, b *Bot, n int) []surveyResponse {
+	t.Helper()
+	start := time.Date(2024, 4, 5, 10, 0, 0, 0, time.UTC)
+	responses := make([]surveyResponse, n)
+	for i := range responses {
+		at := start.Add(time.Duration(i) * time.Minute)
+		user := fmt.Sprintf("U%d", i+1)
+		responses[i] = surveyResponse{ID: newSurveyID(at, user), User: user, Answer: "yes", Channel: "C1", MessageTS: "1712345678.000100", Time: at}
+		if err := b.store.Put(collectionSurveys, responses[i].ID, responses[i]); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return responses
+}
+
+func TestSurveyRecent(t *testing.T) {
+	tests := []struct {
+		name   string
+		stored int
+		text   string
+		header string
+		// users are the users of the listed responses, newest first
+		users int
+	}{
+		{name: "default", stored: 60, header: "*Last 10 survey responses*", users: 10},
+		{name: "n", stored: 60, text: "3", header: "*Last 3 survey responses*", users: 3},
+		{name: "capped", stored: 60, text: "100", header: "*Last 50 survey responses*", users: 50},
+		{name: "fewer than n", stored: 2, text: "5", header: "*Only 2 survey responses so far*", users: 2},
+		{name: "none", header: "There are no survey responses yet"},
+		{name: "not a number", stored: 2, text: "abc", header: "Usage: /survey-recent [n], n being a positive number"},
+		{name: "zero", stored: 2, text: "0", header: "Usage: /survey-recent [n], n being a positive number"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := testConfig(t)
+			cfg.Timezone = "Europe/Kyiv"
+			b, _ := newTestBot(t, cfg)
+			responses := seedSurveyResponses(t, b, tt.stored)
+
+			response, err := b.handleSurveyRecent(slack.SlashCommand{Text: tt.text, UserID: "U0ADMIN"})
+			if err != nil {
+				t.Fatalf("/survey-recent failed: %v", err)
+			}
+			lines := strings.Split(strings.TrimSpace(response.Text), "\n")
+			if lines[0] != tt.header {
+				t.Errorf("got header %q, want %q", lines[0], tt.header)
+			}
+			if tt.users == 0 {
+				if len(lines) != 1 {
+					t.Errorf("listed responses: %s", response.Text)
+				}
+				return
+			}
+			if len(lines)-1 != tt.users {
+				t.Fatalf("listed %d responses, want %d", len(lines)-1, tt.users)
+			}
+			newest := responses[len(responses)-1]
+			// Times are shown in the configured timezone, 3 hours ahead of UTC in April
+			shown := newest.Time.Add(3 * time.Hour).Format("2006-01-02 15:04:05")
+			want := fmt.Sprintf("• <@%s> answered *yes* on %s `%s`", newest.User, shown, newest.ID)
+			if lines[1] != want {
+				t.Errorf("got first line %q, want %q", lines[1], want)
+			}
+			if oldest := responses[len(responses)-tt.users]; !strings.Contains(lines[len(lines)-1], "<@"+oldest.User+">") {
+				t.Errorf("got last line %q, want the response of %s", lines[len(lines)-1], oldest.User)
+			}
+		})
+	}
+}
+
+func TestSurveyGet(t *testing.T) {
+	at := time.Date(2024, 4, 5, 10, 15, 0, 0, time.UTC)
+	full := surveyResponse{
+		ID: newSurveyID(at, "U1"), User: "U1", Answer: "no", Channel: "C1", MessageTS: "1712345678.000100",
+		ArticleTS: "1712345000.000100", Survey: "article", Requester: "U0ADMIN", Time: at,
+	}
+	bare := surveyResponse{ID: newSurveyID(at.Add(time.Minute), "U2"), User: "U2", Answer: "yes", Time: at.Add(time.Minute)}
+	tests := []struct {
+		name string
+		text string
+		// permalinks tells whether Slack answers with permalinks
+		permalinks bool
+		want       string
+	}{
+		{
+			name:       "found",
+			text:       full.ID,
+			permalinks: true,
+			want: "*Survey response* `" + full.ID + "`\n" +
+				"User: <@U1>\n" +
+				"Answer: *no*\n" +
+				"Time: 2024-04-05 13:15:00\n" +
+				"Message: <https://x.slack.com/archives/C1/p1712345678000100|in <#C1>>\n" +
+				"Article: <https://x.slack.com/archives/C1/p1712345678000100|1712345000.000100>\n" +
+				"Survey: `article`\n" +
+				"Requested by: <@U0ADMIN>\n",
+		},
+		{
+			name: "found without permalinks",
+			text: "  " + full.ID + " ",
+			want: "*Survey response* `" + full.ID + "`\n" +
+				"User: <@U1>\n" +
+				"Answer: *no*\n" +
+				"Time: 2024-04-05 13:15:00\n" +
+				"Message: in <#C1>\n" +
+				"Article: 1712345000.000100\n" +
+				"Survey: `article`\n" +
+				"Requested by: <@U0ADMIN>\n",
+		},
+		{
+			name:       "found without a message",
+			text:       bare.ID,
+			permalinks: true,
+			want:       "*Survey response* `" + bare.ID + "`\nUser: <@U2>\nAnswer: *yes*\nTime: 2024-04-05 13:16:00\n",
+		},
+		{name: "not found", text: "20240405T101500.000000000-U404", want: "There is no survey response with ID `20240405T101500.000000000-U404`"},
+		{name: "no ID", text: " ", want: usageOf("/survey-get")},
+		{name: "two IDs", text: full.ID + " " + bare.ID, want: usageOf("/survey-get")},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			captureLog(t)
+			cfg := testConfig(t)
+			// Times are shown in the configured timezone, 3 hours ahead of UTC in April
+			cfg.Timezone = "Europe/Kyiv"
+			b, fake := newTestBot(t, cfg)
+			if tt.permalinks {
+				fake.answer("chat.getPermalink", `{"ok":true,"channel":"C1","permalink":"https://x.slack.com/archives/C1/p1712345678000100"}`)
+			} else {
+				fake.answer("chat.getPermalink", `{"ok":false,"error":"message_not_found"}`)
+			}
+			for _, response := range []surveyResponse{full, bare} {
+				if err := b.store.Put(collectionSurveys, response.ID, response); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			resp, err := b.handleSurveyGet(slack.SlashCommand{Command: "/survey-get", Text: tt.text, UserID: "U0ADMIN"})
+			if err != nil {
+				t.Fatalf("/survey-get failed: %v", err)
+			}
+			if resp.Text != tt.want {
+				t.Errorf("answered\n%s\nwant\n%s", resp.Text, tt.want)
+			}
+			if resp.ResponseType != slack.ResponseTypeEphemeral {
+				t.Errorf("answered in the channel, want ephemerally")
+			}
+		})
+	}
+}
+
+func TestSurveyGetUnreadable(t *testing.T) {
+	b, _ := newTestBot(t, nil)
+	if err := b.store.Put(collectionSurveys, "broken", "not a response"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := b.handleSurveyGet(slack.SlashCommand{Command: "/survey-get", Text: "broken", UserID: "U0ADMIN"}); err == nil || !strings.Contains(err.Error(), "failed to get survey response broken") {
+		t.Errorf("/survey-get error = %v, want the store's failure", err)
+	}
+}
+
+func TestSurveyGetAdminOnly(t *testing.T) {
+	cfg := testConfig(t)
+	cfg.Admins = []string{"U0ADMIN"}
+	b, _ := newTestBot(t, cfg)
+	responses := seedSurveyResponses(t, b, 1)
+	resp, err := b.dispatchSlashCommand(slack.SlashCommand{Command: "/survey-get", Text: responses[0].ID, UserID: "U1", ChannelID: "C1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.Text != "Sorry, this command is available to MAVBot admins only" {
+		t.Errorf("answered %q, want the command refused", resp.Text)
+	}
+}
@@ -0,0 +1,179 @@
+/*
+Copyright © 2024 Pavlo Tarasiuk <pasha.tarasyuk@gmail.com>
+*/
+package cmd
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"text/template"
+)
+
+// Names of the message templates
+const (
+	templateGreeting     = "greeting"
+	templateHelpOffer    = "help_offer"
+	templateHelloCommand = "hello_command"
+	templateRateLimited  = "rate_limited"
+	// templateUnknownCommand answers commands the bot has no handler for, .Text being the command
+	templateUnknownCommand = "unknown_command"
+)
+
+// builtinCatalogs are the sources of the bot's messages in every supported language,
+// keyed by language and then template name, written with text/template
+var builtinCatalogs = map[string]map[string]string{
+	"en": {
+		templateGreeting: `{{if about .Channel "support"}}Hello {{.User}}, sorry you're having trouble. ` +
+			`Tell me what's wrong and I'll do my best to help{{else}}Hello {{.User}}{{end}}`,
+		templateHelpOffer:    `How can I help you {{.User}}`,
+		templateHelloCommand: `Hello {{.User}}! You said: {{.Text}}`,
+		templateRateLimited: `Slow down a little, I can't keep up. ` +
+			`Please try again {{if .Wait}}in {{.Wait}}{{else}}in a moment{{end}}`,
+		templateUnknownCommand: `Sorry, I don't know {{.Text}}, try /help to see what I can do`,
+	},
+	"uk": {
+		templateGreeting: `{{if about .Channel "support"}}Привіт, {{.User}}! Шкода, що у вас проблеми. ` +
+			`Розкажіть, що сталося, і я спробую допомогти{{else}}Привіт, {{.User}}{{end}}`,
+		templateHelpOffer:    `Чим я можу допомогти, {{.User}}?`,
+		templateHelloCommand: `Привіт, {{.User}}! Ви сказали: {{.Text}}`,
+		templateRateLimited: `Трохи повільніше, я не встигаю. ` +
+			`Спробуйте ще раз {{if .Wait}}через {{.Wait}}{{else}}трохи згодом{{end}}`,
+		templateUnknownCommand: `Вибачте, я не знаю команди {{.Text}}, спробуйте /help, щоб побачити, що я вмію`,
+	},
+}
+
+// templateData is what message templates can refer to
+type templateData struct {
+	// User is the name of the user the message is addressed to
+	User string
+	// Channel is the channel the message is posted to
+	Channel channelContext
+	// Text is the text the user sent, if any
+	Text string
+	// Wait is how long the user has to wait before trying again, if limited
+	Wait string
+}
+
+// templateFuncs are the helpers available to message templates
+var templateFuncs = template.FuncMap{
+	// about reports whether the channel's name, topic or purpose mentions the word
+	"about": func(channel channelContext, word string) bool {
+		word = strings.ToLower(word)
+		for _, s := range []string{channel.Name, channel.Topic, channel.Purpose} {
+			if strings.Contains(strings.ToLower(s), word) {
+				return true
+			}
+		}
+		return false
+	},
+}
+
+// catalogs are the parsed message templates of every language
+type catalogs struct {
+	languages map[string]*template.Template
+	// fallback is the language used when a template is missing in the requested one
+	fallback string
+}
+
+// catalogSources returns the built-in catalogs with the templates overridden by the translations
+// in the catalog directory and then by the configuration in the default language
+func catalogSources(cfg *Config) (map[string]map[string]string, error) {
+	sources := make(map[string]map[string]string, len(builtinCatalogs))
+	for lang, templates := range builtinCatalogs {
+		sources[lang] = make(map[string]string, len(templates))
+		for name, source := range templates {
+			sources[lang][name] = source
+		}
+	}
+	if cfg.CatalogDir != "" {
+		if err := readCatalogDir(cfg.CatalogDir, sources); err != nil {
+			return nil, err
+		}
+	}
+	if sources[cfg.DefaultLocale] == nil {
+		return sources, nil
+	}
+	if cfg.RateLimitMessage != "" {
+		sources[cfg.DefaultLocale][templateRateLimited] = cfg.RateLimitMessage
+	}
+	if cfg.UnknownCommandMessage != "" {
+		sources[cfg.DefaultLocale][templateUnknownCommand] = cfg.UnknownCommandMessage
+	}
+	return sources, nil
+}
+
+// parseCatalogs parses the sources of every language, fallback naming the default language
+func parseCatalogs(sources map[string]map[string]string, fallback string) (*catalogs, error) {
+	c := &catalogs{languages: make(map[string]*template.Template), fallback: fallback}
+	for lang, templates := range sources {
+		set := template.New(lang).Funcs(templateFuncs)
+		for name, source := range templates {
+			if _, err := set.New(name).Parse(source); err != nil {
+				return nil, fmt.Errorf("failed to parse %s/%s: %w", lang, name, err)
+			}
+		}
+		c.languages[lang] = set
+	}
+	if _, ok := c.languages[fallback]; !ok {
+		return nil, fmt.Errorf("no catalog for the default language %q", fallback)
+	}
+	return c, nil
+}
+
+// render executes the named template in the language, falling back to the default language
+// when the language or the template in it is missing
+func (c *catalogs) render(lang, name string, data interface{}) (string, error) {
+	set, ok := c.languages[lang]
+	if !ok || set.Lookup(name) == nil {
+		set = c.languages[c.fallback]
+	}
+	var out strings.Builder
+	if err := set.ExecuteTemplate(&out, name, data); err != nil {
+		return "", fmt.Errorf("failed to render %s: %w", name, err)
+	}
+	return out.String(), nil
+}
+
+// defines reports whether the language's catalog has the named template
+func (c *catalogs) defines(lang, name string) bool {
+	set, ok := c.languages[lang]
+	return ok && set.Lookup(name) != nil
+}
+
+// has reports whether there is a catalog for the language
+func (c *catalogs) has(lang string) bool {
+	_, ok := c.languages[lang]
+	return ok
+}
+
+// list returns the languages, sorted
+func (c *catalogs) list() []string {
+	langs := make([]string, 0, len(c.languages))
+	for lang := range c.languages {
+		langs = append(langs, lang)
+	}
+	sort.Strings(langs)
+	return langs
+}
+
+// names returns the names of the templates in the language's catalog, sorted
+func (c *catalogs) names(lang string) []string {
+	set, ok := c.languages[lang]
+	if !ok {
+		return nil
+	}
+	var names []string
+	for _, t := range set.Templates() {
+		if t.Tree != nil {
+			names = append(names, t.Name())
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// render executes the named message template in the language preferred by the user
+func (b *Bot) render(userID, name string, data interface{}) (string, error) {
+	return b.messages().render(b.userLocale(userID), name, data)
+}
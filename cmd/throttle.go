@@ -0,0 +1,72 @@
+/*
+Copyright © 2024 Pavlo Tarasiuk <pasha.tarasyuk@gmail.com>
+*/
+package cmd
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// channelThrottle caps the messages the bot posts to each channel, so a misbehaving
+// integration can't make it flood one. Every channel has a bucket of its own.
+type channelThrottle struct {
+	// perMinute is the limit of channels without one of their own in limits, 0 is unlimited
+	perMinute int
+	limits    map[string]int
+	now       func() time.Time
+
+	mu      sync.Mutex
+	buckets map[string]*tokenBucket
+}
+
+// newChannelThrottle creates a throttle allowing perMinute messages per channel, limits overriding it for single channels
+func newChannelThrottle(perMinute int, limits map[string]int, now func() time.Time) *channelThrottle {
+	return &channelThrottle{
+		perMinute: perMinute,
+		limits:    limits,
+		now:       now,
+		buckets:   make(map[string]*tokenBucket),
+	}
+}
+
+// allow reports whether another message may be posted to the channel now, counting it if so.
+// When it may not, it returns how long until it may.
+func (t *channelThrottle) allow(channelID string) (bool, time.Duration) {
+	limit, ok := t.limits[channelID]
+	if !ok {
+		limit = t.perMinute
+	}
+	if limit == 0 {
+		return true, 0
+	}
+
+	t.mu.Lock()
+	bucket, ok := t.buckets[channelID]
+	if !ok {
+		bucket = newTokenBucket(limit, t.now)
+		t.buckets[channelID] = bucket
+	}
+	t.mu.Unlock()
+	if bucket.tryTake() {
+		return true, 0
+	}
+	return false, bucket.retryAfter()
+}
+
+// parseChannelLimits parses per channel limits written as C123=5,C456=30
+func parseChannelLimits(items []string) (map[string]int, error) {
+	limits := make(map[string]int, len(items))
+	for _, item := range items {
+		channel, value, ok := strings.Cut(item, "=")
+		n, err := strconv.Atoi(strings.TrimSpace(value))
+		if !ok || err != nil || n < 0 {
+			return nil, fmt.Errorf("invalid channel limit %q, expected CHANNEL=N", item)
+		}
+		limits[strings.TrimSpace(channel)] = n
+	}
+	return limits, nil
+}
@@ -0,0 +1,109 @@
+/*
+Copyright © 2024 Pavlo Tarasiuk <pasha.tarasyuk@gmail.com>
+*/
+package cmd
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestChannelThrottle(t *testing.T) {
+	clock := newFakeClock()
+	throttle := newChannelThrottle(3, map[string]int{"C0QUIET": 1, "C0LOUD": 0}, clock.now)
+
+	allowed := func(channel string, n int) int {
+		count := 0
+		for i := 0; i < n; i++ {
+			if ok, _ := throttle.allow(channel); ok {
+				count++
+			}
+		}
+		return count
+	}
+	// Every channel has a limit of its own, filling one leaves the others alone
+	if got := allowed("C1", 5); got != 3 {
+		t.Errorf("C1 allowed %d of 5 messages, want 3", got)
+	}
+	if got := allowed("C2", 5); got != 3 {
+		t.Errorf("C2 allowed %d of 5 messages after C1 filled up, want 3", got)
+	}
+	if got := allowed("C0QUIET", 5); got != 1 {
+		t.Errorf("the channel limited to 1 allowed %d", got)
+	}
+	if got := allowed("C0LOUD", 100); got != 100 {
+		t.Errorf("the unlimited channel allowed %d of 100", got)
+	}
+
+	ok, wait := throttle.allow("C1")
+	if ok || wait != 20*time.Second {
+		t.Errorf("got %t, wait %s, want C1 held off for 20s", ok, wait)
+	}
+	clock.advance(20 * time.Second)
+	if got := allowed("C1", 2); got != 1 {
+		t.Errorf("C1 allowed %d messages 20s later, want 1", got)
+	}
+}
+
+func TestParseChannelLimits(t *testing.T) {
+	tests := []struct {
+		items   []string
+		want    map[string]int
+		wantErr bool
+	}{
+		{items: nil, want: map[string]int{}},
+		{items: []string{"C1=5", " C2 = 30 "}, want: map[string]int{"C1": 5, "C2": 30}},
+		{items: []string{"C1=0"}, want: map[string]int{"C1": 0}},
+		{items: []string{"C1"}, wantErr: true},
+		{items: []string{"C1=lots"}, wantErr: true},
+		{items: []string{"C1=-1"}, wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := parseChannelLimits(tt.items)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("parseChannelLimits(%q) error = %v, want error %t", tt.items, err, tt.wantErr)
+			continue
+		}
+		if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("parseChannelLimits(%q) = %v, want %v", tt.items, got, tt.want)
+		}
+	}
+}
+
+func TestPostsAreThrottledPerChannel(t *testing.T) {
+	cfg := testConfig(t)
+	cfg.ChannelPerMinute = 2
+	b, fake := newTestBot(t, cfg)
+	clock := newFakeClock()
+	b.now = clock.now
+	b.throttle = newChannelThrottle(cfg.ChannelPerMinute, nil, clock.now)
+
+	var limited int
+	for _, channel := range []string{"C1", "C1", "C1", "C2", "C2"} {
+		_, err := b.postMessage(outboundMessage{Channel: channel, Text: "flood"})
+		var rateLimited *rateLimitedError
+		switch {
+		case errors.As(err, &rateLimited):
+			limited++
+		case err != nil:
+			t.Fatalf("post failed: %v", err)
+		}
+	}
+	// Ephemeral messages don't count against the channel
+	if _, err := b.postMessage(outboundMessage{Channel: "C1", EphemeralTo: "U1", Text: "just for you"}); err != nil {
+		t.Errorf("the ephemeral message was throttled: %v", err)
+	}
+
+	if limited != 1 {
+		t.Errorf("%d posts were throttled, want the third to C1", limited)
+	}
+	perChannel := map[string]int{}
+	for _, call := range fake.calls("chat.postMessage") {
+		perChannel[call.Form.Get("channel")]++
+	}
+	if perChannel["C1"] != 2 || perChannel["C2"] != 2 {
+		t.Errorf("posted %v, want 2 messages to each channel", perChannel)
+	}
+}
@@ -0,0 +1,46 @@
+/*
+Copyright © 2024 Pavlo Tarasiuk <pasha.tarasyuk@gmail.com>
+*/
+package cmd
+
+import (
+	"log"
+	"time"
+)
+
+// formatTime renders t with the configured date format in the timezone tz, an IANA name like
+// Europe/Kyiv as Slack reports for users. Without a usable tz the configured timezone is used,
+// and without that t is rendered as it is.
+func (b *Bot) formatTime(t time.Time, tz string) string {
+	if loc := b.location(tz); loc != nil {
+		t = t.In(loc)
+	}
+	return t.Format(b.cfg.DateFormat)
+}
+
+// location loads the timezone tz, or the configured timezone when tz is empty or unknown.
+// It returns nil when neither is usable.
+func (b *Bot) location(tz string) *time.Location {
+	for _, name := range []string{tz, b.cfg.Timezone} {
+		if name == "" {
+			continue
+		}
+		loc, err := time.LoadLocation(name)
+		if err != nil {
+			log.Printf("unknown timezone %q: %v\n", name, err)
+			continue
+		}
+		return loc
+	}
+	return nil
+}
+
+// userTimezone returns the timezone the user set in Slack, or an empty string when unknown
+func (b *Bot) userTimezone(userID string) string {
+	user, err := b.userInfo(userID)
+	if err != nil {
+		log.Printf("failed to get timezone of %s: %v\n", userID, err)
+		return ""
+	}
+	return user.TZ
+}
@@ -0,0 +1,73 @@
+/*
+Copyright © 2024 Pavlo Tarasiuk <pasha.tarasyuk@gmail.com>
+*/
+package cmd
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/slack-go/slack"
+	"github.com/slack-go/slack/slackevents"
+)
+
+func TestFormatTime(t *testing.T) {
+	at := time.Date(2024, 4, 5, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name     string
+		tz       string
+		fallback string
+		layout   string
+		want     string
+	}{
+		{name: "user in Kyiv", tz: "Europe/Kyiv", want: "2024-04-05 15:00:00"},
+		{name: "user in New York", tz: "America/New_York", want: "2024-04-05 08:00:00"},
+		{name: "user's timezone over the configured one", tz: "Europe/Kyiv", fallback: "Asia/Tokyo", want: "2024-04-05 15:00:00"},
+		{name: "unknown timezone falls back", tz: "Mars/Olympus", fallback: "Asia/Tokyo", want: "2024-04-05 21:00:00"},
+		{name: "no timezone at all", want: "2024-04-05 12:00:00"},
+		{name: "custom layout", tz: "Europe/Kyiv", layout: "02 Jan 15:04 MST", want: "05 Apr 15:00 EEST"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := testConfig(t)
+			cfg.Timezone = tt.fallback
+			if tt.layout != "" {
+				cfg.DateFormat = tt.layout
+			}
+			b, _ := newTestBot(t, cfg)
+			if got := b.formatTime(at, tt.tz); got != tt.want {
+				t.Errorf("formatTime = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMentionDateIsInTheUsersTimezone(t *testing.T) {
+	b, fake := newTestBot(t, nil)
+	b.now = newFakeClock().now
+	fake.answer("users.info", `{"ok":true,"user":{"id":"U1","name":"pasha","tz":"Europe/Kyiv"}}`)
+
+	err := b.handleAppMentionEvent(&slackevents.AppMentionEvent{User: "U1", Channel: "C1", Text: "<@U0BOT> hello", TimeStamp: "1712345678.000100"})
+	if err != nil {
+		t.Fatalf("mention failed: %v", err)
+	}
+	posts := fake.calls("chat.postMessage")
+	if len(posts) != 1 {
+		t.Fatalf("got %d posts, want a greeting", len(posts))
+	}
+	var attachments []slack.Attachment
+	json.Unmarshal([]byte(posts[0].Form.Get("attachments")), &attachments)
+	if len(attachments) != 1 {
+		t.Fatalf("posted attachments %s", posts[0].Form.Get("attachments"))
+	}
+	for _, field := range attachments[0].Fields {
+		if field.Title == fieldDate {
+			if field.Value != "2024-04-05 15:00:00" {
+				t.Errorf("the date reads %q, want the time in Kyiv", field.Value)
+			}
+			return
+		}
+	}
+	t.Errorf("the reply has no date: %+v", attachments[0].Fields)
+}
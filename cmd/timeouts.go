@@ -0,0 +1,119 @@
+/*
+Copyright © 2024 Pavlo Tarasiuk <pasha.tarasyuk@gmail.com>
+*/
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// parseEventTimeouts parses timeouts written as app_mention=10s,reaction_added=2s
+func parseEventTimeouts(items []string) (map[string]time.Duration, error) {
+	timeouts := make(map[string]time.Duration, len(items))
+	for _, item := range items {
+		eventType, value, ok := strings.Cut(item, "=")
+		d, err := time.ParseDuration(strings.TrimSpace(value))
+		if !ok || err != nil || d < 0 {
+			return nil, fmt.Errorf("invalid event timeout %q, expected TYPE=DURATION", item)
+		}
+		timeouts[strings.TrimSpace(eventType)] = d
+	}
+	return timeouts, nil
+}
+
+// eventTimeout returns how long processing an event of the type may take, 0 is unlimited
+func (c *Config) eventTimeout(eventType string) time.Duration {
+	if d, ok := c.EventTimeouts[eventType]; ok {
+		return d
+	}
+	return c.EventTimeout
+}
+
+// eventContext creates the context an event of the type is processed under
+func (b *Bot) eventContext(eventType string) (context.Context, context.CancelFunc) {
+	if d := b.cfg.eventTimeout(eventType); d > 0 {
+		return context.WithTimeout(context.Background(), d)
+	}
+	return context.WithCancel(context.Background())
+}
+
+// within returns a copy of the bot whose work is bound to ctx: once ctx is done it doesn't post
+// messages, write to the Store or make requests with its HTTP client anymore
+func (b *Bot) within(ctx context.Context) *Bot {
+	bound := *b
+	bound.ctx = ctx
+	bound.store = contextStore{Store: b.store, ctx: ctx}
+	bound.activity = &activityLog{store: bound.store}
+	bound.httpClient = &http.Client{Transport: contextTransport{next: transportOf(b.httpClient), ctx: ctx}}
+	if canvases, ok := b.canvases.(*webAPI); ok {
+		bound.canvases = &webAPI{httpClient: bound.httpClient, url: canvases.url, token: canvases.token}
+	}
+	return &bound
+}
+
+// contextStore is a Store refusing writes once ctx is done
+type contextStore struct {
+	Store
+	ctx context.Context
+}
+
+// Put implements Store
+func (s contextStore) Put(collection, key string, v interface{}) error {
+	if err := s.ctx.Err(); err != nil {
+		return fmt.Errorf("failed to write %s/%s: %w", collection, key, err)
+	}
+	return s.Store.Put(collection, key, v)
+}
+
+// Delete implements Store
+func (s contextStore) Delete(collection, key string) error {
+	if err := s.ctx.Err(); err != nil {
+		return fmt.Errorf("failed to delete %s/%s: %w", collection, key, err)
+	}
+	return s.Store.Delete(collection, key)
+}
+
+// contextTransport makes the requests under ctx, so they fail once it is done
+type contextTransport struct {
+	next http.RoundTripper
+	ctx  context.Context
+}
+
+// RoundTrip implements http.RoundTripper
+func (t contextTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if err := t.ctx.Err(); err != nil {
+		return nil, err
+	}
+	return t.next.RoundTrip(req.WithContext(t.ctx))
+}
+
+// runWithin runs handler and waits for it until ctx is done. A handler that is still running
+// then is left to finish in the background, what it returns is only logged and passed on to
+// late when set; handlers working under ctx, see Bot.within, fail to post or change the state
+// from then on.
+func runWithin(ctx context.Context, name string, handler func() error, late chan<- error) error {
+	done := make(chan error, 1)
+	go func() {
+		done <- handler()
+	}()
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		go func() {
+			err := <-done
+			if err != nil {
+				log.Printf("%s handler finished late: %v\n", name, err)
+			}
+			if late != nil {
+				late <- err
+			}
+		}()
+		return fmt.Errorf("%s handler didn't finish in time: %w", name, ctx.Err())
+	}
+}
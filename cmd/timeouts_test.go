@@ -0,0 +1,179 @@
+/*
+Copyright © 2024 Pavlo Tarasiuk <pasha.tarasyuk@gmail.com>
+*/
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/slack-go/slack/slackevents"
+	"github.com/slack-go/slack/socketmode"
+)
+
+func TestParseEventTimeouts(t *testing.T) {
+	tests := []struct {
+		items   []string
+		want    map[string]time.Duration
+		wantErr bool
+	}{
+		{items: nil, want: map[string]time.Duration{}},
+		{
+			items: []string{"app_mention=10s", " reaction_added = 2s "},
+			want:  map[string]time.Duration{"app_mention": 10 * time.Second, "reaction_added": 2 * time.Second},
+		},
+		{items: []string{"message=0s"}, want: map[string]time.Duration{"message": 0}},
+		{items: []string{"app_mention"}, wantErr: true},
+		{items: []string{"app_mention=soon"}, wantErr: true},
+		{items: []string{"app_mention=-1s"}, wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := parseEventTimeouts(tt.items)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("parseEventTimeouts(%q) error = %v, want error %t", tt.items, err, tt.wantErr)
+			continue
+		}
+		if !tt.wantErr && fmt.Sprint(got) != fmt.Sprint(tt.want) {
+			t.Errorf("parseEventTimeouts(%q) = %v, want %v", tt.items, got, tt.want)
+		}
+	}
+}
+
+func TestEventContextDeadlines(t *testing.T) {
+	cfg := testConfig(t)
+	cfg.EventTimeout = 30 * time.Second
+	cfg.EventTimeouts = map[string]time.Duration{"app_mention": 10 * time.Second, "reaction_added": 2 * time.Second, "message": 0}
+	b, _ := newTestBot(t, cfg)
+
+	tests := []struct {
+		eventType string
+		// want is the timeout, 0 for none
+		want time.Duration
+	}{
+		{eventType: "app_mention", want: 10 * time.Second},
+		{eventType: "reaction_added", want: 2 * time.Second},
+		{eventType: "team_join", want: 30 * time.Second},
+		{eventType: "message"},
+	}
+	for _, tt := range tests {
+		before := time.Now()
+		ctx, cancel := b.eventContext(tt.eventType)
+		deadline, ok := ctx.Deadline()
+		cancel()
+		if ok != (tt.want > 0) {
+			t.Errorf("%s: has deadline %t, want %t", tt.eventType, ok, tt.want > 0)
+			continue
+		}
+		if ok && (deadline.Before(before.Add(tt.want)) || deadline.After(time.Now().Add(tt.want))) {
+			t.Errorf("%s: deadline in %s, want %s", tt.eventType, deadline.Sub(before), tt.want)
+		}
+	}
+}
+
+func TestRunWithin(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if err := runWithin(ctx, "quick", func() error { return errors.New("failed") }, nil); err == nil || err.Error() != "failed" {
+		t.Errorf("runWithin() error = %v, want the handler's error", err)
+	}
+
+	logs := captureLog(t)
+	release := make(chan struct{})
+	late := make(chan error, 1)
+	err := runWithin(ctx, "slow", func() error {
+		<-release
+		return errors.New("store unavailable")
+	}, late)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("runWithin() error = %v, want the deadline exceeded", err)
+	}
+	close(release)
+	if err := <-late; err == nil || err.Error() != "store unavailable" {
+		t.Errorf("the late handler returned %v, want its error passed on", err)
+	}
+	if !strings.Contains(logs.String(), "slow handler finished late: store unavailable") {
+		t.Errorf("logs = %q, want the late failure logged", logs.String())
+	}
+}
+
+func TestBoundBotStopsAfterDeadline(t *testing.T) {
+	b, fake := newTestBot(t, nil)
+	ctx, cancel := context.WithCancel(context.Background())
+	bound := b.within(ctx)
+	if err := bound.store.Put("test", "k1", "before"); err != nil {
+		t.Fatalf("Put() before the deadline error = %v", err)
+	}
+	cancel()
+
+	if _, err := bound.postMessage(outboundMessage{Channel: "C1", Text: "Too late"}); !errors.Is(err, context.Canceled) {
+		t.Errorf("postMessage() error = %v, want the post refused", err)
+	}
+	if got := len(fake.calls("chat.postMessage")); got != 0 {
+		t.Errorf("posted %d messages after the deadline", got)
+	}
+	if err := bound.store.Put("test", "k2", "after"); err == nil {
+		t.Errorf("Put() after the deadline succeeded")
+	}
+	if err := bound.store.Delete("test", "k1"); err == nil {
+		t.Errorf("Delete() after the deadline succeeded")
+	}
+	var value string
+	if ok, err := bound.store.Get("test", "k1", &value); !ok || err != nil || value != "before" {
+		t.Errorf("Get() = %t, %v, %q, want reads to keep working", ok, err, value)
+	}
+	req, _ := http.NewRequest(http.MethodGet, fake.URL, nil)
+	if _, err := bound.httpClient.Do(req); err == nil {
+		t.Errorf("a request went out after the deadline")
+	}
+
+	// The bot itself isn't bound
+	if _, err := b.postMessage(outboundMessage{Channel: "C1", Text: "Still here"}); err != nil {
+		t.Errorf("the unbound bot failed to post: %v", err)
+	}
+}
+
+func TestSlowEventHandlerTimesOut(t *testing.T) {
+	logs := captureLog(t)
+	cfg := testConfig(t)
+	cfg.EventTimeout = 5 * time.Second
+	cfg.EventTimeouts = map[string]time.Duration{"app_mention": 50 * time.Millisecond}
+	b, fake := newTestBot(t, cfg)
+	late := make(chan error, 1)
+	b.lateHandlers = late
+	release := make(chan struct{})
+	fake.handle("users.info", func(w http.ResponseWriter, r *http.Request) {
+		<-release
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"ok":true,"user":{"id":"U1","name":"pasha"}}`)
+	})
+	mention := &slackevents.AppMentionEvent{User: "U1", Channel: "C1", Text: "<@U0BOT> hello", TimeStamp: "1712345678.000100"}
+	event := socketmode.Event{
+		Type:    socketmode.EventTypeEventsAPI,
+		Data:    callbackEvent("app_mention", mention),
+		Request: &socketmode.Request{EnvelopeID: "env-1"},
+	}
+
+	start := time.Now()
+	b.processEvent(context.Background(), event, &fakeSocket{})
+	if took := time.Since(start); took > 150*time.Millisecond {
+		t.Errorf("processing took %s, want it given up after the app_mention timeout", took)
+	}
+	close(release)
+	if err := <-late; err == nil {
+		t.Errorf("the late handler succeeded, want it stopped from posting")
+	}
+	if got := len(fake.calls("chat.postMessage")); got != 0 {
+		t.Errorf("the late handler posted %d messages", got)
+	}
+	if !strings.Contains(logs.String(), "app_mention handler didn't finish in time") {
+		t.Errorf("logs = %q, want the timeout logged", logs.String())
+	}
+	if got := b.metrics.get(metricHandlerErrors); got != 0 {
+		t.Errorf("reported %d errors for the expected timeout", got)
+	}
+}
@@ -0,0 +1,48 @@
+/*
+Copyright © 2024 Pavlo Tarasiuk <pasha.tarasyuk@gmail.com>
+*/
+package cmd
+
+import (
+	"log"
+	"net/http"
+	"path"
+	"time"
+)
+
+// warnIfSlow logs a warning when the operation took longer than threshold, a zero threshold disables it
+func warnIfSlow(operation string, elapsed, threshold time.Duration) {
+	if threshold > 0 && elapsed > threshold {
+		log.Printf("WARNING slow operation: %s took %s (threshold %s)\n", operation, elapsed.Round(time.Millisecond), threshold)
+	}
+}
+
+// timingTransport measures every Slack Web API request and warns about the slow ones
+type timingTransport struct {
+	next      http.RoundTripper
+	threshold time.Duration
+	now       func() time.Time
+}
+
+// newTimingTransport wraps next, warning about requests slower than threshold
+func newTimingTransport(next http.RoundTripper, threshold time.Duration) *timingTransport {
+	return &timingTransport{next: next, threshold: threshold, now: time.Now}
+}
+
+// RoundTrip implements http.RoundTripper
+func (t *timingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	start := t.now()
+	resp, err := t.next.RoundTrip(req)
+	// Web API URLs end with the method name, e.g. /api/chat.postMessage
+	warnIfSlow("Slack API "+path.Base(req.URL.Path), t.now().Sub(start), t.threshold)
+	return resp, err
+}
+
+// runHandler runs the named handler, warning when it is slow and recovering when it panics
+func (b *Bot) runHandler(name string, handler func() error) error {
+	start := b.now()
+	defer func() {
+		warnIfSlow("handler "+name, b.now().Sub(start), b.cfg.SlowThreshold)
+	}()
+	return b.recoverHandler(name, handler)
+}
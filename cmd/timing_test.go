@@ -0,0 +1,80 @@
+/*
+Copyright © 2024 Pavlo Tarasiuk <pasha.tarasyuk@gmail.com>
+*/
+package cmd
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/slack-go/slack"
+)
+
+// roundTripFunc is an http.RoundTripper made of a function
+type roundTripFunc func(*http.Request) (*http.Response, error)
+
+// RoundTrip implements http.RoundTripper
+func (f roundTripFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+func TestTimingTransportWarnsAboutSlowCalls(t *testing.T) {
+	tests := []struct {
+		name      string
+		took      time.Duration
+		threshold time.Duration
+		want      string
+	}{
+		{name: "slow", took: 2500 * time.Millisecond, threshold: 2 * time.Second, want: "WARNING slow operation: Slack API chat.postMessage took 2.5s (threshold 2s)"},
+		{name: "fast", took: time.Second, threshold: 2 * time.Second},
+		{name: "at the threshold", took: 2 * time.Second, threshold: 2 * time.Second},
+		{name: "disabled", took: time.Hour},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			logs := captureLog(t)
+			clock := newFakeClock()
+			transport := newTimingTransport(roundTripFunc(func(req *http.Request) (*http.Response, error) {
+				clock.advance(tt.took)
+				return &http.Response{StatusCode: http.StatusOK, Body: http.NoBody}, nil
+			}), tt.threshold)
+			transport.now = clock.now
+
+			req, _ := http.NewRequest(http.MethodPost, "https://slack.com/api/chat.postMessage", nil)
+			if _, err := transport.RoundTrip(req); err != nil {
+				t.Fatalf("request failed: %v", err)
+			}
+			if tt.want == "" && strings.Contains(logs.String(), "slow operation") {
+				t.Errorf("warned about a call within the threshold: %s", logs)
+			}
+			if tt.want != "" && !strings.Contains(logs.String(), tt.want) {
+				t.Errorf("got log %q, want %q", logs, tt.want)
+			}
+		})
+	}
+}
+
+func TestSlowHandlerWarning(t *testing.T) {
+	logs := captureLog(t)
+	cfg := testConfig(t)
+	cfg.SlowThreshold = time.Second
+	b, _ := newTestBot(t, cfg)
+	clock := newFakeClock()
+	b.now = clock.now
+	registerTestCommand(t, &slashCommand{
+		Name: "/test-slow",
+		Handler: func(*Bot, slack.SlashCommand) (*SlashResponse, error) {
+			clock.advance(3 * time.Second)
+			return nil, nil
+		},
+	})
+
+	b.processEvent(context.Background(), slashEvent("e1", slack.SlashCommand{Command: "/test-slow", UserID: "U1", ChannelID: "C1"}), &fakeSocket{})
+
+	if want := "WARNING slow operation: handler /test-slow took 3s (threshold 1s)"; !strings.Contains(logs.String(), want) {
+		t.Errorf("got log %q, want %q", logs, want)
+	}
+}
@@ -0,0 +1,146 @@
+/*
+Copyright © 2024 Pavlo Tarasiuk <pasha.tarasyuk@gmail.com>
+*/
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/slack-go/slack"
+)
+
+// weightedToken is an additional bot token messages are posted with and its share of them
+type weightedToken struct {
+	Token  string
+	Weight int
+}
+
+// parsePostingTokens parses the tokens written as TOKEN or TOKEN=WEIGHT, separated by commas
+func parsePostingTokens(s string) ([]weightedToken, error) {
+	var tokens []weightedToken
+	for _, item := range splitList(s) {
+		token, value, hasWeight := strings.Cut(item, "=")
+		weight := 1
+		if hasWeight {
+			n, err := strconv.Atoi(strings.TrimSpace(value))
+			if err != nil || n < 1 {
+				// The item holds a token, so it isn't repeated in the error
+				return nil, fmt.Errorf("invalid weight of posting token %d, expected TOKEN=N with N of at least 1", len(tokens)+1)
+			}
+			weight = n
+		}
+		tokens = append(tokens, weightedToken{Token: strings.TrimSpace(token), Weight: weight})
+	}
+	return tokens, nil
+}
+
+// pooledClient is a client of the pool with its weighted round-robin state
+type pooledClient struct {
+	// client is nil for the bot's own client, which may be replaced by token rotation
+	client *slack.Client
+	weight int
+	// current is the running score of smooth weighted round-robin
+	current int
+	// limitedUntil is when Slack's rate limit on the token is over
+	limitedUntil time.Time
+}
+
+// clientPool spreads posted messages across several bot tokens of the workspace by weighted
+// round-robin, skipping tokens while Slack rate limits them. Messages posted with another token
+// come from another bot user, so only posting goes through the pool; everything else that
+// expects the bot's own messages uses the bot's client.
+type clientPool struct {
+	now func() time.Time
+
+	mu      sync.Mutex
+	members []*pooledClient
+}
+
+// newClientPool creates a pool holding the bot's own client with weight 1
+func newClientPool(now func() time.Time) *clientPool {
+	return &clientPool{now: now, members: []*pooledClient{{weight: 1}}}
+}
+
+// add puts another client in the pool
+func (p *clientPool) add(client *slack.Client, weight int) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.members = append(p.members, &pooledClient{client: client, weight: weight})
+}
+
+// next picks the member to make the next call with. Rate-limited members are skipped, when all
+// of them are limited the one free soonest is picked.
+func (p *clientPool) next() *pooledClient {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	now := p.now()
+	var best *pooledClient
+	total := 0
+	for _, m := range p.members {
+		if now.Before(m.limitedUntil) {
+			continue
+		}
+		m.current += m.weight
+		total += m.weight
+		if best == nil || m.current > best.current {
+			best = m
+		}
+	}
+	if best == nil {
+		best = p.members[0]
+		for _, m := range p.members[1:] {
+			if m.limitedUntil.Before(best.limitedUntil) {
+				best = m
+			}
+		}
+		return best
+	}
+	best.current -= total
+	return best
+}
+
+// backOff takes the member out of the rotation when err is Slack's rate limit
+func (p *clientPool) backOff(m *pooledClient, err error) {
+	var limited *slack.RateLimitedError
+	if !errors.As(err, &limited) {
+		return
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	m.limitedUntil = p.now().Add(limited.RetryAfter)
+}
+
+// others returns the clients of PostingTokens, the pool's members besides the bot's own client
+func (p *clientPool) others() []*slack.Client {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	var clients []*slack.Client
+	for _, m := range p.members {
+		if m.client != nil {
+			clients = append(clients, m.client)
+		}
+	}
+	return clients
+}
+
+// size returns the number of clients in the pool
+func (p *clientPool) size() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return len(p.members)
+}
+
+// poster picks the client to post the next message with
+func (b *Bot) poster() (*slack.Client, *pooledClient) {
+	m := b.clients.next()
+	if m.client == nil {
+		return b.api(), m
+	}
+	return m.client, m
+}
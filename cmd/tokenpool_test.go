@@ -0,0 +1,200 @@
+/*
+Copyright © 2024 Pavlo Tarasiuk <pasha.tarasyuk@gmail.com>
+*/
+package cmd
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/slack-go/slack"
+)
+
+func TestParsePostingTokens(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    []weightedToken
+		wantErr bool
+	}{
+		{value: ""},
+		{value: "xoxb-1", want: []weightedToken{{"xoxb-1", 1}}},
+		{value: "xoxb-1=3, xoxb-2", want: []weightedToken{{"xoxb-1", 3}, {"xoxb-2", 1}}},
+		{value: " xoxb-1 = 2 ,", want: []weightedToken{{"xoxb-1", 2}}},
+		{value: "xoxb-1=0", wantErr: true},
+		{value: "xoxb-1=heavy", wantErr: true},
+		{value: "xoxb-1,xoxb-2=-1", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := parsePostingTokens(tt.value)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("parsePostingTokens(%q) error = %v, want error %t", tt.value, err, tt.wantErr)
+			continue
+		}
+		if err != nil && strings.Contains(err.Error(), "xoxb") {
+			t.Errorf("parsePostingTokens(%q) error = %v, it mustn't show the token", tt.value, err)
+		}
+		if len(got) != len(tt.want) {
+			t.Errorf("parsePostingTokens(%q) = %v, want %v", tt.value, got, tt.want)
+			continue
+		}
+		for i := range got {
+			if got[i] != tt.want[i] {
+				t.Errorf("parsePostingTokens(%q) = %v, want %v", tt.value, got, tt.want)
+			}
+		}
+	}
+}
+
+// newTestPool returns a pool of the bot's own client and two more with weights 2 and 3, in
+// the order of their weights
+func newTestPool(clock *fakeClock) (*clientPool, []*pooledClient) {
+	p := newClientPool(clock.now)
+	p.add(slack.New("xoxb-2"), 2)
+	p.add(slack.New("xoxb-3"), 3)
+	return p, p.members
+}
+
+func TestClientPoolWeightedRoundRobin(t *testing.T) {
+	p, members := newTestPool(newFakeClock())
+	picks := map[*pooledClient]int{}
+	var order []int
+	for i := 0; i < 60; i++ {
+		m := p.next()
+		picks[m]++
+		if i < 6 {
+			order = append(order, m.weight)
+		}
+	}
+	for _, m := range members {
+		if got, want := picks[m], 10*m.weight; got != want {
+			t.Errorf("weight %d picked %d times, want %d", m.weight, got, want)
+		}
+	}
+	// Smooth round-robin interleaves the members rather than picking one in a row, ties go to
+	// the member added first
+	if got, want := order, []int{3, 2, 1, 3, 2, 3}; !equalInts(got, want) {
+		t.Errorf("first picks by weight = %v, want %v", got, want)
+	}
+}
+
+// equalInts reports whether the slices hold the same numbers in the same order
+func equalInts(a, b []int) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func TestClientPoolSkipsRateLimitedToken(t *testing.T) {
+	clock := newFakeClock()
+	p, members := newTestPool(clock)
+	heavy := members[2]
+	p.backOff(heavy, &slack.RateLimitedError{RetryAfter: 30 * time.Second})
+
+	for i := 0; i < 30; i++ {
+		if m := p.next(); m == heavy {
+			t.Fatalf("pick %d used the rate-limited token", i+1)
+		}
+	}
+	clock.advance(30 * time.Second)
+	used := false
+	for i := 0; i < 6; i++ {
+		used = used || p.next() == heavy
+	}
+	if !used {
+		t.Errorf("the token stayed out of the rotation after its rate limit")
+	}
+
+	// Only Slack's rate limit takes a token out
+	until := heavy.limitedUntil
+	p.backOff(heavy, errors.New("channel_not_found"))
+	if !heavy.limitedUntil.Equal(until) {
+		t.Errorf("another error took the token out of the rotation")
+	}
+}
+
+func TestClientPoolAllRateLimited(t *testing.T) {
+	p, members := newTestPool(newFakeClock())
+	p.backOff(members[0], &slack.RateLimitedError{RetryAfter: time.Minute})
+	p.backOff(members[1], &slack.RateLimitedError{RetryAfter: 10 * time.Second})
+	p.backOff(members[2], &slack.RateLimitedError{RetryAfter: 30 * time.Second})
+	if m := p.next(); m != members[1] {
+		t.Errorf("picked weight %d, want the token free soonest", m.weight)
+	}
+}
+
+// rateLimited answers with Slack's rate limit for the seconds
+func rateLimited(seconds string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Retry-After", seconds)
+		w.WriteHeader(http.StatusTooManyRequests)
+	}
+}
+
+func TestPostsSpreadAcrossTokens(t *testing.T) {
+	cfg := testConfig(t)
+	cfg.RateLimitRetryWait = time.Minute
+	b, own := newTestBot(t, cfg)
+	clock := newFakeClock()
+	b.clients = newClientPool(clock.now)
+	other := newFakeSlack(t)
+	b.clients.add(slack.New("xoxb-other", slack.OptionAPIURL(other.apiURL())), 1)
+
+	post := func() {
+		t.Helper()
+		if _, err := b.postMessage(outboundMessage{Channel: "C1", Text: "Deploy finished"}); err != nil {
+			t.Fatalf("post failed: %v", err)
+		}
+	}
+	for i := 0; i < 4; i++ {
+		post()
+	}
+	if o, p := len(own.calls("chat.postMessage")), len(other.calls("chat.postMessage")); o != 2 || p != 2 {
+		t.Fatalf("posted %d with the bot's token and %d with the other, want 2 each", o, p)
+	}
+
+	// The rate-limited post goes out with the bot's token instead, which then takes every post
+	other.handle("chat.postMessage", rateLimited("30"))
+	for i := 0; i < 4; i++ {
+		post()
+	}
+	if o, p := len(own.calls("chat.postMessage")), len(other.calls("chat.postMessage")); o != 6 || p != 3 {
+		t.Errorf("posted %d with the bot's token and %d tried with the other, want 6 and 3", o, p)
+	}
+
+	clock.advance(30 * time.Second)
+	other.handle("chat.postMessage", nil)
+	post()
+	post()
+	if p := len(other.calls("chat.postMessage")); p != 4 {
+		t.Errorf("tried the other token %d times, want it back after its rate limit", p)
+	}
+}
+
+func TestPoolMessagesAreOwn(t *testing.T) {
+	b, fake := newTestBot(t, nil)
+	fake.answer("auth.test", `{"ok":true,"user_id":"U0BOT","bot_id":"B0BOT"}`)
+	other := newFakeSlack(t)
+	other.answer("auth.test", `{"ok":true,"user_id":"U0POOL","bot_id":"B0POOL"}`)
+	b.clients.add(slack.New("xoxb-other", slack.OptionAPIURL(other.apiURL())), 1)
+
+	if err := b.identify(); err != nil {
+		t.Fatalf("identify() error = %v", err)
+	}
+	for _, author := range []struct{ user, bot string }{{"U0BOT", ""}, {"", "B0BOT"}, {"U0POOL", ""}, {"", "B0POOL"}} {
+		if !b.isOwnMessage(author.user, author.bot) {
+			t.Errorf("isOwnMessage(%q, %q) = false, want the bot's own", author.user, author.bot)
+		}
+	}
+	if b.isOwnMessage("U1", "B1") {
+		t.Errorf("isOwnMessage(U1, B1) = true, want someone else's")
+	}
+}
@@ -0,0 +1,148 @@
+/*
+Copyright © 2024 Pavlo Tarasiuk <pasha.tarasyuk@gmail.com>
+*/
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/slack-go/slack"
+)
+
+// collectionTokens holds the rotating bot token, the file Store keeps it readable by the bot's user only
+const collectionTokens = "tokens"
+
+// tokenKey is the key of the bot token in collectionTokens
+const tokenKey = "bot"
+
+// tokenRetryInterval is how long to wait before retrying a failed refresh
+const tokenRetryInterval = time.Minute
+
+// tokenSet is a bot token issued by Slack's token rotation together with what is needed to renew it
+type tokenSet struct {
+	AccessToken  string    `json:"access_token"`
+	RefreshToken string    `json:"refresh_token"`
+	ExpiresAt    time.Time `json:"expires_at"`
+}
+
+// tokenRotator refreshes the bot token before it expires and swaps the bot's client for one using the new token
+type tokenRotator struct {
+	bot        *Bot
+	httpClient *http.Client
+	newClient  func(token string) *slack.Client
+
+	// mu guards current, the token is refreshed on schedule and when Slack stops accepting it
+	mu      sync.Mutex
+	current tokenSet
+}
+
+// tokenRotationEnabled reports whether the configuration asks for token rotation
+func (c *Config) tokenRotationEnabled() bool {
+	return c.ClientID != "" && c.ClientSecret != "" && c.RefreshToken != ""
+}
+
+// newTokenRotator prepares rotation for the bot, refreshing the token right away when the
+// stored one is missing or about to expire so the bot starts with a usable client
+func newTokenRotator(b *Bot, httpClient *http.Client, newClient func(token string) *slack.Client) (*tokenRotator, error) {
+	r := &tokenRotator{bot: b, httpClient: httpClient, newClient: newClient}
+
+	// A stored refresh token supersedes the configured one, which Slack invalidates once used
+	found, err := b.store.Get(collectionTokens, tokenKey, &r.current)
+	if err != nil {
+		return nil, err
+	}
+	if !found {
+		r.current = tokenSet{AccessToken: b.cfg.BotToken, RefreshToken: b.cfg.RefreshToken}
+	}
+
+	if r.due() {
+		if err := r.refresh(context.Background()); err != nil {
+			return nil, err
+		}
+	} else {
+		b.client.set(newClient(r.current.AccessToken), r.current.AccessToken)
+	}
+	return r, nil
+}
+
+// due reports whether the current token expires within the refresh margin
+func (r *tokenRotator) due() bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.current.AccessToken == "" || !r.bot.now().Before(r.current.ExpiresAt.Add(-r.bot.cfg.TokenRefreshMargin))
+}
+
+// refreshAt is when the current token should be refreshed
+func (r *tokenRotator) refreshAt() time.Time {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.current.ExpiresAt.Add(-r.bot.cfg.TokenRefreshMargin)
+}
+
+// refresh exchanges the refresh token for a new token set, stores it and switches the bot to it
+func (r *tokenRotator) refresh(ctx context.Context) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	cfg := r.bot.cfg
+	resp, err := slack.RefreshOAuthV2TokenContext(ctx, r.httpClient, cfg.ClientID, cfg.ClientSecret, r.current.RefreshToken)
+	if err != nil {
+		return fmt.Errorf("failed to refresh the bot token: %w", err)
+	}
+
+	next := tokenSet{
+		AccessToken:  resp.AccessToken,
+		RefreshToken: resp.RefreshToken,
+		ExpiresAt:    r.bot.now().Add(time.Duration(resp.ExpiresIn) * time.Second),
+	}
+	// The old refresh token is spent now, so the new one is persisted before anything else
+	if err := r.bot.store.Put(collectionTokens, tokenKey, next); err != nil {
+		return err
+	}
+	r.current = next
+	r.bot.client.set(r.newClient(next.AccessToken), next.AccessToken)
+	log.Printf("Bot token refreshed, it expires at %s\n", next.ExpiresAt.Format(time.RFC3339))
+	return nil
+}
+
+// Secret implements SecretProvider, so a bot token Slack stopped accepting is reloaded by refreshing
+// it. With rotation SLACK_AUTH_TOKEN holds the first token only, which is stale after a refresh.
+func (r *tokenRotator) Secret(key string) (string, error) {
+	if key != "SLACK_AUTH_TOKEN" {
+		return "", fmt.Errorf("%s isn't rotated", key)
+	}
+	if err := r.refresh(context.Background()); err != nil {
+		return "", err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.current.AccessToken, nil
+}
+
+// run refreshes the token ahead of every expiry until ctx is cancelled
+func (r *tokenRotator) run(ctx context.Context) {
+	for {
+		wait := r.refreshAt().Sub(r.bot.now())
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+
+		if err := r.refresh(ctx); err != nil {
+			log.Println(err)
+			// Keep the expiry so the next attempt is due immediately, just not in a tight loop
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(tokenRetryInterval):
+			}
+		}
+	}
+}
@@ -0,0 +1,155 @@
+/*
+Copyright © 2024 Pavlo Tarasiuk <pasha.tarasyuk@gmail.com>
+*/
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/slack-go/slack"
+)
+
+// newRotationBot creates a bot with token rotation configured, the fake Slack issuing
+// xoxb-1, xoxb-2, ... with refresh tokens xoxe-1, xoxe-2, ... valid for an hour
+func newRotationBot(t *testing.T) (*Bot, *fakeSlack, func(token string) *slack.Client) {
+	cfg := testConfig(t)
+	cfg.ClientID, cfg.ClientSecret, cfg.RefreshToken = "client", "secret", "xoxe-0"
+	cfg.BotToken = "xoxb-0"
+	b, fake := newTestBot(t, cfg)
+	var issued int32
+	fake.handle("oauth.v2.access", func(w http.ResponseWriter, r *http.Request) {
+		n := atomic.AddInt32(&issued, 1)
+		fmt.Fprintf(w, `{"ok":true,"access_token":"xoxb-%d","refresh_token":"xoxe-%d","expires_in":3600,"token_type":"bot"}`, n, n)
+	})
+	newClient := func(token string) *slack.Client {
+		return slack.New(token, slack.OptionAPIURL(fake.apiURL()))
+	}
+	return b, fake, newClient
+}
+
+// tokenUsed returns the token the bot's client calls Slack with
+func tokenUsed(t *testing.T, b *Bot, fake *fakeSlack) string {
+	t.Helper()
+	if _, err := b.api().AuthTest(); err != nil {
+		t.Fatalf("auth.test failed: %v", err)
+	}
+	calls := fake.calls("auth.test")
+	return calls[len(calls)-1].Form.Get("token")
+}
+
+func TestTokenRotationRefreshesAtStart(t *testing.T) {
+	b, fake, newClient := newRotationBot(t)
+	clock := newFakeClock()
+	b.now = clock.now
+
+	if _, err := newTokenRotator(b, fake.redirecting(), newClient); err != nil {
+		t.Fatalf("failed to start rotation: %v", err)
+	}
+
+	calls := fake.calls("oauth.v2.access")
+	if len(calls) != 1 || calls[0].Form.Get("refresh_token") != "xoxe-0" || calls[0].Form.Get("grant_type") != "refresh_token" {
+		t.Fatalf("got refresh calls %+v, want the configured refresh token exchanged", calls)
+	}
+	if got := tokenUsed(t, b, fake); got != "xoxb-1" {
+		t.Errorf("the client uses %q, want the refreshed token", got)
+	}
+	var stored tokenSet
+	if ok, err := b.store.Get(collectionTokens, tokenKey, &stored); err != nil || !ok {
+		t.Fatalf("the token set wasn't stored: %v", err)
+	}
+	if want := (tokenSet{AccessToken: "xoxb-1", RefreshToken: "xoxe-1", ExpiresAt: clock.now().Add(time.Hour)}); stored != want {
+		t.Errorf("stored %+v, want %+v", stored, want)
+	}
+}
+
+func TestTokenRotationKeepsAValidStoredToken(t *testing.T) {
+	b, fake, newClient := newRotationBot(t)
+	clock := newFakeClock()
+	b.now = clock.now
+	stored := tokenSet{AccessToken: "xoxb-stored", RefreshToken: "xoxe-stored", ExpiresAt: clock.now().Add(time.Hour)}
+	if err := b.store.Put(collectionTokens, tokenKey, stored); err != nil {
+		t.Fatal(err)
+	}
+
+	r, err := newTokenRotator(b, fake.redirecting(), newClient)
+	if err != nil {
+		t.Fatalf("failed to start rotation: %v", err)
+	}
+	if len(fake.calls("oauth.v2.access")) != 0 {
+		t.Errorf("refreshed a token valid for another hour")
+	}
+	if got := tokenUsed(t, b, fake); got != "xoxb-stored" {
+		t.Errorf("the client uses %q, want the stored token", got)
+	}
+	if want := stored.ExpiresAt.Add(-b.cfg.TokenRefreshMargin); !r.refreshAt().Equal(want) {
+		t.Errorf("refresh scheduled at %s, want %s", r.refreshAt(), want)
+	}
+
+	// The stored refresh token is the one to exchange, the configured one was spent long ago
+	clock.advance(time.Hour - b.cfg.TokenRefreshMargin)
+	if !r.due() {
+		t.Fatalf("the token isn't due within the refresh margin")
+	}
+	if err := r.refresh(context.Background()); err != nil {
+		t.Fatalf("refresh failed: %v", err)
+	}
+	if got := fake.calls("oauth.v2.access")[0].Form.Get("refresh_token"); got != "xoxe-stored" {
+		t.Errorf("exchanged %q, want the stored refresh token", got)
+	}
+}
+
+func TestTokenRotationRunRefreshesBeforeExpiry(t *testing.T) {
+	b, fake, newClient := newRotationBot(t)
+	b.cfg.TokenRefreshMargin = time.Minute
+	// The token expires a little after the margin starts, so the refresh is due right away
+	stored := tokenSet{AccessToken: "xoxb-stored", RefreshToken: "xoxe-stored", ExpiresAt: time.Now().Add(time.Minute + 50*time.Millisecond)}
+	if err := b.store.Put(collectionTokens, tokenKey, stored); err != nil {
+		t.Fatal(err)
+	}
+	r, err := newTokenRotator(b, fake.redirecting(), newClient)
+	if err != nil {
+		t.Fatalf("failed to start rotation: %v", err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go r.run(ctx)
+
+	fake.waitCalls(t, "oauth.v2.access", 1)
+	deadline := time.Now().Add(time.Second)
+	for b.client.token() != "xoxb-1" && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	if time.Now().After(stored.ExpiresAt) {
+		t.Errorf("the token was refreshed after it expired")
+	}
+	if got := tokenUsed(t, b, fake); got != "xoxb-1" {
+		t.Errorf("the client uses %q after the scheduled refresh, want xoxb-1", got)
+	}
+}
+
+func TestTokenRotationFailureKeepsTheToken(t *testing.T) {
+	b, fake, newClient := newRotationBot(t)
+	clock := newFakeClock()
+	b.now = clock.now
+	stored := tokenSet{AccessToken: "xoxb-stored", RefreshToken: "xoxe-stored", ExpiresAt: clock.now().Add(time.Hour)}
+	if err := b.store.Put(collectionTokens, tokenKey, stored); err != nil {
+		t.Fatal(err)
+	}
+	r, err := newTokenRotator(b, fake.redirecting(), newClient)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fake.answer("oauth.v2.access", `{"ok":false,"error":"invalid_refresh_token"}`)
+
+	if err := r.refresh(context.Background()); err == nil {
+		t.Fatalf("a rejected refresh succeeded")
+	}
+	if got := tokenUsed(t, b, fake); got != "xoxb-stored" {
+		t.Errorf("the client uses %q after a failed refresh, want the old token", got)
+	}
+}
@@ -0,0 +1,139 @@
+/*
+Copyright © 2024 Pavlo Tarasiuk <pasha.tarasyuk@gmail.com>
+*/
+package cmd
+
+import (
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/slack-go/slack"
+)
+
+// maxTrackedItems bounds each list of /tracking, the rest are only counted
+const maxTrackedItems = 20
+
+// trackedList collects the lines of one list of /tracking
+type trackedList struct {
+	title string
+	lines []string
+	total int
+}
+
+// add appends a line, lines past maxTrackedItems are only counted
+func (l *trackedList) add(line string) {
+	l.total++
+	if len(l.lines) < maxTrackedItems {
+		l.lines = append(l.lines, line)
+	}
+}
+
+// format renders the list under its title
+func (l *trackedList) format() string {
+	var out strings.Builder
+	fmt.Fprintf(&out, "*%s* (%d)\n", l.title, l.total)
+	if l.total == 0 {
+		out.WriteString("none\n")
+	}
+	for _, line := range l.lines {
+		fmt.Fprintf(&out, "• %s\n", line)
+	}
+	if more := l.total - len(l.lines); more > 0 {
+		fmt.Fprintf(&out, "…and %d more\n", more)
+	}
+	return out.String()
+}
+
+// formatAge renders how long ago t was
+func formatAge(now, t time.Time) string {
+	if t.IsZero() {
+		return "unknown"
+	}
+	return now.Sub(t).Round(time.Second).String()
+}
+
+// trackedFollowUps lists the threads with a follow-up survey still to come
+func (b *Bot) trackedFollowUps(now time.Time) (*trackedList, error) {
+	list := &trackedList{title: "Threads awaiting a follow-up survey"}
+	keys, err := b.store.Keys(collectionFollowUps)
+	if err != nil {
+		return nil, err
+	}
+	for _, key := range keys {
+		var scheduled followUp
+		if found, err := b.store.Get(collectionFollowUps, key, &scheduled); err != nil || !found {
+			if err != nil {
+				log.Printf("failed to load follow-up %s: %v\n", key, err)
+			}
+			continue
+		}
+		if !scheduled.PostAt.After(now) {
+			continue
+		}
+		list.add(fmt.Sprintf("%s thread %s, scheduled %s ago, due in %s",
+			channelRef(scheduled.Channel), scheduled.ThreadTS,
+			formatAge(now, scheduled.ScheduledAt), scheduled.PostAt.Sub(now).Round(time.Second)))
+	}
+	return list, nil
+}
+
+// trackedConversations lists the conversations in progress
+func (b *Bot) trackedConversations(now time.Time) (*trackedList, error) {
+	list := &trackedList{title: "Conversations in progress"}
+	keys, err := b.store.Keys(collectionConversations)
+	if err != nil {
+		return nil, err
+	}
+	for _, key := range keys {
+		var state conversation
+		if found, err := b.store.Get(collectionConversations, key, &state); err != nil || !found {
+			if err != nil {
+				log.Printf("failed to load conversation %s: %v\n", key, err)
+			}
+			continue
+		}
+		if !now.Before(state.ExpiresAt) {
+			continue
+		}
+		steps := 0
+		if flow, ok := conversationFlows[state.Flow]; ok {
+			steps = len(flow.Steps)
+		}
+		userID, channelID, _ := strings.Cut(key, "/")
+		list.add(fmt.Sprintf("%s in %s: /%s step %d of %d, started %s ago",
+			userRef(userID), channelRef(channelID), state.Flow, len(state.Values)+1, steps, formatAge(now, state.StartedAt)))
+	}
+	return list, nil
+}
+
+// handleTracking shows what the bot is keeping track of: follow-up surveys to come, conversations
+// in progress and messages held back, so operators can see the state behind its behaviour
+func (b *Bot) handleTracking(command slack.SlashCommand) (*SlashResponse, error) {
+	now := b.now()
+	followUps, err := b.trackedFollowUps(now)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list follow-ups: %w", err)
+	}
+	conversations, err := b.trackedConversations(now)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list conversations: %w", err)
+	}
+
+	text := followUps.format() + conversations.format()
+	if held := b.migration.heldCount(); held > 0 {
+		text += fmt.Sprintf("*Messages held during the grid migration* (%d)\n", held)
+	}
+	return ephemeral(text), nil
+}
+
+func init() {
+	registerSlashCommand(&slashCommand{
+		Name:        "/tracking",
+		Description: "List the threads, reminders and conversations the bot is keeping track of",
+		Category:    categoryAdmin,
+		AdminOnly:   true,
+		Handler:     (*Bot).handleTracking,
+	})
+}
@@ -0,0 +1,107 @@
+/*
+Copyright © 2024 Pavlo Tarasiuk <pasha.tarasyuk@gmail.com>
+*/
+package cmd
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/slack-go/slack"
+)
+
+// runTracking runs /tracking and returns the answer
+func runTracking(t *testing.T, b *Bot) string {
+	t.Helper()
+	resp, err := b.handleTracking(slack.SlashCommand{Command: "/tracking", UserID: "U0ADMIN"})
+	if err != nil {
+		t.Fatalf("/tracking failed: %v", err)
+	}
+	if resp.ResponseType != slack.ResponseTypeEphemeral {
+		t.Errorf("answered in the channel, want ephemerally")
+	}
+	return resp.Text
+}
+
+func TestTrackingAges(t *testing.T) {
+	b, _, clock := newFollowUpBot(t)
+	b.cfg.ConversationTimeout = 5 * time.Minute
+	if got, want := runTracking(t, b), "*Threads awaiting a follow-up survey* (0)\nnone\n*Conversations in progress* (0)\nnone\n"; got != want {
+		t.Errorf("answered %q with nothing tracked, want %q", got, want)
+	}
+
+	if _, err := b.scheduleSurvey("C1", "1712345678.000100", "U1"); err != nil {
+		t.Fatalf("failed to schedule the survey: %v", err)
+	}
+	clock.advance(2 * time.Minute)
+	if _, err := b.startConversation(setupFlow, "U2", "C2"); err != nil {
+		t.Fatalf("failed to start the conversation: %v", err)
+	}
+	clock.advance(time.Minute)
+	if _, ok, err := b.advanceConversation("U2", "C2", "uk"); !ok || err != nil {
+		t.Fatalf("failed to answer: %t, %v", ok, err)
+	}
+	clock.advance(30 * time.Second)
+
+	want := "*Threads awaiting a follow-up survey* (1)\n" +
+		"• <#C1> thread 1712345678.000100, scheduled 3m30s ago, due in 6m30s\n" +
+		"*Conversations in progress* (1)\n" +
+		"• <@U2> in <#C2>: /setup step 2 of 2, started 1m30s ago\n"
+	if got := runTracking(t, b); got != want {
+		t.Errorf("answered\n%s\nwant\n%s", got, want)
+	}
+
+	// Neither a due survey nor a timed out conversation is tracked anymore
+	clock.advance(10 * time.Minute)
+	if got := runTracking(t, b); strings.Contains(got, "•") {
+		t.Errorf("answered %q, want the past items left out", got)
+	}
+}
+
+func TestTrackingIsBounded(t *testing.T) {
+	b, _ := newTestBot(t, nil)
+	clock := newFakeClock()
+	b.now = clock.now
+	for i := 0; i < maxTrackedItems+5; i++ {
+		state := conversation{Flow: "setup", StartedAt: clock.now(), ExpiresAt: clock.now().Add(time.Minute)}
+		if err := b.store.Put(collectionConversations, conversationKey(fmt.Sprintf("U%02d", i), "C1"), state); err != nil {
+			t.Fatal(err)
+		}
+	}
+	got := runTracking(t, b)
+	if !strings.Contains(got, fmt.Sprintf("*Conversations in progress* (%d)\n", maxTrackedItems+5)) {
+		t.Errorf("answered %q, want every conversation counted", got)
+	}
+	if lines := strings.Count(got, "•"); lines != maxTrackedItems {
+		t.Errorf("listed %d conversations, want %d", lines, maxTrackedItems)
+	}
+	if !strings.HasSuffix(got, "…and 5 more\n") {
+		t.Errorf("answered %q, want the rest counted", got)
+	}
+}
+
+func TestTrackingHeldMessages(t *testing.T) {
+	captureLog(t)
+	b, _ := newTestBot(t, nil)
+	b.migration.start()
+	b.migration.hold(outboundMessage{Channel: "C1", Text: "Deploy finished"})
+	b.migration.hold(outboundMessage{Channel: "C2", Text: "Deploy finished"})
+	if got := runTracking(t, b); !strings.HasSuffix(got, "*Messages held during the grid migration* (2)\n") {
+		t.Errorf("answered %q, want the held messages counted", got)
+	}
+}
+
+func TestTrackingAdminOnly(t *testing.T) {
+	cfg := testConfig(t)
+	cfg.Admins = []string{"U0ADMIN"}
+	b, _ := newTestBot(t, cfg)
+	resp, err := b.dispatchSlashCommand(slack.SlashCommand{Command: "/tracking", UserID: "U1", ChannelID: "C1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.Text != "Sorry, this command is available to MAVBot admins only" {
+		t.Errorf("answered %q, want the command refused", resp.Text)
+	}
+}
@@ -0,0 +1,58 @@
+/*
+Copyright © 2024 Pavlo Tarasiuk <pasha.tarasyuk@gmail.com>
+*/
+package cmd
+
+import (
+	"encoding/json"
+	"log"
+
+	"github.com/slack-go/slack/socketmode"
+)
+
+// unparsedEventHandlers handle Events API events slack-go doesn't know about, keyed by inner event type.
+// They receive the raw inner event JSON.
+var unparsedEventHandlers = map[string]func(b *Bot, raw json.RawMessage) error{
+	"star_added":              (*Bot).handleStarAdded,
+	"subteam_members_changed": (*Bot).handleSubteamMembersChanged,
+	"subteam_created":         (*Bot).handleSubteamUpdated,
+	"subteam_updated":         (*Bot).handleSubteamUpdated,
+}
+
+// handleBadMessage looks into messages socketmode failed to parse. Events API events of
+// types slack-go doesn't know end up here, those with a handler are acknowledged and handled.
+func (b *Bot) handleBadMessage(event socketmode.Event, socket acker) error {
+	bad, ok := event.Data.(*socketmode.ErrorBadMessage)
+	if !ok {
+		return nil
+	}
+
+	var req socketmode.Request
+	if err := json.Unmarshal(bad.Message, &req); err != nil || req.Type != socketmode.RequestTypeEventsAPI {
+		log.Printf("Could not parse socketmode message: %v\n", bad.Cause)
+		return nil
+	}
+	var callback struct {
+		Event json.RawMessage `json:"event"`
+	}
+	var inner struct {
+		Type string `json:"type"`
+	}
+	if err := json.Unmarshal(req.Payload, &callback); err != nil {
+		log.Printf("Could not parse Events API payload: %v\n", err)
+		return nil
+	}
+	if err := json.Unmarshal(callback.Event, &inner); err != nil {
+		log.Printf("Could not parse Events API event: %v\n", err)
+		return nil
+	}
+
+	handler, ok := unparsedEventHandlers[inner.Type]
+	if !ok {
+		log.Printf("Could not parse socketmode message: %v\n", bad.Cause)
+		return nil
+	}
+	// The request wasn't acknowledged by socketmode, so it's up to us
+	socket.Ack(req)
+	return handler(b, callback.Event)
+}
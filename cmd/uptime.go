@@ -0,0 +1,61 @@
+/*
+Copyright © 2024 Pavlo Tarasiuk <pasha.tarasyuk@gmail.com>
+*/
+package cmd
+
+import (
+	"fmt"
+	"runtime"
+	"time"
+
+	"github.com/slack-go/slack"
+)
+
+// uptime returns how long the bot has been running
+func (b *Bot) uptime() time.Duration {
+	return b.now().Sub(b.startedAt)
+}
+
+// handleUptime reports the bot's uptime and runtime statistics to the invoking user
+func (b *Bot) handleUptime(command slack.SlashCommand) (*SlashResponse, error) {
+	var mem runtime.MemStats
+	runtime.ReadMemStats(&mem)
+
+	text := fmt.Sprintf("*MAVBot %s*\n"+
+		"Started: %s\n"+
+		"Uptime: %s\n"+
+		"Goroutines: %d\n"+
+		"Memory: %s in use, %s obtained from the OS",
+		appVersion,
+		b.startedAt.Format("2006-01-02 15:04:05"),
+		b.uptime().Round(time.Second),
+		runtime.NumGoroutine(),
+		formatBytes(mem.Alloc),
+		formatBytes(mem.Sys),
+	)
+	return ephemeral(text), nil
+}
+
+// formatBytes renders a byte count with a binary unit, e.g. 1.5 MiB
+func formatBytes(n uint64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	div, exp := uint64(unit), 0
+	for m := n / unit; m >= unit; m /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(n)/float64(div), "KMGTPE"[exp])
+}
+
+func init() {
+	registerSlashCommand(&slashCommand{
+		Name:        "/uptime",
+		Description: "Show how long the bot has been running and its resource usage",
+		Category:    categoryGeneral,
+		Format:      formatBlocks,
+		Handler:     (*Bot).handleUptime,
+	})
+}
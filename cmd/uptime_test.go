@@ -0,0 +1,55 @@
+/*
+Copyright © 2024 Pavlo Tarasiuk <pasha.tarasyuk@gmail.com>
+*/
+package cmd
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/slack-go/slack"
+)
+
+func TestUptimeUsesTheClock(t *testing.T) {
+	b, _ := newTestBot(t, nil)
+	clock := newFakeClock()
+	b.now = clock.now
+	b.startedAt = clock.now()
+	clock.advance(26*time.Hour + 3*time.Minute + 4*time.Second + 600*time.Millisecond)
+
+	if got, want := b.uptime(), 26*time.Hour+3*time.Minute+4*time.Second+600*time.Millisecond; got != want {
+		t.Errorf("got uptime %s, want %s", got, want)
+	}
+	response, err := b.handleUptime(slack.SlashCommand{UserID: "U1"})
+	if err != nil {
+		t.Fatalf("/uptime failed: %v", err)
+	}
+	if response.ResponseType != slack.ResponseTypeEphemeral {
+		t.Errorf("got response type %q, want ephemeral", response.ResponseType)
+	}
+	for _, want := range []string{"MAVBot " + appVersion, "Started: 2024-04-05 12:00:00", "Uptime: 26h3m5s", "Goroutines: ", "Memory: "} {
+		if !strings.Contains(response.Text, want) {
+			t.Errorf("response lacks %q:\n%s", want, response.Text)
+		}
+	}
+}
+
+func TestFormatBytes(t *testing.T) {
+	tests := []struct {
+		n    uint64
+		want string
+	}{
+		{0, "0 B"},
+		{1023, "1023 B"},
+		{1024, "1.0 KiB"},
+		{1536, "1.5 KiB"},
+		{5 << 20, "5.0 MiB"},
+		{3 << 30, "3.0 GiB"},
+	}
+	for _, tt := range tests {
+		if got := formatBytes(tt.n); got != tt.want {
+			t.Errorf("formatBytes(%d) = %q, want %q", tt.n, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,106 @@
+/*
+Copyright © 2024 Pavlo Tarasiuk <pasha.tarasyuk@gmail.com>
+*/
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// subteamMembersChangedEvent is the subteam_members_changed event, which slack-go has no type for
+type subteamMembersChangedEvent struct {
+	Type      string `json:"type"`
+	SubteamID string `json:"subteam_id"`
+	TeamID    string `json:"team_id"`
+}
+
+// subteamUpdatedEvent is the subteam_created and subteam_updated event
+type subteamUpdatedEvent struct {
+	Type    string `json:"type"`
+	Subteam struct {
+		ID     string `json:"id"`
+		Handle string `json:"handle"`
+	} `json:"subteam"`
+}
+
+// isUserGroupRef reports whether ref names a user group, by its handle like @oncall or its ID like S0123ABCD
+func isUserGroupRef(ref string) bool {
+	return strings.HasPrefix(ref, "@") || strings.HasPrefix(ref, "S")
+}
+
+// resolveUserGroup returns the ID of the user group with the handle, with or without the leading @
+func (b *Bot) resolveUserGroup(handle string) (string, error) {
+	handle = strings.TrimPrefix(handle, "@")
+	if id, ok := b.groupHandles.get(handle); ok {
+		return id, nil
+	}
+	// One listing resolves every handle, so it is cached whole
+	groups, err := b.api().GetUserGroups()
+	if err != nil {
+		return "", fmt.Errorf("failed to list user groups: %w", err)
+	}
+	id := ""
+	for _, group := range groups {
+		b.groupHandles.set(group.Handle, group.ID)
+		if group.Handle == handle {
+			id = group.ID
+		}
+	}
+	if id == "" {
+		return "", fmt.Errorf("unknown user group @%s", handle)
+	}
+	return id, nil
+}
+
+// groupMembers returns the IDs of the members of the user group, asking Slack only the first time they are needed
+func (b *Bot) groupMembers(groupID string) ([]string, error) {
+	if members, ok := b.groupMembership.get(groupID); ok {
+		return members, nil
+	}
+	members, err := b.api().GetUserGroupMembers(groupID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get members of %s: %w", groupID, err)
+	}
+	b.groupMembership.set(groupID, members)
+	return members, nil
+}
+
+// inUserGroup reports whether the user belongs to the group, given by its handle or ID
+func (b *Bot) inUserGroup(userID, group string) (bool, error) {
+	groupID := group
+	if strings.HasPrefix(group, "@") {
+		var err error
+		if groupID, err = b.resolveUserGroup(group); err != nil {
+			return false, err
+		}
+	}
+	members, err := b.groupMembers(groupID)
+	if err != nil {
+		return false, err
+	}
+	return containsString(members, userID), nil
+}
+
+// handleSubteamMembersChanged forgets the members of the group, they are fetched again when next needed
+func (b *Bot) handleSubteamMembersChanged(raw json.RawMessage) error {
+	var event subteamMembersChangedEvent
+	if err := json.Unmarshal(raw, &event); err != nil {
+		return fmt.Errorf("failed to decode subteam_members_changed event: %w", err)
+	}
+	b.groupMembership.delete(event.SubteamID)
+	return nil
+}
+
+// handleSubteamUpdated keeps the handle of a created or renamed group current
+func (b *Bot) handleSubteamUpdated(raw json.RawMessage) error {
+	var event subteamUpdatedEvent
+	if err := json.Unmarshal(raw, &event); err != nil {
+		return fmt.Errorf("failed to decode subteam event: %w", err)
+	}
+	// The old handle is unknown, so all of them are looked up again
+	b.groupHandles.purge()
+	b.groupMembership.delete(event.Subteam.ID)
+	return nil
+}
@@ -0,0 +1,107 @@
+/*
+Copyright © 2024 Pavlo Tarasiuk <pasha.tarasyuk@gmail.com>
+*/
+package cmd
+
+import (
+	"context"
+	"testing"
+)
+
+// newUserGroupBot creates a bot whose workspace has the @oncall group with U1 and U2
+func newUserGroupBot(t *testing.T) (*Bot, *fakeSlack) {
+	b, fake := newTestBot(t, nil)
+	fake.answer("usergroups.list", `{"ok":true,"usergroups":[{"id":"S0ONCALL","handle":"oncall"},{"id":"S0DEVS","handle":"devs"}]}`)
+	fake.answer("usergroups.users.list", `{"ok":true,"users":["U1","U2"]}`)
+	return b, fake
+}
+
+func TestInUserGroup(t *testing.T) {
+	tests := []struct {
+		user  string
+		group string
+		want  bool
+	}{
+		{"U1", "@oncall", true},
+		{"U2", "oncall", true},
+		{"U2", "S0ONCALL", true},
+		{"U3", "@oncall", false},
+		{"U3", "S0ONCALL", false},
+	}
+	for _, tt := range tests {
+		b, _ := newUserGroupBot(t)
+		got, err := b.inUserGroup(tt.user, tt.group)
+		if err != nil {
+			t.Errorf("inUserGroup(%s, %s) error = %v", tt.user, tt.group, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("inUserGroup(%s, %s) = %t, want %t", tt.user, tt.group, got, tt.want)
+		}
+	}
+}
+
+func TestInUnknownUserGroup(t *testing.T) {
+	b, _ := newUserGroupBot(t)
+	if _, err := b.inUserGroup("U1", "@nobody"); err == nil {
+		t.Errorf("inUserGroup() of an unknown handle succeeded, want an error")
+	}
+}
+
+func TestUserGroupsAreCached(t *testing.T) {
+	b, fake := newUserGroupBot(t)
+	for _, user := range []string{"U1", "U2", "U3", "U1"} {
+		if _, err := b.inUserGroup(user, "@oncall"); err != nil {
+			t.Fatalf("inUserGroup() error = %v", err)
+		}
+	}
+	// The listing resolves every handle, @devs needs no other
+	if _, err := b.resolveUserGroup("@devs"); err != nil {
+		t.Fatalf("resolveUserGroup() error = %v", err)
+	}
+	if got := len(fake.calls("usergroups.list")); got != 1 {
+		t.Errorf("listed the user groups %d times, want once", got)
+	}
+	members := fake.calls("usergroups.users.list")
+	if len(members) != 1 || members[0].Form.Get("usergroup") != "S0ONCALL" {
+		t.Errorf("usergroups.users.list calls = %+v, want one for S0ONCALL", members)
+	}
+}
+
+func TestUserGroupEventsRefreshTheCache(t *testing.T) {
+	b, fake := newUserGroupBot(t)
+	if member, _ := b.inUserGroup("U3", "@oncall"); member {
+		t.Fatalf("U3 is a member before joining")
+	}
+
+	// U3 joins the group
+	fake.answer("usergroups.users.list", `{"ok":true,"users":["U1","U2","U3"]}`)
+	b.processEvent(context.Background(), unparsedEvent("env-1", `{"type":"subteam_members_changed","subteam_id":"S0ONCALL","team_id":"T1"}`), &fakeSocket{})
+	if member, err := b.inUserGroup("U3", "@oncall"); !member || err != nil {
+		t.Errorf("U3 after joining = %t, %v, want a member", member, err)
+	}
+
+	// The group is renamed to @support
+	fake.answer("usergroups.list", `{"ok":true,"usergroups":[{"id":"S0ONCALL","handle":"support"}]}`)
+	b.processEvent(context.Background(), unparsedEvent("env-2", `{"type":"subteam_updated","subteam":{"id":"S0ONCALL","handle":"support"}}`), &fakeSocket{})
+	if member, err := b.inUserGroup("U1", "@support"); !member || err != nil {
+		t.Errorf("U1 in the renamed group = %t, %v, want a member", member, err)
+	}
+	if _, err := b.inUserGroup("U1", "@oncall"); err == nil {
+		t.Errorf("the old handle still resolves after the rename")
+	}
+}
+
+func TestAdminByUserGroup(t *testing.T) {
+	cfg := testConfig(t)
+	cfg.Admins = []string{"U0ADMIN", "@oncall"}
+	b, fake := newTestBot(t, cfg)
+	fake.answer("usergroups.list", `{"ok":true,"usergroups":[{"id":"S0ONCALL","handle":"oncall"}]}`)
+	fake.answer("usergroups.users.list", `{"ok":true,"users":["U1"]}`)
+
+	for user, want := range map[string]bool{"U0ADMIN": true, "U1": true, "U2": false} {
+		if got := b.isAdmin(user); got != want {
+			t.Errorf("isAdmin(%s) = %t, want %t", user, got, want)
+		}
+	}
+}
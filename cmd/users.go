@@ -0,0 +1,19 @@
+/*
+Copyright © 2024 Pavlo Tarasiuk <pasha.tarasyuk@gmail.com>
+*/
+package cmd
+
+import "github.com/slack-go/slack"
+
+// userInfo returns the user, asking Slack only the first time it is needed
+func (b *Bot) userInfo(userID string) (*slack.User, error) {
+	if user, ok := b.users.get(userID); ok {
+		return user, nil
+	}
+	user, err := b.api().GetUserInfo(userID)
+	if err != nil {
+		return nil, err
+	}
+	b.users.set(userID, user)
+	return user, nil
+}
@@ -0,0 +1,150 @@
+/*
+Copyright © 2024 Pavlo Tarasiuk <pasha.tarasyuk@gmail.com>
+*/
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"unicode/utf8"
+
+	"github.com/slack-go/slack"
+)
+
+// Block Kit limits for messages, see https://api.slack.com/reference/block-kit/blocks
+const (
+	maxBlocksPerMessage   = 50
+	maxBlockIDLength      = 255
+	maxSectionTextLength  = 3000
+	maxSectionFields      = 10
+	maxSectionFieldLength = 2000
+	maxHeaderTextLength   = 150
+	maxContextElements    = 10
+	maxActionElements     = 25
+	maxActionIDLength     = 255
+	maxImageAltLength     = 2000
+	maxOptions            = 10
+	maxOptionTextLength   = 75
+	maxOptionValueLength  = 150
+)
+
+// validateBlocks checks the blocks against the constraints Slack enforces when posting,
+// returning an error describing every violation found, or nil when the blocks are valid
+func validateBlocks(blocks []slack.Block) error {
+	var errs []error
+	if len(blocks) > maxBlocksPerMessage {
+		errs = append(errs, fmt.Errorf("message has %d blocks, the limit is %d", len(blocks), maxBlocksPerMessage))
+	}
+	for i, block := range blocks {
+		for _, err := range validateBlock(block) {
+			errs = append(errs, fmt.Errorf("block %d (%s): %w", i+1, block.BlockType(), err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// validateBlock checks a single block
+func validateBlock(block slack.Block) []error {
+	var errs []error
+	check := func(err error) {
+		if err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	switch b := block.(type) {
+	case *slack.SectionBlock:
+		check(checkLength("block_id", b.BlockID, maxBlockIDLength))
+		if b.Text == nil && len(b.Fields) == 0 {
+			check(errors.New("either text or fields is required"))
+		}
+		if b.Text != nil {
+			check(checkLength("text", b.Text.Text, maxSectionTextLength))
+		}
+		if len(b.Fields) > maxSectionFields {
+			check(fmt.Errorf("has %d fields, the limit is %d", len(b.Fields), maxSectionFields))
+		}
+		for i, field := range b.Fields {
+			check(checkLength(fmt.Sprintf("field %d", i+1), field.Text, maxSectionFieldLength))
+		}
+		if b.Accessory != nil && b.Accessory.CheckboxGroupsBlockElement != nil {
+			errs = append(errs, validateOptions(b.Accessory.CheckboxGroupsBlockElement.Options)...)
+		}
+
+	case *slack.HeaderBlock:
+		check(checkLength("block_id", b.BlockID, maxBlockIDLength))
+		if b.Text == nil || b.Text.Text == "" {
+			check(errors.New("text is required"))
+		} else {
+			if b.Text.Type != slack.PlainTextType {
+				check(errors.New("text must be plain_text"))
+			}
+			check(checkLength("text", b.Text.Text, maxHeaderTextLength))
+		}
+
+	case *slack.ContextBlock:
+		check(checkLength("block_id", b.BlockID, maxBlockIDLength))
+		n := len(b.ContextElements.Elements)
+		if n == 0 {
+			check(errors.New("at least one element is required"))
+		} else if n > maxContextElements {
+			check(fmt.Errorf("has %d elements, the limit is %d", n, maxContextElements))
+		}
+
+	case *slack.ActionBlock:
+		check(checkLength("block_id", b.BlockID, maxBlockIDLength))
+		if b.Elements == nil || len(b.Elements.ElementSet) == 0 {
+			check(errors.New("at least one element is required"))
+			break
+		}
+		if n := len(b.Elements.ElementSet); n > maxActionElements {
+			check(fmt.Errorf("has %d elements, the limit is %d", n, maxActionElements))
+		}
+		for _, element := range b.Elements.ElementSet {
+			if button, ok := element.(*slack.ButtonBlockElement); ok {
+				check(checkLength("action_id", button.ActionID, maxActionIDLength))
+				if button.Text == nil || button.Text.Text == "" {
+					check(errors.New("button text is required"))
+				}
+			}
+		}
+
+	case *slack.ImageBlock:
+		check(checkLength("block_id", b.BlockID, maxBlockIDLength))
+		if b.ImageURL == "" {
+			check(errors.New("image_url is required"))
+		}
+		if b.AltText == "" {
+			check(errors.New("alt_text is required"))
+		}
+		check(checkLength("alt_text", b.AltText, maxImageAltLength))
+	}
+	return errs
+}
+
+// validateOptions checks the options of a choice element
+func validateOptions(options []*slack.OptionBlockObject) []error {
+	var errs []error
+	if len(options) > maxOptions {
+		errs = append(errs, fmt.Errorf("has %d options, the limit is %d", len(options), maxOptions))
+	}
+	for i, option := range options {
+		if option.Text == nil || option.Text.Text == "" {
+			errs = append(errs, fmt.Errorf("option %d: text is required", i+1))
+		} else if err := checkLength(fmt.Sprintf("option %d text", i+1), option.Text.Text, maxOptionTextLength); err != nil {
+			errs = append(errs, err)
+		}
+		if err := checkLength(fmt.Sprintf("option %d value", i+1), option.Value, maxOptionValueLength); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errs
+}
+
+// checkLength returns an error when s is longer than max characters
+func checkLength(name, s string, max int) error {
+	if n := utf8.RuneCountInString(s); n > max {
+		return fmt.Errorf("%s is %d characters, the limit is %d", name, n, max)
+	}
+	return nil
+}
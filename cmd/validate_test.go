@@ -0,0 +1,93 @@
+/*
+Copyright © 2024 Pavlo Tarasiuk <pasha.tarasyuk@gmail.com>
+*/
+package cmd
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/slack-go/slack"
+)
+
+func TestValidateBlocks(t *testing.T) {
+	text := func(s string) *slack.TextBlockObject {
+		return slack.NewTextBlockObject(slack.MarkdownType, s, false, false)
+	}
+	plain := func(s string) *slack.TextBlockObject {
+		return slack.NewTextBlockObject(slack.PlainTextType, s, false, false)
+	}
+	section := slack.NewSectionBlock(text("ok"), nil, nil)
+	manyBlocks := make([]slack.Block, maxBlocksPerMessage+1)
+	for i := range manyBlocks {
+		manyBlocks[i] = section
+	}
+	manyFields := make([]*slack.TextBlockObject, maxSectionFields+1)
+	for i := range manyFields {
+		manyFields[i] = text("f")
+	}
+	options := make([]*slack.OptionBlockObject, maxOptions+1)
+	for i := range options {
+		options[i] = slack.NewOptionBlockObject("v", plain("o"), nil)
+	}
+	checkboxes := slack.NewSectionBlock(text("pick"), nil, slack.NewAccessory(slack.NewCheckboxGroupsBlockElement("pick", options...)))
+
+	tests := []struct {
+		name   string
+		blocks []slack.Block
+		want   string
+	}{
+		{
+			name: "valid",
+			blocks: []slack.Block{
+				slack.NewHeaderBlock(plain("Title")),
+				section,
+				slack.NewContextBlock("", text("small")),
+				slack.NewActionBlock("", slack.NewButtonBlockElement("yes", "1", plain("Yes"))),
+				slack.NewImageBlock("https://example.com/a.png", "A chart", "", nil),
+			},
+		},
+		{name: "too many blocks", blocks: manyBlocks, want: "message has 51 blocks, the limit is 50"},
+		{name: "long section text", blocks: []slack.Block{slack.NewSectionBlock(text(strings.Repeat("a", 3001)), nil, nil)}, want: "block 1 (section): text is 3001 characters, the limit is 3000"},
+		{name: "empty section", blocks: []slack.Block{&slack.SectionBlock{Type: slack.MBTSection}}, want: "block 1 (section): either text or fields is required"},
+		{name: "too many fields", blocks: []slack.Block{slack.NewSectionBlock(nil, manyFields, nil)}, want: "has 11 fields, the limit is 10"},
+		{name: "long field", blocks: []slack.Block{slack.NewSectionBlock(nil, []*slack.TextBlockObject{text(strings.Repeat("a", 2001))}, nil)}, want: "field 1 is 2001 characters, the limit is 2000"},
+		{name: "long block ID", blocks: []slack.Block{slack.NewSectionBlock(text("ok"), nil, nil, slack.SectionBlockOptionBlockID(strings.Repeat("b", 256)))}, want: "block_id is 256 characters, the limit is 255"},
+		{name: "empty header", blocks: []slack.Block{&slack.HeaderBlock{Type: slack.MBTHeader}}, want: "block 1 (header): text is required"},
+		{name: "markdown header", blocks: []slack.Block{slack.NewHeaderBlock(text("Title"))}, want: "text must be plain_text"},
+		{name: "long header", blocks: []slack.Block{slack.NewHeaderBlock(plain(strings.Repeat("h", 151)))}, want: "text is 151 characters, the limit is 150"},
+		{name: "empty context", blocks: []slack.Block{slack.NewContextBlock("")}, want: "block 1 (context): at least one element is required"},
+		{name: "empty actions", blocks: []slack.Block{slack.NewActionBlock("")}, want: "block 1 (actions): at least one element is required"},
+		{name: "button without text", blocks: []slack.Block{slack.NewActionBlock("", slack.NewButtonBlockElement("yes", "1", nil))}, want: "button text is required"},
+		{name: "image without URL", blocks: []slack.Block{slack.NewImageBlock("", "alt", "", nil)}, want: "block 1 (image): image_url is required"},
+		{name: "image without alt text", blocks: []slack.Block{slack.NewImageBlock("https://example.com/a.png", "", "", nil)}, want: "alt_text is required"},
+		{name: "too many options", blocks: []slack.Block{checkboxes}, want: "has 11 options, the limit is 10"},
+		{name: "every violation", blocks: []slack.Block{slack.NewContextBlock(""), slack.NewHeaderBlock(plain(""))}, want: "block 1 (context): at least one element is required\nblock 2 (header): text is required"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateBlocks(tt.blocks)
+			if tt.want == "" {
+				if err != nil {
+					t.Errorf("valid blocks failed: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("got %v, want an error containing %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestInvalidBlocksAreNotPosted(t *testing.T) {
+	b, fake := newTestBot(t, nil)
+
+	_, err := b.postMessage(outboundMessage{Channel: "C1", Blocks: []slack.Block{slack.NewContextBlock("")}})
+	if err == nil || !strings.Contains(err.Error(), "invalid blocks") {
+		t.Errorf("got %v, want the blocks refused", err)
+	}
+	if len(fake.calls("chat.postMessage")) != 0 {
+		t.Errorf("invalid blocks reached Slack")
+	}
+}
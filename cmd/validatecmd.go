@@ -0,0 +1,71 @@
+/*
+Copyright © 2024 Pavlo Tarasiuk <pasha.tarasyuk@gmail.com>
+*/
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/slack-go/slack"
+)
+
+// slackUnescaper undoes the escaping Slack applies to the text of slash commands
+var slackUnescaper = strings.NewReplacer("&lt;", "<", "&gt;", ">", "&amp;", "&")
+
+// parseBlocksJSON reads Block Kit JSON as pasted from Block Kit Builder, either a whole payload
+// with a blocks array or the bare array. A surrounding code block is accepted.
+func parseBlocksJSON(text string) ([]slack.Block, error) {
+	text = strings.TrimSpace(slackUnescaper.Replace(text))
+	text = strings.TrimSpace(strings.Trim(text, "`"))
+
+	var blocks slack.Blocks
+	if strings.HasPrefix(text, "[") {
+		if err := json.Unmarshal([]byte(text), &blocks); err != nil {
+			return nil, fmt.Errorf("invalid JSON: %w", err)
+		}
+		return blocks.BlockSet, nil
+	}
+	var payload struct {
+		Blocks *slack.Blocks `json:"blocks"`
+	}
+	if err := json.Unmarshal([]byte(text), &payload); err != nil {
+		return nil, fmt.Errorf("invalid JSON: %w", err)
+	}
+	if payload.Blocks == nil {
+		return nil, errors.New("no blocks array found")
+	}
+	return payload.Blocks.BlockSet, nil
+}
+
+// handleValidateBlocks checks pasted Block Kit JSON against Slack's constraints: /validate-blocks <json>
+func (b *Bot) handleValidateBlocks(command slack.SlashCommand) (*SlashResponse, error) {
+	if strings.TrimSpace(command.Text) == "" {
+		return ephemeral(usageOf("/validate-blocks")), nil
+	}
+	blocks, err := parseBlocksJSON(command.Text)
+	if err != nil {
+		return ephemeral(fmt.Sprintf(":x: %v", err)), nil
+	}
+	if len(blocks) == 0 {
+		return ephemeral(":x: there are no blocks"), nil
+	}
+	if err := validateBlocks(blocks); err != nil {
+		return ephemeral(fmt.Sprintf(":x: The blocks are invalid:\n```\n%v\n```", err)), nil
+	}
+	return ephemeral(fmt.Sprintf(":white_check_mark: valid, %d blocks", len(blocks))), nil
+}
+
+func init() {
+	registerSlashCommand(&slashCommand{
+		Name:        "/validate-blocks",
+		Description: "Check Block Kit JSON against the limits Slack enforces",
+		Usage:       "<Block Kit JSON>",
+		Example:     `/validate-blocks {"blocks": [{"type": "divider"}]}`,
+		Category:    categoryAdmin,
+		AdminOnly:   true,
+		Handler:     (*Bot).handleValidateBlocks,
+	})
+}
@@ -0,0 +1,65 @@
+/*
+Copyright © 2024 Pavlo Tarasiuk <pasha.tarasyuk@gmail.com>
+*/
+package cmd
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/slack-go/slack"
+)
+
+func TestValidateBlocksCommand(t *testing.T) {
+	dividers := "[" + strings.Repeat(`{"type":"divider"},`, maxBlocksPerMessage) + `{"type":"divider"}]`
+	tests := []struct {
+		name string
+		text string
+		// want is the answer, or the part of it naming the problem
+		want string
+	}{
+		{name: "payload", text: `{"blocks": [{"type": "divider"}, {"type": "section", "text": {"type": "mrkdwn", "text": "Hi"}}]}`, want: ":white_check_mark: valid, 2 blocks"},
+		{name: "bare array", text: `[{"type": "header", "text": {"type": "plain_text", "text": "News"}}]`, want: ":white_check_mark: valid, 1 blocks"},
+		{
+			name: "escaped in a code block",
+			text: "```{\"blocks\": [{\"type\": \"section\", \"text\": {\"type\": \"mrkdwn\", \"text\": \"&lt;https://example.com|Docs&gt; &amp; more\"}}]}```",
+			want: ":white_check_mark: valid, 1 blocks",
+		},
+		{name: "nothing", text: "  ", want: usageOf("/validate-blocks")},
+		{name: "not JSON", text: `{"blocks": [`, want: ":x: invalid JSON: "},
+		{name: "no blocks array", text: `{"text": "Hi"}`, want: ":x: no blocks array found"},
+		{name: "no blocks", text: `[]`, want: ":x: there are no blocks"},
+		{name: "section without text", text: `[{"type": "section"}]`, want: "block 1 (section): either text or fields is required"},
+		{name: "markdown header", text: `[{"type": "divider"}, {"type": "header", "text": {"type": "mrkdwn", "text": "News"}}]`, want: "block 2 (header): text must be plain_text"},
+		{name: "empty actions", text: `[{"type": "actions", "elements": []}]`, want: "block 1 (actions): at least one element is required"},
+		{name: "too many blocks", text: dividers, want: "message has 51 blocks, the limit is 50"},
+	}
+	b, _ := newTestBot(t, nil)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp, err := b.handleValidateBlocks(slack.SlashCommand{Text: tt.text})
+			if err != nil {
+				t.Fatalf("/validate-blocks failed: %v", err)
+			}
+			if !strings.Contains(resp.Text, tt.want) {
+				t.Errorf("answered %q, want %q", resp.Text, tt.want)
+			}
+			if resp.ResponseType != slack.ResponseTypeEphemeral {
+				t.Errorf("answered in the channel, want ephemerally")
+			}
+		})
+	}
+}
+
+func TestValidateBlocksAdminOnly(t *testing.T) {
+	cfg := testConfig(t)
+	cfg.Admins = []string{"U0ADMIN"}
+	b, _ := newTestBot(t, cfg)
+	resp, err := b.dispatchSlashCommand(slack.SlashCommand{Command: "/validate-blocks", Text: `[{"type":"divider"}]`, UserID: "U1", ChannelID: "C1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.Text != "Sorry, this command is available to MAVBot admins only" {
+		t.Errorf("answered %q, want the command refused", resp.Text)
+	}
+}
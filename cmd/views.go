@@ -0,0 +1,39 @@
+/*
+Copyright © 2024 Pavlo Tarasiuk <pasha.tarasyuk@gmail.com>
+*/
+package cmd
+
+import (
+	"fmt"
+	"log"
+
+	"github.com/ptarasyuk/mavbot/internal/blocks"
+	"github.com/slack-go/slack"
+)
+
+// handleViewSubmission takes care of modal submissions, size being the size of the raw payload.
+// Oversized submissions are turned down with an error the user sees in the modal.
+func (b *Bot) handleViewSubmission(interaction slack.InteractionCallback, size int) (interface{}, error) {
+	if b.cfg.MaxViewSubmission > 0 && size > b.cfg.MaxViewSubmission {
+		log.Printf("rejected %d byte submission of view %s by %s\n", size, interaction.View.CallbackID, interaction.User.ID)
+		return viewError(interaction.View, fmt.Sprintf("This submission is too large (%s, the limit is %s), please shorten your input",
+			formatBytes(uint64(size)), formatBytes(uint64(b.cfg.MaxViewSubmission)))), nil
+	}
+	return nil, nil
+}
+
+// viewError builds the response_action that shows message in the submitted modal. Slack shows
+// errors next to input blocks, so the modal is replaced with the message when it has none.
+func viewError(view slack.View, message string) *slack.ViewSubmissionResponse {
+	for _, block := range view.Blocks.BlockSet {
+		if input, ok := block.(*slack.InputBlock); ok && input.BlockID != "" {
+			return slack.NewErrorsViewSubmissionResponse(map[string]string{input.BlockID: message})
+		}
+	}
+	return slack.NewUpdateViewSubmissionResponse(&slack.ModalViewRequest{
+		Type:   slack.VTModal,
+		Title:  blocks.PlainText("Submission failed"),
+		Close:  blocks.PlainText("Close"),
+		Blocks: slack.Blocks{BlockSet: []slack.Block{blocks.Section(message)}},
+	})
+}
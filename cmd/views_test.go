@@ -0,0 +1,88 @@
+/*
+Copyright © 2024 Pavlo Tarasiuk <pasha.tarasyuk@gmail.com>
+*/
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/slack-go/slack"
+	"github.com/slack-go/slack/socketmode"
+)
+
+// submissionEvent wraps a submission of the view in a socketmode event with a payload of size bytes
+func submissionEvent(view slack.View, size int) socketmode.Event {
+	interaction := slack.InteractionCallback{
+		Type: slack.InteractionTypeViewSubmission,
+		User: slack.User{ID: "U1"},
+		View: view,
+	}
+	payload, _ := json.Marshal(interaction)
+	// Pad the payload the way a long text input would
+	padded := append(payload[:len(payload)-1], []byte(`,"padding":"`+strings.Repeat("x", size)+`"}`)...)
+	return socketmode.Event{
+		Type:    socketmode.EventTypeInteractive,
+		Data:    interaction,
+		Request: &socketmode.Request{EnvelopeID: "e1", Type: socketmode.RequestTypeInteractive, Payload: padded},
+	}
+}
+
+func TestOversizedViewSubmission(t *testing.T) {
+	withInput := slack.View{
+		CallbackID: "feedback",
+		Blocks: slack.Blocks{BlockSet: []slack.Block{
+			slack.NewSectionBlock(slack.NewTextBlockObject(slack.MarkdownType, "Tell us more", false, false), nil, nil),
+			slack.NewInputBlock("notes", slack.NewTextBlockObject(slack.PlainTextType, "Notes", false, false), nil,
+				slack.NewPlainTextInputBlockElement(nil, "notes_input")),
+		}},
+	}
+	withoutInput := slack.View{CallbackID: "summary"}
+
+	tests := []struct {
+		name string
+		view slack.View
+		size int
+		// action is the response_action acknowledged, empty for a bare acknowledgement
+		action slack.ViewResponseAction
+	}{
+		{name: "within the limit", view: withInput, size: 100},
+		{name: "oversized with an input", view: withInput, size: 32 << 10, action: slack.RAErrors},
+		{name: "oversized without an input", view: withoutInput, size: 32 << 10, action: slack.RAUpdate},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := testConfig(t)
+			cfg.MaxViewSubmission = 16 << 10
+			b, _ := newTestBot(t, cfg)
+			socket := &fakeSocket{}
+
+			b.processEvent(context.Background(), submissionEvent(tt.view, tt.size), socket)
+
+			acks := socket.acked()
+			if len(acks) != 1 {
+				t.Fatalf("got %d acknowledgements, want the submission answered once", len(acks))
+			}
+			response, _ := acks[0].Payload.(*slack.ViewSubmissionResponse)
+			if tt.action == "" {
+				if response != nil {
+					t.Errorf("a submission within the limit was answered with %+v", response)
+				}
+				return
+			}
+			if response == nil || response.ResponseAction != tt.action {
+				t.Fatalf("acknowledged with %#v, want response_action %s", acks[0].Payload, tt.action)
+			}
+			message := response.Errors["notes"]
+			if tt.action == slack.RAUpdate {
+				body, _ := json.Marshal(response.View)
+				message = string(body)
+			}
+			if !strings.Contains(message, "This submission is too large") || !strings.Contains(message, "the limit is 16.0 KiB") {
+				t.Errorf("the user is told %q", message)
+			}
+		})
+	}
+}
@@ -0,0 +1,125 @@
+/*
+Copyright © 2024 Pavlo Tarasiuk <pasha.tarasyuk@gmail.com>
+*/
+package cmd
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/slack-go/slack"
+	"github.com/slack-go/slack/slackevents"
+)
+
+// collectionVotes holds the reaction votes on the bot's messages, keyed by channel and timestamp
+const collectionVotes = "votes"
+
+// messageVotes are the users who voted on a message, by emoji
+type messageVotes struct {
+	Channel   string              `json:"channel"`
+	Timestamp string              `json:"ts"`
+	Votes     map[string][]string `json:"votes"`
+}
+
+// voteKey identifies the votes on a message in the Store
+func voteKey(channelID, timestamp string) string {
+	return channelID + "/" + timestamp
+}
+
+// voteEmoji normalizes a reaction name, skin tones of the same emoji are the same vote
+func voteEmoji(reaction string) string {
+	name, _, _ := strings.Cut(strings.Trim(reaction, ":"), "::")
+	return name
+}
+
+// votesWith reports whether reactions with the emoji count as votes
+func (c *Config) votesWith(reaction string) bool {
+	name := voteEmoji(reaction)
+	for _, allowed := range c.VoteEmoji {
+		if voteEmoji(allowed) == name {
+			return true
+		}
+	}
+	return false
+}
+
+// recordVote adds or removes the user's vote with the reaction on a message the bot posted.
+// Reactions with emoji that aren't configured for voting are ignored.
+func (b *Bot) recordVote(userID, reaction, itemUser string, item slackevents.Item, add bool) error {
+	if item.Type != "message" || !b.isOwnMessage(itemUser, "") || b.isOwnMessage(userID, "") {
+		return nil
+	}
+	if !b.cfg.votesWith(reaction) {
+		return nil
+	}
+
+	key := voteKey(item.Channel, item.Timestamp)
+	votes := messageVotes{Channel: item.Channel, Timestamp: item.Timestamp}
+	if _, err := b.store.Get(collectionVotes, key, &votes); err != nil {
+		return err
+	}
+	if votes.Votes == nil {
+		votes.Votes = make(map[string][]string)
+	}
+	emoji := voteEmoji(reaction)
+	voters := removeString(votes.Votes[emoji], userID)
+	if add {
+		voters = append(voters, userID)
+	}
+	if len(voters) == 0 {
+		delete(votes.Votes, emoji)
+	} else {
+		votes.Votes[emoji] = voters
+	}
+	if err := b.store.Put(collectionVotes, key, votes); err != nil {
+		return fmt.Errorf("failed to record vote: %w", err)
+	}
+	return nil
+}
+
+// removeString returns items without value
+func removeString(items []string, value string) []string {
+	kept := items[:0]
+	for _, item := range items {
+		if item != value {
+			kept = append(kept, item)
+		}
+	}
+	return kept
+}
+
+// handleVotes shows the tally of a message the bot posted: /votes <message link>
+func (b *Bot) handleVotes(command slack.SlashCommand) (*SlashResponse, error) {
+	link, err := parsePermalink(command.Text)
+	if err != nil {
+		return ephemeral(usageOf("/votes")), nil
+	}
+	var votes messageVotes
+	found, err := b.store.Get(collectionVotes, voteKey(link.Channel, link.Timestamp), &votes)
+	if err != nil {
+		return nil, err
+	}
+	if !found || len(votes.Votes) == 0 {
+		return ephemeral("Nobody has voted on that message yet"), nil
+	}
+
+	var tally strings.Builder
+	tally.WriteString("*Votes*\n")
+	for _, emoji := range b.cfg.VoteEmoji {
+		if voters := votes.Votes[voteEmoji(emoji)]; len(voters) > 0 {
+			fmt.Fprintf(&tally, ":%s: %d\n", voteEmoji(emoji), len(voters))
+		}
+	}
+	return ephemeral(tally.String()), nil
+}
+
+func init() {
+	registerSlashCommand(&slashCommand{
+		Name:        "/votes",
+		Description: "Show the reaction votes on a message the bot posted",
+		Usage:       "<message link>",
+		Example:     "/votes https://example.slack.com/archives/C0123456789/p1712345678123456",
+		Category:    categoryGeneral,
+		Handler:     (*Bot).handleVotes,
+	})
+}
@@ -0,0 +1,131 @@
+/*
+Copyright © 2024 Pavlo Tarasiuk <pasha.tarasyuk@gmail.com>
+*/
+package cmd
+
+import (
+	"testing"
+
+	"github.com/slack-go/slack"
+	"github.com/slack-go/slack/slackevents"
+)
+
+func TestVotesWith(t *testing.T) {
+	tests := []struct {
+		allowed  []string
+		reaction string
+		want     bool
+	}{
+		{[]string{"+1", "-1"}, "+1", true},
+		{[]string{"+1", "-1"}, "-1", true},
+		{[]string{"+1", "-1"}, "tada", false},
+		// Skin tones are the same vote
+		{[]string{"+1", "-1"}, "+1::skin-tone-3", true},
+		{[]string{":rocket:", ":fire:"}, "rocket", true},
+		{[]string{"rocket", "fire"}, "+1", false},
+		{nil, "+1", false},
+	}
+	for _, tt := range tests {
+		cfg := &Config{VoteEmoji: tt.allowed}
+		if got := cfg.votesWith(tt.reaction); got != tt.want {
+			t.Errorf("votesWith(%q) with %v = %t, want %t", tt.reaction, tt.allowed, got, tt.want)
+		}
+	}
+}
+
+// newVotesBot returns a bot voted on with the emoji, its message 1712345678.000100 is in C1
+func newVotesBot(t *testing.T, emoji ...string) *Bot {
+	t.Helper()
+	cfg := testConfig(t)
+	cfg.VoteEmoji = emoji
+	b, _ := newTestBot(t, cfg)
+	b.selfUserID = "U0BOT"
+	return b
+}
+
+// react adds or removes the user's reaction on the bot's message in C1
+func react(t *testing.T, b *Bot, user, reaction string, add bool) {
+	t.Helper()
+	item := slackevents.Item{Type: "message", Channel: "C1", Timestamp: "1712345678.000100"}
+	var event slackevents.EventsAPIEvent
+	if add {
+		event = callbackEvent("reaction_added", &slackevents.ReactionAddedEvent{User: user, Reaction: reaction, ItemUser: "U0BOT", Item: item})
+	} else {
+		event = callbackEvent("reaction_removed", &slackevents.ReactionRemovedEvent{User: user, Reaction: reaction, ItemUser: "U0BOT", Item: item})
+	}
+	if err := b.dispatchEvent(event); err != nil {
+		t.Fatalf("reaction %s by %s failed: %v", reaction, user, err)
+	}
+}
+
+// tally runs /votes on the bot's message in C1
+func tally(t *testing.T, b *Bot) string {
+	t.Helper()
+	resp, err := b.handleVotes(slack.SlashCommand{Text: "https://example.slack.com/archives/C1/p1712345678000100"})
+	if err != nil {
+		t.Fatalf("/votes failed: %v", err)
+	}
+	return resp.Text
+}
+
+func TestAllowedEmojiCountAsVotes(t *testing.T) {
+	b := newVotesBot(t, "+1", "-1")
+	react(t, b, "U1", "+1", true)
+	react(t, b, "U2", "+1::skin-tone-2", true)
+	react(t, b, "U3", "-1", true)
+	// Voting twice with the same emoji is one vote
+	react(t, b, "U1", "+1", true)
+
+	if got, want := tally(t, b), "*Votes*\n:+1: 2\n:-1: 1\n"; got != want {
+		t.Errorf("tally =\n%s\nwant\n%s", got, want)
+	}
+
+	react(t, b, "U3", "-1", false)
+	if got, want := tally(t, b), "*Votes*\n:+1: 2\n"; got != want {
+		t.Errorf("tally after a removed vote =\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestDisallowedEmojiAreIgnored(t *testing.T) {
+	b := newVotesBot(t, "rocket")
+	react(t, b, "U1", "+1", true)
+	react(t, b, "U2", "tada", true)
+	if got := tally(t, b); got != "Nobody has voted on that message yet" {
+		t.Errorf("tally = %q, want the other emoji ignored", got)
+	}
+	react(t, b, "U1", "rocket", true)
+	if got, want := tally(t, b), "*Votes*\n:rocket: 1\n"; got != want {
+		t.Errorf("tally = %q, want %q", got, want)
+	}
+}
+
+func TestVotesOnlyOnOwnMessages(t *testing.T) {
+	b := newVotesBot(t, "+1")
+	item := slackevents.Item{Type: "message", Channel: "C1", Timestamp: "1712345678.000100"}
+	for _, event := range []*slackevents.ReactionAddedEvent{
+		// Someone else's message
+		{User: "U1", Reaction: "+1", ItemUser: "U2", Item: item},
+		// The bot's own reaction
+		{User: "U0BOT", Reaction: "+1", ItemUser: "U0BOT", Item: item},
+		// A file rather than a message
+		{User: "U1", Reaction: "+1", ItemUser: "U0BOT", Item: slackevents.Item{Type: "file"}},
+	} {
+		if err := b.dispatchEvent(callbackEvent("reaction_added", event)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if got := tally(t, b); got != "Nobody has voted on that message yet" {
+		t.Errorf("tally = %q, want no votes counted", got)
+	}
+}
+
+func TestVotesUsage(t *testing.T) {
+	b := newVotesBot(t, "+1")
+	resp, err := b.handleVotes(slack.SlashCommand{Text: "not a link"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.Text != usageOf("/votes") {
+		t.Errorf("answered %q, want the usage", resp.Text)
+	}
+}
@@ -0,0 +1,87 @@
+/*
+Copyright © 2024 Pavlo Tarasiuk <pasha.tarasyuk@gmail.com>
+*/
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/slack-go/slack"
+)
+
+// webAPI calls the Web API methods slack-go has no wrappers for
+type webAPI struct {
+	httpClient *http.Client
+	// url is the base URL of the Web API, slack.APIURL unless testing
+	url string
+	// token returns the current bot token
+	token func() string
+}
+
+// newWebAPI creates a webAPI sending its requests through httpClient with the token of client
+func newWebAPI(httpClient *http.Client, client *clientRef) *webAPI {
+	return &webAPI{httpClient: httpClient, url: slack.APIURL, token: client.token}
+}
+
+// webAPIError is the error a Web API method responded with
+type webAPIError struct {
+	Method string
+	Code   string
+	// Needed lists the scopes missing when Code is missing_scope
+	Needed string
+}
+
+// Error implements error
+func (e *webAPIError) Error() string {
+	if e.Needed != "" {
+		return fmt.Sprintf("%s failed: %s (needs %s)", e.Method, e.Code, e.Needed)
+	}
+	return fmt.Sprintf("%s failed: %s", e.Method, e.Code)
+}
+
+// call posts the form-encoded values to the method and decodes the response into out, which may be nil
+func (w *webAPI) call(ctx context.Context, method string, values url.Values, out interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, w.url+method, strings.NewReader(values.Encode()))
+	if err != nil {
+		return fmt.Errorf("failed to call %s: %w", method, err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Authorization", "Bearer "+w.token())
+
+	resp, err := w.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to call %s: %w", method, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("failed to call %s: %s", method, resp.Status)
+	}
+
+	var body json.RawMessage
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return fmt.Errorf("failed to decode %s response: %w", method, err)
+	}
+	var status struct {
+		OK     bool   `json:"ok"`
+		Error  string `json:"error"`
+		Needed string `json:"needed"`
+	}
+	if err := json.Unmarshal(body, &status); err != nil {
+		return fmt.Errorf("failed to decode %s response: %w", method, err)
+	}
+	if !status.OK {
+		return &webAPIError{Method: method, Code: status.Error, Needed: status.Needed}
+	}
+	if out == nil {
+		return nil
+	}
+	if err := json.Unmarshal(body, out); err != nil {
+		return fmt.Errorf("failed to decode %s response: %w", method, err)
+	}
+	return nil
+}
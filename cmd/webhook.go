@@ -0,0 +1,197 @@
+/*
+Copyright © 2024 Pavlo Tarasiuk <pasha.tarasyuk@gmail.com>
+*/
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/slack-go/slack"
+	"github.com/slack-go/slack/slackevents"
+)
+
+// Headers of the outbound webhook requests
+const (
+	webhookTimestampHeader = "X-MAVBot-Timestamp"
+	// webhookSignatureHeader is v0= followed by the hex HMAC-SHA256 of "<timestamp>:<body>" keyed with the secret
+	webhookSignatureHeader = "X-MAVBot-Signature"
+)
+
+// Outcomes of processed events
+const (
+	outcomeOK    = "ok"
+	outcomeError = "error"
+)
+
+// eventSummary describes a processed event to the outbound webhook
+type eventSummary struct {
+	Type    string    `json:"type"`
+	User    string    `json:"user,omitempty"`
+	Channel string    `json:"channel,omitempty"`
+	Command string    `json:"command,omitempty"`
+	Outcome string    `json:"outcome"`
+	Error   string    `json:"error,omitempty"`
+	Time    time.Time `json:"time"`
+}
+
+// eventsAPISummary summarizes an Events API event, most inner events name a user and a channel
+func eventsAPISummary(event slackevents.EventsAPIEvent) eventSummary {
+	summary := eventSummary{Type: event.InnerEvent.Type}
+	callback, ok := event.Data.(*slackevents.EventsAPICallbackEvent)
+	if !ok || callback.InnerEvent == nil {
+		return summary
+	}
+	// Some events carry objects rather than IDs in these fields, those are left out
+	var inner struct {
+		User    json.RawMessage `json:"user"`
+		Channel json.RawMessage `json:"channel"`
+	}
+	if json.Unmarshal(*callback.InnerEvent, &inner) == nil {
+		json.Unmarshal(inner.User, &summary.User)
+		json.Unmarshal(inner.Channel, &summary.Channel)
+	}
+	return summary
+}
+
+// slashCommandSummary summarizes a slash command invocation
+func slashCommandSummary(command slack.SlashCommand) eventSummary {
+	return eventSummary{Type: "slash_command", User: command.UserID, Channel: command.ChannelID, Command: command.Command}
+}
+
+// interactionSummary summarizes an interaction
+func interactionSummary(interaction slack.InteractionCallback) eventSummary {
+	return eventSummary{Type: string(interaction.Type), User: interaction.User.ID, Channel: interaction.Channel.ID}
+}
+
+// webhook posts event summaries to an external URL, signed with a shared secret
+type webhook struct {
+	url    string
+	secret string
+	// retries is how many times a failed delivery is repeated, delay the wait before the first repeat
+	retries int
+	delay   time.Duration
+
+	client *http.Client
+	now    func() time.Time
+
+	// pending counts the deliveries in flight
+	pending sync.WaitGroup
+}
+
+// newWebhook creates a webhook delivering to url
+func newWebhook(url, secret string, retries int, now func() time.Time) *webhook {
+	return &webhook{
+		url:     url,
+		secret:  secret,
+		retries: retries,
+		delay:   time.Second,
+		client:  &http.Client{Timeout: 10 * time.Second},
+		now:     now,
+	}
+}
+
+// Flush waits for the deliveries in flight to finish or ctx to be done
+func (w *webhook) Flush(ctx context.Context) error {
+	done := make(chan struct{})
+	go func() {
+		w.pending.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// sign returns the signature of the body sent at timestamp
+func (w *webhook) sign(timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(w.secret))
+	mac.Write([]byte(timestamp + ":"))
+	mac.Write(body)
+	return "v0=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// send delivers the summary, repeating failed attempts with a doubling delay
+func (w *webhook) send(summary eventSummary) error {
+	body, err := json.Marshal(summary)
+	if err != nil {
+		return fmt.Errorf("failed to encode webhook payload: %w", err)
+	}
+
+	delay := w.delay
+	for attempt := 0; ; attempt++ {
+		err = w.deliver(body)
+		if err == nil || attempt == w.retries {
+			return err
+		}
+		time.Sleep(delay)
+		delay *= 2
+	}
+}
+
+// deliver makes a single delivery attempt, a non-2xx response counting as a failure
+func (w *webhook) deliver(body []byte) error {
+	req, err := http.NewRequest(http.MethodPost, w.url, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create webhook request: %w", err)
+	}
+	timestamp := strconv.FormatInt(w.now().Unix(), 10)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(webhookTimestampHeader, timestamp)
+	if w.secret != "" {
+		req.Header.Set(webhookSignatureHeader, w.sign(timestamp, body))
+	}
+
+	resp, err := w.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to deliver webhook: %w", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("failed to deliver webhook: %s", resp.Status)
+	}
+	return nil
+}
+
+// emitEvent counts a processed event and the error its handler returned into the activity and
+// reports it to the webhook, if configured. Delivery happens in the background so a slow
+// receiver doesn't hold up the bot.
+func (b *Bot) emitEvent(summary eventSummary, err error) {
+	summary.Outcome = outcomeOK
+	if err != nil {
+		summary.Outcome = outcomeError
+		summary.Error = err.Error()
+	}
+	summary.Time = b.now()
+	if err := b.recordActivity(summary); err != nil {
+		log.Println(err)
+	}
+	if summary.User != "" && !b.isOwnMessage(summary.User, "") {
+		if err := b.activity.recordSeen(summary.User, b.localTime(summary.Time)); err != nil {
+			log.Println(err)
+		}
+	}
+	if b.webhook == nil {
+		return
+	}
+	b.webhook.pending.Add(1)
+	go func() {
+		defer b.webhook.pending.Done()
+		if err := b.webhook.send(summary); err != nil {
+			log.Println(err)
+		}
+	}()
+}
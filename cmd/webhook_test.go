@@ -0,0 +1,179 @@
+/*
+Copyright © 2024 Pavlo Tarasiuk <pasha.tarasyuk@gmail.com>
+*/
+package cmd
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/slack-go/slack"
+	"github.com/slack-go/slack/slackevents"
+)
+
+// webhookReceiver is a stub of the downstream system failing the first deliveries
+type webhookReceiver struct {
+	*httptest.Server
+	mu       sync.Mutex
+	failures int
+	attempts int
+	received []*http.Request
+	bodies   [][]byte
+}
+
+// newWebhookReceiver starts a receiver answering the first failures deliveries with a 500
+func newWebhookReceiver(t *testing.T, failures int) *webhookReceiver {
+	r := &webhookReceiver{failures: failures}
+	r.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		body, _ := io.ReadAll(req.Body)
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		r.attempts++
+		if r.attempts <= r.failures {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		r.received = append(r.received, req)
+		r.bodies = append(r.bodies, body)
+	}))
+	t.Cleanup(r.Close)
+	return r
+}
+
+// newWebhookBot creates a bot delivering to the receiver with the retries, without waiting between them
+func newWebhookBot(t *testing.T, receiver *webhookReceiver, retries int) *Bot {
+	b, _ := newTestBot(t, nil)
+	clock := newFakeClock()
+	b.now = clock.now
+	b.webhook = newWebhook(receiver.URL, "shared-secret", retries, clock.now)
+	b.webhook.delay = time.Millisecond
+	return b
+}
+
+func TestWebhookPayload(t *testing.T) {
+	receiver := newWebhookReceiver(t, 0)
+	b := newWebhookBot(t, receiver, 0)
+	registerTestCommand(t, &slashCommand{
+		Name:    "/test-fail",
+		Handler: func(*Bot, slack.SlashCommand) (*SlashResponse, error) { return nil, errors.New("out of coffee") },
+	})
+
+	b.processEvent(context.Background(), slashEvent("e1", slack.SlashCommand{Command: "/hello", UserID: "U1", ChannelID: "C1"}), &fakeSocket{})
+	b.processEvent(context.Background(), slashEvent("e2", slack.SlashCommand{Command: "/test-fail", UserID: "U1", ChannelID: "C1"}), &fakeSocket{})
+	if err := b.webhook.Flush(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(receiver.bodies) != 2 {
+		t.Fatalf("received %d deliveries, want 2", len(receiver.bodies))
+	}
+	want := map[string]string{
+		`"command":"/hello"`:     `{"type":"slash_command","user":"U1","channel":"C1","command":"/hello","outcome":"ok","time":"2024-04-05T12:00:00Z"}`,
+		`"command":"/test-fail"`: `{"type":"slash_command","user":"U1","channel":"C1","command":"/test-fail","outcome":"error","error":"out of coffee","time":"2024-04-05T12:00:00Z"}`,
+	}
+	for i, body := range receiver.bodies {
+		matched := false
+		for key, payload := range want {
+			if strings.Contains(string(body), key) {
+				matched = true
+				if string(body) != payload {
+					t.Errorf("delivered %s, want %s", body, payload)
+				}
+			}
+		}
+		if !matched {
+			t.Errorf("delivered %s for neither command", body)
+		}
+		req := receiver.received[i]
+		timestamp := req.Header.Get(webhookTimestampHeader)
+		if timestamp != "1712318400" || req.Header.Get("Content-Type") != "application/json" {
+			t.Errorf("delivered with headers %v", req.Header)
+		}
+		mac := hmac.New(sha256.New, []byte("shared-secret"))
+		mac.Write([]byte(timestamp + ":"))
+		mac.Write(body)
+		if got := req.Header.Get(webhookSignatureHeader); got != "v0="+hex.EncodeToString(mac.Sum(nil)) {
+			t.Errorf("signed %s, which the receiver can't verify", got)
+		}
+	}
+}
+
+func TestWebhookRetries(t *testing.T) {
+	tests := []struct {
+		name         string
+		failures     int
+		retries      int
+		wantAttempts int
+		wantErr      bool
+	}{
+		{name: "delivered after failures", failures: 2, retries: 2, wantAttempts: 3},
+		{name: "gives up", failures: 5, retries: 2, wantAttempts: 3, wantErr: true},
+		{name: "no retries", failures: 1, retries: 0, wantAttempts: 1, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			receiver := newWebhookReceiver(t, tt.failures)
+			b := newWebhookBot(t, receiver, tt.retries)
+
+			err := b.webhook.send(eventSummary{Type: "app_mention", User: "U1", Channel: "C1", Outcome: outcomeOK})
+			if (err != nil) != tt.wantErr {
+				t.Errorf("got error %v, want error %t", err, tt.wantErr)
+			}
+			if receiver.attempts != tt.wantAttempts {
+				t.Errorf("made %d attempts, want %d", receiver.attempts, tt.wantAttempts)
+			}
+		})
+	}
+}
+
+func TestEventsAPISummary(t *testing.T) {
+	tests := []struct {
+		name  string
+		inner string
+		want  eventSummary
+	}{
+		{
+			name:  "user and channel IDs",
+			inner: `{"type":"app_mention","user":"U1","channel":"C1","text":"hi"}`,
+			want:  eventSummary{Type: "app_mention", User: "U1", Channel: "C1"},
+		},
+		{
+			name:  "channel object left out",
+			inner: `{"type":"channel_rename","channel":{"id":"C1","name":"support"}}`,
+			want:  eventSummary{Type: "channel_rename"},
+		},
+		{
+			name:  "user object left out",
+			inner: `{"type":"user_change","user":{"id":"U1"}}`,
+			want:  eventSummary{Type: "user_change"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			raw := json.RawMessage(tt.inner)
+			var inner struct {
+				Type string `json:"type"`
+			}
+			json.Unmarshal(raw, &inner)
+			event := slackevents.EventsAPIEvent{
+				Type:       slackevents.CallbackEvent,
+				Data:       &slackevents.EventsAPICallbackEvent{InnerEvent: &raw},
+				InnerEvent: slackevents.EventsAPIInnerEvent{Type: inner.Type},
+			}
+			if got := eventsAPISummary(event); got != tt.want {
+				t.Errorf("got %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
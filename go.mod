@@ -9,6 +9,9 @@ require github.com/gorilla/websocket v1.4.2 // indirect
 require (
 	github.com/inconshreveable/mousetrap v1.1.0 // indirect
 	github.com/joho/godotenv v1.5.1
+	github.com/lib/pq v1.10.9
+	github.com/mattn/go-sqlite3 v1.14.22
+	github.com/skip2/go-qrcode v0.0.0-20200617195104-da1b6568686e
 	github.com/slack-go/slack v0.12.3
 	github.com/spf13/pflag v1.0.5 // indirect
 )
@@ -0,0 +1,102 @@
+/*
+Copyright © 2024 Pavlo Tarasiuk <pasha.tarasyuk@gmail.com>
+*/
+
+// Package blocks is MAVBot's library of Block Kit components, so every handler builds
+// headers, buttons, dialogs and surveys the same way
+package blocks
+
+import (
+	"fmt"
+
+	"github.com/slack-go/slack"
+)
+
+// Markdown returns a mrkdwn text object
+func Markdown(text string) *slack.TextBlockObject {
+	return slack.NewTextBlockObject(slack.MarkdownType, text, false, false)
+}
+
+// PlainText returns a plain_text object
+func PlainText(text string) *slack.TextBlockObject {
+	return slack.NewTextBlockObject(slack.PlainTextType, text, false, false)
+}
+
+// Header returns a header block showing text in large bold letters
+func Header(text string) *slack.HeaderBlock {
+	return slack.NewHeaderBlock(PlainText(text))
+}
+
+// Section returns a section block with markdown text
+func Section(markdown string) *slack.SectionBlock {
+	return slack.NewSectionBlock(Markdown(markdown), nil, nil)
+}
+
+// Context returns a context block with markdown text, shown small and grey
+func Context(markdown string) *slack.ContextBlock {
+	return slack.NewContextBlock("", Markdown(markdown))
+}
+
+// Field is a titled value shown by Fields
+type Field struct {
+	Title string
+	Value string
+}
+
+// Fields returns a section block showing the fields in two columns, the title in bold above the value
+func Fields(fields ...Field) *slack.SectionBlock {
+	objects := make([]*slack.TextBlockObject, 0, len(fields))
+	for _, field := range fields {
+		objects = append(objects, Markdown(fmt.Sprintf("*%s*\n%s", field.Title, field.Value)))
+	}
+	return slack.NewSectionBlock(nil, objects, nil)
+}
+
+// Button returns a button sending actionID with value to the bot when clicked
+func Button(actionID, value, label string) *slack.ButtonBlockElement {
+	return slack.NewButtonBlockElement(actionID, value, PlainText(label))
+}
+
+// LinkButton returns a button opening url in the browser
+func LinkButton(label, url string) *slack.ButtonBlockElement {
+	button := slack.NewButtonBlockElement("", "", PlainText(label))
+	button.URL = url
+	return button
+}
+
+// ActionsRow returns an actions block laying out the elements in a row
+func ActionsRow(elements ...slack.BlockElement) *slack.ActionBlock {
+	return slack.NewActionBlock("", elements...)
+}
+
+// Confirm returns a dialog asking the user to confirm before an element's action is sent,
+// e.g. for a button with destructive consequences
+func Confirm(title, text, confirm, deny string) *slack.ConfirmationBlockObject {
+	return slack.NewConfirmationBlockObject(PlainText(title), Markdown(text), PlainText(confirm), PlainText(deny))
+}
+
+// SurveyOption is an answer to a Survey
+type SurveyOption struct {
+	// Value is what the bot receives when the option is checked
+	Value string
+	Label string
+	// Description is shown under the label, empty for none
+	Description string
+}
+
+// Survey returns a section asking question with the options as checkboxes, which send
+// actionID to the bot when checked. blockID identifies the survey, e.g. a signed context.
+func Survey(actionID, blockID, question string, options ...SurveyOption) *slack.SectionBlock {
+	objects := make([]*slack.OptionBlockObject, 0, len(options))
+	for _, option := range options {
+		var description *slack.TextBlockObject
+		if option.Description != "" {
+			description = Markdown(option.Description)
+		}
+		objects = append(objects, slack.NewOptionBlockObject(option.Value, Markdown(option.Label), description))
+	}
+	checkbox := slack.NewCheckboxGroupsBlockElement(actionID, objects...)
+	section := slack.NewSectionBlock(Markdown(question), nil, slack.NewAccessory(checkbox))
+	section.BlockID = blockID
+	return section
+}
@@ -0,0 +1,116 @@
+/*
+Copyright © 2024 Pavlo Tarasiuk <pasha.tarasyuk@gmail.com>
+*/
+package blocks
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/slack-go/slack"
+)
+
+func TestComponents(t *testing.T) {
+	tests := []struct {
+		name      string
+		component interface{}
+		want      string
+	}{
+		{
+			name:      "markdown",
+			component: Markdown("*Deploy* done"),
+			want:      `{"type":"mrkdwn","text":"*Deploy* done"}`,
+		},
+		{
+			name:      "plain text",
+			component: PlainText("Deploy done"),
+			want:      `{"type":"plain_text","text":"Deploy done"}`,
+		},
+		{
+			name:      "header",
+			component: Header("Weekly report"),
+			want:      `{"type":"header","text":{"type":"plain_text","text":"Weekly report"}}`,
+		},
+		{
+			name:      "section",
+			component: Section("Hello *pasha*"),
+			want:      `{"type":"section","text":{"type":"mrkdwn","text":"Hello *pasha*"}}`,
+		},
+		{
+			name:      "context",
+			component: Context("Posted by MAVBot"),
+			want:      `{"type":"context","elements":[{"type":"mrkdwn","text":"Posted by MAVBot"}]}`,
+		},
+		{
+			name:      "fields",
+			component: Fields(Field{Title: "Channel", Value: "#help"}, Field{Title: "Votes", Value: "3"}),
+			want:      `{"type":"section","fields":[{"type":"mrkdwn","text":"*Channel*\n#help"},{"type":"mrkdwn","text":"*Votes*\n3"}]}`,
+		},
+		{
+			name:      "button",
+			component: Button("resolve", "C1/1712345678.000100", "Resolve"),
+			want:      `{"type":"button","text":{"type":"plain_text","text":"Resolve"},"action_id":"resolve","value":"C1/1712345678.000100"}`,
+		},
+		{
+			name:      "link button",
+			component: LinkButton("Open app settings", "https://api.slack.com/apps/A0APP/oauth"),
+			want:      `{"type":"button","text":{"type":"plain_text","text":"Open app settings"},"url":"https://api.slack.com/apps/A0APP/oauth"}`,
+		},
+		{
+			name:      "actions row",
+			component: ActionsRow(Button("yes", "1", "Yes"), Button("no", "0", "No")),
+			want: `{"type":"actions","elements":[` +
+				`{"type":"button","text":{"type":"plain_text","text":"Yes"},"action_id":"yes","value":"1"},` +
+				`{"type":"button","text":{"type":"plain_text","text":"No"},"action_id":"no","value":"0"}]}`,
+		},
+		{
+			name:      "confirm",
+			component: Confirm("Delete?", "The survey results are *gone* for good", "Delete", "Keep"),
+			want: `{"title":{"type":"plain_text","text":"Delete?"},"text":{"type":"mrkdwn","text":"The survey results are *gone* for good"},` +
+				`"confirm":{"type":"plain_text","text":"Delete"},"deny":{"type":"plain_text","text":"Keep"}}`,
+		},
+		{
+			name: "survey",
+			component: Survey("survey", "ctx-token", "Did you think this article was helpful?",
+				SurveyOption{Value: "yes", Label: "Yes", Description: "Did you Enjoy it?"},
+				SurveyOption{Value: "no", Label: "No"},
+			),
+			want: `{"type":"section","text":{"type":"mrkdwn","text":"Did you think this article was helpful?"},"block_id":"ctx-token",` +
+				`"accessory":{"type":"checkboxes","action_id":"survey","options":[` +
+				`{"text":{"type":"mrkdwn","text":"Yes"},"value":"yes","description":{"type":"mrkdwn","text":"Did you Enjoy it?"}},` +
+				`{"text":{"type":"mrkdwn","text":"No"},"value":"no"}]}}`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := json.Marshal(tt.component)
+			if err != nil {
+				t.Fatalf("failed to marshal: %v", err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("got  %s\nwant %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestConfirmOnButton(t *testing.T) {
+	button := Button("delete", "survey-1", "Delete")
+	button.Confirm = Confirm("Delete?", "Sure?", "Delete", "Keep")
+	button.Style = slack.StyleDanger
+	raw, _ := json.Marshal(ActionsRow(button))
+	var row struct {
+		Elements []struct {
+			Style   string `json:"style"`
+			Confirm struct {
+				Deny struct{ Text string } `json:"deny"`
+			} `json:"confirm"`
+		} `json:"elements"`
+	}
+	if err := json.Unmarshal(raw, &row); err != nil {
+		t.Fatalf("invalid row %s", raw)
+	}
+	if len(row.Elements) != 1 || row.Elements[0].Style != "danger" || row.Elements[0].Confirm.Deny.Text != "Keep" {
+		t.Errorf("row = %s, want the dangerous button asking to confirm", raw)
+	}
+}